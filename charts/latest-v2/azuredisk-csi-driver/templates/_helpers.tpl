@@ -23,4 +23,23 @@ imagePullSecrets:
   - name: {{ . }}
 {{- end }}
 {{- end }}
-{{- end -}}
\ No newline at end of file
+{{- end -}}
+
+{{/* whether the controller serves any webhook */}}
+{{- define "azuredisk.webhookEnabled" -}}
+{{- if or (and .Values.api.version.v1beta1.enabled .Values.controller.webhook.conversion.enabled) .Values.controller.webhook.admission.enabled }}true{{- end }}
+{{- end -}}
+
+{{/* annotations injecting the CA of the cert-manager issued webhook certificate */}}
+{{- define "azuredisk.webhookCAInjection" -}}
+{{- if .Values.controller.webhook.certManager.enabled -}}
+cert-manager.io/inject-ca-from: {{ .Release.Namespace }}/{{ .Values.controller.name }}-webhook-cert
+{{- end -}}
+{{- end -}}
+
+{{/* fail if a webhook is enabled without a CA the API server can use to verify it */}}
+{{- define "azuredisk.validateWebhookCA" -}}
+{{- if and (include "azuredisk.webhookEnabled" .) (not .Values.controller.webhook.caBundle) (not .Values.controller.webhook.certManager.enabled) }}
+{{- fail "controller.webhook.caBundle must be set or controller.webhook.certManager.enabled must be true to enable the conversion or admission webhooks" }}
+{{- end }}
+{{- end -}}
@@ -0,0 +1,172 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	azdiskv1beta2 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
+	azdisk "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/client/clientset/versioned"
+)
+
+// azdnCmd represents the azdn command
+var azdnCmd = &cobra.Command{
+	Use:   "azdn",
+	Short: "Azure Driver Node",
+	Long:  `Azure Driver Node is a Kubernetes Custom Resource.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		node, _ := cmd.Flags().GetString("node")
+		zone, _ := cmd.Flags().GetString("zone")
+
+		numFlag := cmd.Flags().NFlag()
+
+		// access to config and Clientsets
+		config := getConfig()
+		clientsetAzDisk := getAzDiskClientset(config)
+
+		var result []AzdnResource
+
+		if numFlag > 1 {
+			fmt.Printf("only one of the flags is allowed.\n" + "Run 'az-analyze --help' for usage.\n")
+		} else {
+			if numFlag == 0 {
+				// if no flag value is provided , list all of the nodes
+				result = GetAllAzDriverNodes(clientsetAzDisk)
+			} else if node != "" {
+				result = GetAzDriverNodesByNode(clientsetAzDisk, node)
+			} else if zone != "" {
+				result = GetAzDriverNodesByZone(clientsetAzDisk, zone)
+			}
+
+			if len(result) != 0 {
+				displayAzdn(result)
+			} else {
+				fmt.Println("No azDriverNode was found")
+			}
+		}
+	},
+}
+
+func init() {
+	getCmd.AddCommand(azdnCmd)
+	azdnCmd.PersistentFlags().StringP("node", "d", "", "insert-node-name (only one of the flags is allowed).")
+	azdnCmd.PersistentFlags().StringP("zone", "z", "", "insert-zone-name (only one of the flags is allowed).")
+}
+
+// AzdnResource is the attach capacity and VM details published by the node plugin of a node.
+// The capacity fields are empty if the node plugin has not published them.
+type AzdnResource struct {
+	NodeName              string
+	ZoneName              string
+	FaultDomain           string
+	InstanceType          string
+	MaxDataDiskCount      string
+	AttachedDataDiskCount string
+	Ready                 bool
+}
+
+// return all azDriverNodes when no flag is provided
+func GetAllAzDriverNodes(clientsetAzDisk azdisk.Interface) []AzdnResource {
+	return getAzDriverNodes(clientsetAzDisk, func(azDriverNode *azdiskv1beta2.AzDriverNode) bool {
+		return true
+	})
+}
+
+// return the azDriverNode of a node when node name is provided
+func GetAzDriverNodesByNode(clientsetAzDisk azdisk.Interface, nodeName string) []AzdnResource {
+	return getAzDriverNodes(clientsetAzDisk, func(azDriverNode *azdiskv1beta2.AzDriverNode) bool {
+		return azDriverNode.Spec.NodeName == nodeName
+	})
+}
+
+// return azDriverNodes by zone when zone name is provided
+func GetAzDriverNodesByZone(clientsetAzDisk azdisk.Interface, zoneName string) []AzdnResource {
+	return getAzDriverNodes(clientsetAzDisk, func(azDriverNode *azdiskv1beta2.AzDriverNode) bool {
+		return azDriverNode.Status != nil && azDriverNode.Status.Zone != nil && *azDriverNode.Status.Zone == zoneName
+	})
+}
+
+func getAzDriverNodes(clientsetAzDisk azdisk.Interface, filter func(*azdiskv1beta2.AzDriverNode) bool) []AzdnResource {
+	result := make([]AzdnResource, 0)
+
+	azDriverNodes, err := clientsetAzDisk.DiskV1beta2().AzDriverNodes(getDriverNamesapce()).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		panic(err.Error())
+	}
+
+	for i := range azDriverNodes.Items {
+		azDriverNode := &azDriverNodes.Items[i]
+		if filter(azDriverNode) {
+			result = append(result, newAzdnResource(azDriverNode))
+		}
+	}
+	return result
+}
+
+func newAzdnResource(azDriverNode *azdiskv1beta2.AzDriverNode) AzdnResource {
+	resource := AzdnResource{NodeName: azDriverNode.Spec.NodeName}
+
+	status := azDriverNode.Status
+	if status == nil {
+		return resource
+	}
+
+	stringValue := func(value *string) string {
+		if value == nil {
+			return ""
+		}
+		return *value
+	}
+	countValue := func(value *int32) string {
+		if value == nil {
+			return ""
+		}
+		return strconv.Itoa(int(*value))
+	}
+
+	resource.ZoneName = stringValue(status.Zone)
+	resource.FaultDomain = stringValue(status.FaultDomain)
+	resource.InstanceType = stringValue(status.InstanceType)
+	resource.MaxDataDiskCount = countValue(status.MaxDataDiskCount)
+	resource.AttachedDataDiskCount = countValue(status.AttachedDataDiskCount)
+	resource.Ready = status.ReadyForVolumeAllocation != nil && *status.ReadyForVolumeAllocation
+	return resource
+}
+
+func displayAzdn(result []AzdnResource) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"NodeName", "ZoneName", "FaultDomain", "InstanceType", "MaxDataDiskCount", "AttachedDataDiskCount", "Ready"})
+
+	for _, azdn := range result {
+		table.Append([]string{
+			azdn.NodeName,
+			azdn.ZoneName,
+			azdn.FaultDomain,
+			azdn.InstanceType,
+			azdn.MaxDataDiskCount,
+			azdn.AttachedDataDiskCount,
+			strconv.FormatBool(azdn.Ready),
+		})
+	}
+	table.Render()
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var azdnResourceNode0 = AzdnResource{
+	NodeName:              TestNode0,
+	ZoneName:              TestZone0,
+	InstanceType:          TestInstanceType,
+	MaxDataDiskCount:      "4",
+	AttachedDataDiskCount: "1",
+	Ready:                 true,
+}
+
+var azdnResourceNode1 = AzdnResource{
+	NodeName: TestNode1,
+}
+
+func TestGetAllAzDriverNodes(t *testing.T) {
+	fakeClientsetAzDisk := NewTestAzDiskClientset()
+
+	result := GetAllAzDriverNodes(fakeClientsetAzDisk)
+	require.Equal(t, []AzdnResource{azdnResourceNode0, azdnResourceNode1}, result)
+}
+
+func TestGetAzDriverNodesByNode(t *testing.T) {
+	fakeClientsetAzDisk := NewTestAzDiskClientset()
+
+	result := GetAzDriverNodesByNode(fakeClientsetAzDisk, TestNode1)
+	require.Equal(t, []AzdnResource{azdnResourceNode1}, result)
+}
+
+func TestGetAzDriverNodesByZone(t *testing.T) {
+	fakeClientsetAzDisk := NewTestAzDiskClientset()
+
+	result := GetAzDriverNodesByZone(fakeClientsetAzDisk, TestZone0)
+	require.Equal(t, []AzdnResource{azdnResourceNode0}, result)
+
+	result = GetAzDriverNodesByZone(fakeClientsetAzDisk, TestZone1)
+	require.Empty(t, result)
+}
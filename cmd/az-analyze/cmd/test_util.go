@@ -23,6 +23,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/pointer"
 	azdiskv1beta2 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
 	azdiskfakes "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/client/clientset/versioned/fake"
 	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
@@ -45,6 +46,7 @@ const (
 	TestPvcClaimName0       = "test-pvcClaimName-0"
 	TestPvcClaimName1       = "test-pvcClaimName-1"
 	TestPvcClaimName2       = "test-pvcClaimName-2"
+	TestInstanceType        = "Standard_D2s_v3"
 )
 
 func NewTestK8sClientset() *fake.Clientset {
@@ -212,6 +214,36 @@ func NewTestAzDiskClientset() *azdiskfakes.Clientset {
 		},
 	}
 
+	fakeAzdns := []azdiskv1beta2.AzDriverNode{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      TestNode0,
+				Namespace: consts.DefaultAzureDiskCrdNamespace,
+			},
+			Spec: azdiskv1beta2.AzDriverNodeSpec{
+				NodeName: TestNode0,
+			},
+			Status: &azdiskv1beta2.AzDriverNodeStatus{
+				ReadyForVolumeAllocation: pointer.Bool(true),
+				MaxDataDiskCount:         pointer.Int32(4),
+				AttachedDataDiskCount:    pointer.Int32(1),
+				InstanceType:             pointer.String(TestInstanceType),
+				Zone:                     pointer.String(TestZone0),
+			},
+		}, {
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      TestNode1,
+				Namespace: consts.DefaultAzureDiskCrdNamespace,
+			},
+			Spec: azdiskv1beta2.AzDriverNodeSpec{
+				NodeName: TestNode1,
+			},
+			Status: &azdiskv1beta2.AzDriverNodeStatus{
+				ReadyForVolumeAllocation: pointer.Bool(false),
+			},
+		},
+	}
+
 	objs := make([]runtime.Object, 0)
 	for _, azv := range fakeAzvs {
 		azv := azv
@@ -223,5 +255,10 @@ func NewTestAzDiskClientset() *azdiskfakes.Clientset {
 		objs = append(objs, &azva)
 	}
 
+	for _, azdn := range fakeAzdns {
+		azdn := azdn
+		objs = append(objs, &azdn)
+	}
+
 	return azdiskfakes.NewSimpleClientset(objs...)
 }
@@ -41,7 +41,7 @@ const (
 	KlogTimeFormat     = `(\d{4}) (\d{2}:\d{2}:\d{2}(.\d+)?)`
 )
 
-func GetFlags(cmd *cobra.Command) ([]string, []string, []string, string, string, bool, bool) {
+func GetFlags(cmd *cobra.Command) ([]string, []string, []string, string, string, bool, bool, bool) {
 	volumes, _ := cmd.Flags().GetStringSlice("volume")
 	nodes, _ := cmd.Flags().GetStringSlice("node")
 	requestIds, _ := cmd.Flags().GetStringSlice("request-id")
@@ -49,13 +49,14 @@ func GetFlags(cmd *cobra.Command) ([]string, []string, []string, string, string,
 	sinceTime, _ := cmd.Flags().GetString("since-time")
 	isFollow, _ := cmd.Flags().GetBool("follow")
 	isPrevious, _ := cmd.Flags().GetBool("previous")
+	isDryRun, _ := cmd.Flags().GetBool("dry-run")
 
 	if since != "" && sinceTime != "" {
 		fmt.Println("error: only one of --since/--since-time may be specified")
 		os.Exit(0)
 	}
 
-	return volumes, nodes, requestIds, since, sinceTime, isFollow, isPrevious
+	return volumes, nodes, requestIds, since, sinceTime, isFollow, isPrevious, isDryRun
 }
 
 func getConfig() *rest.Config {
@@ -115,7 +116,7 @@ func LogTimeFilter(log *string, sinceTime string) bool {
 	return isMatch && len(*log) > 21 && (*log)[1:21] >= sinceTime
 }
 
-func LogFilter(buf *bufio.Scanner, volumes []string, nodes []string, requestIds []string, sinceTime string) {
+func LogFilter(buf *bufio.Scanner, volumes []string, nodes []string, requestIds []string, sinceTime string, isDryRun bool) {
 	var isAfterTime bool
 	if sinceTime == "" {
 		isAfterTime = true
@@ -131,6 +132,11 @@ func LogFilter(buf *bufio.Scanner, volumes []string, nodes []string, requestIds
 		}
 
 		if isAfterTime {
+			// operations recorded by the controllers in dry-run mode are logged with the dry-run operation key
+			if isDryRun && !strings.Contains(log, consts.DryRunOperationKey) {
+				continue
+			}
+
 			if ContainsAny(&log, volumes) && ContainsAny(&log, nodes) && ContainsAny(&log, requestIds) {
 				fmt.Println(log)
 			}
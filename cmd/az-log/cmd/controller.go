@@ -37,14 +37,14 @@ var controllerCmd = &cobra.Command{
 	Short: "csi-azuredisk2-controller",
 	Long:  `csi-azuredisk2-controller`,
 	Run: func(cmd *cobra.Command, args []string) {
-		volumes, nodes, requestIds, since, sinceTime, isFollow, isPrevious := GetFlags(cmd)
+		volumes, nodes, requestIds, since, sinceTime, isFollow, isPrevious, isDryRun := GetFlags(cmd)
 		config := getConfig()
 		clientsetK8s := getKubernetesClientset(config)
 
 		pod := GetLeaderControllerPod(clientsetK8s)
 		for {
 			currPodName := pod.Name
-			GetLogsByAzDriverPod(clientsetK8s, currPodName, AzureDiskContainer, volumes, nodes, requestIds, since, sinceTime, isFollow, isPrevious)
+			GetLogsByAzDriverPod(clientsetK8s, currPodName, AzureDiskContainer, volumes, nodes, requestIds, since, sinceTime, isFollow, isPrevious, isDryRun)
 			// If in watch mode (--follow) and the pod failover and restarts, keep watching logs from newly created pos in the same node
 			if !isFollow {
 				break
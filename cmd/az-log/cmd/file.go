@@ -37,7 +37,7 @@ var fileCmd = &cobra.Command{
 			os.Exit(0)
 		}
 		filePath := args[0]
-		volumes, nodes, requestIds, since, sinceTime, isFollow, isPrevious := GetFlags(cmd)
+		volumes, nodes, requestIds, since, sinceTime, isFollow, isPrevious, isDryRun := GetFlags(cmd)
 
 		if isFollow {
 			fmt.Println("file source can't work with --follow")
@@ -48,7 +48,7 @@ var fileCmd = &cobra.Command{
 			os.Exit(0)
 		}
 
-		GetLogsByFile(filePath, volumes, nodes, requestIds, since, sinceTime)
+		GetLogsByFile(filePath, volumes, nodes, requestIds, since, sinceTime, isDryRun)
 	},
 }
 
@@ -56,7 +56,7 @@ func init() {
 	getCmd.AddCommand(fileCmd)
 }
 
-func GetLogsByFile(path string, volumes []string, nodes []string, requestIds []string, since string, sinceTime string) {
+func GetLogsByFile(path string, volumes []string, nodes []string, requestIds []string, since string, sinceTime string, isDryRun bool) {
 	if sinceTime != "" {
 		t, err := TimestampFormatValidation(sinceTime)
 		if err != nil {
@@ -92,5 +92,5 @@ func GetLogsByFile(path string, volumes []string, nodes []string, requestIds []s
 	// Read file
 	buf := bufio.NewScanner(file)
 
-	LogFilter(buf, volumes, nodes, requestIds, sinceTime)
+	LogFilter(buf, volumes, nodes, requestIds, sinceTime, isDryRun)
 }
@@ -46,4 +46,5 @@ func init() {
 	getCmd.PersistentFlags().Bool("follow", false, "watch-logs")
 	getCmd.PersistentFlags().Bool("previous", false, "fetch-and-prepend-logs-from-the-previously-terminated-container")
 	getCmd.PersistentFlags().String("since", "", "insert-time-duration")
+	getCmd.PersistentFlags().Bool("dry-run", false, "only-show-operations-recorded-in-dry-run-mode")
 }
@@ -42,13 +42,13 @@ var nodeCmd = &cobra.Command{
 
 		config := getConfig()
 		clientsetK8s := getKubernetesClientset(config)
-		volumes, nodes, requestIds, since, sinceTime, isFollow, isPrevious := GetFlags(cmd)
+		volumes, nodes, requestIds, since, sinceTime, isFollow, isPrevious, isDryRun := GetFlags(cmd)
 		nodeName := args[0]
 
 		pod := GetAzurediskPodFromNode(clientsetK8s, nodeName)
 		for {
 			currPodName := pod.Name
-			GetLogsByAzDriverPod(clientsetK8s, currPodName, AzureDiskContainer, volumes, nodes, requestIds, since, sinceTime, isFollow, isPrevious)
+			GetLogsByAzDriverPod(clientsetK8s, currPodName, AzureDiskContainer, volumes, nodes, requestIds, since, sinceTime, isFollow, isPrevious, isDryRun)
 			// If in watch mode (--follow) and the pod failover and restarts, keep watching logs from newly created pos in the same node
 			if !isFollow {
 				break
@@ -43,12 +43,12 @@ var podCmd = &cobra.Command{
 		}
 
 		podContainerNames := strings.Split(args[0], "/")
-		volumes, nodes, requestIds, since, sinceTime, isFollow, isPrevious := GetFlags(cmd)
+		volumes, nodes, requestIds, since, sinceTime, isFollow, isPrevious, isDryRun := GetFlags(cmd)
 
 		config := getConfig()
 		clientsetK8s := getKubernetesClientset(config)
 
-		GetLogsByAzDriverPod(clientsetK8s, podContainerNames[0], podContainerNames[1], volumes, nodes, requestIds, since, sinceTime, isFollow, isPrevious)
+		GetLogsByAzDriverPod(clientsetK8s, podContainerNames[0], podContainerNames[1], volumes, nodes, requestIds, since, sinceTime, isFollow, isPrevious, isDryRun)
 	},
 }
 
@@ -57,7 +57,7 @@ func init() {
 }
 
 func GetLogsByAzDriverPod(clientsetK8s kubernetes.Interface, podName string, container string, volumes []string,
-	nodes []string, requestIds []string, since string, sinceTime string, isFollow bool, isPrevious bool) {
+	nodes []string, requestIds []string, since string, sinceTime string, isFollow bool, isPrevious bool, isDryRun bool) {
 
 	v1PodLogOptions := v1.PodLogOptions{
 		Container: container,
@@ -114,6 +114,6 @@ func GetLogsByAzDriverPod(clientsetK8s kubernetes.Interface, podName string, con
 		defer podLogs.Close()
 
 		buf := bufio.NewScanner(podLogs)
-		LogFilter(buf, volumes, nodes, requestIds, "")
+		LogFilter(buf, volumes, nodes, requestIds, "", isDryRun)
 	}
 }
@@ -36,6 +36,10 @@ fi
 echo "Installing Azure Disk CSI driver, version: $ver ..."
 
 kubectl apply -f $repo/csi-azuredisk-driver.yaml
+if [[ $ver == "v2"* ]]; then
+  # the controller RBAC includes a Role in the namespace of the driver objects
+  kubectl apply -f $repo/namespace-azure-disk-csi.yaml
+fi
 kubectl apply -f $repo/rbac-csi-azuredisk-controller.yaml
 kubectl apply -f $repo/rbac-csi-azuredisk-node.yaml
 kubectl apply -f $repo/csi-azuredisk-node.yaml
@@ -49,10 +53,11 @@ kubectl apply -f $repo/csi-azuredisk-controller.yaml
 if [[ $ver == "v2"* ]]; then
   kubectl apply -f $repo/csi-azuredisk-scheduler-extender.yaml
   kubectl apply -f $repo/rbac-csi-azuredisk-scheduler-extender.yaml
-  kubectl apply -f $repo/namespace-azure-disk-csi.yaml
   kubectl apply -f $repo/disk.csi.azure.com_azdrivernodes.yaml
   kubectl apply -f $repo/disk.csi.azure.com_azvolumeattachments.yaml
   kubectl apply -f $repo/disk.csi.azure.com_azvolumes.yaml
+  kubectl apply -f $repo/disk.csi.azure.com_azsnapshotpolicies.yaml
+  kubectl apply -f $repo/disk.csi.azure.com_azvolumegroupsnapshots.yaml
 fi
 
 if [[ "$#" -gt 1 ]]; then
@@ -41,6 +41,8 @@ if [[ $ver == *"v2"* ]]; then
   kubectl delete -f $repo/disk.csi.azure.com_azdrivernodes.yaml --ignore-not-found
   kubectl delete -f $repo/disk.csi.azure.com_azvolumes.yaml --ignore-not-found
   kubectl delete -f $repo/disk.csi.azure.com_azvolumeattachments.yaml --ignore-not-found
+  kubectl delete -f $repo/disk.csi.azure.com_azsnapshotpolicies.yaml --ignore-not-found
+  kubectl delete -f $repo/disk.csi.azure.com_azvolumegroupsnapshots.yaml --ignore-not-found
   kubectl delete -f $repo/namespace-azure-disk-csi.yaml --ignore-not-found
 fi
 
@@ -0,0 +1,267 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
+)
+
+// ConversionDataAnnotation is the annotation in which the fields of a v1beta2 object without a v1beta1 equivalent
+// are preserved, so that the object survives a round trip through v1beta1 without losing data.
+const ConversionDataAnnotation = "disk.csi.azure.com/conversion-data"
+
+// conversionData holds the fields of a v1beta2 object which cannot be represented in v1beta1.
+type conversionData struct {
+	// Annotations are the metadata annotations of the v1beta2 object.
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// StatusAnnotations are the status annotations of the v1beta2 object.
+	StatusAnnotations map[string]string `json:"statusAnnotations,omitempty"`
+	// Conditions are the status conditions of the v1beta2 object.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// The following fields are only set for AzVolume objects.
+	SecretRef        *corev1.SecretReference `json:"secretRef,omitempty"`
+	UpdateGeneration int64                   `json:"updateGeneration,omitempty"`
+
+	// The following fields are only set for AzDriverNode objects.
+	MaxDataDiskCount      *int32  `json:"maxDataDiskCount,omitempty"`
+	AttachedDataDiskCount *int32  `json:"attachedDataDiskCount,omitempty"`
+	InstanceType          *string `json:"instanceType,omitempty"`
+	Zone                  *string `json:"zone,omitempty"`
+	FaultDomain           *string `json:"faultDomain,omitempty"`
+}
+
+// ConvertTo converts this AzVolume to the v1beta2 version.
+func (src *AzVolume) ConvertTo(dst *v1beta2.AzVolume) error {
+	if err := convertFields(src, dst); err != nil {
+		return err
+	}
+	dst.APIVersion = v1beta2.SchemeGroupVersion.String()
+	dst.Spec.PersistentVolume = src.Status.PersistentVolume
+
+	data, err := popConversionData(&dst.ObjectMeta)
+	if err != nil {
+		return err
+	}
+	dst.Annotations, dst.Status.Annotations = splitAnnotations(dst.Annotations, data)
+	if data != nil {
+		dst.Spec.SecretRef = data.SecretRef
+		dst.Status.UpdateGeneration = data.UpdateGeneration
+		dst.Status.Conditions = data.Conditions
+	}
+	return nil
+}
+
+// ConvertFrom converts the v1beta2 AzVolume to this version.
+func (dst *AzVolume) ConvertFrom(src *v1beta2.AzVolume) error {
+	if err := convertFields(src, dst); err != nil {
+		return err
+	}
+	dst.APIVersion = SchemeGroupVersion.String()
+	dst.Status.PersistentVolume = src.Spec.PersistentVolume
+	dst.Annotations = mergeAnnotations(src.Annotations, src.Status.Annotations)
+
+	return pushConversionData(&dst.ObjectMeta, &conversionData{
+		Annotations:       src.Annotations,
+		StatusAnnotations: src.Status.Annotations,
+		Conditions:        src.Status.Conditions,
+		SecretRef:         src.Spec.SecretRef,
+		UpdateGeneration:  src.Status.UpdateGeneration,
+	})
+}
+
+// ConvertTo converts this AzVolumeAttachment to the v1beta2 version.
+func (src *AzVolumeAttachment) ConvertTo(dst *v1beta2.AzVolumeAttachment) error {
+	if err := convertFields(src, dst); err != nil {
+		return err
+	}
+	dst.APIVersion = v1beta2.SchemeGroupVersion.String()
+
+	data, err := popConversionData(&dst.ObjectMeta)
+	if err != nil {
+		return err
+	}
+	dst.Annotations, dst.Status.Annotations = splitAnnotations(dst.Annotations, data)
+	if data != nil {
+		dst.Status.Conditions = data.Conditions
+	}
+	return nil
+}
+
+// ConvertFrom converts the v1beta2 AzVolumeAttachment to this version.
+func (dst *AzVolumeAttachment) ConvertFrom(src *v1beta2.AzVolumeAttachment) error {
+	if err := convertFields(src, dst); err != nil {
+		return err
+	}
+	dst.APIVersion = SchemeGroupVersion.String()
+	dst.Annotations = mergeAnnotations(src.Annotations, src.Status.Annotations)
+
+	return pushConversionData(&dst.ObjectMeta, &conversionData{
+		Annotations:       src.Annotations,
+		StatusAnnotations: src.Status.Annotations,
+		Conditions:        src.Status.Conditions,
+	})
+}
+
+// ConvertTo converts this AzDriverNode to the v1beta2 version.
+func (src *AzDriverNode) ConvertTo(dst *v1beta2.AzDriverNode) error {
+	if err := convertFields(src, dst); err != nil {
+		return err
+	}
+	dst.APIVersion = v1beta2.SchemeGroupVersion.String()
+
+	data, err := popConversionData(&dst.ObjectMeta)
+	if err != nil {
+		return err
+	}
+	if data != nil && dst.Status != nil {
+		dst.Status.MaxDataDiskCount = data.MaxDataDiskCount
+		dst.Status.AttachedDataDiskCount = data.AttachedDataDiskCount
+		dst.Status.InstanceType = data.InstanceType
+		dst.Status.Zone = data.Zone
+		dst.Status.FaultDomain = data.FaultDomain
+	}
+	return nil
+}
+
+// ConvertFrom converts the v1beta2 AzDriverNode to this version.
+func (dst *AzDriverNode) ConvertFrom(src *v1beta2.AzDriverNode) error {
+	if err := convertFields(src, dst); err != nil {
+		return err
+	}
+	dst.APIVersion = SchemeGroupVersion.String()
+
+	if src.Status == nil {
+		return nil
+	}
+	return pushConversionData(&dst.ObjectMeta, &conversionData{
+		MaxDataDiskCount:      src.Status.MaxDataDiskCount,
+		AttachedDataDiskCount: src.Status.AttachedDataDiskCount,
+		InstanceType:          src.Status.InstanceType,
+		Zone:                  src.Status.Zone,
+		FaultDomain:           src.Status.FaultDomain,
+	})
+}
+
+// convertFields copies the fields shared by both versions of an object, which have the same serialized form in both versions.
+func convertFields(src, dst interface{}) error {
+	raw, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, dst)
+}
+
+// mergeAnnotations returns the metadata annotations of a v1beta2 object merged with its status annotations,
+// as v1beta1 clients expect to find the status annotations among the metadata annotations.
+func mergeAnnotations(annotations, statusAnnotations map[string]string) map[string]string {
+	if len(annotations) == 0 && len(statusAnnotations) == 0 {
+		return nil
+	}
+	merged := make(map[string]string, len(annotations)+len(statusAnnotations))
+	for key, value := range annotations {
+		merged[key] = value
+	}
+	for key, value := range statusAnnotations {
+		merged[key] = value
+	}
+	return merged
+}
+
+// splitAnnotations splits the metadata annotations of a v1beta1 object into the metadata and status annotations of a v1beta2 object.
+// Annotations the object had in v1beta2 are restored to where they came from, while annotations added by a v1beta1 client are
+// kept in both, the same as when an object with an older api version is migrated during recovery.
+func splitAnnotations(annotations map[string]string, data *conversionData) (map[string]string, map[string]string) {
+	if len(annotations) == 0 {
+		return nil, nil
+	}
+	if data == nil {
+		data = &conversionData{}
+	}
+
+	metaAnnotations := map[string]string{}
+	statusAnnotations := map[string]string{}
+	for key, value := range annotations {
+		metaValue, inMeta := data.Annotations[key]
+		statusValue, inStatus := data.StatusAnnotations[key]
+		switch {
+		case inMeta && inStatus:
+			statusAnnotations[key] = value
+			if value == statusValue {
+				metaAnnotations[key] = metaValue
+			} else {
+				metaAnnotations[key] = value
+			}
+		case inMeta:
+			metaAnnotations[key] = value
+		case inStatus:
+			statusAnnotations[key] = value
+		default:
+			metaAnnotations[key] = value
+			statusAnnotations[key] = value
+		}
+	}
+
+	if len(metaAnnotations) == 0 {
+		metaAnnotations = nil
+	}
+	if len(statusAnnotations) == 0 {
+		statusAnnotations = nil
+	}
+	return metaAnnotations, statusAnnotations
+}
+
+// pushConversionData stores the conversion data in the annotations of the v1beta1 object.
+func pushConversionData(objectMeta *metav1.ObjectMeta, data *conversionData) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal conversion data of %s: %v", objectMeta.Name, err)
+	}
+	if string(raw) == "{}" {
+		return nil
+	}
+	if objectMeta.Annotations == nil {
+		objectMeta.Annotations = map[string]string{}
+	}
+	objectMeta.Annotations[ConversionDataAnnotation] = string(raw)
+	return nil
+}
+
+// popConversionData removes the conversion data from the annotations of the converted object and returns it.
+// It returns nil if the object was never converted from v1beta2.
+func popConversionData(objectMeta *metav1.ObjectMeta) (*conversionData, error) {
+	raw, ok := objectMeta.Annotations[ConversionDataAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	delete(objectMeta.Annotations, ConversionDataAnnotation)
+	if len(objectMeta.Annotations) == 0 {
+		objectMeta.Annotations = nil
+	}
+
+	var data conversionData
+	if err := json.Unmarshal([]byte(raw), &data); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal conversion data of %s: %v", objectMeta.Name, err)
+	}
+	return &data, nil
+}
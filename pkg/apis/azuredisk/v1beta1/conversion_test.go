@@ -0,0 +1,184 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
+)
+
+const (
+	testNamespace        = "azure-disk-csi"
+	testVolumeName       = "test-volume"
+	testNodeName         = "test-node"
+	testPersistentVolume = "test-pv"
+)
+
+func newTestV1Beta2AzVolume() *v1beta2.AzVolume {
+	return &v1beta2.AzVolume{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: v1beta2.SchemeGroupVersion.String(),
+			Kind:       "AzVolume",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        testVolumeName,
+			Namespace:   testNamespace,
+			Annotations: map[string]string{"disk.csi.azure.com/apiversion": v1beta2.APIVersion, "shared": "meta"},
+		},
+		Spec: v1beta2.AzVolumeSpec{
+			VolumeName:           testVolumeName,
+			MaxMountReplicaCount: 1,
+			PersistentVolume:     testPersistentVolume,
+			SecretRef:            &corev1.SecretReference{Name: testVolumeName, Namespace: testNamespace},
+		},
+		Status: v1beta2.AzVolumeStatus{
+			State:            v1beta2.VolumeCreated,
+			Detail:           &v1beta2.AzVolumeStatusDetail{VolumeID: "test-volume-id", CapacityBytes: 1024},
+			Annotations:      map[string]string{"disk.csi.azure.com/volume-delete-request": "test", "shared": "status"},
+			UpdateGeneration: 2,
+			Conditions: []metav1.Condition{
+				{Type: v1beta2.VolumeReadyCondition, Status: metav1.ConditionTrue, Reason: string(v1beta2.VolumeCreated)},
+			},
+		},
+	}
+}
+
+func TestAzVolumeConversion(t *testing.T) {
+	src := newTestV1Beta2AzVolume()
+
+	var v1beta1AzVolume AzVolume
+	require.NoError(t, v1beta1AzVolume.ConvertFrom(src))
+	require.Equal(t, SchemeGroupVersion.String(), v1beta1AzVolume.APIVersion)
+	require.Equal(t, testPersistentVolume, v1beta1AzVolume.Status.PersistentVolume)
+	require.Equal(t, src.Spec.MaxMountReplicaCount, v1beta1AzVolume.Spec.MaxMountReplicaCount)
+	require.Equal(t, src.Status.Detail.VolumeID, v1beta1AzVolume.Status.Detail.VolumeID)
+	require.Equal(t, "test", v1beta1AzVolume.Annotations["disk.csi.azure.com/volume-delete-request"])
+	require.Equal(t, "status", v1beta1AzVolume.Annotations["shared"])
+	require.Contains(t, v1beta1AzVolume.Annotations, ConversionDataAnnotation)
+
+	var dst v1beta2.AzVolume
+	require.NoError(t, v1beta1AzVolume.ConvertTo(&dst))
+	require.Equal(t, src, &dst)
+}
+
+func TestAzVolumeConversionFromV1Beta1(t *testing.T) {
+	src := &AzVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        testVolumeName,
+			Namespace:   testNamespace,
+			Annotations: map[string]string{"disk.csi.azure.com/volume-delete-request": "test"},
+		},
+		Spec: AzVolumeSpec{VolumeName: testVolumeName},
+		Status: AzVolumeStatus{
+			PersistentVolume: testPersistentVolume,
+			State:            VolumeCreated,
+		},
+	}
+
+	var dst v1beta2.AzVolume
+	require.NoError(t, src.ConvertTo(&dst))
+	require.Equal(t, v1beta2.SchemeGroupVersion.String(), dst.APIVersion)
+	require.Equal(t, testPersistentVolume, dst.Spec.PersistentVolume)
+	require.Equal(t, v1beta2.VolumeCreated, dst.Status.State)
+	require.Equal(t, src.Annotations, dst.Annotations)
+	require.Equal(t, src.Annotations, dst.Status.Annotations)
+}
+
+func TestAzVolumeAttachmentConversion(t *testing.T) {
+	src := &v1beta2.AzVolumeAttachment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testVolumeName + "-" + testNodeName + "-attachment",
+			Namespace: testNamespace,
+		},
+		Spec: v1beta2.AzVolumeAttachmentSpec{
+			VolumeName:    testVolumeName,
+			NodeName:      testNodeName,
+			RequestedRole: v1beta2.PrimaryRole,
+		},
+		Status: v1beta2.AzVolumeAttachmentStatus{
+			State:       v1beta2.Attached,
+			Detail:      &v1beta2.AzVolumeAttachmentStatusDetail{Role: v1beta2.PrimaryRole},
+			Annotations: map[string]string{"disk.csi.azure.com/volume-detach-request": "test"},
+			Conditions: []metav1.Condition{
+				{Type: v1beta2.AttachmentAttachedCondition, Status: metav1.ConditionTrue, Reason: string(v1beta2.Attached)},
+			},
+		},
+	}
+
+	var v1beta1AzVolumeAttachment AzVolumeAttachment
+	require.NoError(t, v1beta1AzVolumeAttachment.ConvertFrom(src))
+	require.Equal(t, "test", v1beta1AzVolumeAttachment.Annotations["disk.csi.azure.com/volume-detach-request"])
+
+	// annotations added by a v1beta1 client are visible to v1beta2 clients in both metadata and status annotations
+	v1beta1AzVolumeAttachment.Annotations["disk.csi.azure.com/volume-attach-request"] = "test"
+
+	var dst v1beta2.AzVolumeAttachment
+	require.NoError(t, v1beta1AzVolumeAttachment.ConvertTo(&dst))
+	require.Equal(t, map[string]string{"disk.csi.azure.com/volume-attach-request": "test"}, dst.Annotations)
+	require.Equal(t, map[string]string{"disk.csi.azure.com/volume-attach-request": "test", "disk.csi.azure.com/volume-detach-request": "test"}, dst.Status.Annotations)
+	require.Equal(t, src.Status.Conditions, dst.Status.Conditions)
+	require.Equal(t, src.Spec, dst.Spec)
+}
+
+func TestAzDriverNodeConversion(t *testing.T) {
+	src := &v1beta2.AzDriverNode{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: v1beta2.SchemeGroupVersion.String(),
+			Kind:       "AzDriverNode",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testNodeName,
+			Namespace: testNamespace,
+		},
+		Spec: v1beta2.AzDriverNodeSpec{NodeName: testNodeName},
+		Status: &v1beta2.AzDriverNodeStatus{
+			ReadyForVolumeAllocation: pointer.Bool(true),
+			MaxDataDiskCount:         pointer.Int32(8),
+			AttachedDataDiskCount:    pointer.Int32(2),
+			InstanceType:             pointer.String("Standard_D2s_v3"),
+			Zone:                     pointer.String("westus-1"),
+			FaultDomain:              pointer.String("1"),
+		},
+	}
+
+	var v1beta1AzDriverNode AzDriverNode
+	require.NoError(t, v1beta1AzDriverNode.ConvertFrom(src))
+	require.Equal(t, src.Spec.NodeName, v1beta1AzDriverNode.Spec.NodeName)
+	require.Equal(t, true, *v1beta1AzDriverNode.Status.ReadyForVolumeAllocation)
+
+	var dst v1beta2.AzDriverNode
+	require.NoError(t, v1beta1AzDriverNode.ConvertTo(&dst))
+	require.Equal(t, src, &dst)
+}
+
+func TestInvalidConversionData(t *testing.T) {
+	src := &AzVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        testVolumeName,
+			Annotations: map[string]string{ConversionDataAnnotation: "invalid"},
+		},
+	}
+
+	var dst v1beta2.AzVolume
+	require.Error(t, src.ConvertTo(&dst))
+}
@@ -53,6 +53,12 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&AzVolumeAttachmentList{},
 		&AzDriverNode{},
 		&AzDriverNodeList{},
+		&AzSnapshot{},
+		&AzSnapshotList{},
+		&AzSnapshotPolicy{},
+		&AzSnapshotPolicyList{},
+		&AzVolumeGroupSnapshot{},
+		&AzVolumeGroupSnapshotList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
@@ -17,6 +17,7 @@ limitations under the License.
 package v1beta2
 
 import (
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -73,8 +74,13 @@ type AzVolumeSpec struct {
 	//+optional
 	Parameters map[string]string `json:"parameters,omitempty"`
 	//Secrets for the volume.
+	//Deprecated: Secrets are stored in the Secret referenced by SecretRef. Existing values are migrated to a Secret
+	//when the controller recovers.
 	//+optional
 	Secrets map[string]string `json:"secrets,omitempty"`
+	//A reference to the Secret holding the secrets for the volume.
+	//+optional
+	SecretRef *v1.SecretReference `json:"secretRef,omitempty"`
 	//The source of initial content for the volume.
 	//+optional
 	ContentVolumeSource *ContentVolumeSource `json:"contentVolumeSource,omitempty"`
@@ -101,6 +107,18 @@ const (
 	VolumeDeleted          AzVolumeState = "Deleted"
 )
 
+// Condition types maintained in the status of an AzVolume
+const (
+	// VolumeReadyCondition indicates that the underlying volume has been created and can be attached
+	VolumeReadyCondition = "Ready"
+	// VolumeExpandingCondition indicates that the volume is being expanded or requires expansion on the node
+	VolumeExpandingCondition = "Expanding"
+	// VolumeModifyingCondition indicates that the sku, provisioned performance or bursting of the volume is being modified
+	VolumeModifyingCondition = "Modifying"
+	// VolumeDegradedCondition indicates that the last operation on the volume failed
+	VolumeDegradedCondition = "Degraded"
+)
+
 // AzVolumeStatus is the status for an AzVolume resource
 type AzVolumeStatus struct {
 	//Current status detail of the AzVolume
@@ -119,6 +137,16 @@ type AzVolumeStatus struct {
 	//Annotations contains additional resource information to guide driver actions
 	//+optional
 	Annotations map[string]string `json:"annotation,omitempty"`
+
+	//UpdateGeneration is the generation of the spec for which the volume was last expanded or modified
+	//+optional
+	UpdateGeneration int64 `json:"updateGeneration,omitempty"`
+
+	//Conditions are the latest available observations of the volume's state
+	//+optional
+	//+listType=map
+	//+listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
 }
 
 // AzVolumeStatusDetail is the status of the underlying Volume resource
@@ -219,6 +247,18 @@ const (
 	ForceDetachPending AzVolumeAttachmentAttachmentState = "ForceDetachPending"
 )
 
+// Condition types maintained in the status of an AzVolumeAttachment
+const (
+	// AttachmentReadyCondition indicates that the volume is attached to the node in the requested role
+	AttachmentReadyCondition = "Ready"
+	// AttachmentAttachedCondition indicates that the volume is attached to the node
+	AttachmentAttachedCondition = "Attached"
+	// AttachmentDegradedCondition indicates that the last attach or detach operation failed
+	AttachmentDegradedCondition = "Degraded"
+	// AttachmentForceDetachPendingCondition indicates that the controller will retry a failed replica detachment
+	AttachmentForceDetachPendingCondition = "ForceDetachPending"
+)
+
 // AzVolumeAttachmentStatus is the status for a AzVolumeAttachment resource
 type AzVolumeAttachmentStatus struct {
 	//Status summarizes the current attachment state of the volume attachment
@@ -234,6 +274,11 @@ type AzVolumeAttachmentStatus struct {
 	//Annotations contains additional resource information to guide driver actions
 	//+optional
 	Annotations map[string]string `json:"annotation,omitempty"`
+	//Conditions are the latest available observations of the attachment's state
+	//+optional
+	//+listType=map
+	//+listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
 }
 
 // AzVolumeAttachmentStatusDetail is the status of the attachment between specified node and volume.
@@ -298,6 +343,10 @@ type AzError struct {
 // +kubebuilder:printcolumn:name="ReadyForVolumeAllocation",type=boolean,JSONPath=`.status.readyForVolumeAllocation`,description="Indicates if the azure persistent volume driver is ready for new pods which use azure persistent volumes."
 // +kubebuilder:printcolumn:name="LastHeartbeatTime",type=date,JSONPath=`.status.lastHeartbeatTime`,description="Represents the time stamp at which azure persistent volume driver sent a heatbeat."
 // +kubebuilder:printcolumn:name="StatusMessage",type=string,JSONPath=`.status.statusMessage`,description="A brief node status message."
+// +kubebuilder:printcolumn:name="InstanceType",type=string,JSONPath=`.status.instanceType`,description="The VM size of the node.",priority=10
+// +kubebuilder:printcolumn:name="Zone",type=string,JSONPath=`.status.zone`,description="The availability zone of the node.",priority=10
+// +kubebuilder:printcolumn:name="MaxDataDiskCount",type=integer,JSONPath=`.status.maxDataDiskCount`,description="The maximum number of data disks that can be attached to the node.",priority=10
+// +kubebuilder:printcolumn:name="AttachedDataDiskCount",type=integer,JSONPath=`.status.attachedDataDiskCount`,description="The number of data disks attached to the node.",priority=10
 type AzDriverNode struct {
 	metav1.TypeMeta `json:",inline"`
 	// Standard object's metadata.
@@ -350,6 +399,28 @@ type AzDriverNodeStatus struct {
 	// +patchMergeKey=type
 	// +patchStrategy=merge
 	Conditions []AzDriverCondition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// MaxDataDiskCount is the maximum number of data disks that can be attached to the node.
+	// +optional
+	MaxDataDiskCount *int32 `json:"maxDataDiskCount,omitempty"`
+
+	// AttachedDataDiskCount is the number of data disks (LUNs) attached to the node,
+	// including disks not managed by the driver.
+	// The count is refreshed less often than the heartbeat.
+	// +optional
+	AttachedDataDiskCount *int32 `json:"attachedDataDiskCount,omitempty"`
+
+	// InstanceType is the VM size of the node.
+	// +optional
+	InstanceType *string `json:"instanceType,omitempty"`
+
+	// Zone is the availability zone of the node. It is not set if the node is not in an availability zone.
+	// +optional
+	Zone *string `json:"zone,omitempty"`
+
+	// FaultDomain is the platform fault domain of the node.
+	// +optional
+	FaultDomain *string `json:"faultDomain,omitempty"`
 }
 
 // AzDriverCondition defines condition for the AzDriver
@@ -402,6 +473,261 @@ type AzDriverNodeList struct {
 	Items []AzDriverNode `json:"items"`
 }
 
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AzSnapshot is a specification for an AzSnapshot resource
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Namespaced
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="The age of the snapshot"
+// +kubebuilder:printcolumn:name="SourceVolumeID",type=string,JSONPath=`.spec.sourceVolumeID`,description="ID of the disk from which the snapshot is taken",priority=10
+// +kubebuilder:printcolumn:name="ReadyToUse",type=boolean,JSONPath=`.status.detail.ready_to_use`,description="Indicates if the snapshot is ready to be used to restore a volume"
+// +kubebuilder:printcolumn:name="State",type=string,JSONPath=`.status.state`,description="Indicates the state of the snapshot"
+type AzSnapshot struct {
+	metav1.TypeMeta `json:",inline"`
+	// Standard object's metadata.
+	// More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// spec defines the desired state of an AzSnapshot.
+	// Required.
+	Spec AzSnapshotSpec `json:"spec"`
+
+	// status represents the current state of AzSnapshot.
+	// includes error, state, and snapshot status
+	// +optional
+	Status AzSnapshotStatus `json:"status,omitempty"`
+}
+
+// AzSnapshotSpec is the spec for an AzSnapshot resource
+type AzSnapshotSpec struct {
+	//The snapshot name.
+	SnapshotName string `json:"snapshotName"`
+	//The ID of the disk from which the snapshot is taken.
+	SourceVolumeID string `json:"sourceVolumeID"`
+	//Parameters for the snapshot.
+	//+optional
+	Parameters map[string]string `json:"parameters,omitempty"`
+	//A reference to the Secret holding the secrets for the snapshot.
+	//+optional
+	SecretRef *v1.SecretReference `json:"secretRef,omitempty"`
+}
+
+type AzSnapshotState string
+
+const (
+	SnapshotOperationPending AzSnapshotState = "Pending"
+	SnapshotCreating         AzSnapshotState = "Creating"
+	SnapshotCreationFailed   AzSnapshotState = "CreationFailed"
+	SnapshotCreated          AzSnapshotState = "Created"
+	SnapshotDeleting         AzSnapshotState = "Deleting"
+	SnapshotDeletionFailed   AzSnapshotState = "DeletionFailed"
+	SnapshotDeleted          AzSnapshotState = "Deleted"
+)
+
+// AzSnapshotStatus is the status for an AzSnapshot resource
+type AzSnapshotStatus struct {
+	//Current status detail of the AzSnapshot
+	//Nil detail indicates that the snapshot has not been created
+	//+optional
+	Detail *Snapshot `json:"detail,omitempty"`
+
+	//Current state of underlying snapshot
+	//+required
+	State AzSnapshotState `json:"state"`
+
+	//Error occurred during creation/deletion of snapshot
+	//+optional
+	Error *AzError `json:"error,omitempty"`
+
+	//Annotations contains additional resource information to guide driver actions
+	//+optional
+	Annotations map[string]string `json:"annotation,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AzSnapshotList is a list of AzSnapshot resources
+type AzSnapshotList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []AzSnapshot `json:"items"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AzSnapshotPolicy is a specification for an AzSnapshotPolicy resource
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Namespaced
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Schedule",type=string,JSONPath=`.spec.schedule`,description="Interval between the snapshots taken by the policy"
+// +kubebuilder:printcolumn:name="LastSchedule",type=date,JSONPath=`.status.lastScheduleTime`,description="Time at which the policy last took snapshots"
+// +kubebuilder:printcolumn:name="LastSuccess",type=date,JSONPath=`.status.lastSuccessTime`,description="Time at which the policy last succeeded"
+// +kubebuilder:printcolumn:name="LastFailure",type=date,JSONPath=`.status.lastFailureTime`,description="Time at which the policy last failed",priority=10
+type AzSnapshotPolicy struct {
+	metav1.TypeMeta `json:",inline"`
+	// Standard object's metadata.
+	// More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// spec defines the desired state of an AzSnapshotPolicy.
+	// Required.
+	Spec AzSnapshotPolicySpec `json:"spec"`
+
+	// status represents the current state of AzSnapshotPolicy.
+	// +optional
+	Status AzSnapshotPolicyStatus `json:"status,omitempty"`
+}
+
+// AzSnapshotPolicySpec is the spec for an AzSnapshotPolicy resource
+type AzSnapshotPolicySpec struct {
+	//The interval between the snapshots taken by the policy, e.g. 1h or 24h.
+	//Snapshots are taken at the multiples of the interval since the Unix epoch.
+	Schedule metav1.Duration `json:"schedule"`
+	//The selector of the PersistentVolumeClaims in the namespace of the policy to take snapshots of.
+	//An empty selector selects all the PersistentVolumeClaims in the namespace.
+	Selector metav1.LabelSelector `json:"selector"`
+	//The name of the VolumeSnapshotClass of the snapshots.
+	//If empty, the default VolumeSnapshotClass is used.
+	//+optional
+	VolumeSnapshotClassName string `json:"volumeSnapshotClassName,omitempty"`
+	//The retention of the snapshots taken by the policy.
+	//+optional
+	Retention AzSnapshotRetention `json:"retention,omitempty"`
+}
+
+// AzSnapshotRetention defines when the snapshots taken by an AzSnapshotPolicy are deleted
+type AzSnapshotRetention struct {
+	//The maximum number of snapshots kept for each PersistentVolumeClaim.
+	//Zero keeps any number of snapshots.
+	//+optional
+	MaxCount int `json:"maxCount,omitempty"`
+	//The maximum age of the snapshots kept.
+	//Zero keeps snapshots of any age.
+	//+optional
+	MaxAge metav1.Duration `json:"maxAge,omitempty"`
+}
+
+// AzSnapshotPolicyStatus is the status for an AzSnapshotPolicy resource
+type AzSnapshotPolicyStatus struct {
+	//The time at which the policy last took snapshots
+	//+optional
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+
+	//The time at which the policy last took and pruned snapshots successfully
+	//+optional
+	LastSuccessTime *metav1.Time `json:"lastSuccessTime,omitempty"`
+
+	//The time at which the policy last failed
+	//+optional
+	LastFailureTime *metav1.Time `json:"lastFailureTime,omitempty"`
+
+	//Error occurred during the last failure of the policy
+	//+optional
+	Error *AzError `json:"error,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AzSnapshotPolicyList is a list of AzSnapshotPolicy resources
+type AzSnapshotPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []AzSnapshotPolicy `json:"items"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AzVolumeGroupSnapshot is a specification for an AzVolumeGroupSnapshot resource
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Namespaced
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="The age of the group snapshot"
+// +kubebuilder:printcolumn:name="ReadyToUse",type=boolean,JSONPath=`.status.detail.ready_to_use`,description="Indicates if all the snapshots of the group are ready to be used to restore volumes"
+// +kubebuilder:printcolumn:name="State",type=string,JSONPath=`.status.state`,description="Indicates the state of the group snapshot"
+type AzVolumeGroupSnapshot struct {
+	metav1.TypeMeta `json:",inline"`
+	// Standard object's metadata.
+	// More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// spec defines the desired state of an AzVolumeGroupSnapshot.
+	// Required.
+	Spec AzVolumeGroupSnapshotSpec `json:"spec"`
+
+	// status represents the current state of AzVolumeGroupSnapshot.
+	// includes error, state, and group snapshot status
+	// +optional
+	Status AzVolumeGroupSnapshotStatus `json:"status,omitempty"`
+}
+
+// AzVolumeGroupSnapshotSpec is the spec for an AzVolumeGroupSnapshot resource
+type AzVolumeGroupSnapshotSpec struct {
+	//The group snapshot name.
+	GroupSnapshotName string `json:"groupSnapshotName"`
+	//The IDs of the disks from which the snapshots of the group are taken.
+	SourceVolumeIDs []string `json:"sourceVolumeIDs"`
+	//Parameters for the group snapshot.
+	//+optional
+	Parameters map[string]string `json:"parameters,omitempty"`
+	//A reference to the Secret holding the secrets for the group snapshot.
+	//+optional
+	SecretRef *v1.SecretReference `json:"secretRef,omitempty"`
+}
+
+type AzVolumeGroupSnapshotState string
+
+const (
+	GroupSnapshotOperationPending AzVolumeGroupSnapshotState = "Pending"
+	GroupSnapshotCreating         AzVolumeGroupSnapshotState = "Creating"
+	GroupSnapshotCreationFailed   AzVolumeGroupSnapshotState = "CreationFailed"
+	GroupSnapshotCreated          AzVolumeGroupSnapshotState = "Created"
+	GroupSnapshotDeleting         AzVolumeGroupSnapshotState = "Deleting"
+	GroupSnapshotDeletionFailed   AzVolumeGroupSnapshotState = "DeletionFailed"
+	GroupSnapshotDeleted          AzVolumeGroupSnapshotState = "Deleted"
+)
+
+// AzVolumeGroupSnapshotStatus is the status for an AzVolumeGroupSnapshot resource
+type AzVolumeGroupSnapshotStatus struct {
+	//Current status detail of the AzVolumeGroupSnapshot
+	//Nil detail indicates that the group snapshot has not been created
+	//+optional
+	Detail *GroupSnapshot `json:"detail,omitempty"`
+
+	//Current state of underlying group snapshot
+	//+required
+	State AzVolumeGroupSnapshotState `json:"state"`
+
+	//Error occurred during creation/deletion of group snapshot
+	//+optional
+	Error *AzError `json:"error,omitempty"`
+
+	//Annotations contains additional resource information to guide driver actions
+	//+optional
+	Annotations map[string]string `json:"annotation,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AzVolumeGroupSnapshotList is a list of AzVolumeGroupSnapshot resources
+type AzVolumeGroupSnapshotList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []AzVolumeGroupSnapshot `json:"items"`
+}
+
 type VolumeCapabilityAccessMode int
 
 const (
@@ -484,6 +810,14 @@ type Snapshot struct {
 	SizeBytes int64 `json:"size_bytes,omitempty"`
 }
 
+// GroupSnapshot is a set of snapshots of several volumes taken at the same point in time.
+type GroupSnapshot struct {
+	GroupSnapshotID string      `json:"group_snapshot_id"`
+	Snapshots       []Snapshot  `json:"snapshots"`
+	CreationTime    metav1.Time `json:"creation_time"`
+	ReadyToUse      bool        `json:"ready_to_use"`
+}
+
 type VolumeDetails struct {
 	VolumeID string `json:"volume_id"`
 	// +optional
@@ -542,6 +876,11 @@ type AzDiskDriverConfiguration struct {
 	DriverName string `json:"driverName,omitempty"`
 	// The address to expose profiling data from the pprof package. If empty, no profiler server is started.
 	ProfilerAddress string `json:"profilerAddress,omitempty"`
+	// The name of the ConfigMap holding the driver configuration. If set, changes to the fields of the configuration
+	// which are safe to change while the driver is running are applied without restarting it.
+	ConfigMapName string `json:"configMapName,omitempty"`
+	// The namespace of the ConfigMap holding the driver configuration
+	ConfigMapNamespace string `json:"configMapNamespace,omitempty"`
 }
 
 type ControllerConfiguration struct {
@@ -571,12 +910,67 @@ type ControllerConfiguration struct {
 	LeaderElectionNamespace string `json:"leaderElectionNamespace,omitempty"`
 	// The partition name for controller plugin
 	PartitionName string `json:"partitionName,omitempty"`
+	// The number of shards the volumes and nodes are partitioned into among the controller plugin instances.
+	// Each shard is reconciled by the instance holding the shard's lease. Sharding is disabled if the count is 1 or less.
+	ShardCount int `json:"shardCount,omitempty"`
+	// The interval at which the shared state of the controllers is checkpointed so that a new leader only replays the changes made since the checkpoint.
+	// Checkpointing is disabled if the interval is 0.
+	CheckpointIntervalInSec int `json:"checkpointIntervalInSec,omitempty"`
+	// boolean field to run the controllers without modifying the custom resources or the cloud resources.
+	// The operations the controllers would perform are logged and raised as events instead.
+	DryRun bool `json:"dryRun,omitempty"`
+	// The path of the file configuring the latency, errors, throttling and dropped responses injected into the cloud operations of the controllers.
+	// Fault injection is disabled if the path is empty.
+	FaultInjectionConfigPath string `json:"faultInjectionConfigPath,omitempty"`
 	// The number of worker thread per custom resource controller (AzVolume, attach/detach and replica controllers).
 	WorkerThreads int `json:"workerThreads,omitempty"`
 	// boolean field to enable waiting for lun in PublishVolume
 	WaitForLunEnabled bool `json:"waitForLunEnabled,omitempty"`
 	// The maximum number of retries for creating a replica attachment.
 	ReplicaVolumeAttachRetryLimit int `json:"replicaVolumeAttachRetryLimit,omitempty"`
+	// The duration since the last AzDriverNode heartbeat after which the primary attachments of a NotReady or out-of-service node are force detached.
+	// Force detach is disabled if the duration is 0.
+	NodeLeaseDurationInSec int `json:"nodeLeaseDurationInSec,omitempty"`
+	// The interval at which the data disks attached to the VMs of the cluster nodes are reconciled against AzVolumeAttachments.
+	// Dangling attachment detection is disabled if the interval is 0.
+	DanglingAttachmentScanIntervalInSec int `json:"danglingAttachmentScanIntervalInSec,omitempty"`
+	// The duration a dangling attachment of a disk referenced by a PersistentVolume is left in place before the disk is detached.
+	DanglingAttachmentGracePeriodInSec int `json:"danglingAttachmentGracePeriodInSec,omitempty"`
+	// The interval at which the managed disks created by the driver are checked for a PersistentVolume or AzVolume referencing them.
+	// Orphaned disk garbage collection is disabled if the interval is 0.
+	OrphanedDiskScanIntervalInSec int `json:"orphanedDiskScanIntervalInSec,omitempty"`
+	// The duration an orphaned disk is retained before the orphaned disk policy is applied.
+	OrphanedDiskRetentionPeriodInSec int `json:"orphanedDiskRetentionPeriodInSec,omitempty"`
+	// The action taken on orphaned disks after the retention period. available values: report, snapshot-and-delete
+	OrphanedDiskPolicy string `json:"orphanedDiskPolicy,omitempty"`
+	// boolean field to enable the webhook converting custom resources between the v1beta1 and v1beta2 api versions
+	EnableConversionWebhook bool `json:"enableConversionWebhook,omitempty"`
+	// boolean field to enable the webhook defaulting and validating AzVolume specs at admission time
+	EnableAdmissionWebhook bool `json:"enableAdmissionWebhook,omitempty"`
+	// The port at which the controller serves its webhooks.
+	WebhookPort int `json:"webhookPort,omitempty"`
+	// The directory containing the serving certificate (tls.crt) and key (tls.key) of the webhooks.
+	WebhookCertDir string `json:"webhookCertDir,omitempty"`
+	// The filter and scorer plugins used to select the nodes for replica attachments.
+	PlacementProfile PlacementProfile `json:"placementProfile,omitempty"`
+}
+
+// PlacementProfile configures the plugins used to select the nodes for replica attachments.
+// Plugins not listed in the profile keep their default configuration.
+type PlacementProfile struct {
+	// The configuration of the plugins filtering out the nodes which do not qualify for replica attachments.
+	Filters []PlacementPluginConfiguration `json:"filters,omitempty"`
+	// The configuration of the plugins scoring the qualifying nodes.
+	Scorers []PlacementPluginConfiguration `json:"scorers,omitempty"`
+}
+
+type PlacementPluginConfiguration struct {
+	// The name of the plugin
+	Name string `json:"name"`
+	// boolean field to enable or disable the plugin. Built-in plugins are enabled and custom plugins are disabled by default.
+	Enabled *bool `json:"enabled,omitempty"`
+	// The priority of a scorer plugin on a scale of 1 ~ 5 (5 being the highest priority). Ignored for filter plugins.
+	Weight *float64 `json:"weight,omitempty"`
 }
 
 type NodeConfiguration struct {
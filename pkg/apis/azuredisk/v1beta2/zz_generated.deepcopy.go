@@ -22,6 +22,8 @@ limitations under the License.
 package v1beta2
 
 import (
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -29,7 +31,7 @@ import (
 func (in *AzDiskDriverConfiguration) DeepCopyInto(out *AzDiskDriverConfiguration) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
-	out.ControllerConfig = in.ControllerConfig
+	in.ControllerConfig.DeepCopyInto(&out.ControllerConfig)
 	out.NodeConfig = in.NodeConfig
 	out.CloudConfig = in.CloudConfig
 	out.ClientConfig = in.ClientConfig
@@ -169,6 +171,31 @@ func (in *AzDriverNodeStatus) DeepCopyInto(out *AzDriverNodeStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.MaxDataDiskCount != nil {
+		in, out := &in.MaxDataDiskCount, &out.MaxDataDiskCount
+		*out = new(int32)
+		**out = **in
+	}
+	if in.AttachedDataDiskCount != nil {
+		in, out := &in.AttachedDataDiskCount, &out.AttachedDataDiskCount
+		*out = new(int32)
+		**out = **in
+	}
+	if in.InstanceType != nil {
+		in, out := &in.InstanceType, &out.InstanceType
+		*out = new(string)
+		**out = **in
+	}
+	if in.Zone != nil {
+		in, out := &in.Zone, &out.Zone
+		*out = new(string)
+		**out = **in
+	}
+	if in.FaultDomain != nil {
+		in, out := &in.FaultDomain, &out.FaultDomain
+		*out = new(string)
+		**out = **in
+	}
 	return
 }
 
@@ -205,6 +232,258 @@ func (in *AzError) DeepCopy() *AzError {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzSnapshot) DeepCopyInto(out *AzSnapshot) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzSnapshot.
+func (in *AzSnapshot) DeepCopy() *AzSnapshot {
+	if in == nil {
+		return nil
+	}
+	out := new(AzSnapshot)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AzSnapshot) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzSnapshotList) DeepCopyInto(out *AzSnapshotList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]AzSnapshot, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzSnapshotList.
+func (in *AzSnapshotList) DeepCopy() *AzSnapshotList {
+	if in == nil {
+		return nil
+	}
+	out := new(AzSnapshotList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AzSnapshotList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzSnapshotPolicy) DeepCopyInto(out *AzSnapshotPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzSnapshotPolicy.
+func (in *AzSnapshotPolicy) DeepCopy() *AzSnapshotPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(AzSnapshotPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AzSnapshotPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzSnapshotPolicyList) DeepCopyInto(out *AzSnapshotPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]AzSnapshotPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzSnapshotPolicyList.
+func (in *AzSnapshotPolicyList) DeepCopy() *AzSnapshotPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(AzSnapshotPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AzSnapshotPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzSnapshotPolicySpec) DeepCopyInto(out *AzSnapshotPolicySpec) {
+	*out = *in
+	out.Schedule = in.Schedule
+	in.Selector.DeepCopyInto(&out.Selector)
+	out.Retention = in.Retention
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzSnapshotPolicySpec.
+func (in *AzSnapshotPolicySpec) DeepCopy() *AzSnapshotPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AzSnapshotPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzSnapshotPolicyStatus) DeepCopyInto(out *AzSnapshotPolicyStatus) {
+	*out = *in
+	if in.LastScheduleTime != nil {
+		in, out := &in.LastScheduleTime, &out.LastScheduleTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSuccessTime != nil {
+		in, out := &in.LastSuccessTime, &out.LastSuccessTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastFailureTime != nil {
+		in, out := &in.LastFailureTime, &out.LastFailureTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Error != nil {
+		in, out := &in.Error, &out.Error
+		*out = new(AzError)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzSnapshotPolicyStatus.
+func (in *AzSnapshotPolicyStatus) DeepCopy() *AzSnapshotPolicyStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AzSnapshotPolicyStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzSnapshotRetention) DeepCopyInto(out *AzSnapshotRetention) {
+	*out = *in
+	out.MaxAge = in.MaxAge
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzSnapshotRetention.
+func (in *AzSnapshotRetention) DeepCopy() *AzSnapshotRetention {
+	if in == nil {
+		return nil
+	}
+	out := new(AzSnapshotRetention)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzSnapshotSpec) DeepCopyInto(out *AzSnapshotSpec) {
+	*out = *in
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(corev1.SecretReference)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzSnapshotSpec.
+func (in *AzSnapshotSpec) DeepCopy() *AzSnapshotSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AzSnapshotSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzSnapshotStatus) DeepCopyInto(out *AzSnapshotStatus) {
+	*out = *in
+	if in.Detail != nil {
+		in, out := &in.Detail, &out.Detail
+		*out = new(Snapshot)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Error != nil {
+		in, out := &in.Error, &out.Error
+		*out = new(AzError)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzSnapshotStatus.
+func (in *AzSnapshotStatus) DeepCopy() *AzSnapshotStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AzSnapshotStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AzVolume) DeepCopyInto(out *AzVolume) {
 	*out = *in
@@ -337,6 +616,13 @@ func (in *AzVolumeAttachmentStatus) DeepCopyInto(out *AzVolumeAttachmentStatus)
 			(*out)[key] = val
 		}
 	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -373,6 +659,133 @@ func (in *AzVolumeAttachmentStatusDetail) DeepCopy() *AzVolumeAttachmentStatusDe
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzVolumeGroupSnapshot) DeepCopyInto(out *AzVolumeGroupSnapshot) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzVolumeGroupSnapshot.
+func (in *AzVolumeGroupSnapshot) DeepCopy() *AzVolumeGroupSnapshot {
+	if in == nil {
+		return nil
+	}
+	out := new(AzVolumeGroupSnapshot)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AzVolumeGroupSnapshot) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzVolumeGroupSnapshotList) DeepCopyInto(out *AzVolumeGroupSnapshotList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]AzVolumeGroupSnapshot, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzVolumeGroupSnapshotList.
+func (in *AzVolumeGroupSnapshotList) DeepCopy() *AzVolumeGroupSnapshotList {
+	if in == nil {
+		return nil
+	}
+	out := new(AzVolumeGroupSnapshotList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AzVolumeGroupSnapshotList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzVolumeGroupSnapshotSpec) DeepCopyInto(out *AzVolumeGroupSnapshotSpec) {
+	*out = *in
+	if in.SourceVolumeIDs != nil {
+		in, out := &in.SourceVolumeIDs, &out.SourceVolumeIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(corev1.SecretReference)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzVolumeGroupSnapshotSpec.
+func (in *AzVolumeGroupSnapshotSpec) DeepCopy() *AzVolumeGroupSnapshotSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AzVolumeGroupSnapshotSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzVolumeGroupSnapshotStatus) DeepCopyInto(out *AzVolumeGroupSnapshotStatus) {
+	*out = *in
+	if in.Detail != nil {
+		in, out := &in.Detail, &out.Detail
+		*out = new(GroupSnapshot)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Error != nil {
+		in, out := &in.Error, &out.Error
+		*out = new(AzError)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzVolumeGroupSnapshotStatus.
+func (in *AzVolumeGroupSnapshotStatus) DeepCopy() *AzVolumeGroupSnapshotStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AzVolumeGroupSnapshotStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AzVolumeList) DeepCopyInto(out *AzVolumeList) {
 	*out = *in
@@ -435,6 +848,11 @@ func (in *AzVolumeSpec) DeepCopyInto(out *AzVolumeSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(corev1.SecretReference)
+		**out = **in
+	}
 	if in.ContentVolumeSource != nil {
 		in, out := &in.ContentVolumeSource, &out.ContentVolumeSource
 		*out = new(ContentVolumeSource)
@@ -478,6 +896,13 @@ func (in *AzVolumeStatus) DeepCopyInto(out *AzVolumeStatus) {
 			(*out)[key] = val
 		}
 	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -593,6 +1018,7 @@ func (in *ContentVolumeSource) DeepCopy() *ContentVolumeSource {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ControllerConfiguration) DeepCopyInto(out *ControllerConfiguration) {
 	*out = *in
+	in.PlacementProfile.DeepCopyInto(&out.PlacementProfile)
 	return
 }
 
@@ -606,6 +1032,30 @@ func (in *ControllerConfiguration) DeepCopy() *ControllerConfiguration {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GroupSnapshot) DeepCopyInto(out *GroupSnapshot) {
+	*out = *in
+	if in.Snapshots != nil {
+		in, out := &in.Snapshots, &out.Snapshots
+		*out = make([]Snapshot, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.CreationTime.DeepCopyInto(&out.CreationTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GroupSnapshot.
+func (in *GroupSnapshot) DeepCopy() *GroupSnapshot {
+	if in == nil {
+		return nil
+	}
+	out := new(GroupSnapshot)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ListSnapshotsResult) DeepCopyInto(out *ListSnapshotsResult) {
 	*out = *in
@@ -668,6 +1118,62 @@ func (in *NodeConfiguration) DeepCopy() *NodeConfiguration {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlacementPluginConfiguration) DeepCopyInto(out *PlacementPluginConfiguration) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Weight != nil {
+		in, out := &in.Weight, &out.Weight
+		*out = new(float64)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacementPluginConfiguration.
+func (in *PlacementPluginConfiguration) DeepCopy() *PlacementPluginConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(PlacementPluginConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlacementProfile) DeepCopyInto(out *PlacementProfile) {
+	*out = *in
+	if in.Filters != nil {
+		in, out := &in.Filters, &out.Filters
+		*out = make([]PlacementPluginConfiguration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Scorers != nil {
+		in, out := &in.Scorers, &out.Scorers
+		*out = make([]PlacementPluginConfiguration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacementProfile.
+func (in *PlacementProfile) DeepCopy() *PlacementProfile {
+	if in == nil {
+		return nil
+	}
+	out := new(PlacementProfile)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Snapshot) DeepCopyInto(out *Snapshot) {
 	*out = *in
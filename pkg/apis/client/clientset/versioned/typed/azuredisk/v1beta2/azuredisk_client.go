@@ -29,8 +29,11 @@ import (
 type DiskV1beta2Interface interface {
 	RESTClient() rest.Interface
 	AzDriverNodesGetter
+	AzSnapshotsGetter
+	AzSnapshotPoliciesGetter
 	AzVolumesGetter
 	AzVolumeAttachmentsGetter
+	AzVolumeGroupSnapshotsGetter
 }
 
 // DiskV1beta2Client is used to interact with features provided by the disk.csi.azure.com group.
@@ -42,6 +45,14 @@ func (c *DiskV1beta2Client) AzDriverNodes(namespace string) AzDriverNodeInterfac
 	return newAzDriverNodes(c, namespace)
 }
 
+func (c *DiskV1beta2Client) AzSnapshots(namespace string) AzSnapshotInterface {
+	return newAzSnapshots(c, namespace)
+}
+
+func (c *DiskV1beta2Client) AzSnapshotPolicies(namespace string) AzSnapshotPolicyInterface {
+	return newAzSnapshotPolicies(c, namespace)
+}
+
 func (c *DiskV1beta2Client) AzVolumes(namespace string) AzVolumeInterface {
 	return newAzVolumes(c, namespace)
 }
@@ -50,6 +61,10 @@ func (c *DiskV1beta2Client) AzVolumeAttachments(namespace string) AzVolumeAttach
 	return newAzVolumeAttachments(c, namespace)
 }
 
+func (c *DiskV1beta2Client) AzVolumeGroupSnapshots(namespace string) AzVolumeGroupSnapshotInterface {
+	return newAzVolumeGroupSnapshots(c, namespace)
+}
+
 // NewForConfig creates a new DiskV1beta2Client for the given config.
 // NewForConfig is equivalent to NewForConfigAndClient(c, httpClient),
 // where httpClient was generated with rest.HTTPClientFor(c).
@@ -32,6 +32,14 @@ func (c *FakeDiskV1beta2) AzDriverNodes(namespace string) v1beta2.AzDriverNodeIn
 	return &FakeAzDriverNodes{c, namespace}
 }
 
+func (c *FakeDiskV1beta2) AzSnapshots(namespace string) v1beta2.AzSnapshotInterface {
+	return &FakeAzSnapshots{c, namespace}
+}
+
+func (c *FakeDiskV1beta2) AzSnapshotPolicies(namespace string) v1beta2.AzSnapshotPolicyInterface {
+	return &FakeAzSnapshotPolicies{c, namespace}
+}
+
 func (c *FakeDiskV1beta2) AzVolumes(namespace string) v1beta2.AzVolumeInterface {
 	return &FakeAzVolumes{c, namespace}
 }
@@ -40,6 +48,10 @@ func (c *FakeDiskV1beta2) AzVolumeAttachments(namespace string) v1beta2.AzVolume
 	return &FakeAzVolumeAttachments{c, namespace}
 }
 
+func (c *FakeDiskV1beta2) AzVolumeGroupSnapshots(namespace string) v1beta2.AzVolumeGroupSnapshotInterface {
+	return &FakeAzVolumeGroupSnapshots{c, namespace}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeDiskV1beta2) RESTClient() rest.Interface {
@@ -20,6 +20,12 @@ package v1beta2
 
 type AzDriverNodeExpansion interface{}
 
+type AzSnapshotExpansion interface{}
+
+type AzSnapshotPolicyExpansion interface{}
+
 type AzVolumeExpansion interface{}
 
 type AzVolumeAttachmentExpansion interface{}
+
+type AzVolumeGroupSnapshotExpansion interface{}
@@ -26,10 +26,16 @@ import (
 type Interface interface {
 	// AzDriverNodes returns a AzDriverNodeInformer.
 	AzDriverNodes() AzDriverNodeInformer
+	// AzSnapshots returns a AzSnapshotInformer.
+	AzSnapshots() AzSnapshotInformer
+	// AzSnapshotPolicies returns a AzSnapshotPolicyInformer.
+	AzSnapshotPolicies() AzSnapshotPolicyInformer
 	// AzVolumes returns a AzVolumeInformer.
 	AzVolumes() AzVolumeInformer
 	// AzVolumeAttachments returns a AzVolumeAttachmentInformer.
 	AzVolumeAttachments() AzVolumeAttachmentInformer
+	// AzVolumeGroupSnapshots returns a AzVolumeGroupSnapshotInformer.
+	AzVolumeGroupSnapshots() AzVolumeGroupSnapshotInformer
 }
 
 type version struct {
@@ -48,6 +54,16 @@ func (v *version) AzDriverNodes() AzDriverNodeInformer {
 	return &azDriverNodeInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// AzSnapshots returns a AzSnapshotInformer.
+func (v *version) AzSnapshots() AzSnapshotInformer {
+	return &azSnapshotInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// AzSnapshotPolicies returns a AzSnapshotPolicyInformer.
+func (v *version) AzSnapshotPolicies() AzSnapshotPolicyInformer {
+	return &azSnapshotPolicyInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // AzVolumes returns a AzVolumeInformer.
 func (v *version) AzVolumes() AzVolumeInformer {
 	return &azVolumeInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
@@ -57,3 +73,8 @@ func (v *version) AzVolumes() AzVolumeInformer {
 func (v *version) AzVolumeAttachments() AzVolumeAttachmentInformer {
 	return &azVolumeAttachmentInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
+
+// AzVolumeGroupSnapshots returns a AzVolumeGroupSnapshotInformer.
+func (v *version) AzVolumeGroupSnapshots() AzVolumeGroupSnapshotInformer {
+	return &azVolumeGroupSnapshotInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
@@ -64,10 +64,16 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 		// Group=disk.csi.azure.com, Version=v1beta2
 	case v1beta2.SchemeGroupVersion.WithResource("azdrivernodes"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Disk().V1beta2().AzDriverNodes().Informer()}, nil
+	case v1beta2.SchemeGroupVersion.WithResource("azsnapshots"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Disk().V1beta2().AzSnapshots().Informer()}, nil
+	case v1beta2.SchemeGroupVersion.WithResource("azsnapshotpolicies"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Disk().V1beta2().AzSnapshotPolicies().Informer()}, nil
 	case v1beta2.SchemeGroupVersion.WithResource("azvolumes"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Disk().V1beta2().AzVolumes().Informer()}, nil
 	case v1beta2.SchemeGroupVersion.WithResource("azvolumeattachments"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Disk().V1beta2().AzVolumeAttachments().Informer()}, nil
+	case v1beta2.SchemeGroupVersion.WithResource("azvolumegroupsnapshots"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Disk().V1beta2().AzVolumeGroupSnapshots().Informer()}, nil
 
 	}
 
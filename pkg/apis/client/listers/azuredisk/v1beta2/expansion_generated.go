@@ -26,6 +26,22 @@ type AzDriverNodeListerExpansion interface{}
 // AzDriverNodeNamespaceLister.
 type AzDriverNodeNamespaceListerExpansion interface{}
 
+// AzSnapshotListerExpansion allows custom methods to be added to
+// AzSnapshotLister.
+type AzSnapshotListerExpansion interface{}
+
+// AzSnapshotNamespaceListerExpansion allows custom methods to be added to
+// AzSnapshotNamespaceLister.
+type AzSnapshotNamespaceListerExpansion interface{}
+
+// AzSnapshotPolicyListerExpansion allows custom methods to be added to
+// AzSnapshotPolicyLister.
+type AzSnapshotPolicyListerExpansion interface{}
+
+// AzSnapshotPolicyNamespaceListerExpansion allows custom methods to be added to
+// AzSnapshotPolicyNamespaceLister.
+type AzSnapshotPolicyNamespaceListerExpansion interface{}
+
 // AzVolumeListerExpansion allows custom methods to be added to
 // AzVolumeLister.
 type AzVolumeListerExpansion interface{}
@@ -41,3 +57,11 @@ type AzVolumeAttachmentListerExpansion interface{}
 // AzVolumeAttachmentNamespaceListerExpansion allows custom methods to be added to
 // AzVolumeAttachmentNamespaceLister.
 type AzVolumeAttachmentNamespaceListerExpansion interface{}
+
+// AzVolumeGroupSnapshotListerExpansion allows custom methods to be added to
+// AzVolumeGroupSnapshotLister.
+type AzVolumeGroupSnapshotListerExpansion interface{}
+
+// AzVolumeGroupSnapshotNamespaceListerExpansion allows custom methods to be added to
+// AzVolumeGroupSnapshotNamespaceLister.
+type AzVolumeGroupSnapshotNamespaceListerExpansion interface{}
@@ -228,6 +228,8 @@ func prioritize(context context.Context, schedulerExtenderArgs schedulerapi.Exte
 	nodeNameToNumAttachedAttachmentsForPodsMap := make(map[string]int)
 	nodeNameToNumAllAttachmentsMap := make(map[string]int)
 	nodeNameToHeartbeatMap := make(map[string]metav1.Time)
+	nodeNameToMaxDataDiskCountMap := make(map[string]int)
+	nodeNameToAttachedDataDiskCountMap := make(map[string]int)
 	nodesChan, volumesChan := make(chan azDriverNodesMeta), make(chan azVolumeAttachmentsMeta)
 
 	go getAzDriverNodes(context, nodesChan)
@@ -241,11 +243,15 @@ func prioritize(context context.Context, schedulerExtenderArgs schedulerapi.Exte
 		return
 	}
 
-	// map azDriverNode name to its heartbeat
+	// map azDriverNode name to its heartbeat and published data disk capacity
 	for _, azDriverNode := range azDriverNodesMeta.nodes {
 		if azDriverNode.Status != nil {
 			nodeNameToHeartbeatMap[azDriverNode.Spec.NodeName] = *azDriverNode.Status.LastHeartbeatTime
 		}
+		if maxDataDiskCount, attachedDataDiskCount, ok := azureutils.GetDiskCountsFromAzDriverNode(azDriverNode); ok {
+			nodeNameToMaxDataDiskCountMap[azDriverNode.Spec.NodeName] = maxDataDiskCount
+			nodeNameToAttachedDataDiskCountMap[azDriverNode.Spec.NodeName] = attachedDataDiskCount
+		}
 	}
 
 	// get all azVolumeAttachments running in the cluster
@@ -279,9 +285,16 @@ func prioritize(context context.Context, schedulerExtenderArgs schedulerapi.Exte
 	klog.V(4).Infof("scoring nodes for pod %+v.", schedulerExtenderArgs.Pod.Name)
 	for _, nodeName := range *availableNodes {
 		klog.V(4).Infof("node %+v has %d/%d of requested volumeAttachments created", nodeName, nodeNameToNumCreatedAttachmentsForPodMap[nodeName], len(requestedVolumes))
+		maxDataDiskCount, ok := nodeNameToMaxDataDiskCountMap[nodeName]
+		if !ok {
+			maxDataDiskCount = -1
+		}
 		score := getNodeScore(nodeNameToNumCreatedAttachmentsForPodMap[nodeName],
 			nodeNameToNumAttachedAttachmentsForPodsMap[nodeName],
 			nodeNameToNumAllAttachmentsMap[nodeName],
+			len(azDisksRequestedByPod),
+			maxDataDiskCount,
+			nodeNameToAttachedDataDiskCountMap[nodeName],
 			nodeNameToHeartbeatMap[nodeName],
 			nodeName)
 		hostPriority := schedulerapi.HostPriority{Host: nodeName, Score: score}
@@ -333,7 +346,9 @@ func getKubernetesClientset() (*kubernetes.Clientset, error) {
 	return kubeClient, nil
 }
 
-func getNodeScore(createdAttachmentsForPodCount, attachedAttachmentsForPodCount, allAttachmentsCount int, latestHeartbeat metav1.Time, nodeName string) int64 {
+// getNodeScore scores a node for a pod requesting requestedAttachmentsCount disks. A negative maxDataDiskCount indicates
+// that the node plugin has not published the data disk capacity of the node.
+func getNodeScore(createdAttachmentsForPodCount, attachedAttachmentsForPodCount, allAttachmentsCount, requestedAttachmentsCount, maxDataDiskCount, attachedDataDiskCount int, latestHeartbeat metav1.Time, nodeName string) int64 {
 	now := time.Now()
 	latestHeartbeatWas := latestHeartbeat.Time
 	latestHeartbeatCanBe := now.Add(-2 * time.Minute)
@@ -347,9 +362,20 @@ func getNodeScore(createdAttachmentsForPodCount, attachedAttachmentsForPodCount,
 		return 0
 	}
 
-	// TODO: add nodeMaxCapacity to AzDriver nodes to be used in scoring
+	// the data disks attached to the node include those not managed by the driver, so prefer it if it is larger
+	usedDataDiskCount := allAttachmentsCount
+	if maxDataDiskCount >= 0 {
+		if attachedDataDiskCount > usedDataDiskCount {
+			usedDataDiskCount = attachedDataDiskCount
+		}
+		if remaining := maxDataDiskCount - usedDataDiskCount; remaining < requestedAttachmentsCount-createdAttachmentsForPodCount {
+			klog.V(4).Infof("node %s has %d/%d data disks attached and cannot fit the %d disk(s) requested by the pod", nodeName, usedDataDiskCount, maxDataDiskCount, requestedAttachmentsCount-createdAttachmentsForPodCount)
+			return 0
+		}
+	}
+
 	// we prioritze in the following order: 1) number of created requested attachments, 2) number of attached requested attachments, and 3) node capacity
-	score := int64(createdAttachmentsForPodCount*100) + int64(attachedAttachmentsForPodCount*10) - int64(allAttachmentsCount-createdAttachmentsForPodCount)
+	score := int64(createdAttachmentsForPodCount*100) + int64(attachedAttachmentsForPodCount*10) - int64(usedDataDiskCount-createdAttachmentsForPodCount)
 	if score < 0 {
 		score = 0
 	}
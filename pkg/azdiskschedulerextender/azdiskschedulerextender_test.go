@@ -29,6 +29,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"reflect"
 	"sort"
 	"sync"
@@ -91,12 +92,18 @@ var (
 )
 
 func TestMain(m *testing.M) {
-	existingConfigPath, _ := createConfigFileAndSetEnv(validKubeConfigPath, validKubeConfigContent, KubeConfigFileEnvVar)
+	// write the kubeconfig to a temporary directory so that it is not left in the source tree
+	configDir, err := ioutil.TempDir("", "azdiskschedulerextender")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create kubeconfig directory: %v\n", err)
+		os.Exit(1)
+	}
+	configPath := filepath.Join(configDir, validKubeConfigPath)
+	existingConfigPath, _ := createConfigFileAndSetEnv(configPath, validKubeConfigContent, KubeConfigFileEnvVar)
 
 	exitVal := m.Run()
-	if len(existingConfigPath) > 0 {
-		defer cleanConfigAndRestoreEnv(validKubeConfigPath, KubeConfigFileEnvVar, existingConfigPath)
-	}
+	cleanConfigAndRestoreEnv(configPath, KubeConfigFileEnvVar, existingConfigPath)
+	os.RemoveAll(configDir)
 	os.Exit(exitVal)
 }
 
@@ -191,7 +198,7 @@ func TestFilterAndPrioritizeResponses(t *testing.T) {
 				FailedNodes: make(map[string]string),
 				Error:       "",
 			},
-			expectedPrioritizeResult: schedulerapi.HostPriorityList{schedulerapi.HostPriority{Host: "node", Score: getNodeScore(1, 1, 1, metav1.Now(), "node")}},
+			expectedPrioritizeResult: schedulerapi.HostPriorityList{schedulerapi.HostPriority{Host: "node", Score: getNodeScore(1, 1, 1, 1, -1, 0, metav1.Now(), "node")}},
 			expectedPrioritizeOrder:  []string{"node"},
 		},
 		{
@@ -219,7 +226,7 @@ func TestFilterAndPrioritizeResponses(t *testing.T) {
 				FailedNodes: map[string]string{"node": "AzDriverNode for node is not ready."},
 				Error:       "",
 			},
-			expectedPrioritizeResult: schedulerapi.HostPriorityList{schedulerapi.HostPriority{Host: "node", Score: getNodeScore(1, 1, 1, metav1.Now(), "node")}},
+			expectedPrioritizeResult: schedulerapi.HostPriorityList{schedulerapi.HostPriority{Host: "node", Score: getNodeScore(1, 1, 1, 1, -1, 0, metav1.Now(), "node")}},
 			expectedPrioritizeOrder:  []string{"node"},
 		},
 		{
@@ -242,7 +249,7 @@ func TestFilterAndPrioritizeResponses(t *testing.T) {
 				FailedNodes: nil,
 				Error:       "",
 			},
-			expectedPrioritizeResult: schedulerapi.HostPriorityList{schedulerapi.HostPriority{Host: "node", Score: getNodeScore(0, 0, 1, metav1.Now(), "node")}},
+			expectedPrioritizeResult: schedulerapi.HostPriorityList{schedulerapi.HostPriority{Host: "node", Score: getNodeScore(0, 0, 1, 1, -1, 0, metav1.Now(), "node")}},
 			expectedPrioritizeOrder:  []string{"node"},
 		},
 		{
@@ -273,8 +280,8 @@ func TestFilterAndPrioritizeResponses(t *testing.T) {
 				Error:       "",
 			},
 			expectedPrioritizeResult: schedulerapi.HostPriorityList{
-				schedulerapi.HostPriority{Host: "node0", Score: getNodeScore(1, 1, 1, metav1.Now(), "node0")},
-				schedulerapi.HostPriority{Host: "node1", Score: getNodeScore(0, 0, 0, metav1.Now(), "node1")},
+				schedulerapi.HostPriority{Host: "node0", Score: getNodeScore(1, 1, 1, 1, -1, 0, metav1.Now(), "node0")},
+				schedulerapi.HostPriority{Host: "node1", Score: getNodeScore(0, 0, 0, 1, -1, 0, metav1.Now(), "node1")},
 			},
 			expectedPrioritizeOrder: []string{"node0", "node1"},
 		},
@@ -306,8 +313,8 @@ func TestFilterAndPrioritizeResponses(t *testing.T) {
 				Error:       "",
 			},
 			expectedPrioritizeResult: schedulerapi.HostPriorityList{
-				schedulerapi.HostPriority{Host: "node0", Score: getNodeScore(0, 0, 0, metav1.Now(), "node0")},
-				schedulerapi.HostPriority{Host: "node1", Score: getNodeScore(1, 1, 1, metav1.Now(), "node1")},
+				schedulerapi.HostPriority{Host: "node0", Score: getNodeScore(0, 0, 0, 1, -1, 0, metav1.Now(), "node0")},
+				schedulerapi.HostPriority{Host: "node1", Score: getNodeScore(1, 1, 1, 1, -1, 0, metav1.Now(), "node1")},
 			},
 			expectedPrioritizeOrder: []string{"node1", "node0"},
 		},
@@ -343,8 +350,8 @@ func TestFilterAndPrioritizeResponses(t *testing.T) {
 				Error:       "",
 			},
 			expectedPrioritizeResult: schedulerapi.HostPriorityList{
-				schedulerapi.HostPriority{Host: "node0", Score: getNodeScore(2, 2, 2, metav1.Now(), "node0")},
-				schedulerapi.HostPriority{Host: "node1", Score: getNodeScore(0, 0, 0, metav1.Now(), "node1")},
+				schedulerapi.HostPriority{Host: "node0", Score: getNodeScore(2, 2, 2, 1, -1, 0, metav1.Now(), "node0")},
+				schedulerapi.HostPriority{Host: "node1", Score: getNodeScore(0, 0, 0, 1, -1, 0, metav1.Now(), "node1")},
 			},
 			expectedPrioritizeOrder: []string{"node0", "node1"},
 		},
@@ -385,9 +392,9 @@ func TestFilterAndPrioritizeResponses(t *testing.T) {
 				Error:       "",
 			},
 			expectedPrioritizeResult: schedulerapi.HostPriorityList{
-				schedulerapi.HostPriority{Host: "node0", Score: getNodeScore(2, 2, 2, metav1.Now(), "node0")},
-				schedulerapi.HostPriority{Host: "node1", Score: getNodeScore(3, 3, 3, metav1.Now(), "node1")},
-				schedulerapi.HostPriority{Host: "node2", Score: getNodeScore(1, 1, 1, metav1.Now(), "node2")},
+				schedulerapi.HostPriority{Host: "node0", Score: getNodeScore(2, 2, 2, 1, -1, 0, metav1.Now(), "node0")},
+				schedulerapi.HostPriority{Host: "node1", Score: getNodeScore(3, 3, 3, 1, -1, 0, metav1.Now(), "node1")},
+				schedulerapi.HostPriority{Host: "node2", Score: getNodeScore(1, 1, 1, 1, -1, 0, metav1.Now(), "node2")},
 			},
 			expectedPrioritizeOrder: []string{"node1", "node0", "node2"},
 		},
@@ -430,9 +437,9 @@ func TestFilterAndPrioritizeResponses(t *testing.T) {
 				Error:       "",
 			},
 			expectedPrioritizeResult: schedulerapi.HostPriorityList{
-				schedulerapi.HostPriority{Host: "node0", Score: getNodeScore(2, 2, 2, metav1.Now(), "node0")},
-				schedulerapi.HostPriority{Host: "node1", Score: getNodeScore(3, 3, 3, metav1.Now(), "node1")},
-				schedulerapi.HostPriority{Host: "node2", Score: getNodeScore(0, 0, 1, metav1.Now(), "node2")},
+				schedulerapi.HostPriority{Host: "node0", Score: getNodeScore(2, 2, 2, 1, -1, 0, metav1.Now(), "node0")},
+				schedulerapi.HostPriority{Host: "node1", Score: getNodeScore(3, 3, 3, 1, -1, 0, metav1.Now(), "node1")},
+				schedulerapi.HostPriority{Host: "node2", Score: getNodeScore(0, 0, 1, 1, -1, 0, metav1.Now(), "node2")},
 			},
 			expectedPrioritizeOrder: []string{"node1", "node0", "node2"},
 		},
@@ -472,9 +479,9 @@ func TestFilterAndPrioritizeResponses(t *testing.T) {
 				Error:       "",
 			},
 			expectedPrioritizeResult: schedulerapi.HostPriorityList{
-				schedulerapi.HostPriority{Host: "node0", Score: getNodeScore(2, 2, 2, metav1.Now(), "node0")},
-				schedulerapi.HostPriority{Host: "node1", Score: getNodeScore(2, 2, 3, metav1.Now(), "node1")},
-				schedulerapi.HostPriority{Host: "node2", Score: getNodeScore(0, 0, 1, metav1.Now(), "node2")},
+				schedulerapi.HostPriority{Host: "node0", Score: getNodeScore(2, 2, 2, 1, -1, 0, metav1.Now(), "node0")},
+				schedulerapi.HostPriority{Host: "node1", Score: getNodeScore(2, 2, 3, 1, -1, 0, metav1.Now(), "node1")},
+				schedulerapi.HostPriority{Host: "node2", Score: getNodeScore(0, 0, 1, 1, -1, 0, metav1.Now(), "node2")},
 			},
 			expectedPrioritizeOrder: []string{"node0", "node1", "node2"},
 		},
@@ -545,6 +552,79 @@ func TestFilterAndPrioritizeResponses(t *testing.T) {
 	}
 }
 
+func TestGetNodeScore(t *testing.T) {
+	tests := []struct {
+		name                           string
+		createdAttachmentsForPodCount  int
+		attachedAttachmentsForPodCount int
+		allAttachmentsCount            int
+		requestedAttachmentsCount      int
+		maxDataDiskCount               int
+		attachedDataDiskCount          int
+		latestHeartbeat                metav1.Time
+		expectedScore                  int64
+	}{
+		{
+			name:                           "unknown capacity falls back to attachment count",
+			createdAttachmentsForPodCount:  1,
+			attachedAttachmentsForPodCount: 1,
+			allAttachmentsCount:            2,
+			requestedAttachmentsCount:      2,
+			maxDataDiskCount:               -1,
+			latestHeartbeat:                metav1.Now(),
+			expectedScore:                  109,
+		},
+		{
+			name:                           "disks attached outside of the driver reduce the score",
+			createdAttachmentsForPodCount:  1,
+			attachedAttachmentsForPodCount: 1,
+			allAttachmentsCount:            2,
+			requestedAttachmentsCount:      2,
+			maxDataDiskCount:               8,
+			attachedDataDiskCount:          4,
+			latestHeartbeat:                metav1.Now(),
+			expectedScore:                  107,
+		},
+		{
+			name:                          "node without capacity for the requested disks",
+			createdAttachmentsForPodCount: 0,
+			allAttachmentsCount:           3,
+			requestedAttachmentsCount:     2,
+			maxDataDiskCount:              4,
+			attachedDataDiskCount:         3,
+			latestHeartbeat:               metav1.Now(),
+			expectedScore:                 0,
+		},
+		{
+			name:                           "unresponsive node",
+			createdAttachmentsForPodCount:  1,
+			attachedAttachmentsForPodCount: 1,
+			allAttachmentsCount:            1,
+			requestedAttachmentsCount:      1,
+			maxDataDiskCount:               4,
+			attachedDataDiskCount:          1,
+			latestHeartbeat:                metav1.NewTime(time.Now().Add(-5 * time.Minute)),
+			expectedScore:                  0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			score := getNodeScore(test.createdAttachmentsForPodCount,
+				test.attachedAttachmentsForPodCount,
+				test.allAttachmentsCount,
+				test.requestedAttachmentsCount,
+				test.maxDataDiskCount,
+				test.attachedDataDiskCount,
+				test.latestHeartbeat,
+				"node")
+			if score != test.expectedScore {
+				t.Errorf("Actual score (%d) does not equal expected score (%d).", score, test.expectedScore)
+			}
+		})
+	}
+}
+
 // TODO test only checks the response code. add check for response body
 func TestFilterAndPrioritizeInRandomizedLargeCluster(t *testing.T) {
 	var nodeNames []string
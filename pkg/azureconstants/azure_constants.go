@@ -29,6 +29,7 @@ const (
 	AzureDiskCSIDriverName         = "azuredisk_csi_driver"
 	V1beta1                        = "v1beta1"
 	CachingModeField               = "cachingmode"
+	CapacityDiskSizeGiBField       = "capacitydisksizegib"
 	DefaultAzureCredentialFileEnv  = "AZURE_CREDENTIAL_FILE"
 	DefaultCredFilePathLinux       = "/etc/kubernetes/azure.json"
 	DefaultCredFilePathWindows     = "C:\\k\\azure.json"
@@ -44,6 +45,7 @@ const (
 	DiskNameField                  = "diskname"
 	EnableBurstingField            = "enablebursting"
 	ErrDiskNotFound                = "not found"
+	FreezeFilesystemsField         = "freezefilesystems"
 	FsTypeField                    = "fstype"
 	IncrementalField               = "incremental"
 	KindField                      = "kind"
@@ -54,6 +56,7 @@ const (
 	MaxMountReplicaCountField      = "maxmountreplicacount"
 	MaxSharesField                 = "maxshares"
 	MinimumDiskSizeGiB             = 1
+	DefaultCapacityDiskSizeGiB     = 128
 	NetworkAccessPolicyField       = "networkaccesspolicy"
 	NotFound                       = "NotFound"
 	PerfProfileBasic               = "basic"
@@ -67,8 +70,12 @@ const (
 	PvcNamespaceTag                = "kubernetes.io-created-for-pvc-namespace"
 	PvcNamespaceLabel              = "disk.csi.azure.com/pvc-namespace"
 	PvcNameTag                     = "kubernetes.io-created-for-pvc-name"
+	ProvisioningStateFailed        = "Failed"
 	PvNameKey                      = "csi.storage.k8s.io/pv/name"
 	PvNameTag                      = "kubernetes.io-created-for-pv-name"
+	OrphanedSinceTag               = "kubernetes.io-orphaned-since"
+	ClusterIDTag                   = "kubernetes.io-created-for-cluster-id"
+	GroupSnapshotNameTag           = "kubernetes.io-created-for-group-snapshot-name"
 	PvNameLabel                    = "disk.csi.azure.com/pv"
 	VolumeAttachmentKey            = "disk.csi.azure.com/volumeattachment"
 	APIVersion                     = "disk.csi.azure.com/apiversion"
@@ -87,6 +94,7 @@ const (
 	StandardSsdAccountPrefix       = "standardssd"
 	StorageAccountTypeField        = "storageaccounttype"
 	TagsField                      = "tags"
+	TargetLocationField            = "targetlocation"
 	ThrottlingKey                  = "throttlingKey"
 	RetryKey                       = "number_of_retry"
 	NetRetryKey                    = "number_of_net_retry"
@@ -109,6 +117,10 @@ const (
 	// 2. AzVolumeAttachmentFinalizer for AzVolume prevents AzVolume CRI from being deleted before all AzVolumeAttachments attached to that volume is deleted as well
 	AzVolumeAttachmentFinalizer = "disk.csi.azure.com/azvolumeattachment-finalizer"
 	AzVolumeFinalizer           = "disk.csi.azure.com/azvolume-finalizer"
+	// AzSnapshotFinalizer for AzSnapshot objects handles deletion of the underlying snapshot before the CRI is removed
+	AzSnapshotFinalizer = "disk.csi.azure.com/azsnapshot-finalizer"
+	// AzVolumeGroupSnapshotFinalizer for AzVolumeGroupSnapshot objects handles deletion of the underlying snapshots before the CRI is removed
+	AzVolumeGroupSnapshotFinalizer = "disk.csi.azure.com/azvolumegroupsnapshot-finalizer"
 	// ControllerFinalizer is a finalizer added to the pod running Azuredisk driver controller
 	// to prevent the pod deletion until clean up is completed
 	ControllerFinalizer                = "disk.csi.azure.com/azuredisk-finalizer"
@@ -127,6 +139,8 @@ const (
 	RecoverAnnotation                  = "disk.csi.azure.com/recovery" // used to ensure reconciliation is triggered for recovering CRIs
 	VolumeNameLabel                    = "disk.csi.azure.com/volume-name"
 	VolumeIDLabel                      = "disk.csi.azure.com/volume-id"
+	SnapshotNameLabel                  = "disk.csi.azure.com/snapshot-name"
+	GroupSnapshotNameLabel             = "disk.csi.azure.com/group-snapshot-name"
 	InlineVolumeAnnotation             = "disk.csi.azure.com/inline-volume"
 	PodNameKey                         = "disk.csi/azure.com/pod-name"
 	PreProvisionedVolumeAnnotation     = "disk.csi.azure.com/pre-provisioned"
@@ -137,6 +151,14 @@ const (
 	WorkflowKey                        = "disk.csi.azure.com/requester-name"
 	ReplicaVolumeAttachRetryAnnotation = "disk.csi.azure.com/replica-volume-attach-retry"
 	ReplicaVolumeAttachRetryCount      = "disk.csi.azure.com/replica-volume-attach-retry-count"
+	DryRunOperationKey                 = "disk.csi.azure.com/dry-run-operation"
+	SnapshotPolicyLabel                = "disk.csi.azure.com/snapshot-policy"
+	// FilesystemFreezeRequestAnnotation is set on an AzVolumeAttachment by the controller to ask the node to freeze the filesystem
+	// of the volume for the group snapshot named by its value. The node reports the outcome with FilesystemFrozenAnnotation or
+	// FilesystemFreezeErrorAnnotation, and thaws the filesystem once the request is removed.
+	FilesystemFreezeRequestAnnotation = "disk.csi.azure.com/filesystem-freeze-request"
+	FilesystemFrozenAnnotation        = "disk.csi.azure.com/filesystem-frozen"
+	FilesystemFreezeErrorAnnotation   = "disk.csi.azure.com/filesystem-freeze-error"
 
 	ControllerClusterRoleName         = "azuredisk-external-provisioner-role"
 	ControllerClusterRoleBindingName  = "azuredisk-csi-provisioner-binding"
@@ -145,32 +167,45 @@ const (
 	ReleaseNamespace                  = "kube-system"
 	NamespaceField                    = "metadata.namespace"
 
-	AzVolumeCRDName           = "azvolumes.disk.csi.azure.com"
-	AzVolumeAttachmentCRDName = "azvolumeattachments.disk.csi.azure.com"
-	AzDriverNodeCRDName       = "azdrivernodes.disk.csi.azure.com"
-	CRIUpdateRetryDuration    = time.Duration(1) * time.Second
-	CRIUpdateRetryFactor      = 3.0
-	CRIUpdateRetryStep        = 5
-	DefaultInformerResync     = time.Duration(30) * time.Second
-	DefaultPollingRate        = time.Duration(100) * time.Millisecond
-	ZonedField                = "zoned"
-	TooManyRequests           = "TooManyRequests"
-	ClientThrottled           = "client throttled"
-	VolumeID                  = "volumeid"
-	Node                      = "node"
-	SourceResourceID          = "source_resource_id"
-	SnapshotName              = "snapshot_name"
-	SnapshotID                = "snapshot_id"
-	Latency                   = "latency"
-	LongThrottleLatency       = 50 * time.Millisecond
-	NormalUpdateMaxNetRetry   = 0
-	ForcedUpdateMaxNetRetry   = 10
-	DefaultBackoffCap         = 10 * time.Minute
-	EnableAsyncAttachField    = "enableasyncattach"
+	AzVolumeCRDName              = "azvolumes.disk.csi.azure.com"
+	AzVolumeAttachmentCRDName    = "azvolumeattachments.disk.csi.azure.com"
+	AzDriverNodeCRDName          = "azdrivernodes.disk.csi.azure.com"
+	AzSnapshotCRDName            = "azsnapshots.disk.csi.azure.com"
+	AzVolumeGroupSnapshotCRDName = "azvolumegroupsnapshots.disk.csi.azure.com"
+	CRIUpdateRetryDuration       = time.Duration(1) * time.Second
+	CRIUpdateRetryFactor         = 3.0
+	CRIUpdateRetryStep           = 5
+	DefaultInformerResync        = time.Duration(30) * time.Second
+	DefaultPollingRate           = time.Duration(100) * time.Millisecond
+	ZonedField                   = "zoned"
+	TooManyRequests              = "TooManyRequests"
+	ClientThrottled              = "client throttled"
+	VolumeID                     = "volumeid"
+	Node                         = "node"
+	SourceResourceID             = "source_resource_id"
+	SnapshotName                 = "snapshot_name"
+	SnapshotID                   = "snapshot_id"
+	GroupSnapshotName            = "group_snapshot_name"
+	GroupSnapshotID              = "group_snapshot_id"
+	Latency                      = "latency"
+	LongThrottleLatency          = 50 * time.Millisecond
+	NormalUpdateMaxNetRetry      = 0
+	ForcedUpdateMaxNetRetry      = 10
+	DefaultBackoffCap            = 10 * time.Minute
+	EnableAsyncAttachField       = "enableasyncattach"
 
 	// define different sleep time when hit throttling
 	SnapshotOpThrottlingSleepSec = 50
 
+	// the interval at which the fault-injection config is reloaded
+	FaultInjectionConfigReloadInterval = 10 * time.Second
+
+	// how long subscription usages are cached to avoid ARM throttling
+	DiskUsageCacheTTL = 5 * time.Minute
+
+	// how often the node plugin queries the number of data disks attached to its VM for the AzDriverNode status
+	AttachedDataDiskCountRefreshInterval = 5 * time.Minute
+
 	CurrentNodeParameter = "currentNode"
 	DevicePathParameter  = "devicePath"
 
@@ -178,6 +213,24 @@ const (
 	ReplicaAttachmentSuccessEvent = "ReplicaAttachmentSucceeded"
 	ClientFailedGetEvent          = "ClientFailedToGetObject"
 
+	DriverConfigReloadedEvent       = "DriverConfigReloaded"
+	DriverConfigReloadRejectedEvent = "DriverConfigReloadRejected"
+
+	NodeLeaseExpiredEvent = "NodeLeaseExpired"
+
+	DanglingAttachmentDetectedEvent = "DanglingAttachmentDetected"
+	DanglingAttachmentAdoptedEvent  = "DanglingAttachmentAdopted"
+	DanglingAttachmentDetachedEvent = "DanglingAttachmentDetached"
+
+	DryRunOperationEvent = "DryRunOperation"
+
+	OrphanedDiskPolicyReport            = "report"
+	OrphanedDiskPolicySnapshotAndDelete = "snapshot-and-delete"
+	OrphanedDiskSnapshotPrefix          = "orphaned-"
+
+	SharedStateCheckpointSuffix = "-checkpoint"
+	SharedStateCheckpointKey    = "checkpoint"
+
 	// AzDiskDriverConfiguration specific constants
 	DefaultEndpoint                                 = "unix://tmp/csi.sock"
 	DefaultMetricsAddress                           = "0.0.0.0:29604"
@@ -219,6 +272,23 @@ const (
 	DefaultAzureClientAttachDetachRateLimiterBucket = int(DefaultAzureClientAttachDetachRateLimiterQPS * 60.0) // Allow for a burst of a minutes worth of quota
 	DefaultAzureClientAttachDetachBatchInitialDelay = 1 * time.Second                                          // Wait 1s before processing a batch of attach or detach disk requests
 	DefaultReplicaVolumeAttachRetryLimit            = 2
+	DefaultNodeLeaseDurationInSec                   = 0
+	DefaultDanglingAttachmentScanIntervalInSec      = 0
+	DefaultDanglingAttachmentGracePeriodInSec       = 300
+	DefaultOrphanedDiskScanIntervalInSec            = 0
+	DefaultOrphanedDiskRetentionPeriodInSec         = 7 * 24 * 60 * 60
+	DefaultOrphanedDiskPolicy                       = OrphanedDiskPolicyReport
+	DefaultShardCount                               = 1
+	DefaultCheckpointIntervalInSec                  = 0
+	DefaultDryRun                                   = false
+	DefaultFaultInjectionConfigPath                 = ""
+	DefaultEnableConversionWebhook                  = false
+	DefaultEnableAdmissionWebhook                   = false
+	DefaultWebhookPort                              = 9443
+	DefaultWebhookCertDir                           = "/tmp/k8s-webhook-server/serving-certs"
+	DefaultConfigMapName                            = ""
+	DefaultConfigMapNamespace                       = ReleaseNamespace
+	DriverConfigMapKey                              = "config.yaml"
 )
 
 // CommandLineParams is a list of deprecated command-line parameters
@@ -228,7 +298,11 @@ var CommandLineParams = []string{"endpoint", "metrics-address", "kubeconfig", "d
 	"enable-disk-capacity-check", "kube-client-qps", "kube-client-burst", "vmss-cache-ttl-seconds", "enable-attach-detach-rate-limiter", "attach-detach-rate-limiter-qps",
 	"attach-detach-rate-limiter-bucket", "attach-detach-batch-initial-delay", "is-controller-plugin", "is-node-plugin", "driver-object-namespace",
 	"heartbeat-frequency-in-sec", "lease-duration-in-sec", "lease-renew-deadline-in-sec", "lease-retry-period-in-sec", "leader-election-namespace", "node-partition",
-	"controller-partition", "worker-threads", "waitForLunEnabled", "enableTrafficManager", "trafficManagerPort", "replicaVolumeAttachRetryLimit"}
+	"controller-partition", "worker-threads", "waitForLunEnabled", "enableTrafficManager", "trafficManagerPort", "replicaVolumeAttachRetryLimit",
+	"enable-conversion-webhook", "enable-admission-webhook", "webhook-port", "webhook-cert-dir", "config-map-name", "config-map-namespace",
+	"node-lease-duration-in-sec", "dangling-attachment-scan-interval-in-sec", "dangling-attachment-grace-period-in-sec",
+	"orphaned-disk-scan-interval-in-sec", "orphaned-disk-retention-period-in-sec", "orphaned-disk-policy", "shard-count",
+	"checkpoint-interval-in-sec", "dry-run", "fault-injection-config-path"}
 
 type UnpublishMode int
 
@@ -238,6 +312,10 @@ const (
 )
 
 var (
+	// GroupSnapshotPath identifies the snapshots of a group snapshot, which are tagged with the group snapshot name.
+	GroupSnapshotPath   = "/subscriptions/%s/resourceGroups/%s/groupSnapshots/%s"
+	GroupSnapshotPathRE = regexp.MustCompile(`(?i)^/subscriptions/([^/]+)/resourceGroups/([^/]+)/groupSnapshots/([^/]+)$`)
+
 	// ManagedDiskPath is described here: https://docs.microsoft.com/en-us/rest/api/compute/disks/createorupdate#create-a-managed-disk-from-an-existing-managed-disk-in-the-same-or-different-subscription.
 	ManagedDiskPath   = "/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Compute/disks/%s"
 	ManagedDiskPathRE = regexp.MustCompile(`(?i).*/subscriptions/(?:.*)/resourceGroups/(?:.*)/providers/Microsoft.Compute/disks/(.+)`)
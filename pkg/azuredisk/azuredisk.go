@@ -81,7 +81,10 @@ type Driver struct {
 	volumeLocks *volumehelper.VolumeLocks
 	hostUtil    hostUtil
 	// a timed cache GetDisk throttling
-	getDiskThrottlingCache     *azcache.TimedCache
+	getDiskThrottlingCache *azcache.TimedCache
+	usageClient            azureutils.UsageClient
+	// a timed cache of the subscription usages used to compute capacity
+	diskUsageCache             *azcache.TimedCache
 	perfOptimizationEnabled    bool
 	cloudConfigSecretName      string
 	cloudConfigSecretNamespace string
@@ -144,6 +147,13 @@ func newDriverV1(config *azdiskv1beta2.AzDiskDriverConfiguration) *Driver {
 		klog.Fatalf("%v", err)
 	}
 	driver.getDiskThrottlingCache = cache
+
+	driver.diskUsageCache, err = azureutils.NewDiskUsageCache(consts.DiskUsageCacheTTL, func() azureutils.UsageClient {
+		return driver.usageClient
+	})
+	if err != nil {
+		klog.Fatalf("%v", err)
+	}
 	return &driver
 }
 
@@ -209,6 +219,13 @@ func (d *Driver) Run(endpoint, kubeconfig string, disableAVSetNodes, testingMock
 		if d.cloud.ManagedDiskController != nil {
 			d.cloud.DisableUpdateCache = d.disableUpdateCache
 		}
+
+		if d.NodeID == "" {
+			d.usageClient, err = azureutils.NewUsageClient(d.cloud)
+			if err != nil {
+				klog.Warningf("failed to create usage client, GetCapacity will not be available: %v", err)
+			}
+		}
 	}
 
 	d.deviceHelper = optimization.NewSafeDeviceHelper()
@@ -232,6 +249,9 @@ func (d *Driver) Run(endpoint, kubeconfig string, disableAVSetNodes, testingMock
 		csi.ControllerServiceCapability_RPC_CLONE_VOLUME,
 		csi.ControllerServiceCapability_RPC_EXPAND_VOLUME,
 		csi.ControllerServiceCapability_RPC_SINGLE_NODE_MULTI_WRITER,
+		csi.ControllerServiceCapability_RPC_GET_VOLUME,
+		csi.ControllerServiceCapability_RPC_VOLUME_CONDITION,
+		csi.ControllerServiceCapability_RPC_GET_CAPACITY,
 	}
 	if d.enableListVolumes {
 		controllerCap = append(controllerCap, csi.ControllerServiceCapability_RPC_LIST_VOLUMES, csi.ControllerServiceCapability_RPC_LIST_VOLUMES_PUBLISHED_NODES)
@@ -39,6 +39,7 @@ import (
 	apiErrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	apiRuntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/informers"
 	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
@@ -46,6 +47,7 @@ import (
 	"k8s.io/client-go/tools/leaderelection/resourcelock"
 	"k8s.io/klog/v2"
 	"k8s.io/klog/v2/klogr"
+	"k8s.io/utils/pointer"
 
 	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	crdInformers "k8s.io/apiextensions-apiserver/pkg/client/informers/externalversions"
@@ -61,6 +63,7 @@ import (
 	azdiskinformertypes "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/client/informers/externalversions/azuredisk/v1beta2"
 	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
 	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils/faultinjection"
 	"sigs.k8s.io/azuredisk-csi-driver/pkg/controller"
 	csicommon "sigs.k8s.io/azuredisk-csi-driver/pkg/csi-common"
 	"sigs.k8s.io/azuredisk-csi-driver/pkg/optimization"
@@ -68,6 +71,7 @@ import (
 	volumehelper "sigs.k8s.io/azuredisk-csi-driver/pkg/util"
 	"sigs.k8s.io/azuredisk-csi-driver/pkg/watcher"
 	"sigs.k8s.io/azuredisk-csi-driver/pkg/workflow"
+	azcache "sigs.k8s.io/cloud-provider-azure/pkg/cache"
 	azurecloudconsts "sigs.k8s.io/cloud-provider-azure/pkg/consts"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/source"
@@ -89,6 +93,22 @@ const (
 	azDriverNodeHealthy      azDriverNodeStatus = "Driver node healthy."
 )
 
+// azDriverNodeDetails contains the details of the node's VM published in the AzDriverNode status which do not change
+// during the lifetime of the node plugin.
+type azDriverNodeDetails struct {
+	maxDataDiskCount *int32
+	instanceType     *string
+	zone             *string
+	faultDomain      *string
+}
+
+// attachedDataDiskCount is the number of data disks attached to the node's VM as of the last time the VM was queried.
+type attachedDataDiskCount struct {
+	lock        sync.Mutex
+	count       *int32
+	refreshTime time.Time
+}
+
 // LatencyAdapter implements LatencyMetric.
 type LatencyAdapter struct {
 	metric *metrics.HistogramVec
@@ -123,7 +143,16 @@ type DriverV2 struct {
 	azdiskClient         azdisk.Interface
 	crdClient            crdClientset.Interface
 	azDriverNodeInformer azdiskinformertypes.AzDriverNodeInformer
-	deviceChecker        *deviceChecker
+	azDriverNodeDetails  azDriverNodeDetails
+	// attachedDataDiskCount caches the number of data disks attached to the node's VM between heartbeats
+	attachedDataDiskCount attachedDataDiskCount
+	deviceChecker         *deviceChecker
+	// configLock guards reloadedConfig, the driver configuration most recently reloaded from the ConfigMap
+	configLock            sync.Mutex
+	reloadedConfig        *azdiskv1beta2.AzDiskDriverConfiguration
+	configResourceVersion string
+	sharedState           *controller.SharedState
+	filesystemFreezer     filesystemFreezer
 }
 
 func init() {
@@ -200,15 +229,18 @@ func (d *DriverV2) Run(endpoint, kubeconfig string, disableAVSetNodes, testingMo
 		azNodeInformer := azureutils.NewAzNodeInformer(azInformerFactory)
 		azVolumeAttachmentInformer := azureutils.NewAzVolumeAttachmentInformer(azInformerFactory)
 		azVolumeInformer := azureutils.NewAzVolumeInformer(azInformerFactory)
+		azSnapshotInformer := azureutils.NewAzSnapshotInformer(azInformerFactory)
+		azVolumeGroupSnapshotInformer := azureutils.NewAzVolumeGroupSnapshotInformer(azInformerFactory)
 		crdInformer := azureutils.NewCrdInformer(crdInformerFactory)
 
-		conditionWatcher, err := watcher.NewConditionWatcher(azInformerFactory, d.config.ObjectNamespace, azNodeInformer, azVolumeAttachmentInformer, azVolumeInformer)
+		conditionWatcher, err := watcher.NewConditionWatcher(azInformerFactory, d.config.ObjectNamespace, azNodeInformer, azVolumeAttachmentInformer, azVolumeInformer, azSnapshotInformer, azVolumeGroupSnapshotInformer)
 		if err != nil {
 			klog.Fatalf("Failed to create ConditionWatcher, error: %v. Exiting application...", err)
 		}
 
 		d.crdProvisioner, err = provisioner.NewCrdProvisioner(
 			d.azdiskClient,
+			d.kubeClient,
 			d.config,
 			conditionWatcher,
 			provisioner.NewCachedReader(kubeInformerFactory, azInformerFactory, d.config.ObjectNamespace),
@@ -217,7 +249,7 @@ func (d *DriverV2) Run(endpoint, kubeconfig string, disableAVSetNodes, testingMo
 			klog.Fatalf("Failed to create CrdProvisioner, error: %v. Exiting application...", err)
 		}
 
-		azureutils.StartInformersAndWaitForCacheSync(context.Background(), nodeInformer, azNodeInformer, azVolumeAttachmentInformer, azVolumeInformer, crdInformer)
+		azureutils.StartInformersAndWaitForCacheSync(context.Background(), nodeInformer, azNodeInformer, azVolumeAttachmentInformer, azVolumeInformer, azSnapshotInformer, azVolumeGroupSnapshotInformer, crdInformer)
 	}
 
 	// d.cloudProvisioner is set by NewFakeDriver for unit tests.
@@ -291,6 +323,10 @@ func (d *DriverV2) Run(endpoint, kubeconfig string, disableAVSetNodes, testingMo
 		csi.ControllerServiceCapability_RPC_CLONE_VOLUME,
 		csi.ControllerServiceCapability_RPC_EXPAND_VOLUME,
 		csi.ControllerServiceCapability_RPC_SINGLE_NODE_MULTI_WRITER,
+		csi.ControllerServiceCapability_RPC_GET_VOLUME,
+		csi.ControllerServiceCapability_RPC_VOLUME_CONDITION,
+		csi.ControllerServiceCapability_RPC_GET_CAPACITY,
+		csi.ControllerServiceCapability_RPC_MODIFY_VOLUME,
 	}
 	if d.config.ControllerConfig.EnableListVolumes {
 		controllerCap = append(controllerCap, csi.ControllerServiceCapability_RPC_LIST_VOLUMES, csi.ControllerServiceCapability_RPC_LIST_VOLUMES_PUBLISHED_NODES)
@@ -300,6 +336,9 @@ func (d *DriverV2) Run(endpoint, kubeconfig string, disableAVSetNodes, testingMo
 	}
 
 	d.AddControllerServiceCapabilities(controllerCap)
+	d.AddGroupControllerServiceCapabilities([]csi.GroupControllerServiceCapability_RPC_Type{
+		csi.GroupControllerServiceCapability_RPC_CREATE_DELETE_GET_VOLUME_GROUP_SNAPSHOT,
+	})
 	d.AddVolumeCapabilityAccessModes(
 		[]csi.VolumeCapability_AccessMode_Mode{
 			csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
@@ -314,6 +353,11 @@ func (d *DriverV2) Run(endpoint, kubeconfig string, disableAVSetNodes, testingMo
 		csi.NodeServiceCapability_RPC_SINGLE_NODE_MULTI_WRITER,
 	})
 
+	// Watch the driver configuration for changes which can be applied while the driver is running
+	if d.config.ConfigMapName != "" {
+		d.startDriverConfigWatcher(ctx)
+	}
+
 	// Start the controllers if this is a controller plug-in
 	if d.config.ControllerConfig.Enabled {
 		go d.StartControllersAndDieOnExit(ctx)
@@ -322,11 +366,13 @@ func (d *DriverV2) Run(endpoint, kubeconfig string, disableAVSetNodes, testingMo
 	// Register the AzDriverNode
 	if d.config.NodeConfig.Enabled {
 		d.registerAzDriverNodeOrDie(ctx)
+		// Freeze the filesystems of the volumes staged on the node for group snapshots
+		d.startFilesystemFreezeWatcherOrDie(ctx)
 	}
 
 	// Start the CSI endpoint/server
 	s := csicommon.NewNonBlockingGRPCServer()
-	// Driver d acts as IdentityServer, ControllerServer and NodeServer
+	// Driver d acts as IdentityServer, ControllerServer, GroupControllerServer and NodeServer
 	s.Start(endpoint, d, d, d, testingMock)
 
 	// Start sending heartbeat and mark node as ready
@@ -353,12 +399,23 @@ func (d *DriverV2) StartControllersAndDieOnExit(ctx context.Context) {
 	azdiskv1beta2.AddToScheme(scheme)
 	apiext.AddToScheme(scheme)
 
+	// When the volumes and nodes are sharded, every controller plugin instance reconciles the shards whose lease it holds
+	// instead of a single leader reconciling all of them.
+	var shardManager *controller.ShardManager
+	if d.config.ControllerConfig.ShardCount > 1 {
+		var err error
+		if shardManager, err = controller.NewShardManager(d.config, d.kubeClient, log); err != nil {
+			klog.Errorf("Unable to set up shard manager. Error: %v. Exiting application...", err)
+			os.Exit(1)
+		}
+	}
+
 	// Setup a Manager
 	klog.V(2).Info("Setting up controller manager")
 	mgr, err := manager.New(d.kubeConfig, manager.Options{
 		Scheme:                        scheme,
 		Logger:                        log,
-		LeaderElection:                true,
+		LeaderElection:                !shardManager.IsSharded(),
 		LeaderElectionResourceLock:    resourcelock.LeasesResourceLock,
 		LeaderElectionNamespace:       d.config.ControllerConfig.LeaderElectionNamespace,
 		LeaderElectionID:              d.config.ControllerConfig.PartitionName,
@@ -366,7 +423,9 @@ func (d *DriverV2) StartControllersAndDieOnExit(ctx context.Context) {
 		RenewDeadline:                 &renewDeadline,
 		RetryPeriod:                   &retryPeriod,
 		LeaderElectionReleaseOnCancel: true,
-		MetricsBindAddress:            ":8090"})
+		MetricsBindAddress:            ":8090",
+		Port:                          d.config.ControllerConfig.WebhookPort,
+		CertDir:                       d.config.ControllerConfig.WebhookCertDir})
 	if err != nil {
 		klog.Errorf("Unable to set up overall controller manager. Error: %v. Exiting application...", err)
 		os.Exit(1)
@@ -377,7 +436,40 @@ func (d *DriverV2) StartControllersAndDieOnExit(ctx context.Context) {
 	eventBroadcaster.StartRecordingToSink(&v1core.EventSinkImpl{Interface: d.kubeClient.CoreV1().Events("")})
 	eventRecorder := eventBroadcaster.NewRecorder(clientgoscheme.Scheme, v1.EventSource{Component: consts.AzureDiskCSIDriverName})
 
+	if err := controller.ValidatePlacementProfile(d.config.ControllerConfig.PlacementProfile); err != nil {
+		klog.Errorf("Invalid replica placement profile. Error: %v. Exiting application...", err)
+		os.Exit(1)
+	}
+
 	sharedState := controller.NewSharedState(d.config, topologyKey, eventRecorder, mgr.GetClient(), d.crdClient, d.kubeClient, d.crdProvisioner)
+	sharedState.SetShardManager(shardManager)
+	d.setSharedState(sharedState)
+
+	// In dry-run mode, the controllers record the operations they would perform instead of performing them.
+	var cloudProvisioner controller.CloudProvisioner = d.cloudProvisioner
+	if d.config.ControllerConfig.DryRun {
+		klog.V(2).Info("Running controllers in dry-run mode")
+		if err := sharedState.EnableDryRun(d.kubeConfig); err != nil {
+			klog.Errorf("Failed to enable dry-run mode. Error: %v. Exiting application...", err)
+			os.Exit(1)
+		}
+		cloudProvisioner = controller.NewDryRunCloudProvisioner(d.cloudProvisioner, mgr.GetLogger())
+	}
+
+	// Inject the configured faults into the cloud operations of the controllers to exercise their failure handling.
+	if configPath := d.config.ControllerConfig.FaultInjectionConfigPath; configPath != "" {
+		injector, err := faultinjection.NewInjectorFromFile(configPath)
+		if err != nil {
+			klog.Errorf("Failed to load fault-injection config. Error: %v. Exiting application...", err)
+			os.Exit(1)
+		}
+		klog.V(2).Infof("Injecting faults from %s into cloud operations", configPath)
+		if cloud := d.cloudProvisioner.GetCloud(); cloud != nil {
+			injector.InstallClients(cloud)
+		}
+		cloudProvisioner = controller.NewFaultInjectionCloudProvisioner(cloudProvisioner, injector)
+		go injector.Run(ctx, consts.FaultInjectionConfigReloadInterval)
+	}
 
 	// Setup a new controller to clean-up AzDriverNodes
 	// objects for the nodes which get deleted
@@ -388,7 +480,7 @@ func (d *DriverV2) StartControllersAndDieOnExit(ctx context.Context) {
 	}
 
 	klog.V(2).Info("Initializing AzVolumeAttachment controller")
-	attachReconciler, err := controller.NewAttachDetachController(mgr, d.cloudProvisioner, d.crdProvisioner, sharedState)
+	attachReconciler, err := controller.NewAttachDetachController(mgr, cloudProvisioner, d.crdProvisioner, sharedState)
 	if err != nil {
 		klog.Fatalf("Failed to initialize AzVolumeAttachmentController. Error: %v. Exiting application...", err)
 	}
@@ -406,11 +498,29 @@ func (d *DriverV2) StartControllersAndDieOnExit(ctx context.Context) {
 	}
 
 	klog.V(2).Info("Initializing AzVolume controller")
-	azvReconciler, err := controller.NewAzVolumeController(mgr, d.cloudProvisioner, sharedState)
+	azvReconciler, err := controller.NewAzVolumeController(mgr, cloudProvisioner, sharedState)
 	if err != nil {
 		klog.Fatalf("Failed to initialize AzVolumeController. Error: %v. Exiting application...", err)
 	}
 
+	klog.V(2).Info("Initializing AzSnapshot controller")
+	azsReconciler, err := controller.NewAzSnapshotController(mgr, cloudProvisioner, sharedState)
+	if err != nil {
+		klog.Fatalf("Failed to initialize AzSnapshotController. Error: %v. Exiting application...", err)
+	}
+
+	klog.V(2).Info("Initializing AzVolumeGroupSnapshot controller")
+	azvgsReconciler, err := controller.NewAzVolumeGroupSnapshotController(mgr, cloudProvisioner, sharedState)
+	if err != nil {
+		klog.Fatalf("Failed to initialize AzVolumeGroupSnapshotController. Error: %v. Exiting application...", err)
+	}
+
+	klog.V(2).Info("Initializing AzSnapshotPolicy controller")
+	_, err = controller.NewAzSnapshotPolicyController(mgr, sharedState)
+	if err != nil {
+		klog.Fatalf("Failed to initialize AzSnapshotPolicyController. Error: %v. Exiting application...", err)
+	}
+
 	klog.V(2).Info("Initializing PV controller")
 	pvReconciler, err := controller.NewPVController(mgr, sharedState)
 	if err != nil {
@@ -421,46 +531,132 @@ func (d *DriverV2) StartControllersAndDieOnExit(ctx context.Context) {
 	if err != nil {
 		klog.Fatalf("Failed to initialize VolumeAttachment Controller. Error: %v. Exiting application...", err)
 	}
+	klog.V(2).Info("Initializing dangling attachment detector")
+	_, err = controller.NewDanglingAttachmentDetector(mgr, cloudProvisioner, sharedState)
+	if err != nil {
+		klog.Fatalf("Failed to initialize dangling attachment detector. Error: %v. Exiting application...", err)
+	}
+	klog.V(2).Info("Initializing orphaned disk collector")
+	_, err = controller.NewOrphanedDiskCollector(mgr, cloudProvisioner, sharedState)
+	if err != nil {
+		klog.Fatalf("Failed to initialize orphaned disk collector. Error: %v. Exiting application...", err)
+	}
+	klog.V(2).Info("Initializing state checkpointer")
+	_, err = controller.NewStateCheckpointer(mgr, sharedState)
+	if err != nil {
+		klog.Fatalf("Failed to initialize state checkpointer. Error: %v. Exiting application...", err)
+	}
 	err = controller.WatchObject(mgr, source.Kind{Type: &v1.Namespace{}})
 	if err != nil {
 		klog.Fatalf("Failed to watch Namespace. Error: %v. Exiting application...", err)
 	}
 
-	// This goroutine is preserved for leader controller manager
-	// Leader controller manager should recover CRI if possible and clean them up before exiting.
-	go func() {
-		<-mgr.Elected()
-		var errors []error
-		ctx, w := workflow.New(ctx)
-		defer func() { w.Finish(errors...) }()
-
-		// Use unique recoveryID as the Recover Annotation, so if it recovers more than once(for example: driver restarts or upgrades)
-		// the objects can still get a new Recover Annotation to force register an UPDATE event for reconciliation.
-		recoveryID := uuid.NewUUID().String()
+	// The webhooks are served by every controller plugin instance regardless of leadership.
+	if d.config.ControllerConfig.EnableConversionWebhook {
+		klog.V(2).Infof("Registering CRD conversion webhook at %s", controller.ConversionWebhookPath)
+		mgr.GetWebhookServer().Register(controller.ConversionWebhookPath, controller.NewConversionWebhook())
+	}
+	if d.config.ControllerConfig.EnableAdmissionWebhook {
+		klog.V(2).Info("Registering AzVolume admission webhooks")
+		controller.RegisterAzVolumeWebhook(mgr, d.cloudProvisioner.GetCloud())
+	}
 
-		// recover lost states if necessary
-		w.Logger().Infof("Elected as leader; initiating CRI deprecation / recovery with recoveryID: %s ...", recoveryID)
+	// recoverCRIs recovers the CRIs owned by this controller plugin instance in the shards in the scope of the context.
+	recoverCRIs := func(ctx context.Context, recoveryID string) []error {
+		var errors []error
 		if err := nodeReconciler.Recover(ctx, recoveryID); err != nil {
 			errors = append(errors, err)
 		}
 		if err := azvReconciler.Recover(ctx, recoveryID); err != nil {
 			errors = append(errors, err)
 		}
+		if err := azsReconciler.Recover(ctx, recoveryID); err != nil {
+			errors = append(errors, err)
+		}
+		if err := azvgsReconciler.Recover(ctx, recoveryID); err != nil {
+			errors = append(errors, err)
+		}
 		if err := attachReconciler.Recover(ctx, recoveryID); err != nil {
 			errors = append(errors, err)
 		}
+		return errors
+	}
+
+	// recoverState recovers the CRIs owned by this controller plugin instance and the shared state of the controllers.
+	recoverState := func(ctx context.Context, recoveryID string) []error {
+		errors := recoverCRIs(ctx, recoveryID)
 		if err := pvReconciler.Recover(ctx); err != nil {
 			errors = append(errors, err)
 		}
 		if err := podReconciler.Recover(ctx); err != nil {
 			errors = append(errors, err)
 		}
-		if err := sharedState.DeleteAPIVersion(ctx, consts.V1beta1); err != nil {
-			errors = append(errors, err)
+		return errors
+	}
+	recoveryComplete := make(chan struct{})
+
+	// This goroutine is preserved for leader controller manager
+	// Leader controller manager should recover CRI if possible and clean them up before exiting.
+	go func() {
+		<-mgr.Elected()
+		var errors []error
+		ctx, w := workflow.New(ctx)
+		defer func() { w.Finish(errors...) }()
+
+		// Use unique recoveryID as the Recover Annotation, so if it recovers more than once(for example: driver restarts or upgrades)
+		// the objects can still get a new Recover Annotation to force register an UPDATE event for reconciliation.
+		recoveryID := uuid.NewUUID().String()
+
+		// recover lost states if necessary
+		w.Logger().Infof("Elected as leader; initiating CRI deprecation / recovery with recoveryID: %s ...", recoveryID)
+		// only the changes made since the checkpoint of the previous leader need to be replayed
+		if err := sharedState.LoadCheckpoint(ctx); err != nil {
+			w.Logger().Errorf(err, "failed to load shared state checkpoint; recovering the shared state from scratch")
+		}
+		errors = recoverState(ctx, recoveryID)
+		// when sharded, the API version is deleted by the owner of the coordinator shard
+		if !shardManager.IsSharded() {
+			if err := sharedState.DeleteAPIVersion(ctx, consts.V1beta1); err != nil {
+				errors = append(errors, err)
+			}
 		}
 		sharedState.MarkRecoveryComplete()
+		close(recoveryComplete)
 	}()
 
+	if shardManager.IsSharded() {
+		// Recover the CRIs of a shard when its lease is acquired so that they are reconciled by the new owner.
+		shardManager.SetShardAcquiredHandler(func(ctx context.Context, shard int) {
+			select {
+			case <-recoveryComplete:
+			case <-ctx.Done():
+				return
+			}
+
+			var errors []error
+			ctx, w := workflow.New(ctx)
+			defer func() { w.Finish(errors...) }()
+
+			recoveryID := uuid.NewUUID().String()
+			w.Logger().Infof("Acquired shard %d; initiating CRI recovery with recoveryID: %s ...", shard, recoveryID)
+			// the shared state tracks the objects of all shards since the initial recovery, so only the CRIs and
+			// the replicas of the acquired shard are recovered
+			ctx = controller.WithShardScope(ctx, shard)
+			errors = recoverCRIs(ctx, recoveryID)
+			if err := podReconciler.Recover(ctx); err != nil {
+				errors = append(errors, err)
+			}
+			if shard == controller.CoordinatorShard {
+				if err := sharedState.DeleteAPIVersion(ctx, consts.V1beta1); err != nil {
+					errors = append(errors, err)
+				}
+			}
+		})
+		if err := mgr.Add(shardManager); err != nil {
+			klog.Fatalf("Failed to add shard manager. Error: %v. Exiting application...", err)
+		}
+	}
+
 	klog.V(2).Info("Starting controller manager")
 	if err := mgr.Start(ctx); err != nil {
 		klog.Fatalf("Controller manager exited: %v", err)
@@ -478,6 +674,8 @@ func (d *DriverV2) registerAzDriverNodeOrDie(ctx context.Context) {
 		klog.Fatalf("cannot create azdrivernode because node id is not provided")
 	}
 
+	d.azDriverNodeDetails = d.getAzDriverNodeDetails(ctx)
+
 	nodeSelector := fmt.Sprintf("metadata.name=%s", d.NodeID)
 
 	azdiskInformerFactory := azdiskinformers.NewSharedInformerFactoryWithOptions(
@@ -521,9 +719,8 @@ func (d *DriverV2) runAzDriverNodeHeartbeatLoop(ctx context.Context) {
 	// To prevent a regular update storm when lots of nodes start around the same time,
 	// delay a random interval within the configured heartbeat frequency before starting
 	// the timed loop.
-	heartbeatFrequencyMillis := d.config.NodeConfig.HeartbeatFrequencyInSec * 1000
-	initialDelay := time.Duration(rand.Int63n(int64(heartbeatFrequencyMillis))) * time.Millisecond
-	heartbeatFrequency := time.Duration(heartbeatFrequencyMillis) * time.Millisecond
+	heartbeatFrequency := d.getHeartbeatFrequency()
+	initialDelay := time.Duration(rand.Int63n(heartbeatFrequency.Milliseconds())) * time.Millisecond
 
 	klog.V(1).Infof("Starting heartbeat loop with initial delay %v and frequency %v", initialDelay, heartbeatFrequency)
 
@@ -541,6 +738,13 @@ func (d *DriverV2) runAzDriverNodeHeartbeatLoop(ctx context.Context) {
 		// Loop and update heartbeat at update frequency
 		case <-ticker.C:
 			_ = d.updateAzDriverNodeHeartbeat(ctx)
+
+			// Pick up any change of the heartbeat frequency made while the driver is running
+			if frequency := d.getHeartbeatFrequency(); frequency != heartbeatFrequency {
+				klog.V(1).Infof("Changing heartbeat frequency from %v to %v", heartbeatFrequency, frequency)
+				heartbeatFrequency = frequency
+				ticker.Reset(heartbeatFrequency)
+			}
 		}
 	}
 }
@@ -607,6 +811,11 @@ func (d *DriverV2) updateOrCreateAzDriverNode(ctx context.Context, status azDriv
 		ReadyForVolumeAllocation: &readyForAllocation,
 		StatusMessage:            &statusMessage,
 		LastHeartbeatTime:        &lastHeartbeatTime,
+		MaxDataDiskCount:         d.azDriverNodeDetails.maxDataDiskCount,
+		AttachedDataDiskCount:    d.getAttachedDataDiskCount(ctx),
+		InstanceType:             d.azDriverNodeDetails.instanceType,
+		Zone:                     d.azDriverNodeDetails.zone,
+		FaultDomain:              d.azDriverNodeDetails.faultDomain,
 	}
 
 	if _, err := azDriverNodes.UpdateStatus(ctx, thisNode, metav1.UpdateOptions{}); err != nil {
@@ -617,6 +826,96 @@ func (d *DriverV2) updateOrCreateAzDriverNode(ctx context.Context, status azDriv
 	return nil
 }
 
+// getAzDriverNodeDetails gets the instance type, zone, fault domain and data disk limit of the node's VM from the
+// instance metadata service, falling back to the node labels if the instance metadata is not available.
+func (d *DriverV2) getAzDriverNodeDetails(ctx context.Context) azDriverNodeDetails {
+	var details azDriverNodeDetails
+	var instanceType, zone, faultDomain string
+
+	cloud := d.cloudProvisioner.GetCloud()
+	if cloud == nil {
+		return details
+	}
+
+	if cloud.UseInstanceMetadata && cloud.Metadata != nil {
+		metadata, err := cloud.Metadata.GetMetadata(azcache.CacheReadTypeDefault)
+		if err == nil && metadata.Compute != nil {
+			instanceType = metadata.Compute.VMSize
+			faultDomain = metadata.Compute.FaultDomain
+			if metadata.Compute.Zone != "" {
+				zone = fmt.Sprintf("%s-%s", strings.ToLower(metadata.Compute.Location), metadata.Compute.Zone)
+			}
+		} else {
+			klog.Warningf("failed to get instance metadata of node(%s): %v", d.NodeID, err)
+		}
+	}
+
+	if instanceType == "" {
+		failureDomainFromLabels, instanceTypeFromLabels, err := getNodeInfoFromLabels(ctx, d.NodeID, cloud.KubeClient)
+		if err != nil {
+			klog.Warningf("getNodeInfoFromLabels on node(%s) failed with %v", d.NodeID, err)
+		}
+		instanceType = instanceTypeFromLabels
+		// the failure domain label holds the zone of zonal nodes and the fault domain of all other nodes
+		if azureutils.IsValidAvailabilityZone(failureDomainFromLabels, cloud.Location) {
+			zone = failureDomainFromLabels
+		} else if faultDomain == "" {
+			faultDomain = failureDomainFromLabels
+		}
+	}
+
+	maxDataDiskCount := d.VolumeAttachLimit
+	if maxDataDiskCount < 0 {
+		maxDataDiskCount = getMaxDataDiskCount(instanceType)
+	}
+	details.maxDataDiskCount = pointer.Int32(int32(maxDataDiskCount))
+
+	if instanceType != "" {
+		details.instanceType = &instanceType
+	}
+	if zone != "" {
+		details.zone = &zone
+	}
+	if faultDomain != "" {
+		details.faultDomain = &faultDomain
+	}
+
+	klog.V(2).Infof("node(%s) details: instanceType(%s), zone(%s), faultDomain(%s), maxDataDiskCount(%d)", d.NodeID, instanceType, zone, faultDomain, maxDataDiskCount)
+
+	return details
+}
+
+// getAttachedDataDiskCount returns the number of data disks currently attached to the node's VM, including the disks
+// not managed by the driver, or nil if the VM could not be queried. To keep the heartbeats from adding ARM read load
+// proportional to the cluster size, the VM is queried at most once per AttachedDataDiskCountRefreshInterval and the
+// count is reused by the heartbeats in between.
+func (d *DriverV2) getAttachedDataDiskCount(ctx context.Context) *int32 {
+	logger := logr.FromContextOrDiscard(ctx)
+
+	d.attachedDataDiskCount.lock.Lock()
+	defer d.attachedDataDiskCount.lock.Unlock()
+
+	if !d.attachedDataDiskCount.refreshTime.IsZero() && time.Since(d.attachedDataDiskCount.refreshTime) < consts.AttachedDataDiskCountRefreshInterval {
+		return d.attachedDataDiskCount.count
+	}
+
+	cloud := d.cloudProvisioner.GetCloud()
+	if cloud == nil || cloud.VMSet == nil {
+		return nil
+	}
+
+	// the previous count is kept until the next refresh if the VM cannot be queried
+	d.attachedDataDiskCount.refreshTime = time.Now()
+	dataDisks, _, err := cloud.VMSet.GetDataDisks(types.NodeName(d.NodeID), azcache.CacheReadTypeDefault)
+	if err != nil {
+		logger.Error(err, "Failed to get data disks attached to node")
+		return d.attachedDataDiskCount.count
+	}
+
+	d.attachedDataDiskCount.count = pointer.Int32(int32(len(dataDisks)))
+	return d.attachedDataDiskCount.count
+}
+
 func (d *DriverV2) isPerfOptimizationEnabled() bool {
 	return d.config.NodeConfig.Enabled && d.config.NodeConfig.EnablePerfOptimization
 }
@@ -25,15 +25,26 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-03-01/compute"
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
+	fakek8s "k8s.io/client-go/kubernetes/fake"
 	k8stesting "k8s.io/client-go/testing"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/pointer"
 	azdiskfakes "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/client/clientset/versioned/fake"
+	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils/fakecompute"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azureclients/vmclient/mockvmclient"
 )
 
 var osExitErr = errors.New("OSExit called")
@@ -114,6 +125,7 @@ func TestRegisterAzDriverNodeOrDie(t *testing.T) {
 
 			d, err := newFakeDriverV2(t)
 			assert.NoError(t, err)
+			setupFakeNodeVM(d)
 
 			cleanUpFn := test.setupFn(t, d)
 			defer cleanUpFn()
@@ -163,6 +175,7 @@ func TestRunAzDriverNodeHeartbeatLoop(t *testing.T) {
 
 			d, err := newFakeDriverV2(t)
 			assert.NoError(t, err)
+			setupFakeNodeVM(d)
 
 			d.registerAzDriverNodeOrDie(regCtx)
 
@@ -197,4 +210,165 @@ func verifyAzDriverNode(t *testing.T, d *fakeDriverV2, expectedStatusMessage str
 	require.NotNil(t, azDriverNode.Status.ReadyForVolumeAllocation)
 	assert.Equal(t, expectedReadyForAllocation, *azDriverNode.Status.ReadyForVolumeAllocation)
 	require.NotNil(t, azDriverNode.Status.LastHeartbeatTime)
+	assert.Equal(t, pointer.Int32(4), azDriverNode.Status.MaxDataDiskCount)
+	assert.Equal(t, pointer.Int32(2), azDriverNode.Status.AttachedDataDiskCount)
+	assert.Equal(t, pointer.String("Standard_D2s_v3"), azDriverNode.Status.InstanceType)
+	assert.Equal(t, pointer.String("westus-1"), azDriverNode.Status.Zone)
+	assert.Nil(t, azDriverNode.Status.FaultDomain)
+}
+
+// setupFakeNodeVM labels the fake node with its zone and instance type and attaches two data disks to its VM.
+func setupFakeNodeVM(d *fakeDriverV2) {
+	d.getCloud().KubeClient = fakek8s.NewSimpleClientset(&v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: d.NodeID,
+			Labels: map[string]string{
+				consts.WellKnownTopologyKey: "westus-1",
+				consts.InstanceTypeKey:      "Standard_D2s_v3",
+			},
+		},
+	})
+
+	dataDisks := []compute.DataDisk{
+		{Lun: pointer.Int32(0), Name: pointer.String("disk-0")},
+		{Lun: pointer.Int32(1), Name: pointer.String("disk-1")},
+	}
+	vm := compute.VirtualMachine{
+		Name:     &d.NodeID,
+		Location: pointer.String("westus"),
+		VirtualMachineProperties: &compute.VirtualMachineProperties{
+			StorageProfile: &compute.StorageProfile{DataDisks: &dataDisks},
+		},
+	}
+	mockVMsClient := d.getCloud().VirtualMachinesClient.(*mockvmclient.MockInterface)
+	mockVMsClient.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(vm, nil).AnyTimes()
+}
+
+func TestGetAttachedDataDiskCount(t *testing.T) {
+	d, err := newFakeDriverV2(t)
+	require.NoError(t, err)
+
+	// the count queried within the refresh interval is reused without querying the VM
+	d.attachedDataDiskCount.count = pointer.Int32(3)
+	d.attachedDataDiskCount.refreshTime = time.Now()
+	assert.Equal(t, pointer.Int32(3), d.getAttachedDataDiskCount(context.TODO()))
+
+	// the VM is queried once the refresh interval has passed
+	setupFakeNodeVM(d)
+	d.attachedDataDiskCount.refreshTime = time.Now().Add(-consts.AttachedDataDiskCountRefreshInterval)
+	assert.Equal(t, pointer.Int32(2), d.getAttachedDataDiskCount(context.TODO()))
+	assert.WithinDuration(t, time.Now(), d.attachedDataDiskCount.refreshTime, time.Minute)
+}
+
+func TestDriverV2WithFakeComputeBackend(t *testing.T) {
+	backend := fakecompute.NewBackend("subscription", "westus")
+	d, err := newFakeDriverV2WithBackend(t, backend)
+	require.NoError(t, err)
+	backend.AddVirtualMachine(d.getCloud().ResourceGroup, d.NodeID, "Standard_D2s_v3")
+
+	volumeCapabilities := []*csi.VolumeCapability{
+		{
+			AccessType: &csi.VolumeCapability_Mount{Mount: &csi.VolumeCapability_MountVolume{}},
+			AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER},
+		},
+	}
+
+	createResponse, err := d.CreateVolume(context.TODO(), &csi.CreateVolumeRequest{
+		Name:               "fake-compute-disk",
+		CapacityRange:      &csi.CapacityRange{RequiredBytes: 10 << 30},
+		VolumeCapabilities: volumeCapabilities,
+	})
+	require.NoError(t, err)
+	volumeID := createResponse.GetVolume().GetVolumeId()
+	_, found := backend.GetDisk(volumeID)
+	require.True(t, found)
+
+	publishResponse, err := d.ControllerPublishVolume(context.TODO(), &csi.ControllerPublishVolumeRequest{
+		VolumeId:         volumeID,
+		NodeId:           d.NodeID,
+		VolumeCapability: volumeCapabilities[0],
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, publishResponse.GetPublishContext()[consts.LUN])
+	dataDisks, _ := backend.GetDataDisks(d.NodeID)
+	assert.Len(t, dataDisks, 1)
+
+	_, err = d.ControllerUnpublishVolume(context.TODO(), &csi.ControllerUnpublishVolumeRequest{VolumeId: volumeID, NodeId: d.NodeID})
+	require.NoError(t, err)
+	dataDisks, _ = backend.GetDataDisks(d.NodeID)
+	assert.Empty(t, dataDisks)
+
+	_, err = d.DeleteVolume(context.TODO(), &csi.DeleteVolumeRequest{VolumeId: volumeID})
+	require.NoError(t, err)
+	_, found = backend.GetDisk(volumeID)
+	assert.False(t, found)
+}
+
+func TestDriverV2VolumeGroupSnapshotWithFakeComputeBackend(t *testing.T) {
+	backend := fakecompute.NewBackend("subscription", "westus")
+	d, err := newFakeDriverV2WithBackend(t, backend)
+	require.NoError(t, err)
+
+	volumeCapabilities := []*csi.VolumeCapability{
+		{
+			AccessType: &csi.VolumeCapability_Mount{Mount: &csi.VolumeCapability_MountVolume{}},
+			AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER},
+		},
+	}
+
+	volumeIDs := []string{}
+	for _, name := range []string{"fake-compute-data-disk", "fake-compute-log-disk"} {
+		createResponse, err := d.CreateVolume(context.TODO(), &csi.CreateVolumeRequest{
+			Name:               name,
+			CapacityRange:      &csi.CapacityRange{RequiredBytes: 10 << 30},
+			VolumeCapabilities: volumeCapabilities,
+		})
+		require.NoError(t, err)
+		volumeIDs = append(volumeIDs, createResponse.GetVolume().GetVolumeId())
+	}
+
+	capabilitiesResponse, err := d.GroupControllerGetCapabilities(context.TODO(), &csi.GroupControllerGetCapabilitiesRequest{})
+	require.NoError(t, err)
+	require.Len(t, capabilitiesResponse.GetCapabilities(), 1)
+	assert.Equal(t, csi.GroupControllerServiceCapability_RPC_CREATE_DELETE_GET_VOLUME_GROUP_SNAPSHOT, capabilitiesResponse.GetCapabilities()[0].GetRpc().GetType())
+
+	_, err = d.CreateVolumeGroupSnapshot(context.TODO(), &csi.CreateVolumeGroupSnapshotRequest{Name: "fake-compute-group-snapshot"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	createResponse, err := d.CreateVolumeGroupSnapshot(context.TODO(), &csi.CreateVolumeGroupSnapshotRequest{
+		Name:            "fake-compute-group-snapshot",
+		SourceVolumeIds: volumeIDs,
+	})
+	require.NoError(t, err)
+	groupSnapshot := createResponse.GetGroupSnapshot()
+	require.Len(t, groupSnapshot.GetSnapshots(), 2)
+	snapshotIDs := []string{}
+	for i, snapshot := range groupSnapshot.GetSnapshots() {
+		assert.Equal(t, volumeIDs[i], snapshot.GetSourceVolumeId())
+		assert.Equal(t, groupSnapshot.GetGroupSnapshotId(), snapshot.GetGroupSnapshotId())
+		snapshotIDs = append(snapshotIDs, snapshot.GetSnapshotId())
+	}
+
+	getResponse, err := d.GetVolumeGroupSnapshot(context.TODO(), &csi.GetVolumeGroupSnapshotRequest{
+		GroupSnapshotId: groupSnapshot.GetGroupSnapshotId(),
+		SnapshotIds:     snapshotIDs,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, groupSnapshot.GetGroupSnapshotId(), getResponse.GetGroupSnapshot().GetGroupSnapshotId())
+	assert.Len(t, getResponse.GetGroupSnapshot().GetSnapshots(), 2)
+
+	_, err = d.GetVolumeGroupSnapshot(context.TODO(), &csi.GetVolumeGroupSnapshotRequest{
+		GroupSnapshotId: groupSnapshot.GetGroupSnapshotId(),
+		SnapshotIds:     snapshotIDs[:1],
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = d.DeleteVolumeGroupSnapshot(context.TODO(), &csi.DeleteVolumeGroupSnapshotRequest{
+		GroupSnapshotId: groupSnapshot.GetGroupSnapshotId(),
+		SnapshotIds:     snapshotIDs,
+	})
+	require.NoError(t, err)
+
+	_, err = d.GetVolumeGroupSnapshot(context.TODO(), &csi.GetVolumeGroupSnapshotRequest{GroupSnapshotId: groupSnapshot.GetGroupSnapshotId()})
+	assert.Equal(t, codes.NotFound, status.Code(err))
 }
@@ -26,6 +26,7 @@ import (
 
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-03-01/compute"
 	"github.com/container-storage-interface/spec/lib/go/csi"
+	wrappers "github.com/golang/protobuf/ptypes/wrappers"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -341,8 +342,41 @@ func (d *Driver) DeleteVolume(ctx context.Context, req *csi.DeleteVolumeRequest)
 }
 
 // ControllerGetVolume get volume
-func (d *Driver) ControllerGetVolume(context.Context, *csi.ControllerGetVolumeRequest) (*csi.ControllerGetVolumeResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "")
+func (d *Driver) ControllerGetVolume(ctx context.Context, req *csi.ControllerGetVolumeRequest) (*csi.ControllerGetVolumeResponse, error) {
+	if err := d.ValidateControllerServiceRequest(csi.ControllerServiceCapability_RPC_GET_VOLUME); err != nil {
+		return nil, err
+	}
+
+	diskURI := req.GetVolumeId()
+	if len(diskURI) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Volume ID not provided")
+	}
+
+	mc := metrics.NewMetricContext(consts.AzureDiskCSIDriverName, "controller_get_volume", d.cloud.ResourceGroup, d.cloud.SubscriptionID, d.Name)
+	isOperationSucceeded := false
+	defer func() {
+		mc.ObserveOperationWithResult(isOperationSucceeded, consts.VolumeID, diskURI)
+	}()
+
+	volumeEntry, err := azureutils.GetDiskVolumeEntry(ctx, d.cloud, diskURI)
+	if err != nil {
+		return nil, err
+	}
+
+	isOperationSucceeded = true
+	return &csi.ControllerGetVolumeResponse{
+		Volume: &csi.Volume{
+			VolumeId:      volumeEntry.Details.VolumeID,
+			CapacityBytes: volumeEntry.Details.CapacityBytes,
+		},
+		Status: &csi.ControllerGetVolumeResponse_VolumeStatus{
+			PublishedNodeIds: volumeEntry.Status.PublishedNodeIds,
+			VolumeCondition: &csi.VolumeCondition{
+				Abnormal: volumeEntry.Status.Condition.Abnormal,
+				Message:  volumeEntry.Status.Condition.Message,
+			},
+		},
+	}, nil
 }
 
 // ControllerPublishVolume attach an azure disk to a required node
@@ -541,9 +575,44 @@ func (d *Driver) ControllerGetCapabilities(ctx context.Context, req *csi.Control
 	}, nil
 }
 
-// GetCapacity returns the capacity of the total available storage pool
+// GetCapacity returns the capacity available for new disks in the requested topology segment based on the subscription disk quota
 func (d *Driver) GetCapacity(ctx context.Context, req *csi.GetCapacityRequest) (*csi.GetCapacityResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "")
+	if err := d.ValidateControllerServiceRequest(csi.ControllerServiceCapability_RPC_GET_CAPACITY); err != nil {
+		return nil, err
+	}
+
+	diskParams, err := azureutils.ParseDiskParameters(req.GetParameters(), azureutils.IgnoreUnknown)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Failed parsing disk parameters: %v", err)
+	}
+
+	skuName, err := azureutils.NormalizeStorageAccountType(diskParams.AccountType, d.cloud.Config.Cloud, d.cloud.Config.DisableAzureStackCloud)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if diskParams.Location == "" {
+		diskParams.Location = d.cloud.Location
+	}
+	zone := azureutils.GetZoneFromTopologySegments(req.GetAccessibleTopology().GetSegments(), topologyKey)
+
+	mc := metrics.NewMetricContext(consts.AzureDiskCSIDriverName, "controller_get_capacity", d.cloud.ResourceGroup, d.cloud.SubscriptionID, d.Name)
+	isOperationSucceeded := false
+	defer func() {
+		mc.ObserveOperationWithResult(isOperationSucceeded)
+	}()
+
+	availableCapacity, maximumVolumeSize, err := azureutils.GetDiskCapacity(d.diskUsageCache, skuName, diskParams.Location, zone, int64(diskParams.CapacityDiskSizeGiB))
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to get disk usages in location(%s): %v", diskParams.Location, err)
+	}
+	klog.V(5).Infof("available capacity for sku(%s) in location(%s) zone(%s) is %d bytes, maximum volume size is %d bytes", skuName, diskParams.Location, zone, availableCapacity, maximumVolumeSize)
+
+	isOperationSucceeded = true
+	return &csi.GetCapacityResponse{
+		AvailableCapacity: availableCapacity,
+		MaximumVolumeSize: &wrappers.Int64Value{Value: maximumVolumeSize},
+	}, nil
 }
 
 // ListVolumes return all available volumes
@@ -806,6 +875,11 @@ func (d *Driver) ControllerExpandVolume(ctx context.Context, req *csi.Controller
 	}, nil
 }
 
+// ControllerModifyVolume is not supported by the v1 driver
+func (d *Driver) ControllerModifyVolume(ctx context.Context, req *csi.ControllerModifyVolumeRequest) (*csi.ControllerModifyVolumeResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "ControllerModifyVolume is not supported")
+}
+
 // CreateSnapshot create a snapshot
 func (d *Driver) CreateSnapshot(ctx context.Context, req *csi.CreateSnapshotRequest) (*csi.CreateSnapshotResponse, error) {
 	sourceVolumeID := req.GetSourceVolumeId()
@@ -39,6 +39,8 @@ import (
 	"sigs.k8s.io/azuredisk-csi-driver/pkg/azuredisk/mockcorev1"
 	"sigs.k8s.io/azuredisk-csi-driver/pkg/azuredisk/mockkubeclient"
 	"sigs.k8s.io/azuredisk-csi-driver/pkg/azuredisk/mockpersistentvolume"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/azuredisk/mockprovisioner"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils/mockusageclient"
 	volumehelper "sigs.k8s.io/azuredisk-csi-driver/pkg/util"
 	"sigs.k8s.io/azuredisk-csi-driver/test/utils/testutil"
 	"sigs.k8s.io/cloud-provider-azure/pkg/azureclients/diskclient/mockdiskclient"
@@ -618,15 +620,89 @@ func TestDeleteVolume(t *testing.T) {
 }
 
 func TestControllerGetVolume(t *testing.T) {
-	d, err := NewFakeDriver(t)
-	if err != nil {
-		t.Fatalf("Error getting driver: %v", err)
+	testVMID := fmt.Sprintf("/subscriptions/subs/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/%s", testVMName)
+	succeeded, failed := "Succeeded", consts.ProvisioningStateFailed
+	diskSizeGB := int32(10)
+
+	tests := []struct {
+		desc             string
+		req              *csi.ControllerGetVolumeRequest
+		disk             *compute.Disk
+		rerr             *retry.Error
+		expectedErrCode  codes.Code
+		expectedNodes    []string
+		expectedAbnormal bool
+	}{
+		{
+			desc:            "fail with no volume id",
+			req:             &csi.ControllerGetVolumeRequest{},
+			expectedErrCode: codes.InvalidArgument,
+		},
+		{
+			desc:            "fail with the invalid diskURI",
+			req:             &csi.ControllerGetVolumeRequest{VolumeId: "123"},
+			expectedErrCode: codes.InvalidArgument,
+		},
+		{
+			desc:            "fail when the disk does not exist",
+			req:             &csi.ControllerGetVolumeRequest{VolumeId: testVolumeID},
+			rerr:            &retry.Error{RawError: fmt.Errorf(consts.ResourceNotFound)},
+			expectedErrCode: codes.NotFound,
+		},
+		{
+			desc: "success with an attached disk",
+			req:  &csi.ControllerGetVolumeRequest{VolumeId: testVolumeID},
+			disk: &compute.Disk{
+				ID:        &testVolumeID,
+				ManagedBy: &testVMID,
+				DiskProperties: &compute.DiskProperties{
+					DiskSizeGB:        &diskSizeGB,
+					DiskState:         compute.Attached,
+					ProvisioningState: &succeeded,
+				},
+			},
+			expectedNodes: []string{testVMName},
+		},
+		{
+			desc: "success with an abnormal condition when disk provisioning failed",
+			req:  &csi.ControllerGetVolumeRequest{VolumeId: testVolumeID},
+			disk: &compute.Disk{
+				ID: &testVolumeID,
+				DiskProperties: &compute.DiskProperties{
+					DiskSizeGB:        &diskSizeGB,
+					DiskState:         compute.Unattached,
+					ProvisioningState: &failed,
+				},
+			},
+			expectedNodes:    []string{},
+			expectedAbnormal: true,
+		},
 	}
-	req := csi.ControllerGetVolumeRequest{}
-	resp, err := d.ControllerGetVolume(context.Background(), &req)
-	assert.Nil(t, resp)
-	if !testutil.IsErrorEquivalent(err, status.Error(codes.Unimplemented, "")) {
-		t.Errorf("Unexpected error: %v", err)
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			d, err := NewFakeDriver(t)
+			if err != nil {
+				t.Fatalf("Error getting driver: %v", err)
+			}
+			disk := compute.Disk{}
+			if test.disk != nil {
+				disk = *test.disk
+			}
+			d.getCloud().DisksClient.(*mockdiskclient.MockInterface).EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(disk, test.rerr).AnyTimes()
+
+			resp, err := d.ControllerGetVolume(context.Background(), test.req)
+			if test.expectedErrCode != codes.OK {
+				assert.Nil(t, resp)
+				checkTestError(t, test.expectedErrCode, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, testVolumeID, resp.GetVolume().GetVolumeId())
+			assert.Equal(t, volumehelper.GiBToBytes(int64(diskSizeGB)), resp.GetVolume().GetCapacityBytes())
+			assert.Equal(t, test.expectedNodes, resp.GetStatus().GetPublishedNodeIds())
+			assert.Equal(t, test.expectedAbnormal, resp.GetStatus().GetVolumeCondition().GetAbnormal())
+		})
 	}
 }
 
@@ -1148,6 +1224,58 @@ func TestControllerExpandVolume(t *testing.T) {
 	}
 }
 
+func TestControllerModifyVolume(t *testing.T) {
+	skuParameters := map[string]string{consts.SkuNameField: string(compute.PremiumLRS)}
+
+	testCases := []struct {
+		name        string
+		req         *csi.ControllerModifyVolumeRequest
+		setupFunc   func(t *testing.T, d FakeDriver)
+		expectedErr error
+	}{
+		{
+			name:        "Volume ID missing",
+			req:         &csi.ControllerModifyVolumeRequest{MutableParameters: skuParameters},
+			expectedErr: status.Error(codes.InvalidArgument, "Volume ID missing in the request"),
+		},
+		{
+			name:        "Mutable parameters missing",
+			req:         &csi.ControllerModifyVolumeRequest{VolumeId: testVolumeID},
+			expectedErr: status.Error(codes.InvalidArgument, "mutable parameters missing in request"),
+		},
+		{
+			name: "Modify volume through CRD provisioner",
+			req:  &csi.ControllerModifyVolumeRequest{VolumeId: testVolumeID, MutableParameters: skuParameters},
+			setupFunc: func(t *testing.T, d FakeDriver) {
+				ctrl := gomock.NewController(t)
+				crdProvisioner := mockprovisioner.NewMockCrdProvisioner(ctrl)
+				crdProvisioner.EXPECT().ModifyVolume(gomock.Any(), testVolumeID, skuParameters).Return(nil)
+				d.setCrdProvisioner(crdProvisioner)
+			},
+			expectedErr: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			d, _ := NewFakeDriver(t)
+			if !isTestingDriverV2() {
+				_, err := d.ControllerModifyVolume(context.Background(), tc.req)
+				assert.Equal(t, status.Error(codes.Unimplemented, "ControllerModifyVolume is not supported"), err)
+				return
+			}
+			if tc.setupFunc != nil {
+				tc.setupFunc(t, d)
+			}
+			_, err := d.ControllerModifyVolume(context.Background(), tc.req)
+			if !testutil.IsErrorEquivalent(err, tc.expectedErr) {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
 func TestCreateSnapshot(t *testing.T) {
 	testCases := []struct {
 		name     string
@@ -1416,6 +1544,7 @@ func TestDeleteSnapshot(t *testing.T) {
 				rerr := &retry.Error{
 					RawError: fmt.Errorf("get snapshot error"),
 				}
+				mockSnapshotClient.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(compute.Snapshot{}, nil).AnyTimes()
 				mockSnapshotClient.EXPECT().Delete(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(rerr).AnyTimes()
 				expectedErr := status.Errorf(codes.Internal, "delete snapshot error: Retriable: false, RetryAfter: 0s, HTTPStatusCode: 0, RawError: get snapshot error")
 				_, err := d.DeleteSnapshot(context.Background(), req)
@@ -1436,6 +1565,7 @@ func TestDeleteSnapshot(t *testing.T) {
 				req := &csi.DeleteSnapshotRequest{
 					SnapshotId: "testurl/subscriptions/12/resourceGroups/23/providers/Microsoft.Compute/snapshots/snapshot-name",
 				}
+				mockSnapshotClient.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(compute.Snapshot{}, nil).AnyTimes()
 				mockSnapshotClient.EXPECT().Delete(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
 				_, err := d.DeleteSnapshot(context.Background(), req)
 				if !testutil.IsErrorEquivalent(err, nil) {
@@ -1640,12 +1770,121 @@ func TestListSnapshots(t *testing.T) {
 
 }
 func TestGetCapacity(t *testing.T) {
-	d, _ := NewFakeDriver(t)
-	req := csi.GetCapacityRequest{}
-	resp, err := d.GetCapacity(context.Background(), &req)
-	assert.Nil(t, resp)
-	if !testutil.IsErrorEquivalent(err, status.Error(codes.Unimplemented, "")) {
-		t.Errorf("Unexpected error: %v", err)
+	newUsage := func(name string, currentValue int32, limit int64) compute.Usage {
+		return compute.Usage{Name: &compute.UsageName{Value: &name}, CurrentValue: &currentValue, Limit: &limit}
+	}
+	usages := []compute.Usage{
+		newUsage("PremiumDiskCount", 8, 10),
+		newUsage("StandardSSDStorageDisks", 10, 10),
+		newUsage("UltraSSDTotalSizeInGB", 1024, 2048),
+	}
+
+	tests := []struct {
+		desc                      string
+		req                       *csi.GetCapacityRequest
+		usages                    []compute.Usage
+		usageErr                  error
+		expectedErrCode           codes.Code
+		expectedAvailableCapacity int64
+		expectedMaximumVolumeSize int64
+	}{
+		{
+			desc:            "fail with an invalid sku",
+			req:             &csi.GetCapacityRequest{Parameters: map[string]string{consts.SkuNameField: "invalid"}},
+			expectedErrCode: codes.InvalidArgument,
+		},
+		{
+			desc:            "fail when usages cannot be listed",
+			req:             &csi.GetCapacityRequest{Parameters: map[string]string{consts.SkuNameField: "Premium_LRS"}},
+			usageErr:        fmt.Errorf("test error"),
+			expectedErrCode: codes.Unavailable,
+		},
+		{
+			desc:                      "success with a disk count quota",
+			req:                       &csi.GetCapacityRequest{Parameters: map[string]string{consts.SkuNameField: "Premium_LRS"}},
+			usages:                    usages,
+			expectedAvailableCapacity: volumehelper.GiBToBytes(2 * consts.DefaultCapacityDiskSizeGiB),
+			expectedMaximumVolumeSize: volumehelper.GiBToBytes(32767),
+		},
+		{
+			desc:                      "success with a disk count quota and a capacity disk size",
+			req:                       &csi.GetCapacityRequest{Parameters: map[string]string{consts.SkuNameField: "Premium_LRS", consts.CapacityDiskSizeGiBField: "64"}},
+			usages:                    usages,
+			expectedAvailableCapacity: volumehelper.GiBToBytes(2 * 64),
+			expectedMaximumVolumeSize: volumehelper.GiBToBytes(32767),
+		},
+		{
+			desc:   "success with an exhausted disk count quota",
+			req:    &csi.GetCapacityRequest{Parameters: map[string]string{consts.SkuNameField: "StandardSSD_LRS"}},
+			usages: usages,
+		},
+		{
+			desc:                      "success with a disk size quota",
+			req:                       &csi.GetCapacityRequest{Parameters: map[string]string{consts.SkuNameField: "UltraSSD_LRS"}},
+			usages:                    usages,
+			expectedAvailableCapacity: volumehelper.GiBToBytes(1024),
+			expectedMaximumVolumeSize: volumehelper.GiBToBytes(1024),
+		},
+		{
+			desc: "success with a zone in the location",
+			req: &csi.GetCapacityRequest{
+				Parameters:         map[string]string{consts.SkuNameField: "Premium_LRS"},
+				AccessibleTopology: &csi.Topology{Segments: map[string]string{consts.WellKnownTopologyKey: "westus-1"}},
+			},
+			usages:                    usages,
+			expectedAvailableCapacity: volumehelper.GiBToBytes(2 * consts.DefaultCapacityDiskSizeGiB),
+			expectedMaximumVolumeSize: volumehelper.GiBToBytes(32767),
+		},
+		{
+			desc: "success with no capacity in a zone outside the location",
+			req: &csi.GetCapacityRequest{
+				Parameters:         map[string]string{consts.SkuNameField: "Premium_LRS"},
+				AccessibleTopology: &csi.Topology{Segments: map[string]string{consts.WellKnownTopologyKey: "eastus-1"}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			d, err := NewFakeDriver(t)
+			if err != nil {
+				t.Fatalf("Error getting driver: %v", err)
+			}
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			usageClient := mockusageclient.NewMockUsageClient(ctrl)
+			usageClient.EXPECT().ListUsages(gomock.Any(), d.getCloud().Location).Return(test.usages, test.usageErr).AnyTimes()
+			d.setUsageClient(usageClient)
+
+			resp, err := d.GetCapacity(context.Background(), test.req)
+			if test.expectedErrCode != codes.OK {
+				assert.Nil(t, resp)
+				checkTestError(t, test.expectedErrCode, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedAvailableCapacity, resp.GetAvailableCapacity())
+			assert.Equal(t, test.expectedMaximumVolumeSize, resp.GetMaximumVolumeSize().GetValue())
+		})
+	}
+}
+
+func TestGetCapacityCachesUsages(t *testing.T) {
+	d, err := NewFakeDriver(t)
+	if err != nil {
+		t.Fatalf("Error getting driver: %v", err)
+	}
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	usageClient := mockusageclient.NewMockUsageClient(ctrl)
+	usageClient.EXPECT().ListUsages(gomock.Any(), gomock.Any()).Return([]compute.Usage{}, nil).Times(1)
+	d.setUsageClient(usageClient)
+
+	req := &csi.GetCapacityRequest{Parameters: map[string]string{consts.SkuNameField: "Premium_LRS"}}
+	for i := 0; i < 2; i++ {
+		resp, err := d.GetCapacity(context.Background(), req)
+		assert.NoError(t, err)
+		assert.Equal(t, volumehelper.GiBToBytes(32767), resp.GetMaximumVolumeSize().GetValue())
 	}
 }
 
@@ -27,8 +27,10 @@ import (
 	"github.com/container-storage-interface/spec/lib/go/csi"
 
 	"github.com/golang/protobuf/ptypes"
+	wrappers "github.com/golang/protobuf/ptypes/wrappers"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/klog/v2"
 
@@ -206,8 +208,47 @@ func (d *DriverV2) DeleteVolume(ctx context.Context, req *csi.DeleteVolumeReques
 }
 
 // ControllerGetVolume get volume
-func (d *DriverV2) ControllerGetVolume(context.Context, *csi.ControllerGetVolumeRequest) (*csi.ControllerGetVolumeResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "")
+func (d *DriverV2) ControllerGetVolume(ctx context.Context, req *csi.ControllerGetVolumeRequest) (*csi.ControllerGetVolumeResponse, error) {
+	if err := d.ValidateControllerServiceRequest(csi.ControllerServiceCapability_RPC_GET_VOLUME); err != nil {
+		return nil, err
+	}
+
+	volumeID := req.GetVolumeId()
+	if len(volumeID) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Volume ID not provided")
+	}
+
+	mc := metrics.NewMetricContext(d.cloudProvisioner.GetMetricPrefix(), "controller_get_volume", d.cloudProvisioner.GetCloud().ResourceGroup, d.cloudProvisioner.GetCloud().SubscriptionID, d.Name)
+	isOperationSucceeded := false
+	defer func() {
+		mc.ObserveOperationWithResult(isOperationSucceeded, consts.VolumeID, volumeID)
+	}()
+
+	result, err := d.crdProvisioner.GetVolume(ctx, volumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if result == nil || result.Details == nil {
+		return nil, status.Error(codes.Unknown, "Error getting volume")
+	}
+
+	responseVolumeStatus := &csi.ControllerGetVolumeResponse_VolumeStatus{}
+	if result.Status != nil {
+		responseVolumeStatus.PublishedNodeIds = result.Status.PublishedNodeIds
+		if result.Status.Condition != nil {
+			responseVolumeStatus.VolumeCondition = &csi.VolumeCondition{
+				Abnormal: result.Status.Condition.Abnormal,
+				Message:  result.Status.Condition.Message,
+			}
+		}
+	}
+
+	isOperationSucceeded = true
+	return &csi.ControllerGetVolumeResponse{
+		Volume: getCSIVolume(result.Details),
+		Status: responseVolumeStatus,
+	}, nil
 }
 
 // ControllerPublishVolume attach an azure disk to a required node
@@ -348,9 +389,35 @@ func (d *DriverV2) ControllerGetCapabilities(ctx context.Context, req *csi.Contr
 	}, nil
 }
 
-// GetCapacity returns the capacity of the total available storage pool
+// GetCapacity returns the capacity available for new disks in the requested topology segment based on the subscription disk quota
 func (d *DriverV2) GetCapacity(ctx context.Context, req *csi.GetCapacityRequest) (*csi.GetCapacityResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "")
+	if err := d.ValidateControllerServiceRequest(csi.ControllerServiceCapability_RPC_GET_CAPACITY); err != nil {
+		return nil, err
+	}
+
+	var accessibleTopology *azdiskv1beta2.Topology
+	if req.GetAccessibleTopology() != nil {
+		accessibleTopology = &azdiskv1beta2.Topology{
+			Segments: req.GetAccessibleTopology().GetSegments(),
+		}
+	}
+
+	mc := metrics.NewMetricContext(d.cloudProvisioner.GetMetricPrefix(), "controller_get_capacity", d.cloudProvisioner.GetCloud().ResourceGroup, d.cloudProvisioner.GetCloud().SubscriptionID, d.Name)
+	isOperationSucceeded := false
+	defer func() {
+		mc.ObserveOperationWithResult(isOperationSucceeded)
+	}()
+
+	availableCapacity, maximumVolumeSize, err := d.cloudProvisioner.GetCapacity(ctx, req.GetParameters(), accessibleTopology)
+	if err != nil {
+		return nil, err
+	}
+
+	isOperationSucceeded = true
+	return &csi.GetCapacityResponse{
+		AvailableCapacity: availableCapacity,
+		MaximumVolumeSize: &wrappers.Int64Value{Value: maximumVolumeSize},
+	}, nil
 }
 
 // ListVolumes return all available volumes
@@ -387,34 +454,6 @@ func (d *DriverV2) ListVolumes(ctx context.Context, req *csi.ListVolumesRequest)
 	responseEntries := []*csi.ListVolumesResponse_Entry{}
 
 	for _, resultEntry := range result.Entries {
-		resultVolumeDetail := resultEntry.Details
-		responseContentSource := &csi.VolumeContentSource{}
-
-		if resultVolumeDetail.ContentSource != nil {
-			if resultVolumeDetail.ContentSource.ContentSource == azdiskv1beta2.ContentVolumeSourceTypeSnapshot {
-				responseContentSource.Type = &csi.VolumeContentSource_Snapshot{
-					Snapshot: &csi.VolumeContentSource_SnapshotSource{
-						SnapshotId: resultVolumeDetail.ContentSource.ContentSourceID,
-					},
-				}
-			} else {
-				responseContentSource.Type = &csi.VolumeContentSource_Volume{
-					Volume: &csi.VolumeContentSource_VolumeSource{
-						VolumeId: resultVolumeDetail.ContentSource.ContentSourceID,
-					},
-				}
-			}
-		}
-
-		responseAccessibleTopology := []*csi.Topology{}
-		for _, t := range resultVolumeDetail.AccessibleTopology {
-			topology := &csi.Topology{
-				Segments: t.Segments,
-			}
-
-			responseAccessibleTopology = append(responseAccessibleTopology, topology)
-		}
-
 		responseVolumeStatus := &csi.ListVolumesResponse_VolumeStatus{}
 
 		if resultEntry.Status != nil {
@@ -430,13 +469,7 @@ func (d *DriverV2) ListVolumes(ctx context.Context, req *csi.ListVolumesRequest)
 		}
 
 		responseEntries = append(responseEntries, &csi.ListVolumesResponse_Entry{
-			Volume: &csi.Volume{
-				VolumeId:           resultVolumeDetail.VolumeID,
-				CapacityBytes:      resultVolumeDetail.CapacityBytes,
-				VolumeContext:      resultVolumeDetail.VolumeContext,
-				ContentSource:      responseContentSource,
-				AccessibleTopology: responseAccessibleTopology,
-			},
+			Volume: getCSIVolume(resultEntry.Details),
 			Status: responseVolumeStatus,
 		})
 	}
@@ -451,6 +484,44 @@ func (d *DriverV2) ListVolumes(ctx context.Context, req *csi.ListVolumesRequest)
 	return response, nil
 }
 
+// getCSIVolume converts the volume details returned by a provisioner to a CSI volume
+func getCSIVolume(resultVolumeDetail *azdiskv1beta2.VolumeDetails) *csi.Volume {
+	responseContentSource := &csi.VolumeContentSource{}
+
+	if resultVolumeDetail.ContentSource != nil {
+		if resultVolumeDetail.ContentSource.ContentSource == azdiskv1beta2.ContentVolumeSourceTypeSnapshot {
+			responseContentSource.Type = &csi.VolumeContentSource_Snapshot{
+				Snapshot: &csi.VolumeContentSource_SnapshotSource{
+					SnapshotId: resultVolumeDetail.ContentSource.ContentSourceID,
+				},
+			}
+		} else {
+			responseContentSource.Type = &csi.VolumeContentSource_Volume{
+				Volume: &csi.VolumeContentSource_VolumeSource{
+					VolumeId: resultVolumeDetail.ContentSource.ContentSourceID,
+				},
+			}
+		}
+	}
+
+	responseAccessibleTopology := []*csi.Topology{}
+	for _, t := range resultVolumeDetail.AccessibleTopology {
+		topology := &csi.Topology{
+			Segments: t.Segments,
+		}
+
+		responseAccessibleTopology = append(responseAccessibleTopology, topology)
+	}
+
+	return &csi.Volume{
+		VolumeId:           resultVolumeDetail.VolumeID,
+		CapacityBytes:      resultVolumeDetail.CapacityBytes,
+		VolumeContext:      resultVolumeDetail.VolumeContext,
+		ContentSource:      responseContentSource,
+		AccessibleTopology: responseAccessibleTopology,
+	}
+}
+
 // ControllerExpandVolume controller expand volume
 func (d *DriverV2) ControllerExpandVolume(ctx context.Context, req *csi.ControllerExpandVolumeRequest) (*csi.ControllerExpandVolumeResponse, error) {
 	diskURI := req.GetVolumeId()
@@ -494,6 +565,35 @@ func (d *DriverV2) ControllerExpandVolume(ctx context.Context, req *csi.Controll
 	}, nil
 }
 
+// ControllerModifyVolume modifies the sku, provisioned performance or bursting of a volume
+func (d *DriverV2) ControllerModifyVolume(ctx context.Context, req *csi.ControllerModifyVolumeRequest) (*csi.ControllerModifyVolumeResponse, error) {
+	diskURI := req.GetVolumeId()
+	if len(diskURI) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Volume ID missing in the request")
+	}
+	if err := d.ValidateControllerServiceRequest(csi.ControllerServiceCapability_RPC_MODIFY_VOLUME); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid modify volume request: %v", req)
+	}
+
+	parameters := req.GetMutableParameters()
+	if len(parameters) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "mutable parameters missing in request")
+	}
+
+	mc := metrics.NewMetricContext(d.cloudProvisioner.GetMetricPrefix(), "controller_modify_volume", d.cloudProvisioner.GetCloud().ResourceGroup, d.cloudProvisioner.GetCloud().SubscriptionID, d.Name)
+	isOperationSucceeded := false
+	defer func() {
+		mc.ObserveOperationWithResult(isOperationSucceeded, consts.VolumeID, diskURI)
+	}()
+
+	if err := d.crdProvisioner.ModifyVolume(ctx, diskURI, parameters); err != nil {
+		return nil, err
+	}
+
+	isOperationSucceeded = true
+	return &csi.ControllerModifyVolumeResponse{}, nil
+}
+
 // CreateSnapshot create a snapshot
 func (d *DriverV2) CreateSnapshot(ctx context.Context, req *csi.CreateSnapshotRequest) (*csi.CreateSnapshotResponse, error) {
 	sourceVolumeID := req.GetSourceVolumeId()
@@ -512,7 +612,7 @@ func (d *DriverV2) CreateSnapshot(ctx context.Context, req *csi.CreateSnapshotRe
 		mc.ObserveOperationWithResult(isOperationSucceeded, consts.SourceResourceID, sourceVolumeID, consts.SnapshotName, snapshotName)
 	}()
 
-	snapshot, err := d.cloudProvisioner.CreateSnapshot(ctx, sourceVolumeID, snapshotName, req.GetSecrets(), req.GetParameters())
+	snapshot, err := d.crdProvisioner.CreateSnapshot(ctx, sourceVolumeID, snapshotName, req.GetSecrets(), req.GetParameters())
 
 	if err != nil {
 		return nil, err
@@ -562,7 +662,11 @@ func (d *DriverV2) DeleteSnapshot(ctx context.Context, req *csi.DeleteSnapshotRe
 		mc.ObserveOperationWithResult(isOperationSucceeded, consts.SnapshotID, snapshotName)
 	}()
 
-	err = d.cloudProvisioner.DeleteSnapshot(ctx, snapshotID, req.GetSecrets())
+	err = d.crdProvisioner.DeleteSnapshot(ctx, snapshotID, req.GetSecrets())
+	if apiErrors.IsNotFound(err) {
+		// snapshots created before AzSnapshot was introduced have no backing CRI, so delete them directly
+		err = d.cloudProvisioner.DeleteSnapshot(ctx, snapshotID, req.GetSecrets())
+	}
 
 	if err != nil {
 		return nil, err
@@ -0,0 +1,242 @@
+//go:build azurediskv2
+// +build azurediskv2
+
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azuredisk
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	v1core "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	azdiskv1beta2 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
+	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/controller"
+	"sigs.k8s.io/yaml"
+)
+
+// reloadableDriverConfigFields are the fields of the driver configuration which are applied while the driver is running.
+// Changes to any other field take effect only after the driver restarts.
+var reloadableDriverConfigFields = map[string]bool{
+	"controllerConfig.replicaVolumeAttachRetryLimit":       true,
+	"nodeConfig.heartbeatFrequencyInSec":                   true,
+	"cloudConfig.enableAzureClientAttachDetachRateLimiter": true,
+	"cloudConfig.azureClientAttachDetachRateLimiterQPS":    true,
+	"cloudConfig.azureClientAttachDetachRateLimiterBucket": true,
+}
+
+// startDriverConfigWatcher watches the ConfigMap holding the driver configuration and reloads the configuration when it changes.
+func (d *DriverV2) startDriverConfigWatcher(ctx context.Context) {
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartRecordingToSink(&v1core.EventSinkImpl{Interface: d.kubeClient.CoreV1().Events("")})
+	eventRecorder := eventBroadcaster.NewRecorder(clientgoscheme.Scheme, v1.EventSource{Component: consts.AzureDiskCSIDriverName, Host: d.NodeID})
+
+	informerFactory := informers.NewSharedInformerFactoryWithOptions(
+		d.kubeClient,
+		consts.DefaultInformerResync,
+		informers.WithNamespace(d.config.ConfigMapNamespace),
+		informers.WithTweakListOptions(func(opt *metav1.ListOptions) {
+			opt.FieldSelector = fields.OneTermEqualSelector("metadata.name", d.config.ConfigMapName).String()
+		}),
+	)
+
+	_, err := informerFactory.Core().V1().ConfigMaps().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if configMap, ok := obj.(*v1.ConfigMap); ok {
+				d.reloadDriverConfig(configMap, eventRecorder)
+			}
+		},
+		UpdateFunc: func(_, newObj interface{}) {
+			if configMap, ok := newObj.(*v1.ConfigMap); ok {
+				d.reloadDriverConfig(configMap, eventRecorder)
+			}
+		},
+	})
+	if err != nil {
+		klog.Fatalf("Failed to add driver configuration event handler: %v", err)
+	}
+
+	klog.V(2).Infof("Watching ConfigMap %s/%s for driver configuration changes", d.config.ConfigMapNamespace, d.config.ConfigMapName)
+	informerFactory.Start(ctx.Done())
+}
+
+// reloadDriverConfig validates the driver configuration held by the ConfigMap and applies the changes to the
+// fields which can be changed while the driver is running. The result is reported as an event of the ConfigMap.
+func (d *DriverV2) reloadDriverConfig(configMap *v1.ConfigMap, eventRecorder record.EventRecorder) {
+	d.configLock.Lock()
+	defer d.configLock.Unlock()
+
+	if configMap.ResourceVersion != "" && configMap.ResourceVersion == d.configResourceVersion {
+		return
+	}
+	d.configResourceVersion = configMap.ResourceVersion
+
+	newConfig, restartRequired, err := getDriverConfigUpdate(d.getReloadedConfig(), configMap)
+	if err != nil {
+		klog.Errorf("Rejected driver configuration in ConfigMap %s/%s: %v", configMap.Namespace, configMap.Name, err)
+		eventRecorder.Eventf(configMap, v1.EventTypeWarning, consts.DriverConfigReloadRejectedEvent, "Rejected driver configuration: %v", err)
+		return
+	}
+	if newConfig == nil {
+		return
+	}
+
+	d.applyReloadableConfig(newConfig)
+
+	message := "Applied driver configuration"
+	if len(restartRequired) > 0 {
+		message = fmt.Sprintf("%s; changes to %s take effect after the driver restarts", message, strings.Join(restartRequired, ", "))
+	}
+	klog.Infof("%s from ConfigMap %s/%s", message, configMap.Namespace, configMap.Name)
+	eventRecorder.Event(configMap, v1.EventTypeNormal, consts.DriverConfigReloadedEvent, message)
+}
+
+// applyReloadableConfig publishes the new driver configuration and applies its reloadable fields to the components
+// which use them. The configuration the components were created with is never modified, so they can read it without
+// holding configLock. The caller must hold configLock.
+func (d *DriverV2) applyReloadableConfig(newConfig *azdiskv1beta2.AzDiskDriverConfiguration) {
+	reloadedConfig := d.getReloadedConfig().DeepCopy()
+	reloadedConfig.ControllerConfig.ReplicaVolumeAttachRetryLimit = newConfig.ControllerConfig.ReplicaVolumeAttachRetryLimit
+	reloadedConfig.NodeConfig.HeartbeatFrequencyInSec = newConfig.NodeConfig.HeartbeatFrequencyInSec
+	reloadedConfig.CloudConfig.EnableAzureClientAttachDetachRateLimiter = newConfig.CloudConfig.EnableAzureClientAttachDetachRateLimiter
+	reloadedConfig.CloudConfig.AzureClientAttachDetachRateLimiterQPS = newConfig.CloudConfig.AzureClientAttachDetachRateLimiterQPS
+	reloadedConfig.CloudConfig.AzureClientAttachDetachRateLimiterBucket = newConfig.CloudConfig.AzureClientAttachDetachRateLimiterBucket
+	d.reloadedConfig = reloadedConfig
+
+	if d.cloudProvisioner != nil {
+		d.cloudProvisioner.SetAttachDetachRateLimit(reloadedConfig.CloudConfig)
+	}
+
+	if d.sharedState != nil {
+		d.sharedState.SetReplicaVolumeAttachRetryLimit(reloadedConfig.ControllerConfig.ReplicaVolumeAttachRetryLimit)
+	}
+}
+
+// getReloadedConfig returns the most recently applied driver configuration. The caller must hold configLock.
+func (d *DriverV2) getReloadedConfig() *azdiskv1beta2.AzDiskDriverConfiguration {
+	if d.reloadedConfig != nil {
+		return d.reloadedConfig
+	}
+	return d.config
+}
+
+// setSharedState registers the state shared by the controllers so it receives configuration changes.
+func (d *DriverV2) setSharedState(sharedState *controller.SharedState) {
+	d.configLock.Lock()
+	defer d.configLock.Unlock()
+
+	d.sharedState = sharedState
+	d.sharedState.SetReplicaVolumeAttachRetryLimit(d.getReloadedConfig().ControllerConfig.ReplicaVolumeAttachRetryLimit)
+}
+
+// getHeartbeatFrequency returns the frequency at which the node plugin updates the heartbeat of its AzDriverNode.
+func (d *DriverV2) getHeartbeatFrequency() time.Duration {
+	d.configLock.Lock()
+	defer d.configLock.Unlock()
+
+	return time.Duration(d.getReloadedConfig().NodeConfig.HeartbeatFrequencyInSec) * time.Second
+}
+
+// getDriverConfigUpdate returns the driver configuration held by the ConfigMap and the fields whose changes require a
+// restart of the driver. Fields missing from the ConfigMap keep their current values. A nil configuration is returned
+// if none of the reloadable fields changed.
+func getDriverConfigUpdate(current *azdiskv1beta2.AzDiskDriverConfiguration, configMap *v1.ConfigMap) (*azdiskv1beta2.AzDiskDriverConfiguration, []string, error) {
+	data, ok := configMap.Data[consts.DriverConfigMapKey]
+	if !ok {
+		return nil, nil, fmt.Errorf("key %s not found in ConfigMap %s/%s", consts.DriverConfigMapKey, configMap.Namespace, configMap.Name)
+	}
+
+	newConfig := current.DeepCopy()
+	if err := yaml.Unmarshal([]byte(data), newConfig); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal the driver configuration: %v", err)
+	}
+	if err := validateReloadableDriverConfig(newConfig); err != nil {
+		return nil, nil, err
+	}
+
+	var reloaded bool
+	var restartRequired []string
+	for _, field := range getChangedFields(reflect.ValueOf(*current), reflect.ValueOf(*newConfig), "") {
+		if reloadableDriverConfigFields[field] {
+			reloaded = true
+		} else {
+			restartRequired = append(restartRequired, field)
+		}
+	}
+	if !reloaded {
+		if len(restartRequired) > 0 {
+			return nil, nil, fmt.Errorf("changes to %s require a restart of the driver", strings.Join(restartRequired, ", "))
+		}
+		return nil, nil, nil
+	}
+
+	return newConfig, restartRequired, nil
+}
+
+// validateReloadableDriverConfig validates the values of the reloadable fields of the driver configuration.
+func validateReloadableDriverConfig(config *azdiskv1beta2.AzDiskDriverConfiguration) error {
+	if config.ControllerConfig.ReplicaVolumeAttachRetryLimit < 0 {
+		return fmt.Errorf("controllerConfig.replicaVolumeAttachRetryLimit must not be negative: %d", config.ControllerConfig.ReplicaVolumeAttachRetryLimit)
+	}
+	if config.NodeConfig.HeartbeatFrequencyInSec <= 0 {
+		return fmt.Errorf("nodeConfig.heartbeatFrequencyInSec must be positive: %d", config.NodeConfig.HeartbeatFrequencyInSec)
+	}
+	if config.CloudConfig.EnableAzureClientAttachDetachRateLimiter {
+		if config.CloudConfig.AzureClientAttachDetachRateLimiterQPS <= 0 {
+			return fmt.Errorf("cloudConfig.azureClientAttachDetachRateLimiterQPS must be positive: %f", config.CloudConfig.AzureClientAttachDetachRateLimiterQPS)
+		}
+		if config.CloudConfig.AzureClientAttachDetachRateLimiterBucket <= 0 {
+			return fmt.Errorf("cloudConfig.azureClientAttachDetachRateLimiterBucket must be positive: %d", config.CloudConfig.AzureClientAttachDetachRateLimiterBucket)
+		}
+	}
+	return nil
+}
+
+// getChangedFields returns the JSON paths of the fields whose values differ between the two structs.
+func getChangedFields(current, desired reflect.Value, prefix string) []string {
+	var changed []string
+	for i := 0; i < current.NumField(); i++ {
+		field := current.Type().Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" {
+			// skip inlined fields such as the TypeMeta
+			continue
+		}
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+
+		if field.Type.Kind() == reflect.Struct {
+			changed = append(changed, getChangedFields(current.Field(i), desired.Field(i), name)...)
+		} else if !reflect.DeepEqual(current.Field(i).Interface(), desired.Field(i).Interface()) {
+			changed = append(changed, name)
+		}
+	}
+	return changed
+}
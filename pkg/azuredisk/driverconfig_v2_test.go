@@ -0,0 +1,192 @@
+//go:build azurediskv2
+// +build azurediskv2
+
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azuredisk
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	azdiskv1beta2 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
+	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
+)
+
+func TestReloadDriverConfig(t *testing.T) {
+	newConfigMap := func(resourceVersion, config string) *v1.ConfigMap {
+		return &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "csi-azuredisk-controller-config",
+				Namespace:       consts.ReleaseNamespace,
+				ResourceVersion: resourceVersion,
+			},
+			Data: map[string]string{consts.DriverConfigMapKey: config},
+		}
+	}
+
+	tests := []struct {
+		description     string
+		configMaps      []*v1.ConfigMap
+		expectedEvents  []string
+		expectedMessage string
+		verifyFunc      func(*testing.T, *azdiskv1beta2.AzDiskDriverConfiguration)
+	}{
+		{
+			description: "[Success] Should apply changes to the reloadable fields",
+			configMaps: []*v1.ConfigMap{
+				newConfigMap("1", "controllerConfig:\n  replicaVolumeAttachRetryLimit: 5\nnodeConfig:\n  heartbeatFrequencyInSec: 10\n"),
+			},
+			expectedEvents: []string{v1.EventTypeNormal + " " + consts.DriverConfigReloadedEvent},
+			verifyFunc: func(t *testing.T, config *azdiskv1beta2.AzDiskDriverConfiguration) {
+				assert.Equal(t, 5, config.ControllerConfig.ReplicaVolumeAttachRetryLimit)
+				assert.Equal(t, 10, config.NodeConfig.HeartbeatFrequencyInSec)
+			},
+		},
+		{
+			description: "[Success] Should apply changes to the attach/detach rate limiter",
+			configMaps: []*v1.ConfigMap{
+				newConfigMap("1", "cloudConfig:\n  azureClientAttachDetachRateLimiterQPS: 5\n  azureClientAttachDetachRateLimiterBucket: 10\n"),
+			},
+			expectedEvents: []string{v1.EventTypeNormal + " " + consts.DriverConfigReloadedEvent},
+			verifyFunc: func(t *testing.T, config *azdiskv1beta2.AzDiskDriverConfiguration) {
+				assert.Equal(t, float32(5), config.CloudConfig.AzureClientAttachDetachRateLimiterQPS)
+				assert.Equal(t, 10, config.CloudConfig.AzureClientAttachDetachRateLimiterBucket)
+			},
+		},
+		{
+			description: "[Failure] Should reject an invalid attach/detach rate limiter QPS",
+			configMaps: []*v1.ConfigMap{
+				newConfigMap("1", "cloudConfig:\n  azureClientAttachDetachRateLimiterQPS: 0\n"),
+			},
+			expectedEvents:  []string{v1.EventTypeWarning + " " + consts.DriverConfigReloadRejectedEvent},
+			expectedMessage: "cloudConfig.azureClientAttachDetachRateLimiterQPS",
+		},
+		{
+			description: "[Success] Should report the changes requiring a restart along with the applied changes",
+			configMaps: []*v1.ConfigMap{
+				newConfigMap("1", "controllerConfig:\n  replicaVolumeAttachRetryLimit: 5\n  workerThreads: 20\n"),
+			},
+			expectedEvents:  []string{v1.EventTypeNormal + " " + consts.DriverConfigReloadedEvent},
+			expectedMessage: "controllerConfig.workerThreads",
+			verifyFunc: func(t *testing.T, config *azdiskv1beta2.AzDiskDriverConfiguration) {
+				assert.Equal(t, 5, config.ControllerConfig.ReplicaVolumeAttachRetryLimit)
+				assert.Equal(t, consts.DefaultWorkerThreads, config.ControllerConfig.WorkerThreads)
+			},
+		},
+		{
+			description: "[Success] Should not report a ConfigMap matching the current configuration",
+			configMaps: []*v1.ConfigMap{
+				newConfigMap("1", "controllerConfig:\n  workerThreads: 10\n"),
+			},
+			expectedEvents: nil,
+		},
+		{
+			description: "[Success] Should not reload a ConfigMap which has not changed",
+			configMaps: []*v1.ConfigMap{
+				newConfigMap("1", "nodeConfig:\n  heartbeatFrequencyInSec: 10\n"),
+				newConfigMap("1", "nodeConfig:\n  heartbeatFrequencyInSec: 10\n"),
+			},
+			expectedEvents: []string{v1.EventTypeNormal + " " + consts.DriverConfigReloadedEvent},
+		},
+		{
+			description: "[Failure] Should reject changes which only take effect after a restart",
+			configMaps: []*v1.ConfigMap{
+				newConfigMap("1", "controllerConfig:\n  workerThreads: 20\n"),
+			},
+			expectedEvents:  []string{v1.EventTypeWarning + " " + consts.DriverConfigReloadRejectedEvent},
+			expectedMessage: "controllerConfig.workerThreads",
+		},
+		{
+			description: "[Failure] Should reject an invalid heartbeat frequency",
+			configMaps: []*v1.ConfigMap{
+				newConfigMap("1", "nodeConfig:\n  heartbeatFrequencyInSec: 0\n"),
+			},
+			expectedEvents:  []string{v1.EventTypeWarning + " " + consts.DriverConfigReloadRejectedEvent},
+			expectedMessage: "nodeConfig.heartbeatFrequencyInSec",
+			verifyFunc: func(t *testing.T, config *azdiskv1beta2.AzDiskDriverConfiguration) {
+				assert.Equal(t, consts.DefaultHeartbeatFrequencyInSec, config.NodeConfig.HeartbeatFrequencyInSec)
+			},
+		},
+		{
+			description: "[Failure] Should reject a malformed configuration",
+			configMaps: []*v1.ConfigMap{
+				newConfigMap("1", "nodeConfig: ["),
+			},
+			expectedEvents: []string{v1.EventTypeWarning + " " + consts.DriverConfigReloadRejectedEvent},
+		},
+	}
+
+	for _, test := range tests {
+		tt := test
+		t.Run(tt.description, func(t *testing.T) {
+			d, err := newFakeDriverV2(t)
+			require.NoError(t, err)
+			d.config = &azdiskv1beta2.AzDiskDriverConfiguration{
+				ControllerConfig: azdiskv1beta2.ControllerConfiguration{
+					WorkerThreads:                 consts.DefaultWorkerThreads,
+					ReplicaVolumeAttachRetryLimit: consts.DefaultReplicaVolumeAttachRetryLimit,
+				},
+				NodeConfig: azdiskv1beta2.NodeConfiguration{
+					HeartbeatFrequencyInSec: consts.DefaultHeartbeatFrequencyInSec,
+				},
+				CloudConfig: azdiskv1beta2.CloudConfiguration{
+					EnableAzureClientAttachDetachRateLimiter: consts.DefaultEnableAzureClientAttachDetachRateLimiter,
+					AzureClientAttachDetachRateLimiterQPS:    consts.DefaultAzureClientAttachDetachRateLimiterQPS,
+					AzureClientAttachDetachRateLimiterBucket: consts.DefaultAzureClientAttachDetachRateLimiterBucket,
+				},
+			}
+
+			eventRecorder := record.NewFakeRecorder(len(tt.configMaps))
+			for _, configMap := range tt.configMaps {
+				d.reloadDriverConfig(configMap, eventRecorder)
+			}
+			close(eventRecorder.Events)
+
+			var events []string
+			for event := range eventRecorder.Events {
+				fields := strings.SplitN(event, " ", 3)
+				events = append(events, strings.Join(fields[:2], " "))
+				assert.Contains(t, event, tt.expectedMessage)
+			}
+			assert.Equal(t, tt.expectedEvents, events)
+
+			if tt.verifyFunc != nil {
+				tt.verifyFunc(t, d.getReloadedConfig())
+			}
+		})
+	}
+}
+
+func TestGetHeartbeatFrequency(t *testing.T) {
+	d, err := newFakeDriverV2(t)
+	require.NoError(t, err)
+	d.config.NodeConfig.HeartbeatFrequencyInSec = consts.DefaultHeartbeatFrequencyInSec
+	assert.Equal(t, consts.DefaultHeartbeatFrequencyInSec*time.Second, d.getHeartbeatFrequency())
+
+	d.reloadDriverConfig(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{ResourceVersion: "1"},
+		Data:       map[string]string{consts.DriverConfigMapKey: "nodeConfig:\n  heartbeatFrequencyInSec: 5\n"},
+	}, record.NewFakeRecorder(1))
+	assert.Equal(t, 5*time.Second, d.getHeartbeatFrequency())
+}
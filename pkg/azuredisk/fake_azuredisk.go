@@ -97,6 +97,7 @@ type FakeDriver interface {
 	ensureMountPoint(string) (bool, error)
 
 	setDiskThrottlingCache(key string, value string)
+	setUsageClient(usageClient azureutils.UsageClient)
 }
 
 type fakeDriverV1 struct {
@@ -141,6 +142,13 @@ func newFakeDriverV1(t *testing.T) (*fakeDriverV1, error) {
 	}
 	driver.getDiskThrottlingCache = cache
 
+	driver.diskUsageCache, err = azureutils.NewDiskUsageCache(time.Minute, func() azureutils.UsageClient {
+		return driver.usageClient
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	mockDeviceHelper := mockoptimization.NewMockInterface(ctrl)
 	driver.deviceHelper = mockDeviceHelper
 
@@ -154,6 +162,9 @@ func newFakeDriverV1(t *testing.T) (*fakeDriverV1, error) {
 			csi.ControllerServiceCapability_RPC_EXPAND_VOLUME,
 			csi.ControllerServiceCapability_RPC_LIST_VOLUMES,
 			csi.ControllerServiceCapability_RPC_LIST_VOLUMES_PUBLISHED_NODES,
+			csi.ControllerServiceCapability_RPC_GET_VOLUME,
+			csi.ControllerServiceCapability_RPC_VOLUME_CONDITION,
+			csi.ControllerServiceCapability_RPC_GET_CAPACITY,
 		})
 	driver.AddVolumeCapabilityAccessModes([]csi.VolumeCapability_AccessMode_Mode{csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER})
 	driver.AddNodeServiceCapabilities([]csi.NodeServiceCapability_RPC_Type{
@@ -193,6 +204,10 @@ func (d *fakeDriverV1) setDiskThrottlingCache(key string, value string) {
 	d.getDiskThrottlingCache.Set(key, value)
 }
 
+func (d *fakeDriverV1) setUsageClient(usageClient azureutils.UsageClient) {
+	d.usageClient = usageClient
+}
+
 func (d *fakeDriverV1) getCrdProvisioner() CrdProvisioner { return nil }
 
 func (d *fakeDriverV1) setCrdProvisioner(crdProvisioner CrdProvisioner) {}
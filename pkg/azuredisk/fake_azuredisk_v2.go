@@ -35,6 +35,7 @@ import (
 	azdiskfakes "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/client/clientset/versioned/fake"
 	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
 	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils/fakecompute"
 	csicommon "sigs.k8s.io/azuredisk-csi-driver/pkg/csi-common"
 	"sigs.k8s.io/azuredisk-csi-driver/pkg/optimization/mockoptimization"
 	"sigs.k8s.io/azuredisk-csi-driver/pkg/provisioner"
@@ -57,6 +58,17 @@ func NewFakeDriver(t *testing.T) (FakeDriver, error) {
 }
 
 func newFakeDriverV2(t *testing.T) (*fakeDriverV2, error) {
+	return newFakeDriverV2WithCloudProvisioner(t, provisioner.NewFakeCloudProvisioner)
+}
+
+// newFakeDriverV2WithBackend returns a DriverV2 whose cloud provisioner runs the cloud operations against the fake compute backend.
+func newFakeDriverV2WithBackend(t *testing.T, backend *fakecompute.Backend) (*fakeDriverV2, error) {
+	return newFakeDriverV2WithCloudProvisioner(t, func(ctrl *gomock.Controller) (*provisioner.FakeCloudProvisioner, error) {
+		return provisioner.NewFakeCloudProvisionerWithBackend(ctrl, backend)
+	})
+}
+
+func newFakeDriverV2WithCloudProvisioner(t *testing.T, newCloudProvisioner func(*gomock.Controller) (*provisioner.FakeCloudProvisioner, error)) (*fakeDriverV2, error) {
 	klog.Warning("Using DriverV2")
 	driver := fakeDriverV2{}
 	driver.config = &azdiskv1beta2.AzDiskDriverConfiguration{}
@@ -77,7 +89,7 @@ func newFakeDriverV2(t *testing.T) (*fakeDriverV2, error) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	cloudProvisioner, err := provisioner.NewFakeCloudProvisioner(ctrl)
+	cloudProvisioner, err := newCloudProvisioner(ctrl)
 	if err != nil {
 		return nil, err
 	}
@@ -114,7 +126,14 @@ func newFakeDriverV2(t *testing.T) (*fakeDriverV2, error) {
 			csi.ControllerServiceCapability_RPC_EXPAND_VOLUME,
 			csi.ControllerServiceCapability_RPC_LIST_VOLUMES,
 			csi.ControllerServiceCapability_RPC_LIST_VOLUMES_PUBLISHED_NODES,
+			csi.ControllerServiceCapability_RPC_GET_VOLUME,
+			csi.ControllerServiceCapability_RPC_VOLUME_CONDITION,
+			csi.ControllerServiceCapability_RPC_GET_CAPACITY,
+			csi.ControllerServiceCapability_RPC_MODIFY_VOLUME,
 		})
+	driver.AddGroupControllerServiceCapabilities([]csi.GroupControllerServiceCapability_RPC_Type{
+		csi.GroupControllerServiceCapability_RPC_CREATE_DELETE_GET_VOLUME_GROUP_SNAPSHOT,
+	})
 	driver.AddVolumeCapabilityAccessModes([]csi.VolumeCapability_AccessMode_Mode{csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER})
 	driver.AddNodeServiceCapabilities([]csi.NodeServiceCapability_RPC_Type{
 		csi.NodeServiceCapability_RPC_STAGE_UNSTAGE_VOLUME,
@@ -183,6 +202,10 @@ func (d *fakeDriverV2) setCrdProvisioner(crdProvisioner CrdProvisioner) {
 func (d *DriverV2) setDiskThrottlingCache(key string, value string) {
 }
 
+func (d *fakeDriverV2) setUsageClient(usageClient azureutils.UsageClient) {
+	d.cloudProvisioner.(*provisioner.FakeCloudProvisioner).SetUsageClient(usageClient)
+}
+
 func skipIfTestingDriverV2(t *testing.T) {
 	t.Skip("Skipping test on DriverV2")
 }
@@ -0,0 +1,339 @@
+//go:build azurediskv2
+// +build azurediskv2
+
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azuredisk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	azdiskv1beta2 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
+	azdiskinformers "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/client/informers/externalversions"
+	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils"
+)
+
+// maxFilesystemFreezeDuration is the longest time a filesystem is kept frozen for a group snapshot. It outlasts the time
+// the controller takes to freeze the filesystems and take the snapshots, so the filesystem is only thawed this way if the
+// controller fails to withdraw its request.
+const maxFilesystemFreezeDuration = time.Duration(6) * time.Minute
+
+const (
+	// kubeletVolumeDataFile is the file in which kubelet records the volume handle and driver of a staged or block volume.
+	kubeletVolumeDataFile = "vol_data.json"
+	// kubeletStagingDir is the last element of the staging path kubelet gives to NodeStageVolume.
+	kubeletStagingDir = "globalmount"
+	// kubeletBlockPublishDir is the directory under which kubelet publishes block volumes to
+	// volumeDevices/publish/<spec name>/<pod UID>, and records their data in volumeDevices/<spec name>/data.
+	kubeletBlockPublishDir = "publish"
+)
+
+// kubeletVolumeData is the volume data kubelet records in kubeletVolumeDataFile.
+type kubeletVolumeData struct {
+	VolumeHandle string `json:"volumeHandle"`
+	DriverName   string `json:"driverName"`
+}
+
+// filesystemFreezer freezes the filesystems of the volumes staged on the node at the request of the AzVolumeGroupSnapshot controller.
+// Its zero value is ready to use.
+type filesystemFreezer struct {
+	lock sync.Mutex
+	// stagingPaths maps the IDs of the volumes staged on the node to their staging paths; block volumes have no staging path.
+	stagingPaths map[string]string
+	// frozen maps the names of the AzVolumeAttachments whose filesystems are frozen to the freeze.
+	frozen map[string]*frozenFilesystem
+}
+
+type frozenFilesystem struct {
+	path    string
+	request string
+	timer   *time.Timer
+}
+
+// recordStagingPath records the staging path of a volume so that its filesystem can be frozen.
+func (d *DriverV2) recordStagingPath(volumeID, stagingPath string) {
+	f := &d.filesystemFreezer
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.stagingPaths == nil {
+		f.stagingPaths = map[string]string{}
+	}
+	f.stagingPaths[strings.ToLower(volumeID)] = stagingPath
+}
+
+// recoverStagingPaths records the staging paths of the volumes staged on the node before the driver started from the
+// staging and block volume mounts of kubelet.
+func (d *DriverV2) recoverStagingPaths() {
+	mountPoints, err := d.nodeProvisioner.GetMountPoints()
+	if err != nil {
+		klog.Warningf("failed to list mount points to recover the staging paths of volumes: %v", err)
+		return
+	}
+
+	f := &d.filesystemFreezer
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.stagingPaths == nil {
+		f.stagingPaths = map[string]string{}
+	}
+	for _, mountPoint := range mountPoints {
+		var dataDir, stagingPath string
+		switch {
+		case filepath.Base(mountPoint.Path) == kubeletStagingDir:
+			dataDir, stagingPath = filepath.Dir(mountPoint.Path), mountPoint.Path
+		case filepath.Base(filepath.Dir(filepath.Dir(mountPoint.Path))) == kubeletBlockPublishDir:
+			// block volumes have no staging path
+			publishDir := filepath.Dir(filepath.Dir(mountPoint.Path))
+			dataDir = filepath.Join(filepath.Dir(publishDir), filepath.Base(filepath.Dir(mountPoint.Path)), "data")
+		default:
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dataDir, kubeletVolumeDataFile))
+		if err != nil {
+			continue
+		}
+		var volumeData kubeletVolumeData
+		if err := json.Unmarshal(data, &volumeData); err != nil || volumeData.DriverName != d.Name || volumeData.VolumeHandle == "" {
+			continue
+		}
+		volumeID := strings.ToLower(volumeData.VolumeHandle)
+		if _, ok := f.stagingPaths[volumeID]; !ok {
+			klog.V(2).Infof("recovered staging path %q of volume (%s)", stagingPath, volumeData.VolumeHandle)
+			f.stagingPaths[volumeID] = stagingPath
+		}
+	}
+}
+
+// forgetStagingPath thaws the filesystem of a volume which is being unstaged and forgets its staging path.
+func (d *DriverV2) forgetStagingPath(volumeID string) {
+	f := &d.filesystemFreezer
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	delete(f.stagingPaths, strings.ToLower(volumeID))
+	attachmentName := d.getAzVolumeAttachmentName(volumeID)
+	if frozen, ok := f.frozen[attachmentName]; ok {
+		d.thawFilesystemLocked(attachmentName, frozen)
+	}
+}
+
+// startFilesystemFreezeWatcherOrDie watches the AzVolumeAttachments of the node for filesystem freeze requests.
+func (d *DriverV2) startFilesystemFreezeWatcherOrDie(ctx context.Context) {
+	d.recoverStagingPaths()
+
+	nodeSelector := fmt.Sprintf("%s=%s", consts.NodeNameLabel, d.NodeID)
+
+	azdiskInformerFactory := azdiskinformers.NewSharedInformerFactoryWithOptions(
+		d.azdiskClient,
+		consts.DefaultInformerResync,
+		azdiskinformers.WithNamespace(d.config.ObjectNamespace),
+		azdiskinformers.WithTweakListOptions(func(opt *metav1.ListOptions) {
+			opt.LabelSelector = nodeSelector
+		}),
+	)
+
+	azVolumeAttachmentInformer := azdiskInformerFactory.Disk().V1beta2().AzVolumeAttachments()
+	_, err := azVolumeAttachmentInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if azVolumeAttachment, ok := obj.(*azdiskv1beta2.AzVolumeAttachment); ok {
+				d.syncFilesystemFreeze(ctx, azVolumeAttachment)
+			}
+		},
+		UpdateFunc: func(_, newObj interface{}) {
+			if azVolumeAttachment, ok := newObj.(*azdiskv1beta2.AzVolumeAttachment); ok {
+				d.syncFilesystemFreeze(ctx, azVolumeAttachment)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if azVolumeAttachment, ok := obj.(*azdiskv1beta2.AzVolumeAttachment); ok {
+				d.thawFilesystem(azVolumeAttachment.Name)
+			}
+		},
+	})
+	if err != nil {
+		klog.Fatalf("Failed to add AzVolumeAttachment event handler: %v", err)
+	}
+
+	azdiskInformerFactory.Start(ctx.Done())
+
+	if !cache.WaitForCacheSync(ctx.Done(), azVolumeAttachmentInformer.Informer().HasSynced) {
+		klog.Fatal("failed to sync azvolumeattachment informer")
+	}
+}
+
+// syncFilesystemFreeze freezes or thaws the filesystem of the volume of an AzVolumeAttachment according to its freeze request
+// and reports the outcome of a freeze in the status annotations of the AzVolumeAttachment.
+func (d *DriverV2) syncFilesystemFreeze(ctx context.Context, azVolumeAttachment *azdiskv1beta2.AzVolumeAttachment) {
+	request, requested := azVolumeAttachment.Status.Annotations[consts.FilesystemFreezeRequestAnnotation]
+	if !requested {
+		if d.thawFilesystem(azVolumeAttachment.Name) || azureutils.MapContains(azVolumeAttachment.Status.Annotations, consts.FilesystemFrozenAnnotation) {
+			d.reportFilesystemFreeze(ctx, azVolumeAttachment, "", nil)
+		}
+		return
+	}
+
+	// the request has already been handled
+	if azVolumeAttachment.Status.Annotations[consts.FilesystemFrozenAnnotation] == request || azureutils.MapContains(azVolumeAttachment.Status.Annotations, consts.FilesystemFreezeErrorAnnotation) {
+		return
+	}
+
+	// the volume is not staged or unstaged while its filesystem is being frozen
+	volumeID := azVolumeAttachment.Spec.VolumeID
+	if acquired := d.volumeLocks.TryAcquire(volumeID); !acquired {
+		d.reportFilesystemFreeze(ctx, azVolumeAttachment, request, fmt.Errorf(volumeOperationAlreadyExistsFmt, volumeID))
+		return
+	}
+	defer d.volumeLocks.Release(volumeID)
+
+	f := &d.filesystemFreezer
+	f.lock.Lock()
+	frozen, isFrozen := f.frozen[azVolumeAttachment.Name]
+	if isFrozen {
+		// the filesystem is still frozen for a previous request, so the freeze is handed over to this one
+		frozen.request = request
+		frozen.timer.Reset(maxFilesystemFreezeDuration)
+	}
+	stagingPath, isStaged := f.stagingPaths[strings.ToLower(volumeID)]
+	f.lock.Unlock()
+
+	// the filesystem is frozen without holding the lock as fsfreeze waits for the filesystem to be flushed
+	var err error
+	switch {
+	case isFrozen:
+	case !isStaged:
+		err = fmt.Errorf("volume (%s) is not staged on node (%s)", volumeID, d.NodeID)
+	case stagingPath == "":
+		// block volumes have no filesystem to freeze
+	default:
+		if err = d.nodeProvisioner.FreezeFilesystem(stagingPath); err == nil {
+			klog.V(2).Infof("froze filesystem of volume (%s) at %s for group snapshot (%s)", volumeID, stagingPath, request)
+			attachmentName := azVolumeAttachment.Name
+			frozen := &frozenFilesystem{path: stagingPath, request: request}
+			frozen.timer = time.AfterFunc(maxFilesystemFreezeDuration, func() { d.expireFilesystemFreeze(attachmentName, frozen) })
+			f.lock.Lock()
+			if f.frozen == nil {
+				f.frozen = map[string]*frozenFilesystem{}
+			}
+			f.frozen[attachmentName] = frozen
+			f.lock.Unlock()
+		}
+	}
+
+	if err != nil {
+		klog.Errorf("failed to freeze filesystem of volume (%s) for group snapshot (%s): %v", azVolumeAttachment.Spec.VolumeID, request, err)
+	}
+	d.reportFilesystemFreeze(ctx, azVolumeAttachment, request, err)
+}
+
+// thawFilesystem thaws the filesystem of the volume of an AzVolumeAttachment if it is frozen and returns true if it was.
+func (d *DriverV2) thawFilesystem(attachmentName string) bool {
+	f := &d.filesystemFreezer
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	frozen, ok := f.frozen[attachmentName]
+	if ok {
+		d.thawFilesystemLocked(attachmentName, frozen)
+	}
+	return ok
+}
+
+func (d *DriverV2) thawFilesystemLocked(attachmentName string, frozen *frozenFilesystem) {
+	frozen.timer.Stop()
+	delete(d.filesystemFreezer.frozen, attachmentName)
+	if err := d.nodeProvisioner.ThawFilesystem(frozen.path); err != nil {
+		klog.Errorf("failed to thaw filesystem at %s: %v", frozen.path, err)
+		return
+	}
+	klog.V(2).Infof("thawed filesystem at %s", frozen.path)
+}
+
+// expireFilesystemFreeze thaws a filesystem which has been frozen for too long and reports the freeze as failed
+// so that the group snapshot is not mistaken for a consistent one.
+func (d *DriverV2) expireFilesystemFreeze(attachmentName string, frozen *frozenFilesystem) {
+	f := &d.filesystemFreezer
+	f.lock.Lock()
+	if f.frozen[attachmentName] != frozen {
+		f.lock.Unlock()
+		return
+	}
+	klog.Warningf("filesystem at %s has been frozen for longer than %v, thawing it", frozen.path, maxFilesystemFreezeDuration)
+	d.thawFilesystemLocked(attachmentName, frozen)
+	request := frozen.request
+	f.lock.Unlock()
+
+	ctx := context.Background()
+	azVolumeAttachment, err := d.azdiskClient.DiskV1beta2().AzVolumeAttachments(d.config.ObjectNamespace).Get(ctx, attachmentName, metav1.GetOptions{})
+	if err != nil {
+		klog.Errorf("failed to get AzVolumeAttachment (%s) to report the expired filesystem freeze: %v", attachmentName, err)
+		return
+	}
+	d.reportFilesystemFreeze(ctx, azVolumeAttachment, request, fmt.Errorf("filesystem was thawed after being frozen for %v", maxFilesystemFreezeDuration))
+}
+
+// reportFilesystemFreeze records the outcome of a freeze request in the status annotations of an AzVolumeAttachment.
+// An empty request clears the outcome of a withdrawn request.
+func (d *DriverV2) reportFilesystemFreeze(ctx context.Context, azVolumeAttachment *azdiskv1beta2.AzVolumeAttachment, request string, freezeErr error) {
+	updateFunc := func(obj client.Object) error {
+		azva := obj.(*azdiskv1beta2.AzVolumeAttachment)
+		// the outcome is only reported for the request that is still pending
+		if azva.Status.Annotations[consts.FilesystemFreezeRequestAnnotation] != request {
+			return nil
+		}
+		azva.Status.Annotations = azureutils.RemoveFromMap(azva.Status.Annotations, consts.FilesystemFrozenAnnotation, consts.FilesystemFreezeErrorAnnotation)
+		if request == "" {
+			return nil
+		}
+		if freezeErr != nil {
+			azva.Status.Annotations = azureutils.AddToMap(azva.Status.Annotations, consts.FilesystemFreezeErrorAnnotation, freezeErr.Error())
+		} else {
+			azva.Status.Annotations = azureutils.AddToMap(azva.Status.Annotations, consts.FilesystemFrozenAnnotation, request)
+		}
+		return nil
+	}
+	if _, err := azureutils.UpdateCRIWithRetry(ctx, nil, nil, d.azdiskClient, azVolumeAttachment, updateFunc, consts.NormalUpdateMaxNetRetry, azureutils.UpdateCRIStatus); err != nil {
+		klog.Errorf("failed to report filesystem freeze of AzVolumeAttachment (%s): %v", azVolumeAttachment.Name, err)
+	}
+}
+
+// getAzVolumeAttachmentName returns the name of the AzVolumeAttachment of a volume on the node.
+func (d *DriverV2) getAzVolumeAttachmentName(volumeID string) string {
+	diskName, err := azureutils.GetDiskName(volumeID)
+	if err != nil {
+		return ""
+	}
+	return azureutils.GetAzVolumeAttachmentName(diskName, d.NodeID)
+}
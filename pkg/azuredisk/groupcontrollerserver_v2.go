@@ -0,0 +1,194 @@
+//go:build azurediskv2
+// +build azurediskv2
+
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azuredisk
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+
+	azdiskv1beta2 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils"
+	"sigs.k8s.io/cloud-provider-azure/pkg/metrics"
+
+	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
+)
+
+// GroupControllerGetCapabilities returns the capabilities of the Group Controller plugin
+func (d *DriverV2) GroupControllerGetCapabilities(ctx context.Context, req *csi.GroupControllerGetCapabilitiesRequest) (*csi.GroupControllerGetCapabilitiesResponse, error) {
+	return &csi.GroupControllerGetCapabilitiesResponse{
+		Capabilities: d.GCCap,
+	}, nil
+}
+
+// CreateVolumeGroupSnapshot creates snapshots of a group of volumes taken at the same point in time
+func (d *DriverV2) CreateVolumeGroupSnapshot(ctx context.Context, req *csi.CreateVolumeGroupSnapshotRequest) (*csi.CreateVolumeGroupSnapshotResponse, error) {
+	groupSnapshotName := req.GetName()
+	if len(groupSnapshotName) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "CreateVolumeGroupSnapshot Name must be provided")
+	}
+	sourceVolumeIDs := req.GetSourceVolumeIds()
+	if len(sourceVolumeIDs) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "CreateVolumeGroupSnapshot Source Volume IDs must be provided")
+	}
+	groupSnapshotName = azureutils.CreateValidDiskName(groupSnapshotName, false)
+
+	mc := metrics.NewMetricContext(d.cloudProvisioner.GetMetricPrefix(), "controller_create_volume_group_snapshot", d.cloudProvisioner.GetCloud().ResourceGroup, d.cloudProvisioner.GetCloud().SubscriptionID, d.Name)
+	isOperationSucceeded := false
+	defer func() {
+		mc.ObserveOperationWithResult(isOperationSucceeded, consts.GroupSnapshotName, groupSnapshotName)
+	}()
+
+	groupSnapshot, err := d.crdProvisioner.CreateGroupSnapshot(ctx, groupSnapshotName, sourceVolumeIDs, req.GetSecrets(), req.GetParameters())
+	if err != nil {
+		return nil, err
+	}
+
+	if groupSnapshot == nil {
+		return nil, status.Error(codes.Unknown, "Error creating group snapshot")
+	}
+
+	volumeGroupSnapshot, err := newCSIVolumeGroupSnapshot(groupSnapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	isOperationSucceeded = true
+	return &csi.CreateVolumeGroupSnapshotResponse{
+		GroupSnapshot: volumeGroupSnapshot,
+	}, nil
+}
+
+// DeleteVolumeGroupSnapshot deletes the snapshots of a group snapshot
+func (d *DriverV2) DeleteVolumeGroupSnapshot(ctx context.Context, req *csi.DeleteVolumeGroupSnapshotRequest) (*csi.DeleteVolumeGroupSnapshotResponse, error) {
+	groupSnapshotID := req.GetGroupSnapshotId()
+	if len(groupSnapshotID) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Group Snapshot ID must be provided")
+	}
+
+	mc := metrics.NewMetricContext(d.cloudProvisioner.GetMetricPrefix(), "controller_delete_volume_group_snapshot", d.cloudProvisioner.GetCloud().ResourceGroup, d.cloudProvisioner.GetCloud().SubscriptionID, d.Name)
+	isOperationSucceeded := false
+	defer func() {
+		mc.ObserveOperationWithResult(isOperationSucceeded, consts.GroupSnapshotID, groupSnapshotID)
+	}()
+
+	err := d.crdProvisioner.DeleteGroupSnapshot(ctx, groupSnapshotID, req.GetSecrets())
+	if apiErrors.IsNotFound(err) {
+		// the snapshots of a group snapshot whose AzVolumeGroupSnapshot is gone can still be found by their tag, so delete them directly
+		err = d.cloudProvisioner.DeleteGroupSnapshot(ctx, groupSnapshotID, req.GetSecrets())
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	isOperationSucceeded = true
+	return &csi.DeleteVolumeGroupSnapshotResponse{}, nil
+}
+
+// GetVolumeGroupSnapshot returns the current state of a group snapshot
+func (d *DriverV2) GetVolumeGroupSnapshot(ctx context.Context, req *csi.GetVolumeGroupSnapshotRequest) (*csi.GetVolumeGroupSnapshotResponse, error) {
+	groupSnapshotID := req.GetGroupSnapshotId()
+	if len(groupSnapshotID) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Group Snapshot ID must be provided")
+	}
+
+	mc := metrics.NewMetricContext(d.cloudProvisioner.GetMetricPrefix(), "controller_get_volume_group_snapshot", d.cloudProvisioner.GetCloud().ResourceGroup, d.cloudProvisioner.GetCloud().SubscriptionID, d.Name)
+	isOperationSucceeded := false
+	defer func() {
+		mc.ObserveOperationWithResult(isOperationSucceeded, consts.GroupSnapshotID, groupSnapshotID)
+	}()
+
+	groupSnapshot, err := d.crdProvisioner.GetGroupSnapshot(ctx, groupSnapshotID, req.GetSecrets())
+	if apiErrors.IsNotFound(err) {
+		groupSnapshot, err = d.cloudProvisioner.GetGroupSnapshot(ctx, groupSnapshotID, req.GetSecrets())
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if groupSnapshot == nil {
+		return nil, status.Errorf(codes.NotFound, "group snapshot (%s) could not be found", groupSnapshotID)
+	}
+
+	// the snapshot IDs of the request must be those of the group snapshot
+	if snapshotIDs := req.GetSnapshotIds(); len(snapshotIDs) > 0 {
+		members := make(map[string]bool, len(groupSnapshot.Snapshots))
+		for _, snapshot := range groupSnapshot.Snapshots {
+			members[strings.ToLower(snapshot.SnapshotID)] = true
+		}
+		if len(snapshotIDs) != len(members) {
+			return nil, status.Errorf(codes.InvalidArgument, "group snapshot (%s) has %d snapshots but %d snapshot IDs were given", groupSnapshotID, len(members), len(snapshotIDs))
+		}
+		for _, snapshotID := range snapshotIDs {
+			if !members[strings.ToLower(snapshotID)] {
+				return nil, status.Errorf(codes.InvalidArgument, "snapshot (%s) does not belong to group snapshot (%s)", snapshotID, groupSnapshotID)
+			}
+		}
+	}
+
+	volumeGroupSnapshot, err := newCSIVolumeGroupSnapshot(groupSnapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	isOperationSucceeded = true
+	return &csi.GetVolumeGroupSnapshotResponse{
+		GroupSnapshot: volumeGroupSnapshot,
+	}, nil
+}
+
+// newCSIVolumeGroupSnapshot converts a group snapshot to its CSI representation.
+func newCSIVolumeGroupSnapshot(groupSnapshot *azdiskv1beta2.GroupSnapshot) (*csi.VolumeGroupSnapshot, error) {
+	creationTime, err := ptypes.TimestampProto(groupSnapshot.CreationTime.Time)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to convert creation timestamp: %v", err)
+	}
+
+	snapshots := make([]*csi.Snapshot, len(groupSnapshot.Snapshots))
+	for i, snapshot := range groupSnapshot.Snapshots {
+		tp, err := ptypes.TimestampProto(snapshot.CreationTime.Time)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to convert creation timestamp: %v", err)
+		}
+		snapshots[i] = &csi.Snapshot{
+			SnapshotId:      snapshot.SnapshotID,
+			SourceVolumeId:  snapshot.SourceVolumeID,
+			CreationTime:    tp,
+			ReadyToUse:      snapshot.ReadyToUse,
+			SizeBytes:       snapshot.SizeBytes,
+			GroupSnapshotId: groupSnapshot.GroupSnapshotID,
+		}
+	}
+
+	return &csi.VolumeGroupSnapshot{
+		GroupSnapshotId: groupSnapshot.GroupSnapshotID,
+		Snapshots:       snapshots,
+		CreationTime:    creationTime,
+		ReadyToUse:      groupSnapshot.ReadyToUse,
+	}, nil
+}
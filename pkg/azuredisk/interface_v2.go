@@ -20,6 +20,7 @@ import (
 	"context"
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
+	mount "k8s.io/mount-utils"
 	azdiskv1beta2 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
 	azdisk "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/client/clientset/versioned"
 	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
@@ -40,6 +41,13 @@ type CrdProvisioner interface {
 	WaitForDetach(ctx context.Context, volume, node string) error
 	GetAzVolumeAttachment(ctx context.Context, volumeID string, nodeID string) (*azdiskv1beta2.AzVolumeAttachment, error)
 	ExpandVolume(ctx context.Context, volumeID string, capacityRange *azdiskv1beta2.CapacityRange, secrets map[string]string) (*azdiskv1beta2.AzVolumeStatusDetail, error)
+	ModifyVolume(ctx context.Context, volumeID string, parameters map[string]string) error
+	GetVolume(ctx context.Context, volumeID string) (*azdiskv1beta2.VolumeEntry, error)
+	CreateSnapshot(ctx context.Context, sourceVolumeID string, snapshotName string, secrets map[string]string, parameters map[string]string) (*azdiskv1beta2.Snapshot, error)
+	DeleteSnapshot(ctx context.Context, snapshotID string, secrets map[string]string) error
+	CreateGroupSnapshot(ctx context.Context, groupSnapshotName string, sourceVolumeIDs []string, secrets map[string]string, parameters map[string]string) (*azdiskv1beta2.GroupSnapshot, error)
+	GetGroupSnapshot(ctx context.Context, groupSnapshotID string, secrets map[string]string) (*azdiskv1beta2.GroupSnapshot, error)
+	DeleteGroupSnapshot(ctx context.Context, groupSnapshotID string, secrets map[string]string) error
 	GetDiskClientSet() azdisk.Interface
 	GetConditionWatcher() *watcher.ConditionWatcher
 	IsDriverUninstall() bool
@@ -56,10 +64,13 @@ type NodeProvisioner interface {
 	FormatAndMount(source, target, fstype string, options []string) error
 	Mount(source, target, fstype string, options []string) error
 	Unmount(target string) error
+	GetMountPoints() ([]mount.MountPoint, error)
 	CleanupMountPoint(path string, extensiveCheck bool) error
 	RescanVolume(devicePath string) error
 	Resize(source, target string) error
 	NeedsResize(devicePath, volumePath string) (bool, error)
 	GetBlockSizeBytes(devicePath string) (int64, error)
 	GetVolumeStats(ctx context.Context, target string) ([]*csi.VolumeUsage, error)
+	FreezeFilesystem(path string) error
+	ThawFilesystem(path string) error
 }
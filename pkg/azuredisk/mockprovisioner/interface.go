@@ -24,6 +24,7 @@ import (
 	context "context"
 	csi "github.com/container-storage-interface/spec/lib/go/csi"
 	gomock "github.com/golang/mock/gomock"
+	mount "k8s.io/mount-utils"
 	reflect "reflect"
 	v1beta2 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
 	versioned "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/client/clientset/versioned"
@@ -186,6 +187,108 @@ func (mr *MockCrdProvisionerMockRecorder) ExpandVolume(ctx, volumeID, capacityRa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpandVolume", reflect.TypeOf((*MockCrdProvisioner)(nil).ExpandVolume), ctx, volumeID, capacityRange, secrets)
 }
 
+// ModifyVolume mocks base method
+func (m *MockCrdProvisioner) ModifyVolume(ctx context.Context, volumeID string, parameters map[string]string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ModifyVolume", ctx, volumeID, parameters)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ModifyVolume indicates an expected call of ModifyVolume
+func (mr *MockCrdProvisionerMockRecorder) ModifyVolume(ctx, volumeID, parameters interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ModifyVolume", reflect.TypeOf((*MockCrdProvisioner)(nil).ModifyVolume), ctx, volumeID, parameters)
+}
+
+// GetVolume mocks base method
+func (m *MockCrdProvisioner) GetVolume(ctx context.Context, volumeID string) (*v1beta2.VolumeEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVolume", ctx, volumeID)
+	ret0, _ := ret[0].(*v1beta2.VolumeEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVolume indicates an expected call of GetVolume
+func (mr *MockCrdProvisionerMockRecorder) GetVolume(ctx, volumeID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVolume", reflect.TypeOf((*MockCrdProvisioner)(nil).GetVolume), ctx, volumeID)
+}
+
+// CreateSnapshot mocks base method
+func (m *MockCrdProvisioner) CreateSnapshot(ctx context.Context, sourceVolumeID, snapshotName string, secrets, parameters map[string]string) (*v1beta2.Snapshot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSnapshot", ctx, sourceVolumeID, snapshotName, secrets, parameters)
+	ret0, _ := ret[0].(*v1beta2.Snapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSnapshot indicates an expected call of CreateSnapshot
+func (mr *MockCrdProvisionerMockRecorder) CreateSnapshot(ctx, sourceVolumeID, snapshotName, secrets, parameters interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSnapshot", reflect.TypeOf((*MockCrdProvisioner)(nil).CreateSnapshot), ctx, sourceVolumeID, snapshotName, secrets, parameters)
+}
+
+// DeleteSnapshot mocks base method
+func (m *MockCrdProvisioner) DeleteSnapshot(ctx context.Context, snapshotID string, secrets map[string]string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSnapshot", ctx, snapshotID, secrets)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSnapshot indicates an expected call of DeleteSnapshot
+func (mr *MockCrdProvisionerMockRecorder) DeleteSnapshot(ctx, snapshotID, secrets interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSnapshot", reflect.TypeOf((*MockCrdProvisioner)(nil).DeleteSnapshot), ctx, snapshotID, secrets)
+}
+
+// CreateGroupSnapshot mocks base method
+func (m *MockCrdProvisioner) CreateGroupSnapshot(ctx context.Context, groupSnapshotName string, sourceVolumeIDs []string, secrets, parameters map[string]string) (*v1beta2.GroupSnapshot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateGroupSnapshot", ctx, groupSnapshotName, sourceVolumeIDs, secrets, parameters)
+	ret0, _ := ret[0].(*v1beta2.GroupSnapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateGroupSnapshot indicates an expected call of CreateGroupSnapshot
+func (mr *MockCrdProvisionerMockRecorder) CreateGroupSnapshot(ctx, groupSnapshotName, sourceVolumeIDs, secrets, parameters interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateGroupSnapshot", reflect.TypeOf((*MockCrdProvisioner)(nil).CreateGroupSnapshot), ctx, groupSnapshotName, sourceVolumeIDs, secrets, parameters)
+}
+
+// GetGroupSnapshot mocks base method
+func (m *MockCrdProvisioner) GetGroupSnapshot(ctx context.Context, groupSnapshotID string, secrets map[string]string) (*v1beta2.GroupSnapshot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGroupSnapshot", ctx, groupSnapshotID, secrets)
+	ret0, _ := ret[0].(*v1beta2.GroupSnapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGroupSnapshot indicates an expected call of GetGroupSnapshot
+func (mr *MockCrdProvisionerMockRecorder) GetGroupSnapshot(ctx, groupSnapshotID, secrets interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroupSnapshot", reflect.TypeOf((*MockCrdProvisioner)(nil).GetGroupSnapshot), ctx, groupSnapshotID, secrets)
+}
+
+// DeleteGroupSnapshot mocks base method
+func (m *MockCrdProvisioner) DeleteGroupSnapshot(ctx context.Context, groupSnapshotID string, secrets map[string]string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteGroupSnapshot", ctx, groupSnapshotID, secrets)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteGroupSnapshot indicates an expected call of DeleteGroupSnapshot
+func (mr *MockCrdProvisionerMockRecorder) DeleteGroupSnapshot(ctx, groupSnapshotID, secrets interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteGroupSnapshot", reflect.TypeOf((*MockCrdProvisioner)(nil).DeleteGroupSnapshot), ctx, groupSnapshotID, secrets)
+}
+
 // GetDiskClientSet mocks base method
 func (m *MockCrdProvisioner) GetDiskClientSet() versioned.Interface {
 	m.ctrl.T.Helper()
@@ -467,3 +570,46 @@ func (mr *MockNodeProvisionerMockRecorder) GetVolumeStats(ctx, target interface{
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVolumeStats", reflect.TypeOf((*MockNodeProvisioner)(nil).GetVolumeStats), ctx, target)
 }
+
+// GetMountPoints mocks base method
+func (m *MockNodeProvisioner) GetMountPoints() ([]mount.MountPoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMountPoints")
+	ret0, _ := ret[0].([]mount.MountPoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMountPoints indicates an expected call of GetMountPoints
+func (mr *MockNodeProvisionerMockRecorder) GetMountPoints() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMountPoints", reflect.TypeOf((*MockNodeProvisioner)(nil).GetMountPoints))
+}
+
+// FreezeFilesystem mocks base method
+func (m *MockNodeProvisioner) FreezeFilesystem(path string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FreezeFilesystem", path)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FreezeFilesystem indicates an expected call of FreezeFilesystem
+func (mr *MockNodeProvisionerMockRecorder) FreezeFilesystem(path interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FreezeFilesystem", reflect.TypeOf((*MockNodeProvisioner)(nil).FreezeFilesystem), path)
+}
+
+// ThawFilesystem mocks base method
+func (m *MockNodeProvisioner) ThawFilesystem(path string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ThawFilesystem", path)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ThawFilesystem indicates an expected call of ThawFilesystem
+func (mr *MockNodeProvisionerMockRecorder) ThawFilesystem(path interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ThawFilesystem", reflect.TypeOf((*MockNodeProvisioner)(nil).ThawFilesystem), path)
+}
@@ -174,6 +174,7 @@ func (d *DriverV2) NodeStageVolume(ctx context.Context, req *csi.NodeStageVolume
 	// If the access type is block, do nothing for stage
 	switch req.GetVolumeCapability().GetAccessType().(type) {
 	case *csi.VolumeCapability_Block:
+		d.recordStagingPath(diskURI, "")
 		return &csi.NodeStageVolumeResponse{}, nil
 	}
 
@@ -183,6 +184,7 @@ func (d *DriverV2) NodeStageVolume(ctx context.Context, req *csi.NodeStageVolume
 	}
 	if mnt {
 		klog.V(2).Infof("NodeStageVolume: already mounted on target %s", target)
+		d.recordStagingPath(diskURI, target)
 		return &csi.NodeStageVolumeResponse{}, nil
 	}
 
@@ -234,6 +236,7 @@ func (d *DriverV2) NodeStageVolume(ctx context.Context, req *csi.NodeStageVolume
 		klog.V(2).Infof("NodeStageVolume: fs resize successful on target(%s) volumeid(%s).", target, diskURI)
 	}
 
+	d.recordStagingPath(diskURI, target)
 	return &csi.NodeStageVolumeResponse{}, nil
 }
 
@@ -254,6 +257,9 @@ func (d *DriverV2) NodeUnstageVolume(ctx context.Context, req *csi.NodeUnstageVo
 	}
 	defer d.volumeLocks.Release(volumeID)
 
+	// a frozen filesystem cannot be unmounted
+	d.forgetStagingPath(volumeID)
+
 	klog.V(2).Infof("NodeUnstageVolume: unmounting %s", stagingTargetPath)
 	err := d.nodeProvisioner.CleanupMountPoint(stagingTargetPath, true /*extensiveMountPointCheck*/)
 	if err != nil {
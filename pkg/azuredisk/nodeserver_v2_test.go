@@ -23,7 +23,9 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
@@ -31,6 +33,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	mount "k8s.io/mount-utils"
 	azdiskv1beta2 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
 	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
 	"sigs.k8s.io/azuredisk-csi-driver/pkg/azuredisk/mockprovisioner"
@@ -248,3 +252,98 @@ func TestRecoverMount(t *testing.T) {
 		})
 	}
 }
+
+func TestSyncFilesystemFreeze(t *testing.T) {
+	d, err := newFakeDriverV2(t)
+	assert.NoError(t, err)
+
+	volumeID := "/subscriptions/test-subscription/resourceGroups/test-rg/providers/Microsoft.Compute/disks/freeze-test-disk"
+	azVolumeAttachment := &azdiskv1beta2.AzVolumeAttachment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      d.getAzVolumeAttachmentName(volumeID),
+			Namespace: d.config.ObjectNamespace,
+			Labels:    map[string]string{consts.NodeNameLabel: d.NodeID},
+		},
+		Spec: azdiskv1beta2.AzVolumeAttachmentSpec{
+			VolumeID: volumeID,
+			NodeName: d.NodeID,
+		},
+		Status: azdiskv1beta2.AzVolumeAttachmentStatus{
+			Annotations: map[string]string{consts.FilesystemFreezeRequestAnnotation: "test-group-snapshot"},
+		},
+	}
+	azVolumeAttachment, err = d.azdiskClient.DiskV1beta2().AzVolumeAttachments(d.config.ObjectNamespace).Create(context.TODO(), azVolumeAttachment, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	getAzVolumeAttachment := func() *azdiskv1beta2.AzVolumeAttachment {
+		azva, err := d.azdiskClient.DiskV1beta2().AzVolumeAttachments(d.config.ObjectNamespace).Get(context.TODO(), azVolumeAttachment.Name, metav1.GetOptions{})
+		assert.NoError(t, err)
+		return azva
+	}
+
+	// a volume which is not staged on the node cannot be frozen
+	d.syncFilesystemFreeze(context.TODO(), azVolumeAttachment)
+	azVolumeAttachment = getAzVolumeAttachment()
+	assert.Contains(t, azVolumeAttachment.Status.Annotations, consts.FilesystemFreezeErrorAnnotation)
+
+	// the filesystem of a staged volume is frozen once requested
+	d.recordStagingPath(volumeID, "/staging/freeze-test-disk")
+	azVolumeAttachment.Status.Annotations = map[string]string{consts.FilesystemFreezeRequestAnnotation: "test-group-snapshot"}
+	d.syncFilesystemFreeze(context.TODO(), azVolumeAttachment)
+	azVolumeAttachment = getAzVolumeAttachment()
+	assert.Equal(t, "test-group-snapshot", azVolumeAttachment.Status.Annotations[consts.FilesystemFrozenAnnotation])
+	assert.NotContains(t, azVolumeAttachment.Status.Annotations, consts.FilesystemFreezeErrorAnnotation)
+	assert.Contains(t, d.filesystemFreezer.frozen, azVolumeAttachment.Name)
+
+	// the filesystem is thawed once the request is withdrawn
+	delete(azVolumeAttachment.Status.Annotations, consts.FilesystemFreezeRequestAnnotation)
+	azVolumeAttachment, err = d.azdiskClient.DiskV1beta2().AzVolumeAttachments(d.config.ObjectNamespace).UpdateStatus(context.TODO(), azVolumeAttachment, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+	d.syncFilesystemFreeze(context.TODO(), azVolumeAttachment)
+	azVolumeAttachment = getAzVolumeAttachment()
+	assert.NotContains(t, azVolumeAttachment.Status.Annotations, consts.FilesystemFrozenAnnotation)
+	assert.NotContains(t, d.filesystemFreezer.frozen, azVolumeAttachment.Name)
+}
+
+func TestRecoverStagingPaths(t *testing.T) {
+	if mounter.IsFakeUsingCSIProxy() {
+		t.Skip("Skipping test because CSI Proxy is used.")
+	}
+	d, err := newFakeDriverV2(t)
+	assert.NoError(t, err)
+
+	csiDir := filepath.Join(t.TempDir(), "plugins", "kubernetes.io", "csi")
+	writeVolumeData := func(dataDir, volumeHandle, driverName string) {
+		assert.NoError(t, os.MkdirAll(dataDir, 0750))
+		data := fmt.Sprintf(`{"volumeHandle":%q,"driverName":%q}`, volumeHandle, driverName)
+		assert.NoError(t, os.WriteFile(filepath.Join(dataDir, kubeletVolumeDataFile), []byte(data), 0600))
+	}
+
+	stagedVolumeID := "/subscriptions/test-subscription/resourceGroups/test-rg/providers/Microsoft.Compute/disks/staged-disk"
+	stagingPath := filepath.Join(csiDir, d.Name, "staged-disk-hash", kubeletStagingDir)
+	writeVolumeData(filepath.Dir(stagingPath), stagedVolumeID, d.Name)
+
+	otherDriverVolumeID := "/subscriptions/test-subscription/resourceGroups/test-rg/providers/Microsoft.Compute/disks/other-disk"
+	otherDriverStagingPath := filepath.Join(csiDir, "other.csi.driver", "other-disk-hash", kubeletStagingDir)
+	writeVolumeData(filepath.Dir(otherDriverStagingPath), otherDriverVolumeID, "other.csi.driver")
+
+	blockVolumeID := "/subscriptions/test-subscription/resourceGroups/test-rg/providers/Microsoft.Compute/disks/block-disk"
+	blockPublishPath := filepath.Join(csiDir, "volumeDevices", kubeletBlockPublishDir, "pv-block", "pod-uid")
+	writeVolumeData(filepath.Join(csiDir, "volumeDevices", "pv-block", "data"), blockVolumeID, d.Name)
+
+	fakeMounter, err := mounter.NewFakeSafeMounter()
+	assert.NoError(t, err)
+	fakeMounter.Interface.(*mounter.FakeSafeMounter).MountPoints = []mount.MountPoint{
+		{Device: "/dev/sdc", Path: stagingPath},
+		{Device: "/dev/sdd", Path: otherDriverStagingPath},
+		{Device: "/dev/sde", Path: blockPublishPath},
+		{Device: "/dev/sdf", Path: filepath.Join(csiDir, "unknown", kubeletStagingDir)},
+	}
+	d.setMounter(fakeMounter)
+
+	d.recoverStagingPaths()
+	assert.Equal(t, map[string]string{
+		strings.ToLower(stagedVolumeID): stagingPath,
+		strings.ToLower(blockVolumeID):  "",
+	}, d.filesystemFreezer.stagingPaths)
+}
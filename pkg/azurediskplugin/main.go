@@ -23,6 +23,7 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"reflect"
 	"strings"
 
 	"sigs.k8s.io/azuredisk-csi-driver/pkg/azuredisk"
@@ -85,11 +86,27 @@ var (
 	leaderElectionNamespace                  = flag.String("leader-election-namespace", consts.ReleaseNamespace, "The leader election namespace for controller")
 	nodePartition                            = flag.String("node-partition", consts.DefaultNodePartitionName, "The partition name for node plugin.")
 	controllerPartition                      = flag.String("controller-partition", consts.DefaultControllerPartitionName, "The partition name for controller plugin.")
+	shardCount                               = flag.Int("shard-count", consts.DefaultShardCount, "The number of shards the volumes and nodes are partitioned into among the controller plugin instances. A value of one or less disables sharding.")
+	checkpointIntervalInSec                  = flag.Int("checkpoint-interval-in-sec", consts.DefaultCheckpointIntervalInSec, "The interval in seconds at which the shared state of the controllers is checkpointed to shorten the recovery after a leader change. A value of zero disables checkpointing.")
+	dryRun                                   = flag.Bool("dry-run", consts.DefaultDryRun, "boolean flag to run the controllers without modifying the custom resources or the cloud resources. The operations the controllers would perform are logged and raised as events instead.")
+	faultInjectionConfigPath                 = flag.String("fault-injection-config-path", consts.DefaultFaultInjectionConfigPath, "The path of the file configuring the faults injected into the cloud operations of the controllers. An empty path disables fault injection.")
 	workerThreads                            = flag.Int("worker-threads", consts.DefaultWorkerThreads, "The number of worker thread per custom resource controller (AzVolume, attach/detach and replica controllers).")
 	waitForLunEnabled                        = flag.Bool("wait-for-lun-enabled", consts.DefaultWaitForLunEnabled, "boolean field to enable waiting for lun in PublishVolume")
 	enableTrafficManager                     = flag.Bool("enable-traffic-manager", false, "boolean flag to enable traffic manager")
 	trafficManagerPort                       = flag.Int64("traffic-manager-port", 7788, "default traffic manager port")
 	replicaVolumeAttachRetryLimit            = flag.Int("volume-attach-retry-limit", consts.DefaultReplicaVolumeAttachRetryLimit, "The maximum number of retries for creating a replica attachment.")
+	danglingAttachmentScanIntervalInSec      = flag.Int("dangling-attachment-scan-interval-in-sec", consts.DefaultDanglingAttachmentScanIntervalInSec, "The interval in seconds at which the data disks attached to the cluster nodes are reconciled against AzVolumeAttachments. A value of zero disables dangling attachment detection.")
+	danglingAttachmentGracePeriodInSec       = flag.Int("dangling-attachment-grace-period-in-sec", consts.DefaultDanglingAttachmentGracePeriodInSec, "The duration in seconds a dangling attachment of a disk referenced by a PersistentVolume is left in place before the disk is detached.")
+	orphanedDiskScanIntervalInSec            = flag.Int("orphaned-disk-scan-interval-in-sec", consts.DefaultOrphanedDiskScanIntervalInSec, "The interval in seconds at which the disks created by the driver are checked for a PersistentVolume or AzVolume referencing them. A value of zero disables orphaned disk garbage collection.")
+	orphanedDiskRetentionPeriodInSec         = flag.Int("orphaned-disk-retention-period-in-sec", consts.DefaultOrphanedDiskRetentionPeriodInSec, "The duration in seconds an orphaned disk is retained before the orphaned disk policy is applied.")
+	orphanedDiskPolicy                       = flag.String("orphaned-disk-policy", consts.DefaultOrphanedDiskPolicy, "The action taken on orphaned disks after the retention period. available values: report, snapshot-and-delete")
+	nodeLeaseDurationInSec                   = flag.Int("node-lease-duration-in-sec", consts.DefaultNodeLeaseDurationInSec, "The duration since the last node heartbeat after which the volumes attached to a NotReady or out-of-service node are force detached. A value of zero disables force detach.")
+	enableConversionWebhook                  = flag.Bool("enable-conversion-webhook", consts.DefaultEnableConversionWebhook, "boolean flag to enable the webhook converting custom resources between the v1beta1 and v1beta2 api versions")
+	enableAdmissionWebhook                   = flag.Bool("enable-admission-webhook", consts.DefaultEnableAdmissionWebhook, "boolean flag to enable the webhook defaulting and validating AzVolume specs at admission time")
+	webhookPort                              = flag.Int("webhook-port", consts.DefaultWebhookPort, "The port at which the controller serves its webhooks.")
+	webhookCertDir                           = flag.String("webhook-cert-dir", consts.DefaultWebhookCertDir, "The directory containing the serving certificate (tls.crt) and key (tls.key) of the webhooks.")
+	configMapName                            = flag.String("config-map-name", consts.DefaultConfigMapName, "The name of the ConfigMap holding the driver configuration. If set, safe-to-change fields are applied when the ConfigMap changes.")
+	configMapNamespace                       = flag.String("config-map-namespace", consts.DefaultConfigMapNamespace, "The namespace of the ConfigMap holding the driver configuration.")
 )
 
 func main() {
@@ -135,22 +152,36 @@ func getDriverConfig() *azdiskv1beta2.AzDiskDriverConfiguration {
 		// Initialize driveConfig object with default values
 		driverConfig = azdiskv1beta2.AzDiskDriverConfiguration{
 			ControllerConfig: azdiskv1beta2.ControllerConfiguration{
-				DisableAVSetNodes:             consts.DefaultDisableAVSetNodes,
-				VMType:                        consts.DefaultVMType,
-				EnableDiskOnlineResize:        consts.DefaultEnableDiskOnlineResize,
-				EnableAsyncAttach:             consts.DefaultEnableAsyncAttach,
-				EnableListVolumes:             consts.DefaultEnableListVolumes,
-				EnableListSnapshots:           consts.DefaultEnableListSnapshots,
-				EnableDiskCapacityCheck:       consts.DefaultEnableDiskCapacityCheck,
-				Enabled:                       consts.DefaultIsControllerPlugin,
-				LeaseDurationInSec:            consts.DefaultControllerLeaseDurationInSec,
-				LeaseRenewDeadlineInSec:       consts.DefaultControllerLeaseRenewDeadlineInSec,
-				LeaseRetryPeriodInSec:         consts.DefaultControllerLeaseRetryPeriodInSec,
-				LeaderElectionNamespace:       consts.ReleaseNamespace,
-				PartitionName:                 consts.DefaultControllerPartitionName,
-				WorkerThreads:                 consts.DefaultWorkerThreads,
-				WaitForLunEnabled:             consts.DefaultWaitForLunEnabled,
-				ReplicaVolumeAttachRetryLimit: consts.DefaultReplicaVolumeAttachRetryLimit,
+				DisableAVSetNodes:                   consts.DefaultDisableAVSetNodes,
+				VMType:                              consts.DefaultVMType,
+				EnableDiskOnlineResize:              consts.DefaultEnableDiskOnlineResize,
+				EnableAsyncAttach:                   consts.DefaultEnableAsyncAttach,
+				EnableListVolumes:                   consts.DefaultEnableListVolumes,
+				EnableListSnapshots:                 consts.DefaultEnableListSnapshots,
+				EnableDiskCapacityCheck:             consts.DefaultEnableDiskCapacityCheck,
+				Enabled:                             consts.DefaultIsControllerPlugin,
+				LeaseDurationInSec:                  consts.DefaultControllerLeaseDurationInSec,
+				LeaseRenewDeadlineInSec:             consts.DefaultControllerLeaseRenewDeadlineInSec,
+				LeaseRetryPeriodInSec:               consts.DefaultControllerLeaseRetryPeriodInSec,
+				LeaderElectionNamespace:             consts.ReleaseNamespace,
+				PartitionName:                       consts.DefaultControllerPartitionName,
+				ShardCount:                          consts.DefaultShardCount,
+				CheckpointIntervalInSec:             consts.DefaultCheckpointIntervalInSec,
+				DryRun:                              consts.DefaultDryRun,
+				FaultInjectionConfigPath:            consts.DefaultFaultInjectionConfigPath,
+				WorkerThreads:                       consts.DefaultWorkerThreads,
+				WaitForLunEnabled:                   consts.DefaultWaitForLunEnabled,
+				ReplicaVolumeAttachRetryLimit:       consts.DefaultReplicaVolumeAttachRetryLimit,
+				NodeLeaseDurationInSec:              consts.DefaultNodeLeaseDurationInSec,
+				DanglingAttachmentScanIntervalInSec: consts.DefaultDanglingAttachmentScanIntervalInSec,
+				DanglingAttachmentGracePeriodInSec:  consts.DefaultDanglingAttachmentGracePeriodInSec,
+				OrphanedDiskScanIntervalInSec:       consts.DefaultOrphanedDiskScanIntervalInSec,
+				OrphanedDiskRetentionPeriodInSec:    consts.DefaultOrphanedDiskRetentionPeriodInSec,
+				OrphanedDiskPolicy:                  consts.DefaultOrphanedDiskPolicy,
+				EnableConversionWebhook:             consts.DefaultEnableConversionWebhook,
+				EnableAdmissionWebhook:              consts.DefaultEnableAdmissionWebhook,
+				WebhookPort:                         consts.DefaultWebhookPort,
+				WebhookCertDir:                      consts.DefaultWebhookCertDir,
 			},
 			NodeConfig: azdiskv1beta2.NodeConfiguration{
 				VolumeAttachLimit:       consts.DefaultVolumeAttachLimit,
@@ -182,11 +213,13 @@ func getDriverConfig() *azdiskv1beta2.AzDiskDriverConfiguration {
 				KubeClientQPS:   consts.DefaultKubeClientQPS,
 				KubeClientBurst: consts.DefaultKubeClientBurst,
 			},
-			ObjectNamespace: consts.DefaultAzureDiskCrdNamespace,
-			Endpoint:        consts.DefaultEndpoint,
-			MetricsAddress:  consts.DefaultMetricsAddress,
-			DriverName:      consts.DefaultDriverName,
-			ProfilerAddress: consts.DefaultProfilerAddress,
+			ObjectNamespace:    consts.DefaultAzureDiskCrdNamespace,
+			Endpoint:           consts.DefaultEndpoint,
+			MetricsAddress:     consts.DefaultMetricsAddress,
+			DriverName:         consts.DefaultDriverName,
+			ProfilerAddress:    consts.DefaultProfilerAddress,
+			ConfigMapName:      consts.DefaultConfigMapName,
+			ConfigMapNamespace: consts.DefaultConfigMapNamespace,
 		}
 
 		// Read yaml file
@@ -210,22 +243,36 @@ func getDriverConfig() *azdiskv1beta2.AzDiskDriverConfiguration {
 	} else {
 		driverConfig = azdiskv1beta2.AzDiskDriverConfiguration{
 			ControllerConfig: azdiskv1beta2.ControllerConfiguration{
-				DisableAVSetNodes:             *disableAVSetNodes,
-				VMType:                        *vmType,
-				EnableDiskOnlineResize:        *enableDiskOnlineResize,
-				EnableAsyncAttach:             *enableAsyncAttach,
-				EnableListVolumes:             *enableListVolumes,
-				EnableListSnapshots:           *enableListSnapshots,
-				EnableDiskCapacityCheck:       *enableDiskCapacityCheck,
-				Enabled:                       *isControllerPlugin,
-				LeaseDurationInSec:            *controllerLeaseDurationInSec,
-				LeaseRenewDeadlineInSec:       *controllerLeaseRenewDeadlineInSec,
-				LeaseRetryPeriodInSec:         *controllerLeaseRetryPeriodInSec,
-				LeaderElectionNamespace:       *leaderElectionNamespace,
-				PartitionName:                 *controllerPartition,
-				WorkerThreads:                 *workerThreads,
-				WaitForLunEnabled:             *waitForLunEnabled,
-				ReplicaVolumeAttachRetryLimit: *replicaVolumeAttachRetryLimit,
+				DisableAVSetNodes:                   *disableAVSetNodes,
+				VMType:                              *vmType,
+				EnableDiskOnlineResize:              *enableDiskOnlineResize,
+				EnableAsyncAttach:                   *enableAsyncAttach,
+				EnableListVolumes:                   *enableListVolumes,
+				EnableListSnapshots:                 *enableListSnapshots,
+				EnableDiskCapacityCheck:             *enableDiskCapacityCheck,
+				Enabled:                             *isControllerPlugin,
+				LeaseDurationInSec:                  *controllerLeaseDurationInSec,
+				LeaseRenewDeadlineInSec:             *controllerLeaseRenewDeadlineInSec,
+				LeaseRetryPeriodInSec:               *controllerLeaseRetryPeriodInSec,
+				LeaderElectionNamespace:             *leaderElectionNamespace,
+				PartitionName:                       *controllerPartition,
+				ShardCount:                          *shardCount,
+				CheckpointIntervalInSec:             *checkpointIntervalInSec,
+				DryRun:                              *dryRun,
+				FaultInjectionConfigPath:            *faultInjectionConfigPath,
+				WorkerThreads:                       *workerThreads,
+				WaitForLunEnabled:                   *waitForLunEnabled,
+				ReplicaVolumeAttachRetryLimit:       *replicaVolumeAttachRetryLimit,
+				NodeLeaseDurationInSec:              *nodeLeaseDurationInSec,
+				DanglingAttachmentScanIntervalInSec: *danglingAttachmentScanIntervalInSec,
+				DanglingAttachmentGracePeriodInSec:  *danglingAttachmentGracePeriodInSec,
+				OrphanedDiskScanIntervalInSec:       *orphanedDiskScanIntervalInSec,
+				OrphanedDiskRetentionPeriodInSec:    *orphanedDiskRetentionPeriodInSec,
+				OrphanedDiskPolicy:                  *orphanedDiskPolicy,
+				EnableConversionWebhook:             *enableConversionWebhook,
+				EnableAdmissionWebhook:              *enableAdmissionWebhook,
+				WebhookPort:                         *webhookPort,
+				WebhookCertDir:                      *webhookCertDir,
 			},
 			NodeConfig: azdiskv1beta2.NodeConfiguration{
 				VolumeAttachLimit:       *volumeAttachLimit,
@@ -257,11 +304,13 @@ func getDriverConfig() *azdiskv1beta2.AzDiskDriverConfiguration {
 				KubeClientQPS:   float32(*kubeClientQPS),
 				KubeClientBurst: *kubeClientBurst,
 			},
-			ObjectNamespace: *driverObjectNamespace,
-			Endpoint:        *endpoint,
-			MetricsAddress:  *metricsAddress,
-			DriverName:      *driverName,
-			ProfilerAddress: *profilerAddress,
+			ObjectNamespace:    *driverObjectNamespace,
+			Endpoint:           *endpoint,
+			MetricsAddress:     *metricsAddress,
+			DriverName:         *driverName,
+			ProfilerAddress:    *profilerAddress,
+			ConfigMapName:      *configMapName,
+			ConfigMapNamespace: *configMapNamespace,
 		}
 
 		// Emit warning log for using deprecated command-line parameters
@@ -272,7 +321,7 @@ func getDriverConfig() *azdiskv1beta2.AzDiskDriverConfiguration {
 		})
 	}
 	driverConfig.NodeConfig.NodeID = *nodeID
-	if driverConfig == (azdiskv1beta2.AzDiskDriverConfiguration{}) {
+	if reflect.DeepEqual(driverConfig, azdiskv1beta2.AzDiskDriverConfiguration{}) {
 		klog.Fatal("failed to initialize the driverConfig object")
 	}
 	return &driverConfig
@@ -0,0 +1,274 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azureutils
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-03-01/compute"
+	"github.com/Azure/go-autorest/autorest"
+	autorestazure "github.com/Azure/go-autorest/autorest/azure"
+	"k8s.io/client-go/util/flowcontrol"
+	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/util"
+	azclients "sigs.k8s.io/cloud-provider-azure/pkg/azureclients"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azureclients/armclient"
+	azcache "sigs.k8s.io/cloud-provider-azure/pkg/cache"
+	cloudproviderconsts "sigs.k8s.io/cloud-provider-azure/pkg/consts"
+	azure "sigs.k8s.io/cloud-provider-azure/pkg/provider"
+	ratelimitconfig "sigs.k8s.io/cloud-provider-azure/pkg/provider/config"
+	"sigs.k8s.io/cloud-provider-azure/pkg/retry"
+)
+
+const (
+	// Names of the compute usages reporting the managed disk quota of a subscription.
+	standardDiskCountUsageName            = "StandardDiskCount"
+	premiumDiskCountUsageName             = "PremiumDiskCount"
+	standardSSDDiskCountUsageName         = "StandardSSDStorageDisks"
+	ultraSSDTotalSizeUsageName            = "UltraSSDTotalSizeInGB"
+	premiumV2TotalDiskSizeUsageName       = "PremiumV2TotalDiskSizeInGB"
+	defaultMaximumDiskSizeGiB       int64 = 32767
+	largeMaximumDiskSizeGiB         int64 = 65536
+
+	// usageAPIVersion is the version of the compute API used to list the usages of a subscription.
+	usageAPIVersion = "2022-03-01"
+	// listUsagesTimeout bounds the time taken to refresh the cached usages of a location.
+	listUsagesTimeout = time.Minute
+	// usageRateLimitQPS and usageRateLimitBucket throttle listing usages. The disk rate limit of the cloud provider
+	// cannot be used since the driver disables it.
+	usageRateLimitQPS    float32 = 1
+	usageRateLimitBucket         = 5
+)
+
+// diskQuota describes how the subscription quota of a disk SKU is accounted.
+type diskQuota struct {
+	usageName      string
+	isSizeBased    bool // the usage is measured in GiB instead of number of disks
+	maxDiskSizeGiB int64
+}
+
+var diskQuotas = map[compute.DiskStorageAccountTypes]diskQuota{
+	compute.StandardLRS:              {usageName: standardDiskCountUsageName, maxDiskSizeGiB: defaultMaximumDiskSizeGiB},
+	compute.PremiumLRS:               {usageName: premiumDiskCountUsageName, maxDiskSizeGiB: defaultMaximumDiskSizeGiB},
+	compute.PremiumZRS:               {usageName: premiumDiskCountUsageName, maxDiskSizeGiB: defaultMaximumDiskSizeGiB},
+	compute.StandardSSDLRS:           {usageName: standardSSDDiskCountUsageName, maxDiskSizeGiB: defaultMaximumDiskSizeGiB},
+	compute.StandardSSDZRS:           {usageName: standardSSDDiskCountUsageName, maxDiskSizeGiB: defaultMaximumDiskSizeGiB},
+	compute.UltraSSDLRS:              {usageName: ultraSSDTotalSizeUsageName, isSizeBased: true, maxDiskSizeGiB: largeMaximumDiskSizeGiB},
+	cloudproviderconsts.PremiumV2LRS: {usageName: premiumV2TotalDiskSizeUsageName, isSizeBased: true, maxDiskSizeGiB: largeMaximumDiskSizeGiB},
+}
+
+// UsageClient lists the compute resource usages and limits of a subscription.
+type UsageClient interface {
+	ListUsages(ctx context.Context, location string) ([]compute.Usage, error)
+}
+
+type usageClient struct {
+	armClient      armclient.Interface
+	subscriptionID string
+	rateLimiter    flowcontrol.RateLimiter
+}
+
+// NewUsageClient creates a UsageClient using the credentials, endpoints and backoff configuration of the specified
+// Azure cloud provider, so that listing usages is retried like the other Azure clients. Listing usages is rate limited
+// independently of the other Azure clients.
+func NewUsageClient(cloud *azure.Cloud) (UsageClient, error) {
+	if cloud == nil {
+		return nil, fmt.Errorf("cloud provider is not initialized")
+	}
+
+	env := &cloud.Environment
+	servicePrincipalToken, err := ratelimitconfig.GetServicePrincipalToken(&cloud.Config.AzureAuthConfig, env, env.ServiceManagementEndpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service principal token: %v", err)
+	}
+
+	config := &azclients.ClientConfig{
+		CloudName:               cloud.Config.Cloud,
+		Location:                cloud.Config.Location,
+		SubscriptionID:          cloud.SubscriptionID,
+		ResourceManagerEndpoint: env.ResourceManagerEndpoint,
+		Authorizer:              autorest.NewBearerAuthorizer(servicePrincipalToken),
+		RateLimitConfig:         newUsageRateLimitConfig(),
+		Backoff:                 &retry.Backoff{Steps: 1},
+		DisableAzureStackCloud:  cloud.Config.DisableAzureStackCloud,
+		UserAgent:               cloud.UserAgent,
+	}
+	if cloud.Config.CloudProviderBackoff {
+		config.Backoff = &retry.Backoff{
+			Steps:    cloud.Config.CloudProviderBackoffRetries,
+			Factor:   cloud.Config.CloudProviderBackoffExponent,
+			Duration: time.Duration(cloud.Config.CloudProviderBackoffDuration) * time.Second,
+			Jitter:   cloud.Config.CloudProviderBackoffJitter,
+		}
+	}
+
+	rateLimiterReader, _ := azclients.NewRateLimiter(config.RateLimitConfig)
+	return &usageClient{
+		armClient:      armclient.New(config.Authorizer, *config, config.ResourceManagerEndpoint, usageAPIVersion),
+		subscriptionID: config.SubscriptionID,
+		rateLimiter:    rateLimiterReader,
+	}, nil
+}
+
+// newUsageRateLimitConfig returns the rate limit configuration of the usage client.
+func newUsageRateLimitConfig() *azclients.RateLimitConfig {
+	return &azclients.RateLimitConfig{
+		CloudProviderRateLimit:       true,
+		CloudProviderRateLimitQPS:    usageRateLimitQPS,
+		CloudProviderRateLimitBucket: usageRateLimitBucket,
+	}
+}
+
+// ListUsages returns the compute resource usages of the subscription in the specified location.
+func (c *usageClient) ListUsages(ctx context.Context, location string) ([]compute.Usage, error) {
+	if !c.rateLimiter.TryAccept() {
+		return nil, retry.GetRateLimitError(false, "UsagesList").Error()
+	}
+
+	resourceID := fmt.Sprintf("/subscriptions/%s/providers/Microsoft.Compute/locations/%s/usages",
+		autorest.Encode("path", c.subscriptionID),
+		autorest.Encode("path", location))
+
+	response, rerr := c.armClient.GetResource(ctx, resourceID)
+	var usages []compute.Usage
+	for {
+		if rerr != nil {
+			return nil, fmt.Errorf("failed to list usages in location %s: %v", location, rerr.Error())
+		}
+
+		var result compute.ListUsagesResult
+		err := autorest.Respond(
+			response,
+			autorestazure.WithErrorUnlessStatusCode(http.StatusOK),
+			autorest.ByUnmarshallingJSON(&result),
+			autorest.ByClosing())
+		if err != nil {
+			return nil, fmt.Errorf("failed to list usages in location %s: %v", location, err)
+		}
+		if result.Value != nil {
+			usages = append(usages, *result.Value...)
+		}
+		if result.NextLink == nil || *result.NextLink == "" {
+			return usages, nil
+		}
+
+		request, err := c.armClient.PrepareGetRequest(ctx, autorest.WithBaseURL(*result.NextLink))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list usages in location %s: %v", location, err)
+		}
+		response, rerr = c.armClient.Send(ctx, request)
+	}
+}
+
+// NewDiskUsageCache creates a cache of the compute resource usages keyed by location.
+func NewDiskUsageCache(ttl time.Duration, getUsageClient func() UsageClient) (*azcache.TimedCache, error) {
+	return azcache.NewTimedcache(ttl, func(location string) (interface{}, error) {
+		usageClient := getUsageClient()
+		if usageClient == nil {
+			return nil, fmt.Errorf("usage client is not initialized")
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), listUsagesTimeout)
+		defer cancel()
+		return usageClient.ListUsages(ctx, location)
+	})
+}
+
+// GetZoneFromTopologySegments returns the availability zone in the topology segments, if any.
+func GetZoneFromTopologySegments(segments map[string]string, topologyKey string) string {
+	if zone, ok := segments[consts.WellKnownTopologyKey]; ok {
+		return zone
+	}
+	return segments[topologyKey]
+}
+
+// GetDiskCapacity returns the capacity available for new disks of the specified SKU in the specified location and
+// zone along with the size of the largest disk that can be created, both in bytes, using the cached subscription usages.
+// Disk quotas are tracked per region, so every zone of the location reports the capacity of the whole location and a
+// zone which does not belong to the location reports no capacity. See GetDiskCapacityFromUsages for how the capacity
+// is derived from the usages.
+func GetDiskCapacity(usageCache *azcache.TimedCache, skuName compute.DiskStorageAccountTypes, location, zone string, diskSizeGiB int64) (int64, int64, error) {
+	if zone != "" && IsValidAvailabilityZone(zone, "") && !IsValidAvailabilityZone(zone, location) {
+		return 0, 0, nil
+	}
+
+	obj, err := usageCache.Get(strings.ToLower(location), azcache.CacheReadTypeDefault)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	usages, _ := obj.([]compute.Usage)
+	available, maxVolumeSize := GetDiskCapacityFromUsages(usages, skuName, diskSizeGiB)
+	return available, maxVolumeSize, nil
+}
+
+// GetDiskCapacityFromUsages returns the capacity available for new disks of the specified SKU and the size of the
+// largest disk that can be created, both in bytes, given the compute resource usages of the subscription.
+//
+// diskSizeGiB is the size assumed for each new disk, usually the capacitydisksizegib parameter of the StorageClass; if
+// it is not positive, DefaultCapacityDiskSizeGiB is used. The capacity is derived from the quota of the SKU as follows:
+//   - For SKUs whose quota limits the number of disks, the capacity is the number of disks that can still be created
+//     multiplied by diskSizeGiB and the maximum volume size is the largest disk size supported by the SKU.
+//   - For SKUs whose quota limits the total size of the disks, the capacity is the remaining size and the maximum
+//     volume size is the smaller of the remaining size and the largest disk size supported by the SKU.
+//   - If the subscription reports no usage for the SKU, its quota is unknown, so the capacity of a single disk of
+//     diskSizeGiB is reported along with the largest disk size supported by the SKU.
+//   - If the quota is exhausted, both the capacity and the maximum volume size are zero.
+func GetDiskCapacityFromUsages(usages []compute.Usage, skuName compute.DiskStorageAccountTypes, diskSizeGiB int64) (int64, int64) {
+	if diskSizeGiB <= 0 {
+		diskSizeGiB = consts.DefaultCapacityDiskSizeGiB
+	}
+
+	quota, ok := diskQuotas[skuName]
+	if !ok {
+		quota = diskQuota{maxDiskSizeGiB: defaultMaximumDiskSizeGiB}
+	}
+	if diskSizeGiB > quota.maxDiskSizeGiB {
+		diskSizeGiB = quota.maxDiskSizeGiB
+	}
+
+	for _, usage := range usages {
+		if usage.Name == nil || usage.Name.Value == nil || !strings.EqualFold(*usage.Name.Value, quota.usageName) {
+			continue
+		}
+
+		var remaining int64
+		if usage.Limit != nil {
+			remaining = *usage.Limit
+		}
+		if usage.CurrentValue != nil {
+			remaining -= int64(*usage.CurrentValue)
+		}
+		if remaining <= 0 {
+			return 0, 0
+		}
+
+		if quota.isSizeBased {
+			maxVolumeSizeGiB := remaining
+			if maxVolumeSizeGiB > quota.maxDiskSizeGiB {
+				maxVolumeSizeGiB = quota.maxDiskSizeGiB
+			}
+			return util.GiBToBytes(remaining), util.GiBToBytes(maxVolumeSizeGiB)
+		}
+		return util.GiBToBytes(remaining * diskSizeGiB), util.GiBToBytes(quota.maxDiskSizeGiB)
+	}
+
+	return util.GiBToBytes(diskSizeGiB), util.GiBToBytes(quota.maxDiskSizeGiB)
+}
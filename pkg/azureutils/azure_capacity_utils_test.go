@@ -0,0 +1,192 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azureutils
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-03-01/compute"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/util/flowcontrol"
+	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
+	volumehelper "sigs.k8s.io/azuredisk-csi-driver/pkg/util"
+	azclients "sigs.k8s.io/cloud-provider-azure/pkg/azureclients"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azureclients/armclient"
+	azcache "sigs.k8s.io/cloud-provider-azure/pkg/cache"
+	cloudproviderconsts "sigs.k8s.io/cloud-provider-azure/pkg/consts"
+	"sigs.k8s.io/cloud-provider-azure/pkg/retry"
+)
+
+func TestGetDiskCapacityFromUsages(t *testing.T) {
+	newUsage := func(name string, currentValue int32, limit int64) compute.Usage {
+		return compute.Usage{Name: &compute.UsageName{Value: &name}, CurrentValue: &currentValue, Limit: &limit}
+	}
+
+	tests := []struct {
+		desc                      string
+		usages                    []compute.Usage
+		skuName                   compute.DiskStorageAccountTypes
+		diskSizeGiB               int64
+		expectedAvailableCapacity int64
+		expectedMaximumVolumeSize int64
+	}{
+		{
+			desc:                      "no quota reported for the sku",
+			skuName:                   compute.StandardLRS,
+			expectedAvailableCapacity: volumehelper.GiBToBytes(consts.DefaultCapacityDiskSizeGiB),
+			expectedMaximumVolumeSize: volumehelper.GiBToBytes(defaultMaximumDiskSizeGiB),
+		},
+		{
+			desc:                      "disk count quota shared by LRS and ZRS skus",
+			usages:                    []compute.Usage{newUsage("premiumdiskcount", 1, 3)},
+			skuName:                   compute.PremiumZRS,
+			expectedAvailableCapacity: volumehelper.GiBToBytes(2 * consts.DefaultCapacityDiskSizeGiB),
+			expectedMaximumVolumeSize: volumehelper.GiBToBytes(defaultMaximumDiskSizeGiB),
+		},
+		{
+			desc:                      "disk count quota with requested disk size",
+			usages:                    []compute.Usage{newUsage(standardSSDDiskCountUsageName, 10, 50)},
+			skuName:                   compute.StandardSSDLRS,
+			diskSizeGiB:               16,
+			expectedAvailableCapacity: volumehelper.GiBToBytes(40 * 16),
+			expectedMaximumVolumeSize: volumehelper.GiBToBytes(defaultMaximumDiskSizeGiB),
+		},
+		{
+			desc:                      "requested disk size larger than the maximum disk size",
+			usages:                    []compute.Usage{newUsage(standardDiskCountUsageName, 0, 2)},
+			skuName:                   compute.StandardLRS,
+			diskSizeGiB:               100000,
+			expectedAvailableCapacity: volumehelper.GiBToBytes(2 * defaultMaximumDiskSizeGiB),
+			expectedMaximumVolumeSize: volumehelper.GiBToBytes(defaultMaximumDiskSizeGiB),
+		},
+		{
+			desc:    "exceeded disk count quota",
+			usages:  []compute.Usage{newUsage(standardDiskCountUsageName, 3, 2)},
+			skuName: compute.StandardLRS,
+		},
+		{
+			desc:                      "disk size quota larger than the maximum disk size",
+			usages:                    []compute.Usage{newUsage(premiumV2TotalDiskSizeUsageName, 0, 100000)},
+			skuName:                   cloudproviderconsts.PremiumV2LRS,
+			diskSizeGiB:               16,
+			expectedAvailableCapacity: volumehelper.GiBToBytes(100000),
+			expectedMaximumVolumeSize: volumehelper.GiBToBytes(largeMaximumDiskSizeGiB),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			availableCapacity, maximumVolumeSize := GetDiskCapacityFromUsages(test.usages, test.skuName, test.diskSizeGiB)
+			assert.Equal(t, test.expectedAvailableCapacity, availableCapacity)
+			assert.Equal(t, test.expectedMaximumVolumeSize, maximumVolumeSize)
+		})
+	}
+}
+
+func TestGetZoneFromTopologySegments(t *testing.T) {
+	topologyKey := "topology.disk.csi.azure.com/zone"
+
+	assert.Equal(t, "", GetZoneFromTopologySegments(nil, topologyKey))
+	assert.Equal(t, "westus-1", GetZoneFromTopologySegments(map[string]string{topologyKey: "westus-1"}, topologyKey))
+	assert.Equal(t, "westus-2", GetZoneFromTopologySegments(map[string]string{consts.WellKnownTopologyKey: "westus-2", topologyKey: "westus-1"}, topologyKey))
+}
+
+func TestUsageClientListUsages(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/subscriptions/sub/providers/Microsoft.Compute/locations/eastus/usages":
+			fmt.Fprintf(w, `{"value":[{"name":{"value":"%s"},"currentValue":1,"limit":10}],"nextLink":"%s/next"}`, premiumDiskCountUsageName, server.URL)
+		case "/next":
+			fmt.Fprintf(w, `{"value":[{"name":{"value":"%s"},"currentValue":2,"limit":20}]}`, standardDiskCountUsageName)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := &usageClient{
+		armClient:      armclient.New(autorest.NullAuthorizer{}, azclients.ClientConfig{Backoff: &retry.Backoff{Steps: 1}}, server.URL, usageAPIVersion),
+		subscriptionID: "sub",
+		rateLimiter:    flowcontrol.NewFakeAlwaysRateLimiter(),
+	}
+
+	usages, err := client.ListUsages(context.Background(), "eastus")
+	require.NoError(t, err)
+	require.Len(t, usages, 2)
+	assert.Equal(t, premiumDiskCountUsageName, *usages[0].Name.Value)
+	assert.Equal(t, standardDiskCountUsageName, *usages[1].Name.Value)
+
+	_, err = client.ListUsages(context.Background(), "westus")
+	assert.Error(t, err)
+
+	client.rateLimiter = flowcontrol.NewFakeNeverRateLimiter()
+	_, err = client.ListUsages(context.Background(), "eastus")
+	assert.Error(t, err)
+}
+
+func TestUsageClientRateLimit(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"value":[]}`)
+	}))
+	defer server.Close()
+
+	rateLimiter, _ := azclients.NewRateLimiter(newUsageRateLimitConfig())
+	client := &usageClient{
+		armClient:      armclient.New(autorest.NullAuthorizer{}, azclients.ClientConfig{Backoff: &retry.Backoff{Steps: 1}}, server.URL, usageAPIVersion),
+		subscriptionID: "sub",
+		rateLimiter:    rateLimiter,
+	}
+
+	for i := 0; i < usageRateLimitBucket; i++ {
+		_, err := client.ListUsages(context.Background(), "eastus")
+		require.NoError(t, err)
+	}
+	_, err := client.ListUsages(context.Background(), "eastus")
+	assert.Error(t, err)
+	assert.Equal(t, usageRateLimitBucket, requests)
+}
+
+type deadlineCheckingUsageClient struct {
+	hasDeadline bool
+}
+
+func (c *deadlineCheckingUsageClient) ListUsages(ctx context.Context, location string) ([]compute.Usage, error) {
+	_, c.hasDeadline = ctx.Deadline()
+	return []compute.Usage{}, nil
+}
+
+func TestNewDiskUsageCache(t *testing.T) {
+	client := &deadlineCheckingUsageClient{}
+	usageCache, err := NewDiskUsageCache(time.Minute, func() UsageClient { return client })
+	require.NoError(t, err)
+
+	_, err = usageCache.Get("eastus", azcache.CacheReadTypeDefault)
+	require.NoError(t, err)
+	assert.True(t, client.hasDeadline)
+}
@@ -118,6 +118,7 @@ type ClientOperationMode int
 type ManagedDiskParameters struct {
 	AccountType             string
 	CachingMode             v1.AzureDataDiskCachingMode
+	CapacityDiskSizeGiB     int
 	DeviceSettings          map[string]string
 	DiskAccessID            string
 	DiskEncryptionSetID     string
@@ -231,52 +232,85 @@ func GetNodeMaxDiskCountWithLabels(labels map[string]string) (int, error) {
 	return int(maxDataDiskCount), nil
 }
 
-func GetNodeMaxDiskCount(ctx context.Context, cachedReader client.Reader, nodeName string) (int, error) {
-	nodeObj := &v1.Node{}
-	if err := cachedReader.Get(ctx, types.NamespacedName{Name: nodeName}, nodeObj); err != nil {
-		return -1, err
+// GetDiskCountsFromAzDriverNode returns the maximum and attached data disk counts published by the node plugin in the
+// AzDriverNode status. The last return value is false if the node plugin has not published the capacity of the node.
+func GetDiskCountsFromAzDriverNode(azDriverNode *azdiskv1beta2.AzDriverNode) (int, int, bool) {
+	if azDriverNode == nil || azDriverNode.Status == nil || azDriverNode.Status.MaxDataDiskCount == nil {
+		return 0, 0, false
 	}
 
-	return GetNodeMaxDiskCountWithLabels(nodeObj.Labels)
+	attachedDiskCount := 0
+	if azDriverNode.Status.AttachedDataDiskCount != nil {
+		attachedDiskCount = int(*azDriverNode.Status.AttachedDataDiskCount)
+	}
+	return int(*azDriverNode.Status.MaxDataDiskCount), attachedDiskCount, true
+}
+
+func getDiskCountsFromAzDriverNode(ctx context.Context, cachedReader client.Reader, namespace, nodeName string) (int, int, bool) {
+	azDriverNode := &azdiskv1beta2.AzDriverNode{}
+	if err := cachedReader.Get(ctx, types.NamespacedName{Namespace: namespace, Name: nodeName}, azDriverNode); err != nil {
+		return 0, 0, false
+	}
+	return GetDiskCountsFromAzDriverNode(azDriverNode)
 }
 
-func GetNodeRemainingDiskCount(ctx context.Context, cachedReader client.Reader, nodeName string) (int, error) {
+func GetNodeMaxDiskCount(ctx context.Context, cachedReader client.Reader, namespace, nodeName string) (int, error) {
+	if maxDiskCount, _, ok := getDiskCountsFromAzDriverNode(ctx, cachedReader, namespace, nodeName); ok {
+		return maxDiskCount, nil
+	}
+
 	nodeObj := &v1.Node{}
 	if err := cachedReader.Get(ctx, types.NamespacedName{Name: nodeName}, nodeObj); err != nil {
 		return -1, err
 	}
 
-	// get all replica azvolumeattachments on the node
-	attachments, err := GetAzVolumeAttachmentsForNode(ctx, cachedReader, nodeName, AllRoles)
-	if err != nil {
-		return -1, err
-	}
+	return GetNodeMaxDiskCountWithLabels(nodeObj.Labels)
+}
 
-	// get node instance type to query node capacity
-	capacity := 0
-	queryAttachable := false
+func GetNodeRemainingDiskCount(ctx context.Context, cachedReader client.Reader, namespace, nodeName string) (int, error) {
+	// prefer the capacity published by the node plugin, which also accounts for disks attached outside of the driver
+	capacity, attachedDiskCount, ok := getDiskCountsFromAzDriverNode(ctx, cachedReader, namespace, nodeName)
+	if !ok {
+		nodeObj := &v1.Node{}
+		if err := cachedReader.Get(ctx, types.NamespacedName{Name: nodeName}, nodeObj); err != nil {
+			return -1, err
+		}
 
-	if nodeObj.Labels == nil {
-		queryAttachable = true
-	} else {
-		if _, ok := nodeObj.Labels[v1.LabelInstanceTypeStable]; !ok {
+		// get node instance type to query node capacity
+		queryAttachable := false
+
+		if nodeObj.Labels == nil {
 			queryAttachable = true
 		} else {
-			capacity, _ = GetNodeMaxDiskCountWithLabels(nodeObj.Labels)
+			if _, ok := nodeObj.Labels[v1.LabelInstanceTypeStable]; !ok {
+				queryAttachable = true
+			} else {
+				capacity, _ = GetNodeMaxDiskCountWithLabels(nodeObj.Labels)
+			}
 		}
-	}
 
-	if queryAttachable {
-		// check node capacity
-		maxAttachables, ok := nodeObj.Status.Allocatable[consts.AttachableVolumesField]
-		if !ok {
-			err := status.Errorf(codes.Internal, "failed to get the max node capacity for node (%s).", nodeName)
-			return -1, err
+		if queryAttachable {
+			// check node capacity
+			maxAttachables, ok := nodeObj.Status.Allocatable[consts.AttachableVolumesField]
+			if !ok {
+				err := status.Errorf(codes.Internal, "failed to get the max node capacity for node (%s).", nodeName)
+				return -1, err
+			}
+			capacity = int(maxAttachables.Value())
 		}
-		capacity = int(maxAttachables.Value())
 	}
 
-	return capacity - len(attachments), nil
+	// get all replica azvolumeattachments on the node
+	attachments, err := GetAzVolumeAttachmentsForNode(ctx, cachedReader, nodeName, AllRoles)
+	if err != nil {
+		return -1, err
+	}
+
+	if attachedDiskCount < len(attachments) {
+		attachedDiskCount = len(attachments)
+	}
+
+	return capacity - attachedDiskCount, nil
 }
 
 func GetMaxShares(attributes map[string]string) (int, error) {
@@ -314,6 +348,79 @@ func GetSubscriptionIDFromURI(diskURI string) string {
 	return ""
 }
 
+// GetDiskVolumeEntry returns the capacity, the nodes the disk with the specified URI is attached to and its condition.
+func GetDiskVolumeEntry(ctx context.Context, cloud *azure.Cloud, diskURI string) (*azdiskv1beta2.VolumeEntry, error) {
+	if err := IsValidDiskURI(diskURI); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "disk URI(%s) is not valid: %v", diskURI, err)
+	}
+
+	diskName, err := GetDiskName(diskURI)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "could not get disk name from diskURI(%s) with error(%v)", diskURI, err)
+	}
+	resourceGroup, err := GetResourceGroupFromURI(diskURI)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "could not get resource group from diskURI(%s) with error(%v)", diskURI, err)
+	}
+	subsID := GetSubscriptionIDFromURI(diskURI)
+
+	disk, rerr := cloud.DisksClient.Get(ctx, subsID, resourceGroup, diskName)
+	if rerr != nil {
+		if strings.Contains(rerr.Error().Error(), consts.ResourceNotFound) {
+			return nil, status.Errorf(codes.NotFound, "disk(%s) does not exist", diskURI)
+		}
+		return nil, status.Errorf(codes.Internal, "could not get the disk(%s) under rg(%s) with error(%v)", diskName, resourceGroup, rerr.Error())
+	}
+
+	managedBy := []string{}
+	if disk.ManagedBy != nil {
+		managedBy = append(managedBy, *disk.ManagedBy)
+	}
+	if disk.ManagedByExtended != nil {
+		for _, vmID := range *disk.ManagedByExtended {
+			if disk.ManagedBy == nil || !strings.EqualFold(vmID, *disk.ManagedBy) {
+				managedBy = append(managedBy, vmID)
+			}
+		}
+	}
+	nodeList := []string{}
+	for _, vmID := range managedBy {
+		attachedNode, err := cloud.VMSet.GetNodeNameByProviderID(vmID)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "could not get node name for VM(%s) with error(%v)", vmID, err)
+		}
+		nodeList = append(nodeList, string(attachedNode))
+	}
+
+	details := &azdiskv1beta2.VolumeDetails{
+		VolumeID: diskURI,
+	}
+	if disk.ID != nil {
+		details.VolumeID = *disk.ID
+	}
+
+	condition := &azdiskv1beta2.VolumeCondition{}
+	if disk.DiskProperties != nil {
+		if disk.DiskProperties.DiskSizeGB != nil {
+			details.CapacityBytes = util.GiBToBytes(int64(*disk.DiskProperties.DiskSizeGB))
+		}
+		provisioningState := ""
+		if disk.DiskProperties.ProvisioningState != nil {
+			provisioningState = *disk.DiskProperties.ProvisioningState
+		}
+		condition.Abnormal = strings.EqualFold(provisioningState, consts.ProvisioningStateFailed)
+		condition.Message = fmt.Sprintf("disk state: %s, provisioning state: %s", disk.DiskProperties.DiskState, provisioningState)
+	}
+
+	return &azdiskv1beta2.VolumeEntry{
+		Details: details,
+		Status: &azdiskv1beta2.VolumeStatus{
+			PublishedNodeIds: nodeList,
+			Condition:        condition,
+		},
+	}, nil
+}
+
 func ValidateDiskEncryptionType(encryptionType string) error {
 	if encryptionType == "" {
 		return nil
@@ -428,6 +535,14 @@ func ParseDiskParameters(parameters map[string]string, filterMode FilterMode) (M
 			if err != nil {
 				return diskParams, fmt.Errorf("parse %s failed with error: %v", v, err)
 			}
+		case consts.CapacityDiskSizeGiBField:
+			diskParams.CapacityDiskSizeGiB, err = strconv.Atoi(v)
+			if err != nil {
+				return diskParams, fmt.Errorf("parse %s failed with error: %v", v, err)
+			}
+			if diskParams.CapacityDiskSizeGiB < consts.MinimumDiskSizeGiB {
+				return diskParams, fmt.Errorf("%s must be at least %d, got %s", consts.CapacityDiskSizeGiBField, consts.MinimumDiskSizeGiB, v)
+			}
 		case consts.DiskNameField:
 			diskParams.DiskName = v
 		case consts.DesIDField:
@@ -510,6 +625,46 @@ func ParseDiskParameters(parameters map[string]string, filterMode FilterMode) (M
 	return diskParams, err
 }
 
+// modifiableDiskParameters are the disk parameters which can be changed after the disk has been created.
+var modifiableDiskParameters = map[string]bool{
+	consts.SkuNameField:           true,
+	consts.DiskIOPSReadWriteField: true,
+	consts.DiskMBPSReadWriteField: true,
+	consts.EnableBurstingField:    true,
+}
+
+// IsModifiableDiskParameter returns true if the disk parameter can be changed after the disk has been created.
+func IsModifiableDiskParameter(key string) bool {
+	return modifiableDiskParameters[strings.ToLower(key)]
+}
+
+// GetModifiedDiskParameters returns the modifiable disk parameters whose desired value differs from their current value.
+// The returned parameters are keyed by their lower-case name.
+func GetModifiedDiskParameters(desired, current map[string]string) map[string]string {
+	currentValues := make(map[string]string, len(modifiableDiskParameters))
+	for key, value := range current {
+		if IsModifiableDiskParameter(key) {
+			currentValues[strings.ToLower(key)] = value
+		}
+	}
+
+	var modified map[string]string
+	for key, value := range desired {
+		if !IsModifiableDiskParameter(key) {
+			continue
+		}
+		key = strings.ToLower(key)
+		if currentValue, ok := currentValues[key]; (ok && strings.EqualFold(currentValue, value)) || (!ok && value == "") {
+			continue
+		}
+		if modified == nil {
+			modified = make(map[string]string)
+		}
+		modified[key] = value
+	}
+	return modified
+}
+
 func ParseMaxShares(maxSharesValue string) (int, error) {
 	maxShares, err := strconv.Atoi(maxSharesValue)
 	if err != nil {
@@ -1023,11 +1178,247 @@ func ListAzVolumes(ctx context.Context, cachedClient client.Client, azDiskClient
 	return *azVolumeList, err
 }
 
+// GetAzVolumeSecretName returns the name of the Secret holding the secrets for the AzVolume.
+func GetAzVolumeSecretName(azVolumeName string) string {
+	return azVolumeName + "-secrets"
+}
+
+// StoreAzVolumeSecrets creates or updates the Secret holding the secrets for the AzVolume and returns a reference to it.
+// No Secret is created if there are no secrets.
+func StoreAzVolumeSecrets(ctx context.Context, kubeClient clientset.Interface, azVolume *azdiskv1beta2.AzVolume, secrets map[string]string) (*v1.SecretReference, error) {
+	return StoreSecrets(ctx, kubeClient, azVolume, GetAzVolumeSecretName(azVolume.Name), secrets)
+}
+
+// GetAzVolumeSecrets returns the secrets for the AzVolume, resolving its secret reference if set.
+func GetAzVolumeSecrets(ctx context.Context, kubeClient clientset.Interface, azVolume *azdiskv1beta2.AzVolume) (map[string]string, error) {
+	if azVolume.Spec.SecretRef == nil {
+		// AzVolumes which have not been migrated yet still carry their secrets in the spec
+		return azVolume.Spec.Secrets, nil
+	}
+	return GetSecrets(ctx, kubeClient, azVolume, azVolume.Spec.SecretRef)
+}
+
+// DeleteAzVolumeSecrets deletes the Secret referenced by the AzVolume, if any.
+func DeleteAzVolumeSecrets(ctx context.Context, kubeClient clientset.Interface, azVolume *azdiskv1beta2.AzVolume) error {
+	return DeleteSecrets(ctx, kubeClient, azVolume, azVolume.Spec.SecretRef)
+}
+
+// GetAzSnapshotSecretName returns the name of the Secret holding the secrets for the AzSnapshot.
+func GetAzSnapshotSecretName(azSnapshotName string) string {
+	return azSnapshotName + "-snapshot-secrets"
+}
+
+// StoreAzSnapshotSecrets creates or updates the Secret holding the secrets for the AzSnapshot and returns a reference to it.
+// No Secret is created if there are no secrets.
+func StoreAzSnapshotSecrets(ctx context.Context, kubeClient clientset.Interface, azSnapshot *azdiskv1beta2.AzSnapshot, secrets map[string]string) (*v1.SecretReference, error) {
+	return StoreSecrets(ctx, kubeClient, azSnapshot, GetAzSnapshotSecretName(azSnapshot.Name), secrets)
+}
+
+// GetAzSnapshotSecrets returns the secrets for the AzSnapshot stored in the Secret it references.
+func GetAzSnapshotSecrets(ctx context.Context, kubeClient clientset.Interface, azSnapshot *azdiskv1beta2.AzSnapshot) (map[string]string, error) {
+	return GetSecrets(ctx, kubeClient, azSnapshot, azSnapshot.Spec.SecretRef)
+}
+
+// DeleteAzSnapshotSecrets deletes the Secret referenced by the AzSnapshot, if any.
+func DeleteAzSnapshotSecrets(ctx context.Context, kubeClient clientset.Interface, azSnapshot *azdiskv1beta2.AzSnapshot) error {
+	return DeleteSecrets(ctx, kubeClient, azSnapshot, azSnapshot.Spec.SecretRef)
+}
+
+// GetAzVolumeGroupSnapshotSecretName returns the name of the Secret holding the secrets for the AzVolumeGroupSnapshot.
+func GetAzVolumeGroupSnapshotSecretName(azVolumeGroupSnapshotName string) string {
+	return azVolumeGroupSnapshotName + "-group-snapshot-secrets"
+}
+
+// StoreAzVolumeGroupSnapshotSecrets creates or updates the Secret holding the secrets for the AzVolumeGroupSnapshot and returns a reference to it.
+// No Secret is created if there are no secrets.
+func StoreAzVolumeGroupSnapshotSecrets(ctx context.Context, kubeClient clientset.Interface, azVolumeGroupSnapshot *azdiskv1beta2.AzVolumeGroupSnapshot, secrets map[string]string) (*v1.SecretReference, error) {
+	return StoreSecrets(ctx, kubeClient, azVolumeGroupSnapshot, GetAzVolumeGroupSnapshotSecretName(azVolumeGroupSnapshot.Name), secrets)
+}
+
+// GetAzVolumeGroupSnapshotSecrets returns the secrets for the AzVolumeGroupSnapshot stored in the Secret it references.
+func GetAzVolumeGroupSnapshotSecrets(ctx context.Context, kubeClient clientset.Interface, azVolumeGroupSnapshot *azdiskv1beta2.AzVolumeGroupSnapshot) (map[string]string, error) {
+	return GetSecrets(ctx, kubeClient, azVolumeGroupSnapshot, azVolumeGroupSnapshot.Spec.SecretRef)
+}
+
+// DeleteAzVolumeGroupSnapshotSecrets deletes the Secret referenced by the AzVolumeGroupSnapshot, if any.
+func DeleteAzVolumeGroupSnapshotSecrets(ctx context.Context, kubeClient clientset.Interface, azVolumeGroupSnapshot *azdiskv1beta2.AzVolumeGroupSnapshot) error {
+	return DeleteSecrets(ctx, kubeClient, azVolumeGroupSnapshot, azVolumeGroupSnapshot.Spec.SecretRef)
+}
+
+// getSecretsOwnerLabel returns the kind of a CRI whose secrets are stored in a Secret and the label identifying the CRI on the Secret.
+func getSecretsOwnerLabel(owner client.Object) (string, string, error) {
+	switch owner.(type) {
+	case *azdiskv1beta2.AzVolume:
+		return "AzVolume", consts.VolumeNameLabel, nil
+	case *azdiskv1beta2.AzSnapshot:
+		return "AzSnapshot", consts.SnapshotNameLabel, nil
+	case *azdiskv1beta2.AzVolumeGroupSnapshot:
+		return "AzVolumeGroupSnapshot", consts.GroupSnapshotNameLabel, nil
+	default:
+		return "", "", fmt.Errorf("secrets cannot be stored for object of type %T", owner)
+	}
+}
+
+// StoreSecrets creates or updates the named Secret holding the secrets for the CRI and returns a reference to it.
+// The CRI becomes the owner of the Secret once it has a UID so that the Secret is garbage collected with the CRI.
+// No Secret is created if there are no secrets.
+func StoreSecrets(ctx context.Context, kubeClient clientset.Interface, owner client.Object, secretName string, secrets map[string]string) (*v1.SecretReference, error) {
+	if len(secrets) == 0 {
+		return nil, nil
+	}
+
+	kind, ownerLabel, err := getSecretsOwnerLabel(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make(map[string][]byte, len(secrets))
+	for key, value := range secrets {
+		data[key] = []byte(value)
+	}
+
+	namespace := owner.GetNamespace()
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretName,
+			Namespace: namespace,
+			Labels:    map[string]string{ownerLabel: owner.GetName()},
+		},
+		Type: v1.SecretTypeOpaque,
+		Data: data,
+	}
+	if owner.GetUID() != "" {
+		secret.OwnerReferences = []metav1.OwnerReference{getSecretsOwnerReference(owner, kind)}
+	}
+
+	secretClient := kubeClient.CoreV1().Secrets(namespace)
+	if _, err := secretClient.Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+		if !k8serrors.IsAlreadyExists(err) {
+			return nil, err
+		}
+		if err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			existing, err := secretClient.Get(ctx, secret.Name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			if existing.Labels[ownerLabel] != owner.GetName() {
+				return fmt.Errorf("secret (%s/%s) does not belong to %s (%s)", namespace, secret.Name, kind, owner.GetName())
+			}
+			ownerRefs, ownerChanged := addSecretsOwnerReference(existing.OwnerReferences, owner, kind)
+			if reflect.DeepEqual(existing.Data, data) && !ownerChanged {
+				return nil
+			}
+			existing.Data = data
+			existing.OwnerReferences = ownerRefs
+			_, err = secretClient.Update(ctx, existing, metav1.UpdateOptions{})
+			return err
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	return &v1.SecretReference{Name: secret.Name, Namespace: namespace}, nil
+}
+
+// SetSecretsOwner makes the CRI the owner of the Secret holding its secrets.
+// It is used for Secrets which were stored before the CRI was created.
+func SetSecretsOwner(ctx context.Context, kubeClient clientset.Interface, owner client.Object, secretRef *v1.SecretReference) error {
+	if secretRef == nil {
+		return nil
+	}
+
+	kind, _, err := getSecretsOwnerLabel(owner)
+	if err != nil {
+		return err
+	}
+
+	secretClient := kubeClient.CoreV1().Secrets(getSecretNamespace(owner, secretRef))
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		secret, err := secretClient.Get(ctx, secretRef.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		ownerRefs, ownerChanged := addSecretsOwnerReference(secret.OwnerReferences, owner, kind)
+		if !ownerChanged {
+			return nil
+		}
+		secret.OwnerReferences = ownerRefs
+		_, err = secretClient.Update(ctx, secret, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// GetSecrets returns the secrets stored in the Secret referenced by the CRI.
+func GetSecrets(ctx context.Context, kubeClient clientset.Interface, owner client.Object, secretRef *v1.SecretReference) (map[string]string, error) {
+	if secretRef == nil {
+		return nil, nil
+	}
+
+	namespace := getSecretNamespace(owner, secretRef)
+	secret, err := kubeClient.CoreV1().Secrets(namespace).Get(ctx, secretRef.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret (%s/%s) for %s: %w", namespace, secretRef.Name, owner.GetName(), err)
+	}
+
+	secrets := make(map[string]string, len(secret.Data)+len(secret.StringData))
+	for key, value := range secret.Data {
+		secrets[key] = string(value)
+	}
+	for key, value := range secret.StringData {
+		secrets[key] = value
+	}
+	return secrets, nil
+}
+
+// DeleteSecrets deletes the Secret referenced by the CRI, if any.
+func DeleteSecrets(ctx context.Context, kubeClient clientset.Interface, owner client.Object, secretRef *v1.SecretReference) error {
+	if secretRef == nil {
+		return nil
+	}
+
+	namespace := getSecretNamespace(owner, secretRef)
+	if err := kubeClient.CoreV1().Secrets(namespace).Delete(ctx, secretRef.Name, metav1.DeleteOptions{}); err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+func getSecretNamespace(owner client.Object, secretRef *v1.SecretReference) string {
+	if secretRef.Namespace != "" {
+		return secretRef.Namespace
+	}
+	return owner.GetNamespace()
+}
+
+func getSecretsOwnerReference(owner client.Object, kind string) metav1.OwnerReference {
+	return metav1.OwnerReference{
+		APIVersion: azdiskv1beta2.SchemeGroupVersion.String(),
+		Kind:       kind,
+		Name:       owner.GetName(),
+		UID:        owner.GetUID(),
+	}
+}
+
+// addSecretsOwnerReference adds the CRI to the owner references of a Secret and returns whether they changed.
+func addSecretsOwnerReference(ownerRefs []metav1.OwnerReference, owner client.Object, kind string) ([]metav1.OwnerReference, bool) {
+	if owner.GetUID() == "" {
+		return ownerRefs, false
+	}
+	for _, ownerRef := range ownerRefs {
+		if ownerRef.UID == owner.GetUID() {
+			return ownerRefs, false
+		}
+	}
+	return append(ownerRefs, getSecretsOwnerReference(owner, kind)), true
+}
+
 func AnnotateAPIVersion(obj client.Object) {
 	switch obj.(type) {
 	case *azdiskv1beta2.AzDriverNode:
 	case *azdiskv1beta2.AzVolume:
 	case *azdiskv1beta2.AzVolumeAttachment:
+	case *azdiskv1beta2.AzSnapshot:
+	case *azdiskv1beta2.AzVolumeGroupSnapshot:
 	default:
 		return
 	}
@@ -1060,6 +1451,30 @@ func ListAzVolumeAttachments(ctx context.Context, cachedClient client.Client, az
 	return *azVolumeAttachmentList, err
 }
 
+func GetAzSnapshot(ctx context.Context, cachedClient client.Client, azDiskClient azdisk.Interface, azSnapshotName, namespace string, useCache bool) (*azdiskv1beta2.AzSnapshot, error) {
+	var azSnapshot *azdiskv1beta2.AzSnapshot
+	var err error
+	if useCache {
+		azSnapshot = &azdiskv1beta2.AzSnapshot{}
+		err = cachedClient.Get(ctx, types.NamespacedName{Name: azSnapshotName, Namespace: namespace}, azSnapshot)
+	} else {
+		azSnapshot, err = azDiskClient.DiskV1beta2().AzSnapshots(namespace).Get(ctx, azSnapshotName, metav1.GetOptions{})
+	}
+	return azSnapshot, err
+}
+
+func GetAzVolumeGroupSnapshot(ctx context.Context, cachedClient client.Client, azDiskClient azdisk.Interface, azVolumeGroupSnapshotName, namespace string, useCache bool) (*azdiskv1beta2.AzVolumeGroupSnapshot, error) {
+	var azVolumeGroupSnapshot *azdiskv1beta2.AzVolumeGroupSnapshot
+	var err error
+	if useCache {
+		azVolumeGroupSnapshot = &azdiskv1beta2.AzVolumeGroupSnapshot{}
+		err = cachedClient.Get(ctx, types.NamespacedName{Name: azVolumeGroupSnapshotName, Namespace: namespace}, azVolumeGroupSnapshot)
+	} else {
+		azVolumeGroupSnapshot, err = azDiskClient.DiskV1beta2().AzVolumeGroupSnapshots(namespace).Get(ctx, azVolumeGroupSnapshotName, metav1.GetOptions{})
+	}
+	return azVolumeGroupSnapshot, err
+}
+
 func GetAzVolumeAttachmentsForVolume(ctx context.Context, cachedClient client.Reader, volumeName string, azVolumeAttachmentRole AttachmentRoleMode) (attachments []azdiskv1beta2.AzVolumeAttachment, err error) {
 	w, _ := workflow.GetWorkflowFromContext(ctx)
 	w.Logger().V(5).Infof("Getting AzVolumeAttachment list for volume (%s)", volumeName)
@@ -1187,6 +1602,24 @@ func UpdateCRIWithRetry(ctx context.Context, informerFactory azdiskinformers.Sha
 			if (updateMode & UpdateCRI) != 0 {
 				updatedObj, err = azDiskClient.DiskV1beta2().AzDriverNodes(target.Namespace).Update(ctx, target, metav1.UpdateOptions{})
 			}
+		case *azdiskv1beta2.AzSnapshot:
+			if (updateMode&UpdateCRIStatus) != 0 && !reflect.DeepEqual(originalObj.(*azdiskv1beta2.AzSnapshot).Status, target.Status) {
+				if updatedObj, err = azDiskClient.DiskV1beta2().AzSnapshots(target.Namespace).UpdateStatus(ctx, target, metav1.UpdateOptions{}); err != nil {
+					return err
+				}
+			}
+			if (updateMode & UpdateCRI) != 0 {
+				updatedObj, err = azDiskClient.DiskV1beta2().AzSnapshots(target.Namespace).Update(ctx, target, metav1.UpdateOptions{})
+			}
+		case *azdiskv1beta2.AzVolumeGroupSnapshot:
+			if (updateMode&UpdateCRIStatus) != 0 && !reflect.DeepEqual(originalObj.(*azdiskv1beta2.AzVolumeGroupSnapshot).Status, target.Status) {
+				if updatedObj, err = azDiskClient.DiskV1beta2().AzVolumeGroupSnapshots(target.Namespace).UpdateStatus(ctx, target, metav1.UpdateOptions{}); err != nil {
+					return err
+				}
+			}
+			if (updateMode & UpdateCRI) != 0 {
+				updatedObj, err = azDiskClient.DiskV1beta2().AzVolumeGroupSnapshots(target.Namespace).Update(ctx, target, metav1.UpdateOptions{})
+			}
 		case *storagev1.VolumeAttachment:
 			if (updateMode&UpdateCRIStatus) != 0 && !reflect.DeepEqual(originalObj.(*storagev1.VolumeAttachment).Status, target.Status) {
 				if err = cachedClient.Status().Update(ctx, target); err != nil {
@@ -1252,8 +1685,10 @@ func validateParamsForUpdateCRIWithRetry(cachedClient client.Client, azDiskClien
 	_, isAzVolume := originalObj.(*azdiskv1beta2.AzVolume)
 	_, isAzVolumeAttachment := originalObj.(*azdiskv1beta2.AzVolumeAttachment)
 	_, isAzDriverNode := originalObj.(*azdiskv1beta2.AzDriverNode)
+	_, isAzSnapshot := originalObj.(*azdiskv1beta2.AzSnapshot)
+	_, isAzVolumeGroupSnapshot := originalObj.(*azdiskv1beta2.AzVolumeGroupSnapshot)
 	_, isVolumeAttachment := originalObj.(*storagev1.VolumeAttachment)
-	if azDiskClient == nil && (isAzVolume || isAzVolumeAttachment || isAzDriverNode) {
+	if azDiskClient == nil && (isAzVolume || isAzVolumeAttachment || isAzDriverNode || isAzSnapshot || isAzVolumeGroupSnapshot) {
 		return status.Errorf(codes.Internal, "azDiskClient is not provided.")
 	}
 	if cachedClient == nil && isVolumeAttachment {
@@ -1305,6 +1740,28 @@ func getOriginalObjFromClientForUpdate(ctx context.Context, w workflow.Workflow,
 		if err != nil || originalObj == nil {
 			originalObj, err = azDiskClient.DiskV1beta2().AzDriverNodes(target.Namespace).Get(ctx, objName, metav1.GetOptions{})
 		}
+	case *azdiskv1beta2.AzSnapshot:
+		if informerFactory != nil {
+			originalObj, err = informerFactory.Disk().V1beta2().AzSnapshots().Lister().AzSnapshots(target.Namespace).Get(objName)
+		} else if cachedClient != nil {
+			originalObj = &azdiskv1beta2.AzSnapshot{}
+			err = cachedClient.Get(ctx, types.NamespacedName{Namespace: target.Namespace, Name: objName}, originalObj)
+		}
+
+		if err != nil || originalObj == nil {
+			originalObj, err = azDiskClient.DiskV1beta2().AzSnapshots(target.Namespace).Get(ctx, objName, metav1.GetOptions{})
+		}
+	case *azdiskv1beta2.AzVolumeGroupSnapshot:
+		if informerFactory != nil {
+			originalObj, err = informerFactory.Disk().V1beta2().AzVolumeGroupSnapshots().Lister().AzVolumeGroupSnapshots(target.Namespace).Get(objName)
+		} else if cachedClient != nil {
+			originalObj = &azdiskv1beta2.AzVolumeGroupSnapshot{}
+			err = cachedClient.Get(ctx, types.NamespacedName{Namespace: target.Namespace, Name: objName}, originalObj)
+		}
+
+		if err != nil || originalObj == nil {
+			originalObj, err = azDiskClient.DiskV1beta2().AzVolumeGroupSnapshots(target.Namespace).Get(ctx, objName, metav1.GetOptions{})
+		}
 	case *storagev1.VolumeAttachment:
 		originalObj = &storagev1.VolumeAttachment{}
 		err = cachedClient.Get(ctx, types.NamespacedName{Namespace: target.Namespace, Name: objName}, originalObj)
@@ -1478,3 +1935,15 @@ func GetDefaultDiskMBPSReadWrite(requestGiB int) int {
 	}
 	return bandwidth
 }
+
+// GetClusterID returns the UID of the kube-system namespace, which identifies the cluster in the tags of the disks it creates.
+func GetClusterID(ctx context.Context, kubeClient clientset.Interface) (string, error) {
+	namespace, err := kubeClient.CoreV1().Namespaces().Get(ctx, metav1.NamespaceSystem, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	if namespace.UID == "" {
+		return "", fmt.Errorf("namespace %s has no UID", metav1.NamespaceSystem)
+	}
+	return string(namespace.UID), nil
+}
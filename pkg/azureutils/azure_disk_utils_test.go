@@ -43,6 +43,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k8sRuntime "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	fakev1 "k8s.io/client-go/kubernetes/fake"
 	testingClient "k8s.io/client-go/testing"
 	"k8s.io/utils/pointer"
 	azdiskv1beta2 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
@@ -1501,6 +1502,18 @@ func TestParseDiskParameters(t *testing.T) {
 			},
 			expectedError: nil,
 		},
+		{
+			name:        "invalid capacity disk size in parameters",
+			inputParams: map[string]string{consts.CapacityDiskSizeGiBField: "0"},
+			filterMode:  StrictValidation,
+			expectedManagedDiskParameters: ManagedDiskParameters{
+				Incremental:    true,
+				Tags:           make(map[string]string),
+				VolumeContext:  map[string]string{consts.CapacityDiskSizeGiBField: "0"},
+				DeviceSettings: make(map[string]string),
+			},
+			expectedError: fmt.Errorf("capacitydisksizegib must be at least 1, got 0"),
+		},
 		{
 			name:        "invalid value in parameters",
 			inputParams: map[string]string{consts.LogicalSectorSizeField: "invalidValue"},
@@ -1523,6 +1536,7 @@ func TestParseDiskParameters(t *testing.T) {
 				consts.DiskIOPSReadWriteField:   "diskIOPSReadWrite",
 				consts.DiskMBPSReadWriteField:   "diskMBPSReadWrite",
 				consts.LogicalSectorSizeField:   "1",
+				consts.CapacityDiskSizeGiBField: "16",
 				consts.DiskNameField:            "diskName",
 				consts.DesIDField:               "diskEncyptionSetID",
 				consts.TagsField:                "key0=value0, key1=value1",
@@ -1575,6 +1589,7 @@ func TestParseDiskParameters(t *testing.T) {
 					consts.DiskIOPSReadWriteField:   "diskIOPSReadWrite",
 					consts.DiskMBPSReadWriteField:   "diskMBPSReadWrite",
 					consts.LogicalSectorSizeField:   "1",
+					consts.CapacityDiskSizeGiBField: "16",
 					consts.DiskNameField:            "diskName",
 					consts.DesIDField:               "diskEncyptionSetID",
 					consts.TagsField:                "key0=value0, key1=value1",
@@ -1594,9 +1609,10 @@ func TestParseDiskParameters(t *testing.T) {
 					consts.IncrementalField:         "false",
 					consts.ZonedField:               "ignored",
 				},
-				DeviceSettings:    make(map[string]string),
-				MaxShares:         1,
-				LogicalSectorSize: 1,
+				DeviceSettings:      make(map[string]string),
+				MaxShares:           1,
+				LogicalSectorSize:   1,
+				CapacityDiskSizeGiB: 16,
 			},
 			expectedError: nil,
 		},
@@ -2844,6 +2860,145 @@ func TestAnnotateAPIVersion(t *testing.T) {
 	}
 }
 
+func TestAzVolumeSecrets(t *testing.T) {
+	kubeClient := fakev1.NewSimpleClientset()
+	azVolume := &azdiskv1beta2.AzVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-volume",
+			Namespace: consts.DefaultAzureDiskCrdNamespace,
+		},
+	}
+
+	// no Secret is created without secrets
+	secretRef, err := StoreAzVolumeSecrets(context.TODO(), kubeClient, azVolume, nil)
+	require.NoError(t, err)
+	assert.Nil(t, secretRef)
+
+	// the secrets in the spec are used until they are migrated
+	azVolume.Spec.Secrets = map[string]string{"secret": "spec"}
+	secrets, err := GetAzVolumeSecrets(context.TODO(), kubeClient, azVolume)
+	require.NoError(t, err)
+	assert.Equal(t, azVolume.Spec.Secrets, secrets)
+
+	secretRef, err = StoreAzVolumeSecrets(context.TODO(), kubeClient, azVolume, map[string]string{"secret": "old"})
+	require.NoError(t, err)
+	require.NotNil(t, secretRef)
+	assert.Equal(t, GetAzVolumeSecretName(azVolume.Name), secretRef.Name)
+
+	// the Secret has no owner until the AzVolume has been created
+	secret, err := kubeClient.CoreV1().Secrets(azVolume.Namespace).Get(context.TODO(), secretRef.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, secret.OwnerReferences)
+
+	azVolume.UID = types.UID("test-volume-uid")
+	require.NoError(t, SetSecretsOwner(context.TODO(), kubeClient, azVolume, secretRef))
+	secret, err = kubeClient.CoreV1().Secrets(azVolume.Namespace).Get(context.TODO(), secretRef.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Len(t, secret.OwnerReferences, 1)
+	assert.Equal(t, "AzVolume", secret.OwnerReferences[0].Kind)
+	assert.Equal(t, azVolume.UID, secret.OwnerReferences[0].UID)
+
+	// storing the secrets again updates the existing Secret and keeps its owner
+	_, err = StoreAzVolumeSecrets(context.TODO(), kubeClient, azVolume, map[string]string{"secret": "new"})
+	require.NoError(t, err)
+	secret, err = kubeClient.CoreV1().Secrets(azVolume.Namespace).Get(context.TODO(), secretRef.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Len(t, secret.OwnerReferences, 1)
+
+	// a Secret belonging to another AzVolume is not overwritten
+	otherVolume := azVolume.DeepCopy()
+	otherVolume.Name = "other-volume"
+	_, err = StoreSecrets(context.TODO(), kubeClient, otherVolume, secretRef.Name, map[string]string{"secret": "other"})
+	assert.Error(t, err)
+
+	azVolume.Spec.Secrets = nil
+	azVolume.Spec.SecretRef = secretRef
+	secrets, err = GetAzVolumeSecrets(context.TODO(), kubeClient, azVolume)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"secret": "new"}, secrets)
+
+	require.NoError(t, DeleteAzVolumeSecrets(context.TODO(), kubeClient, azVolume))
+	_, err = GetAzVolumeSecrets(context.TODO(), kubeClient, azVolume)
+	assert.True(t, k8serrors.IsNotFound(err))
+
+	// deleting a Secret which does not exist succeeds
+	require.NoError(t, DeleteAzVolumeSecrets(context.TODO(), kubeClient, azVolume))
+}
+
+func TestAzSnapshotSecrets(t *testing.T) {
+	kubeClient := fakev1.NewSimpleClientset()
+	azSnapshot := &azdiskv1beta2.AzSnapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-snapshot",
+			Namespace: consts.DefaultAzureDiskCrdNamespace,
+			UID:       types.UID("test-snapshot-uid"),
+		},
+	}
+
+	secretRef, err := StoreAzSnapshotSecrets(context.TODO(), kubeClient, azSnapshot, map[string]string{"secret": "value"})
+	require.NoError(t, err)
+	require.NotNil(t, secretRef)
+	assert.Equal(t, GetAzSnapshotSecretName(azSnapshot.Name), secretRef.Name)
+
+	secret, err := kubeClient.CoreV1().Secrets(azSnapshot.Namespace).Get(context.TODO(), secretRef.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, azSnapshot.Name, secret.Labels[consts.SnapshotNameLabel])
+	require.Len(t, secret.OwnerReferences, 1)
+	assert.Equal(t, "AzSnapshot", secret.OwnerReferences[0].Kind)
+
+	azSnapshot.Spec.SecretRef = secretRef
+	secrets, err := GetAzSnapshotSecrets(context.TODO(), kubeClient, azSnapshot)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"secret": "value"}, secrets)
+
+	// an AzVolume whose Secret would have the same name does not take over the Secret of the AzSnapshot
+	azVolume := &azdiskv1beta2.AzVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      azSnapshot.Name + "-snapshot",
+			Namespace: azSnapshot.Namespace,
+		},
+	}
+	_, err = StoreAzVolumeSecrets(context.TODO(), kubeClient, azVolume, map[string]string{"secret": "other"})
+	assert.Error(t, err)
+
+	require.NoError(t, DeleteAzSnapshotSecrets(context.TODO(), kubeClient, azSnapshot))
+	_, err = GetAzSnapshotSecrets(context.TODO(), kubeClient, azSnapshot)
+	assert.True(t, k8serrors.IsNotFound(err))
+}
+
+func TestGetModifiedDiskParameters(t *testing.T) {
+	tests := []struct {
+		description string
+		desired     map[string]string
+		current     map[string]string
+		expected    map[string]string
+	}{
+		{
+			description: "unchanged parameters",
+			desired:     map[string]string{"skuName": "Premium_LRS", "cachingMode": "None"},
+			current:     map[string]string{"skuname": "premium_lrs", "cachingMode": "ReadOnly"},
+			expected:    nil,
+		},
+		{
+			description: "modified parameters",
+			desired:     map[string]string{"skuName": "PremiumV2_LRS", "DiskIOPSReadWrite": "5000", consts.EnableBurstingField: ""},
+			current:     map[string]string{"skuName": "Premium_LRS"},
+			expected:    map[string]string{consts.SkuNameField: "PremiumV2_LRS", consts.DiskIOPSReadWriteField: "5000"},
+		},
+		{
+			description: "parameter set on a volume without a current value",
+			desired:     map[string]string{consts.DiskMBPSReadWriteField: "200"},
+			current:     nil,
+			expected:    map[string]string{consts.DiskMBPSReadWriteField: "200"},
+		},
+	}
+
+	for _, test := range tests {
+		result := GetModifiedDiskParameters(test.desired, test.current)
+		assert.Equal(t, test.expected, result, test.description)
+	}
+}
+
 func TestGetDefaultDiskIOPSReadWrite(t *testing.T) {
 	tests := []struct {
 		requestGiB int
@@ -2909,3 +3064,58 @@ func TestGetDefaultDiskMBPSReadWrite(t *testing.T) {
 		}
 	}
 }
+
+func TestGetDiskCountsFromAzDriverNode(t *testing.T) {
+	tests := []struct {
+		desc                  string
+		azDriverNode          *azdiskv1beta2.AzDriverNode
+		expectedMaxDiskCount  int
+		expectedAttachedCount int
+		expectedOK            bool
+	}{
+		{
+			desc: "status not published",
+			azDriverNode: &azdiskv1beta2.AzDriverNode{
+				Spec: azdiskv1beta2.AzDriverNodeSpec{NodeName: "node"},
+			},
+		},
+		{
+			desc: "capacity not published",
+			azDriverNode: &azdiskv1beta2.AzDriverNode{
+				Spec:   azdiskv1beta2.AzDriverNodeSpec{NodeName: "node"},
+				Status: &azdiskv1beta2.AzDriverNodeStatus{AttachedDataDiskCount: pointer.Int32(2)},
+			},
+		},
+		{
+			desc: "attached disk count not published",
+			azDriverNode: &azdiskv1beta2.AzDriverNode{
+				Spec:   azdiskv1beta2.AzDriverNodeSpec{NodeName: "node"},
+				Status: &azdiskv1beta2.AzDriverNodeStatus{MaxDataDiskCount: pointer.Int32(8)},
+			},
+			expectedMaxDiskCount: 8,
+			expectedOK:           true,
+		},
+		{
+			desc: "capacity published",
+			azDriverNode: &azdiskv1beta2.AzDriverNode{
+				Spec: azdiskv1beta2.AzDriverNodeSpec{NodeName: "node"},
+				Status: &azdiskv1beta2.AzDriverNodeStatus{
+					MaxDataDiskCount:      pointer.Int32(8),
+					AttachedDataDiskCount: pointer.Int32(3),
+				},
+			},
+			expectedMaxDiskCount:  8,
+			expectedAttachedCount: 3,
+			expectedOK:            true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			maxDiskCount, attachedCount, ok := GetDiskCountsFromAzDriverNode(test.azDriverNode)
+			assert.Equal(t, test.expectedMaxDiskCount, maxDiskCount)
+			assert.Equal(t, test.expectedAttachedCount, attachedCount)
+			assert.Equal(t, test.expectedOK, ok)
+		})
+	}
+}
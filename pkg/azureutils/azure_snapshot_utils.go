@@ -122,6 +122,7 @@ func NewAzureDiskSnapshot(sourceVolumeID string, snapshot *compute.Snapshot) (*a
 	}
 
 	ready, _ := isSnapshotReady(*snapshot.SnapshotProperties.ProvisioningState)
+	ready = ready && IsSnapshotCopyComplete(snapshot)
 
 	if snapshot.SnapshotProperties.DiskSizeGB == nil {
 		return nil, fmt.Errorf("diskSizeGB of snapshot property is nil")
@@ -150,6 +151,7 @@ func GenerateCSISnapshot(sourceVolumeID string, snapshot *compute.Snapshot) (*cs
 		return nil, fmt.Errorf("failed to convert timestamp(%v)", snapshot.SnapshotProperties.TimeCreated.ToTime())
 	}
 	ready, _ := isSnapshotReady(*snapshot.SnapshotProperties.ProvisioningState)
+	ready = ready && IsSnapshotCopyComplete(snapshot)
 
 	if snapshot.SnapshotProperties.DiskSizeGB == nil {
 		return nil, fmt.Errorf("diskSizeGB of snapshot property is nil")
@@ -178,6 +180,48 @@ func GetSourceVolumeID(snapshot *compute.Snapshot) string {
 	return ""
 }
 
+// IsSnapshotCopyComplete returns false while a snapshot created by a background copy from another region is being copied.
+func IsSnapshotCopyComplete(snapshot *compute.Snapshot) bool {
+	if snapshot == nil || snapshot.SnapshotProperties == nil || snapshot.SnapshotProperties.CompletionPercent == nil {
+		return true
+	}
+	return *snapshot.SnapshotProperties.CompletionPercent >= 100
+}
+
+// GetCrossRegionSourceSnapshotName returns the name of the snapshot taken in the region of the source disk to be copied
+// to another region as the snapshot with the specified name. The snapshot name is truncated to keep the location suffix.
+func GetCrossRegionSourceSnapshotName(snapshotName, location string) string {
+	suffix := "-" + location
+	if maxLength := diskNameMaxLength - len(suffix); maxLength > 0 && len(snapshotName) > maxLength {
+		snapshotName = snapshotName[:maxLength]
+	}
+	return CreateValidDiskName(snapshotName+suffix, false)
+}
+
+// IsCrossRegionSourceSnapshotName returns true if the source snapshot name is the name returned by
+// GetCrossRegionSourceSnapshotName for the snapshot in the location the source snapshot name ends with.
+func IsCrossRegionSourceSnapshotName(snapshotName, sourceSnapshotName string) bool {
+	i := strings.LastIndex(sourceSnapshotName, "-")
+	if i < 0 {
+		return false
+	}
+	return strings.EqualFold(sourceSnapshotName, GetCrossRegionSourceSnapshotName(snapshotName, sourceSnapshotName[i+1:]))
+}
+
+// GetGroupSnapshotID returns the ID of the group snapshot with the specified name.
+func GetGroupSnapshotID(subsID, resourceGroup, groupSnapshotName string) string {
+	return fmt.Sprintf(consts.GroupSnapshotPath, subsID, resourceGroup, groupSnapshotName)
+}
+
+// ParseGroupSnapshotID returns the subscription, resource group and name of the group snapshot with the specified ID.
+func ParseGroupSnapshotID(groupSnapshotID string) (subsID, resourceGroup, groupSnapshotName string, err error) {
+	matches := consts.GroupSnapshotPathRE.FindStringSubmatch(groupSnapshotID)
+	if len(matches) != 4 {
+		return "", "", "", fmt.Errorf("could not parse group snapshot ID %s, correct format: %s", groupSnapshotID, consts.GroupSnapshotPathRE)
+	}
+	return matches[1], matches[2], matches[3], nil
+}
+
 func isSnapshotReady(state string) (bool, error) {
 	switch strings.ToLower(state) {
 	case "succeeded":
@@ -19,6 +19,7 @@ package azureutils
 import (
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-03-01/compute"
@@ -28,6 +29,7 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
+	"k8s.io/utils/pointer"
 	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
 	volumehelper "sigs.k8s.io/azuredisk-csi-driver/pkg/util"
 )
@@ -313,3 +315,104 @@ func TestIsSnapshotReady(t *testing.T) {
 		assert.Nil(t, err)
 	}
 }
+
+func TestIsSnapshotCopyComplete(t *testing.T) {
+	tests := []struct {
+		desc              string
+		completionPercent *float64
+		expectedResp      bool
+	}{
+		{
+			desc:         "snapshot not created by a background copy",
+			expectedResp: true,
+		},
+		{
+			desc:              "background copy in progress",
+			completionPercent: pointer.Float64(50),
+			expectedResp:      false,
+		},
+		{
+			desc:              "background copy completed",
+			completionPercent: pointer.Float64(100),
+			expectedResp:      true,
+		},
+	}
+	for _, test := range tests {
+		provisioningState := "Succeeded"
+		diskSizeGB := int32(10)
+		snapshotID := "test"
+		snapshot := &compute.Snapshot{
+			ID: &snapshotID,
+			SnapshotProperties: &compute.SnapshotProperties{
+				TimeCreated:       &date.Time{},
+				ProvisioningState: &provisioningState,
+				DiskSizeGB:        &diskSizeGB,
+				CompletionPercent: test.completionPercent,
+			},
+		}
+		assert.Equal(t, test.expectedResp, IsSnapshotCopyComplete(snapshot), test.desc)
+
+		azSnapshot, err := NewAzureDiskSnapshot("unit-test", snapshot)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expectedResp, azSnapshot.ReadyToUse, test.desc)
+	}
+}
+
+func TestGetCrossRegionSourceSnapshotName(t *testing.T) {
+	assert.Equal(t, "snapshot-westus2", GetCrossRegionSourceSnapshotName("snapshot", "westus2"))
+
+	// the snapshot name is truncated to keep the location suffix within the maximum length
+	longSnapshotName := strings.Repeat("a", diskNameMaxLength)
+	sourceSnapshotName := GetCrossRegionSourceSnapshotName(longSnapshotName, "westus2")
+	assert.Len(t, sourceSnapshotName, diskNameMaxLength)
+	assert.True(t, strings.HasSuffix(sourceSnapshotName, "-westus2"))
+	assert.True(t, IsCrossRegionSourceSnapshotName(longSnapshotName, sourceSnapshotName))
+}
+
+func TestIsCrossRegionSourceSnapshotName(t *testing.T) {
+	tests := []struct {
+		desc               string
+		sourceSnapshotName string
+		expectedResp       bool
+	}{
+		{
+			desc:               "source snapshot taken for the copy",
+			sourceSnapshotName: GetCrossRegionSourceSnapshotName("snapshot", "westus2"),
+			expectedResp:       true,
+		},
+		{
+			desc:               "source snapshot taken for another copy",
+			sourceSnapshotName: GetCrossRegionSourceSnapshotName("other-snapshot", "westus2"),
+			expectedResp:       false,
+		},
+		{
+			desc:               "source snapshot not taken for a copy",
+			sourceSnapshotName: "snapshot",
+			expectedResp:       false,
+		},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expectedResp, IsCrossRegionSourceSnapshotName("snapshot", test.sourceSnapshotName), test.desc)
+	}
+}
+
+func TestParseGroupSnapshotID(t *testing.T) {
+	groupSnapshotID := GetGroupSnapshotID("23", "rg", "group-snapshot")
+	assert.Equal(t, "/subscriptions/23/resourceGroups/rg/groupSnapshots/group-snapshot", groupSnapshotID)
+
+	subsID, resourceGroup, groupSnapshotName, err := ParseGroupSnapshotID(groupSnapshotID)
+	assert.NoError(t, err)
+	assert.Equal(t, "23", subsID)
+	assert.Equal(t, "rg", resourceGroup)
+	assert.Equal(t, "group-snapshot", groupSnapshotName)
+
+	for _, invalidID := range []string{
+		"",
+		"group-snapshot",
+		"/subscriptions/23/resourceGroups/rg/providers/Microsoft.Compute/snapshots/snapshot-name",
+		"/subscriptions/23/resourceGroups/rg/groupSnapshots/group-snapshot/extra",
+	} {
+		_, _, _, err := ParseGroupSnapshotID(invalidID)
+		assert.Error(t, err, invalidID)
+	}
+}
@@ -0,0 +1,494 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakecompute
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-03-01/compute"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"k8s.io/utils/pointer"
+	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils"
+	"sigs.k8s.io/cloud-provider-azure/pkg/provider"
+	"sigs.k8s.io/cloud-provider-azure/pkg/retry"
+)
+
+const (
+	virtualMachinePath           = "/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Compute/virtualMachines/%s"
+	virtualMachineScaleSetPath   = "/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Compute/virtualMachineScaleSets/%s"
+	virtualMachineScaleSetVMPath = virtualMachineScaleSetPath + "/virtualMachines/%s"
+	defaultMaxDataDiskCount      = 64
+	provisioningStateSucceeded   = "Succeeded"
+	powerStateRunning            = "PowerState/running"
+	notImplementedMessage        = "not implemented by the fake compute backend"
+)
+
+// pendingUpdate is an asynchronous VM update to be applied when its result is waited for.
+type pendingUpdate struct {
+	apply func() (interface{}, *retry.Error)
+}
+
+/*
+Backend is an in-process fake of the Azure compute resources of a subscription.
+It keeps the state of the managed disks, snapshots, VMs and VMSS VMs, and serves it through fakes of the
+disk, snapshot, VM, VMSS and VMSS VM clients consumed by the cloud provider.
+The backend models
+ 1. the creation of disks from scratch, snapshots or other disks
+ 2. the attachment and detachment of disks with LUN allocation and the data disk limit of each VM size
+ 3. asynchronous VM updates completing after the configured operation latency
+ 4. throttling responses returned for every request while throttling is in effect
+
+The backend also counts the updates applied to each VM and VMSS VM, so that tests can verify how the cloud provider groups disk operations.
+*/
+type Backend struct {
+	subscriptionID string
+	location       string
+
+	lock             sync.Mutex
+	disks            map[string]compute.Disk
+	snapshots        map[string]compute.Snapshot
+	virtualMachines  map[string]compute.VirtualMachine
+	scaleSets        map[string]compute.VirtualMachineScaleSet
+	scaleSetVMs      map[string]compute.VirtualMachineScaleSetVM
+	pendingUpdates   map[*azure.Future]*pendingUpdate
+	updateCounts     map[string]int
+	throttledUntil   time.Time
+	operationLatency time.Duration
+}
+
+// NewBackend returns an empty fake compute backend for the specified subscription and location.
+func NewBackend(subscriptionID, location string) *Backend {
+	return &Backend{
+		subscriptionID:  subscriptionID,
+		location:        location,
+		disks:           map[string]compute.Disk{},
+		snapshots:       map[string]compute.Snapshot{},
+		virtualMachines: map[string]compute.VirtualMachine{},
+		scaleSets:       map[string]compute.VirtualMachineScaleSet{},
+		scaleSetVMs:     map[string]compute.VirtualMachineScaleSetVM{},
+		pendingUpdates:  map[*azure.Future]*pendingUpdate{},
+		updateCounts:    map[string]int{},
+	}
+}
+
+// Install replaces the compute clients of the cloud with the clients served by the backend
+// and points the cloud at the subscription and location of the backend.
+func (b *Backend) Install(cloud *provider.Cloud) {
+	cloud.SubscriptionID = b.subscriptionID
+	cloud.Location = b.location
+	cloud.DisksClient = &disksClient{backend: b}
+	cloud.SnapshotsClient = &snapshotsClient{backend: b}
+	cloud.VirtualMachinesClient = &virtualMachinesClient{backend: b}
+	cloud.VirtualMachineScaleSetsClient = &virtualMachineScaleSetsClient{backend: b}
+	cloud.VirtualMachineScaleSetVMsClient = &virtualMachineScaleSetVMsClient{backend: b}
+}
+
+// ThrottleFor makes the backend respond to every request with a throttling error for the specified duration.
+func (b *Backend) ThrottleFor(duration time.Duration) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.throttledUntil = time.Now().Add(duration)
+}
+
+// SetOperationLatency sets the time it takes for an asynchronous VM update to complete.
+func (b *Backend) SetOperationLatency(latency time.Duration) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.operationLatency = latency
+}
+
+// AddVirtualMachine adds a VM of the specified size without any data disk to the backend.
+func (b *Backend) AddVirtualMachine(resourceGroup, name, vmSize string) compute.VirtualMachine {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	vm := compute.VirtualMachine{
+		ID:       pointer.String(fmt.Sprintf(virtualMachinePath, b.subscriptionID, resourceGroup, name)),
+		Name:     pointer.String(name),
+		Location: pointer.String(b.location),
+		VirtualMachineProperties: &compute.VirtualMachineProperties{
+			HardwareProfile:   &compute.HardwareProfile{VMSize: compute.VirtualMachineSizeTypes(vmSize)},
+			OsProfile:         &compute.OSProfile{ComputerName: pointer.String(name)},
+			StorageProfile:    &compute.StorageProfile{DataDisks: &[]compute.DataDisk{}},
+			NetworkProfile:    &compute.NetworkProfile{NetworkInterfaces: &[]compute.NetworkInterfaceReference{}},
+			ProvisioningState: pointer.String(provisioningStateSucceeded),
+			InstanceView: &compute.VirtualMachineInstanceView{
+				Statuses: &[]compute.InstanceViewStatus{{Code: pointer.String(powerStateRunning)}},
+			},
+		},
+	}
+	b.virtualMachines[getKey(resourceGroup, name)] = vm
+	return copyVirtualMachine(vm)
+}
+
+// AddVirtualMachineScaleSet adds a uniform VMSS of the specified size with the specified number of instances to the backend.
+// The computer name of each instance is the name of the scale set followed by the instance ID in base 36 with a width of 6 digits.
+func (b *Backend) AddVirtualMachineScaleSet(resourceGroup, name, vmSize string, instanceCount int) compute.VirtualMachineScaleSet {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	scaleSet := compute.VirtualMachineScaleSet{
+		ID:       pointer.String(fmt.Sprintf(virtualMachineScaleSetPath, b.subscriptionID, resourceGroup, name)),
+		Name:     pointer.String(name),
+		Location: pointer.String(b.location),
+		Sku:      &compute.Sku{Name: pointer.String(vmSize), Capacity: pointer.Int64(int64(instanceCount))},
+		VirtualMachineScaleSetProperties: &compute.VirtualMachineScaleSetProperties{
+			OrchestrationMode: compute.Uniform,
+			ProvisioningState: pointer.String(provisioningStateSucceeded),
+		},
+	}
+	b.scaleSets[getKey(resourceGroup, name)] = scaleSet
+
+	for i := 0; i < instanceCount; i++ {
+		instanceID := fmt.Sprintf("%d", i)
+		vm := compute.VirtualMachineScaleSetVM{
+			ID:         pointer.String(fmt.Sprintf(virtualMachineScaleSetVMPath, b.subscriptionID, resourceGroup, name, instanceID)),
+			Name:       pointer.String(fmt.Sprintf("%s_%s", name, instanceID)),
+			InstanceID: pointer.String(instanceID),
+			Location:   pointer.String(b.location),
+			Sku:        &compute.Sku{Name: pointer.String(vmSize)},
+			VirtualMachineScaleSetVMProperties: &compute.VirtualMachineScaleSetVMProperties{
+				OsProfile:         &compute.OSProfile{ComputerName: pointer.String(getScaleSetVMComputerName(name, i))},
+				StorageProfile:    &compute.StorageProfile{DataDisks: &[]compute.DataDisk{}},
+				NetworkProfile:    &compute.NetworkProfile{NetworkInterfaces: &[]compute.NetworkInterfaceReference{}},
+				ProvisioningState: pointer.String(provisioningStateSucceeded),
+				InstanceView: &compute.VirtualMachineScaleSetVMInstanceView{
+					Statuses: &[]compute.InstanceViewStatus{{Code: pointer.String(powerStateRunning)}},
+				},
+			},
+		}
+		b.scaleSetVMs[getScaleSetVMKey(resourceGroup, name, instanceID)] = vm
+	}
+	return copyVirtualMachineScaleSet(scaleSet)
+}
+
+// SetSnapshotCopyCompletionPercent sets the progress of the background copy of the snapshot with the specified URI
+// to another region. It returns false if the snapshot does not exist or was not created by a background copy.
+func (b *Backend) SetSnapshotCopyCompletionPercent(snapshotURI string, completionPercent float64) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	snapshot, ok := b.getSnapshotByURI(snapshotURI)
+	if !ok || snapshot.CompletionPercent == nil {
+		return false
+	}
+	snapshot.SnapshotProperties.CompletionPercent = pointer.Float64(completionPercent)
+	return true
+}
+
+// GetDisk returns the managed disk with the specified URI.
+func (b *Backend) GetDisk(diskURI string) (compute.Disk, bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	disk, ok := b.getDiskByURI(diskURI)
+	return copyDisk(disk), ok
+}
+
+// GetDataDisks returns the data disks attached to the VM or VMSS VM with the specified computer name.
+func (b *Backend) GetDataDisks(computerName string) ([]compute.DataDisk, bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	for _, vm := range b.virtualMachines {
+		if strings.EqualFold(pointer.StringDeref(vm.OsProfile.ComputerName, ""), computerName) {
+			return copyDataDisks(vm.StorageProfile.DataDisks), true
+		}
+	}
+	for _, vm := range b.scaleSetVMs {
+		if strings.EqualFold(pointer.StringDeref(vm.OsProfile.ComputerName, ""), computerName) {
+			return copyDataDisks(vm.StorageProfile.DataDisks), true
+		}
+	}
+	return nil, false
+}
+
+// GetUpdateCount returns the number of updates applied to the VM or VMSS VM with the specified computer name.
+func (b *Backend) GetUpdateCount(computerName string) int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.updateCounts[strings.ToLower(computerName)]
+}
+
+// recordUpdate counts an update applied to the VM or VMSS VM with the specified computer name. It must be called with the lock held.
+func (b *Backend) recordUpdate(osProfile *compute.OSProfile) {
+	if osProfile != nil {
+		b.updateCounts[strings.ToLower(pointer.StringDeref(osProfile.ComputerName, ""))]++
+	}
+}
+
+// checkThrottled returns a throttling error if throttling is in effect. It must be called with the lock held.
+func (b *Backend) checkThrottled(operation string) *retry.Error {
+	if time.Now().Before(b.throttledUntil) {
+		return &retry.Error{
+			Retriable:      true,
+			HTTPStatusCode: http.StatusTooManyRequests,
+			RetryAfter:     b.throttledUntil,
+			RawError:       fmt.Errorf("%s: operation %s was throttled by the fake compute backend", consts.TooManyRequests, operation),
+		}
+	}
+	return nil
+}
+
+// startUpdate registers an asynchronous update applied when its result is waited for. It must be called with the lock held.
+func (b *Backend) startUpdate(apply func() (interface{}, *retry.Error)) *azure.Future {
+	future := &azure.Future{}
+	b.pendingUpdates[future] = &pendingUpdate{apply: apply}
+	return future
+}
+
+// waitForUpdate waits for the operation latency and applies the asynchronous update of the future.
+func (b *Backend) waitForUpdate(ctx context.Context, future *azure.Future) (interface{}, *retry.Error) {
+	b.lock.Lock()
+	update, ok := b.pendingUpdates[future]
+	delete(b.pendingUpdates, future)
+	latency := b.operationLatency
+	b.lock.Unlock()
+
+	if !ok {
+		return nil, newError(http.StatusBadRequest, "InvalidParameter", "the future does not belong to a pending operation")
+	}
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-ctx.Done():
+			return nil, retry.NewError(true, ctx.Err())
+		}
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return update.apply()
+}
+
+// updateDataDisks validates the desired data disks of the VM and updates the state of the disks attached to or detached from it.
+// It returns the data disks attached to the VM after the update. It must be called with the lock held.
+func (b *Backend) updateDataDisks(vmID, vmSize string, current *[]compute.DataDisk, desired *[]compute.DataDisk) ([]compute.DataDisk, *retry.Error) {
+	if desired == nil {
+		return copyDataDisks(current), nil
+	}
+
+	updated := []compute.DataDisk{}
+	attachedDisks := map[string]compute.Disk{}
+	luns := map[int32]string{}
+	for _, dataDisk := range copyDataDisks(desired) {
+		if pointer.BoolDeref(dataDisk.ToBeDetached, false) {
+			continue
+		}
+		if dataDisk.ManagedDisk == nil || dataDisk.ManagedDisk.ID == nil {
+			return nil, newError(http.StatusBadRequest, "InvalidParameter", "only managed data disks are supported")
+		}
+		diskURI := *dataDisk.ManagedDisk.ID
+		if dataDisk.Lun == nil {
+			return nil, newError(http.StatusBadRequest, "InvalidParameter", fmt.Sprintf("LUN of data disk %s is not specified", diskURI))
+		}
+		if existing, ok := luns[*dataDisk.Lun]; ok {
+			return nil, newError(http.StatusConflict, "Conflict", fmt.Sprintf("data disks %s and %s cannot be attached at the same LUN %d", existing, diskURI, *dataDisk.Lun))
+		}
+		luns[*dataDisk.Lun] = diskURI
+
+		disk, ok := b.getDiskByURI(diskURI)
+		if !ok {
+			return nil, newError(http.StatusNotFound, "NotFound", fmt.Sprintf("disk %s is not found", diskURI))
+		}
+		maxShares := pointer.Int32Deref(disk.MaxShares, 1)
+		if managedBy := pointer.StringDeref(disk.ManagedBy, ""); managedBy != "" && !strings.EqualFold(managedBy, vmID) && maxShares <= 1 {
+			return nil, newError(http.StatusConflict, "OperationNotAllowed", fmt.Sprintf("disk %s is attached to VM %s", diskURI, managedBy))
+		}
+		attachedDisks[strings.ToLower(diskURI)] = disk
+
+		dataDisk.ToBeDetached = nil
+		dataDisk.Name = disk.Name
+		updated = append(updated, dataDisk)
+	}
+
+	if maxDataDiskCount := getMaxDataDiskCount(vmSize); len(updated) > maxDataDiskCount {
+		return nil, newError(http.StatusConflict, "OperationNotAllowed", fmt.Sprintf("the maximum number of data disks allowed to be attached to a VM of size %s is %d", vmSize, maxDataDiskCount))
+	}
+
+	// release the disks no longer attached to the VM
+	if current != nil {
+		for _, dataDisk := range *current {
+			if dataDisk.ManagedDisk == nil || dataDisk.ManagedDisk.ID == nil {
+				continue
+			}
+			if _, ok := attachedDisks[strings.ToLower(*dataDisk.ManagedDisk.ID)]; ok {
+				continue
+			}
+			if disk, ok := b.getDiskByURI(*dataDisk.ManagedDisk.ID); ok {
+				disk = copyDisk(disk)
+				disk.ManagedBy = nil
+				disk.DiskState = compute.Unattached
+				b.disks[getDiskKeyFromURI(*dataDisk.ManagedDisk.ID)] = disk
+			}
+		}
+	}
+	for diskKey, disk := range attachedDisks {
+		disk = copyDisk(disk)
+		disk.ManagedBy = pointer.String(vmID)
+		disk.DiskState = compute.Attached
+		b.disks[getDiskKeyFromURI(diskKey)] = disk
+	}
+
+	return updated, nil
+}
+
+func (b *Backend) getDiskByURI(diskURI string) (compute.Disk, bool) {
+	disk, ok := b.disks[getDiskKeyFromURI(diskURI)]
+	return disk, ok
+}
+
+func getMaxDataDiskCount(vmSize string) int {
+	if maxDataDiskCount, ok := azureutils.MaxDataDiskCountMap[strings.ToUpper(vmSize)]; ok {
+		return int(maxDataDiskCount)
+	}
+	return defaultMaxDataDiskCount
+}
+
+func getScaleSetVMComputerName(scaleSetName string, instanceID int) string {
+	suffix := strconv.FormatInt(int64(instanceID), 36)
+	return scaleSetName + strings.Repeat("0", 6-len(suffix)) + suffix
+}
+
+func getKey(resourceGroup, name string) string {
+	return strings.ToLower(resourceGroup + "/" + name)
+}
+
+func getScaleSetVMKey(resourceGroup, scaleSetName, instanceID string) string {
+	return strings.ToLower(resourceGroup + "/" + scaleSetName + "/" + instanceID)
+}
+
+// getDiskKeyFromURI returns the key of the disk in the form of <resourceGroup>/<diskName>.
+func getDiskKeyFromURI(diskURI string) string {
+	diskName, err := azureutils.GetDiskName(diskURI)
+	if err != nil {
+		return strings.ToLower(diskURI)
+	}
+	resourceGroup, err := azureutils.GetResourceGroupFromURI(diskURI)
+	if err != nil {
+		return strings.ToLower(diskURI)
+	}
+	return getKey(resourceGroup, diskName)
+}
+
+func newError(statusCode int, code, message string) *retry.Error {
+	return &retry.Error{
+		HTTPStatusCode: statusCode,
+		RawError:       fmt.Errorf("Code=%q Message=%q", code, message),
+	}
+}
+
+func newNotFoundError(resourceType, name string) *retry.Error {
+	return newError(http.StatusNotFound, "ResourceNotFound", fmt.Sprintf("the %s %s is not found", resourceType, name))
+}
+
+func newNotImplementedError(operation string) *retry.Error {
+	return newError(http.StatusNotImplemented, "NotImplemented", fmt.Sprintf("%s is %s", operation, notImplementedMessage))
+}
+
+func copyDataDisks(dataDisks *[]compute.DataDisk) []compute.DataDisk {
+	if dataDisks == nil {
+		return []compute.DataDisk{}
+	}
+	return deepCopy(reflect.ValueOf(*dataDisks)).Interface().([]compute.DataDisk)
+}
+
+func copyTags(tags map[string]*string) map[string]*string {
+	return deepCopy(reflect.ValueOf(tags)).Interface().(map[string]*string)
+}
+
+func copyDisk(disk compute.Disk) compute.Disk {
+	return deepCopy(reflect.ValueOf(disk)).Interface().(compute.Disk)
+}
+
+func copySnapshot(snapshot compute.Snapshot) compute.Snapshot {
+	return deepCopy(reflect.ValueOf(snapshot)).Interface().(compute.Snapshot)
+}
+
+func copyVirtualMachine(vm compute.VirtualMachine) compute.VirtualMachine {
+	return deepCopy(reflect.ValueOf(vm)).Interface().(compute.VirtualMachine)
+}
+
+func copyVirtualMachineScaleSet(scaleSet compute.VirtualMachineScaleSet) compute.VirtualMachineScaleSet {
+	return deepCopy(reflect.ValueOf(scaleSet)).Interface().(compute.VirtualMachineScaleSet)
+}
+
+func copyVirtualMachineScaleSetVM(vm compute.VirtualMachineScaleSetVM) compute.VirtualMachineScaleSetVM {
+	return deepCopy(reflect.ValueOf(vm)).Interface().(compute.VirtualMachineScaleSetVM)
+}
+
+// deepCopy returns a copy of the value not sharing any pointer, slice or map with it, so that neither the callers
+// nor the backend can modify the state of the other through the objects they exchange.
+// The generated compute models have no DeepCopy methods, so the copy is made by walking the exported fields.
+func deepCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.New(v.Elem().Type())
+		copied.Elem().Set(deepCopy(v.Elem()))
+		return copied
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(deepCopy(v.Elem()))
+		return copied
+	case reflect.Struct:
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if copied.Field(i).CanSet() {
+				copied.Field(i).Set(deepCopy(v.Field(i)))
+			}
+		}
+		return copied
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			copied.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return copied
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			copied.SetMapIndex(iter.Key(), deepCopy(iter.Value()))
+		}
+		return copied
+	default:
+		return v
+	}
+}
@@ -0,0 +1,282 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakecompute
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-03-01/compute"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/pointer"
+	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
+)
+
+const (
+	testSubscription  = "subscription"
+	testLocation      = "westus"
+	testResourceGroup = "rg"
+	testVMName        = "test-vm"
+	testVMSize        = "Standard_D2s_v3"
+	testScaleSetName  = "test-vmss"
+)
+
+var (
+	testDiskURI0 = fmt.Sprintf(consts.ManagedDiskPath, testSubscription, testResourceGroup, "disk-0")
+	testDiskURI1 = fmt.Sprintf(consts.ManagedDiskPath, testSubscription, testResourceGroup, "disk-1")
+)
+
+func newTestDisk(sizeGB int32) compute.Disk {
+	return compute.Disk{
+		DiskProperties: &compute.DiskProperties{
+			CreationData: &compute.CreationData{CreateOption: compute.Empty},
+			DiskSizeGB:   pointer.Int32(sizeGB),
+		},
+	}
+}
+
+func newTestDataDisk(diskURI string, lun int32) compute.DataDisk {
+	return compute.DataDisk{
+		Lun:          pointer.Int32(lun),
+		ManagedDisk:  &compute.ManagedDiskParameters{ID: pointer.String(diskURI)},
+		CreateOption: compute.DiskCreateOptionTypesAttach,
+	}
+}
+
+func newTestVMUpdate(dataDisks ...compute.DataDisk) compute.VirtualMachineUpdate {
+	return compute.VirtualMachineUpdate{
+		VirtualMachineProperties: &compute.VirtualMachineProperties{
+			StorageProfile: &compute.StorageProfile{DataDisks: &dataDisks},
+		},
+	}
+}
+
+func TestDisks(t *testing.T) {
+	backend := NewBackend(testSubscription, testLocation)
+	disks := &disksClient{backend: backend}
+	snapshots := &snapshotsClient{backend: backend}
+	ctx := context.TODO()
+
+	require.Nil(t, disks.CreateOrUpdate(ctx, testSubscription, testResourceGroup, "disk-0", newTestDisk(10)))
+	disk, rerr := disks.Get(ctx, testSubscription, testResourceGroup, "disk-0")
+	require.Nil(t, rerr)
+	assert.Equal(t, testDiskURI0, *disk.ID)
+	assert.Equal(t, compute.Unattached, disk.DiskState)
+	assert.Equal(t, provisioningStateSucceeded, *disk.ProvisioningState)
+	assert.NotNil(t, disk.TimeCreated)
+
+	// mutating the returned disk must not change the state of the backend
+	*disk.DiskSizeGB = 1
+	disk, _ = disks.Get(ctx, testSubscription, testResourceGroup, "disk-0")
+	assert.Equal(t, int32(10), *disk.DiskSizeGB)
+
+	rerr = disks.Update(ctx, testSubscription, testResourceGroup, "disk-0", compute.DiskUpdate{DiskUpdateProperties: &compute.DiskUpdateProperties{DiskSizeGB: pointer.Int32(5)}})
+	require.NotNil(t, rerr)
+	assert.Equal(t, http.StatusBadRequest, rerr.HTTPStatusCode)
+	require.Nil(t, disks.Update(ctx, testSubscription, testResourceGroup, "disk-0", compute.DiskUpdate{DiskUpdateProperties: &compute.DiskUpdateProperties{DiskSizeGB: pointer.Int32(20)}}))
+
+	snapshot := compute.Snapshot{
+		SnapshotProperties: &compute.SnapshotProperties{
+			CreationData: &compute.CreationData{CreateOption: compute.Copy, SourceURI: pointer.String(testDiskURI0)},
+		},
+	}
+	require.Nil(t, snapshots.CreateOrUpdate(ctx, testSubscription, testResourceGroup, "snapshot-0", snapshot))
+	snapshot, rerr = snapshots.Get(ctx, testSubscription, testResourceGroup, "snapshot-0")
+	require.Nil(t, rerr)
+	assert.Equal(t, int32(20), *snapshot.DiskSizeGB)
+
+	clone := compute.Disk{
+		DiskProperties: &compute.DiskProperties{
+			CreationData: &compute.CreationData{CreateOption: compute.Copy, SourceResourceID: snapshot.ID},
+		},
+	}
+	require.Nil(t, disks.CreateOrUpdate(ctx, testSubscription, testResourceGroup, "disk-1", clone))
+	disk, _ = disks.Get(ctx, testSubscription, testResourceGroup, "disk-1")
+	assert.Equal(t, int32(20), *disk.DiskSizeGB)
+
+	clone.CreationData.SourceResourceID = pointer.String(fmt.Sprintf(consts.DiskSnapshotPath, testSubscription, testResourceGroup, "missing"))
+	rerr = disks.CreateOrUpdate(ctx, testSubscription, testResourceGroup, "disk-2", clone)
+	require.NotNil(t, rerr)
+	assert.True(t, rerr.IsNotFound())
+
+	list, rerr := disks.ListByResourceGroup(ctx, testSubscription, testResourceGroup)
+	require.Nil(t, rerr)
+	assert.Len(t, list, 2)
+
+	require.Nil(t, disks.Delete(ctx, testSubscription, testResourceGroup, "disk-1"))
+	_, rerr = disks.Get(ctx, testSubscription, testResourceGroup, "disk-1")
+	require.NotNil(t, rerr)
+	assert.True(t, rerr.IsNotFound())
+}
+
+func TestVirtualMachineDataDisks(t *testing.T) {
+	backend := NewBackend(testSubscription, testLocation)
+	disks := &disksClient{backend: backend}
+	vms := &virtualMachinesClient{backend: backend}
+	ctx := context.TODO()
+
+	vm := backend.AddVirtualMachine(testResourceGroup, testVMName, testVMSize)
+	require.Nil(t, disks.CreateOrUpdate(ctx, testSubscription, testResourceGroup, "disk-0", newTestDisk(10)))
+	require.Nil(t, disks.CreateOrUpdate(ctx, testSubscription, testResourceGroup, "disk-1", newTestDisk(10)))
+
+	// disks attached at the same LUN
+	_, rerr := vms.Update(ctx, testResourceGroup, testVMName, newTestVMUpdate(newTestDataDisk(testDiskURI0, 0), newTestDataDisk(testDiskURI1, 0)), "attach_disk")
+	require.NotNil(t, rerr)
+	assert.Equal(t, http.StatusConflict, rerr.HTTPStatusCode)
+
+	// missing disk
+	_, rerr = vms.Update(ctx, testResourceGroup, testVMName, newTestVMUpdate(newTestDataDisk(fmt.Sprintf(consts.ManagedDiskPath, testSubscription, testResourceGroup, "missing"), 0)), "attach_disk")
+	require.NotNil(t, rerr)
+	assert.True(t, rerr.IsNotFound())
+
+	result, rerr := vms.Update(ctx, testResourceGroup, testVMName, newTestVMUpdate(newTestDataDisk(testDiskURI0, 0), newTestDataDisk(testDiskURI1, 1)), "attach_disk")
+	require.Nil(t, rerr)
+	assert.Len(t, *result.StorageProfile.DataDisks, 2)
+
+	disk, _ := backend.GetDisk(testDiskURI0)
+	assert.Equal(t, compute.Attached, disk.DiskState)
+	assert.Equal(t, *vm.ID, *disk.ManagedBy)
+
+	// an attached disk can neither be deleted nor attached to another VM
+	rerr = disks.Delete(ctx, testSubscription, testResourceGroup, "disk-0")
+	require.NotNil(t, rerr)
+	assert.Equal(t, http.StatusConflict, rerr.HTTPStatusCode)
+	backend.AddVirtualMachine(testResourceGroup, "other-vm", testVMSize)
+	_, rerr = vms.Update(ctx, testResourceGroup, "other-vm", newTestVMUpdate(newTestDataDisk(testDiskURI0, 0)), "attach_disk")
+	require.NotNil(t, rerr)
+	assert.Equal(t, http.StatusConflict, rerr.HTTPStatusCode)
+
+	detach := newTestDataDisk(testDiskURI0, 0)
+	detach.ToBeDetached = pointer.Bool(true)
+	_, rerr = vms.Update(ctx, testResourceGroup, testVMName, newTestVMUpdate(detach, newTestDataDisk(testDiskURI1, 1)), "detach_disk")
+	require.Nil(t, rerr)
+
+	dataDisks, ok := backend.GetDataDisks(testVMName)
+	require.True(t, ok)
+	require.Len(t, dataDisks, 1)
+	assert.Equal(t, testDiskURI1, *dataDisks[0].ManagedDisk.ID)
+	disk, _ = backend.GetDisk(testDiskURI0)
+	assert.Equal(t, compute.Unattached, disk.DiskState)
+	assert.Nil(t, disk.ManagedBy)
+}
+
+func TestVirtualMachineMaxDataDiskCount(t *testing.T) {
+	backend := NewBackend(testSubscription, testLocation)
+	disks := &disksClient{backend: backend}
+	vms := &virtualMachinesClient{backend: backend}
+	ctx := context.TODO()
+
+	// a Standard_D2s_v3 VM supports at most 4 data disks
+	backend.AddVirtualMachine(testResourceGroup, testVMName, testVMSize)
+	dataDisks := []compute.DataDisk{}
+	for i := 0; i < 5; i++ {
+		diskName := fmt.Sprintf("disk-%d", i)
+		require.Nil(t, disks.CreateOrUpdate(ctx, testSubscription, testResourceGroup, diskName, newTestDisk(10)))
+		dataDisks = append(dataDisks, newTestDataDisk(fmt.Sprintf(consts.ManagedDiskPath, testSubscription, testResourceGroup, diskName), int32(i)))
+	}
+
+	_, rerr := vms.Update(ctx, testResourceGroup, testVMName, newTestVMUpdate(dataDisks[:4]...), "attach_disk")
+	require.Nil(t, rerr)
+	_, rerr = vms.Update(ctx, testResourceGroup, testVMName, newTestVMUpdate(dataDisks...), "attach_disk")
+	require.NotNil(t, rerr)
+	assert.Equal(t, http.StatusConflict, rerr.HTTPStatusCode)
+}
+
+func TestVirtualMachineAsyncUpdate(t *testing.T) {
+	backend := NewBackend(testSubscription, testLocation)
+	disks := &disksClient{backend: backend}
+	vms := &virtualMachinesClient{backend: backend}
+	ctx := context.TODO()
+
+	backend.AddVirtualMachine(testResourceGroup, testVMName, testVMSize)
+	backend.SetOperationLatency(50 * time.Millisecond)
+	require.Nil(t, disks.CreateOrUpdate(ctx, testSubscription, testResourceGroup, "disk-0", newTestDisk(10)))
+
+	future, rerr := vms.UpdateAsync(ctx, testResourceGroup, testVMName, newTestVMUpdate(newTestDataDisk(testDiskURI0, 0)), "attach_disk")
+	require.Nil(t, rerr)
+
+	// the update is only applied once its result is waited for
+	dataDisks, _ := backend.GetDataDisks(testVMName)
+	assert.Empty(t, dataDisks)
+	assert.Zero(t, backend.GetUpdateCount(testVMName))
+
+	start := time.Now()
+	result, rerr := vms.WaitForUpdateResult(ctx, future, testResourceGroup, "attach_disk")
+	require.Nil(t, rerr)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	assert.Len(t, *result.StorageProfile.DataDisks, 1)
+	assert.Equal(t, 1, backend.GetUpdateCount(testVMName))
+
+	future, rerr = vms.UpdateAsync(ctx, testResourceGroup, testVMName, compute.VirtualMachineUpdate{}, "update_vm")
+	require.Nil(t, rerr)
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, rerr = vms.WaitForUpdateResult(canceledCtx, future, testResourceGroup, "update_vm")
+	require.NotNil(t, rerr)
+	assert.Equal(t, 1, backend.GetUpdateCount(testVMName))
+}
+
+func TestThrottling(t *testing.T) {
+	backend := NewBackend(testSubscription, testLocation)
+	disks := &disksClient{backend: backend}
+	ctx := context.TODO()
+
+	backend.ThrottleFor(time.Minute)
+	_, rerr := disks.Get(ctx, testSubscription, testResourceGroup, "disk-0")
+	require.NotNil(t, rerr)
+	assert.True(t, rerr.IsThrottled())
+	assert.Contains(t, rerr.Error().Error(), consts.TooManyRequests)
+
+	backend.ThrottleFor(0)
+	_, rerr = disks.Get(ctx, testSubscription, testResourceGroup, "disk-0")
+	require.NotNil(t, rerr)
+	assert.True(t, rerr.IsNotFound())
+}
+
+func TestVirtualMachineScaleSetVMDataDisks(t *testing.T) {
+	backend := NewBackend(testSubscription, testLocation)
+	disks := &disksClient{backend: backend}
+	vmssVMs := &virtualMachineScaleSetVMsClient{backend: backend}
+	ctx := context.TODO()
+
+	backend.AddVirtualMachineScaleSet(testResourceGroup, testScaleSetName, testVMSize, 2)
+	require.Nil(t, disks.CreateOrUpdate(ctx, testSubscription, testResourceGroup, "disk-0", newTestDisk(10)))
+
+	vms, rerr := vmssVMs.List(ctx, testResourceGroup, testScaleSetName, "")
+	require.Nil(t, rerr)
+	assert.Len(t, vms, 2)
+
+	dataDisks := []compute.DataDisk{newTestDataDisk(testDiskURI0, 0)}
+	future, rerr := vmssVMs.UpdateAsync(ctx, testResourceGroup, testScaleSetName, "1", compute.VirtualMachineScaleSetVM{
+		VirtualMachineScaleSetVMProperties: &compute.VirtualMachineScaleSetVMProperties{
+			StorageProfile: &compute.StorageProfile{DataDisks: &dataDisks},
+		},
+	}, "attach_disk")
+	require.Nil(t, rerr)
+	_, rerr = vmssVMs.WaitForUpdateResult(ctx, future, testResourceGroup, "attach_disk")
+	require.Nil(t, rerr)
+
+	attached, ok := backend.GetDataDisks(testScaleSetName + "000001")
+	require.True(t, ok)
+	assert.Len(t, attached, 1)
+	disk, _ := backend.GetDisk(testDiskURI0)
+	assert.Equal(t, compute.Attached, disk.DiskState)
+}
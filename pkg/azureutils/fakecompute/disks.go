@@ -0,0 +1,259 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakecompute
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-03-01/compute"
+	"github.com/Azure/go-autorest/autorest/date"
+	"k8s.io/utils/pointer"
+	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azureclients/diskclient"
+	"sigs.k8s.io/cloud-provider-azure/pkg/retry"
+)
+
+type disksClient struct {
+	backend *Backend
+}
+
+var _ diskclient.Interface = &disksClient{}
+
+// Get gets a Disk.
+func (c *disksClient) Get(ctx context.Context, subsID, resourceGroupName, diskName string) (compute.Disk, *retry.Error) {
+	b := c.backend
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if rerr := b.checkThrottled("GetDisk"); rerr != nil {
+		return compute.Disk{}, rerr
+	}
+
+	disk, ok := b.disks[getKey(resourceGroupName, diskName)]
+	if !ok {
+		return compute.Disk{}, newNotFoundError("disk", diskName)
+	}
+	return copyDisk(disk), nil
+}
+
+// CreateOrUpdate creates or updates a Disk.
+func (c *disksClient) CreateOrUpdate(ctx context.Context, subsID, resourceGroupName, diskName string, diskParameter compute.Disk) *retry.Error {
+	b := c.backend
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if rerr := b.checkThrottled("CreateOrUpdateDisk"); rerr != nil {
+		return rerr
+	}
+
+	disk := copyDisk(diskParameter)
+	if disk.DiskProperties == nil {
+		return newError(http.StatusBadRequest, "InvalidParameter", "disk properties are required")
+	}
+	if disk.CreationData == nil {
+		disk.CreationData = &compute.CreationData{CreateOption: compute.Empty}
+	}
+	if disk.Location == nil {
+		disk.Location = pointer.String(b.location)
+	}
+
+	switch disk.CreationData.CreateOption {
+	case compute.Empty:
+		if disk.DiskSizeGB == nil {
+			return newError(http.StatusBadRequest, "InvalidParameter", "the size of an empty disk is required")
+		}
+	case compute.Copy:
+		sourceSizeGB, rerr := b.getSourceSizeGB(disk.CreationData)
+		if rerr != nil {
+			return rerr
+		}
+		if rerr := b.checkSourceLocation(disk.CreationData, *disk.Location); rerr != nil {
+			return rerr
+		}
+		if disk.DiskSizeGB == nil {
+			disk.DiskSizeGB = pointer.Int32(sourceSizeGB)
+		} else if *disk.DiskSizeGB < sourceSizeGB {
+			return newError(http.StatusBadRequest, "InvalidParameter", fmt.Sprintf("the size of the disk (%d GiB) cannot be smaller than the size of its source (%d GiB)", *disk.DiskSizeGB, sourceSizeGB))
+		}
+	default:
+		return newNotImplementedError(fmt.Sprintf("disk create option %s", disk.CreationData.CreateOption))
+	}
+
+	key := getKey(resourceGroupName, diskName)
+	if existing, ok := b.disks[key]; ok {
+		if pointer.Int32Deref(disk.DiskSizeGB, 0) < pointer.Int32Deref(existing.DiskSizeGB, 0) {
+			return newError(http.StatusBadRequest, "InvalidParameter", fmt.Sprintf("the size of disk %s cannot be reduced", diskName))
+		}
+		disk.ManagedBy = existing.ManagedBy
+		disk.DiskState = existing.DiskState
+		disk.TimeCreated = existing.TimeCreated
+	} else {
+		disk.DiskState = compute.Unattached
+		disk.TimeCreated = &date.Time{Time: time.Now()}
+	}
+
+	disk.ID = pointer.String(fmt.Sprintf(consts.ManagedDiskPath, b.subscriptionID, resourceGroupName, diskName))
+	disk.Name = pointer.String(diskName)
+	disk.DiskSizeBytes = pointer.Int64(int64(*disk.DiskSizeGB) << 30)
+	disk.ProvisioningState = pointer.String(provisioningStateSucceeded)
+	b.disks[key] = disk
+	return nil
+}
+
+// Update updates a Disk.
+func (c *disksClient) Update(ctx context.Context, subsID, resourceGroupName, diskName string, diskParameter compute.DiskUpdate) *retry.Error {
+	b := c.backend
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if rerr := b.checkThrottled("UpdateDisk"); rerr != nil {
+		return rerr
+	}
+
+	key := getKey(resourceGroupName, diskName)
+	existing, ok := b.disks[key]
+	if !ok {
+		return newNotFoundError("disk", diskName)
+	}
+
+	disk := copyDisk(existing)
+	if diskParameter.Tags != nil {
+		disk.Tags = copyTags(diskParameter.Tags)
+	}
+	if diskParameter.Sku != nil {
+		disk.Sku = &compute.DiskSku{Name: diskParameter.Sku.Name}
+	}
+	if properties := diskParameter.DiskUpdateProperties; properties != nil {
+		if properties.DiskSizeGB != nil {
+			if *properties.DiskSizeGB < pointer.Int32Deref(disk.DiskSizeGB, 0) {
+				return newError(http.StatusBadRequest, "InvalidParameter", fmt.Sprintf("the size of disk %s cannot be reduced", diskName))
+			}
+			disk.DiskSizeGB = properties.DiskSizeGB
+			disk.DiskSizeBytes = pointer.Int64(int64(*properties.DiskSizeGB) << 30)
+		}
+		if properties.MaxShares != nil {
+			if disk.DiskState != compute.Unattached {
+				return newError(http.StatusConflict, "OperationNotAllowed", fmt.Sprintf("the maximum number of shares of disk %s cannot be changed while it is attached", diskName))
+			}
+			disk.MaxShares = properties.MaxShares
+		}
+		if properties.DiskIOPSReadWrite != nil {
+			disk.DiskIOPSReadWrite = properties.DiskIOPSReadWrite
+		}
+		if properties.DiskMBpsReadWrite != nil {
+			disk.DiskMBpsReadWrite = properties.DiskMBpsReadWrite
+		}
+		if properties.BurstingEnabled != nil {
+			disk.BurstingEnabled = properties.BurstingEnabled
+		}
+		if properties.NetworkAccessPolicy != "" {
+			disk.NetworkAccessPolicy = properties.NetworkAccessPolicy
+		}
+	}
+	b.disks[key] = disk
+	return nil
+}
+
+// Delete deletes a Disk by name.
+func (c *disksClient) Delete(ctx context.Context, subsID, resourceGroupName, diskName string) *retry.Error {
+	b := c.backend
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if rerr := b.checkThrottled("DeleteDisk"); rerr != nil {
+		return rerr
+	}
+
+	key := getKey(resourceGroupName, diskName)
+	disk, ok := b.disks[key]
+	if !ok {
+		// deleting a missing resource succeeds in ARM
+		return nil
+	}
+	if managedBy := pointer.StringDeref(disk.ManagedBy, ""); managedBy != "" {
+		return newError(http.StatusConflict, "OperationNotAllowed", fmt.Sprintf("disk %s is attached to VM %s", diskName, managedBy))
+	}
+	delete(b.disks, key)
+	return nil
+}
+
+// ListByResourceGroup lists all the disks under a resource group.
+func (c *disksClient) ListByResourceGroup(ctx context.Context, subsID, resourceGroupName string) ([]compute.Disk, *retry.Error) {
+	b := c.backend
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if rerr := b.checkThrottled("ListDisksByResourceGroup"); rerr != nil {
+		return nil, rerr
+	}
+
+	prefix := getKey(resourceGroupName, "")
+	disks := []compute.Disk{}
+	for key, disk := range b.disks {
+		if strings.HasPrefix(key, prefix) {
+			disks = append(disks, copyDisk(disk))
+		}
+	}
+	return disks, nil
+}
+
+// getSourceSizeGB returns the size of the disk or snapshot a resource is copied from. It must be called with the lock held.
+func (b *Backend) getSourceSizeGB(creationData *compute.CreationData) (int32, *retry.Error) {
+	sourceID := getSourceID(creationData)
+	if sourceID == "" {
+		return 0, newError(http.StatusBadRequest, "InvalidParameter", "the source of a copy is required")
+	}
+
+	if consts.DiskSnapshotPathRE.MatchString(sourceID) {
+		snapshot, ok := b.getSnapshotByURI(sourceID)
+		if !ok {
+			return 0, newNotFoundError("snapshot", sourceID)
+		}
+		return pointer.Int32Deref(snapshot.DiskSizeGB, 0), nil
+	}
+
+	disk, ok := b.getDiskByURI(sourceID)
+	if !ok {
+		return 0, newNotFoundError("disk", sourceID)
+	}
+	return pointer.Int32Deref(disk.DiskSizeGB, 0), nil
+}
+
+// checkSourceLocation checks that the source of a copy is in the location of the copy and that the copy of a source
+// snapshot started from another region has completed.
+func (b *Backend) checkSourceLocation(creationData *compute.CreationData, location string) *retry.Error {
+	sourceID := getSourceID(creationData)
+
+	var sourceLocation string
+	if snapshot, ok := b.getSnapshotByURI(sourceID); ok {
+		if pointer.Float64Deref(snapshot.CompletionPercent, 100) < 100 {
+			return newError(http.StatusConflict, "OperationNotAllowed", fmt.Sprintf("the copy of snapshot %s has not completed", sourceID))
+		}
+		sourceLocation = pointer.StringDeref(snapshot.Location, b.location)
+	} else if disk, ok := b.getDiskByURI(sourceID); ok {
+		sourceLocation = pointer.StringDeref(disk.Location, b.location)
+	}
+
+	if !strings.EqualFold(sourceLocation, location) {
+		return newError(http.StatusBadRequest, "InvalidParameter", fmt.Sprintf("the source %s in location %s cannot be copied to location %s", sourceID, sourceLocation, location))
+	}
+	return nil
+}
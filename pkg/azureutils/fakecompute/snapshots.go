@@ -0,0 +1,174 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakecompute
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-03-01/compute"
+	"github.com/Azure/go-autorest/autorest/date"
+	"k8s.io/utils/pointer"
+	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azureclients/snapshotclient"
+	"sigs.k8s.io/cloud-provider-azure/pkg/retry"
+)
+
+type snapshotsClient struct {
+	backend *Backend
+}
+
+var _ snapshotclient.Interface = &snapshotsClient{}
+
+// Get gets a Snapshot.
+func (c *snapshotsClient) Get(ctx context.Context, subsID, resourceGroupName, snapshotName string) (compute.Snapshot, *retry.Error) {
+	b := c.backend
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if rerr := b.checkThrottled("GetSnapshot"); rerr != nil {
+		return compute.Snapshot{}, rerr
+	}
+
+	snapshot, ok := b.snapshots[getKey(resourceGroupName, snapshotName)]
+	if !ok {
+		return compute.Snapshot{}, newNotFoundError("snapshot", snapshotName)
+	}
+	return copySnapshot(snapshot), nil
+}
+
+// Delete deletes a Snapshot by name.
+func (c *snapshotsClient) Delete(ctx context.Context, subsID, resourceGroupName, snapshotName string) *retry.Error {
+	b := c.backend
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if rerr := b.checkThrottled("DeleteSnapshot"); rerr != nil {
+		return rerr
+	}
+
+	delete(b.snapshots, getKey(resourceGroupName, snapshotName))
+	return nil
+}
+
+// ListByResourceGroup get a list snapshots by resourceGroup.
+func (c *snapshotsClient) ListByResourceGroup(ctx context.Context, subsID, resourceGroupName string) ([]compute.Snapshot, *retry.Error) {
+	b := c.backend
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if rerr := b.checkThrottled("ListSnapshotsByResourceGroup"); rerr != nil {
+		return nil, rerr
+	}
+
+	prefix := getKey(resourceGroupName, "")
+	snapshots := []compute.Snapshot{}
+	for key, snapshot := range b.snapshots {
+		if strings.HasPrefix(key, prefix) {
+			snapshots = append(snapshots, copySnapshot(snapshot))
+		}
+	}
+	return snapshots, nil
+}
+
+// CreateOrUpdate creates or updates a Snapshot.
+func (c *snapshotsClient) CreateOrUpdate(ctx context.Context, subsID, resourceGroupName, snapshotName string, snapshot compute.Snapshot) *retry.Error {
+	b := c.backend
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if rerr := b.checkThrottled("CreateOrUpdateSnapshot"); rerr != nil {
+		return rerr
+	}
+
+	snapshot = copySnapshot(snapshot)
+	if snapshot.SnapshotProperties == nil || snapshot.CreationData == nil {
+		return newError(http.StatusBadRequest, "InvalidParameter", "the creation data of a snapshot is required")
+	}
+	if snapshot.Location == nil {
+		snapshot.Location = pointer.String(b.location)
+	}
+
+	sourceSizeGB, rerr := b.getSourceSizeGB(snapshot.CreationData)
+	if rerr != nil {
+		return rerr
+	}
+
+	switch snapshot.CreationData.CreateOption {
+	case compute.Copy:
+		if rerr := b.checkSourceLocation(snapshot.CreationData, *snapshot.Location); rerr != nil {
+			return rerr
+		}
+		// Azure reports the managed disk a snapshot was copied from as its source resource
+		if snapshot.CreationData.SourceResourceID == nil {
+			snapshot.CreationData.SourceResourceID = snapshot.CreationData.SourceURI
+		}
+	case compute.CopyStart:
+		// an incremental snapshot is copied to another region in the background, see SetSnapshotCopyCompletionPercent
+		source, ok := b.getSnapshotByURI(getSourceID(snapshot.CreationData))
+		if !ok {
+			return newError(http.StatusBadRequest, "InvalidParameter", "the source of a background copy must be a snapshot")
+		}
+		if !pointer.BoolDeref(source.Incremental, false) || !pointer.BoolDeref(snapshot.Incremental, false) {
+			return newError(http.StatusBadRequest, "InvalidParameter", "only incremental snapshots can be copied in the background")
+		}
+	default:
+		return newNotImplementedError(fmt.Sprintf("snapshot create option %s", snapshot.CreationData.CreateOption))
+	}
+
+	key := getKey(resourceGroupName, snapshotName)
+	if existing, ok := b.snapshots[key]; ok {
+		if !strings.EqualFold(getSourceID(existing.CreationData), getSourceID(snapshot.CreationData)) {
+			return newError(http.StatusConflict, "Conflict", fmt.Sprintf("snapshot %s was already created from an existing disk %s", snapshotName, getSourceID(existing.CreationData)))
+		}
+		snapshot.TimeCreated = existing.TimeCreated
+		snapshot.CompletionPercent = existing.CompletionPercent
+	} else {
+		snapshot.TimeCreated = &date.Time{Time: time.Now()}
+		if snapshot.CreationData.CreateOption == compute.CopyStart {
+			snapshot.CompletionPercent = pointer.Float64(0)
+		}
+	}
+
+	snapshot.ID = pointer.String(fmt.Sprintf(consts.DiskSnapshotPath, b.subscriptionID, resourceGroupName, snapshotName))
+	snapshot.Name = pointer.String(snapshotName)
+	snapshot.DiskSizeGB = pointer.Int32(sourceSizeGB)
+	snapshot.DiskSizeBytes = pointer.Int64(int64(sourceSizeGB) << 30)
+	snapshot.ProvisioningState = pointer.String(provisioningStateSucceeded)
+	b.snapshots[key] = snapshot
+	return nil
+}
+
+func (b *Backend) getSnapshotByURI(snapshotURI string) (compute.Snapshot, bool) {
+	snapshotName, resourceGroup, err := azureutils.GetSnapshotAndResourceNameFromSnapshotID(snapshotURI)
+	if err != nil {
+		return compute.Snapshot{}, false
+	}
+	snapshot, ok := b.snapshots[getKey(resourceGroup, snapshotName)]
+	return snapshot, ok
+}
+
+func getSourceID(creationData *compute.CreationData) string {
+	if creationData == nil {
+		return ""
+	}
+	return pointer.StringDeref(creationData.SourceResourceID, pointer.StringDeref(creationData.SourceURI, ""))
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakecompute
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-03-01/compute"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azureclients/vmclient"
+	"sigs.k8s.io/cloud-provider-azure/pkg/retry"
+)
+
+type virtualMachinesClient struct {
+	backend *Backend
+}
+
+var _ vmclient.Interface = &virtualMachinesClient{}
+
+// Get gets a VirtualMachine.
+func (c *virtualMachinesClient) Get(ctx context.Context, resourceGroupName string, VMName string, expand compute.InstanceViewTypes) (compute.VirtualMachine, *retry.Error) {
+	b := c.backend
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if rerr := b.checkThrottled("GetVM"); rerr != nil {
+		return compute.VirtualMachine{}, rerr
+	}
+
+	vm, ok := b.virtualMachines[getKey(resourceGroupName, VMName)]
+	if !ok {
+		return compute.VirtualMachine{}, newNotFoundError("virtual machine", VMName)
+	}
+	return copyVirtualMachine(vm), nil
+}
+
+// List gets a list of VirtualMachines in the resourceGroupName.
+func (c *virtualMachinesClient) List(ctx context.Context, resourceGroupName string) ([]compute.VirtualMachine, *retry.Error) {
+	b := c.backend
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if rerr := b.checkThrottled("ListVMs"); rerr != nil {
+		return nil, rerr
+	}
+
+	prefix := getKey(resourceGroupName, "")
+	vms := []compute.VirtualMachine{}
+	for key, vm := range b.virtualMachines {
+		if strings.HasPrefix(key, prefix) {
+			vms = append(vms, copyVirtualMachine(vm))
+		}
+	}
+	return vms, nil
+}
+
+// ListVmssFlexVMsWithoutInstanceView gets a list of VirtualMachine in the VMSS Flex without InstanceView.
+// VMSS Flex is not modeled by the backend, so the list is always empty.
+func (c *virtualMachinesClient) ListVmssFlexVMsWithoutInstanceView(ctx context.Context, vmssFlexID string) ([]compute.VirtualMachine, *retry.Error) {
+	return []compute.VirtualMachine{}, nil
+}
+
+// ListVmssFlexVMsWithOnlyInstanceView gets a list of VirtualMachine in the VMSS Flex with only InstanceView.
+// VMSS Flex is not modeled by the backend, so the list is always empty.
+func (c *virtualMachinesClient) ListVmssFlexVMsWithOnlyInstanceView(ctx context.Context, vmssFlexID string) ([]compute.VirtualMachine, *retry.Error) {
+	return []compute.VirtualMachine{}, nil
+}
+
+// CreateOrUpdate creates or updates a VirtualMachine.
+func (c *virtualMachinesClient) CreateOrUpdate(ctx context.Context, resourceGroupName string, VMName string, parameters compute.VirtualMachine, source string) *retry.Error {
+	return newNotImplementedError("CreateOrUpdateVM")
+}
+
+// Update updates a VirtualMachine.
+func (c *virtualMachinesClient) Update(ctx context.Context, resourceGroupName string, VMName string, parameters compute.VirtualMachineUpdate, source string) (*compute.VirtualMachine, *retry.Error) {
+	b := c.backend
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if rerr := b.checkThrottled("UpdateVM"); rerr != nil {
+		return nil, rerr
+	}
+
+	return b.updateVirtualMachine(resourceGroupName, VMName, parameters)
+}
+
+// UpdateAsync updates a VirtualMachine asynchronously
+func (c *virtualMachinesClient) UpdateAsync(ctx context.Context, resourceGroupName string, VMName string, parameters compute.VirtualMachineUpdate, source string) (*azure.Future, *retry.Error) {
+	b := c.backend
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if rerr := b.checkThrottled("UpdateVMAsync"); rerr != nil {
+		return nil, rerr
+	}
+	if _, ok := b.virtualMachines[getKey(resourceGroupName, VMName)]; !ok {
+		return nil, newNotFoundError("virtual machine", VMName)
+	}
+
+	return b.startUpdate(func() (interface{}, *retry.Error) {
+		return b.updateVirtualMachine(resourceGroupName, VMName, parameters)
+	}), nil
+}
+
+// WaitForUpdateResult waits for the response of the update request
+func (c *virtualMachinesClient) WaitForUpdateResult(ctx context.Context, future *azure.Future, resourceGroupName, source string) (*compute.VirtualMachine, *retry.Error) {
+	result, rerr := c.backend.waitForUpdate(ctx, future)
+	if rerr != nil {
+		return nil, rerr
+	}
+	return result.(*compute.VirtualMachine), nil
+}
+
+// Delete deletes a VirtualMachine.
+func (c *virtualMachinesClient) Delete(ctx context.Context, resourceGroupName string, VMName string) *retry.Error {
+	return newNotImplementedError("DeleteVM")
+}
+
+// updateVirtualMachine applies the update to the VM. It must be called with the lock held.
+func (b *Backend) updateVirtualMachine(resourceGroupName, vmName string, parameters compute.VirtualMachineUpdate) (*compute.VirtualMachine, *retry.Error) {
+	key := getKey(resourceGroupName, vmName)
+	vm, ok := b.virtualMachines[key]
+	if !ok {
+		return nil, newNotFoundError("virtual machine", vmName)
+	}
+
+	vm = copyVirtualMachine(vm)
+	if properties := parameters.VirtualMachineProperties; properties != nil && properties.StorageProfile != nil {
+		dataDisks, rerr := b.updateDataDisks(*vm.ID, string(vm.HardwareProfile.VMSize), vm.StorageProfile.DataDisks, properties.StorageProfile.DataDisks)
+		if rerr != nil {
+			return nil, rerr
+		}
+		vm.StorageProfile.DataDisks = &dataDisks
+	}
+	if parameters.Tags != nil {
+		vm.Tags = copyTags(parameters.Tags)
+	}
+	b.virtualMachines[key] = vm
+	b.recordUpdate(vm.OsProfile)
+
+	result := copyVirtualMachine(vm)
+	return &result, nil
+}
@@ -0,0 +1,259 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakecompute
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-03-01/compute"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azureclients/vmssclient"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azureclients/vmssvmclient"
+	"sigs.k8s.io/cloud-provider-azure/pkg/retry"
+)
+
+type virtualMachineScaleSetsClient struct {
+	backend *Backend
+}
+
+var _ vmssclient.Interface = &virtualMachineScaleSetsClient{}
+
+// Get gets a VirtualMachineScaleSet.
+func (c *virtualMachineScaleSetsClient) Get(ctx context.Context, resourceGroupName string, VMScaleSetName string) (compute.VirtualMachineScaleSet, *retry.Error) {
+	b := c.backend
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if rerr := b.checkThrottled("GetVMSS"); rerr != nil {
+		return compute.VirtualMachineScaleSet{}, rerr
+	}
+
+	scaleSet, ok := b.scaleSets[getKey(resourceGroupName, VMScaleSetName)]
+	if !ok {
+		return compute.VirtualMachineScaleSet{}, newNotFoundError("virtual machine scale set", VMScaleSetName)
+	}
+	return copyVirtualMachineScaleSet(scaleSet), nil
+}
+
+// List gets a list of VirtualMachineScaleSets in the resource group.
+func (c *virtualMachineScaleSetsClient) List(ctx context.Context, resourceGroupName string) ([]compute.VirtualMachineScaleSet, *retry.Error) {
+	b := c.backend
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if rerr := b.checkThrottled("ListVMSS"); rerr != nil {
+		return nil, rerr
+	}
+
+	prefix := getKey(resourceGroupName, "")
+	scaleSets := []compute.VirtualMachineScaleSet{}
+	for key, scaleSet := range b.scaleSets {
+		if strings.HasPrefix(key, prefix) {
+			scaleSets = append(scaleSets, copyVirtualMachineScaleSet(scaleSet))
+		}
+	}
+	return scaleSets, nil
+}
+
+// CreateOrUpdate creates or updates a VirtualMachineScaleSet.
+func (c *virtualMachineScaleSetsClient) CreateOrUpdate(ctx context.Context, resourceGroupName string, VMScaleSetName string, parameters compute.VirtualMachineScaleSet) *retry.Error {
+	return newNotImplementedError("CreateOrUpdateVMSS")
+}
+
+// CreateOrUpdateAsync sends the request to arm client and DO NOT wait for the response
+func (c *virtualMachineScaleSetsClient) CreateOrUpdateAsync(ctx context.Context, resourceGroupName string, VMScaleSetName string, parameters compute.VirtualMachineScaleSet) (*azure.Future, *retry.Error) {
+	return nil, newNotImplementedError("CreateOrUpdateVMSSAsync")
+}
+
+// WaitForAsyncOperationResult waits for the response of the request
+func (c *virtualMachineScaleSetsClient) WaitForAsyncOperationResult(ctx context.Context, future *azure.Future, resourceGroupName, request, asyncOpName string) (*http.Response, error) {
+	return nil, newNotImplementedError("WaitForAsyncOperationResult").Error()
+}
+
+// DeleteInstances deletes the instances for a VirtualMachineScaleSet.
+func (c *virtualMachineScaleSetsClient) DeleteInstances(ctx context.Context, resourceGroupName string, vmScaleSetName string, vmInstanceIDs compute.VirtualMachineScaleSetVMInstanceRequiredIDs) *retry.Error {
+	return newNotImplementedError("DeleteVMSSInstances")
+}
+
+// DeleteInstancesAsync sends the delete request to the ARM client and DOEST NOT wait on the future
+func (c *virtualMachineScaleSetsClient) DeleteInstancesAsync(ctx context.Context, resourceGroupName string, vmScaleSetName string, vmInstanceIDs compute.VirtualMachineScaleSetVMInstanceRequiredIDs, forceDelete bool) (*azure.Future, *retry.Error) {
+	return nil, newNotImplementedError("DeleteVMSSInstancesAsync")
+}
+
+// WaitForCreateOrUpdateResult waits for the response of the create or update request
+func (c *virtualMachineScaleSetsClient) WaitForCreateOrUpdateResult(ctx context.Context, future *azure.Future, resourceGroupName string) (*http.Response, error) {
+	return nil, newNotImplementedError("WaitForCreateOrUpdateResult").Error()
+}
+
+// WaitForDeleteInstancesResult waits for the response of the delete instances request
+func (c *virtualMachineScaleSetsClient) WaitForDeleteInstancesResult(ctx context.Context, future *azure.Future, resourceGroupName string) (*http.Response, error) {
+	return nil, newNotImplementedError("WaitForDeleteInstancesResult").Error()
+}
+
+// DeallocateInstancesAsync sends the deallocate request to the ARM client and DOEST NOT wait on the future
+func (c *virtualMachineScaleSetsClient) DeallocateInstancesAsync(ctx context.Context, resourceGroupName string, vmScaleSetName string, vmInstanceIDs compute.VirtualMachineScaleSetVMInstanceRequiredIDs) (*azure.Future, *retry.Error) {
+	return nil, newNotImplementedError("DeallocateVMSSInstancesAsync")
+}
+
+// WaitForDeallocateInstancesResult waits for the response of the deallocate instances request
+func (c *virtualMachineScaleSetsClient) WaitForDeallocateInstancesResult(ctx context.Context, future *azure.Future, resourceGroupName string) (*http.Response, error) {
+	return nil, newNotImplementedError("WaitForDeallocateInstancesResult").Error()
+}
+
+// StartInstancesAsync starts the instances for a VirtualMachineScaleSet.
+func (c *virtualMachineScaleSetsClient) StartInstancesAsync(ctx context.Context, resourceGroupName string, vmScaleSetName string, vmInstanceIDs compute.VirtualMachineScaleSetVMInstanceRequiredIDs) (*azure.Future, *retry.Error) {
+	return nil, newNotImplementedError("StartVMSSInstancesAsync")
+}
+
+// WaitForStartInstancesResult waits for the response of the start instances request
+func (c *virtualMachineScaleSetsClient) WaitForStartInstancesResult(ctx context.Context, future *azure.Future, resourceGroupName string) (*http.Response, error) {
+	return nil, newNotImplementedError("WaitForStartInstancesResult").Error()
+}
+
+type virtualMachineScaleSetVMsClient struct {
+	backend *Backend
+}
+
+var _ vmssvmclient.Interface = &virtualMachineScaleSetVMsClient{}
+
+// Get gets a VirtualMachineScaleSetVM.
+func (c *virtualMachineScaleSetVMsClient) Get(ctx context.Context, resourceGroupName string, VMScaleSetName string, instanceID string, expand compute.InstanceViewTypes) (compute.VirtualMachineScaleSetVM, *retry.Error) {
+	b := c.backend
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if rerr := b.checkThrottled("GetVMSSVM"); rerr != nil {
+		return compute.VirtualMachineScaleSetVM{}, rerr
+	}
+
+	vm, ok := b.scaleSetVMs[getScaleSetVMKey(resourceGroupName, VMScaleSetName, instanceID)]
+	if !ok {
+		return compute.VirtualMachineScaleSetVM{}, newNotFoundError("virtual machine scale set VM", instanceID)
+	}
+	return copyVirtualMachineScaleSetVM(vm), nil
+}
+
+// List gets a list of VirtualMachineScaleSetVMs in the virtualMachineScaleSet.
+func (c *virtualMachineScaleSetVMsClient) List(ctx context.Context, resourceGroupName string, virtualMachineScaleSetName string, expand string) ([]compute.VirtualMachineScaleSetVM, *retry.Error) {
+	b := c.backend
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if rerr := b.checkThrottled("ListVMSSVMs"); rerr != nil {
+		return nil, rerr
+	}
+
+	prefix := getScaleSetVMKey(resourceGroupName, virtualMachineScaleSetName, "")
+	vms := []compute.VirtualMachineScaleSetVM{}
+	for key, vm := range b.scaleSetVMs {
+		if strings.HasPrefix(key, prefix) {
+			vms = append(vms, copyVirtualMachineScaleSetVM(vm))
+		}
+	}
+	return vms, nil
+}
+
+// Update updates a VirtualMachineScaleSetVM.
+func (c *virtualMachineScaleSetVMsClient) Update(ctx context.Context, resourceGroupName string, VMScaleSetName string, instanceID string, parameters compute.VirtualMachineScaleSetVM, source string) (*compute.VirtualMachineScaleSetVM, *retry.Error) {
+	b := c.backend
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if rerr := b.checkThrottled("UpdateVMSSVM"); rerr != nil {
+		return nil, rerr
+	}
+
+	return b.updateVirtualMachineScaleSetVM(resourceGroupName, VMScaleSetName, instanceID, parameters)
+}
+
+// UpdateAsync updates a VirtualMachineScaleSetVM asynchronously
+func (c *virtualMachineScaleSetVMsClient) UpdateAsync(ctx context.Context, resourceGroupName string, VMScaleSetName string, instanceID string, parameters compute.VirtualMachineScaleSetVM, source string) (*azure.Future, *retry.Error) {
+	b := c.backend
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if rerr := b.checkThrottled("UpdateVMSSVMAsync"); rerr != nil {
+		return nil, rerr
+	}
+	if _, ok := b.scaleSetVMs[getScaleSetVMKey(resourceGroupName, VMScaleSetName, instanceID)]; !ok {
+		return nil, newNotFoundError("virtual machine scale set VM", instanceID)
+	}
+
+	return b.startUpdate(func() (interface{}, *retry.Error) {
+		return b.updateVirtualMachineScaleSetVM(resourceGroupName, VMScaleSetName, instanceID, parameters)
+	}), nil
+}
+
+// WaitForUpdateResult waits for the response of the update request
+func (c *virtualMachineScaleSetVMsClient) WaitForUpdateResult(ctx context.Context, future *azure.Future, resourceGroupName, source string) (*compute.VirtualMachineScaleSetVM, *retry.Error) {
+	result, rerr := c.backend.waitForUpdate(ctx, future)
+	if rerr != nil {
+		return nil, rerr
+	}
+	return result.(*compute.VirtualMachineScaleSetVM), nil
+}
+
+// UpdateVMs updates a list of VirtualMachineScaleSetVM from map[instanceID]compute.VirtualMachineScaleSetVM.
+func (c *virtualMachineScaleSetVMsClient) UpdateVMs(ctx context.Context, resourceGroupName string, VMScaleSetName string, instances map[string]compute.VirtualMachineScaleSetVM, source string, batchSize int) *retry.Error {
+	b := c.backend
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if rerr := b.checkThrottled("UpdateVMSSVMs"); rerr != nil {
+		return rerr
+	}
+
+	for instanceID, parameters := range instances {
+		if _, rerr := b.updateVirtualMachineScaleSetVM(resourceGroupName, VMScaleSetName, instanceID, parameters); rerr != nil {
+			return rerr
+		}
+	}
+	return nil
+}
+
+// updateVirtualMachineScaleSetVM applies the update to the VMSS VM. It must be called with the lock held.
+func (b *Backend) updateVirtualMachineScaleSetVM(resourceGroupName, scaleSetName, instanceID string, parameters compute.VirtualMachineScaleSetVM) (*compute.VirtualMachineScaleSetVM, *retry.Error) {
+	key := getScaleSetVMKey(resourceGroupName, scaleSetName, instanceID)
+	vm, ok := b.scaleSetVMs[key]
+	if !ok {
+		return nil, newNotFoundError("virtual machine scale set VM", instanceID)
+	}
+
+	vm = copyVirtualMachineScaleSetVM(vm)
+	if properties := parameters.VirtualMachineScaleSetVMProperties; properties != nil && properties.StorageProfile != nil {
+		vmSize := ""
+		if vm.Sku != nil {
+			vmSize = pointer.StringDeref(vm.Sku.Name, "")
+		}
+		dataDisks, rerr := b.updateDataDisks(*vm.ID, vmSize, vm.StorageProfile.DataDisks, properties.StorageProfile.DataDisks)
+		if rerr != nil {
+			return nil, rerr
+		}
+		vm.StorageProfile.DataDisks = &dataDisks
+	}
+	if parameters.Tags != nil {
+		vm.Tags = copyTags(parameters.Tags)
+	}
+	b.scaleSetVMs[key] = vm
+	b.recordUpdate(vm.OsProfile)
+
+	result := copyVirtualMachineScaleSetVM(vm)
+	return &result, nil
+}
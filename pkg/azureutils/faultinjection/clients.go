@@ -0,0 +1,332 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package faultinjection
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-03-01/compute"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azureclients/diskclient"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azureclients/snapshotclient"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azureclients/vmclient"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azureclients/vmssvmclient"
+	"sigs.k8s.io/cloud-provider-azure/pkg/provider"
+	"sigs.k8s.io/cloud-provider-azure/pkg/retry"
+)
+
+// InstallClients wraps the disk, snapshot, virtual machine and scale set virtual machine clients of the cloud so that
+// the faults of the injector are injected into their calls.
+func (i *Injector) InstallClients(cloud *provider.Cloud) {
+	if cloud.DisksClient != nil {
+		cloud.DisksClient = &disksClient{Interface: cloud.DisksClient, injector: i}
+	}
+	if cloud.SnapshotsClient != nil {
+		cloud.SnapshotsClient = &snapshotsClient{Interface: cloud.SnapshotsClient, injector: i}
+	}
+	if cloud.VirtualMachinesClient != nil {
+		cloud.VirtualMachinesClient = &virtualMachinesClient{Interface: cloud.VirtualMachinesClient, injector: i}
+	}
+	if cloud.VirtualMachineScaleSetVMsClient != nil {
+		cloud.VirtualMachineScaleSetVMsClient = &virtualMachineScaleSetVMsClient{Interface: cloud.VirtualMachineScaleSetVMsClient, injector: i}
+	}
+}
+
+// inject injects a fault into a call returning only an error.
+func (i *Injector) inject(ctx context.Context, operation string, disks []string, call func() *retry.Error) *retry.Error {
+	rerr, dropResponse := i.Inject(ctx, operation, disks...)
+	if rerr != nil {
+		return rerr
+	}
+	if rerr = call(); rerr == nil && dropResponse {
+		rerr = DroppedResponseError(operation)
+	}
+	return rerr
+}
+
+type disksClient struct {
+	diskclient.Interface
+	injector *Injector
+}
+
+var _ diskclient.Interface = &disksClient{}
+
+func (c *disksClient) Get(ctx context.Context, subsID, resourceGroupName, diskName string) (result compute.Disk, rerr *retry.Error) {
+	rerr = c.injector.inject(ctx, "DisksClient.Get", []string{diskName}, func() *retry.Error {
+		result, rerr = c.Interface.Get(ctx, subsID, resourceGroupName, diskName)
+		return rerr
+	})
+	if rerr != nil {
+		return compute.Disk{}, rerr
+	}
+	return result, nil
+}
+
+func (c *disksClient) CreateOrUpdate(ctx context.Context, subsID, resourceGroupName, diskName string, diskParameter compute.Disk) *retry.Error {
+	return c.injector.inject(ctx, "DisksClient.CreateOrUpdate", []string{diskName}, func() *retry.Error {
+		return c.Interface.CreateOrUpdate(ctx, subsID, resourceGroupName, diskName, diskParameter)
+	})
+}
+
+func (c *disksClient) Update(ctx context.Context, subsID, resourceGroupName, diskName string, diskParameter compute.DiskUpdate) *retry.Error {
+	return c.injector.inject(ctx, "DisksClient.Update", []string{diskName}, func() *retry.Error {
+		return c.Interface.Update(ctx, subsID, resourceGroupName, diskName, diskParameter)
+	})
+}
+
+func (c *disksClient) Delete(ctx context.Context, subsID, resourceGroupName, diskName string) *retry.Error {
+	return c.injector.inject(ctx, "DisksClient.Delete", []string{diskName}, func() *retry.Error {
+		return c.Interface.Delete(ctx, subsID, resourceGroupName, diskName)
+	})
+}
+
+func (c *disksClient) ListByResourceGroup(ctx context.Context, subsID, resourceGroupName string) (result []compute.Disk, rerr *retry.Error) {
+	rerr = c.injector.inject(ctx, "DisksClient.ListByResourceGroup", nil, func() *retry.Error {
+		result, rerr = c.Interface.ListByResourceGroup(ctx, subsID, resourceGroupName)
+		return rerr
+	})
+	if rerr != nil {
+		return nil, rerr
+	}
+	return result, nil
+}
+
+type snapshotsClient struct {
+	snapshotclient.Interface
+	injector *Injector
+}
+
+var _ snapshotclient.Interface = &snapshotsClient{}
+
+func (c *snapshotsClient) Get(ctx context.Context, subsID, resourceGroupName, snapshotName string) (result compute.Snapshot, rerr *retry.Error) {
+	rerr = c.injector.inject(ctx, "SnapshotsClient.Get", []string{snapshotName}, func() *retry.Error {
+		result, rerr = c.Interface.Get(ctx, subsID, resourceGroupName, snapshotName)
+		return rerr
+	})
+	if rerr != nil {
+		return compute.Snapshot{}, rerr
+	}
+	return result, nil
+}
+
+func (c *snapshotsClient) Delete(ctx context.Context, subsID, resourceGroupName, snapshotName string) *retry.Error {
+	return c.injector.inject(ctx, "SnapshotsClient.Delete", []string{snapshotName}, func() *retry.Error {
+		return c.Interface.Delete(ctx, subsID, resourceGroupName, snapshotName)
+	})
+}
+
+func (c *snapshotsClient) ListByResourceGroup(ctx context.Context, subsID, resourceGroupName string) (result []compute.Snapshot, rerr *retry.Error) {
+	rerr = c.injector.inject(ctx, "SnapshotsClient.ListByResourceGroup", nil, func() *retry.Error {
+		result, rerr = c.Interface.ListByResourceGroup(ctx, subsID, resourceGroupName)
+		return rerr
+	})
+	if rerr != nil {
+		return nil, rerr
+	}
+	return result, nil
+}
+
+func (c *snapshotsClient) CreateOrUpdate(ctx context.Context, subsID, resourceGroupName, snapshotName string, snapshot compute.Snapshot) *retry.Error {
+	disks := []string{snapshotName}
+	if snapshot.SnapshotProperties != nil && snapshot.CreationData != nil && snapshot.CreationData.SourceResourceID != nil {
+		disks = append(disks, *snapshot.CreationData.SourceResourceID)
+	}
+	return c.injector.inject(ctx, "SnapshotsClient.CreateOrUpdate", disks, func() *retry.Error {
+		return c.Interface.CreateOrUpdate(ctx, subsID, resourceGroupName, snapshotName, snapshot)
+	})
+}
+
+// futureDisks tracks the disks of the VM updates started asynchronously so that the faults for those disks can be
+// injected when waiting for the result of the update.
+type futureDisks struct {
+	disks sync.Map
+}
+
+func (f *futureDisks) store(future *azure.Future, disks []string) {
+	if future != nil {
+		f.disks.Store(future, disks)
+	}
+}
+
+func (f *futureDisks) load(future *azure.Future) []string {
+	disks, ok := f.disks.LoadAndDelete(future)
+	if !ok {
+		return nil
+	}
+	return disks.([]string)
+}
+
+func getDataDiskNames(dataDisks *[]compute.DataDisk) []string {
+	if dataDisks == nil {
+		return nil
+	}
+
+	disks := make([]string, 0, len(*dataDisks))
+	for _, dataDisk := range *dataDisks {
+		if dataDisk.ManagedDisk != nil && dataDisk.ManagedDisk.ID != nil {
+			disks = append(disks, *dataDisk.ManagedDisk.ID)
+		} else {
+			disks = append(disks, pointer.StringDeref(dataDisk.Name, ""))
+		}
+	}
+	return disks
+}
+
+type virtualMachinesClient struct {
+	vmclient.Interface
+	injector *Injector
+	futures  futureDisks
+}
+
+var _ vmclient.Interface = &virtualMachinesClient{}
+
+func getVirtualMachineDataDisks(parameters compute.VirtualMachineUpdate) []string {
+	if parameters.VirtualMachineProperties == nil || parameters.StorageProfile == nil {
+		return nil
+	}
+	return getDataDiskNames(parameters.StorageProfile.DataDisks)
+}
+
+func (c *virtualMachinesClient) Get(ctx context.Context, resourceGroupName string, VMName string, expand compute.InstanceViewTypes) (result compute.VirtualMachine, rerr *retry.Error) {
+	rerr = c.injector.inject(ctx, "VirtualMachinesClient.Get", nil, func() *retry.Error {
+		result, rerr = c.Interface.Get(ctx, resourceGroupName, VMName, expand)
+		return rerr
+	})
+	if rerr != nil {
+		return compute.VirtualMachine{}, rerr
+	}
+	return result, nil
+}
+
+func (c *virtualMachinesClient) Update(ctx context.Context, resourceGroupName string, VMName string, parameters compute.VirtualMachineUpdate, source string) (result *compute.VirtualMachine, rerr *retry.Error) {
+	rerr = c.injector.inject(ctx, "VirtualMachinesClient.Update", getVirtualMachineDataDisks(parameters), func() *retry.Error {
+		result, rerr = c.Interface.Update(ctx, resourceGroupName, VMName, parameters, source)
+		return rerr
+	})
+	if rerr != nil {
+		return nil, rerr
+	}
+	return result, nil
+}
+
+func (c *virtualMachinesClient) UpdateAsync(ctx context.Context, resourceGroupName string, VMName string, parameters compute.VirtualMachineUpdate, source string) (future *azure.Future, rerr *retry.Error) {
+	disks := getVirtualMachineDataDisks(parameters)
+	rerr = c.injector.inject(ctx, "VirtualMachinesClient.UpdateAsync", disks, func() *retry.Error {
+		future, rerr = c.Interface.UpdateAsync(ctx, resourceGroupName, VMName, parameters, source)
+		return rerr
+	})
+	if rerr != nil {
+		return nil, rerr
+	}
+	c.futures.store(future, disks)
+	return future, nil
+}
+
+func (c *virtualMachinesClient) WaitForUpdateResult(ctx context.Context, future *azure.Future, resourceGroupName, source string) (result *compute.VirtualMachine, rerr *retry.Error) {
+	rerr = c.injector.inject(ctx, "VirtualMachinesClient.WaitForUpdateResult", c.futures.load(future), func() *retry.Error {
+		result, rerr = c.Interface.WaitForUpdateResult(ctx, future, resourceGroupName, source)
+		return rerr
+	})
+	if rerr != nil {
+		return nil, rerr
+	}
+	return result, nil
+}
+
+type virtualMachineScaleSetVMsClient struct {
+	vmssvmclient.Interface
+	injector *Injector
+	futures  futureDisks
+}
+
+var _ vmssvmclient.Interface = &virtualMachineScaleSetVMsClient{}
+
+func getVirtualMachineScaleSetVMDataDisks(parameters compute.VirtualMachineScaleSetVM) []string {
+	if parameters.VirtualMachineScaleSetVMProperties == nil || parameters.StorageProfile == nil {
+		return nil
+	}
+	return getDataDiskNames(parameters.StorageProfile.DataDisks)
+}
+
+func (c *virtualMachineScaleSetVMsClient) Get(ctx context.Context, resourceGroupName string, VMScaleSetName string, instanceID string, expand compute.InstanceViewTypes) (result compute.VirtualMachineScaleSetVM, rerr *retry.Error) {
+	rerr = c.injector.inject(ctx, "VirtualMachineScaleSetVMsClient.Get", nil, func() *retry.Error {
+		result, rerr = c.Interface.Get(ctx, resourceGroupName, VMScaleSetName, instanceID, expand)
+		return rerr
+	})
+	if rerr != nil {
+		return compute.VirtualMachineScaleSetVM{}, rerr
+	}
+	return result, nil
+}
+
+func (c *virtualMachineScaleSetVMsClient) List(ctx context.Context, resourceGroupName string, virtualMachineScaleSetName string, expand string) (result []compute.VirtualMachineScaleSetVM, rerr *retry.Error) {
+	rerr = c.injector.inject(ctx, "VirtualMachineScaleSetVMsClient.List", nil, func() *retry.Error {
+		result, rerr = c.Interface.List(ctx, resourceGroupName, virtualMachineScaleSetName, expand)
+		return rerr
+	})
+	if rerr != nil {
+		return nil, rerr
+	}
+	return result, nil
+}
+
+func (c *virtualMachineScaleSetVMsClient) Update(ctx context.Context, resourceGroupName string, VMScaleSetName string, instanceID string, parameters compute.VirtualMachineScaleSetVM, source string) (result *compute.VirtualMachineScaleSetVM, rerr *retry.Error) {
+	rerr = c.injector.inject(ctx, "VirtualMachineScaleSetVMsClient.Update", getVirtualMachineScaleSetVMDataDisks(parameters), func() *retry.Error {
+		result, rerr = c.Interface.Update(ctx, resourceGroupName, VMScaleSetName, instanceID, parameters, source)
+		return rerr
+	})
+	if rerr != nil {
+		return nil, rerr
+	}
+	return result, nil
+}
+
+func (c *virtualMachineScaleSetVMsClient) UpdateAsync(ctx context.Context, resourceGroupName string, VMScaleSetName string, instanceID string, parameters compute.VirtualMachineScaleSetVM, source string) (future *azure.Future, rerr *retry.Error) {
+	disks := getVirtualMachineScaleSetVMDataDisks(parameters)
+	rerr = c.injector.inject(ctx, "VirtualMachineScaleSetVMsClient.UpdateAsync", disks, func() *retry.Error {
+		future, rerr = c.Interface.UpdateAsync(ctx, resourceGroupName, VMScaleSetName, instanceID, parameters, source)
+		return rerr
+	})
+	if rerr != nil {
+		return nil, rerr
+	}
+	c.futures.store(future, disks)
+	return future, nil
+}
+
+func (c *virtualMachineScaleSetVMsClient) WaitForUpdateResult(ctx context.Context, future *azure.Future, resourceGroupName, source string) (result *compute.VirtualMachineScaleSetVM, rerr *retry.Error) {
+	rerr = c.injector.inject(ctx, "VirtualMachineScaleSetVMsClient.WaitForUpdateResult", c.futures.load(future), func() *retry.Error {
+		result, rerr = c.Interface.WaitForUpdateResult(ctx, future, resourceGroupName, source)
+		return rerr
+	})
+	if rerr != nil {
+		return nil, rerr
+	}
+	return result, nil
+}
+
+func (c *virtualMachineScaleSetVMsClient) UpdateVMs(ctx context.Context, resourceGroupName string, VMScaleSetName string, instances map[string]compute.VirtualMachineScaleSetVM, source string, batchSize int) *retry.Error {
+	var disks []string
+	for _, instance := range instances {
+		disks = append(disks, getVirtualMachineScaleSetVMDataDisks(instance)...)
+	}
+	return c.injector.inject(ctx, "VirtualMachineScaleSetVMsClient.UpdateVMs", disks, func() *retry.Error {
+		return c.Interface.UpdateVMs(ctx, resourceGroupName, VMScaleSetName, instances, source, batchSize)
+	})
+}
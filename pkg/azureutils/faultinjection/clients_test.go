@@ -0,0 +1,129 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package faultinjection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"syscall"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-03-01/compute"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/pointer"
+	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils/fakecompute"
+	"sigs.k8s.io/cloud-provider-azure/pkg/provider"
+)
+
+const (
+	testSubscription  = "subscription"
+	testResourceGroup = "rg"
+	testVMName        = "test-vm"
+)
+
+func newTestCloud(t *testing.T, faults ...Fault) (*provider.Cloud, *fakecompute.Backend) {
+	injector, err := NewInjector(&Config{Faults: faults})
+	require.NoError(t, err)
+
+	backend := fakecompute.NewBackend(testSubscription, "westus")
+	backend.AddVirtualMachine(testResourceGroup, testVMName, "Standard_D2s_v3")
+
+	cloud := &provider.Cloud{}
+	backend.Install(cloud)
+	injector.InstallClients(cloud)
+	return cloud, backend
+}
+
+func newTestDisk() compute.Disk {
+	return compute.Disk{
+		DiskProperties: &compute.DiskProperties{
+			CreationData: &compute.CreationData{CreateOption: compute.Empty},
+			DiskSizeGB:   pointer.Int32(10),
+		},
+	}
+}
+
+func newTestVMUpdate(diskNames ...string) compute.VirtualMachineUpdate {
+	dataDisks := make([]compute.DataDisk, 0, len(diskNames))
+	for lun, diskName := range diskNames {
+		dataDisks = append(dataDisks, compute.DataDisk{
+			Lun:          pointer.Int32(int32(lun)),
+			ManagedDisk:  &compute.ManagedDiskParameters{ID: pointer.String(fmt.Sprintf(consts.ManagedDiskPath, testSubscription, testResourceGroup, diskName))},
+			CreateOption: compute.DiskCreateOptionTypesAttach,
+		})
+	}
+	return compute.VirtualMachineUpdate{
+		VirtualMachineProperties: &compute.VirtualMachineProperties{
+			StorageProfile: &compute.StorageProfile{DataDisks: &dataDisks},
+		},
+	}
+}
+
+func TestDisksClient(t *testing.T) {
+	cloud, backend := newTestCloud(t,
+		Fault{Operation: "DisksClient.Get", Disk: "disk-0", HTTPStatusCode: http.StatusConflict, ErrorCode: "OperationNotAllowed"},
+		Fault{Operation: "DisksClient.CreateOrUpdate", Disk: "disk-1", DropResponse: true},
+	)
+	ctx := context.TODO()
+
+	require.Nil(t, cloud.DisksClient.CreateOrUpdate(ctx, testSubscription, testResourceGroup, "disk-0", newTestDisk()))
+	_, rerr := cloud.DisksClient.Get(ctx, testSubscription, testResourceGroup, "disk-0")
+	require.NotNil(t, rerr)
+	assert.Equal(t, http.StatusConflict, rerr.HTTPStatusCode)
+
+	// the disk is created even though the response is dropped
+	rerr = cloud.DisksClient.CreateOrUpdate(ctx, testSubscription, testResourceGroup, "disk-1", newTestDisk())
+	require.NotNil(t, rerr)
+	assert.True(t, errors.Is(rerr.Error(), syscall.ECONNRESET))
+	_, ok := backend.GetDisk(fmt.Sprintf(consts.ManagedDiskPath, testSubscription, testResourceGroup, "disk-1"))
+	assert.True(t, ok)
+
+	disk, rerr := cloud.DisksClient.Get(ctx, testSubscription, testResourceGroup, "disk-1")
+	require.Nil(t, rerr)
+	assert.Equal(t, "disk-1", *disk.Name)
+}
+
+func TestVirtualMachinesClient(t *testing.T) {
+	cloud, backend := newTestCloud(t,
+		Fault{Operation: "VirtualMachinesClient.WaitForUpdateResult", Disk: "disk-1", RetryAfterInSec: 10},
+	)
+	ctx := context.TODO()
+
+	for _, diskName := range []string{"disk-0", "disk-1"} {
+		require.Nil(t, cloud.DisksClient.CreateOrUpdate(ctx, testSubscription, testResourceGroup, diskName, newTestDisk()))
+	}
+
+	future, rerr := cloud.VirtualMachinesClient.UpdateAsync(ctx, testResourceGroup, testVMName, newTestVMUpdate("disk-0"), "test")
+	require.Nil(t, rerr)
+	_, rerr = cloud.VirtualMachinesClient.WaitForUpdateResult(ctx, future, testResourceGroup, "test")
+	require.Nil(t, rerr)
+
+	// the fault for the disk is injected when waiting for the result of the update attaching it
+	future, rerr = cloud.VirtualMachinesClient.UpdateAsync(ctx, testResourceGroup, testVMName, newTestVMUpdate("disk-0", "disk-1"), "test")
+	require.Nil(t, rerr)
+	_, rerr = cloud.VirtualMachinesClient.WaitForUpdateResult(ctx, future, testResourceGroup, "test")
+	require.NotNil(t, rerr)
+	assert.True(t, rerr.IsThrottled())
+
+	dataDisks, ok := backend.GetDataDisks(testVMName)
+	require.True(t, ok)
+	assert.Len(t, dataDisks, 1)
+}
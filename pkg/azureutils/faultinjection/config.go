@@ -0,0 +1,112 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package faultinjection
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// AllOperations matches every operation.
+	AllOperations = "*"
+)
+
+// Fault describes a failure injected into the matching operations.
+type Fault struct {
+	// The operation the fault is injected into, e.g. "PublishVolume" for a cloud provisioner operation or "DisksClient.Get" for an Azure client call.
+	// The fault is injected into all operations if the operation is empty or "*".
+	Operation string `json:"operation,omitempty"`
+	// The name or resource ID of the disk or snapshot the fault is injected for. The fault is injected for all disks if the disk is empty.
+	Disk string `json:"disk,omitempty"`
+	// The probability between 0 and 1 with which a matching call is faulted. The fault is always injected if the probability is 0.
+	Probability float64 `json:"probability,omitempty"`
+	// The maximum number of times the fault is injected. The fault is injected indefinitely if the count is 0.
+	Count int `json:"count,omitempty"`
+	// The latency in milliseconds added before the operation is performed.
+	LatencyInMillis int `json:"latencyInMillis,omitempty"`
+	// The HTTP status code of the error returned instead of performing the operation.
+	HTTPStatusCode int `json:"httpStatusCode,omitempty"`
+	// The error code of the error returned instead of performing the operation, e.g. "OperationNotAllowed".
+	ErrorCode string `json:"errorCode,omitempty"`
+	// The Retry-After duration in seconds of a TooManyRequests error returned instead of performing the operation.
+	RetryAfterInSec int `json:"retryAfterInSec,omitempty"`
+	// Whether the operation is performed but a connection reset error is returned to the caller as if the response was lost.
+	DropResponse bool `json:"dropResponse,omitempty"`
+}
+
+// Config is the fault-injection configuration read from a file or a ConfigMap.
+type Config struct {
+	// The faults to inject. The first fault matching a call is injected.
+	Faults []Fault `json:"faults,omitempty"`
+}
+
+// LoadConfig reads the fault-injection configuration in YAML or JSON format from the specified file.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fault-injection config %s: %v", path, err)
+	}
+
+	config := &Config{}
+	if err := yaml.UnmarshalStrict(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse fault-injection config %s: %v", path, err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid fault-injection config %s: %v", path, err)
+	}
+
+	return config, nil
+}
+
+// Validate checks that each fault of the configuration is well formed.
+func (c *Config) Validate() error {
+	for i, fault := range c.Faults {
+		if err := fault.validate(); err != nil {
+			return fmt.Errorf("fault %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+func (f *Fault) validate() error {
+	switch {
+	case f.Probability < 0 || f.Probability > 1:
+		return fmt.Errorf("probability %v is not between 0 and 1", f.Probability)
+	case f.Count < 0:
+		return fmt.Errorf("count %d is negative", f.Count)
+	case f.LatencyInMillis < 0:
+		return fmt.Errorf("latencyInMillis %d is negative", f.LatencyInMillis)
+	case f.RetryAfterInSec < 0:
+		return fmt.Errorf("retryAfterInSec %d is negative", f.RetryAfterInSec)
+	case f.HTTPStatusCode != 0 && (f.HTTPStatusCode < http.StatusBadRequest || f.HTTPStatusCode > 599):
+		return fmt.Errorf("httpStatusCode %d is not an error status code", f.HTTPStatusCode)
+	case f.DropResponse && f.returnsError():
+		return fmt.Errorf("dropResponse cannot be combined with an error")
+	case f.LatencyInMillis == 0 && !f.DropResponse && !f.returnsError():
+		return fmt.Errorf("no latency, error or dropped response is specified")
+	}
+	return nil
+}
+
+func (f *Fault) returnsError() bool {
+	return f.HTTPStatusCode != 0 || f.ErrorCode != "" || f.RetryAfterInSec > 0
+}
@@ -0,0 +1,227 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package faultinjection
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"k8s.io/klog/v2"
+	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
+	"sigs.k8s.io/cloud-provider-azure/pkg/retry"
+)
+
+// Injector injects the faults of a configuration into the operations it is consulted for.
+type Injector struct {
+	lock     sync.Mutex
+	config   *Config
+	injected []int
+	random   *rand.Rand
+
+	path    string
+	modTime time.Time
+}
+
+// NewInjector returns an injector for the specified configuration.
+func NewInjector(config *Config) (*Injector, error) {
+	if config == nil {
+		config = &Config{}
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &Injector{
+		config:   config,
+		injected: make([]int, len(config.Faults)),
+		random:   rand.New(rand.NewSource(time.Now().UnixNano())),
+	}, nil
+}
+
+// NewInjectorFromFile returns an injector for the configuration in the specified file.
+// The configuration is reloaded by Reload and Run when the file changes, e.g. when the ConfigMap it is mounted from is updated.
+func NewInjectorFromFile(path string) (*Injector, error) {
+	injector, err := NewInjector(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	injector.path = path
+	if err := injector.Reload(); err != nil {
+		return nil, err
+	}
+
+	return injector, nil
+}
+
+// Reload re-reads the configuration file if it was modified since it was last loaded.
+// The number of times each fault was injected is reset when the configuration is reloaded.
+func (i *Injector) Reload() error {
+	if i.path == "" {
+		return nil
+	}
+
+	info, err := os.Stat(i.path)
+	if err != nil {
+		return fmt.Errorf("failed to stat fault-injection config %s: %v", i.path, err)
+	}
+
+	i.lock.Lock()
+	modTime := i.modTime
+	i.lock.Unlock()
+	if info.ModTime().Equal(modTime) {
+		return nil
+	}
+
+	config, err := LoadConfig(i.path)
+	if err != nil {
+		return err
+	}
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	i.config = config
+	i.injected = make([]int, len(config.Faults))
+	i.modTime = info.ModTime()
+	klog.Infof("Loaded %d fault(s) from fault-injection config %s", len(config.Faults), i.path)
+
+	return nil
+}
+
+// Run reloads the configuration file at the specified interval until the context is done.
+func (i *Injector) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := i.Reload(); err != nil {
+				klog.Errorf("Failed to reload fault-injection config: %v", err)
+			}
+		}
+	}
+}
+
+// Inject injects the first fault matching the operation and any of the disks. It waits for the latency of the fault
+// and returns the error to return instead of performing the operation, if any. If dropResponse is true, the operation
+// should be performed but its result replaced with the error returned by DroppedResponseError.
+func (i *Injector) Inject(ctx context.Context, operation string, disks ...string) (rerr *retry.Error, dropResponse bool) {
+	fault, ok := i.match(operation, disks)
+	if !ok {
+		return nil, false
+	}
+
+	klog.V(2).Infof("Injecting fault into operation %s for disk(s) %v: %+v", operation, disks, fault)
+
+	if fault.LatencyInMillis > 0 {
+		timer := time.NewTimer(time.Duration(fault.LatencyInMillis) * time.Millisecond)
+		defer timer.Stop()
+
+		select {
+		case <-ctx.Done():
+			return retry.NewError(true, ctx.Err()), false
+		case <-timer.C:
+		}
+	}
+
+	if fault.returnsError() {
+		return newError(operation, fault), false
+	}
+
+	return nil, fault.DropResponse
+}
+
+// DroppedResponseError returns the error returned to the caller of an operation whose response was dropped.
+func DroppedResponseError(operation string) *retry.Error {
+	return retry.NewError(true, fmt.Errorf("fault injected into operation %s: response dropped: %w", operation, syscall.ECONNRESET))
+}
+
+func (i *Injector) match(operation string, disks []string) (Fault, bool) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	for index, fault := range i.config.Faults {
+		if !matchesOperation(fault.Operation, operation) || !matchesDisk(fault.Disk, disks) {
+			continue
+		}
+		if fault.Count > 0 && i.injected[index] >= fault.Count {
+			continue
+		}
+		if fault.Probability > 0 && i.random.Float64() >= fault.Probability {
+			continue
+		}
+		i.injected[index]++
+		return fault, true
+	}
+
+	return Fault{}, false
+}
+
+func matchesOperation(pattern, operation string) bool {
+	return pattern == "" || pattern == AllOperations || strings.EqualFold(pattern, operation)
+}
+
+func matchesDisk(pattern string, disks []string) bool {
+	if pattern == "" {
+		return true
+	}
+
+	for _, disk := range disks {
+		if strings.EqualFold(pattern, disk) || strings.EqualFold(pattern, path.Base(disk)) {
+			return true
+		}
+	}
+	return false
+}
+
+func newError(operation string, fault Fault) *retry.Error {
+	statusCode, errorCode := fault.HTTPStatusCode, fault.ErrorCode
+	var retryAfter time.Time
+	if fault.RetryAfterInSec > 0 {
+		if statusCode == 0 {
+			statusCode = http.StatusTooManyRequests
+		}
+		if errorCode == "" {
+			errorCode = consts.TooManyRequests
+		}
+		retryAfter = time.Now().Add(time.Duration(fault.RetryAfterInSec) * time.Second)
+	}
+	if statusCode == 0 {
+		statusCode = http.StatusInternalServerError
+	}
+	if errorCode == "" {
+		errorCode = strings.ReplaceAll(http.StatusText(statusCode), " ", "")
+	}
+
+	return &retry.Error{
+		Retriable:      statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError,
+		HTTPStatusCode: statusCode,
+		RetryAfter:     retryAfter,
+		RawError:       fmt.Errorf("Code=%q Message=%q", errorCode, fmt.Sprintf("fault injected into operation %s", operation)),
+	}
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package faultinjection
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils"
+)
+
+const testConfig = `
+faults:
+- operation: DisksClient.Get
+  disk: disk-0
+  httpStatusCode: 404
+  errorCode: NotFound
+- operation: PublishVolume
+  retryAfterInSec: 30
+  count: 1
+- operation: "*"
+  disk: disk-1
+  dropResponse: true
+`
+
+func writeTestConfig(t *testing.T, config string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(config), 0600))
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		description string
+		config      string
+		expectedErr bool
+	}{
+		{
+			description: "[Success] Valid config",
+			config:      testConfig,
+		},
+		{
+			description: "[Success] Empty config",
+			config:      "",
+		},
+		{
+			description: "[Failure] Unknown field",
+			config:      "faults:\n- operation: PublishVolume\n  latency: 10\n",
+			expectedErr: true,
+		},
+		{
+			description: "[Failure] Fault without effect",
+			config:      "faults:\n- operation: PublishVolume\n",
+			expectedErr: true,
+		},
+		{
+			description: "[Failure] Invalid probability",
+			config:      "faults:\n- probability: 2\n  latencyInMillis: 10\n",
+			expectedErr: true,
+		},
+		{
+			description: "[Failure] Invalid status code",
+			config:      "faults:\n- httpStatusCode: 200\n",
+			expectedErr: true,
+		},
+		{
+			description: "[Failure] Dropped response with error",
+			config:      "faults:\n- httpStatusCode: 500\n  dropResponse: true\n",
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		tt := test
+		t.Run(tt.description, func(t *testing.T) {
+			_, err := LoadConfig(writeTestConfig(t, tt.config))
+			if tt.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestInject(t *testing.T) {
+	injector, err := NewInjectorFromFile(writeTestConfig(t, testConfig))
+	require.NoError(t, err)
+	ctx := context.TODO()
+
+	// the fault is only injected for the matching operation and disk
+	rerr, dropResponse := injector.Inject(ctx, "DisksClient.Get", "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/disks/DISK-0")
+	require.NotNil(t, rerr)
+	assert.False(t, dropResponse)
+	assert.True(t, rerr.IsNotFound())
+	assert.Contains(t, rerr.Error().Error(), `Code="NotFound"`)
+
+	rerr, dropResponse = injector.Inject(ctx, "DisksClient.Get", "disk-2")
+	assert.Nil(t, rerr)
+	assert.False(t, dropResponse)
+
+	rerr, _ = injector.Inject(ctx, "DisksClient.Update", "disk-0")
+	assert.Nil(t, rerr)
+
+	// a throttling fault returns a TooManyRequests error with Retry-After and is only injected the configured number of times
+	rerr, _ = injector.Inject(ctx, "PublishVolume", "disk-2")
+	require.NotNil(t, rerr)
+	assert.True(t, rerr.IsThrottled())
+	assert.Equal(t, http.StatusTooManyRequests, rerr.HTTPStatusCode)
+	assert.True(t, rerr.RetryAfter.After(time.Now().Add(20*time.Second)))
+	assert.Contains(t, rerr.Error().Error(), "TooManyRequests")
+
+	rerr, _ = injector.Inject(ctx, "PublishVolume", "disk-2")
+	assert.Nil(t, rerr)
+
+	// a dropped response is reported as a connection reset
+	rerr, dropResponse = injector.Inject(ctx, "DeleteVolume", "disk-1")
+	assert.Nil(t, rerr)
+	assert.True(t, dropResponse)
+	assert.True(t, errors.Is(DroppedResponseError("DeleteVolume").Error(), syscall.ECONNRESET))
+}
+
+func TestInjectLatency(t *testing.T) {
+	injector, err := NewInjector(&Config{Faults: []Fault{{Operation: "PublishVolume", LatencyInMillis: 50}}})
+	require.NoError(t, err)
+
+	start := time.Now()
+	rerr, dropResponse := injector.Inject(context.TODO(), "PublishVolume")
+	assert.Nil(t, rerr)
+	assert.False(t, dropResponse)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	// the latency is cut short when the context is done
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	rerr, _ = injector.Inject(ctx, "PublishVolume")
+	require.NotNil(t, rerr)
+	assert.True(t, errors.Is(rerr.Error(), context.Canceled))
+}
+
+func TestInjectProbability(t *testing.T) {
+	injector, err := NewInjector(&Config{Faults: []Fault{{HTTPStatusCode: http.StatusInternalServerError, Probability: 0.5}}})
+	require.NoError(t, err)
+
+	injected := 0
+	for i := 0; i < 1000; i++ {
+		if rerr, _ := injector.Inject(context.TODO(), "CreateVolume"); rerr != nil {
+			assert.True(t, rerr.Retriable)
+			injected++
+		}
+	}
+	assert.Greater(t, injected, 0)
+	assert.Less(t, injected, 1000)
+}
+
+func TestReload(t *testing.T) {
+	path := writeTestConfig(t, testConfig)
+	injector, err := NewInjectorFromFile(path)
+	require.NoError(t, err)
+
+	rerr, _ := injector.Inject(context.TODO(), "DisksClient.Get", "disk-0")
+	assert.NotNil(t, rerr)
+
+	require.NoError(t, os.WriteFile(path, []byte("faults: []\n"), 0600))
+	modTime := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+	require.NoError(t, injector.Reload())
+
+	rerr, _ = injector.Inject(context.TODO(), "DisksClient.Get", "disk-0")
+	assert.Nil(t, rerr)
+
+	// an invalid config is rejected and the previous config is kept
+	require.NoError(t, os.WriteFile(path, []byte("faults:\n- operation: DisksClient.Get\n"), 0600))
+	modTime = modTime.Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+	assert.Error(t, injector.Reload())
+}
+
+func TestSleepIfThrottled(t *testing.T) {
+	injector, err := NewInjector(&Config{Faults: []Fault{{RetryAfterInSec: 1}}})
+	require.NoError(t, err)
+
+	rerr, _ := injector.Inject(context.TODO(), "SnapshotsClient.CreateOrUpdate")
+	require.NotNil(t, rerr)
+
+	start := time.Now()
+	azureutils.SleepIfThrottled(rerr.Error(), 1)
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+}
@@ -65,6 +65,20 @@ func NewAzVolumeInformer(factory azdiskinformers.SharedInformerFactory) GenericI
 	}
 }
 
+func NewAzSnapshotInformer(factory azdiskinformers.SharedInformerFactory) GenericInformer {
+	return &GenericAzInformerInfo{
+		factory:  factory,
+		informer: factory.Disk().V1beta2().AzSnapshots().Informer(),
+	}
+}
+
+func NewAzVolumeGroupSnapshotInformer(factory azdiskinformers.SharedInformerFactory) GenericInformer {
+	return &GenericAzInformerInfo{
+		factory:  factory,
+		informer: factory.Disk().V1beta2().AzVolumeGroupSnapshots().Informer(),
+	}
+}
+
 type GenericKubeInformer struct {
 	factory  informers.SharedInformerFactory
 	informer cache.SharedIndexInformer
@@ -104,6 +104,11 @@ func (r *ReconcileAttachDetach) Reconcile(ctx context.Context, request reconcile
 		return reconcileReturnOnError(ctx, azVolumeAttachment, "get", err, r.retryInfo)
 	}
 
+	// skip requests queued before the shard of the volume was handed off to another controller
+	if !r.ownsVolume(azVolumeAttachment.Spec.VolumeName) {
+		return reconcileReturnOnSuccess(azVolumeAttachment.Name, r.retryInfo)
+	}
+
 	ctx, _ = workflow.GetWorkflowFromObj(ctx, azVolumeAttachment)
 
 	// if underlying cloud operation already in process, skip until operation is completed
@@ -532,7 +537,7 @@ func updateRole(azVolumeAttachment *azdiskv1beta2.AzVolumeAttachment, role azdis
 	azVolumeAttachment.Status.Detail.PreviousRole = azVolumeAttachment.Status.Detail.Role
 	azVolumeAttachment.Status.Detail.Role = role
 
-	return azVolumeAttachment
+	return updateAzVolumeAttachmentConditions(azVolumeAttachment)
 }
 
 func updateStatusDetail(azVolumeAttachment *azdiskv1beta2.AzVolumeAttachment, status map[string]string) *azdiskv1beta2.AzVolumeAttachment {
@@ -548,7 +553,7 @@ func updateStatusDetail(azVolumeAttachment *azdiskv1beta2.AzVolumeAttachment, st
 	azVolumeAttachment.Status.Detail.Role = azVolumeAttachment.Spec.RequestedRole
 	azVolumeAttachment.Status.Detail.PublishContext = status
 
-	return azVolumeAttachment
+	return updateAzVolumeAttachmentConditions(azVolumeAttachment)
 }
 
 func updateError(azVolumeAttachment *azdiskv1beta2.AzVolumeAttachment, err error) *azdiskv1beta2.AzVolumeAttachment {
@@ -575,6 +580,7 @@ func updateState(azVolumeAttachment *azdiskv1beta2.AzVolumeAttachment, state azd
 	}
 	if err == nil {
 		azVolumeAttachment.Status.State = state
+		azVolumeAttachment = updateAzVolumeAttachmentConditions(azVolumeAttachment)
 	}
 	return azVolumeAttachment, err
 }
@@ -651,6 +657,12 @@ func (r *ReconcileAttachDetach) recreateAzVolumeAttachment(ctx context.Context,
 			azVolumeAttachmentName := azureutils.GetAzVolumeAttachmentName(diskName, nodeName)
 			r.azVolumeAttachmentToVaMap.Store(azVolumeAttachmentName, volumeAttachment.Name)
 
+			// the AzVolumeAttachment is recreated by the owner of the volume's shard
+			if !r.ownsInScope(ctx, volumeName) {
+				syncedVolumeAttachments[volumeAttachment.Name] = true
+				continue
+			}
+
 			desiredAzVolumeAttachment := &azdiskv1beta2.AzVolumeAttachment{
 				ObjectMeta: metav1.ObjectMeta{
 					Name: azVolumeAttachmentName,
@@ -746,8 +758,8 @@ func (r *ReconcileAttachDetach) recoverAzVolumeAttachment(ctx context.Context, r
 	numRecovered := int32(0)
 
 	for _, azVolumeAttachment := range azVolumeAttachments.Items {
-		// skip if AzVolumeAttachment already recovered
-		if _, ok := recoveredAzVolumeAttachments.Load(azVolumeAttachment.Name); ok {
+		// skip if AzVolumeAttachment already recovered or is recovered by the owner of its volume's shard
+		if _, ok := recoveredAzVolumeAttachments.Load(azVolumeAttachment.Name); ok || !r.ownsInScope(ctx, azVolumeAttachment.Spec.VolumeName) {
 			numRecovered++
 			continue
 		}
@@ -835,7 +847,7 @@ func NewAttachDetachController(mgr manager.Manager, cloudDiskAttacher CloudDiskA
 	c.GetLogger().Info("Starting to watch AzVolumeAttachments.")
 
 	// Watch for CRUD events on azVolumeAttachment objects
-	err = c.Watch(&source.Kind{Type: &azdiskv1beta2.AzVolumeAttachment{}}, &handler.EnqueueRequestForObject{})
+	err = c.Watch(&source.Kind{Type: &azdiskv1beta2.AzVolumeAttachment{}}, &handler.EnqueueRequestForObject{}, controllerSharedState.shardPredicate(getAzVolumeAttachmentShardKey))
 	if err != nil {
 		c.GetLogger().Error(err, "failed to initialize watch for AzVolumeAttachment CRI")
 		return nil, err
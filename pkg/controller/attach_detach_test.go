@@ -18,6 +18,7 @@ package controller
 
 import (
 	"context"
+	"strconv"
 	"sync"
 	"testing"
 
@@ -34,6 +35,7 @@ import (
 	azdiskfakes "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/client/clientset/versioned/fake"
 	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
 	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils/fakecompute"
 	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils/mockclient"
 	"sigs.k8s.io/azuredisk-csi-driver/pkg/controller/mockattachmentprovisioner"
 	"sigs.k8s.io/azuredisk-csi-driver/pkg/provisioner"
@@ -439,3 +441,93 @@ func TestAttachDetachRecover(t *testing.T) {
 		})
 	}
 }
+
+func TestAzVolumeCreateAndAttachWithFakeComputeBackend(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	backend := fakecompute.NewBackend(testSubscription, "westus")
+	cloudProvisioner, err := provisioner.NewFakeCloudProvisionerWithBackend(mockCtl, backend)
+	require.NoError(t, err)
+	cloudProvisioner.GetCloud().SubscriptionID = testSubscription
+	cloudProvisioner.GetCloud().ResourceGroup = testResourceGroup
+	backend.AddVirtualMachine(testResourceGroup, testNode0Name, "Standard_D2s_v3")
+
+	azVolume := testAzVolume0.DeepCopy()
+	azVolume.Status.State = azdiskv1beta2.VolumeOperationPending
+	newVolumeAttachment := testVolumeAttachment.DeepCopy()
+
+	sharedState := NewTestSharedState(mockCtl, testNamespace, azVolume, newVolumeAttachment)
+	mockClients(sharedState.cachedClient.(*mockclient.MockClient), sharedState.azClient, sharedState.kubeClient)
+
+	azVolumeController := &ReconcileAzVolume{
+		volumeProvisioner: cloudProvisioner,
+		stateLock:         &sync.Map{},
+		retryInfo:         newRetryInfo(),
+		SharedState:       sharedState,
+		logger:            klogr.New(),
+	}
+	attachDetachController := &ReconcileAttachDetach{
+		cloudDiskAttacher: cloudProvisioner,
+		stateLock:         &sync.Map{},
+		retryInfo:         newRetryInfo(),
+		SharedState:       sharedState,
+		logger:            klogr.New(),
+	}
+
+	// the AzVolume controller creates the disk in the backend
+	result, err := azVolumeController.Reconcile(context.TODO(), testAzVolume0Request)
+	require.NoError(t, err)
+	require.False(t, result.Requeue)
+
+	var volumeID string
+	conditionError := wait.PollImmediate(verifyCRIInterval, verifyCRITimeout, func() (bool, error) {
+		azVolume, localError := sharedState.azClient.DiskV1beta2().AzVolumes(testNamespace).Get(context.TODO(), testAzVolume0.Name, metav1.GetOptions{})
+		if localError != nil || azVolume.Status.State != azdiskv1beta2.VolumeCreated {
+			return false, nil
+		}
+		volumeID = azVolume.Status.Detail.VolumeID
+		return true, nil
+	})
+	require.NoError(t, conditionError)
+	_, found := backend.GetDisk(volumeID)
+	require.True(t, found)
+
+	// the attach/detach controller attaches the created disk to the node's VM in the backend
+	newAttachment := testPrimaryAzVolumeAttachment0.DeepCopy()
+	newAttachment.Spec.VolumeID = volumeID
+	newAttachment.Status.State = azdiskv1beta2.AttachmentPending
+	newAttachment.Status.Annotations = azureutils.AddToMap(newAttachment.Status.Annotations, consts.VolumeAttachmentKey, testVolumeAttachmentName)
+	_, err = sharedState.azClient.DiskV1beta2().AzVolumeAttachments(testNamespace).Create(context.TODO(), newAttachment, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	attachDetachController.azVolumeAttachmentToVaMap.Store(newAttachment.Name, newVolumeAttachment.Name)
+	addTestNodeInAvailableAttachmentsMap(sharedState, testNode0Name, testNodeAvailableAttachmentCount)
+
+	result, err = attachDetachController.Reconcile(context.TODO(), testPrimaryAzVolumeAttachment0Request)
+	require.NoError(t, err)
+	require.False(t, result.Requeue)
+
+	conditionError = wait.PollImmediate(verifyCRIInterval, verifyCRITimeout, func() (bool, error) {
+		azVolumeAttachment, localError := sharedState.azClient.DiskV1beta2().AzVolumeAttachments(testNamespace).Get(context.TODO(), newAttachment.Name, metav1.GetOptions{})
+		if localError != nil {
+			return false, nil
+		}
+		return azVolumeAttachment.Status.State == azdiskv1beta2.Attached, nil
+	})
+	require.NoError(t, conditionError)
+
+	dataDisks, _ := backend.GetDataDisks(testNode0Name)
+	require.Len(t, dataDisks, 1)
+	require.Equal(t, volumeID, *dataDisks[0].ManagedDisk.ID)
+
+	// the LUN of the attached disk is passed back to the VolumeAttachment
+	conditionError = wait.PollImmediate(verifyCRIInterval, verifyCRITimeout, func() (bool, error) {
+		volumeAttachment, localError := sharedState.kubeClient.StorageV1().VolumeAttachments().Get(context.TODO(), testVolumeAttachmentName, metav1.GetOptions{})
+		if localError != nil {
+			return false, nil
+		}
+		return volumeAttachment.Status.AttachmentMetadata[consts.LUN] == strconv.Itoa(int(*dataDisks[0].Lun)), nil
+	})
+	require.NoError(t, conditionError)
+}
@@ -0,0 +1,541 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	azdiskv1beta2 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils"
+	util "sigs.k8s.io/azuredisk-csi-driver/pkg/util"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/workflow"
+
+	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// snapshotReadinessPollInterval is the interval at which a created but not yet ready snapshot is refreshed.
+const snapshotReadinessPollInterval = time.Duration(10) * time.Second
+
+// SnapshotProvisioner defines the subset of Cloud Provisioner functions used by the AzSnapshot controller.
+type SnapshotProvisioner interface {
+	CreateSnapshot(ctx context.Context, sourceVolumeID string, snapshotName string, secrets map[string]string, parameters map[string]string) (*azdiskv1beta2.Snapshot, error)
+	ListSnapshots(ctx context.Context, maxEntries int32, startingToken string, sourceVolumeID string, snapshotID string, secrets map[string]string) (*azdiskv1beta2.ListSnapshotsResult, error)
+	DeleteSnapshot(ctx context.Context, snapshotID string, secrets map[string]string) error
+}
+
+// Struct for the reconciler
+type ReconcileAzSnapshot struct {
+	*SharedState
+	logger              logr.Logger
+	snapshotProvisioner SnapshotProvisioner
+	// stateLock prevents concurrent cloud operation for same snapshot to be executed due to state update race
+	stateLock *sync.Map
+	retryInfo *retryInfo
+}
+
+// Implement reconcile.Reconciler so the controller can reconcile objects
+var _ reconcile.Reconciler = &ReconcileAzSnapshot{}
+
+var allowedTargetSnapshotStates = map[string][]string{
+	"": {string(azdiskv1beta2.SnapshotOperationPending), string(azdiskv1beta2.SnapshotCreating), string(azdiskv1beta2.SnapshotDeleting)},
+	string(azdiskv1beta2.SnapshotOperationPending): {string(azdiskv1beta2.SnapshotCreating), string(azdiskv1beta2.SnapshotDeleting)},
+	string(azdiskv1beta2.SnapshotCreating):         {string(azdiskv1beta2.SnapshotCreated), string(azdiskv1beta2.SnapshotCreationFailed)},
+	string(azdiskv1beta2.SnapshotDeleting):         {string(azdiskv1beta2.SnapshotDeleted), string(azdiskv1beta2.SnapshotDeletionFailed)},
+	string(azdiskv1beta2.SnapshotCreated):          {string(azdiskv1beta2.SnapshotDeleting)},
+	string(azdiskv1beta2.SnapshotDeleted):          {},
+	string(azdiskv1beta2.SnapshotCreationFailed):   {},
+	string(azdiskv1beta2.SnapshotDeletionFailed):   {},
+}
+
+func (r *ReconcileAzSnapshot) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	if !r.isRecoveryComplete() {
+		return reconcile.Result{Requeue: true}, nil
+	}
+
+	azSnapshot, err := azureutils.GetAzSnapshot(ctx, r.cachedClient, r.azClient, request.Name, request.Namespace, true)
+	if err != nil {
+		// if AzSnapshot has been deleted, return success
+		if errors.IsNotFound(err) {
+			return reconcileReturnOnSuccess(request.Name, r.retryInfo)
+		}
+
+		// if the GET failure is triggered by other errors, requeue the request
+		azSnapshot.Name = request.Name
+		return reconcileReturnOnError(ctx, azSnapshot, "get", err, r.retryInfo)
+	}
+
+	// skip requests queued before the shard of the snapshot was handed off to another controller
+	if !r.shardManager.Owns(azSnapshot.Name) {
+		return reconcileReturnOnSuccess(azSnapshot.Name, r.retryInfo)
+	}
+
+	ctx, _ = workflow.GetWorkflowFromObj(ctx, azSnapshot)
+
+	// if underlying cloud operation already in process, skip until operation is completed
+	if isOperationInProcess(azSnapshot) {
+		return reconcileReturnOnSuccess(azSnapshot.Name, r.retryInfo)
+	}
+
+	// azSnapshot deletion
+	if deleteRequested, deleteAfter := objectDeletionRequested(azSnapshot); deleteRequested {
+		if deleteAfter > 0 {
+			return reconcileAfter(deleteAfter, request.Name, r.retryInfo)
+		}
+		if err := r.triggerDelete(ctx, azSnapshot); err != nil {
+			//If delete failed, requeue request
+			return reconcileReturnOnError(ctx, azSnapshot, "delete", err, r.retryInfo)
+		}
+		//azSnapshot creation
+	} else if azSnapshot.Status.Detail == nil {
+		if err := r.triggerCreate(ctx, azSnapshot); err != nil {
+			return reconcileReturnOnError(ctx, azSnapshot, "create", err, r.retryInfo)
+		}
+		// azSnapshot readiness refresh
+	} else if !azSnapshot.Status.Detail.ReadyToUse {
+		if err := r.triggerRefresh(ctx, azSnapshot); err != nil {
+			return reconcileReturnOnError(ctx, azSnapshot, "refresh", err, r.retryInfo)
+		}
+		return reconcileAfter(snapshotReadinessPollInterval, azSnapshot.Name, r.retryInfo)
+	}
+
+	return reconcileReturnOnSuccess(azSnapshot.Name, r.retryInfo)
+}
+
+func (r *ReconcileAzSnapshot) triggerCreate(ctx context.Context, azSnapshot *azdiskv1beta2.AzSnapshot) error {
+	var err error
+	ctx, w := workflow.New(ctx)
+	defer func() { w.Finish(err) }()
+
+	// requeue if AzSnapshot's state is being updated by a different worker
+	if _, ok := r.stateLock.LoadOrStore(azSnapshot.Name, nil); ok {
+		err = getOperationRequeueError("create", azSnapshot)
+		return err
+	}
+	defer r.stateLock.Delete(azSnapshot.Name)
+
+	// update state
+	updateFunc := func(obj client.Object) error {
+		azs := obj.(*azdiskv1beta2.AzSnapshot)
+		_, err := r.updateState(azs, azdiskv1beta2.SnapshotCreating, normalUpdate)
+		return err
+	}
+
+	var updatedObj client.Object
+	if updatedObj, err = azureutils.UpdateCRIWithRetry(ctx, nil, r.cachedClient, r.azClient, azSnapshot, updateFunc, consts.NormalUpdateMaxNetRetry, azureutils.UpdateCRIStatus); err != nil {
+		return err
+	}
+	azSnapshot = updatedObj.(*azdiskv1beta2.AzSnapshot)
+
+	w.Logger().V(5).Info("Creating Snapshot...")
+	waitCh := make(chan goSignal)
+	// create snapshot
+	//nolint:contextcheck // call is asynchronous; context is not inherited by design
+	go func() {
+		var createErr error
+		_, goWorkflow := workflow.New(ctx)
+		defer func() { goWorkflow.Finish(createErr) }()
+		waitCh <- goSignal{}
+
+		goCtx := goWorkflow.SaveToContext(context.Background())
+		cloudCtx, cloudCancel := context.WithTimeout(goCtx, cloudTimeout)
+		defer cloudCancel()
+
+		var updateFunc azureutils.UpdateCRIFunc
+		var response *azdiskv1beta2.Snapshot
+		response, createErr = r.createSnapshot(cloudCtx, azSnapshot)
+		updateMode := azureutils.UpdateCRIStatus
+		if createErr != nil {
+			updateFunc = func(obj client.Object) error {
+				azs := obj.(*azdiskv1beta2.AzSnapshot)
+				azs = r.deleteFinalizer(azs, map[string]bool{consts.AzSnapshotFinalizer: true})
+				_, derr := r.reportError(azs, azdiskv1beta2.SnapshotCreationFailed, createErr)
+				return derr
+			}
+			updateMode = azureutils.UpdateAll
+		} else {
+			updateFunc = func(obj client.Object) error {
+				azs := obj.(*azdiskv1beta2.AzSnapshot)
+				if response == nil {
+					return status.Errorf(codes.Internal, "non-nil Snapshot expected but nil given")
+				}
+				azs = r.updateStatusDetail(azs, response)
+				_, derr := r.updateState(azs, azdiskv1beta2.SnapshotCreated, forceUpdate)
+				return derr
+			}
+		}
+
+		//nolint:contextcheck // final status update of the CRI must occur even when the current context's deadline passes.
+		_, _ = azureutils.UpdateCRIWithRetry(goCtx, nil, r.cachedClient, r.azClient, azSnapshot, updateFunc, consts.ForcedUpdateMaxNetRetry, updateMode)
+	}()
+
+	// wait for the workflow in goroutine to be created
+	<-waitCh
+	return nil
+}
+
+func (r *ReconcileAzSnapshot) triggerDelete(ctx context.Context, azSnapshot *azdiskv1beta2.AzSnapshot) error {
+	var err error
+	ctx, w := workflow.New(ctx)
+	defer func() { w.Finish(err) }()
+
+	// requeue if AzSnapshot's state is being updated by a different worker
+	if _, ok := r.stateLock.LoadOrStore(azSnapshot.Name, nil); ok {
+		err = getOperationRequeueError("delete", azSnapshot)
+		return err
+	}
+	defer r.stateLock.Delete(azSnapshot.Name)
+
+	updateFunc := func(obj client.Object) error {
+		azs := obj.(*azdiskv1beta2.AzSnapshot)
+		_, derr := r.updateState(azs, azdiskv1beta2.SnapshotDeleting, normalUpdate)
+		return derr
+	}
+	var updatedObj client.Object
+	if updatedObj, err = azureutils.UpdateCRIWithRetry(ctx, nil, r.cachedClient, r.azClient, azSnapshot, updateFunc, consts.NormalUpdateMaxNetRetry, azureutils.UpdateCRIStatus); err != nil {
+		return err
+	}
+	azSnapshot = updatedObj.(*azdiskv1beta2.AzSnapshot)
+
+	w.Logger().V(5).Info("Deleting Snapshot...")
+	waitCh := make(chan goSignal)
+	//nolint:contextcheck // call is asynchronous; context is not inherited by design
+	go func() {
+		var deleteErr error
+		_, goWorkflow := workflow.New(ctx)
+		defer func() { goWorkflow.Finish(deleteErr) }()
+		waitCh <- goSignal{}
+
+		goCtx := goWorkflow.SaveToContext(context.Background())
+
+		var updateFunc azureutils.UpdateCRIFunc
+		updateMode := azureutils.UpdateCRI
+
+		if r.driverLifecycle.IsDriverUninstall() {
+			goWorkflow.Logger().V(12).Infof("trying to delete snapshot (%s) as the driver is uninstalling, deletion will be ignored", azSnapshot.Name)
+		} else {
+			cloudCtx, cloudCancel := context.WithTimeout(goCtx, cloudTimeout)
+			defer cloudCancel()
+
+			deleteErr = r.deleteSnapshot(cloudCtx, azSnapshot)
+		}
+
+		if deleteErr != nil {
+			updateMode = azureutils.UpdateCRIStatus
+			updateFunc = func(obj client.Object) error {
+				azs := obj.(*azdiskv1beta2.AzSnapshot)
+				_, derr := r.reportError(azs, azdiskv1beta2.SnapshotDeletionFailed, deleteErr)
+				return derr
+			}
+		} else {
+			// the secrets are no longer needed once the AzSnapshot is gone
+			if derr := azureutils.DeleteAzSnapshotSecrets(goCtx, r.kubeClient, azSnapshot); derr != nil {
+				goWorkflow.Logger().Errorf(derr, "failed to delete secrets for AzSnapshot (%s)", azSnapshot.Name)
+			}
+			// if the snapshot was deleted, delete the finalizer
+			updateFunc = func(obj client.Object) error {
+				azs := obj.(*azdiskv1beta2.AzSnapshot)
+				_ = r.deleteFinalizer(azs, map[string]bool{consts.AzSnapshotFinalizer: true})
+				return nil
+			}
+		}
+
+		//nolint:contextcheck // final status update of the CRI must occur even when the current context's deadline passes.
+		_, _ = azureutils.UpdateCRIWithRetry(goCtx, nil, r.cachedClient, r.azClient, azSnapshot, updateFunc, consts.ForcedUpdateMaxNetRetry, updateMode)
+	}()
+	<-waitCh
+	return nil
+}
+
+func (r *ReconcileAzSnapshot) triggerRefresh(ctx context.Context, azSnapshot *azdiskv1beta2.AzSnapshot) error {
+	var err error
+	ctx, w := workflow.New(ctx)
+	defer func() { w.Finish(err) }()
+
+	// requeue if AzSnapshot is being refreshed or its state is being updated by a different worker
+	if _, ok := r.stateLock.LoadOrStore(azSnapshot.Name, nil); ok {
+		err = getOperationRequeueError("refresh", azSnapshot)
+		return err
+	}
+
+	w.Logger().V(5).Info("Refreshing Snapshot...")
+	waitCh := make(chan goSignal)
+	//nolint:contextcheck // call is asynchronous; context is not inherited by design
+	go func() {
+		// the state is not changed by a refresh, so the lock is held until the refresh completes
+		defer r.stateLock.Delete(azSnapshot.Name)
+
+		var refreshErr error
+		_, goWorkflow := workflow.New(ctx)
+		defer func() { goWorkflow.Finish(refreshErr) }()
+		waitCh <- goSignal{}
+
+		goCtx := goWorkflow.SaveToContext(context.Background())
+		cloudCtx, cloudCancel := context.WithTimeout(goCtx, cloudTimeout)
+		defer cloudCancel()
+
+		var response *azdiskv1beta2.Snapshot
+		if response, refreshErr = r.getSnapshot(cloudCtx, azSnapshot); refreshErr != nil || response == nil {
+			return
+		}
+
+		updateFunc := func(obj client.Object) error {
+			azs := obj.(*azdiskv1beta2.AzSnapshot)
+			_ = r.updateStatusDetail(azs, response)
+			return nil
+		}
+
+		//nolint:contextcheck // final status update of the CRI must occur even when the current context's deadline passes.
+		_, refreshErr = azureutils.UpdateCRIWithRetry(goCtx, nil, r.cachedClient, r.azClient, azSnapshot, updateFunc, consts.NormalUpdateMaxNetRetry, azureutils.UpdateCRIStatus)
+	}()
+	<-waitCh
+	return nil
+}
+
+func (r *ReconcileAzSnapshot) deleteFinalizer(azSnapshot *azdiskv1beta2.AzSnapshot, finalizersToDelete map[string]bool) *azdiskv1beta2.AzSnapshot {
+	if azSnapshot == nil {
+		return nil
+	}
+
+	if azSnapshot.ObjectMeta.Finalizers == nil {
+		return azSnapshot
+	}
+
+	finalizers := []string{}
+	for _, finalizer := range azSnapshot.ObjectMeta.Finalizers {
+		if exists := finalizersToDelete[finalizer]; exists {
+			continue
+		}
+		finalizers = append(finalizers, finalizer)
+	}
+	azSnapshot.ObjectMeta.Finalizers = finalizers
+	return azSnapshot
+}
+
+func (r *ReconcileAzSnapshot) reportError(azSnapshot *azdiskv1beta2.AzSnapshot, state azdiskv1beta2.AzSnapshotState, err error) (*azdiskv1beta2.AzSnapshot, error) {
+	if azSnapshot == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "function `reportError` requires non-nil AzSnapshot object.")
+	}
+	azSnapshot = r.updateError(azSnapshot, err)
+	return r.updateState(azSnapshot, state, forceUpdate)
+}
+
+func (r *ReconcileAzSnapshot) updateState(azSnapshot *azdiskv1beta2.AzSnapshot, state azdiskv1beta2.AzSnapshotState, mode updateMode) (*azdiskv1beta2.AzSnapshot, error) {
+	var err error
+	if azSnapshot == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "function `updateState` requires non-nil AzSnapshot object.")
+	}
+	if mode == normalUpdate {
+		if expectedStates, exists := allowedTargetSnapshotStates[string(azSnapshot.Status.State)]; !exists || !containsString(string(state), expectedStates) {
+			err = status.Error(codes.FailedPrecondition, formatUpdateStateError("azSnapshot", string(azSnapshot.Status.State), string(state), expectedStates...))
+		}
+	}
+	if err == nil {
+		azSnapshot.Status.State = state
+	}
+	return azSnapshot, err
+}
+
+func (r *ReconcileAzSnapshot) updateStatusDetail(azSnapshot *azdiskv1beta2.AzSnapshot, detail *azdiskv1beta2.Snapshot) *azdiskv1beta2.AzSnapshot {
+	if azSnapshot == nil {
+		return nil
+	}
+	azSnapshot.Status.Detail = detail.DeepCopy()
+	return azSnapshot
+}
+
+func (r *ReconcileAzSnapshot) updateError(azSnapshot *azdiskv1beta2.AzSnapshot, err error) *azdiskv1beta2.AzSnapshot {
+	if azSnapshot == nil {
+		return nil
+	}
+	azSnapshot.Status.Error = util.NewAzError(err)
+	return azSnapshot
+}
+
+func (r *ReconcileAzSnapshot) createSnapshot(ctx context.Context, azSnapshot *azdiskv1beta2.AzSnapshot) (*azdiskv1beta2.Snapshot, error) {
+	if azSnapshot.Status.Detail != nil {
+		return azSnapshot.Status.Detail, nil
+	}
+	// use deep-copied version of the azSnapshot CRI to prevent any unwanted update to the object
+	copied := azSnapshot.DeepCopy()
+	// the Secret is stored before the AzSnapshot is created, so the AzSnapshot becomes its owner only now
+	if err := azureutils.SetSecretsOwner(ctx, r.kubeClient, copied, copied.Spec.SecretRef); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to set owner of secrets for AzSnapshot (%s): %v", azSnapshot.Name, err)
+	}
+	secrets, err := azureutils.GetAzSnapshotSecrets(ctx, r.kubeClient, copied)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get secrets for AzSnapshot (%s): %v", azSnapshot.Name, err)
+	}
+	return r.snapshotProvisioner.CreateSnapshot(ctx, copied.Spec.SourceVolumeID, copied.Spec.SnapshotName, secrets, copied.Spec.Parameters)
+}
+
+func (r *ReconcileAzSnapshot) getSnapshot(ctx context.Context, azSnapshot *azdiskv1beta2.AzSnapshot) (*azdiskv1beta2.Snapshot, error) {
+	if azSnapshot.Status.Detail == nil {
+		return nil, status.Errorf(codes.Internal, "Snapshot for refresh does not exist for AzSnapshot (%s).", azSnapshot.Name)
+	}
+	// use deep-copied version of the azSnapshot CRI to prevent any unwanted update to the object
+	copied := azSnapshot.DeepCopy()
+	secrets, err := azureutils.GetAzSnapshotSecrets(ctx, r.kubeClient, copied)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get secrets for AzSnapshot (%s): %v", azSnapshot.Name, err)
+	}
+	result, err := r.snapshotProvisioner.ListSnapshots(ctx, 0, "", copied.Spec.SourceVolumeID, copied.Status.Detail.SnapshotID, secrets)
+	if err != nil || result == nil || len(result.Entries) == 0 {
+		return nil, err
+	}
+	return &result.Entries[0], nil
+}
+
+func (r *ReconcileAzSnapshot) deleteSnapshot(ctx context.Context, azSnapshot *azdiskv1beta2.AzSnapshot) error {
+	if azSnapshot.Status.Detail == nil {
+		return nil
+	}
+	// use deep-copied version of the azSnapshot CRI to prevent any unwanted update to the object
+	copied := azSnapshot.DeepCopy()
+	secrets, err := azureutils.GetAzSnapshotSecrets(ctx, r.kubeClient, copied)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to get secrets for AzSnapshot (%s): %v", azSnapshot.Name, err)
+	}
+	return r.snapshotProvisioner.DeleteSnapshot(ctx, copied.Status.Detail.SnapshotID, secrets)
+}
+
+func (r *ReconcileAzSnapshot) recoverAzSnapshot(ctx context.Context, recoveredAzSnapshots *sync.Map, recoveryID string) error {
+	w, _ := workflow.GetWorkflowFromContext(ctx)
+	// list all AzSnapshots
+	azSnapshots, err := r.azClient.DiskV1beta2().AzSnapshots(r.config.ObjectNamespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		w.Logger().Error(err, "failed to get list of existing AzSnapshot CRI in controller recovery stage")
+		return err
+	}
+
+	var wg sync.WaitGroup
+	numRecovered := int32(0)
+
+	for _, azSnapshot := range azSnapshots.Items {
+		// skip if AzSnapshot already recovered or is recovered by the owner of its shard
+		if _, ok := recoveredAzSnapshots.Load(azSnapshot.Name); ok || !r.ownsInScope(ctx, azSnapshot.Name) {
+			numRecovered++
+			continue
+		}
+
+		wg.Add(1)
+		go func(azs azdiskv1beta2.AzSnapshot, azsMap *sync.Map) {
+			defer wg.Done()
+			var targetState azdiskv1beta2.AzSnapshotState
+			updateFunc := func(obj client.Object) error {
+				var err error
+				azs := obj.(*azdiskv1beta2.AzSnapshot)
+				// add a recover annotation to the CRI so that reconciliation can be triggered for the CRI even if CRI's current state == target state
+				azs.Status.Annotations = azureutils.AddToMap(azs.Status.Annotations, consts.RecoverAnnotation, recoveryID)
+				if azs.Status.State != targetState {
+					_, err = r.updateState(azs, targetState, forceUpdate)
+				}
+				return err
+			}
+			switch azs.Status.State {
+			case azdiskv1beta2.SnapshotCreating:
+				// reset state to Pending so Create operation can be redone
+				targetState = azdiskv1beta2.SnapshotOperationPending
+			case azdiskv1beta2.SnapshotDeleting:
+				// reset state to Created so Delete operation can be redone
+				targetState = azdiskv1beta2.SnapshotCreated
+			default:
+				targetState = azs.Status.State
+			}
+
+			if _, err := azureutils.UpdateCRIWithRetry(ctx, nil, r.cachedClient, r.azClient, &azs, updateFunc, consts.ForcedUpdateMaxNetRetry, azureutils.UpdateCRIStatus); err != nil {
+				w.Logger().Errorf(err, "failed to update AzSnapshot (%s) for recovery", azs.Name)
+			} else {
+				// if update succeeded, add the CRI to the recoveryComplete list
+				azsMap.Store(azs.Name, struct{}{})
+				atomic.AddInt32(&numRecovered, 1)
+			}
+		}(azSnapshot, recoveredAzSnapshots)
+	}
+	wg.Wait()
+
+	// if recovery has not been completed for all CRIs, return error
+	if numRecovered < int32(len(azSnapshots.Items)) {
+		return status.Errorf(codes.Internal, "failed to recover some AzSnapshot states")
+	}
+	return nil
+}
+
+func (r *ReconcileAzSnapshot) Recover(ctx context.Context, recoveryID string) error {
+	var err error
+	ctx, w := workflow.New(ctx)
+	defer func() { w.Finish(err) }()
+
+	w.Logger().V(5).Info("Recovering AzSnapshot CRIs...")
+	recovered := &sync.Map{}
+	for i := 0; i < maxRetry; i++ {
+		if err = r.recoverAzSnapshot(ctx, recovered, recoveryID); err == nil {
+			break
+		}
+		w.Logger().Error(err, "failed to recover AzSnapshot state")
+	}
+	return err
+}
+
+func NewAzSnapshotController(mgr manager.Manager, snapshotProvisioner SnapshotProvisioner, controllerSharedState *SharedState) (*ReconcileAzSnapshot, error) {
+	logger := mgr.GetLogger().WithValues("controller", "azsnapshot")
+
+	reconciler := ReconcileAzSnapshot{
+		snapshotProvisioner: snapshotProvisioner,
+		stateLock:           &sync.Map{},
+		retryInfo:           newRetryInfo(),
+		SharedState:         controllerSharedState,
+		logger:              logger,
+	}
+
+	c, err := controller.New("azsnapshot-controller", mgr, controller.Options{
+		MaxConcurrentReconciles: controllerSharedState.config.ControllerConfig.WorkerThreads,
+		Reconciler:              &reconciler,
+		LogConstructor:          func(req *reconcile.Request) logr.Logger { return logger },
+	})
+
+	if err != nil {
+		logger.Error(err, "failed to create controller")
+		return nil, err
+	}
+
+	logger.V(2).Info("Starting to watch AzSnapshot.")
+
+	// Watch for CRUD events on azSnapshot objects
+	err = c.Watch(&source.Kind{Type: &azdiskv1beta2.AzSnapshot{}}, &handler.EnqueueRequestForObject{}, controllerSharedState.shardPredicate(getObjectNameShardKey))
+	if err != nil {
+		logger.Error(err, "failed to initialize watch for AzSnapshot CRI")
+		return nil, err
+	}
+
+	logger.V(2).Info("Controller set-up successful.")
+
+	return &reconciler, nil
+}
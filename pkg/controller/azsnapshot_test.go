@@ -0,0 +1,337 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	fakev1 "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/klog/v2/klogr"
+	azdiskv1beta2 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
+	azdiskfakes "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/client/clientset/versioned/fake"
+	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils/mockclient"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/controller/mocksnapshotprovisioner"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	testSnapshot0Name = "test-snapshot-0"
+)
+
+var (
+	testSnapshot0URI = fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Compute/snapshots/%s", testSubscription, testResourceGroup, testSnapshot0Name)
+
+	testAzSnapshot0 = createTestAzSnapshot(testSnapshot0Name, testManagedDiskURI0)
+
+	testAzSnapshot0Request = createReconcileRequest(testNamespace, testSnapshot0Name)
+)
+
+func createTestAzSnapshot(snapshotName, sourceVolumeID string) azdiskv1beta2.AzSnapshot {
+	azSnapshot := azdiskv1beta2.AzSnapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      snapshotName,
+			Namespace: testNamespace,
+		},
+		Spec: azdiskv1beta2.AzSnapshotSpec{
+			SnapshotName:   snapshotName,
+			SourceVolumeID: sourceVolumeID,
+		},
+	}
+	azureutils.AnnotateAPIVersion(&azSnapshot)
+	return azSnapshot
+}
+
+func NewTestAzSnapshotController(controller *gomock.Controller, namespace string, objects ...runtime.Object) *ReconcileAzSnapshot {
+	azDiskObjs, kubeObjs := splitObjects(objects...)
+	controllerSharedState := initState(mockclient.NewMockClient(controller), azdiskfakes.NewSimpleClientset(azDiskObjs...), fakev1.NewSimpleClientset(kubeObjs...), objects...)
+
+	return &ReconcileAzSnapshot{
+		snapshotProvisioner: mocksnapshotprovisioner.NewMockSnapshotProvisioner(controller),
+		stateLock:           &sync.Map{},
+		retryInfo:           newRetryInfo(),
+		SharedState:         controllerSharedState,
+		logger:              klogr.New(),
+	}
+}
+
+func mockClientsAndSnapshotProvisioner(controller *ReconcileAzSnapshot, readyToUse bool) {
+	mockClients(controller.cachedClient.(*mockclient.MockClient), controller.azClient, controller.kubeClient)
+
+	snapshot := azdiskv1beta2.Snapshot{
+		SnapshotID:     testSnapshot0URI,
+		SourceVolumeID: testManagedDiskURI0,
+		SizeBytes:      10,
+		CreationTime:   metav1.Now(),
+		ReadyToUse:     readyToUse,
+	}
+
+	controller.snapshotProvisioner.(*mocksnapshotprovisioner.MockSnapshotProvisioner).EXPECT().
+		CreateSnapshot(gomock.Any(), testManagedDiskURI0, testSnapshot0Name, gomock.Any(), gomock.Any()).
+		Return(snapshot.DeepCopy(), nil).
+		MaxTimes(1)
+	controller.snapshotProvisioner.(*mocksnapshotprovisioner.MockSnapshotProvisioner).EXPECT().
+		ListSnapshots(gomock.Any(), gomock.Any(), gomock.Any(), testManagedDiskURI0, testSnapshot0URI, gomock.Any()).
+		DoAndReturn(func(
+			ctx context.Context,
+			maxEntries int32,
+			startingToken string,
+			sourceVolumeID string,
+			snapshotID string,
+			secrets map[string]string) (*azdiskv1beta2.ListSnapshotsResult, error) {
+			refreshed := snapshot.DeepCopy()
+			refreshed.ReadyToUse = true
+			return &azdiskv1beta2.ListSnapshotsResult{Entries: []azdiskv1beta2.Snapshot{*refreshed}}, nil
+		}).
+		MaxTimes(1)
+	controller.snapshotProvisioner.(*mocksnapshotprovisioner.MockSnapshotProvisioner).EXPECT().
+		DeleteSnapshot(gomock.Any(), testSnapshot0URI, gomock.Any()).
+		Return(nil).
+		MaxTimes(1)
+}
+
+func TestAzSnapshotControllerReconcile(t *testing.T) {
+	tests := []struct {
+		description string
+		request     reconcile.Request
+		setupFunc   func(*testing.T, *gomock.Controller) *ReconcileAzSnapshot
+		verifyFunc  func(*testing.T, *ReconcileAzSnapshot, reconcile.Result, error)
+	}{
+		{
+			description: "[Success] Should succeed if AzSnapshot is not found.",
+			request:     testAzSnapshot0Request,
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *ReconcileAzSnapshot {
+				controller := NewTestAzSnapshotController(
+					mockCtl,
+					testNamespace)
+
+				mockClientsAndSnapshotProvisioner(controller, true)
+
+				return controller
+			},
+			verifyFunc: func(t *testing.T, controller *ReconcileAzSnapshot, result reconcile.Result, err error) {
+				require.NoError(t, err)
+				require.False(t, result.Requeue)
+			},
+		},
+		{
+			description: "[Success] Should create a snapshot when a new AzSnapshot instance is created.",
+			request:     testAzSnapshot0Request,
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *ReconcileAzSnapshot {
+				azSnapshot := testAzSnapshot0.DeepCopy()
+				azSnapshot.Status.State = azdiskv1beta2.SnapshotOperationPending
+
+				controller := NewTestAzSnapshotController(
+					mockCtl,
+					testNamespace,
+					azSnapshot)
+
+				mockClientsAndSnapshotProvisioner(controller, true)
+
+				return controller
+			},
+			verifyFunc: func(t *testing.T, controller *ReconcileAzSnapshot, result reconcile.Result, err error) {
+				require.NoError(t, err)
+				require.False(t, result.Requeue)
+
+				conditionFunc := func() (bool, error) {
+					azSnapshot, localError := controller.azClient.DiskV1beta2().AzSnapshots(testNamespace).Get(context.TODO(), testSnapshot0Name, metav1.GetOptions{})
+					if localError != nil {
+						return false, nil
+					}
+					return azSnapshot.Status.State == azdiskv1beta2.SnapshotCreated && azSnapshot.Status.Detail != nil, nil
+				}
+				conditionError := wait.PollImmediate(verifyCRIInterval, verifyCRITimeout, conditionFunc)
+				require.NoError(t, conditionError)
+			},
+		},
+		{
+			description: "[Failure] Should report an error and remove the finalizer when snapshot creation fails.",
+			request:     testAzSnapshot0Request,
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *ReconcileAzSnapshot {
+				azSnapshot := testAzSnapshot0.DeepCopy()
+				azSnapshot.Finalizers = []string{consts.AzSnapshotFinalizer}
+				azSnapshot.Status.State = azdiskv1beta2.SnapshotOperationPending
+
+				controller := NewTestAzSnapshotController(
+					mockCtl,
+					testNamespace,
+					azSnapshot)
+
+				mockClients(controller.cachedClient.(*mockclient.MockClient), controller.azClient, controller.kubeClient)
+				controller.snapshotProvisioner.(*mocksnapshotprovisioner.MockSnapshotProvisioner).EXPECT().
+					CreateSnapshot(gomock.Any(), testManagedDiskURI0, testSnapshot0Name, gomock.Any(), gomock.Any()).
+					Return(nil, status.Errorf(codes.Internal, "test error")).
+					MaxTimes(1)
+
+				return controller
+			},
+			verifyFunc: func(t *testing.T, controller *ReconcileAzSnapshot, result reconcile.Result, err error) {
+				require.NoError(t, err)
+				require.False(t, result.Requeue)
+
+				conditionFunc := func() (bool, error) {
+					azSnapshot, localError := controller.azClient.DiskV1beta2().AzSnapshots(testNamespace).Get(context.TODO(), testSnapshot0Name, metav1.GetOptions{})
+					if localError != nil {
+						return false, nil
+					}
+					return azSnapshot.Status.State == azdiskv1beta2.SnapshotCreationFailed && azSnapshot.Status.Error != nil && len(azSnapshot.Finalizers) == 0, nil
+				}
+				conditionError := wait.PollImmediate(verifyCRIInterval, verifyCRITimeout, conditionFunc)
+				require.NoError(t, conditionError)
+			},
+		},
+		{
+			description: "[Success] Should refresh a snapshot that is not yet ready to use.",
+			request:     testAzSnapshot0Request,
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *ReconcileAzSnapshot {
+				azSnapshot := testAzSnapshot0.DeepCopy()
+				azSnapshot.Status.State = azdiskv1beta2.SnapshotCreated
+				azSnapshot.Status.Detail = &azdiskv1beta2.Snapshot{
+					SnapshotID:     testSnapshot0URI,
+					SourceVolumeID: testManagedDiskURI0,
+					ReadyToUse:     false,
+				}
+
+				controller := NewTestAzSnapshotController(
+					mockCtl,
+					testNamespace,
+					azSnapshot)
+
+				mockClientsAndSnapshotProvisioner(controller, false)
+
+				return controller
+			},
+			verifyFunc: func(t *testing.T, controller *ReconcileAzSnapshot, result reconcile.Result, err error) {
+				require.NoError(t, err)
+				require.Equal(t, snapshotReadinessPollInterval, result.RequeueAfter)
+
+				conditionFunc := func() (bool, error) {
+					azSnapshot, localError := controller.azClient.DiskV1beta2().AzSnapshots(testNamespace).Get(context.TODO(), testSnapshot0Name, metav1.GetOptions{})
+					if localError != nil {
+						return false, nil
+					}
+					return azSnapshot.Status.Detail.ReadyToUse, nil
+				}
+				conditionError := wait.PollImmediate(verifyCRIInterval, verifyCRITimeout, conditionFunc)
+				require.NoError(t, conditionError)
+			},
+		},
+		{
+			description: "[Success] Should delete a snapshot when a AzSnapshot is marked for deletion.",
+			request:     testAzSnapshot0Request,
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *ReconcileAzSnapshot {
+				azSnapshot := testAzSnapshot0.DeepCopy()
+				azSnapshot.Finalizers = []string{consts.AzSnapshotFinalizer}
+				azSnapshot.Status.State = azdiskv1beta2.SnapshotCreated
+				azSnapshot.Status.Detail = &azdiskv1beta2.Snapshot{
+					SnapshotID:     testSnapshot0URI,
+					SourceVolumeID: testManagedDiskURI0,
+					ReadyToUse:     true,
+				}
+				now := metav1.Time{Time: metav1.Now().Add(-1000)}
+				azSnapshot.ObjectMeta.DeletionTimestamp = &now
+
+				controller := NewTestAzSnapshotController(
+					mockCtl,
+					testNamespace,
+					azSnapshot)
+
+				mockClientsAndSnapshotProvisioner(controller, true)
+
+				return controller
+			},
+			verifyFunc: func(t *testing.T, controller *ReconcileAzSnapshot, result reconcile.Result, err error) {
+				require.NoError(t, err)
+				require.False(t, result.Requeue)
+
+				conditionFunc := func() (bool, error) {
+					azSnapshot, localError := controller.azClient.DiskV1beta2().AzSnapshots(testNamespace).Get(context.TODO(), testSnapshot0Name, metav1.GetOptions{})
+					return len(azSnapshot.Finalizers) == 0, localError
+				}
+				conditionError := wait.PollImmediate(verifyCRIInterval, verifyCRITimeout, conditionFunc)
+				require.NoError(t, conditionError)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		tt := test
+		t.Run(tt.description, func(t *testing.T) {
+			mockCtl := gomock.NewController(t)
+			defer mockCtl.Finish()
+			controller := tt.setupFunc(t, mockCtl)
+			result, err := controller.Reconcile(context.TODO(), tt.request)
+			tt.verifyFunc(t, controller, result, err)
+		})
+	}
+}
+
+func TestAzSnapshotControllerRecover(t *testing.T) {
+	tests := []struct {
+		description string
+		setupFunc   func(*testing.T, *gomock.Controller) *ReconcileAzSnapshot
+		verifyFunc  func(*testing.T, *ReconcileAzSnapshot, error)
+	}{
+		{
+			description: "[Success] Should reset in-progress AzSnapshot states so the operations can be redone.",
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *ReconcileAzSnapshot {
+				azSnapshot := testAzSnapshot0.DeepCopy()
+				azSnapshot.Status.State = azdiskv1beta2.SnapshotCreating
+
+				controller := NewTestAzSnapshotController(
+					mockCtl,
+					testNamespace,
+					azSnapshot)
+
+				mockClientsAndSnapshotProvisioner(controller, true)
+
+				return controller
+			},
+			verifyFunc: func(t *testing.T, controller *ReconcileAzSnapshot, err error) {
+				require.NoError(t, err)
+
+				azSnapshot, localErr := controller.azClient.DiskV1beta2().AzSnapshots(testNamespace).Get(context.TODO(), testSnapshot0Name, metav1.GetOptions{})
+				require.NoError(t, localErr)
+				require.Equal(t, azdiskv1beta2.SnapshotOperationPending, azSnapshot.Status.State)
+				require.Contains(t, azSnapshot.Status.Annotations, consts.RecoverAnnotation)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		tt := test
+		t.Run(tt.description, func(t *testing.T) {
+			mockCtl := gomock.NewController(t)
+			defer mockCtl.Finish()
+			controller := tt.setupFunc(t, mockCtl)
+			err := controller.Recover(context.TODO(), "test-recovery-id")
+			tt.verifyFunc(t, controller, err)
+		})
+	}
+}
@@ -0,0 +1,461 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	snapshotclientset "github.com/kubernetes-csi/external-snapshotter/client/v4/clientset/versioned"
+	snapshotv1client "github.com/kubernetes-csi/external-snapshotter/client/v4/clientset/versioned/typed/volumesnapshot/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	azdiskv1beta2 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
+	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
+	util "sigs.k8s.io/azuredisk-csi-driver/pkg/util"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/workflow"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// policySnapshotTimeFormat is the format of the schedule time appended to the names of the VolumeSnapshots taken by a policy.
+	policySnapshotTimeFormat = "20060102150405"
+	// maxVolumeSnapshotNameLength is the maximum length of the name of a VolumeSnapshot.
+	maxVolumeSnapshotNameLength = validation.DNS1123SubdomainMaxLength
+)
+
+var (
+	snapshotPolicyLastSuccessTime = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Subsystem:      consts.AzureDiskCSIDriverName,
+			Name:           "snapshot_policy_last_success_timestamp_seconds",
+			Help:           "Time at which the snapshot policy last took and pruned snapshots successfully.",
+			StabilityLevel: metrics.ALPHA,
+		}, []string{"namespace", "policy"})
+
+	snapshotPolicyLastFailureTime = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Subsystem:      consts.AzureDiskCSIDriverName,
+			Name:           "snapshot_policy_last_failure_timestamp_seconds",
+			Help:           "Time at which the snapshot policy last failed.",
+			StabilityLevel: metrics.ALPHA,
+		}, []string{"namespace", "policy"})
+
+	snapshotPolicySnapshotsCreated = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      consts.AzureDiskCSIDriverName,
+			Name:           "snapshot_policy_snapshots_created_total",
+			Help:           "Number of VolumeSnapshots created by the snapshot policy.",
+			StabilityLevel: metrics.ALPHA,
+		}, []string{"namespace", "policy"})
+
+	snapshotPolicySnapshotsDeleted = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      consts.AzureDiskCSIDriverName,
+			Name:           "snapshot_policy_snapshots_deleted_total",
+			Help:           "Number of VolumeSnapshots pruned by the snapshot policy.",
+			StabilityLevel: metrics.ALPHA,
+		}, []string{"namespace", "policy"})
+)
+
+func init() {
+	legacyregistry.MustRegister(snapshotPolicyLastSuccessTime, snapshotPolicyLastFailureTime, snapshotPolicySnapshotsCreated, snapshotPolicySnapshotsDeleted)
+}
+
+/*
+AzSnapshotPolicy controller takes snapshots of the PersistentVolumeClaims selected by an AzSnapshotPolicy at every interval of its schedule and prunes the expired ones.
+At each multiple of the schedule interval since the Unix epoch, it
+ 1. creates a VolumeSnapshot labeled with the name of the policy for each bound PersistentVolumeClaim provisioned by the driver,
+    so that the snapshot is taken incrementally through the external snapshotter and the AzSnapshot controller
+ 2. deletes the snapshots of each claim beyond the maximum count or older than the maximum age of the retention,
+    keeping at least the newest snapshot which is ready to use as well as any snapshot taken after it
+ 3. records the result in the status of the policy and in the snapshot policy metrics
+*/
+type ReconcileAzSnapshotPolicy struct {
+	*SharedState
+	logger         logr.Logger
+	snapshotClient snapshotv1client.SnapshotV1Interface
+	retryInfo      *retryInfo
+}
+
+// Implement reconcile.Reconciler so the controller can reconcile objects
+var _ reconcile.Reconciler = &ReconcileAzSnapshotPolicy{}
+
+func (r *ReconcileAzSnapshotPolicy) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	if !r.isRecoveryComplete() {
+		return reconcile.Result{Requeue: true}, nil
+	}
+
+	policy := &azdiskv1beta2.AzSnapshotPolicy{}
+	if err := r.cachedClient.Get(ctx, request.NamespacedName, policy); err != nil {
+		// if AzSnapshotPolicy has been deleted, stop reporting its metrics and return success
+		if errors.IsNotFound(err) {
+			deleteSnapshotPolicyMetrics(request.Namespace, request.Name)
+			return reconcileReturnOnSuccess(request.Name, r.retryInfo)
+		}
+
+		// if the GET failure is triggered by other errors, requeue the request
+		policy.Name = request.Name
+		return reconcileReturnOnError(ctx, policy, "get", err, r.retryInfo)
+	}
+
+	if deleteRequested, _ := objectDeletionRequested(policy); deleteRequested {
+		return reconcileReturnOnSuccess(policy.Name, r.retryInfo)
+	}
+
+	if err := validateSnapshotPolicy(policy); err != nil {
+		_ = r.updateStatus(ctx, policy, nil, err)
+		return reconcileReturnOnError(ctx, policy, "validate", err, r.retryInfo)
+	}
+
+	interval := policy.Spec.Schedule.Duration
+	now := time.Now()
+	scheduleTime := now.Truncate(interval)
+	nextScheduleAfter := scheduleTime.Add(interval).Sub(now)
+
+	// when sharded, snapshot policies are run by the owner of the coordinator shard
+	if !r.isCoordinator() {
+		return reconcileAfter(nextScheduleAfter, policy.Name, r.retryInfo)
+	}
+
+	if err := r.runPolicy(ctx, policy, scheduleTime); err != nil {
+		return reconcileReturnOnError(ctx, policy, "run", err, r.retryInfo)
+	}
+
+	return reconcileAfter(nextScheduleAfter, policy.Name, r.retryInfo)
+}
+
+func (r *ReconcileAzSnapshotPolicy) runPolicy(ctx context.Context, policy *azdiskv1beta2.AzSnapshotPolicy, scheduleTime time.Time) error {
+	var err error
+	ctx, w := workflow.New(ctx, workflow.WithDetails("namespace", policy.Namespace, "policy", policy.Name))
+	defer func() { w.Finish(err) }()
+
+	var lastScheduleTime *metav1.Time
+	if policy.Status.LastScheduleTime == nil || policy.Status.LastScheduleTime.Time.Before(scheduleTime) {
+		w.Logger().V(5).Infof("Taking snapshots scheduled at %v", scheduleTime)
+		if err = r.createSnapshots(ctx, policy, scheduleTime); err != nil {
+			// the retained snapshots are not pruned until the scheduled snapshots have been taken
+			_ = r.updateStatus(ctx, policy, nil, err)
+			return err
+		}
+		lastScheduleTime = &metav1.Time{Time: scheduleTime}
+	}
+
+	err = r.pruneSnapshots(ctx, policy)
+	if uerr := r.updateStatus(ctx, policy, lastScheduleTime, err); err == nil {
+		err = uerr
+	}
+	return err
+}
+
+func (r *ReconcileAzSnapshotPolicy) createSnapshots(ctx context.Context, policy *azdiskv1beta2.AzSnapshotPolicy, scheduleTime time.Time) error {
+	w, _ := workflow.GetWorkflowFromContext(ctx)
+
+	if policy.Spec.VolumeSnapshotClassName != "" {
+		if err := r.validateSnapshotClass(ctx, policy.Spec.VolumeSnapshotClassName); err != nil {
+			return err
+		}
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(&policy.Spec.Selector)
+	if err != nil {
+		return status.Errorf(codes.FailedPrecondition, "invalid selector of AzSnapshotPolicy (%s/%s): %v", policy.Namespace, policy.Name, err)
+	}
+
+	claims, err := r.kubeClient.CoreV1().PersistentVolumeClaims(policy.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		w.Logger().Error(err, "failed to list PersistentVolumeClaims")
+		return err
+	}
+
+	// the other claims are still snapshotted when one fails, and the first error is returned
+	var firstErr error
+	for i := range claims.Items {
+		claim := &claims.Items[i]
+		if claim.Status.Phase != v1.ClaimBound || claim.Spec.VolumeName == "" {
+			continue
+		}
+
+		pv := &v1.PersistentVolume{}
+		if err := r.cachedClient.Get(ctx, types.NamespacedName{Name: claim.Spec.VolumeName}, pv); err != nil {
+			w.Logger().Errorf(err, "failed to get PersistentVolume (%s) bound to PersistentVolumeClaim (%s)", claim.Spec.VolumeName, claim.Name)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if pv.Spec.CSI == nil || pv.Spec.CSI.Driver != r.config.DriverName {
+			continue
+		}
+
+		snapshot := &snapshotv1.VolumeSnapshot{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      getPolicySnapshotName(policy.Name, claim.Name, scheduleTime),
+				Namespace: policy.Namespace,
+				Labels:    map[string]string{consts.SnapshotPolicyLabel: policy.Name},
+			},
+			Spec: snapshotv1.VolumeSnapshotSpec{
+				Source: snapshotv1.VolumeSnapshotSource{
+					PersistentVolumeClaimName: &claim.Name,
+				},
+			},
+		}
+		if policy.Spec.VolumeSnapshotClassName != "" {
+			className := policy.Spec.VolumeSnapshotClassName
+			snapshot.Spec.VolumeSnapshotClassName = &className
+		}
+
+		// the snapshot of a claim has the same name for the same schedule time, so a retried creation is idempotent
+		if _, err := r.snapshotClient.VolumeSnapshots(policy.Namespace).Create(ctx, snapshot, metav1.CreateOptions{}); err != nil {
+			if errors.IsAlreadyExists(err) {
+				continue
+			}
+			w.Logger().Errorf(err, "failed to create VolumeSnapshot (%s) of PersistentVolumeClaim (%s)", snapshot.Name, claim.Name)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		w.Logger().V(5).Infof("Created VolumeSnapshot (%s) of PersistentVolumeClaim (%s)", snapshot.Name, claim.Name)
+		snapshotPolicySnapshotsCreated.WithLabelValues(policy.Namespace, policy.Name).Inc()
+	}
+	return firstErr
+}
+
+func (r *ReconcileAzSnapshotPolicy) validateSnapshotClass(ctx context.Context, className string) error {
+	class, err := r.snapshotClient.VolumeSnapshotClasses().Get(ctx, className, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return status.Errorf(codes.FailedPrecondition, "VolumeSnapshotClass (%s) does not exist", className)
+		}
+		return err
+	}
+	if class.Driver != r.config.DriverName {
+		return status.Errorf(codes.FailedPrecondition, "VolumeSnapshotClass (%s) is not provisioned by driver %s", className, r.config.DriverName)
+	}
+	for key, value := range class.Parameters {
+		if strings.EqualFold(key, consts.IncrementalField) && strings.EqualFold(value, "false") {
+			return status.Errorf(codes.FailedPrecondition, "VolumeSnapshotClass (%s) does not take incremental snapshots", className)
+		}
+	}
+	return nil
+}
+
+func (r *ReconcileAzSnapshotPolicy) pruneSnapshots(ctx context.Context, policy *azdiskv1beta2.AzSnapshotPolicy) error {
+	w, _ := workflow.GetWorkflowFromContext(ctx)
+
+	retention := policy.Spec.Retention
+	if retention.MaxCount <= 0 && retention.MaxAge.Duration <= 0 {
+		return nil
+	}
+
+	snapshots, err := r.snapshotClient.VolumeSnapshots(policy.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{consts.SnapshotPolicyLabel: policy.Name}).String(),
+	})
+	if err != nil {
+		w.Logger().Error(err, "failed to list VolumeSnapshots")
+		return err
+	}
+
+	claimSnapshots := map[string][]*snapshotv1.VolumeSnapshot{}
+	for i := range snapshots.Items {
+		snapshot := &snapshots.Items[i]
+		if snapshot.DeletionTimestamp != nil || snapshot.Spec.Source.PersistentVolumeClaimName == nil {
+			continue
+		}
+		claimName := *snapshot.Spec.Source.PersistentVolumeClaimName
+		claimSnapshots[claimName] = append(claimSnapshots[claimName], snapshot)
+	}
+
+	// the other snapshots are still pruned when one fails, and the first error is returned
+	var firstErr error
+	for claimName, snapshots := range claimSnapshots {
+		for _, snapshot := range getExpiredSnapshots(snapshots, retention, time.Now()) {
+			if err := r.snapshotClient.VolumeSnapshots(policy.Namespace).Delete(ctx, snapshot.Name, metav1.DeleteOptions{}); err != nil {
+				if errors.IsNotFound(err) {
+					continue
+				}
+				w.Logger().Errorf(err, "failed to delete expired VolumeSnapshot (%s) of PersistentVolumeClaim (%s)", snapshot.Name, claimName)
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			w.Logger().V(5).Infof("Deleted expired VolumeSnapshot (%s) of PersistentVolumeClaim (%s)", snapshot.Name, claimName)
+			snapshotPolicySnapshotsDeleted.WithLabelValues(policy.Namespace, policy.Name).Inc()
+		}
+	}
+	return firstErr
+}
+
+func (r *ReconcileAzSnapshotPolicy) updateStatus(ctx context.Context, policy *azdiskv1beta2.AzSnapshotPolicy, lastScheduleTime *metav1.Time, policyErr error) error {
+	now := metav1.Now()
+	if policyErr != nil {
+		snapshotPolicyLastFailureTime.WithLabelValues(policy.Namespace, policy.Name).Set(float64(now.Unix()))
+	} else {
+		snapshotPolicyLastSuccessTime.WithLabelValues(policy.Namespace, policy.Name).Set(float64(now.Unix()))
+	}
+
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		updated, err := r.azClient.DiskV1beta2().AzSnapshotPolicies(policy.Namespace).Get(ctx, policy.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if lastScheduleTime != nil {
+			updated.Status.LastScheduleTime = lastScheduleTime
+		}
+		if policyErr != nil {
+			updated.Status.LastFailureTime = &now
+			updated.Status.Error = util.NewAzError(policyErr)
+		} else {
+			updated.Status.LastSuccessTime = &now
+			updated.Status.Error = nil
+		}
+		_, err = r.azClient.DiskV1beta2().AzSnapshotPolicies(policy.Namespace).UpdateStatus(ctx, updated, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		w, _ := workflow.GetWorkflowFromContext(ctx)
+		w.Logger().Errorf(err, "failed to update status of AzSnapshotPolicy (%s/%s)", policy.Namespace, policy.Name)
+	}
+	return err
+}
+
+func validateSnapshotPolicy(policy *azdiskv1beta2.AzSnapshotPolicy) error {
+	if policy.Spec.Schedule.Duration <= 0 {
+		return status.Errorf(codes.FailedPrecondition, "schedule of AzSnapshotPolicy (%s/%s) must be a positive duration", policy.Namespace, policy.Name)
+	}
+	if policy.Spec.Retention.MaxCount < 0 || policy.Spec.Retention.MaxAge.Duration < 0 {
+		return status.Errorf(codes.FailedPrecondition, "retention of AzSnapshotPolicy (%s/%s) must not be negative", policy.Namespace, policy.Name)
+	}
+	// the snapshots taken by a policy are labeled with its name
+	if errs := validation.IsValidLabelValue(policy.Name); len(errs) > 0 {
+		return status.Errorf(codes.FailedPrecondition, "name of AzSnapshotPolicy (%s/%s) must be a valid label value: %s", policy.Namespace, policy.Name, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// getPolicySnapshotName returns the name of the VolumeSnapshot taken of a claim by a policy at the specified schedule time.
+func getPolicySnapshotName(policyName, claimName string, scheduleTime time.Time) string {
+	suffix := "-" + scheduleTime.UTC().Format(policySnapshotTimeFormat)
+	prefix := fmt.Sprintf("%s-%s", policyName, claimName)
+	if len(prefix)+len(suffix) > maxVolumeSnapshotNameLength {
+		prefix = strings.TrimRight(prefix[:maxVolumeSnapshotNameLength-len(suffix)], "-.")
+	}
+	return prefix + suffix
+}
+
+// getExpiredSnapshots returns the snapshots of a claim which are beyond the maximum count or older than the maximum age of the retention.
+// The newest snapshot which is ready to use and the snapshots taken after it are never expired.
+func getExpiredSnapshots(snapshots []*snapshotv1.VolumeSnapshot, retention azdiskv1beta2.AzSnapshotRetention, now time.Time) []*snapshotv1.VolumeSnapshot {
+	sort.Slice(snapshots, func(i, j int) bool {
+		ti, tj := snapshots[i].CreationTimestamp, snapshots[j].CreationTimestamp
+		if ti.Equal(&tj) {
+			return snapshots[i].Name > snapshots[j].Name
+		}
+		return tj.Before(&ti)
+	})
+
+	expired := []*snapshotv1.VolumeSnapshot{}
+	newestReady := -1
+	for i, snapshot := range snapshots {
+		if snapshot.Status != nil && snapshot.Status.ReadyToUse != nil && *snapshot.Status.ReadyToUse {
+			newestReady = i
+			break
+		}
+	}
+	if newestReady < 0 {
+		return expired
+	}
+
+	for i := newestReady + 1; i < len(snapshots); i++ {
+		snapshot := snapshots[i]
+		if (retention.MaxCount > 0 && i >= retention.MaxCount) ||
+			(retention.MaxAge.Duration > 0 && now.Sub(snapshot.CreationTimestamp.Time) > retention.MaxAge.Duration) {
+			expired = append(expired, snapshot)
+		}
+	}
+	return expired
+}
+
+func deleteSnapshotPolicyMetrics(namespace, name string) {
+	metricLabels := map[string]string{"namespace": namespace, "policy": name}
+	snapshotPolicyLastSuccessTime.Delete(metricLabels)
+	snapshotPolicyLastFailureTime.Delete(metricLabels)
+	snapshotPolicySnapshotsCreated.Delete(metricLabels)
+	snapshotPolicySnapshotsDeleted.Delete(metricLabels)
+}
+
+func NewAzSnapshotPolicyController(mgr manager.Manager, controllerSharedState *SharedState) (*ReconcileAzSnapshotPolicy, error) {
+	logger := mgr.GetLogger().WithValues("controller", "azsnapshotpolicy")
+
+	snapshotClient, err := snapshotclientset.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		logger.Error(err, "failed to create VolumeSnapshot client")
+		return nil, err
+	}
+
+	reconciler := ReconcileAzSnapshotPolicy{
+		SharedState:    controllerSharedState,
+		logger:         logger,
+		snapshotClient: snapshotClient.SnapshotV1(),
+		retryInfo:      newRetryInfo(),
+	}
+
+	c, err := controller.New("azsnapshotpolicy-controller", mgr, controller.Options{
+		MaxConcurrentReconciles: controllerSharedState.config.ControllerConfig.WorkerThreads,
+		Reconciler:              &reconciler,
+		LogConstructor:          func(req *reconcile.Request) logr.Logger { return logger },
+	})
+
+	if err != nil {
+		logger.Error(err, "failed to create controller")
+		return nil, err
+	}
+
+	logger.V(2).Info("Starting to watch AzSnapshotPolicy.")
+
+	// Watch for changes to the spec of AzSnapshotPolicy objects; the updates of their status are made by the controller itself
+	err = c.Watch(&source.Kind{Type: &azdiskv1beta2.AzSnapshotPolicy{}}, &handler.EnqueueRequestForObject{}, predicate.GenerationChangedPredicate{})
+	if err != nil {
+		logger.Error(err, "failed to initialize watch for AzSnapshotPolicy CRI")
+		return nil, err
+	}
+
+	logger.V(2).Info("Controller set-up successful.")
+
+	return &reconciler, nil
+}
@@ -0,0 +1,566 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	snapshotv1client "github.com/kubernetes-csi/external-snapshotter/client/v4/clientset/versioned/typed/volumesnapshot/v1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakev1 "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2/klogr"
+	"k8s.io/utils/pointer"
+	azdiskv1beta2 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
+	azdiskfakes "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/client/clientset/versioned/fake"
+	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils/mockclient"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	testSnapshotPolicyName  = "test-snapshot-policy"
+	testSnapshotClassName   = "test-snapshot-class"
+	testSnapshotPolicyLabel = "app"
+)
+
+var (
+	testSnapshotPolicyRequest = createReconcileRequest(testNamespace, testSnapshotPolicyName)
+
+	testSnapshotPolicyInterval = time.Hour
+)
+
+// fakeSnapshotClient is an in-memory implementation of the subset of the VolumeSnapshot client used by the AzSnapshotPolicy controller.
+type fakeSnapshotClient struct {
+	snapshotv1client.SnapshotV1Interface
+	lock      sync.Mutex
+	snapshots map[string]*snapshotv1.VolumeSnapshot
+	classes   map[string]*snapshotv1.VolumeSnapshotClass
+	createErr error
+}
+
+type fakeVolumeSnapshots struct {
+	snapshotv1client.VolumeSnapshotInterface
+	client    *fakeSnapshotClient
+	namespace string
+}
+
+type fakeVolumeSnapshotClasses struct {
+	snapshotv1client.VolumeSnapshotClassInterface
+	client *fakeSnapshotClient
+}
+
+func newFakeSnapshotClient(objects ...runtime.Object) *fakeSnapshotClient {
+	client := &fakeSnapshotClient{
+		snapshots: map[string]*snapshotv1.VolumeSnapshot{},
+		classes:   map[string]*snapshotv1.VolumeSnapshotClass{},
+	}
+	for _, obj := range objects {
+		switch target := obj.(type) {
+		case *snapshotv1.VolumeSnapshot:
+			client.snapshots[getQualifiedName(target.Namespace, target.Name)] = target.DeepCopy()
+		case *snapshotv1.VolumeSnapshotClass:
+			client.classes[target.Name] = target.DeepCopy()
+		}
+	}
+	return client
+}
+
+func (c *fakeSnapshotClient) VolumeSnapshots(namespace string) snapshotv1client.VolumeSnapshotInterface {
+	return &fakeVolumeSnapshots{client: c, namespace: namespace}
+}
+
+func (c *fakeSnapshotClient) VolumeSnapshotClasses() snapshotv1client.VolumeSnapshotClassInterface {
+	return &fakeVolumeSnapshotClasses{client: c}
+}
+
+func (c *fakeSnapshotClient) snapshotNames(namespace string) []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	names := []string{}
+	for _, snapshot := range c.snapshots {
+		if snapshot.Namespace == namespace {
+			names = append(names, snapshot.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *fakeVolumeSnapshots) Create(ctx context.Context, volumeSnapshot *snapshotv1.VolumeSnapshot, opts metav1.CreateOptions) (*snapshotv1.VolumeSnapshot, error) {
+	s.client.lock.Lock()
+	defer s.client.lock.Unlock()
+
+	if s.client.createErr != nil {
+		return nil, s.client.createErr
+	}
+	key := getQualifiedName(s.namespace, volumeSnapshot.Name)
+	if _, ok := s.client.snapshots[key]; ok {
+		return nil, k8serrors.NewAlreadyExists(snapshotv1.Resource("volumesnapshots"), volumeSnapshot.Name)
+	}
+	created := volumeSnapshot.DeepCopy()
+	created.Namespace = s.namespace
+	created.CreationTimestamp = metav1.Now()
+	s.client.snapshots[key] = created
+	return created.DeepCopy(), nil
+}
+
+func (s *fakeVolumeSnapshots) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	s.client.lock.Lock()
+	defer s.client.lock.Unlock()
+
+	key := getQualifiedName(s.namespace, name)
+	if _, ok := s.client.snapshots[key]; !ok {
+		return k8serrors.NewNotFound(snapshotv1.Resource("volumesnapshots"), name)
+	}
+	delete(s.client.snapshots, key)
+	return nil
+}
+
+func (s *fakeVolumeSnapshots) List(ctx context.Context, opts metav1.ListOptions) (*snapshotv1.VolumeSnapshotList, error) {
+	s.client.lock.Lock()
+	defer s.client.lock.Unlock()
+
+	selector, err := labels.Parse(opts.LabelSelector)
+	if err != nil {
+		return nil, err
+	}
+	list := &snapshotv1.VolumeSnapshotList{}
+	for _, snapshot := range s.client.snapshots {
+		if snapshot.Namespace == s.namespace && selector.Matches(labels.Set(snapshot.Labels)) {
+			list.Items = append(list.Items, *snapshot.DeepCopy())
+		}
+	}
+	return list, nil
+}
+
+func (c *fakeVolumeSnapshotClasses) Get(ctx context.Context, name string, opts metav1.GetOptions) (*snapshotv1.VolumeSnapshotClass, error) {
+	c.client.lock.Lock()
+	defer c.client.lock.Unlock()
+
+	class, ok := c.client.classes[name]
+	if !ok {
+		return nil, k8serrors.NewNotFound(snapshotv1.Resource("volumesnapshotclasses"), name)
+	}
+	return class.DeepCopy(), nil
+}
+
+func createTestAzSnapshotPolicy(retention azdiskv1beta2.AzSnapshotRetention) *azdiskv1beta2.AzSnapshotPolicy {
+	return &azdiskv1beta2.AzSnapshotPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testSnapshotPolicyName,
+			Namespace: testNamespace,
+		},
+		Spec: azdiskv1beta2.AzSnapshotPolicySpec{
+			Schedule: metav1.Duration{Duration: testSnapshotPolicyInterval},
+			Selector: metav1.LabelSelector{
+				MatchLabels: map[string]string{testSnapshotPolicyLabel: testSnapshotPolicyName},
+			},
+			Retention: retention,
+		},
+	}
+}
+
+func createTestPersistentVolumeClaim(name, volumeName string, phase v1.PersistentVolumeClaimPhase, selected bool) *v1.PersistentVolumeClaim {
+	claim := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testNamespace,
+		},
+		Spec: v1.PersistentVolumeClaimSpec{
+			VolumeName: volumeName,
+		},
+		Status: v1.PersistentVolumeClaimStatus{
+			Phase: phase,
+		},
+	}
+	if selected {
+		claim.Labels = map[string]string{testSnapshotPolicyLabel: testSnapshotPolicyName}
+	}
+	return claim
+}
+
+func createTestPolicySnapshot(claimName string, age time.Duration, readyToUse bool) *snapshotv1.VolumeSnapshot {
+	creationTime := time.Now().Add(-age)
+	return &snapshotv1.VolumeSnapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              fmt.Sprintf("%s-%s-%d", testSnapshotPolicyName, claimName, int(age.Hours())),
+			Namespace:         testNamespace,
+			Labels:            map[string]string{consts.SnapshotPolicyLabel: testSnapshotPolicyName},
+			CreationTimestamp: metav1.Time{Time: creationTime},
+		},
+		Spec: snapshotv1.VolumeSnapshotSpec{
+			Source: snapshotv1.VolumeSnapshotSource{PersistentVolumeClaimName: pointer.String(claimName)},
+		},
+		Status: &snapshotv1.VolumeSnapshotStatus{ReadyToUse: pointer.Bool(readyToUse)},
+	}
+}
+
+func NewTestAzSnapshotPolicyController(controller *gomock.Controller, namespace string, objects ...runtime.Object) *ReconcileAzSnapshotPolicy {
+	snapshotObjs := []runtime.Object{}
+	otherObjs := []runtime.Object{}
+	for _, obj := range objects {
+		switch obj.(type) {
+		case *snapshotv1.VolumeSnapshot, *snapshotv1.VolumeSnapshotClass:
+			snapshotObjs = append(snapshotObjs, obj)
+		default:
+			otherObjs = append(otherObjs, obj)
+		}
+	}
+	azDiskObjs, kubeObjs := splitObjects(otherObjs...)
+	controllerSharedState := initState(mockclient.NewMockClient(controller), azdiskfakes.NewSimpleClientset(azDiskObjs...), fakev1.NewSimpleClientset(kubeObjs...), otherObjs...)
+
+	reconciler := &ReconcileAzSnapshotPolicy{
+		SharedState:    controllerSharedState,
+		logger:         klogr.New(),
+		snapshotClient: newFakeSnapshotClient(snapshotObjs...),
+		retryInfo:      newRetryInfo(),
+	}
+	mockClients(reconciler.cachedClient.(*mockclient.MockClient), reconciler.azClient, reconciler.kubeClient)
+	return reconciler
+}
+
+func getSnapshotPolicyMetricValue(t *testing.T, name, namespace, policy string) (float64, bool) {
+	families, err := legacyregistry.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != fmt.Sprintf("%s_%s", consts.AzureDiskCSIDriverName, name) {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labelValues := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labelValues[label.GetName()] = label.GetValue()
+			}
+			if labelValues["namespace"] != namespace || labelValues["policy"] != policy {
+				continue
+			}
+			if metric.GetCounter() != nil {
+				return metric.GetCounter().GetValue(), true
+			}
+			return metric.GetGauge().GetValue(), true
+		}
+	}
+	return 0, false
+}
+
+func getTestAzSnapshotPolicy(t *testing.T, controller *ReconcileAzSnapshotPolicy) *azdiskv1beta2.AzSnapshotPolicy {
+	policy, err := controller.azClient.DiskV1beta2().AzSnapshotPolicies(testNamespace).Get(context.TODO(), testSnapshotPolicyName, metav1.GetOptions{})
+	require.NoError(t, err)
+	return policy
+}
+
+func TestAzSnapshotPolicyControllerReconcile(t *testing.T) {
+	tests := []struct {
+		description string
+		request     reconcile.Request
+		setupFunc   func(*testing.T, *gomock.Controller) *ReconcileAzSnapshotPolicy
+		verifyFunc  func(*testing.T, *ReconcileAzSnapshotPolicy, reconcile.Result, error)
+	}{
+		{
+			description: "[Success] Should succeed and remove the policy metrics if AzSnapshotPolicy is not found.",
+			request:     testSnapshotPolicyRequest,
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *ReconcileAzSnapshotPolicy {
+				snapshotPolicyLastSuccessTime.WithLabelValues(testNamespace, testSnapshotPolicyName).Set(1)
+				return NewTestAzSnapshotPolicyController(mockCtl, testNamespace)
+			},
+			verifyFunc: func(t *testing.T, controller *ReconcileAzSnapshotPolicy, result reconcile.Result, err error) {
+				require.NoError(t, err)
+				require.False(t, result.Requeue)
+				_, found := getSnapshotPolicyMetricValue(t, "snapshot_policy_last_success_timestamp_seconds", testNamespace, testSnapshotPolicyName)
+				require.False(t, found)
+			},
+		},
+		{
+			description: "[Success] Should snapshot the bound claims of the driver selected by the policy.",
+			request:     testSnapshotPolicyRequest,
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *ReconcileAzSnapshotPolicy {
+				otherDriverVolume := testPersistentVolume1.DeepCopy()
+				otherDriverVolume.Spec.CSI.Driver = "other.csi.driver"
+
+				return NewTestAzSnapshotPolicyController(
+					mockCtl,
+					testNamespace,
+					createTestAzSnapshotPolicy(azdiskv1beta2.AzSnapshotRetention{}),
+					&testPersistentVolume0,
+					otherDriverVolume,
+					createTestPersistentVolumeClaim(testPersistentVolumeClaim0Name, testPersistentVolume0Name, v1.ClaimBound, true),
+					createTestPersistentVolumeClaim(testPersistentVolumeClaim1Name, testPersistentVolume1Name, v1.ClaimBound, true),
+					createTestPersistentVolumeClaim(testPersistentVolumeClaim2Name, "", v1.ClaimPending, true),
+					createTestPersistentVolumeClaim("test-pvc-unselected", testPersistentVolume0Name, v1.ClaimBound, false))
+			},
+			verifyFunc: func(t *testing.T, controller *ReconcileAzSnapshotPolicy, result reconcile.Result, err error) {
+				require.NoError(t, err)
+				require.True(t, result.Requeue)
+				require.LessOrEqual(t, result.RequeueAfter, testSnapshotPolicyInterval)
+
+				scheduleTime := time.Now().Truncate(testSnapshotPolicyInterval)
+				snapshotName := getPolicySnapshotName(testSnapshotPolicyName, testPersistentVolumeClaim0Name, scheduleTime)
+				require.Equal(t, []string{snapshotName}, controller.snapshotClient.(*fakeSnapshotClient).snapshotNames(testNamespace))
+
+				policy := getTestAzSnapshotPolicy(t, controller)
+				require.NotNil(t, policy.Status.LastScheduleTime)
+				require.True(t, policy.Status.LastScheduleTime.Time.Equal(scheduleTime))
+				require.NotNil(t, policy.Status.LastSuccessTime)
+				require.Nil(t, policy.Status.LastFailureTime)
+				require.Nil(t, policy.Status.Error)
+
+				created, found := getSnapshotPolicyMetricValue(t, "snapshot_policy_snapshots_created_total", testNamespace, testSnapshotPolicyName)
+				require.True(t, found)
+				require.Equal(t, float64(1), created)
+			},
+		},
+		{
+			description: "[Success] Should not snapshot the claims again before the next schedule time.",
+			request:     testSnapshotPolicyRequest,
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *ReconcileAzSnapshotPolicy {
+				policy := createTestAzSnapshotPolicy(azdiskv1beta2.AzSnapshotRetention{})
+				policy.Status.LastScheduleTime = &metav1.Time{Time: time.Now().Truncate(testSnapshotPolicyInterval)}
+
+				return NewTestAzSnapshotPolicyController(
+					mockCtl,
+					testNamespace,
+					policy,
+					&testPersistentVolume0,
+					createTestPersistentVolumeClaim(testPersistentVolumeClaim0Name, testPersistentVolume0Name, v1.ClaimBound, true))
+			},
+			verifyFunc: func(t *testing.T, controller *ReconcileAzSnapshotPolicy, result reconcile.Result, err error) {
+				require.NoError(t, err)
+				require.True(t, result.Requeue)
+				require.Empty(t, controller.snapshotClient.(*fakeSnapshotClient).snapshotNames(testNamespace))
+			},
+		},
+		{
+			description: "[Success] Should prune the snapshots beyond the maximum count or older than the maximum age.",
+			request:     testSnapshotPolicyRequest,
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *ReconcileAzSnapshotPolicy {
+				policy := createTestAzSnapshotPolicy(azdiskv1beta2.AzSnapshotRetention{
+					MaxCount: 3,
+					MaxAge:   metav1.Duration{Duration: 4 * testSnapshotPolicyInterval},
+				})
+				policy.Status.LastScheduleTime = &metav1.Time{Time: time.Now().Truncate(testSnapshotPolicyInterval)}
+
+				return NewTestAzSnapshotPolicyController(
+					mockCtl,
+					testNamespace,
+					policy,
+					createTestPolicySnapshot(testPersistentVolumeClaim0Name, 0, true),
+					createTestPolicySnapshot(testPersistentVolumeClaim0Name, testSnapshotPolicyInterval, true),
+					createTestPolicySnapshot(testPersistentVolumeClaim0Name, 2*testSnapshotPolicyInterval, true),
+					createTestPolicySnapshot(testPersistentVolumeClaim0Name, 3*testSnapshotPolicyInterval, true),
+					createTestPolicySnapshot(testPersistentVolumeClaim1Name, 5*testSnapshotPolicyInterval, true),
+					createTestPolicySnapshot(testPersistentVolumeClaim1Name, 6*testSnapshotPolicyInterval, true))
+			},
+			verifyFunc: func(t *testing.T, controller *ReconcileAzSnapshotPolicy, result reconcile.Result, err error) {
+				require.NoError(t, err)
+				require.True(t, result.Requeue)
+
+				expected := []string{
+					createTestPolicySnapshot(testPersistentVolumeClaim0Name, 0, true).Name,
+					createTestPolicySnapshot(testPersistentVolumeClaim0Name, testSnapshotPolicyInterval, true).Name,
+					createTestPolicySnapshot(testPersistentVolumeClaim0Name, 2*testSnapshotPolicyInterval, true).Name,
+					// the newest ready snapshot of a claim is kept regardless of its age
+					createTestPolicySnapshot(testPersistentVolumeClaim1Name, 5*testSnapshotPolicyInterval, true).Name,
+				}
+				sort.Strings(expected)
+				require.Equal(t, expected, controller.snapshotClient.(*fakeSnapshotClient).snapshotNames(testNamespace))
+
+				deleted, found := getSnapshotPolicyMetricValue(t, "snapshot_policy_snapshots_deleted_total", testNamespace, testSnapshotPolicyName)
+				require.True(t, found)
+				require.Equal(t, float64(2), deleted)
+			},
+		},
+		{
+			description: "[Failure] Should not retry and should report an error if the VolumeSnapshotClass does not take incremental snapshots.",
+			request:     testSnapshotPolicyRequest,
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *ReconcileAzSnapshotPolicy {
+				policy := createTestAzSnapshotPolicy(azdiskv1beta2.AzSnapshotRetention{})
+				policy.Spec.VolumeSnapshotClassName = testSnapshotClassName
+
+				return NewTestAzSnapshotPolicyController(
+					mockCtl,
+					testNamespace,
+					policy,
+					&snapshotv1.VolumeSnapshotClass{
+						ObjectMeta: metav1.ObjectMeta{Name: testSnapshotClassName},
+						Driver:     consts.DefaultDriverName,
+						Parameters: map[string]string{consts.IncrementalField: "false"},
+					},
+					&testPersistentVolume0,
+					createTestPersistentVolumeClaim(testPersistentVolumeClaim0Name, testPersistentVolume0Name, v1.ClaimBound, true))
+			},
+			verifyFunc: func(t *testing.T, controller *ReconcileAzSnapshotPolicy, result reconcile.Result, err error) {
+				require.NoError(t, err)
+				require.False(t, result.Requeue)
+				require.Empty(t, controller.snapshotClient.(*fakeSnapshotClient).snapshotNames(testNamespace))
+
+				policy := getTestAzSnapshotPolicy(t, controller)
+				require.Nil(t, policy.Status.LastScheduleTime)
+				require.NotNil(t, policy.Status.LastFailureTime)
+				require.NotNil(t, policy.Status.Error)
+				require.Equal(t, azdiskv1beta2.AzErrorCodeFailedPrecondition, policy.Status.Error.Code)
+
+				_, found := getSnapshotPolicyMetricValue(t, "snapshot_policy_last_failure_timestamp_seconds", testNamespace, testSnapshotPolicyName)
+				require.True(t, found)
+			},
+		},
+		{
+			description: "[Failure] Should retry without pruning if a snapshot cannot be created.",
+			request:     testSnapshotPolicyRequest,
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *ReconcileAzSnapshotPolicy {
+				policy := createTestAzSnapshotPolicy(azdiskv1beta2.AzSnapshotRetention{MaxCount: 1})
+
+				controller := NewTestAzSnapshotPolicyController(
+					mockCtl,
+					testNamespace,
+					policy,
+					createTestPolicySnapshot(testPersistentVolumeClaim0Name, testSnapshotPolicyInterval, true),
+					createTestPolicySnapshot(testPersistentVolumeClaim0Name, 2*testSnapshotPolicyInterval, true),
+					&testPersistentVolume0,
+					createTestPersistentVolumeClaim(testPersistentVolumeClaim0Name, testPersistentVolume0Name, v1.ClaimBound, true))
+				controller.snapshotClient.(*fakeSnapshotClient).createErr = status.Errorf(codes.Unavailable, "test error")
+				return controller
+			},
+			verifyFunc: func(t *testing.T, controller *ReconcileAzSnapshotPolicy, result reconcile.Result, err error) {
+				require.NoError(t, err)
+				require.True(t, result.Requeue)
+				require.Len(t, controller.snapshotClient.(*fakeSnapshotClient).snapshotNames(testNamespace), 2)
+
+				policy := getTestAzSnapshotPolicy(t, controller)
+				require.Nil(t, policy.Status.LastScheduleTime)
+				require.Nil(t, policy.Status.LastSuccessTime)
+				require.NotNil(t, policy.Status.LastFailureTime)
+				require.NotNil(t, policy.Status.Error)
+			},
+		},
+		{
+			description: "[Failure] Should not retry if the schedule is not a positive duration.",
+			request:     testSnapshotPolicyRequest,
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *ReconcileAzSnapshotPolicy {
+				policy := createTestAzSnapshotPolicy(azdiskv1beta2.AzSnapshotRetention{})
+				policy.Spec.Schedule = metav1.Duration{}
+
+				return NewTestAzSnapshotPolicyController(mockCtl, testNamespace, policy)
+			},
+			verifyFunc: func(t *testing.T, controller *ReconcileAzSnapshotPolicy, result reconcile.Result, err error) {
+				require.NoError(t, err)
+				require.False(t, result.Requeue)
+
+				policy := getTestAzSnapshotPolicy(t, controller)
+				require.NotNil(t, policy.Status.Error)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		tt := test
+		t.Run(tt.description, func(t *testing.T) {
+			mockCtl := gomock.NewController(t)
+			defer mockCtl.Finish()
+			defer deleteSnapshotPolicyMetrics(testNamespace, testSnapshotPolicyName)
+			controller := tt.setupFunc(t, mockCtl)
+			result, err := controller.Reconcile(context.TODO(), tt.request)
+			tt.verifyFunc(t, controller, result, err)
+		})
+	}
+}
+
+func TestGetExpiredSnapshots(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		description string
+		snapshots   []*snapshotv1.VolumeSnapshot
+		retention   azdiskv1beta2.AzSnapshotRetention
+		expected    []int
+	}{
+		{
+			description: "no snapshot is expired without a retention",
+			snapshots: []*snapshotv1.VolumeSnapshot{
+				createTestPolicySnapshot(testPersistentVolumeClaim0Name, 0, true),
+				createTestPolicySnapshot(testPersistentVolumeClaim0Name, testSnapshotPolicyInterval, true),
+			},
+			expected: []int{},
+		},
+		{
+			description: "the snapshots after the newest ready snapshot are kept beyond the maximum count",
+			snapshots: []*snapshotv1.VolumeSnapshot{
+				createTestPolicySnapshot(testPersistentVolumeClaim0Name, 0, false),
+				createTestPolicySnapshot(testPersistentVolumeClaim0Name, testSnapshotPolicyInterval, false),
+				createTestPolicySnapshot(testPersistentVolumeClaim0Name, 2*testSnapshotPolicyInterval, true),
+				createTestPolicySnapshot(testPersistentVolumeClaim0Name, 3*testSnapshotPolicyInterval, true),
+			},
+			retention: azdiskv1beta2.AzSnapshotRetention{MaxCount: 1},
+			expected:  []int{3},
+		},
+		{
+			description: "no snapshot is expired until a snapshot is ready to use",
+			snapshots: []*snapshotv1.VolumeSnapshot{
+				createTestPolicySnapshot(testPersistentVolumeClaim0Name, 0, false),
+				createTestPolicySnapshot(testPersistentVolumeClaim0Name, 5*testSnapshotPolicyInterval, false),
+			},
+			retention: azdiskv1beta2.AzSnapshotRetention{MaxCount: 1, MaxAge: metav1.Duration{Duration: testSnapshotPolicyInterval}},
+			expected:  []int{},
+		},
+		{
+			description: "the snapshots older than the maximum age are expired",
+			snapshots: []*snapshotv1.VolumeSnapshot{
+				createTestPolicySnapshot(testPersistentVolumeClaim0Name, 3*testSnapshotPolicyInterval, true),
+				createTestPolicySnapshot(testPersistentVolumeClaim0Name, 0, true),
+				createTestPolicySnapshot(testPersistentVolumeClaim0Name, 2*testSnapshotPolicyInterval, true),
+			},
+			retention: azdiskv1beta2.AzSnapshotRetention{MaxAge: metav1.Duration{Duration: testSnapshotPolicyInterval}},
+			expected:  []int{2, 0},
+		},
+	}
+
+	for _, test := range tests {
+		tt := test
+		t.Run(tt.description, func(t *testing.T) {
+			expected := []*snapshotv1.VolumeSnapshot{}
+			for _, i := range tt.expected {
+				expected = append(expected, tt.snapshots[i])
+			}
+			snapshots := append([]*snapshotv1.VolumeSnapshot{}, tt.snapshots...)
+			require.Equal(t, expected, getExpiredSnapshots(snapshots, tt.retention, now))
+		})
+	}
+}
+
+func TestGetPolicySnapshotName(t *testing.T) {
+	scheduleTime := time.Date(2023, 4, 5, 6, 0, 0, 0, time.UTC)
+	require.Equal(t, "policy-claim-20230405060000", getPolicySnapshotName("policy", "claim", scheduleTime))
+
+	longName := getPolicySnapshotName("policy", strings.Repeat("a", 240)+".b", scheduleTime)
+	require.Len(t, longName, maxVolumeSnapshotNameLength)
+	require.True(t, strings.HasSuffix(longName, "a-20230405060000"))
+}
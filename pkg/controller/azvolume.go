@@ -18,6 +18,7 @@ package controller
 
 import (
 	"context"
+	"strings"
 	"sync"
 	"sync/atomic"
 
@@ -53,6 +54,7 @@ type VolumeProvisioner interface {
 		accessibilityTopology *azdiskv1beta2.TopologyRequirement) (*azdiskv1beta2.AzVolumeStatusDetail, error)
 	DeleteVolume(ctx context.Context, volumeID string, secrets map[string]string) error
 	ExpandVolume(ctx context.Context, volumeID string, capacityRange *azdiskv1beta2.CapacityRange, secrets map[string]string) (*azdiskv1beta2.AzVolumeStatusDetail, error)
+	ModifyVolume(ctx context.Context, volumeID string, parameters map[string]string) error
 }
 
 // Struct for the reconciler
@@ -77,6 +79,7 @@ var allowedTargetVolumeStates = map[string][]string{
 	string(azdiskv1beta2.VolumeCreated):          {string(azdiskv1beta2.VolumeUpdating), string(azdiskv1beta2.VolumeDeleting)},
 	string(azdiskv1beta2.VolumeDeleted):          {},
 	string(azdiskv1beta2.VolumeUpdated):          {string(azdiskv1beta2.VolumeUpdating), string(azdiskv1beta2.VolumeDeleting)},
+	string(azdiskv1beta2.VolumeUpdateFailed):     {string(azdiskv1beta2.VolumeUpdating), string(azdiskv1beta2.VolumeDeleting)},
 	string(azdiskv1beta2.VolumeCreationFailed):   {},
 	string(azdiskv1beta2.VolumeDeletionFailed):   {},
 }
@@ -99,6 +102,11 @@ func (r *ReconcileAzVolume) Reconcile(ctx context.Context, request reconcile.Req
 		return reconcileReturnOnError(ctx, azVolume, "get", err, r.retryInfo)
 	}
 
+	// skip requests queued before the shard of the volume was handed off to another controller
+	if !r.ownsVolume(azVolume.Name) {
+		return reconcileReturnOnSuccess(azVolume.Name, r.retryInfo)
+	}
+
 	ctx, _ = workflow.GetWorkflowFromObj(ctx, azVolume)
 
 	// if underlying cloud operation already in process, skip until operation is completed
@@ -120,11 +128,19 @@ func (r *ReconcileAzVolume) Reconcile(ctx context.Context, request reconcile.Req
 		if err := r.triggerCreate(ctx, azVolume); err != nil {
 			return reconcileReturnOnError(ctx, azVolume, "create", err, r.retryInfo)
 		}
+		// a failed update is only retried once the spec changes, as retrying the same update would fail the same way
+	} else if azVolume.Status.State == azdiskv1beta2.VolumeUpdateFailed && azVolume.Status.UpdateGeneration == azVolume.Generation {
+		return reconcileReturnOnSuccess(azVolume.Name, r.retryInfo)
 		// azVolume update
 	} else if azVolume.Spec.CapacityRange != nil && azVolume.Spec.CapacityRange.RequiredBytes > azVolume.Status.Detail.CapacityBytes {
 		if err := r.triggerUpdate(ctx, azVolume); err != nil {
 			return reconcileReturnOnError(ctx, azVolume, "update", err, r.retryInfo)
 		}
+		// azVolume modification
+	} else if modifications := azureutils.GetModifiedDiskParameters(azVolume.Spec.Parameters, azVolume.Status.Detail.VolumeContext); len(modifications) > 0 {
+		if err := r.triggerModify(ctx, azVolume, modifications); err != nil {
+			return reconcileReturnOnError(ctx, azVolume, "modify", err, r.retryInfo)
+		}
 	}
 
 	return reconcileReturnOnSuccess(azVolume.Name, r.retryInfo)
@@ -289,6 +305,10 @@ func (r *ReconcileAzVolume) triggerDelete(ctx context.Context, azVolume *azdiskv
 				return derr
 			}
 		} else {
+			// the secrets are no longer needed once the AzVolume is gone
+			if derr := azureutils.DeleteAzVolumeSecrets(goCtx, r.kubeClient, azVolume); derr != nil {
+				goWorkflow.Logger().Errorf(derr, "failed to delete secrets for AzVolume (%s)", azVolume.Name)
+			}
 			// if every operation was successful, delete the finalizer
 			updateFunc = func(obj client.Object) error {
 				azv := obj.(*azdiskv1beta2.AzVolume)
@@ -319,6 +339,7 @@ func (r *ReconcileAzVolume) triggerUpdate(ctx context.Context, azVolume *azdiskv
 
 	updateFunc := func(obj client.Object) error {
 		azv := obj.(*azdiskv1beta2.AzVolume)
+		azv.Status.UpdateGeneration = azv.Generation
 		_, derr := r.updateState(azv, azdiskv1beta2.VolumeUpdating, normalUpdate)
 		return derr
 	}
@@ -370,6 +391,68 @@ func (r *ReconcileAzVolume) triggerUpdate(ctx context.Context, azVolume *azdiskv
 	return nil
 }
 
+func (r *ReconcileAzVolume) triggerModify(ctx context.Context, azVolume *azdiskv1beta2.AzVolume, modifications map[string]string) error {
+	var err error
+	ctx, w := workflow.New(ctx)
+	defer func() { w.Finish(err) }()
+
+	// requeue if AzVolume's state is being updated by a different worker
+	if _, ok := r.stateLock.LoadOrStore(azVolume.Name, nil); ok {
+		err = getOperationRequeueError("modify", azVolume)
+		return err
+	}
+	defer r.stateLock.Delete(azVolume.Name)
+
+	updateFunc := func(obj client.Object) error {
+		azv := obj.(*azdiskv1beta2.AzVolume)
+		azv.Status.UpdateGeneration = azv.Generation
+		_, derr := r.updateState(azv, azdiskv1beta2.VolumeUpdating, normalUpdate)
+		return derr
+	}
+	var updatedObj client.Object
+	if updatedObj, err = azureutils.UpdateCRIWithRetry(ctx, nil, r.cachedClient, r.azClient, azVolume, updateFunc, consts.NormalUpdateMaxNetRetry, azureutils.UpdateCRIStatus); err != nil {
+		return err
+	}
+	azVolume = updatedObj.(*azdiskv1beta2.AzVolume)
+
+	w.Logger().Infof("Modifying Volume parameters %v", modifications)
+	waitCh := make(chan goSignal)
+	//nolint:contextcheck // call is asynchronous; context is not inherited by design
+	go func() {
+		var modifyErr error
+		_, goWorkflow := workflow.New(ctx)
+		defer func() { goWorkflow.Finish(modifyErr) }()
+		waitCh <- goSignal{}
+
+		goCtx := goWorkflow.SaveToContext(context.Background())
+		cloudCtx, cloudCancel := context.WithTimeout(goCtx, cloudTimeout)
+		defer cloudCancel()
+
+		var updateFunc azureutils.UpdateCRIFunc
+		modifyErr = r.volumeProvisioner.ModifyVolume(cloudCtx, azVolume.Status.Detail.VolumeID, modifications)
+		if modifyErr != nil {
+			updateFunc = func(obj client.Object) error {
+				azv := obj.(*azdiskv1beta2.AzVolume)
+				_, derr := r.reportError(azv, azdiskv1beta2.VolumeUpdateFailed, modifyErr)
+				return derr
+			}
+		} else {
+			updateFunc = func(obj client.Object) error {
+				azv := obj.(*azdiskv1beta2.AzVolume)
+				azv = r.updateVolumeContext(azv, modifications)
+				_, derr := r.updateState(azv, azdiskv1beta2.VolumeUpdated, forceUpdate)
+				return derr
+			}
+		}
+
+		//nolint:contextcheck // final status update of the CRI must occur even when the current context's deadline passes.
+		_, _ = azureutils.UpdateCRIWithRetry(goCtx, nil, r.cachedClient, r.azClient, azVolume, updateFunc, consts.ForcedUpdateMaxNetRetry, azureutils.UpdateCRIStatus)
+	}()
+	<-waitCh
+
+	return nil
+}
+
 func (r *ReconcileAzVolume) deleteFinalizer(azVolume *azdiskv1beta2.AzVolume, finalizersToDelete map[string]bool) *azdiskv1beta2.AzVolume {
 	if azVolume == nil {
 		return nil
@@ -410,6 +493,7 @@ func (r *ReconcileAzVolume) updateState(azVolume *azdiskv1beta2.AzVolume, state
 	}
 	if err == nil {
 		azVolume.Status.State = state
+		azVolume = updateAzVolumeConditions(azVolume)
 	}
 	return azVolume, err
 }
@@ -424,6 +508,25 @@ func (r *ReconcileAzVolume) updateStatusDetail(azVolume *azdiskv1beta2.AzVolume,
 		azVolume.Status.Detail.CapacityBytes = capacityBytes
 		azVolume.Status.Detail.NodeExpansionRequired = nodeExpansionRequired
 	}
+	return updateAzVolumeConditions(azVolume)
+}
+
+// updateVolumeContext records the modified parameters in the volume context of the AzVolume status.
+func (r *ReconcileAzVolume) updateVolumeContext(azVolume *azdiskv1beta2.AzVolume, modifications map[string]string) *azdiskv1beta2.AzVolume {
+	if azVolume == nil || azVolume.Status.Detail == nil {
+		return azVolume
+	}
+	volumeContext := make(map[string]string, len(azVolume.Status.Detail.VolumeContext)+len(modifications))
+	for key, value := range azVolume.Status.Detail.VolumeContext {
+		// drop differently-cased keys of the modified parameters so they are not recorded twice
+		if _, ok := modifications[strings.ToLower(key)]; !ok {
+			volumeContext[key] = value
+		}
+	}
+	for key, value := range modifications {
+		volumeContext[key] = value
+	}
+	azVolume.Status.Detail.VolumeContext = volumeContext
 	return azVolume
 }
 
@@ -442,7 +545,11 @@ func (r *ReconcileAzVolume) expandVolume(ctx context.Context, azVolume *azdiskv1
 	}
 	// use deep-copied version of the azVolume CRI to prevent any unwanted update to the object
 	copied := azVolume.DeepCopy()
-	return r.volumeProvisioner.ExpandVolume(ctx, copied.Status.Detail.VolumeID, copied.Spec.CapacityRange, copied.Spec.Secrets)
+	secrets, err := azureutils.GetAzVolumeSecrets(ctx, r.kubeClient, copied)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get secrets for AzVolume (%s): %v", azVolume.Name, err)
+	}
+	return r.volumeProvisioner.ExpandVolume(ctx, copied.Status.Detail.VolumeID, copied.Spec.CapacityRange, secrets)
 }
 
 func (r *ReconcileAzVolume) createVolume(ctx context.Context, azVolume *azdiskv1beta2.AzVolume) (*azdiskv1beta2.AzVolumeStatusDetail, error) {
@@ -451,7 +558,15 @@ func (r *ReconcileAzVolume) createVolume(ctx context.Context, azVolume *azdiskv1
 	}
 	// use deep-copied version of the azVolume CRI to prevent any unwanted update to the object
 	copied := azVolume.DeepCopy()
-	return r.volumeProvisioner.CreateVolume(ctx, copied.Spec.VolumeName, copied.Spec.CapacityRange, copied.Spec.VolumeCapability, copied.Spec.Parameters, copied.Spec.Secrets, copied.Spec.ContentVolumeSource, copied.Spec.AccessibilityRequirements)
+	// the Secret is stored before the AzVolume is created, so the AzVolume becomes its owner only now
+	if err := azureutils.SetSecretsOwner(ctx, r.kubeClient, copied, copied.Spec.SecretRef); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to set owner of secrets for AzVolume (%s): %v", azVolume.Name, err)
+	}
+	secrets, err := azureutils.GetAzVolumeSecrets(ctx, r.kubeClient, copied)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get secrets for AzVolume (%s): %v", azVolume.Name, err)
+	}
+	return r.volumeProvisioner.CreateVolume(ctx, copied.Spec.VolumeName, copied.Spec.CapacityRange, copied.Spec.VolumeCapability, copied.Spec.Parameters, secrets, copied.Spec.ContentVolumeSource, copied.Spec.AccessibilityRequirements)
 }
 
 func (r *ReconcileAzVolume) deleteVolume(ctx context.Context, azVolume *azdiskv1beta2.AzVolume) error {
@@ -460,7 +575,11 @@ func (r *ReconcileAzVolume) deleteVolume(ctx context.Context, azVolume *azdiskv1
 	}
 	// use deep-copied version of the azVolume CRI to prevent any unwanted update to the object
 	copied := azVolume.DeepCopy()
-	return r.volumeProvisioner.DeleteVolume(ctx, copied.Status.Detail.VolumeID, copied.Spec.Secrets)
+	secrets, err := azureutils.GetAzVolumeSecrets(ctx, r.kubeClient, copied)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to get secrets for AzVolume (%s): %v", azVolume.Name, err)
+	}
+	return r.volumeProvisioner.DeleteVolume(ctx, copied.Status.Detail.VolumeID, secrets)
 }
 
 func (r *ReconcileAzVolume) recreateAzVolumes(ctx context.Context) error {
@@ -492,8 +611,8 @@ func (r *ReconcileAzVolume) recoverAzVolume(ctx context.Context, recoveredAzVolu
 	numRecovered := int32(0)
 
 	for _, azVolume := range azVolumes.Items {
-		// skip if AzVolume already recovered
-		if _, ok := recoveredAzVolumes.Load(azVolume.Name); ok {
+		// skip if AzVolume already recovered or is recovered by the owner of its shard
+		if _, ok := recoveredAzVolumes.Load(azVolume.Name); ok || !r.ownsInScope(ctx, azVolume.Name) {
 			numRecovered++
 			continue
 		}
@@ -501,6 +620,17 @@ func (r *ReconcileAzVolume) recoverAzVolume(ctx context.Context, recoveredAzVolu
 		wg.Add(1)
 		go func(azv azdiskv1beta2.AzVolume, azvMap *sync.Map) {
 			defer wg.Done()
+			if len(azv.Spec.Secrets) > 0 {
+				migratedAzVolume, err := r.migrateSecrets(ctx, &azv)
+				if err != nil {
+					w.Logger().Errorf(err, "failed to migrate secrets of AzVolume (%s)", azv.Name)
+					return
+				}
+				azv = *migratedAzVolume
+			} else if err := azureutils.SetSecretsOwner(ctx, r.kubeClient, &azv, azv.Spec.SecretRef); err != nil {
+				w.Logger().Errorf(err, "failed to set owner of secrets for AzVolume (%s)", azv.Name)
+			}
+
 			var targetState azdiskv1beta2.AzVolumeState
 			updateFunc := func(obj client.Object) error {
 				var err error
@@ -544,6 +674,27 @@ func (r *ReconcileAzVolume) recoverAzVolume(ctx context.Context, recoveredAzVolu
 	return nil
 }
 
+// migrateSecrets moves the secrets in the spec of an AzVolume created by an older driver version into a Secret
+// and replaces them with a reference to it.
+func (r *ReconcileAzVolume) migrateSecrets(ctx context.Context, azVolume *azdiskv1beta2.AzVolume) (*azdiskv1beta2.AzVolume, error) {
+	secretRef, err := azureutils.StoreAzVolumeSecrets(ctx, r.kubeClient, azVolume, azVolume.Spec.Secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	updateFunc := func(obj client.Object) error {
+		azv := obj.(*azdiskv1beta2.AzVolume)
+		azv.Spec.Secrets = nil
+		azv.Spec.SecretRef = secretRef
+		return nil
+	}
+	updatedObj, err := azureutils.UpdateCRIWithRetry(ctx, nil, r.cachedClient, r.azClient, azVolume, updateFunc, consts.ForcedUpdateMaxNetRetry, azureutils.UpdateCRI)
+	if err != nil {
+		return nil, err
+	}
+	return updatedObj.(*azdiskv1beta2.AzVolume), nil
+}
+
 func (r *ReconcileAzVolume) Recover(ctx context.Context, recoveryID string) error {
 	var err error
 	ctx, w := workflow.New(ctx)
@@ -590,7 +741,7 @@ func NewAzVolumeController(mgr manager.Manager, volumeProvisioner VolumeProvisio
 	logger.V(2).Info("Starting to watch AzVolume.")
 
 	// Watch for CRUD events on azVolume objects
-	err = c.Watch(&source.Kind{Type: &azdiskv1beta2.AzVolume{}}, &handler.EnqueueRequestForObject{})
+	err = c.Watch(&source.Kind{Type: &azdiskv1beta2.AzVolume{}}, &handler.EnqueueRequestForObject{}, controllerSharedState.shardPredicate(getObjectNameShardKey))
 	if err != nil {
 		logger.Error(err, "failed to initialize watch for AzVolume CRI")
 		return nil, err
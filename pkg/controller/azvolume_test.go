@@ -104,6 +104,10 @@ func mockClientsAndVolumeProvisioner(controller *ReconcileAzVolume) {
 			return azVolumeStatusParams, nil
 		}).
 		MaxTimes(1)
+	controller.volumeProvisioner.(*mockvolumeprovisioner.MockVolumeProvisioner).EXPECT().
+		ModifyVolume(gomock.Any(), testManagedDiskURI0, gomock.Any()).
+		Return(nil).
+		MaxTimes(1)
 }
 
 func TestAzVolumeControllerReconcile(t *testing.T) {
@@ -203,6 +207,121 @@ func TestAzVolumeControllerReconcile(t *testing.T) {
 				require.NoError(t, conditionError)
 			},
 		},
+		{
+			description: "[Success] Should modify a volume when a AzVolume Spec and Status report different parameters.",
+			request:     testAzVolume0Request,
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *ReconcileAzVolume {
+				azVolume := testAzVolume0.DeepCopy()
+
+				azVolume.Spec.Parameters = map[string]string{consts.SkuNameField: "PremiumV2_LRS", consts.DiskIOPSReadWriteField: "5000"}
+				azVolume.Status.Detail = &azdiskv1beta2.AzVolumeStatusDetail{
+					VolumeID:      testManagedDiskURI0,
+					CapacityBytes: azVolume.Spec.CapacityRange.RequiredBytes,
+					VolumeContext: map[string]string{consts.SkuNameField: "Premium_LRS"},
+				}
+				azVolume.Status.State = azdiskv1beta2.VolumeCreated
+
+				controller := NewTestAzVolumeController(
+					mockCtl,
+					testNamespace,
+					azVolume)
+
+				mockClientsAndVolumeProvisioner(controller)
+
+				return controller
+			},
+			verifyFunc: func(t *testing.T, controller *ReconcileAzVolume, result reconcile.Result, err error) {
+				require.NoError(t, err)
+				require.False(t, result.Requeue)
+				conditionFunc := func() (bool, error) {
+					azVolume, localError := controller.azClient.DiskV1beta2().AzVolumes(testAzVolume0.Namespace).Get(context.TODO(), testAzVolume0.Name, metav1.GetOptions{})
+					if localError != nil {
+						return false, nil
+					}
+					return azVolume.Status.State == azdiskv1beta2.VolumeUpdated && len(azureutils.GetModifiedDiskParameters(azVolume.Spec.Parameters, azVolume.Status.Detail.VolumeContext)) == 0, nil
+				}
+				conditionError := wait.PollImmediate(verifyCRIInterval, verifyCRITimeout, conditionFunc)
+				require.NoError(t, conditionError)
+			},
+		},
+		{
+			description: "[Success] Should modify a volume again after a failed modification once its spec changes.",
+			request:     testAzVolume0Request,
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *ReconcileAzVolume {
+				azVolume := testAzVolume0.DeepCopy()
+
+				azVolume.Generation = 2
+				azVolume.Spec.Parameters = map[string]string{consts.SkuNameField: "PremiumV2_LRS"}
+				azVolume.Status.Detail = &azdiskv1beta2.AzVolumeStatusDetail{
+					VolumeID:      testManagedDiskURI0,
+					CapacityBytes: azVolume.Spec.CapacityRange.RequiredBytes,
+					VolumeContext: map[string]string{consts.SkuNameField: "Premium_LRS"},
+				}
+				azVolume.Status.State = azdiskv1beta2.VolumeUpdateFailed
+				azVolume.Status.UpdateGeneration = 1
+				azVolume.Status.Error = &azdiskv1beta2.AzError{Code: azdiskv1beta2.AzErrorCodeInvalidArgument, Message: "unsupported sku transition"}
+
+				controller := NewTestAzVolumeController(
+					mockCtl,
+					testNamespace,
+					azVolume)
+
+				mockClientsAndVolumeProvisioner(controller)
+
+				return controller
+			},
+			verifyFunc: func(t *testing.T, controller *ReconcileAzVolume, result reconcile.Result, err error) {
+				require.NoError(t, err)
+				require.False(t, result.Requeue)
+				conditionFunc := func() (bool, error) {
+					azVolume, localError := controller.azClient.DiskV1beta2().AzVolumes(testAzVolume0.Namespace).Get(context.TODO(), testAzVolume0.Name, metav1.GetOptions{})
+					if localError != nil {
+						return false, nil
+					}
+					return azVolume.Status.State == azdiskv1beta2.VolumeUpdated && azVolume.Status.Detail.VolumeContext[consts.SkuNameField] == "PremiumV2_LRS", nil
+				}
+				conditionError := wait.PollImmediate(verifyCRIInterval, verifyCRITimeout, conditionFunc)
+				require.NoError(t, conditionError)
+			},
+		},
+		{
+			description: "[Success] Should not retry a failed modification until its spec changes.",
+			request:     testAzVolume0Request,
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *ReconcileAzVolume {
+				azVolume := testAzVolume0.DeepCopy()
+
+				azVolume.Generation = 2
+				azVolume.Spec.Parameters = map[string]string{consts.SkuNameField: "UltraSSD_LRS"}
+				azVolume.Status.Detail = &azdiskv1beta2.AzVolumeStatusDetail{
+					VolumeID:      testManagedDiskURI0,
+					CapacityBytes: azVolume.Spec.CapacityRange.RequiredBytes,
+					VolumeContext: map[string]string{consts.SkuNameField: "Premium_LRS"},
+				}
+				azVolume.Status.State = azdiskv1beta2.VolumeUpdateFailed
+				azVolume.Status.UpdateGeneration = 2
+				azVolume.Status.Error = &azdiskv1beta2.AzError{Code: azdiskv1beta2.AzErrorCodeFailedPrecondition, Message: "unsupported sku transition"}
+
+				controller := NewTestAzVolumeController(
+					mockCtl,
+					testNamespace,
+					azVolume)
+
+				mockClients(controller.cachedClient.(*mockclient.MockClient), controller.azClient, controller.kubeClient)
+				controller.volumeProvisioner.(*mockvolumeprovisioner.MockVolumeProvisioner).EXPECT().
+					ModifyVolume(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+
+				return controller
+			},
+			verifyFunc: func(t *testing.T, controller *ReconcileAzVolume, result reconcile.Result, err error) {
+				require.NoError(t, err)
+				require.False(t, result.Requeue)
+				azVolume, err := controller.azClient.DiskV1beta2().AzVolumes(testAzVolume0.Namespace).Get(context.TODO(), testAzVolume0.Name, metav1.GetOptions{})
+				require.NoError(t, err)
+				require.Equal(t, azdiskv1beta2.VolumeUpdateFailed, azVolume.Status.State)
+				require.Equal(t, "Premium_LRS", azVolume.Status.Detail.VolumeContext[consts.SkuNameField])
+			},
+		},
 		{
 			description: "[Success] Should delete a volume when a AzVolume is marked for deletion.",
 			request:     testAzVolume0Request,
@@ -440,6 +559,35 @@ func TestAzVolumeControllerRecover(t *testing.T) {
 				require.Contains(t, azVolume.Status.Annotations, consts.RecoverAnnotation)
 			},
 		},
+		{
+			description: "[Success] Should move the secrets of existing AzVolume CRIs to a Secret",
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *ReconcileAzVolume {
+				azVolume := testAzVolume0.DeepCopy()
+				azVolume.Spec.Secrets = map[string]string{"secret": "value"}
+
+				controller := NewTestAzVolumeController(
+					mockCtl,
+					testNamespace,
+					azVolume)
+
+				mockClientsAndVolumeProvisioner(controller)
+
+				return controller
+			},
+			verifyFunc: func(t *testing.T, controller *ReconcileAzVolume, err error) {
+				require.NoError(t, err)
+
+				azVolume, localErr := controller.azClient.DiskV1beta2().AzVolumes(testNamespace).Get(context.TODO(), testPersistentVolume0Name, metav1.GetOptions{})
+				require.NoError(t, localErr)
+				require.Empty(t, azVolume.Spec.Secrets)
+				require.NotNil(t, azVolume.Spec.SecretRef)
+				require.Contains(t, azVolume.Status.Annotations, consts.RecoverAnnotation)
+
+				secrets, localErr := azureutils.GetAzVolumeSecrets(context.TODO(), controller.kubeClient, azVolume)
+				require.NoError(t, localErr)
+				require.Equal(t, map[string]string{"secret": "value"}, secrets)
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -0,0 +1,190 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	azdiskv1beta2 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils"
+	"sigs.k8s.io/cloud-provider-azure/pkg/provider"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	// AzVolumeDefaultingWebhookPath is the path at which the controller plugin serves the AzVolume defaulting webhook.
+	AzVolumeDefaultingWebhookPath = "/mutate-disk-csi-azure-com-v1beta2-azvolume"
+	// AzVolumeValidatingWebhookPath is the path at which the controller plugin serves the AzVolume validating webhook.
+	AzVolumeValidatingWebhookPath = "/validate-disk-csi-azure-com-v1beta2-azvolume"
+)
+
+// AzVolumeWebhook defaults and validates the spec of AzVolume objects at admission time, so that objects
+// which would fail in triggerCreate are rejected immediately instead of ending up in the CreationFailed state.
+type AzVolumeWebhook struct {
+	cloud *provider.Cloud
+}
+
+var _ admission.CustomDefaulter = &AzVolumeWebhook{}
+var _ admission.CustomValidator = &AzVolumeWebhook{}
+
+// NewAzVolumeWebhook returns the AzVolume admission webhook. The cloud is used to validate the sku name of the
+// volume and may be nil.
+func NewAzVolumeWebhook(cloud *provider.Cloud) *AzVolumeWebhook {
+	return &AzVolumeWebhook{cloud: cloud}
+}
+
+// RegisterAzVolumeWebhook registers the AzVolume defaulting and validating webhooks with the webhook server of the manager.
+func RegisterAzVolumeWebhook(mgr manager.Manager, cloud *provider.Cloud) {
+	wh := NewAzVolumeWebhook(cloud)
+	server := mgr.GetWebhookServer()
+	server.Register(AzVolumeDefaultingWebhookPath, admission.WithCustomDefaulter(&azdiskv1beta2.AzVolume{}, wh))
+	server.Register(AzVolumeValidatingWebhookPath, admission.WithCustomValidator(&azdiskv1beta2.AzVolume{}, wh))
+}
+
+// Default sets the MaxMountReplicaCount of the AzVolume from its parameters and volume capabilities if it is not set.
+func (wh *AzVolumeWebhook) Default(ctx context.Context, obj runtime.Object) error {
+	azVolume, ok := obj.(*azdiskv1beta2.AzVolume)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected an AzVolume but got %T", obj))
+	}
+
+	if azVolume.Spec.MaxMountReplicaCount == 0 {
+		_, azVolume.Spec.MaxMountReplicaCount = azureutils.GetMaxSharesAndMaxMountReplicaCount(azVolume.Spec.Parameters, azureutils.HasMultiNodeAzVolumeCapabilityAccessMode(azVolume.Spec.VolumeCapability))
+	}
+
+	return nil
+}
+
+// ValidateCreate validates the spec of a new AzVolume.
+func (wh *AzVolumeWebhook) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	azVolume, ok := obj.(*azdiskv1beta2.AzVolume)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected an AzVolume but got %T", obj))
+	}
+
+	return wh.validate(azVolume)
+}
+
+// ValidateUpdate validates the spec of an updated AzVolume. Updates which leave the spec unchanged, such as
+// metadata updates, are always allowed so that existing objects can still be reconciled and deleted.
+func (wh *AzVolumeWebhook) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	oldAzVolume, ok := oldObj.(*azdiskv1beta2.AzVolume)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected an AzVolume but got %T", oldObj))
+	}
+	newAzVolume, ok := newObj.(*azdiskv1beta2.AzVolume)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected an AzVolume but got %T", newObj))
+	}
+
+	if reflect.DeepEqual(oldAzVolume.Spec, newAzVolume.Spec) {
+		return nil
+	}
+
+	return wh.validate(newAzVolume)
+}
+
+// ValidateDelete allows all deletions.
+func (wh *AzVolumeWebhook) ValidateDelete(ctx context.Context, obj runtime.Object) error {
+	return nil
+}
+
+func (wh *AzVolumeWebhook) validate(azVolume *azdiskv1beta2.AzVolume) error {
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+	parametersPath := specPath.Child("parameters")
+
+	diskParams, err := azureutils.ParseDiskParameters(azVolume.Spec.Parameters, azureutils.StrictValidation)
+	if err != nil {
+		allErrs = append(allErrs, field.Invalid(parametersPath, azVolume.Spec.Parameters, fmt.Sprintf("failed parsing disk parameters: %v", err)))
+		return apierrors.NewInvalid(azdiskv1beta2.SchemeGroupVersion.WithKind("AzVolume").GroupKind(), azVolume.Name, allErrs)
+	}
+
+	// the disk is not shared unless maxShares is specified
+	maxShares := diskParams.MaxShares
+	if maxShares < 1 {
+		maxShares = 1
+	}
+
+	if wh.cloud != nil {
+		if _, err := azureutils.NormalizeStorageAccountType(diskParams.AccountType, wh.cloud.Config.Cloud, wh.cloud.Config.DisableAzureStackCloud); err != nil {
+			allErrs = append(allErrs, field.Invalid(parametersPath, diskParams.AccountType, err.Error()))
+		}
+	}
+
+	if cachingMode, err := azureutils.NormalizeCachingMode(diskParams.CachingMode, maxShares); err != nil {
+		allErrs = append(allErrs, field.Invalid(parametersPath, diskParams.CachingMode, err.Error()))
+	} else if maxShares > 1 && cachingMode != v1.AzureDataDiskCachingNone {
+		allErrs = append(allErrs, field.Invalid(parametersPath, diskParams.CachingMode, fmt.Sprintf("cachingMode %s is not supported for a shared disk with maxShares %d", cachingMode, maxShares)))
+	}
+
+	if err := azureutils.ValidateDiskEncryptionType(diskParams.DiskEncryptionType); err != nil {
+		allErrs = append(allErrs, field.Invalid(parametersPath, diskParams.DiskEncryptionType, err.Error()))
+	}
+
+	// AzVolumes created for inline volumes have no volume capabilities
+	if !azureutils.IsValidVolumeCapabilities(getCSIVolumeCapabilities(azVolume.Spec.VolumeCapability), maxShares) {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("volumeCapability"), azVolume.Spec.VolumeCapability, fmt.Sprintf("volume capabilities are not supported for a volume with maxShares %d", maxShares)))
+	}
+
+	maxMountReplicaCountPath := specPath.Child("maxMountReplicaCount")
+	switch {
+	case azVolume.Spec.MaxMountReplicaCount < 0:
+		allErrs = append(allErrs, field.Invalid(maxMountReplicaCountPath, azVolume.Spec.MaxMountReplicaCount, "must not be negative"))
+	case azVolume.Spec.MaxMountReplicaCount > 0 && azureutils.HasMultiNodeAzVolumeCapabilityAccessMode(azVolume.Spec.VolumeCapability):
+		allErrs = append(allErrs, field.Invalid(maxMountReplicaCountPath, azVolume.Spec.MaxMountReplicaCount, "must be 0 for a volume that can be mounted to multiple nodes"))
+	case azVolume.Spec.MaxMountReplicaCount > maxShares-1:
+		allErrs = append(allErrs, field.Invalid(maxMountReplicaCountPath, azVolume.Spec.MaxMountReplicaCount, fmt.Sprintf("must not be larger than maxShares - 1 (%d)", maxShares-1)))
+	}
+
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(azdiskv1beta2.SchemeGroupVersion.WithKind("AzVolume").GroupKind(), azVolume.Name, allErrs)
+}
+
+// getCSIVolumeCapabilities converts the volume capabilities of an AzVolume to their CSI equivalents.
+func getCSIVolumeCapabilities(volumeCapabilities []azdiskv1beta2.VolumeCapability) []*csi.VolumeCapability {
+	csiVolumeCapabilities := make([]*csi.VolumeCapability, 0, len(volumeCapabilities))
+	for _, volumeCapability := range volumeCapabilities {
+		csiVolumeCapability := &csi.VolumeCapability{
+			AccessMode: &csi.VolumeCapability_AccessMode{
+				Mode: csi.VolumeCapability_AccessMode_Mode(volumeCapability.AccessMode),
+			},
+		}
+		if volumeCapability.AccessType == azdiskv1beta2.VolumeCapabilityAccessBlock {
+			csiVolumeCapability.AccessType = &csi.VolumeCapability_Block{Block: &csi.VolumeCapability_BlockVolume{}}
+		} else {
+			csiVolumeCapability.AccessType = &csi.VolumeCapability_Mount{
+				Mount: &csi.VolumeCapability_MountVolume{
+					FsType:     volumeCapability.FsType,
+					MountFlags: volumeCapability.MountFlags,
+				},
+			}
+		}
+		csiVolumeCapabilities = append(csiVolumeCapabilities, csiVolumeCapability)
+	}
+	return csiVolumeCapabilities
+}
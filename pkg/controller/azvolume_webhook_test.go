@@ -0,0 +1,193 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	azdiskv1beta2 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
+	"sigs.k8s.io/cloud-provider-azure/pkg/provider"
+)
+
+var (
+	testSingleNodeVolumeCapability = azdiskv1beta2.VolumeCapability{
+		AccessType: azdiskv1beta2.VolumeCapabilityAccessMount,
+		AccessMode: azdiskv1beta2.VolumeCapabilityAccessModeSingleNodeWriter,
+	}
+	testMultiNodeVolumeCapability = azdiskv1beta2.VolumeCapability{
+		AccessType: azdiskv1beta2.VolumeCapabilityAccessBlock,
+		AccessMode: azdiskv1beta2.VolumeCapabilityAccessModeMultiNodeMultiWriter,
+	}
+)
+
+func TestAzVolumeWebhookDefault(t *testing.T) {
+	tests := []struct {
+		description                  string
+		maxMountReplicaCount         int
+		parameters                   map[string]string
+		volumeCapability             azdiskv1beta2.VolumeCapability
+		expectedMaxMountReplicaCount int
+	}{
+		{
+			description:                  "[Success] Should default to maxShares - 1 for a shared disk",
+			parameters:                   map[string]string{"maxShares": "3"},
+			volumeCapability:             testSingleNodeVolumeCapability,
+			expectedMaxMountReplicaCount: 2,
+		},
+		{
+			description:                  "[Success] Should default from the maxMountReplicaCount parameter",
+			parameters:                   map[string]string{"maxShares": "3", "maxMountReplicaCount": "1"},
+			volumeCapability:             testSingleNodeVolumeCapability,
+			expectedMaxMountReplicaCount: 1,
+		},
+		{
+			description:                  "[Success] Should default to 0 for a volume that can be mounted to multiple nodes",
+			parameters:                   map[string]string{"maxShares": "3"},
+			volumeCapability:             testMultiNodeVolumeCapability,
+			expectedMaxMountReplicaCount: 0,
+		},
+		{
+			description:                  "[Success] Should not override an explicit maxMountReplicaCount",
+			maxMountReplicaCount:         1,
+			parameters:                   map[string]string{"maxShares": "3"},
+			volumeCapability:             testSingleNodeVolumeCapability,
+			expectedMaxMountReplicaCount: 1,
+		},
+	}
+
+	for _, test := range tests {
+		tt := test
+		t.Run(tt.description, func(t *testing.T) {
+			azVolume := testAzVolume0.DeepCopy()
+			azVolume.Spec.MaxMountReplicaCount = tt.maxMountReplicaCount
+			azVolume.Spec.Parameters = tt.parameters
+			azVolume.Spec.VolumeCapability = []azdiskv1beta2.VolumeCapability{tt.volumeCapability}
+
+			require.NoError(t, NewAzVolumeWebhook(nil).Default(context.TODO(), azVolume))
+			require.Equal(t, tt.expectedMaxMountReplicaCount, azVolume.Spec.MaxMountReplicaCount)
+		})
+	}
+}
+
+func TestAzVolumeWebhookValidateCreate(t *testing.T) {
+	cloud := &provider.Cloud{}
+
+	tests := []struct {
+		description          string
+		maxMountReplicaCount int
+		parameters           map[string]string
+		volumeCapabilities   []azdiskv1beta2.VolumeCapability
+		expectError          bool
+	}{
+		{
+			description:          "[Success] Should accept a valid shared disk",
+			maxMountReplicaCount: 2,
+			parameters:           map[string]string{"skuName": "Premium_LRS", "maxShares": "3", "cachingMode": "None"},
+			volumeCapabilities:   []azdiskv1beta2.VolumeCapability{testSingleNodeVolumeCapability},
+		},
+		{
+			description: "[Success] Should accept an inline volume without volume capabilities",
+			parameters:  map[string]string{"skuName": "StandardSSD_LRS"},
+		},
+		{
+			description:        "[Failure] Should reject an unknown parameter",
+			parameters:         map[string]string{"unknownParameter": "value"},
+			volumeCapabilities: []azdiskv1beta2.VolumeCapability{testSingleNodeVolumeCapability},
+			expectError:        true,
+		},
+		{
+			description:        "[Failure] Should reject an unknown sku name",
+			parameters:         map[string]string{"skuName": "Unknown_LRS"},
+			volumeCapabilities: []azdiskv1beta2.VolumeCapability{testSingleNodeVolumeCapability},
+			expectError:        true,
+		},
+		{
+			description:        "[Failure] Should reject an invalid maxShares",
+			parameters:         map[string]string{"maxShares": "0"},
+			volumeCapabilities: []azdiskv1beta2.VolumeCapability{testSingleNodeVolumeCapability},
+			expectError:        true,
+		},
+		{
+			description:        "[Failure] Should reject a caching mode incompatible with shared disks",
+			parameters:         map[string]string{"maxShares": "2", "cachingMode": "ReadOnly"},
+			volumeCapabilities: []azdiskv1beta2.VolumeCapability{testSingleNodeVolumeCapability},
+			expectError:        true,
+		},
+		{
+			description:        "[Failure] Should reject an unsupported disk encryption type",
+			parameters:         map[string]string{"diskEncryptionType": "Unknown"},
+			volumeCapabilities: []azdiskv1beta2.VolumeCapability{testSingleNodeVolumeCapability},
+			expectError:        true,
+		},
+		{
+			description:        "[Failure] Should reject a multi-node volume capability for a disk that is not shared",
+			volumeCapabilities: []azdiskv1beta2.VolumeCapability{testMultiNodeVolumeCapability},
+			expectError:        true,
+		},
+		{
+			description:          "[Failure] Should reject a maxMountReplicaCount larger than maxShares - 1",
+			maxMountReplicaCount: 2,
+			parameters:           map[string]string{"maxShares": "2"},
+			volumeCapabilities:   []azdiskv1beta2.VolumeCapability{testSingleNodeVolumeCapability},
+			expectError:          true,
+		},
+		{
+			description:          "[Failure] Should reject replicas for a volume that can be mounted to multiple nodes",
+			maxMountReplicaCount: 1,
+			parameters:           map[string]string{"maxShares": "3"},
+			volumeCapabilities:   []azdiskv1beta2.VolumeCapability{testMultiNodeVolumeCapability},
+			expectError:          true,
+		},
+	}
+
+	for _, test := range tests {
+		tt := test
+		t.Run(tt.description, func(t *testing.T) {
+			azVolume := testAzVolume0.DeepCopy()
+			azVolume.Spec.MaxMountReplicaCount = tt.maxMountReplicaCount
+			azVolume.Spec.Parameters = tt.parameters
+			azVolume.Spec.VolumeCapability = tt.volumeCapabilities
+
+			err := NewAzVolumeWebhook(cloud).ValidateCreate(context.TODO(), azVolume)
+			if tt.expectError {
+				require.Error(t, err)
+				require.True(t, apierrors.IsInvalid(err), "unexpected error: %v", err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAzVolumeWebhookValidateUpdate(t *testing.T) {
+	invalidAzVolume := testAzVolume0.DeepCopy()
+	invalidAzVolume.Spec.Parameters = map[string]string{"unknownParameter": "value"}
+
+	// metadata updates of existing objects are allowed even if their spec is invalid
+	updatedAzVolume := invalidAzVolume.DeepCopy()
+	updatedAzVolume.Finalizers = nil
+	require.NoError(t, NewAzVolumeWebhook(nil).ValidateUpdate(context.TODO(), invalidAzVolume, updatedAzVolume))
+
+	// spec updates are validated
+	validAzVolume := testAzVolume0.DeepCopy()
+	validAzVolume.Spec.MaxMountReplicaCount = 0
+	require.NoError(t, NewAzVolumeWebhook(nil).ValidateUpdate(context.TODO(), invalidAzVolume, validAzVolume))
+	require.Error(t, NewAzVolumeWebhook(nil).ValidateUpdate(context.TODO(), validAzVolume, invalidAzVolume))
+}
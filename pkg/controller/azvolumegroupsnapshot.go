@@ -0,0 +1,655 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	azdiskv1beta2 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils"
+	util "sigs.k8s.io/azuredisk-csi-driver/pkg/util"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/workflow"
+
+	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// filesystemFreezeTimeout is the time given to the nodes to freeze the filesystems of the volumes of a group snapshot.
+	filesystemFreezeTimeout = time.Duration(30) * time.Second
+	// filesystemFreezePollInterval is the interval at which the outcome of the filesystem freeze requests is checked.
+	filesystemFreezePollInterval = time.Duration(500) * time.Millisecond
+)
+
+// GroupSnapshotProvisioner defines the subset of Cloud Provisioner functions used by the AzVolumeGroupSnapshot controller.
+type GroupSnapshotProvisioner interface {
+	CreateGroupSnapshot(ctx context.Context, groupSnapshotName string, sourceVolumeIDs []string, secrets map[string]string, parameters map[string]string) (*azdiskv1beta2.GroupSnapshot, error)
+	GetGroupSnapshot(ctx context.Context, groupSnapshotID string, secrets map[string]string) (*azdiskv1beta2.GroupSnapshot, error)
+	DeleteGroupSnapshot(ctx context.Context, groupSnapshotID string, secrets map[string]string) error
+}
+
+// Struct for the reconciler
+type ReconcileAzVolumeGroupSnapshot struct {
+	*SharedState
+	logger                   logr.Logger
+	groupSnapshotProvisioner GroupSnapshotProvisioner
+	// stateLock prevents concurrent cloud operation for same group snapshot to be executed due to state update race
+	stateLock *sync.Map
+	retryInfo *retryInfo
+}
+
+// Implement reconcile.Reconciler so the controller can reconcile objects
+var _ reconcile.Reconciler = &ReconcileAzVolumeGroupSnapshot{}
+
+var allowedTargetGroupSnapshotStates = map[string][]string{
+	"": {string(azdiskv1beta2.GroupSnapshotOperationPending), string(azdiskv1beta2.GroupSnapshotCreating), string(azdiskv1beta2.GroupSnapshotDeleting)},
+	string(azdiskv1beta2.GroupSnapshotOperationPending): {string(azdiskv1beta2.GroupSnapshotCreating), string(azdiskv1beta2.GroupSnapshotDeleting)},
+	string(azdiskv1beta2.GroupSnapshotCreating):         {string(azdiskv1beta2.GroupSnapshotCreated), string(azdiskv1beta2.GroupSnapshotCreationFailed)},
+	string(azdiskv1beta2.GroupSnapshotDeleting):         {string(azdiskv1beta2.GroupSnapshotDeleted), string(azdiskv1beta2.GroupSnapshotDeletionFailed)},
+	string(azdiskv1beta2.GroupSnapshotCreated):          {string(azdiskv1beta2.GroupSnapshotDeleting)},
+	string(azdiskv1beta2.GroupSnapshotDeleted):          {},
+	string(azdiskv1beta2.GroupSnapshotCreationFailed):   {},
+	string(azdiskv1beta2.GroupSnapshotDeletionFailed):   {},
+}
+
+func (r *ReconcileAzVolumeGroupSnapshot) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	if !r.isRecoveryComplete() {
+		return reconcile.Result{Requeue: true}, nil
+	}
+
+	azVolumeGroupSnapshot, err := azureutils.GetAzVolumeGroupSnapshot(ctx, r.cachedClient, r.azClient, request.Name, request.Namespace, true)
+	if err != nil {
+		// if AzVolumeGroupSnapshot has been deleted, return success
+		if errors.IsNotFound(err) {
+			return reconcileReturnOnSuccess(request.Name, r.retryInfo)
+		}
+
+		// if the GET failure is triggered by other errors, requeue the request
+		azVolumeGroupSnapshot.Name = request.Name
+		return reconcileReturnOnError(ctx, azVolumeGroupSnapshot, "get", err, r.retryInfo)
+	}
+
+	// skip requests queued before the shard of the group snapshot was handed off to another controller
+	if !r.shardManager.Owns(azVolumeGroupSnapshot.Name) {
+		return reconcileReturnOnSuccess(azVolumeGroupSnapshot.Name, r.retryInfo)
+	}
+
+	ctx, _ = workflow.GetWorkflowFromObj(ctx, azVolumeGroupSnapshot)
+
+	// if underlying cloud operation already in process, skip until operation is completed
+	if isOperationInProcess(azVolumeGroupSnapshot) {
+		return reconcileReturnOnSuccess(azVolumeGroupSnapshot.Name, r.retryInfo)
+	}
+
+	// azVolumeGroupSnapshot deletion
+	if deleteRequested, deleteAfter := objectDeletionRequested(azVolumeGroupSnapshot); deleteRequested {
+		if deleteAfter > 0 {
+			return reconcileAfter(deleteAfter, request.Name, r.retryInfo)
+		}
+		if err := r.triggerDelete(ctx, azVolumeGroupSnapshot); err != nil {
+			//If delete failed, requeue request
+			return reconcileReturnOnError(ctx, azVolumeGroupSnapshot, "delete", err, r.retryInfo)
+		}
+		//azVolumeGroupSnapshot creation
+	} else if azVolumeGroupSnapshot.Status.Detail == nil {
+		if err := r.triggerCreate(ctx, azVolumeGroupSnapshot); err != nil {
+			return reconcileReturnOnError(ctx, azVolumeGroupSnapshot, "create", err, r.retryInfo)
+		}
+		// azVolumeGroupSnapshot readiness refresh
+	} else if !azVolumeGroupSnapshot.Status.Detail.ReadyToUse {
+		if err := r.triggerRefresh(ctx, azVolumeGroupSnapshot); err != nil {
+			return reconcileReturnOnError(ctx, azVolumeGroupSnapshot, "refresh", err, r.retryInfo)
+		}
+		return reconcileAfter(snapshotReadinessPollInterval, azVolumeGroupSnapshot.Name, r.retryInfo)
+	}
+
+	return reconcileReturnOnSuccess(azVolumeGroupSnapshot.Name, r.retryInfo)
+}
+
+func (r *ReconcileAzVolumeGroupSnapshot) triggerCreate(ctx context.Context, azVolumeGroupSnapshot *azdiskv1beta2.AzVolumeGroupSnapshot) error {
+	var err error
+	ctx, w := workflow.New(ctx)
+	defer func() { w.Finish(err) }()
+
+	// requeue if AzVolumeGroupSnapshot's state is being updated by a different worker
+	if _, ok := r.stateLock.LoadOrStore(azVolumeGroupSnapshot.Name, nil); ok {
+		err = getOperationRequeueError("create", azVolumeGroupSnapshot)
+		return err
+	}
+	defer r.stateLock.Delete(azVolumeGroupSnapshot.Name)
+
+	// update state
+	updateFunc := func(obj client.Object) error {
+		azvgs := obj.(*azdiskv1beta2.AzVolumeGroupSnapshot)
+		_, err := r.updateState(azvgs, azdiskv1beta2.GroupSnapshotCreating, normalUpdate)
+		return err
+	}
+
+	var updatedObj client.Object
+	if updatedObj, err = azureutils.UpdateCRIWithRetry(ctx, nil, r.cachedClient, r.azClient, azVolumeGroupSnapshot, updateFunc, consts.NormalUpdateMaxNetRetry, azureutils.UpdateCRIStatus); err != nil {
+		return err
+	}
+	azVolumeGroupSnapshot = updatedObj.(*azdiskv1beta2.AzVolumeGroupSnapshot)
+
+	w.Logger().V(5).Info("Creating Group Snapshot...")
+	waitCh := make(chan goSignal)
+	// create group snapshot
+	//nolint:contextcheck // call is asynchronous; context is not inherited by design
+	go func() {
+		var createErr error
+		_, goWorkflow := workflow.New(ctx)
+		defer func() { goWorkflow.Finish(createErr) }()
+		waitCh <- goSignal{}
+
+		goCtx := goWorkflow.SaveToContext(context.Background())
+
+		var updateFunc azureutils.UpdateCRIFunc
+		var response *azdiskv1beta2.GroupSnapshot
+		response, createErr = r.createGroupSnapshot(goCtx, azVolumeGroupSnapshot)
+		updateMode := azureutils.UpdateCRIStatus
+		if createErr != nil {
+			updateFunc = func(obj client.Object) error {
+				azvgs := obj.(*azdiskv1beta2.AzVolumeGroupSnapshot)
+				azvgs = r.deleteFinalizer(azvgs, map[string]bool{consts.AzVolumeGroupSnapshotFinalizer: true})
+				_, derr := r.reportError(azvgs, azdiskv1beta2.GroupSnapshotCreationFailed, createErr)
+				return derr
+			}
+			updateMode = azureutils.UpdateAll
+		} else {
+			updateFunc = func(obj client.Object) error {
+				azvgs := obj.(*azdiskv1beta2.AzVolumeGroupSnapshot)
+				if response == nil {
+					return status.Errorf(codes.Internal, "non-nil GroupSnapshot expected but nil given")
+				}
+				azvgs = r.updateStatusDetail(azvgs, response)
+				_, derr := r.updateState(azvgs, azdiskv1beta2.GroupSnapshotCreated, forceUpdate)
+				return derr
+			}
+		}
+
+		//nolint:contextcheck // final status update of the CRI must occur even when the current context's deadline passes.
+		_, _ = azureutils.UpdateCRIWithRetry(goCtx, nil, r.cachedClient, r.azClient, azVolumeGroupSnapshot, updateFunc, consts.ForcedUpdateMaxNetRetry, updateMode)
+	}()
+
+	// wait for the workflow in goroutine to be created
+	<-waitCh
+	return nil
+}
+
+func (r *ReconcileAzVolumeGroupSnapshot) triggerDelete(ctx context.Context, azVolumeGroupSnapshot *azdiskv1beta2.AzVolumeGroupSnapshot) error {
+	var err error
+	ctx, w := workflow.New(ctx)
+	defer func() { w.Finish(err) }()
+
+	// requeue if AzVolumeGroupSnapshot's state is being updated by a different worker
+	if _, ok := r.stateLock.LoadOrStore(azVolumeGroupSnapshot.Name, nil); ok {
+		err = getOperationRequeueError("delete", azVolumeGroupSnapshot)
+		return err
+	}
+	defer r.stateLock.Delete(azVolumeGroupSnapshot.Name)
+
+	updateFunc := func(obj client.Object) error {
+		azvgs := obj.(*azdiskv1beta2.AzVolumeGroupSnapshot)
+		_, derr := r.updateState(azvgs, azdiskv1beta2.GroupSnapshotDeleting, normalUpdate)
+		return derr
+	}
+	var updatedObj client.Object
+	if updatedObj, err = azureutils.UpdateCRIWithRetry(ctx, nil, r.cachedClient, r.azClient, azVolumeGroupSnapshot, updateFunc, consts.NormalUpdateMaxNetRetry, azureutils.UpdateCRIStatus); err != nil {
+		return err
+	}
+	azVolumeGroupSnapshot = updatedObj.(*azdiskv1beta2.AzVolumeGroupSnapshot)
+
+	w.Logger().V(5).Info("Deleting Group Snapshot...")
+	waitCh := make(chan goSignal)
+	//nolint:contextcheck // call is asynchronous; context is not inherited by design
+	go func() {
+		var deleteErr error
+		_, goWorkflow := workflow.New(ctx)
+		defer func() { goWorkflow.Finish(deleteErr) }()
+		waitCh <- goSignal{}
+
+		goCtx := goWorkflow.SaveToContext(context.Background())
+
+		var updateFunc azureutils.UpdateCRIFunc
+		updateMode := azureutils.UpdateCRI
+
+		if r.driverLifecycle.IsDriverUninstall() {
+			goWorkflow.Logger().V(12).Infof("trying to delete group snapshot (%s) as the driver is uninstalling, deletion will be ignored", azVolumeGroupSnapshot.Name)
+		} else {
+			cloudCtx, cloudCancel := context.WithTimeout(goCtx, cloudTimeout)
+			defer cloudCancel()
+
+			deleteErr = r.deleteGroupSnapshot(cloudCtx, azVolumeGroupSnapshot)
+		}
+
+		if deleteErr != nil {
+			updateMode = azureutils.UpdateCRIStatus
+			updateFunc = func(obj client.Object) error {
+				azvgs := obj.(*azdiskv1beta2.AzVolumeGroupSnapshot)
+				_, derr := r.reportError(azvgs, azdiskv1beta2.GroupSnapshotDeletionFailed, deleteErr)
+				return derr
+			}
+		} else {
+			// the secrets are no longer needed once the AzVolumeGroupSnapshot is gone
+			if derr := azureutils.DeleteAzVolumeGroupSnapshotSecrets(goCtx, r.kubeClient, azVolumeGroupSnapshot); derr != nil {
+				goWorkflow.Logger().Errorf(derr, "failed to delete secrets for AzVolumeGroupSnapshot (%s)", azVolumeGroupSnapshot.Name)
+			}
+			// if the group snapshot was deleted, delete the finalizer
+			updateFunc = func(obj client.Object) error {
+				azvgs := obj.(*azdiskv1beta2.AzVolumeGroupSnapshot)
+				_ = r.deleteFinalizer(azvgs, map[string]bool{consts.AzVolumeGroupSnapshotFinalizer: true})
+				return nil
+			}
+		}
+
+		//nolint:contextcheck // final status update of the CRI must occur even when the current context's deadline passes.
+		_, _ = azureutils.UpdateCRIWithRetry(goCtx, nil, r.cachedClient, r.azClient, azVolumeGroupSnapshot, updateFunc, consts.ForcedUpdateMaxNetRetry, updateMode)
+	}()
+	<-waitCh
+	return nil
+}
+
+func (r *ReconcileAzVolumeGroupSnapshot) triggerRefresh(ctx context.Context, azVolumeGroupSnapshot *azdiskv1beta2.AzVolumeGroupSnapshot) error {
+	var err error
+	ctx, w := workflow.New(ctx)
+	defer func() { w.Finish(err) }()
+
+	// requeue if AzVolumeGroupSnapshot is being refreshed or its state is being updated by a different worker
+	if _, ok := r.stateLock.LoadOrStore(azVolumeGroupSnapshot.Name, nil); ok {
+		err = getOperationRequeueError("refresh", azVolumeGroupSnapshot)
+		return err
+	}
+
+	w.Logger().V(5).Info("Refreshing Group Snapshot...")
+	waitCh := make(chan goSignal)
+	//nolint:contextcheck // call is asynchronous; context is not inherited by design
+	go func() {
+		// the state is not changed by a refresh, so the lock is held until the refresh completes
+		defer r.stateLock.Delete(azVolumeGroupSnapshot.Name)
+
+		var refreshErr error
+		_, goWorkflow := workflow.New(ctx)
+		defer func() { goWorkflow.Finish(refreshErr) }()
+		waitCh <- goSignal{}
+
+		goCtx := goWorkflow.SaveToContext(context.Background())
+		cloudCtx, cloudCancel := context.WithTimeout(goCtx, cloudTimeout)
+		defer cloudCancel()
+
+		var response *azdiskv1beta2.GroupSnapshot
+		if response, refreshErr = r.getGroupSnapshot(cloudCtx, azVolumeGroupSnapshot); refreshErr != nil || response == nil {
+			return
+		}
+
+		updateFunc := func(obj client.Object) error {
+			azvgs := obj.(*azdiskv1beta2.AzVolumeGroupSnapshot)
+			_ = r.updateStatusDetail(azvgs, response)
+			return nil
+		}
+
+		//nolint:contextcheck // final status update of the CRI must occur even when the current context's deadline passes.
+		_, refreshErr = azureutils.UpdateCRIWithRetry(goCtx, nil, r.cachedClient, r.azClient, azVolumeGroupSnapshot, updateFunc, consts.NormalUpdateMaxNetRetry, azureutils.UpdateCRIStatus)
+	}()
+	<-waitCh
+	return nil
+}
+
+func (r *ReconcileAzVolumeGroupSnapshot) deleteFinalizer(azVolumeGroupSnapshot *azdiskv1beta2.AzVolumeGroupSnapshot, finalizersToDelete map[string]bool) *azdiskv1beta2.AzVolumeGroupSnapshot {
+	if azVolumeGroupSnapshot == nil {
+		return nil
+	}
+
+	if azVolumeGroupSnapshot.ObjectMeta.Finalizers == nil {
+		return azVolumeGroupSnapshot
+	}
+
+	finalizers := []string{}
+	for _, finalizer := range azVolumeGroupSnapshot.ObjectMeta.Finalizers {
+		if exists := finalizersToDelete[finalizer]; exists {
+			continue
+		}
+		finalizers = append(finalizers, finalizer)
+	}
+	azVolumeGroupSnapshot.ObjectMeta.Finalizers = finalizers
+	return azVolumeGroupSnapshot
+}
+
+func (r *ReconcileAzVolumeGroupSnapshot) reportError(azVolumeGroupSnapshot *azdiskv1beta2.AzVolumeGroupSnapshot, state azdiskv1beta2.AzVolumeGroupSnapshotState, err error) (*azdiskv1beta2.AzVolumeGroupSnapshot, error) {
+	if azVolumeGroupSnapshot == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "function `reportError` requires non-nil AzVolumeGroupSnapshot object.")
+	}
+	azVolumeGroupSnapshot = r.updateError(azVolumeGroupSnapshot, err)
+	return r.updateState(azVolumeGroupSnapshot, state, forceUpdate)
+}
+
+func (r *ReconcileAzVolumeGroupSnapshot) updateState(azVolumeGroupSnapshot *azdiskv1beta2.AzVolumeGroupSnapshot, state azdiskv1beta2.AzVolumeGroupSnapshotState, mode updateMode) (*azdiskv1beta2.AzVolumeGroupSnapshot, error) {
+	var err error
+	if azVolumeGroupSnapshot == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "function `updateState` requires non-nil AzVolumeGroupSnapshot object.")
+	}
+	if mode == normalUpdate {
+		if expectedStates, exists := allowedTargetGroupSnapshotStates[string(azVolumeGroupSnapshot.Status.State)]; !exists || !containsString(string(state), expectedStates) {
+			err = status.Error(codes.FailedPrecondition, formatUpdateStateError("azVolumeGroupSnapshot", string(azVolumeGroupSnapshot.Status.State), string(state), expectedStates...))
+		}
+	}
+	if err == nil {
+		azVolumeGroupSnapshot.Status.State = state
+	}
+	return azVolumeGroupSnapshot, err
+}
+
+func (r *ReconcileAzVolumeGroupSnapshot) updateStatusDetail(azVolumeGroupSnapshot *azdiskv1beta2.AzVolumeGroupSnapshot, detail *azdiskv1beta2.GroupSnapshot) *azdiskv1beta2.AzVolumeGroupSnapshot {
+	if azVolumeGroupSnapshot == nil {
+		return nil
+	}
+	azVolumeGroupSnapshot.Status.Detail = detail.DeepCopy()
+	return azVolumeGroupSnapshot
+}
+
+func (r *ReconcileAzVolumeGroupSnapshot) updateError(azVolumeGroupSnapshot *azdiskv1beta2.AzVolumeGroupSnapshot, err error) *azdiskv1beta2.AzVolumeGroupSnapshot {
+	if azVolumeGroupSnapshot == nil {
+		return nil
+	}
+	azVolumeGroupSnapshot.Status.Error = util.NewAzError(err)
+	return azVolumeGroupSnapshot
+}
+
+func (r *ReconcileAzVolumeGroupSnapshot) createGroupSnapshot(ctx context.Context, azVolumeGroupSnapshot *azdiskv1beta2.AzVolumeGroupSnapshot) (*azdiskv1beta2.GroupSnapshot, error) {
+	if azVolumeGroupSnapshot.Status.Detail != nil {
+		return azVolumeGroupSnapshot.Status.Detail, nil
+	}
+	// use deep-copied version of the azVolumeGroupSnapshot CRI to prevent any unwanted update to the object
+	copied := azVolumeGroupSnapshot.DeepCopy()
+	// the Secret is stored before the AzVolumeGroupSnapshot is created, so the AzVolumeGroupSnapshot becomes its owner only now
+	if err := azureutils.SetSecretsOwner(ctx, r.kubeClient, copied, copied.Spec.SecretRef); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to set owner of secrets for AzVolumeGroupSnapshot (%s): %v", azVolumeGroupSnapshot.Name, err)
+	}
+	secrets, err := azureutils.GetAzVolumeGroupSnapshotSecrets(ctx, r.kubeClient, copied)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get secrets for AzVolumeGroupSnapshot (%s): %v", azVolumeGroupSnapshot.Name, err)
+	}
+
+	freezeFilesystems, err := isFilesystemFreezeRequested(copied.Spec.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	if freezeFilesystems {
+		frozenAttachments, err := r.freezeFilesystems(ctx, copied)
+		// the filesystems already frozen are thawed even if some of them could not be frozen
+		defer r.thawFilesystems(ctx, copied, frozenAttachments)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	cloudCtx, cloudCancel := context.WithTimeout(ctx, cloudTimeout)
+	defer cloudCancel()
+	return r.groupSnapshotProvisioner.CreateGroupSnapshot(cloudCtx, copied.Spec.GroupSnapshotName, copied.Spec.SourceVolumeIDs, secrets, copied.Spec.Parameters)
+}
+
+func (r *ReconcileAzVolumeGroupSnapshot) getGroupSnapshot(ctx context.Context, azVolumeGroupSnapshot *azdiskv1beta2.AzVolumeGroupSnapshot) (*azdiskv1beta2.GroupSnapshot, error) {
+	if azVolumeGroupSnapshot.Status.Detail == nil {
+		return nil, status.Errorf(codes.Internal, "Group Snapshot for refresh does not exist for AzVolumeGroupSnapshot (%s).", azVolumeGroupSnapshot.Name)
+	}
+	// use deep-copied version of the azVolumeGroupSnapshot CRI to prevent any unwanted update to the object
+	copied := azVolumeGroupSnapshot.DeepCopy()
+	secrets, err := azureutils.GetAzVolumeGroupSnapshotSecrets(ctx, r.kubeClient, copied)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get secrets for AzVolumeGroupSnapshot (%s): %v", azVolumeGroupSnapshot.Name, err)
+	}
+	return r.groupSnapshotProvisioner.GetGroupSnapshot(ctx, copied.Status.Detail.GroupSnapshotID, secrets)
+}
+
+func (r *ReconcileAzVolumeGroupSnapshot) deleteGroupSnapshot(ctx context.Context, azVolumeGroupSnapshot *azdiskv1beta2.AzVolumeGroupSnapshot) error {
+	if azVolumeGroupSnapshot.Status.Detail == nil {
+		return nil
+	}
+	// use deep-copied version of the azVolumeGroupSnapshot CRI to prevent any unwanted update to the object
+	copied := azVolumeGroupSnapshot.DeepCopy()
+	secrets, err := azureutils.GetAzVolumeGroupSnapshotSecrets(ctx, r.kubeClient, copied)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to get secrets for AzVolumeGroupSnapshot (%s): %v", azVolumeGroupSnapshot.Name, err)
+	}
+	return r.groupSnapshotProvisioner.DeleteGroupSnapshot(ctx, copied.Status.Detail.GroupSnapshotID, secrets)
+}
+
+// isFilesystemFreezeRequested returns true if the parameters of a group snapshot request the filesystems of its volumes to be frozen.
+func isFilesystemFreezeRequested(parameters map[string]string) (bool, error) {
+	for k, v := range parameters {
+		if strings.EqualFold(k, consts.FreezeFilesystemsField) {
+			freeze, err := strconv.ParseBool(v)
+			if err != nil {
+				return false, status.Errorf(codes.InvalidArgument, "invalid value %s for %s parameter: %v", v, consts.FreezeFilesystemsField, err)
+			}
+			return freeze, nil
+		}
+	}
+	return false, nil
+}
+
+// freezeFilesystems asks the nodes to which the source volumes of the group snapshot are attached to freeze the filesystems
+// of the volumes and waits until all of them are frozen. It returns the AzVolumeAttachments for which a freeze was requested
+// so that the filesystems can be thawed once the snapshots have been taken. Volumes which are not attached are not frozen.
+func (r *ReconcileAzVolumeGroupSnapshot) freezeFilesystems(ctx context.Context, azVolumeGroupSnapshot *azdiskv1beta2.AzVolumeGroupSnapshot) ([]*azdiskv1beta2.AzVolumeAttachment, error) {
+	w, _ := workflow.GetWorkflowFromContext(ctx)
+
+	attachments := []*azdiskv1beta2.AzVolumeAttachment{}
+	for _, sourceVolumeID := range azVolumeGroupSnapshot.Spec.SourceVolumeIDs {
+		diskName, err := azureutils.GetDiskName(sourceVolumeID)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		volumeAttachments, err := azureutils.GetAzVolumeAttachmentsForVolume(ctx, r.cachedClient, strings.ToLower(diskName), azureutils.PrimaryOnly)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to get AzVolumeAttachments of volume (%s): %v", sourceVolumeID, err)
+		}
+		for i := range volumeAttachments {
+			if volumeAttachments[i].Status.State == azdiskv1beta2.Attached {
+				attachments = append(attachments, &volumeAttachments[i])
+			}
+		}
+	}
+
+	requested := make([]*azdiskv1beta2.AzVolumeAttachment, 0, len(attachments))
+	for _, attachment := range attachments {
+		updateFunc := func(obj client.Object) error {
+			azva := obj.(*azdiskv1beta2.AzVolumeAttachment)
+			// the outcome of an earlier request must not be mistaken for that of this one
+			azva.Status.Annotations = azureutils.RemoveFromMap(azva.Status.Annotations, consts.FilesystemFrozenAnnotation, consts.FilesystemFreezeErrorAnnotation)
+			azva.Status.Annotations = azureutils.AddToMap(azva.Status.Annotations, consts.FilesystemFreezeRequestAnnotation, azVolumeGroupSnapshot.Name)
+			return nil
+		}
+		updatedObj, err := azureutils.UpdateCRIWithRetry(ctx, nil, r.cachedClient, r.azClient, attachment, updateFunc, consts.NormalUpdateMaxNetRetry, azureutils.UpdateCRIStatus)
+		if err != nil {
+			return requested, err
+		}
+		requested = append(requested, updatedObj.(*azdiskv1beta2.AzVolumeAttachment))
+	}
+
+	w.Logger().V(5).Infof("Waiting for the filesystems of %d volume attachments to be frozen", len(requested))
+	conditionFunc := func() (bool, error) {
+		for _, attachment := range requested {
+			azVolumeAttachment := &azdiskv1beta2.AzVolumeAttachment{}
+			if err := r.cachedClient.Get(ctx, types.NamespacedName{Namespace: attachment.Namespace, Name: attachment.Name}, azVolumeAttachment); err != nil {
+				return false, err
+			}
+			if freezeErr, ok := azVolumeAttachment.Status.Annotations[consts.FilesystemFreezeErrorAnnotation]; ok {
+				return false, status.Errorf(codes.Internal, "failed to freeze the filesystem of volume (%s) on node (%s): %s", azVolumeAttachment.Spec.VolumeID, azVolumeAttachment.Spec.NodeName, freezeErr)
+			}
+			if azVolumeAttachment.Status.Annotations[consts.FilesystemFrozenAnnotation] != azVolumeGroupSnapshot.Name {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+	if err := wait.PollImmediate(filesystemFreezePollInterval, filesystemFreezeTimeout, conditionFunc); err != nil {
+		if err == wait.ErrWaitTimeout {
+			err = status.Errorf(codes.DeadlineExceeded, "timed out after %v waiting for the filesystems of the volumes of group snapshot (%s) to be frozen", filesystemFreezeTimeout, azVolumeGroupSnapshot.Spec.GroupSnapshotName)
+		}
+		return requested, err
+	}
+	return requested, nil
+}
+
+// thawFilesystems withdraws the filesystem freeze requests of the group snapshot so that the nodes thaw the filesystems.
+func (r *ReconcileAzVolumeGroupSnapshot) thawFilesystems(ctx context.Context, azVolumeGroupSnapshot *azdiskv1beta2.AzVolumeGroupSnapshot, attachments []*azdiskv1beta2.AzVolumeAttachment) {
+	w, _ := workflow.GetWorkflowFromContext(ctx)
+
+	for _, attachment := range attachments {
+		updateFunc := func(obj client.Object) error {
+			azva := obj.(*azdiskv1beta2.AzVolumeAttachment)
+			if azva.Status.Annotations[consts.FilesystemFreezeRequestAnnotation] == azVolumeGroupSnapshot.Name {
+				azva.Status.Annotations = azureutils.RemoveFromMap(azva.Status.Annotations, consts.FilesystemFreezeRequestAnnotation)
+			}
+			return nil
+		}
+		if _, err := azureutils.UpdateCRIWithRetry(ctx, nil, r.cachedClient, r.azClient, attachment, updateFunc, consts.ForcedUpdateMaxNetRetry, azureutils.UpdateCRIStatus); err != nil && !errors.IsNotFound(err) {
+			w.Logger().Errorf(err, "failed to request the filesystem of volume (%s) to be thawed on node (%s)", attachment.Spec.VolumeID, attachment.Spec.NodeName)
+		}
+	}
+}
+
+func (r *ReconcileAzVolumeGroupSnapshot) recoverAzVolumeGroupSnapshot(ctx context.Context, recoveredAzVolumeGroupSnapshots *sync.Map, recoveryID string) error {
+	w, _ := workflow.GetWorkflowFromContext(ctx)
+	// list all AzVolumeGroupSnapshots
+	azVolumeGroupSnapshots, err := r.azClient.DiskV1beta2().AzVolumeGroupSnapshots(r.config.ObjectNamespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		w.Logger().Error(err, "failed to get list of existing AzVolumeGroupSnapshot CRI in controller recovery stage")
+		return err
+	}
+
+	var wg sync.WaitGroup
+	numRecovered := int32(0)
+
+	for _, azVolumeGroupSnapshot := range azVolumeGroupSnapshots.Items {
+		// skip if AzVolumeGroupSnapshot already recovered or is recovered by the owner of its shard
+		if _, ok := recoveredAzVolumeGroupSnapshots.Load(azVolumeGroupSnapshot.Name); ok || !r.ownsInScope(ctx, azVolumeGroupSnapshot.Name) {
+			numRecovered++
+			continue
+		}
+
+		wg.Add(1)
+		go func(azvgs azdiskv1beta2.AzVolumeGroupSnapshot, azvgsMap *sync.Map) {
+			defer wg.Done()
+			var targetState azdiskv1beta2.AzVolumeGroupSnapshotState
+			updateFunc := func(obj client.Object) error {
+				var err error
+				azvgs := obj.(*azdiskv1beta2.AzVolumeGroupSnapshot)
+				// add a recover annotation to the CRI so that reconciliation can be triggered for the CRI even if CRI's current state == target state
+				azvgs.Status.Annotations = azureutils.AddToMap(azvgs.Status.Annotations, consts.RecoverAnnotation, recoveryID)
+				if azvgs.Status.State != targetState {
+					_, err = r.updateState(azvgs, targetState, forceUpdate)
+				}
+				return err
+			}
+			switch azvgs.Status.State {
+			case azdiskv1beta2.GroupSnapshotCreating:
+				// reset state to Pending so Create operation can be redone
+				targetState = azdiskv1beta2.GroupSnapshotOperationPending
+			case azdiskv1beta2.GroupSnapshotDeleting:
+				// reset state to Created so Delete operation can be redone
+				targetState = azdiskv1beta2.GroupSnapshotCreated
+			default:
+				targetState = azvgs.Status.State
+			}
+
+			if _, err := azureutils.UpdateCRIWithRetry(ctx, nil, r.cachedClient, r.azClient, &azvgs, updateFunc, consts.ForcedUpdateMaxNetRetry, azureutils.UpdateCRIStatus); err != nil {
+				w.Logger().Errorf(err, "failed to update AzVolumeGroupSnapshot (%s) for recovery", azvgs.Name)
+			} else {
+				// if update succeeded, add the CRI to the recoveryComplete list
+				azvgsMap.Store(azvgs.Name, struct{}{})
+				atomic.AddInt32(&numRecovered, 1)
+			}
+		}(azVolumeGroupSnapshot, recoveredAzVolumeGroupSnapshots)
+	}
+	wg.Wait()
+
+	// if recovery has not been completed for all CRIs, return error
+	if numRecovered < int32(len(azVolumeGroupSnapshots.Items)) {
+		return status.Errorf(codes.Internal, "failed to recover some AzVolumeGroupSnapshot states")
+	}
+	return nil
+}
+
+func (r *ReconcileAzVolumeGroupSnapshot) Recover(ctx context.Context, recoveryID string) error {
+	var err error
+	ctx, w := workflow.New(ctx)
+	defer func() { w.Finish(err) }()
+
+	w.Logger().V(5).Info("Recovering AzVolumeGroupSnapshot CRIs...")
+	recovered := &sync.Map{}
+	for i := 0; i < maxRetry; i++ {
+		if err = r.recoverAzVolumeGroupSnapshot(ctx, recovered, recoveryID); err == nil {
+			break
+		}
+		w.Logger().Error(err, "failed to recover AzVolumeGroupSnapshot state")
+	}
+	return err
+}
+
+func NewAzVolumeGroupSnapshotController(mgr manager.Manager, groupSnapshotProvisioner GroupSnapshotProvisioner, controllerSharedState *SharedState) (*ReconcileAzVolumeGroupSnapshot, error) {
+	logger := mgr.GetLogger().WithValues("controller", "azvolumegroupsnapshot")
+
+	reconciler := ReconcileAzVolumeGroupSnapshot{
+		groupSnapshotProvisioner: groupSnapshotProvisioner,
+		stateLock:                &sync.Map{},
+		retryInfo:                newRetryInfo(),
+		SharedState:              controllerSharedState,
+		logger:                   logger,
+	}
+
+	c, err := controller.New("azvolumegroupsnapshot-controller", mgr, controller.Options{
+		MaxConcurrentReconciles: controllerSharedState.config.ControllerConfig.WorkerThreads,
+		Reconciler:              &reconciler,
+		LogConstructor:          func(req *reconcile.Request) logr.Logger { return logger },
+	})
+
+	if err != nil {
+		logger.Error(err, "failed to create controller")
+		return nil, err
+	}
+
+	logger.V(2).Info("Starting to watch AzVolumeGroupSnapshot.")
+
+	// Watch for CRUD events on azVolumeGroupSnapshot objects
+	err = c.Watch(&source.Kind{Type: &azdiskv1beta2.AzVolumeGroupSnapshot{}}, &handler.EnqueueRequestForObject{}, controllerSharedState.shardPredicate(getObjectNameShardKey))
+	if err != nil {
+		logger.Error(err, "failed to initialize watch for AzVolumeGroupSnapshot CRI")
+		return nil, err
+	}
+
+	logger.V(2).Info("Controller set-up successful.")
+
+	return &reconciler, nil
+}
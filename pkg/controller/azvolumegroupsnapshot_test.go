@@ -0,0 +1,394 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	fakev1 "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/klog/v2/klogr"
+	azdiskv1beta2 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
+	azdiskfakes "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/client/clientset/versioned/fake"
+	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils/mockclient"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/controller/mockgroupsnapshotprovisioner"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	testGroupSnapshot0Name = "test-group-snapshot-0"
+)
+
+var (
+	testGroupSnapshot0ID = azureutils.GetGroupSnapshotID(testSubscription, testResourceGroup, testGroupSnapshot0Name)
+
+	testAzVolumeGroupSnapshot0 = createTestAzVolumeGroupSnapshot(testGroupSnapshot0Name, testManagedDiskURI0)
+
+	testAzVolumeGroupSnapshot0Request = createReconcileRequest(testNamespace, testGroupSnapshot0Name)
+)
+
+func createTestAzVolumeGroupSnapshot(groupSnapshotName string, sourceVolumeIDs ...string) azdiskv1beta2.AzVolumeGroupSnapshot {
+	azVolumeGroupSnapshot := azdiskv1beta2.AzVolumeGroupSnapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      groupSnapshotName,
+			Namespace: testNamespace,
+		},
+		Spec: azdiskv1beta2.AzVolumeGroupSnapshotSpec{
+			GroupSnapshotName: groupSnapshotName,
+			SourceVolumeIDs:   sourceVolumeIDs,
+		},
+	}
+	azureutils.AnnotateAPIVersion(&azVolumeGroupSnapshot)
+	return azVolumeGroupSnapshot
+}
+
+func NewTestAzVolumeGroupSnapshotController(controller *gomock.Controller, namespace string, objects ...runtime.Object) *ReconcileAzVolumeGroupSnapshot {
+	azDiskObjs, kubeObjs := splitObjects(objects...)
+	controllerSharedState := initState(mockclient.NewMockClient(controller), azdiskfakes.NewSimpleClientset(azDiskObjs...), fakev1.NewSimpleClientset(kubeObjs...), objects...)
+
+	return &ReconcileAzVolumeGroupSnapshot{
+		groupSnapshotProvisioner: mockgroupsnapshotprovisioner.NewMockGroupSnapshotProvisioner(controller),
+		stateLock:                &sync.Map{},
+		retryInfo:                newRetryInfo(),
+		SharedState:              controllerSharedState,
+		logger:                   klogr.New(),
+	}
+}
+
+func newTestGroupSnapshot(readyToUse bool) *azdiskv1beta2.GroupSnapshot {
+	return &azdiskv1beta2.GroupSnapshot{
+		GroupSnapshotID: testGroupSnapshot0ID,
+		Snapshots: []azdiskv1beta2.Snapshot{
+			{
+				SnapshotID:     fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Compute/snapshots/%s-%s", testSubscription, testResourceGroup, testGroupSnapshot0Name, testPersistentVolume0Name),
+				SourceVolumeID: testManagedDiskURI0,
+				SizeBytes:      10,
+				CreationTime:   metav1.Now(),
+				ReadyToUse:     readyToUse,
+			},
+		},
+		CreationTime: metav1.Now(),
+		ReadyToUse:   readyToUse,
+	}
+}
+
+func mockClientsAndGroupSnapshotProvisioner(controller *ReconcileAzVolumeGroupSnapshot, readyToUse bool) {
+	mockClients(controller.cachedClient.(*mockclient.MockClient), controller.azClient, controller.kubeClient)
+
+	controller.groupSnapshotProvisioner.(*mockgroupsnapshotprovisioner.MockGroupSnapshotProvisioner).EXPECT().
+		CreateGroupSnapshot(gomock.Any(), testGroupSnapshot0Name, []string{testManagedDiskURI0}, gomock.Any(), gomock.Any()).
+		Return(newTestGroupSnapshot(readyToUse), nil).
+		MaxTimes(1)
+	controller.groupSnapshotProvisioner.(*mockgroupsnapshotprovisioner.MockGroupSnapshotProvisioner).EXPECT().
+		GetGroupSnapshot(gomock.Any(), testGroupSnapshot0ID, gomock.Any()).
+		Return(newTestGroupSnapshot(true), nil).
+		MaxTimes(1)
+	controller.groupSnapshotProvisioner.(*mockgroupsnapshotprovisioner.MockGroupSnapshotProvisioner).EXPECT().
+		DeleteGroupSnapshot(gomock.Any(), testGroupSnapshot0ID, gomock.Any()).
+		Return(nil).
+		MaxTimes(1)
+}
+
+func TestAzVolumeGroupSnapshotControllerReconcile(t *testing.T) {
+	tests := []struct {
+		description string
+		request     reconcile.Request
+		setupFunc   func(*testing.T, *gomock.Controller) *ReconcileAzVolumeGroupSnapshot
+		verifyFunc  func(*testing.T, *ReconcileAzVolumeGroupSnapshot, reconcile.Result, error)
+	}{
+		{
+			description: "[Success] Should succeed if AzVolumeGroupSnapshot is not found.",
+			request:     testAzVolumeGroupSnapshot0Request,
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *ReconcileAzVolumeGroupSnapshot {
+				controller := NewTestAzVolumeGroupSnapshotController(
+					mockCtl,
+					testNamespace)
+
+				mockClientsAndGroupSnapshotProvisioner(controller, true)
+
+				return controller
+			},
+			verifyFunc: func(t *testing.T, controller *ReconcileAzVolumeGroupSnapshot, result reconcile.Result, err error) {
+				require.NoError(t, err)
+				require.False(t, result.Requeue)
+			},
+		},
+		{
+			description: "[Success] Should create a group snapshot when a new AzVolumeGroupSnapshot instance is created.",
+			request:     testAzVolumeGroupSnapshot0Request,
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *ReconcileAzVolumeGroupSnapshot {
+				azVolumeGroupSnapshot := testAzVolumeGroupSnapshot0.DeepCopy()
+				azVolumeGroupSnapshot.Status.State = azdiskv1beta2.GroupSnapshotOperationPending
+
+				controller := NewTestAzVolumeGroupSnapshotController(
+					mockCtl,
+					testNamespace,
+					azVolumeGroupSnapshot)
+
+				mockClientsAndGroupSnapshotProvisioner(controller, true)
+
+				return controller
+			},
+			verifyFunc: func(t *testing.T, controller *ReconcileAzVolumeGroupSnapshot, result reconcile.Result, err error) {
+				require.NoError(t, err)
+				require.False(t, result.Requeue)
+
+				conditionFunc := func() (bool, error) {
+					azVolumeGroupSnapshot, localError := controller.azClient.DiskV1beta2().AzVolumeGroupSnapshots(testNamespace).Get(context.TODO(), testGroupSnapshot0Name, metav1.GetOptions{})
+					if localError != nil {
+						return false, nil
+					}
+					return azVolumeGroupSnapshot.Status.State == azdiskv1beta2.GroupSnapshotCreated && azVolumeGroupSnapshot.Status.Detail != nil, nil
+				}
+				conditionError := wait.PollImmediate(verifyCRIInterval, verifyCRITimeout, conditionFunc)
+				require.NoError(t, conditionError)
+			},
+		},
+		{
+			description: "[Success] Should freeze the filesystems of the attached volumes while the group snapshot is created.",
+			request:     testAzVolumeGroupSnapshot0Request,
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *ReconcileAzVolumeGroupSnapshot {
+				azVolumeGroupSnapshot := testAzVolumeGroupSnapshot0.DeepCopy()
+				azVolumeGroupSnapshot.Spec.Parameters = map[string]string{consts.FreezeFilesystemsField: "true"}
+				azVolumeGroupSnapshot.Status.State = azdiskv1beta2.GroupSnapshotOperationPending
+
+				azVolumeAttachment := testPrimaryAzVolumeAttachment0.DeepCopy()
+				azVolumeAttachment.Status.State = azdiskv1beta2.Attached
+
+				controller := NewTestAzVolumeGroupSnapshotController(
+					mockCtl,
+					testNamespace,
+					azVolumeGroupSnapshot,
+					azVolumeAttachment)
+
+				mockClients(controller.cachedClient.(*mockclient.MockClient), controller.azClient, controller.kubeClient)
+				controller.groupSnapshotProvisioner.(*mockgroupsnapshotprovisioner.MockGroupSnapshotProvisioner).EXPECT().
+					CreateGroupSnapshot(gomock.Any(), testGroupSnapshot0Name, []string{testManagedDiskURI0}, gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, groupSnapshotName string, sourceVolumeIDs []string, secrets, parameters map[string]string) (*azdiskv1beta2.GroupSnapshot, error) {
+						azva, err := controller.azClient.DiskV1beta2().AzVolumeAttachments(testNamespace).Get(ctx, azVolumeAttachment.Name, metav1.GetOptions{})
+						if err != nil {
+							return nil, err
+						}
+						if azva.Status.Annotations[consts.FilesystemFrozenAnnotation] != testGroupSnapshot0Name {
+							return nil, status.Errorf(codes.FailedPrecondition, "filesystem of volume (%s) is not frozen", azva.Spec.VolumeID)
+						}
+						return newTestGroupSnapshot(true), nil
+					}).
+					MaxTimes(1)
+
+				// act as the node and freeze the filesystem once requested
+				go func() {
+					_ = wait.PollImmediate(verifyCRIInterval, verifyCRITimeout, func() (bool, error) {
+						azva, err := controller.azClient.DiskV1beta2().AzVolumeAttachments(testNamespace).Get(context.TODO(), azVolumeAttachment.Name, metav1.GetOptions{})
+						if err != nil || azva.Status.Annotations[consts.FilesystemFreezeRequestAnnotation] != testGroupSnapshot0Name {
+							return false, nil
+						}
+						azva.Status.Annotations = azureutils.AddToMap(azva.Status.Annotations, consts.FilesystemFrozenAnnotation, testGroupSnapshot0Name)
+						_, err = controller.azClient.DiskV1beta2().AzVolumeAttachments(testNamespace).UpdateStatus(context.TODO(), azva, metav1.UpdateOptions{})
+						return err == nil, nil
+					})
+				}()
+
+				return controller
+			},
+			verifyFunc: func(t *testing.T, controller *ReconcileAzVolumeGroupSnapshot, result reconcile.Result, err error) {
+				require.NoError(t, err)
+				require.False(t, result.Requeue)
+
+				conditionFunc := func() (bool, error) {
+					azVolumeGroupSnapshot, localError := controller.azClient.DiskV1beta2().AzVolumeGroupSnapshots(testNamespace).Get(context.TODO(), testGroupSnapshot0Name, metav1.GetOptions{})
+					if localError != nil || azVolumeGroupSnapshot.Status.State != azdiskv1beta2.GroupSnapshotCreated {
+						return false, nil
+					}
+					azVolumeAttachment, localError := controller.azClient.DiskV1beta2().AzVolumeAttachments(testNamespace).Get(context.TODO(), testPrimaryAzVolumeAttachment0.Name, metav1.GetOptions{})
+					if localError != nil {
+						return false, nil
+					}
+					return !azureutils.MapContains(azVolumeAttachment.Status.Annotations, consts.FilesystemFreezeRequestAnnotation), nil
+				}
+				conditionError := wait.PollImmediate(verifyCRIInterval, verifyCRITimeout, conditionFunc)
+				require.NoError(t, conditionError)
+			},
+		},
+		{
+			description: "[Failure] Should report an error and remove the finalizer when group snapshot creation fails.",
+			request:     testAzVolumeGroupSnapshot0Request,
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *ReconcileAzVolumeGroupSnapshot {
+				azVolumeGroupSnapshot := testAzVolumeGroupSnapshot0.DeepCopy()
+				azVolumeGroupSnapshot.Finalizers = []string{consts.AzVolumeGroupSnapshotFinalizer}
+				azVolumeGroupSnapshot.Status.State = azdiskv1beta2.GroupSnapshotOperationPending
+
+				controller := NewTestAzVolumeGroupSnapshotController(
+					mockCtl,
+					testNamespace,
+					azVolumeGroupSnapshot)
+
+				mockClients(controller.cachedClient.(*mockclient.MockClient), controller.azClient, controller.kubeClient)
+				controller.groupSnapshotProvisioner.(*mockgroupsnapshotprovisioner.MockGroupSnapshotProvisioner).EXPECT().
+					CreateGroupSnapshot(gomock.Any(), testGroupSnapshot0Name, []string{testManagedDiskURI0}, gomock.Any(), gomock.Any()).
+					Return(nil, status.Errorf(codes.Internal, "test error")).
+					MaxTimes(1)
+
+				return controller
+			},
+			verifyFunc: func(t *testing.T, controller *ReconcileAzVolumeGroupSnapshot, result reconcile.Result, err error) {
+				require.NoError(t, err)
+				require.False(t, result.Requeue)
+
+				conditionFunc := func() (bool, error) {
+					azVolumeGroupSnapshot, localError := controller.azClient.DiskV1beta2().AzVolumeGroupSnapshots(testNamespace).Get(context.TODO(), testGroupSnapshot0Name, metav1.GetOptions{})
+					if localError != nil {
+						return false, nil
+					}
+					return azVolumeGroupSnapshot.Status.State == azdiskv1beta2.GroupSnapshotCreationFailed && azVolumeGroupSnapshot.Status.Error != nil && len(azVolumeGroupSnapshot.Finalizers) == 0, nil
+				}
+				conditionError := wait.PollImmediate(verifyCRIInterval, verifyCRITimeout, conditionFunc)
+				require.NoError(t, conditionError)
+			},
+		},
+		{
+			description: "[Success] Should refresh a group snapshot that is not yet ready to use.",
+			request:     testAzVolumeGroupSnapshot0Request,
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *ReconcileAzVolumeGroupSnapshot {
+				azVolumeGroupSnapshot := testAzVolumeGroupSnapshot0.DeepCopy()
+				azVolumeGroupSnapshot.Status.State = azdiskv1beta2.GroupSnapshotCreated
+				azVolumeGroupSnapshot.Status.Detail = newTestGroupSnapshot(false)
+
+				controller := NewTestAzVolumeGroupSnapshotController(
+					mockCtl,
+					testNamespace,
+					azVolumeGroupSnapshot)
+
+				mockClientsAndGroupSnapshotProvisioner(controller, false)
+
+				return controller
+			},
+			verifyFunc: func(t *testing.T, controller *ReconcileAzVolumeGroupSnapshot, result reconcile.Result, err error) {
+				require.NoError(t, err)
+				require.Equal(t, snapshotReadinessPollInterval, result.RequeueAfter)
+
+				conditionFunc := func() (bool, error) {
+					azVolumeGroupSnapshot, localError := controller.azClient.DiskV1beta2().AzVolumeGroupSnapshots(testNamespace).Get(context.TODO(), testGroupSnapshot0Name, metav1.GetOptions{})
+					if localError != nil {
+						return false, nil
+					}
+					return azVolumeGroupSnapshot.Status.Detail.ReadyToUse, nil
+				}
+				conditionError := wait.PollImmediate(verifyCRIInterval, verifyCRITimeout, conditionFunc)
+				require.NoError(t, conditionError)
+			},
+		},
+		{
+			description: "[Success] Should delete a group snapshot when a AzVolumeGroupSnapshot is marked for deletion.",
+			request:     testAzVolumeGroupSnapshot0Request,
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *ReconcileAzVolumeGroupSnapshot {
+				azVolumeGroupSnapshot := testAzVolumeGroupSnapshot0.DeepCopy()
+				azVolumeGroupSnapshot.Finalizers = []string{consts.AzVolumeGroupSnapshotFinalizer}
+				azVolumeGroupSnapshot.Status.State = azdiskv1beta2.GroupSnapshotCreated
+				azVolumeGroupSnapshot.Status.Detail = newTestGroupSnapshot(true)
+				now := metav1.Time{Time: metav1.Now().Add(-1000)}
+				azVolumeGroupSnapshot.ObjectMeta.DeletionTimestamp = &now
+
+				controller := NewTestAzVolumeGroupSnapshotController(
+					mockCtl,
+					testNamespace,
+					azVolumeGroupSnapshot)
+
+				mockClientsAndGroupSnapshotProvisioner(controller, true)
+
+				return controller
+			},
+			verifyFunc: func(t *testing.T, controller *ReconcileAzVolumeGroupSnapshot, result reconcile.Result, err error) {
+				require.NoError(t, err)
+				require.False(t, result.Requeue)
+
+				conditionFunc := func() (bool, error) {
+					azVolumeGroupSnapshot, localError := controller.azClient.DiskV1beta2().AzVolumeGroupSnapshots(testNamespace).Get(context.TODO(), testGroupSnapshot0Name, metav1.GetOptions{})
+					return len(azVolumeGroupSnapshot.Finalizers) == 0, localError
+				}
+				conditionError := wait.PollImmediate(verifyCRIInterval, verifyCRITimeout, conditionFunc)
+				require.NoError(t, conditionError)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		tt := test
+		t.Run(tt.description, func(t *testing.T) {
+			mockCtl := gomock.NewController(t)
+			defer mockCtl.Finish()
+			controller := tt.setupFunc(t, mockCtl)
+			result, err := controller.Reconcile(context.TODO(), tt.request)
+			tt.verifyFunc(t, controller, result, err)
+		})
+	}
+}
+
+func TestAzVolumeGroupSnapshotControllerRecover(t *testing.T) {
+	tests := []struct {
+		description string
+		setupFunc   func(*testing.T, *gomock.Controller) *ReconcileAzVolumeGroupSnapshot
+		verifyFunc  func(*testing.T, *ReconcileAzVolumeGroupSnapshot, error)
+	}{
+		{
+			description: "[Success] Should reset in-progress AzVolumeGroupSnapshot states so the operations can be redone.",
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *ReconcileAzVolumeGroupSnapshot {
+				azVolumeGroupSnapshot := testAzVolumeGroupSnapshot0.DeepCopy()
+				azVolumeGroupSnapshot.Status.State = azdiskv1beta2.GroupSnapshotCreating
+
+				controller := NewTestAzVolumeGroupSnapshotController(
+					mockCtl,
+					testNamespace,
+					azVolumeGroupSnapshot)
+
+				mockClientsAndGroupSnapshotProvisioner(controller, true)
+
+				return controller
+			},
+			verifyFunc: func(t *testing.T, controller *ReconcileAzVolumeGroupSnapshot, err error) {
+				require.NoError(t, err)
+
+				azVolumeGroupSnapshot, localErr := controller.azClient.DiskV1beta2().AzVolumeGroupSnapshots(testNamespace).Get(context.TODO(), testGroupSnapshot0Name, metav1.GetOptions{})
+				require.NoError(t, localErr)
+				require.Equal(t, azdiskv1beta2.GroupSnapshotOperationPending, azVolumeGroupSnapshot.Status.State)
+				require.Contains(t, azVolumeGroupSnapshot.Status.Annotations, consts.RecoverAnnotation)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		tt := test
+		t.Run(tt.description, func(t *testing.T) {
+			mockCtl := gomock.NewController(t)
+			defer mockCtl.Finish()
+			controller := tt.setupFunc(t, mockCtl)
+			err := controller.Recover(context.TODO(), "test-recovery-id")
+			tt.verifyFunc(t, controller, err)
+		})
+	}
+}
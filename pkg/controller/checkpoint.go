@@ -0,0 +1,394 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	azdiskv1beta2 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
+	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/workflow"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const sharedStateCheckpointVersion = 1
+
+// maxSharedStateCheckpointSize is the size limit of the data of a ConfigMap, which is the same as that of a Secret.
+var maxSharedStateCheckpointSize = v1.MaxSecretSize
+
+// sharedStateCheckpoint is the persisted form of the shared state rebuilt by the controllers during recovery.
+// Each entry records the resourceVersion of the objects it was derived from so that only the objects changed since the checkpoint are replayed.
+type sharedStateCheckpoint struct {
+	Version           int                       `json:"version"`
+	PersistentVolumes map[string]pvCheckpoint   `json:"persistentVolumes,omitempty"`
+	Pods              map[string]podCheckpoint  `json:"pods,omitempty"`
+	Nodes             map[string]nodeCheckpoint `json:"nodes,omitempty"`
+	ReplicaRequests   []ReplicaRequest          `json:"replicaRequests,omitempty"`
+}
+
+type pvCheckpoint struct {
+	ResourceVersion string `json:"resourceVersion"`
+	VolumeName      string `json:"volumeName"`
+	ClaimName       string `json:"claimName"`
+}
+
+type podCheckpoint struct {
+	ResourceVersion string   `json:"resourceVersion"`
+	Claims          []string `json:"claims,omitempty"`
+}
+
+// nodeCheckpoint records the available attachments of a node along with the resourceVersions of the AzVolumeAttachments on the node at the time.
+type nodeCheckpoint struct {
+	AvailableAttachments int32             `json:"availableAttachments"`
+	Attachments          map[string]string `json:"attachments,omitempty"`
+}
+
+/*
+State checkpointer periodically persists the shared state of the controllers to a ConfigMap so that a new leader does not need to rebuild it from scratch.
+On election, the new leader loads the checkpoint and only replays the objects changed since the checkpoint. It
+ 1. restores the entries of the PersistentVolumes and Pods whose resourceVersion has not changed since the checkpoint
+ 2. restores the available attachments of the nodes whose AzVolumeAttachments have not changed since the checkpoint
+ 3. requeues the pending replica requests
+*/
+type StateCheckpointer struct {
+	*SharedState
+	logger   logr.Logger
+	interval time.Duration
+}
+
+var _ manager.LeaderElectionRunnable = &StateCheckpointer{}
+
+// Start checkpoints the shared state at every interval until the context is done.
+func (c *StateCheckpointer) Start(ctx context.Context) error {
+	c.logger.V(2).Info("Starting state checkpointer.", "interval", c.interval)
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		// the shared state is incomplete until recovery completes
+		if !c.isRecoveryComplete() || !c.isCoordinator() || c.checkpointDisabled.Load() {
+			return
+		}
+		_ = c.saveCheckpoint(ctx)
+	}, c.interval)
+	return nil
+}
+
+// NeedLeaderElection returns true so that only the leader controller plugin checkpoints the shared state.
+func (c *StateCheckpointer) NeedLeaderElection() bool {
+	return true
+}
+
+func (c *SharedState) isCheckpointEnabled() bool {
+	return c.config.ControllerConfig.CheckpointIntervalInSec > 0
+}
+
+func (c *SharedState) getCheckpointName() string {
+	return c.config.ControllerConfig.PartitionName + consts.SharedStateCheckpointSuffix
+}
+
+func (c *SharedState) saveCheckpoint(ctx context.Context) error {
+	var err error
+	ctx, w := workflow.New(ctx)
+	defer func() { w.Finish(err) }()
+
+	var checkpoint *sharedStateCheckpoint
+	if checkpoint, err = c.captureCheckpoint(ctx); err != nil {
+		return err
+	}
+
+	var data []byte
+	if data, err = encodeCheckpoint(checkpoint); err != nil {
+		w.Logger().Error(err, "failed to encode shared state checkpoint")
+		return err
+	}
+
+	configMaps := c.kubeClient.CoreV1().ConfigMaps(c.config.ObjectNamespace)
+	if len(data) > maxSharedStateCheckpointSize {
+		// the next leader rebuilds the shared state from scratch rather than restore it from an outdated checkpoint
+		c.checkpointDisabled.Store(true)
+		err = fmt.Errorf("shared state checkpoint of %d bytes exceeds the ConfigMap size limit of %d bytes", len(data), maxSharedStateCheckpointSize)
+		w.Logger().Errorf(err, "disabling shared state checkpointing with %d PersistentVolumes, %d Pods and %d nodes", len(checkpoint.PersistentVolumes), len(checkpoint.Pods), len(checkpoint.Nodes))
+		if deleteErr := configMaps.Delete(ctx, c.getCheckpointName(), metav1.DeleteOptions{}); deleteErr != nil && !apiErrors.IsNotFound(deleteErr) {
+			w.Logger().Errorf(deleteErr, "failed to delete outdated shared state checkpoint in ConfigMap (%s)", c.getCheckpointName())
+		}
+		return err
+	}
+
+	var configMap *v1.ConfigMap
+	configMap, err = configMaps.Get(ctx, c.getCheckpointName(), metav1.GetOptions{})
+	if apiErrors.IsNotFound(err) {
+		configMap = &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      c.getCheckpointName(),
+				Namespace: c.config.ObjectNamespace,
+			},
+			BinaryData: map[string][]byte{consts.SharedStateCheckpointKey: data},
+		}
+		_, err = configMaps.Create(ctx, configMap, metav1.CreateOptions{})
+	} else if err == nil {
+		configMap = configMap.DeepCopy()
+		configMap.BinaryData = map[string][]byte{consts.SharedStateCheckpointKey: data}
+		_, err = configMaps.Update(ctx, configMap, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		w.Logger().Errorf(err, "failed to save shared state checkpoint to ConfigMap (%s)", c.getCheckpointName())
+		return err
+	}
+	w.Logger().V(5).Infof("Saved shared state checkpoint with %d PersistentVolumes, %d Pods and %d nodes.", len(checkpoint.PersistentVolumes), len(checkpoint.Pods), len(checkpoint.Nodes))
+	return nil
+}
+
+func (c *SharedState) captureCheckpoint(ctx context.Context) (*sharedStateCheckpoint, error) {
+	checkpoint := &sharedStateCheckpoint{
+		Version:           sharedStateCheckpointVersion,
+		PersistentVolumes: map[string]pvCheckpoint{},
+		Pods:              map[string]podCheckpoint{},
+		Nodes:             map[string]nodeCheckpoint{},
+	}
+
+	c.pvCheckpoints.Range(func(key, value interface{}) bool {
+		checkpoint.PersistentVolumes[key.(string)] = value.(pvCheckpoint)
+		return true
+	})
+	c.podCheckpoints.Range(func(key, value interface{}) bool {
+		podKey := key.(string)
+		// pods with inline volumes are always replayed as their AzVolumes need to be created
+		if _, ok := c.podToInlineMap.Load(podKey); ok {
+			return true
+		}
+		entry := value.(podCheckpoint)
+		entry.Claims = append([]string{}, entry.Claims...)
+		checkpoint.Pods[podKey] = entry
+		return true
+	})
+
+	// the AzVolumeAttachments are listed before the available attachments are read so that
+	// a change in the available attachments of a node missed by the checkpoint invalidates the node's entry
+	nodeAttachments, err := c.getNodeAttachmentVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.availableAttachmentsMap.Range(func(key, value interface{}) bool {
+		nodeName := key.(string)
+		checkpoint.Nodes[nodeName] = nodeCheckpoint{
+			AvailableAttachments: value.(*atomic.Int32).Load(),
+			Attachments:          nodeAttachments[nodeName],
+		}
+		return true
+	})
+
+	for _, request := range c.priorityReplicaRequestsQueue.queue.List() {
+		checkpoint.ReplicaRequests = append(checkpoint.ReplicaRequests, *request.(*ReplicaRequest))
+	}
+
+	return checkpoint, nil
+}
+
+// getNodeAttachmentVersions returns the resourceVersions of the AzVolumeAttachments keyed by node and AzVolumeAttachment name.
+func (c *SharedState) getNodeAttachmentVersions(ctx context.Context) (map[string]map[string]string, error) {
+	w, _ := workflow.GetWorkflowFromContext(ctx)
+	var azVolumeAttachments azdiskv1beta2.AzVolumeAttachmentList
+	if err := c.cachedClient.List(ctx, &azVolumeAttachments, &client.ListOptions{Namespace: c.config.ObjectNamespace}); err != nil {
+		w.Logger().Error(err, "failed to list AzVolumeAttachments")
+		return nil, err
+	}
+	nodeAttachments := map[string]map[string]string{}
+	for _, azVolumeAttachment := range azVolumeAttachments.Items {
+		nodeName := azVolumeAttachment.Spec.NodeName
+		if _, ok := nodeAttachments[nodeName]; !ok {
+			nodeAttachments[nodeName] = map[string]string{}
+		}
+		nodeAttachments[nodeName][azVolumeAttachment.Name] = azVolumeAttachment.ResourceVersion
+	}
+	return nodeAttachments, nil
+}
+
+// LoadCheckpoint loads the shared state checkpoint saved by the previous leader, if any, to be used by the next recovery.
+func (c *SharedState) LoadCheckpoint(ctx context.Context) error {
+	var err error
+	ctx, w := workflow.New(ctx)
+	defer func() { w.Finish(err) }()
+
+	if !c.isCheckpointEnabled() {
+		return nil
+	}
+
+	var configMap *v1.ConfigMap
+	configMap, err = c.kubeClient.CoreV1().ConfigMaps(c.config.ObjectNamespace).Get(ctx, c.getCheckpointName(), metav1.GetOptions{})
+	if err != nil {
+		if apiErrors.IsNotFound(err) {
+			w.Logger().V(2).Infof("No shared state checkpoint found in ConfigMap (%s).", c.getCheckpointName())
+			err = nil
+		}
+		return err
+	}
+
+	var checkpoint *sharedStateCheckpoint
+	if checkpoint, err = decodeCheckpoint(configMap.BinaryData[consts.SharedStateCheckpointKey]); err != nil {
+		w.Logger().Errorf(err, "failed to decode shared state checkpoint in ConfigMap (%s)", c.getCheckpointName())
+		return err
+	}
+	if checkpoint.Version != sharedStateCheckpointVersion {
+		w.Logger().V(2).Infof("Ignoring shared state checkpoint with unsupported version %d.", checkpoint.Version)
+		return nil
+	}
+
+	var nodeAttachments map[string]map[string]string
+	if nodeAttachments, err = c.getNodeAttachmentVersions(ctx); err != nil {
+		return err
+	}
+	restoredNodes := 0
+	for nodeName, entry := range checkpoint.Nodes {
+		if !isStringMapEqual(entry.Attachments, nodeAttachments[nodeName]) {
+			continue
+		}
+		var count atomic.Int32
+		count.Store(entry.AvailableAttachments)
+		c.availableAttachmentsMap.LoadOrStore(nodeName, &count)
+		restoredNodes++
+	}
+
+	// a request for a volume which is queued again by the recovery replaces the request restored from the checkpoint
+	for i := range checkpoint.ReplicaRequests {
+		request := checkpoint.ReplicaRequests[i]
+		c.priorityReplicaRequestsQueue.Push(ctx, &request)
+	}
+
+	c.checkpoint.Store(checkpoint)
+	w.Logger().V(2).Infof("Loaded shared state checkpoint; restored %d out of %d nodes and %d replica requests.", restoredNodes, len(checkpoint.Nodes), len(checkpoint.ReplicaRequests))
+	return nil
+}
+
+// restorePVFromCheckpoint restores the shared state of the PersistentVolume and returns true if it has not changed since the checkpoint.
+func (c *SharedState) restorePVFromCheckpoint(pv *v1.PersistentVolume) bool {
+	checkpoint := c.checkpoint.Load()
+	if checkpoint == nil {
+		return false
+	}
+	entry, ok := checkpoint.PersistentVolumes[pv.Name]
+	if !ok || entry.ResourceVersion != pv.ResourceVersion {
+		return false
+	}
+	c.addBoundPV(pv, entry.VolumeName, entry.ClaimName)
+	return true
+}
+
+// restorePodFromCheckpoint restores the shared state of the pod and returns true if it has not changed since the checkpoint.
+func (c *SharedState) restorePodFromCheckpoint(pod *v1.Pod) bool {
+	checkpoint := c.checkpoint.Load()
+	if checkpoint == nil {
+		return false
+	}
+	podKey := getQualifiedName(pod.Namespace, pod.Name)
+	entry, ok := checkpoint.Pods[podKey]
+	if !ok || entry.ResourceVersion != pod.ResourceVersion {
+		return false
+	}
+
+	_, _ = c.podLocks.LoadOrStore(podKey, &sync.Mutex{})
+	c.podToClaimsMap.Store(podKey, append([]string{}, entry.Claims...))
+	c.podCheckpoints.Store(podKey, entry)
+	for _, claim := range entry.Claims {
+		v, _ := c.claimToPodsMap.LoadOrStore(claim, newLockableEntry(set{}))
+		lockable := v.(*lockableEntry)
+		lockable.Lock()
+		lockable.entry.(set).add(podKey)
+		lockable.Unlock()
+	}
+	return true
+}
+
+func encodeCheckpoint(checkpoint *sharedStateCheckpoint) ([]byte, error) {
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return nil, err
+	}
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	if _, err = writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err = writer.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func decodeCheckpoint(data []byte) (*sharedStateCheckpoint, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	if data, err = io.ReadAll(reader); err != nil {
+		return nil, err
+	}
+	checkpoint := &sharedStateCheckpoint{}
+	if err = json.Unmarshal(data, checkpoint); err != nil {
+		return nil, err
+	}
+	return checkpoint, nil
+}
+
+func isStringMapEqual(left, right map[string]string) bool {
+	if len(left) != len(right) {
+		return false
+	}
+	for key, value := range left {
+		if rightValue, ok := right[key]; !ok || rightValue != value {
+			return false
+		}
+	}
+	return true
+}
+
+// NewStateCheckpointer initializes the state checkpointer and adds it to the manager.
+// It returns nil if checkpointing is disabled.
+func NewStateCheckpointer(mgr manager.Manager, controllerSharedState *SharedState) (*StateCheckpointer, error) {
+	logger := mgr.GetLogger().WithValues("controller", "checkpoint")
+	interval := time.Duration(controllerSharedState.config.ControllerConfig.CheckpointIntervalInSec) * time.Second
+	if interval <= 0 {
+		logger.V(2).Info("Shared state checkpointing is disabled.")
+		return nil, nil
+	}
+
+	checkpointer := &StateCheckpointer{
+		SharedState: controllerSharedState,
+		logger:      logger,
+		interval:    interval,
+	}
+
+	if err := mgr.Add(checkpointer); err != nil {
+		logger.Error(err, "failed to add state checkpointer to manager")
+		return nil, err
+	}
+	logger.V(2).Info("Controller set-up successful.")
+
+	return checkpointer, nil
+}
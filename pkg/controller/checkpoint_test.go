@@ -0,0 +1,211 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils/mockclient"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/workflow"
+)
+
+func NewTestCheckpointSharedState(mockCtl *gomock.Controller, objects ...runtime.Object) *SharedState {
+	sharedState := NewTestSharedState(mockCtl, testNamespace, objects...)
+	sharedState.config.ControllerConfig.PartitionName = "test-controller"
+	sharedState.config.ControllerConfig.CheckpointIntervalInSec = 60
+	mockClients(sharedState.cachedClient.(*mockclient.MockClient), sharedState.azClient, sharedState.kubeClient)
+	return sharedState
+}
+
+func TestSharedStateCheckpoint(t *testing.T) {
+	pv := testPersistentVolume0.DeepCopy()
+	pv.SetResourceVersion("1")
+	pod := testPod0.DeepCopy()
+	pod.SetResourceVersion("2")
+	azVolumeAttachment := testPrimaryAzVolumeAttachment0.DeepCopy()
+	azVolumeAttachment.SetResourceVersion("3")
+
+	podKey := getQualifiedName(pod.Namespace, pod.Name)
+	claimName := getQualifiedName(testNamespace, testPersistentVolumeClaim0Name)
+
+	// save the checkpoint from the shared state of the previous leader
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	previousState := NewTestCheckpointSharedState(mockCtl, pv, pod, azVolumeAttachment)
+	previousState.addBoundPV(pv, testAzVolume0.Name, claimName)
+	require.NoError(t, previousState.addPod(context.TODO(), pod, acquireLock))
+	var count atomic.Int32
+	count.Store(7)
+	previousState.availableAttachmentsMap.Store(testNode0Name, &count)
+	previousState.priorityReplicaRequestsQueue.Push(context.TODO(), &ReplicaRequest{VolumeName: testAzVolume0.Name, Priority: 1})
+
+	require.NoError(t, previousState.saveCheckpoint(context.TODO()))
+	configMap, err := previousState.kubeClient.CoreV1().ConfigMaps(previousState.config.ObjectNamespace).Get(context.TODO(), "test-controller-checkpoint", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	tests := []struct {
+		description string
+		setupFunc   func(*SharedState)
+		verifyFunc  func(*testing.T, *SharedState)
+	}{
+		{
+			description: "[Success] Should restore the shared state of unchanged objects",
+			verifyFunc: func(t *testing.T, sharedState *SharedState) {
+				require.True(t, sharedState.restorePVFromCheckpoint(pv))
+				volumeName, ok := sharedState.pvToVolumeMap.Load(pv.Name)
+				require.True(t, ok)
+				require.Equal(t, testAzVolume0.Name, volumeName)
+				claim, ok := sharedState.volumeToClaimMap.Load(testAzVolume0.Name)
+				require.True(t, ok)
+				require.Equal(t, claimName, claim)
+
+				require.True(t, sharedState.restorePodFromCheckpoint(pod))
+				claims, ok := sharedState.podToClaimsMap.Load(podKey)
+				require.True(t, ok)
+				require.Equal(t, []string{claimName}, claims)
+				pods, ok := sharedState.claimToPodsMap.Load(claimName)
+				require.True(t, ok)
+				require.True(t, pods.(*lockableEntry).entry.(set).has(podKey))
+
+				capacity, ok := sharedState.availableAttachmentsMap.Load(testNode0Name)
+				require.True(t, ok)
+				require.Equal(t, int32(7), capacity.(*atomic.Int32).Load())
+
+				// the request of the volume queued again by the recovery replaces the restored request
+				sharedState.priorityReplicaRequestsQueue.Push(context.TODO(), &ReplicaRequest{VolumeName: testAzVolume0.Name, Priority: 2})
+				requests := sharedState.priorityReplicaRequestsQueue.DrainQueue()
+				require.Len(t, requests, 1)
+				require.Equal(t, testAzVolume0.Name, requests[0].VolumeName)
+				require.Equal(t, 2, requests[0].Priority)
+
+				sharedState.MarkRecoveryComplete()
+				require.Nil(t, sharedState.checkpoint.Load())
+			},
+		},
+		{
+			description: "[Success] Should replay objects changed since the checkpoint",
+			setupFunc: func(sharedState *SharedState) {
+				updated := azVolumeAttachment.DeepCopy()
+				updated.SetResourceVersion("4")
+				_, err := sharedState.azClient.DiskV1beta2().AzVolumeAttachments(testNamespace).Update(context.TODO(), updated, metav1.UpdateOptions{})
+				require.NoError(t, err)
+			},
+			verifyFunc: func(t *testing.T, sharedState *SharedState) {
+				updatedPV := pv.DeepCopy()
+				updatedPV.SetResourceVersion("5")
+				require.False(t, sharedState.restorePVFromCheckpoint(updatedPV))
+
+				updatedPod := pod.DeepCopy()
+				updatedPod.SetResourceVersion("6")
+				require.False(t, sharedState.restorePodFromCheckpoint(updatedPod))
+
+				_, ok := sharedState.availableAttachmentsMap.Load(testNode0Name)
+				require.False(t, ok)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		tt := test
+		t.Run(tt.description, func(t *testing.T) {
+			mockCtl := gomock.NewController(t)
+			defer mockCtl.Finish()
+			sharedState := NewTestCheckpointSharedState(mockCtl, pv.DeepCopy(), pod.DeepCopy(), azVolumeAttachment.DeepCopy(), configMap.DeepCopy())
+			if tt.setupFunc != nil {
+				tt.setupFunc(sharedState)
+			}
+			ctx, w := workflow.New(context.TODO())
+			defer w.Finish(nil)
+			require.NoError(t, sharedState.LoadCheckpoint(ctx))
+			require.NotNil(t, sharedState.checkpoint.Load())
+			tt.verifyFunc(t, sharedState)
+		})
+	}
+}
+
+func TestLoadCheckpointWithoutConfigMap(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	sharedState := NewTestCheckpointSharedState(mockCtl)
+
+	require.NoError(t, sharedState.LoadCheckpoint(context.TODO()))
+	require.Nil(t, sharedState.checkpoint.Load())
+	require.False(t, sharedState.restorePVFromCheckpoint(&v1.PersistentVolume{}))
+	require.False(t, sharedState.restorePodFromCheckpoint(&v1.Pod{}))
+}
+
+func TestCaptureCheckpointRecordsReconciledVersions(t *testing.T) {
+	pv := testPersistentVolume0.DeepCopy()
+	pv.SetResourceVersion("1")
+	pod := testPod0.DeepCopy()
+	pod.SetResourceVersion("2")
+	claimName := getQualifiedName(testNamespace, testPersistentVolumeClaim0Name)
+
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	// the objects changed since their last reconciliation keep the resourceVersion their shared state was derived from
+	updatedPV := pv.DeepCopy()
+	updatedPV.SetResourceVersion("3")
+	updatedPod := pod.DeepCopy()
+	updatedPod.SetResourceVersion("4")
+	sharedState := NewTestCheckpointSharedState(mockCtl, updatedPV, updatedPod)
+	sharedState.addBoundPV(pv, testAzVolume0.Name, claimName)
+	require.NoError(t, sharedState.addPod(context.TODO(), pod, acquireLock))
+
+	ctx, w := workflow.New(context.TODO())
+	defer w.Finish(nil)
+	checkpoint, err := sharedState.captureCheckpoint(ctx)
+	require.NoError(t, err)
+	require.Equal(t, pvCheckpoint{ResourceVersion: "1", VolumeName: testAzVolume0.Name, ClaimName: claimName}, checkpoint.PersistentVolumes[pv.Name])
+	require.Equal(t, podCheckpoint{ResourceVersion: "2", Claims: []string{claimName}}, checkpoint.Pods[getQualifiedName(pod.Namespace, pod.Name)])
+
+	// the entries of released PersistentVolumes and deleted pods are dropped
+	sharedState.pvCheckpoints.Delete(pv.Name)
+	require.NoError(t, sharedState.deletePod(ctx, getQualifiedName(pod.Namespace, pod.Name)))
+	checkpoint, err = sharedState.captureCheckpoint(ctx)
+	require.NoError(t, err)
+	require.Empty(t, checkpoint.PersistentVolumes)
+	require.Empty(t, checkpoint.Pods)
+}
+
+func TestSaveCheckpointExceedingSizeLimit(t *testing.T) {
+	defer func(size int) { maxSharedStateCheckpointSize = size }(maxSharedStateCheckpointSize)
+
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	sharedState := NewTestCheckpointSharedState(mockCtl)
+	pv := testPersistentVolume0.DeepCopy()
+	pv.SetResourceVersion("1")
+	sharedState.addBoundPV(pv, testAzVolume0.Name, getQualifiedName(testNamespace, testPersistentVolumeClaim0Name))
+	require.NoError(t, sharedState.saveCheckpoint(context.TODO()))
+
+	maxSharedStateCheckpointSize = 1
+	require.Error(t, sharedState.saveCheckpoint(context.TODO()))
+	require.True(t, sharedState.checkpointDisabled.Load())
+
+	// the outdated checkpoint is deleted so that the next leader does not restore it
+	_, err := sharedState.kubeClient.CoreV1().ConfigMaps(sharedState.config.ObjectNamespace).Get(context.TODO(), "test-controller-checkpoint", metav1.GetOptions{})
+	require.True(t, apiErrors.IsNotFound(err))
+}
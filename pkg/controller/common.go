@@ -36,6 +36,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/component-helpers/scheduling/corev1/nodeaffinity"
 	azdiskv1beta2 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
@@ -87,6 +88,8 @@ const (
 	pv               operationRequester = "pv-controller"
 	replica          operationRequester = "replica-controller"
 	nodeavailability operationRequester = "nodeavailability-controller"
+	nodecordon       operationRequester = "nodecordon-controller"
+	nodelease        operationRequester = "nodelease-controller"
 	pod              operationRequester = "pod-controller"
 )
 
@@ -134,14 +137,23 @@ type CloudProvisioner interface {
 	DeleteVolume(ctx context.Context, volumeID string, secrets map[string]string) error
 	PublishVolume(ctx context.Context, volumeID string, nodeID string, volumeContext map[string]string) provisioner.CloudAttachResult
 	UnpublishVolume(ctx context.Context, volumeID string, nodeID string) error
+	GetNodeDataDisks(ctx context.Context, nodeID string) ([]compute.DataDisk, error)
 	ExpandVolume(ctx context.Context, volumeID string, capacityRange *azdiskv1beta2.CapacityRange, secrets map[string]string) (*azdiskv1beta2.AzVolumeStatusDetail, error)
+	ModifyVolume(ctx context.Context, volumeID string, parameters map[string]string) error
+	ListDisksByResourceGroup(ctx context.Context, resourceGroup string) ([]compute.Disk, error)
+	UpdateDiskTags(ctx context.Context, volumeID string, tags map[string]*string) error
 	ListVolumes(ctx context.Context, maxEntries int32, startingToken string) (*azdiskv1beta2.ListVolumesResult, error)
+	GetCapacity(ctx context.Context, parameters map[string]string, accessibleTopology *azdiskv1beta2.Topology) (int64, int64, error)
 	CreateSnapshot(ctx context.Context, sourceVolumeID string, snapshotName string, secrets map[string]string, parameters map[string]string) (*azdiskv1beta2.Snapshot, error)
 	ListSnapshots(ctx context.Context, maxEntries int32, startingToken string, sourceVolumeID string, snapshotID string, secrets map[string]string) (*azdiskv1beta2.ListSnapshotsResult, error)
 	DeleteSnapshot(ctx context.Context, snapshotID string, secrets map[string]string) error
+	CreateGroupSnapshot(ctx context.Context, groupSnapshotName string, sourceVolumeIDs []string, secrets map[string]string, parameters map[string]string) (*azdiskv1beta2.GroupSnapshot, error)
+	GetGroupSnapshot(ctx context.Context, groupSnapshotID string, secrets map[string]string) (*azdiskv1beta2.GroupSnapshot, error)
+	DeleteGroupSnapshot(ctx context.Context, groupSnapshotID string, secrets map[string]string) error
 	CheckDiskExists(ctx context.Context, diskURI string) (*compute.Disk, error)
 	GetCloud() *provider.Cloud
 	GetMetricPrefix() string
+	SetAttachDetachRateLimit(cloudConfig azdiskv1beta2.CloudConfiguration)
 }
 
 type replicaOperation struct {
@@ -615,14 +627,85 @@ func (v *volumeNodeSelectorFilter) filter(ctx context.Context, nodes []v1.Node)
 	return filteredNodes, nil
 }
 
+// topologySpreadFilter selects nodes outside of the zones and fault domains already hosting attachments of the zone-redundant or shared volumes,
+// as long as such nodes exist
+type topologySpreadFilter struct {
+	persistentVolumes []*v1.PersistentVolume
+	state             *SharedState
+}
+
+func (t *topologySpreadFilter) name() string {
+	return "topology spread filter"
+}
+
+func (t *topologySpreadFilter) setup(pods []v1.Pod, persistentVolumes []*v1.PersistentVolume, state *SharedState) {
+	t.persistentVolumes = persistentVolumes
+	t.state = state
+}
+
+func (t *topologySpreadFilter) filter(ctx context.Context, nodes []v1.Node) ([]v1.Node, error) {
+	ctx, w := workflow.New(ctx, workflow.WithDetails("filter-plugin", t.name()))
+	defer w.Finish(nil)
+
+	occupiedZones, occupiedFaultDomains := set{}, set{}
+	for _, pv := range t.persistentVolumes {
+		if pv.Spec.CSI == nil {
+			continue
+		}
+		diskName, err := azureutils.GetDiskName(pv.Spec.CSI.VolumeHandle)
+		if err != nil {
+			w.Logger().Errorf(err, "failed to get disk name from volume handle (%s)", pv.Spec.CSI.VolumeHandle)
+			continue
+		}
+		zones, faultDomains, ok := t.state.getOccupiedFailureDomains(ctx, strings.ToLower(diskName))
+		if !ok {
+			continue
+		}
+		for zone := range zones {
+			occupiedZones.add(zone)
+		}
+		for faultDomain := range faultDomains {
+			occupiedFaultDomains.add(faultDomain)
+		}
+	}
+
+	if len(occupiedZones) == 0 && len(occupiedFaultDomains) == 0 {
+		return nodes, nil
+	}
+
+	var zoneSpreadNodes, faultDomainSpreadNodes []v1.Node
+	for _, node := range nodes {
+		domain := t.state.getNodeFailureDomain(ctx, &node)
+		if domain.zone != "" && !occupiedZones.has(domain.zone) {
+			zoneSpreadNodes = append(zoneSpreadNodes, node)
+		}
+		if domain.faultDomain != "" && !occupiedFaultDomains.has(domain.key()) {
+			faultDomainSpreadNodes = append(faultDomainSpreadNodes, node)
+		}
+	}
+
+	// prefer spreading across zones over spreading across the fault domains of a zone
+	if len(zoneSpreadNodes) > 0 {
+		w.Logger().V(5).Infof("Selecting %d out of %d nodes outside of the zones (%+v) with attachments.", len(zoneSpreadNodes), len(nodes), occupiedZones.toStringSlice())
+		return zoneSpreadNodes, nil
+	}
+	if len(faultDomainSpreadNodes) > 0 {
+		w.Logger().V(5).Infof("Selecting %d out of %d nodes outside of the fault domains (%+v) with attachments.", len(faultDomainSpreadNodes), len(nodes), occupiedFaultDomains.toStringSlice())
+		return faultDomainSpreadNodes, nil
+	}
+	return nodes, nil
+}
+
 type nodeScorerPlugin interface {
 	name() string
 	setup(nodes []v1.Node, pods []v1.Pod, volumes []string, state *SharedState)
 	priority() float64 // returns the score plugin's priority in a scale of 1 ~ 5 (5 being the highest priority)
+	setPriority(priority float64)
 	score(ctx context.Context, nodeScores map[string]int) (map[string]int, error)
 }
 
 type scoreByNodeCapacity struct {
+	scorerPriority
 	nodes   []v1.Node
 	volumes []string
 	state   *SharedState
@@ -632,10 +715,6 @@ func (s *scoreByNodeCapacity) name() string {
 	return "score by node capacity"
 }
 
-func (s *scoreByNodeCapacity) priority() float64 {
-	return 1
-}
-
 func (s *scoreByNodeCapacity) setup(nodes []v1.Node, pods []v1.Pod, volumes []string, state *SharedState) {
 	s.nodes = nodes
 	s.volumes = volumes
@@ -649,13 +728,13 @@ func (s *scoreByNodeCapacity) score(ctx context.Context, nodeScores map[string]i
 		if _, ok := nodeScores[node.Name]; !ok {
 			continue
 		}
-		maxCapacity, err := azureutils.GetNodeMaxDiskCountWithLabels(node.Labels)
+		maxCapacity, err := azureutils.GetNodeMaxDiskCount(ctx, s.state.cachedClient, s.state.config.ObjectNamespace, node.Name)
 		if err != nil {
 			w.Logger().Errorf(err, "failed to get max capacity of node (%s)", node.Name)
 			delete(nodeScores, node.Name)
 			continue
 		}
-		remainingCapacity, err := azureutils.GetNodeRemainingDiskCount(ctx, s.state.cachedClient, node.Name)
+		remainingCapacity, err := azureutils.GetNodeRemainingDiskCount(ctx, s.state.cachedClient, s.state.config.ObjectNamespace, node.Name)
 		if err != nil {
 			// if failed to get node's remaining capacity, remove the node from the candidate list and proceed
 			w.Logger().Errorf(err, "failed to get remaining capacity of node (%s)", node.Name)
@@ -675,6 +754,7 @@ func (s *scoreByNodeCapacity) score(ctx context.Context, nodeScores map[string]i
 }
 
 type scoreByReplicaCount struct {
+	scorerPriority
 	volumes []string
 	state   *SharedState
 }
@@ -683,10 +763,6 @@ func (s *scoreByReplicaCount) name() string {
 	return "score by replica count"
 }
 
-func (s *scoreByReplicaCount) priority() float64 {
-	return 3
-}
-
 func (s *scoreByReplicaCount) setup(nodes []v1.Node, pods []v1.Pod, volumes []string, state *SharedState) {
 	s.volumes = volumes
 	s.state = state
@@ -736,6 +812,7 @@ func (s *scoreByReplicaCount) score(ctx context.Context, nodeScores map[string]i
 }
 
 type scoreByInterPodAffinity struct {
+	scorerPriority
 	nodes   []v1.Node
 	pods    []v1.Pod
 	volumes []string
@@ -746,10 +823,6 @@ func (s *scoreByInterPodAffinity) name() string {
 	return "score by inter pod affinity"
 }
 
-func (s *scoreByInterPodAffinity) priority() float64 {
-	return 2
-}
-
 func (s *scoreByInterPodAffinity) setup(nodes []v1.Node, pods []v1.Pod, volumes []string, state *SharedState) {
 	s.nodes = nodes
 	s.pods = pods
@@ -796,6 +869,7 @@ func (s *scoreByInterPodAffinity) score(ctx context.Context, nodeScores map[stri
 }
 
 type scoreByInterPodAntiAffinity struct {
+	scorerPriority
 	nodes   []v1.Node
 	pods    []v1.Pod
 	volumes []string
@@ -806,10 +880,6 @@ func (s *scoreByInterPodAntiAffinity) name() string {
 	return "score by inter pod anti affinity"
 }
 
-func (s *scoreByInterPodAntiAffinity) priority() float64 {
-	return 2
-}
-
 func (s *scoreByInterPodAntiAffinity) setup(nodes []v1.Node, pods []v1.Pod, volumes []string, state *SharedState) {
 	s.nodes = nodes
 	s.pods = pods
@@ -854,6 +924,7 @@ func (s *scoreByInterPodAntiAffinity) score(ctx context.Context, nodeScores map[
 }
 
 type scoreByPodNodeAffinity struct {
+	scorerPriority
 	nodes   []v1.Node
 	pods    []v1.Pod
 	volumes []string
@@ -864,10 +935,6 @@ func (s *scoreByPodNodeAffinity) name() string {
 	return "score by pod node affinity"
 }
 
-func (s *scoreByPodNodeAffinity) priority() float64 {
-	return 2
-}
-
 func (s *scoreByPodNodeAffinity) setup(nodes []v1.Node, pods []v1.Pod, volumes []string, state *SharedState) {
 	s.nodes = nodes
 	s.pods = pods
@@ -880,12 +947,20 @@ func (s *scoreByPodNodeAffinity) score(ctx context.Context, nodeScores map[strin
 	defer w.Finish(nil)
 
 	var preferredSchedulingTerms []v1.PreferredSchedulingTerm
+	var maxAffinityScore int32
 
 	for _, pod := range s.pods {
 		if pod.Spec.Affinity == nil || pod.Spec.Affinity.NodeAffinity == nil {
 			continue
 		}
 		preferredSchedulingTerms = append(preferredSchedulingTerms, pod.Spec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution...)
+		for _, preferredSchedulingTerm := range pod.Spec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+			maxAffinityScore += preferredSchedulingTerm.Weight
+		}
+	}
+
+	if maxAffinityScore == 0 {
+		return nodeScores, nil
 	}
 
 	preferredAffinity, err := nodeaffinity.NewPreferredSchedulingTerms(preferredSchedulingTerms)
@@ -898,11 +973,169 @@ func (s *scoreByPodNodeAffinity) score(ctx context.Context, nodeScores map[strin
 		if _, ok := nodeScores[node.Name]; !ok {
 			continue
 		}
-		nodeScores[node.Name] += int(nodeScore)
+		nodeScores[node.Name] += int((float64(nodeScore) / float64(maxAffinityScore)) * math.Pow(10, s.priority()))
+	}
+	return nodeScores, nil
+}
+
+// scoreByTopologySpread scores nodes by whether they spread the attachments of the zone-redundant or shared volumes across zones and fault domains
+type scoreByTopologySpread struct {
+	scorerPriority
+	nodes   []v1.Node
+	volumes []string
+	state   *SharedState
+}
+
+func (s *scoreByTopologySpread) name() string {
+	return "score by topology spread"
+}
+
+func (s *scoreByTopologySpread) setup(nodes []v1.Node, pods []v1.Pod, volumes []string, state *SharedState) {
+	s.nodes = nodes
+	s.volumes = volumes
+	s.state = state
+}
+
+func (s *scoreByTopologySpread) score(ctx context.Context, nodeScores map[string]int) (map[string]int, error) {
+	ctx, w := workflow.New(ctx, workflow.WithDetails("score-plugin", s.name()))
+	defer w.Finish(nil)
+
+	nodeDomains := map[string]failureDomain{}
+	for i := range s.nodes {
+		if _, ok := nodeScores[s.nodes[i].Name]; ok {
+			nodeDomains[s.nodes[i].Name] = s.state.getNodeFailureDomain(ctx, &s.nodes[i])
+		}
+	}
+
+	// a node in a new zone scores twice as much as a node in a new fault domain of an occupied zone
+	const zoneSpreadScore, faultDomainSpreadScore = 2, 1
+	nodeSpreadScores := map[string]int{}
+	var maxSpreadScore int
+
+	for _, volume := range s.volumes {
+		zones, faultDomains, ok := s.state.getOccupiedFailureDomains(ctx, volume)
+		if !ok {
+			continue
+		}
+		maxSpreadScore += zoneSpreadScore + faultDomainSpreadScore
+
+		for node, domain := range nodeDomains {
+			if domain.zone != "" && !zones.has(domain.zone) {
+				nodeSpreadScores[node] += zoneSpreadScore
+			}
+			if domain.faultDomain != "" && !faultDomains.has(domain.key()) {
+				nodeSpreadScores[node] += faultDomainSpreadScore
+			}
+		}
+	}
+
+	if maxSpreadScore == 0 {
+		return nodeScores, nil
+	}
+
+	for node, spreadScore := range nodeSpreadScores {
+		if _, ok := nodeScores[node]; !ok {
+			continue
+		}
+		nodeScores[node] += int((float64(spreadScore) / float64(maxSpreadScore)) * math.Pow(10, s.priority()))
 	}
 	return nodeScores, nil
 }
 
+// failureDomain is the zone and platform fault domain of a node
+type failureDomain struct {
+	zone        string
+	faultDomain string
+}
+
+// key returns the fault domain qualified by the zone, as fault domains are numbered within a zone or availability set
+func (f failureDomain) key() string {
+	return f.zone + "/" + f.faultDomain
+}
+
+// getNodeFailureDomain returns the zone and fault domain published in the node's AzDriverNode, falling back to the zone label of the node
+func (c *SharedState) getNodeFailureDomain(ctx context.Context, node *v1.Node) failureDomain {
+	var domain failureDomain
+	azDriverNode := &azdiskv1beta2.AzDriverNode{}
+	if err := c.cachedClient.Get(ctx, types.NamespacedName{Namespace: c.config.ObjectNamespace, Name: node.Name}, azDriverNode); err == nil && azDriverNode.Status != nil {
+		if azDriverNode.Status.Zone != nil {
+			domain.zone = *azDriverNode.Status.Zone
+		}
+		if azDriverNode.Status.FaultDomain != nil {
+			domain.faultDomain = *azDriverNode.Status.FaultDomain
+		}
+	}
+	if domain.zone == "" && domain.faultDomain == "" {
+		domain.zone = node.Labels[consts.WellKnownTopologyKey]
+	}
+	return domain
+}
+
+// getOccupiedFailureDomains returns the zones and fault domains of the nodes with attachments of the volume. It returns false if the replica attachments
+// of the volume need not be spread, i.e. the volume is neither a zone-redundant nor a shared disk, or it already has MaxMountReplicaCount replica attachments.
+func (c *SharedState) getOccupiedFailureDomains(ctx context.Context, volume string) (set, set, bool) {
+	w, _ := workflow.GetWorkflowFromContext(ctx)
+
+	azVolume, err := azureutils.GetAzVolume(ctx, c.cachedClient, nil, volume, c.config.ObjectNamespace, true)
+	if err != nil {
+		w.Logger().Errorf(err, "failed to get AzVolume (%s)", volume)
+		return nil, nil, false
+	}
+	if !shouldSpreadReplicas(azVolume) {
+		return nil, nil, false
+	}
+
+	azVolumeAttachments, err := azureutils.GetAzVolumeAttachmentsForVolume(ctx, c.cachedClient, volume, azureutils.AllRoles)
+	if err != nil {
+		w.Logger().Errorf(err, "failed listing AzVolumeAttachments for AzVolume (%s)", volume)
+		return nil, nil, false
+	}
+
+	zones, faultDomains := set{}, set{}
+	replicaCount := 0
+	for _, azVolumeAttachment := range azVolumeAttachments {
+		if deleteRequested, _ := objectDeletionRequested(&azVolumeAttachment); deleteRequested {
+			continue
+		}
+		if azVolumeAttachment.Spec.RequestedRole == azdiskv1beta2.ReplicaRole {
+			replicaCount++
+		}
+
+		node := &v1.Node{}
+		if err := c.cachedClient.Get(ctx, types.NamespacedName{Name: azVolumeAttachment.Spec.NodeName}, node); err != nil {
+			node.Name = azVolumeAttachment.Spec.NodeName
+		}
+		domain := c.getNodeFailureDomain(ctx, node)
+		if domain.zone != "" {
+			zones.add(domain.zone)
+		}
+		if domain.faultDomain != "" {
+			faultDomains.add(domain.key())
+		}
+	}
+
+	if replicaCount >= azVolume.Spec.MaxMountReplicaCount {
+		return nil, nil, false
+	}
+	return zones, faultDomains, true
+}
+
+// shouldSpreadReplicas returns true if the volume is a zone-redundant or shared disk with mount replicas
+func shouldSpreadReplicas(azVolume *azdiskv1beta2.AzVolume) bool {
+	if azVolume.Spec.MaxMountReplicaCount <= 0 {
+		return false
+	}
+	if maxShares, err := azureutils.GetMaxShares(azVolume.Spec.Parameters); err == nil && maxShares > 1 {
+		return true
+	}
+	for key, value := range azVolume.Spec.Parameters {
+		if strings.EqualFold(key, consts.SkuNameField) {
+			return strings.HasSuffix(strings.ToLower(value), "_zrs")
+		}
+	}
+	return false
+}
+
 func getSupportedZones(nodeSelectorTerms []v1.NodeSelectorTerm, topologyKey string) set {
 	// Get the list of supported zones for pv
 	supportedZones := set{}
@@ -1054,6 +1287,10 @@ func isOperationInProcess(obj interface{}) bool {
 	case *azdiskv1beta2.AzVolumeAttachment:
 		deleteRequested, _ := objectDeletionRequested(target)
 		return target.Status.State == azdiskv1beta2.Attaching || (target.Status.State == azdiskv1beta2.Detaching && !deleteRequested)
+	case *azdiskv1beta2.AzSnapshot:
+		return target.Status.State == azdiskv1beta2.SnapshotCreating || target.Status.State == azdiskv1beta2.SnapshotDeleting
+	case *azdiskv1beta2.AzVolumeGroupSnapshot:
+		return target.Status.State == azdiskv1beta2.GroupSnapshotCreating || target.Status.State == azdiskv1beta2.GroupSnapshotDeleting
 	}
 	return false
 }
@@ -1079,16 +1316,25 @@ type ReplicaRequest struct {
 	Priority   int //The number of replicas that have yet to be created
 }
 type VolumeReplicaRequestsPriorityQueue struct {
+	// lock keeps size consistent with the number of requests in queue
+	lock  sync.Mutex
 	queue *cache.Heap
 	size  int32
 }
 
+// Push adds the replica request to the queue. A request for a volume which is already queued replaces the queued request.
 func (vq *VolumeReplicaRequestsPriorityQueue) Push(ctx context.Context, replicaRequest *ReplicaRequest) {
+	vq.lock.Lock()
+	defer vq.lock.Unlock()
+
 	w, _ := workflow.GetWorkflowFromContext(ctx)
-	err := vq.queue.Add(replicaRequest)
-	atomic.AddInt32(&vq.size, 1)
-	if err != nil {
+	_, exists, _ := vq.queue.GetByKey(replicaRequest.VolumeName)
+	if err := vq.queue.Add(replicaRequest); err != nil {
 		w.Logger().Errorf(err, "failed to add replica request for volume %s", replicaRequest.VolumeName)
+		return
+	}
+	if !exists {
+		atomic.AddInt32(&vq.size, 1)
 	}
 }
 
@@ -1098,6 +1344,9 @@ func (vq *VolumeReplicaRequestsPriorityQueue) Pop() *ReplicaRequest {
 	return request.(*ReplicaRequest)
 }
 func (vq *VolumeReplicaRequestsPriorityQueue) DrainQueue() []*ReplicaRequest {
+	vq.lock.Lock()
+	defer vq.lock.Unlock()
+
 	var listRequests []*ReplicaRequest
 	for i := vq.size; i > 0; i-- {
 		listRequests = append(listRequests, vq.Pop())
@@ -470,6 +470,18 @@ func convertToNode(obj client.Object) *v1.Node {
 	return nil
 }
 
+func withNodeReadyCondition(node *v1.Node, status v1.ConditionStatus) *v1.Node {
+	node = node.DeepCopy()
+	node.Status.Conditions = []v1.NodeCondition{{Type: v1.NodeReady, Status: status}}
+	return node
+}
+
+func withHeartbeat(azDriverNode *azdiskv1beta2.AzDriverNode, heartbeat time.Time) *azdiskv1beta2.AzDriverNode {
+	azDriverNode = azDriverNode.DeepCopy()
+	azDriverNode.Status = &azdiskv1beta2.AzDriverNodeStatus{LastHeartbeatTime: &metav1.Time{Time: heartbeat}}
+	return azDriverNode
+}
+
 func initState(client client.Client, azClient azdisk.Interface, kubeClient kubernetes.Interface, objs ...runtime.Object) (c *SharedState) {
 	config := &azdiskv1beta2.AzDiskDriverConfiguration{
 		DriverName:      consts.DefaultDriverName,
@@ -548,6 +560,7 @@ func splitObjects(objs ...runtime.Object) (azDiskObjs, kubeObjs []runtime.Object
 
 type mockStatusClient struct {
 	azVolumeClient azdisk.Interface
+	kubeClient     kubernetes.Interface
 }
 
 func (s *mockStatusClient) updateStatus(ctx context.Context, obj client.Object) error {
@@ -564,6 +577,24 @@ func (s *mockStatusClient) updateStatus(ctx context.Context, obj client.Object)
 			return err
 		}
 
+	case *azdiskv1beta2.AzSnapshot:
+		_, err := s.azVolumeClient.DiskV1beta2().AzSnapshots(obj.GetNamespace()).UpdateStatus(ctx, target, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+
+	case *azdiskv1beta2.AzVolumeGroupSnapshot:
+		_, err := s.azVolumeClient.DiskV1beta2().AzVolumeGroupSnapshots(obj.GetNamespace()).UpdateStatus(ctx, target, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+
+	case *storagev1.VolumeAttachment:
+		_, err := s.kubeClient.StorageV1().VolumeAttachments().UpdateStatus(ctx, target, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+
 	default:
 		gr := schema.GroupResource{
 			Group:    target.GetObjectKind().GroupVersionKind().Group,
@@ -593,7 +624,7 @@ func (s *mockStatusClient) Patch(ctx context.Context, obj client.Object, _ clien
 }
 
 func mockClients(mockClient *mockclient.MockClient, azVolumeClient azdisk.Interface, kubeClient kubernetes.Interface) {
-	statusClient := mockStatusClient{azVolumeClient: azVolumeClient}
+	statusClient := mockStatusClient{azVolumeClient: azVolumeClient, kubeClient: kubeClient}
 	mockClient.EXPECT().Status().Return(&statusClient).AnyTimes()
 	mockClient.EXPECT().
 		Get(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
@@ -637,6 +668,30 @@ func mockClients(mockClient *mockclient.MockClient, azVolumeClient azdisk.Interf
 
 				azDriverNode.DeepCopyInto(obj.(*azdiskv1beta2.AzDriverNode))
 
+			case *azdiskv1beta2.AzSnapshot:
+				azSnapshot, err := azVolumeClient.DiskV1beta2().AzSnapshots(key.Namespace).Get(ctx, key.Name, metav1.GetOptions{})
+				if err != nil {
+					return err
+				}
+
+				azSnapshot.DeepCopyInto(target)
+
+			case *azdiskv1beta2.AzVolumeGroupSnapshot:
+				azVolumeGroupSnapshot, err := azVolumeClient.DiskV1beta2().AzVolumeGroupSnapshots(key.Namespace).Get(ctx, key.Name, metav1.GetOptions{})
+				if err != nil {
+					return err
+				}
+
+				azVolumeGroupSnapshot.DeepCopyInto(target)
+
+			case *azdiskv1beta2.AzSnapshotPolicy:
+				policy, err := azVolumeClient.DiskV1beta2().AzSnapshotPolicies(key.Namespace).Get(ctx, key.Name, metav1.GetOptions{})
+				if err != nil {
+					return err
+				}
+
+				policy.DeepCopyInto(target)
+
 			case *v1.PersistentVolume:
 				pv, err := kubeClient.CoreV1().PersistentVolumes().Get(ctx, key.Name, metav1.GetOptions{})
 				if err != nil {
@@ -728,6 +783,18 @@ func mockClients(mockClient *mockclient.MockClient, azVolumeClient azdisk.Interf
 					return err
 				}
 
+			case *azdiskv1beta2.AzSnapshot:
+				_, err := azVolumeClient.DiskV1beta2().AzSnapshots(obj.GetNamespace()).Update(ctx, target, metav1.UpdateOptions{})
+				if err != nil {
+					return err
+				}
+
+			case *azdiskv1beta2.AzVolumeGroupSnapshot:
+				_, err := azVolumeClient.DiskV1beta2().AzVolumeGroupSnapshots(obj.GetNamespace()).Update(ctx, target, metav1.UpdateOptions{})
+				if err != nil {
+					return err
+				}
+
 			default:
 				gr := schema.GroupResource{
 					Group:    target.GetObjectKind().GroupVersionKind().Group,
@@ -768,6 +835,20 @@ func mockClients(mockClient *mockclient.MockClient, azVolumeClient azdisk.Interf
 				}
 
 				azDriverNodes.DeepCopyInto(target)
+			case *azdiskv1beta2.AzSnapshotList:
+				azSnapshots, err := azVolumeClient.DiskV1beta2().AzSnapshots(testNamespace).List(ctx, *options.AsListOptions())
+				if err != nil {
+					return err
+				}
+
+				azSnapshots.DeepCopyInto(target)
+			case *azdiskv1beta2.AzVolumeGroupSnapshotList:
+				azVolumeGroupSnapshots, err := azVolumeClient.DiskV1beta2().AzVolumeGroupSnapshots(testNamespace).List(ctx, *options.AsListOptions())
+				if err != nil {
+					return err
+				}
+
+				azVolumeGroupSnapshots.DeepCopyInto(target)
 
 			case *v1.PodList:
 				pods, err := kubeClient.CoreV1().Pods(namespace).List(ctx, *options.AsListOptions())
@@ -805,6 +886,18 @@ func mockClients(mockClient *mockclient.MockClient, azVolumeClient azdisk.Interf
 					return err
 				}
 
+			case *azdiskv1beta2.AzSnapshot:
+				err := azVolumeClient.DiskV1beta2().AzSnapshots(obj.GetNamespace()).Delete(ctx, target.Name, metav1.DeleteOptions{})
+				if err != nil {
+					return err
+				}
+
+			case *azdiskv1beta2.AzVolumeGroupSnapshot:
+				err := azVolumeClient.DiskV1beta2().AzVolumeGroupSnapshots(obj.GetNamespace()).Delete(ctx, target.Name, metav1.DeleteOptions{})
+				if err != nil {
+					return err
+				}
+
 			default:
 				gr := schema.GroupResource{
 					Group:    target.GetObjectKind().GroupVersionKind().Group,
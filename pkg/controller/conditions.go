@@ -0,0 +1,144 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	azdiskv1beta2 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils"
+)
+
+const (
+	// Reasons of the conditions which are not derived from the state or error of the object.
+	conditionReasonNoError               = "NoError"
+	conditionReasonNodeExpansionRequired = "NodeExpansionRequired"
+	conditionReasonNotExpanding          = "NotExpanding"
+	conditionReasonNotModifying          = "NotModifying"
+	conditionReasonRoleChangePending     = "RoleChangePending"
+)
+
+// setCondition sets the condition of the specified type, only updating its transition time if its status changed.
+func setCondition(conditions *[]metav1.Condition, conditionType string, conditionStatus bool, reason, message string, generation int64) {
+	condition := metav1.Condition{
+		Type:               conditionType,
+		Status:             metav1.ConditionFalse,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: generation,
+	}
+	if conditionStatus {
+		condition.Status = metav1.ConditionTrue
+	}
+	meta.SetStatusCondition(conditions, condition)
+}
+
+// setDegradedCondition sets the condition of the specified type from the error of the last operation.
+func setDegradedCondition(conditions *[]metav1.Condition, conditionType string, azError *azdiskv1beta2.AzError, generation int64) {
+	if azError == nil {
+		setCondition(conditions, conditionType, false, conditionReasonNoError, "", generation)
+		return
+	}
+	setCondition(conditions, conditionType, true, string(azError.Code), azError.Message, generation)
+}
+
+// updateAzVolumeConditions derives the conditions of the AzVolume from its current state, detail and error.
+func updateAzVolumeConditions(azVolume *azdiskv1beta2.AzVolume) *azdiskv1beta2.AzVolume {
+	if azVolume == nil {
+		return nil
+	}
+
+	status := &azVolume.Status
+	generation := azVolume.Generation
+	reason := string(status.State)
+	if reason == "" {
+		reason = string(azdiskv1beta2.VolumeOperationPending)
+	}
+
+	// the volume remains usable while it is being updated, even if the update failed
+	var ready bool
+	switch status.State {
+	case azdiskv1beta2.VolumeCreated, azdiskv1beta2.VolumeUpdating, azdiskv1beta2.VolumeUpdated, azdiskv1beta2.VolumeUpdateFailed:
+		ready = status.Detail != nil
+	}
+	setCondition(&status.Conditions, azdiskv1beta2.VolumeReadyCondition, ready, reason, "", generation)
+
+	// an update either expands the volume or modifies its parameters, depending on which part of the spec changed, so
+	// the volume is only expanding if the requested capacity exceeds the current capacity
+	updating := ready && status.State == azdiskv1beta2.VolumeUpdating
+	switch {
+	case updating && azVolume.Spec.CapacityRange != nil && azVolume.Spec.CapacityRange.RequiredBytes > status.Detail.CapacityBytes:
+		setCondition(&status.Conditions, azdiskv1beta2.VolumeExpandingCondition, true, reason, fmt.Sprintf("The volume is being expanded from %d to %d bytes.", status.Detail.CapacityBytes, azVolume.Spec.CapacityRange.RequiredBytes), generation)
+	case ready && status.Detail.NodeExpansionRequired:
+		setCondition(&status.Conditions, azdiskv1beta2.VolumeExpandingCondition, true, conditionReasonNodeExpansionRequired, "The volume must be expanded on the node.", generation)
+	default:
+		setCondition(&status.Conditions, azdiskv1beta2.VolumeExpandingCondition, false, conditionReasonNotExpanding, "", generation)
+	}
+
+	var modifications map[string]string
+	if updating {
+		modifications = azureutils.GetModifiedDiskParameters(azVolume.Spec.Parameters, status.Detail.VolumeContext)
+	}
+	if len(modifications) > 0 {
+		setCondition(&status.Conditions, azdiskv1beta2.VolumeModifyingCondition, true, reason, fmt.Sprintf("The volume parameters are being modified to %v.", modifications), generation)
+	} else {
+		setCondition(&status.Conditions, azdiskv1beta2.VolumeModifyingCondition, false, conditionReasonNotModifying, "", generation)
+	}
+
+	setDegradedCondition(&status.Conditions, azdiskv1beta2.VolumeDegradedCondition, status.Error, generation)
+
+	return azVolume
+}
+
+// updateAzVolumeAttachmentConditions derives the conditions of the AzVolumeAttachment from its current state, detail and error.
+func updateAzVolumeAttachmentConditions(azVolumeAttachment *azdiskv1beta2.AzVolumeAttachment) *azdiskv1beta2.AzVolumeAttachment {
+	if azVolumeAttachment == nil {
+		return nil
+	}
+
+	status := &azVolumeAttachment.Status
+	generation := azVolumeAttachment.Generation
+	reason := string(status.State)
+	if reason == "" {
+		reason = string(azdiskv1beta2.AttachmentPending)
+	}
+
+	// the volume remains attached to the node until its detachment succeeds
+	var attached bool
+	switch status.State {
+	case azdiskv1beta2.Attached, azdiskv1beta2.Detaching, azdiskv1beta2.DetachmentFailed, azdiskv1beta2.ForceDetachPending:
+		attached = true
+	}
+	setCondition(&status.Conditions, azdiskv1beta2.AttachmentAttachedCondition, attached, reason, "", generation)
+
+	switch {
+	case status.State != azdiskv1beta2.Attached || status.Detail == nil:
+		setCondition(&status.Conditions, azdiskv1beta2.AttachmentReadyCondition, false, reason, "", generation)
+	case status.Detail.Role != azVolumeAttachment.Spec.RequestedRole:
+		setCondition(&status.Conditions, azdiskv1beta2.AttachmentReadyCondition, false, conditionReasonRoleChangePending, fmt.Sprintf("The attachment is pending a change from the %s role to the %s role.", status.Detail.Role, azVolumeAttachment.Spec.RequestedRole), generation)
+	default:
+		setCondition(&status.Conditions, azdiskv1beta2.AttachmentReadyCondition, true, reason, "", generation)
+	}
+
+	setDegradedCondition(&status.Conditions, azdiskv1beta2.AttachmentDegradedCondition, status.Error, generation)
+
+	setCondition(&status.Conditions, azdiskv1beta2.AttachmentForceDetachPendingCondition, status.State == azdiskv1beta2.ForceDetachPending, reason, "", generation)
+
+	return azVolumeAttachment
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	azdiskv1beta2 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/util"
+)
+
+func requireCondition(t *testing.T, conditions []metav1.Condition, conditionType string, expectedStatus metav1.ConditionStatus, expectedReason string) {
+	condition := meta.FindStatusCondition(conditions, conditionType)
+	require.NotNil(t, condition, "condition %s not found", conditionType)
+	require.Equal(t, expectedStatus, condition.Status, "unexpected status of condition %s", conditionType)
+	require.Equal(t, expectedReason, condition.Reason, "unexpected reason of condition %s", conditionType)
+}
+
+func TestUpdateAzVolumeConditions(t *testing.T) {
+	tests := []struct {
+		description string
+		setupFunc   func(*azdiskv1beta2.AzVolume)
+		verifyFunc  func(*testing.T, *azdiskv1beta2.AzVolume)
+	}{
+		{
+			description: "[Success] Should not be ready while the volume is being created",
+			setupFunc: func(azVolume *azdiskv1beta2.AzVolume) {
+				azVolume.Status.State = azdiskv1beta2.VolumeCreating
+			},
+			verifyFunc: func(t *testing.T, azVolume *azdiskv1beta2.AzVolume) {
+				requireCondition(t, azVolume.Status.Conditions, azdiskv1beta2.VolumeReadyCondition, metav1.ConditionFalse, string(azdiskv1beta2.VolumeCreating))
+				requireCondition(t, azVolume.Status.Conditions, azdiskv1beta2.VolumeExpandingCondition, metav1.ConditionFalse, conditionReasonNotExpanding)
+				requireCondition(t, azVolume.Status.Conditions, azdiskv1beta2.VolumeDegradedCondition, metav1.ConditionFalse, conditionReasonNoError)
+			},
+		},
+		{
+			description: "[Success] Should report node expansion required on a created volume",
+			setupFunc: func(azVolume *azdiskv1beta2.AzVolume) {
+				azVolume.Status.State = azdiskv1beta2.VolumeUpdated
+				azVolume.Status.Detail = &azdiskv1beta2.AzVolumeStatusDetail{NodeExpansionRequired: true}
+			},
+			verifyFunc: func(t *testing.T, azVolume *azdiskv1beta2.AzVolume) {
+				requireCondition(t, azVolume.Status.Conditions, azdiskv1beta2.VolumeReadyCondition, metav1.ConditionTrue, string(azdiskv1beta2.VolumeUpdated))
+				requireCondition(t, azVolume.Status.Conditions, azdiskv1beta2.VolumeExpandingCondition, metav1.ConditionTrue, conditionReasonNodeExpansionRequired)
+			},
+		},
+		{
+			description: "[Success] Should report expansion while the volume is updating to a larger capacity",
+			setupFunc: func(azVolume *azdiskv1beta2.AzVolume) {
+				azVolume.Status.State = azdiskv1beta2.VolumeUpdating
+				azVolume.Status.Detail = &azdiskv1beta2.AzVolumeStatusDetail{CapacityBytes: azVolume.Spec.CapacityRange.RequiredBytes / 2}
+			},
+			verifyFunc: func(t *testing.T, azVolume *azdiskv1beta2.AzVolume) {
+				requireCondition(t, azVolume.Status.Conditions, azdiskv1beta2.VolumeReadyCondition, metav1.ConditionTrue, string(azdiskv1beta2.VolumeUpdating))
+				requireCondition(t, azVolume.Status.Conditions, azdiskv1beta2.VolumeExpandingCondition, metav1.ConditionTrue, string(azdiskv1beta2.VolumeUpdating))
+				requireCondition(t, azVolume.Status.Conditions, azdiskv1beta2.VolumeModifyingCondition, metav1.ConditionFalse, conditionReasonNotModifying)
+			},
+		},
+		{
+			description: "[Success] Should report the parameters being modified while the volume is updating",
+			setupFunc: func(azVolume *azdiskv1beta2.AzVolume) {
+				azVolume.Status.State = azdiskv1beta2.VolumeUpdating
+				azVolume.Spec.Parameters = map[string]string{"skuName": "PremiumV2_LRS"}
+				azVolume.Status.Detail = &azdiskv1beta2.AzVolumeStatusDetail{
+					CapacityBytes: azVolume.Spec.CapacityRange.RequiredBytes,
+					VolumeContext: map[string]string{"skuName": "Premium_LRS"},
+				}
+			},
+			verifyFunc: func(t *testing.T, azVolume *azdiskv1beta2.AzVolume) {
+				requireCondition(t, azVolume.Status.Conditions, azdiskv1beta2.VolumeReadyCondition, metav1.ConditionTrue, string(azdiskv1beta2.VolumeUpdating))
+				requireCondition(t, azVolume.Status.Conditions, azdiskv1beta2.VolumeExpandingCondition, metav1.ConditionFalse, conditionReasonNotExpanding)
+				requireCondition(t, azVolume.Status.Conditions, azdiskv1beta2.VolumeModifyingCondition, metav1.ConditionTrue, string(azdiskv1beta2.VolumeUpdating))
+			},
+		},
+		{
+			description: "[Success] Should report degraded volume on failed update",
+			setupFunc: func(azVolume *azdiskv1beta2.AzVolume) {
+				azVolume.Status.State = azdiskv1beta2.VolumeUpdateFailed
+				azVolume.Status.Detail = &azdiskv1beta2.AzVolumeStatusDetail{}
+				azVolume.Status.Error = util.NewAzError(status.Error(codes.Internal, "test error"))
+			},
+			verifyFunc: func(t *testing.T, azVolume *azdiskv1beta2.AzVolume) {
+				requireCondition(t, azVolume.Status.Conditions, azdiskv1beta2.VolumeReadyCondition, metav1.ConditionTrue, string(azdiskv1beta2.VolumeUpdateFailed))
+				requireCondition(t, azVolume.Status.Conditions, azdiskv1beta2.VolumeDegradedCondition, metav1.ConditionTrue, string(azdiskv1beta2.AzErrorCodeInternal))
+			},
+		},
+	}
+
+	for _, test := range tests {
+		tt := test
+		t.Run(tt.description, func(t *testing.T) {
+			azVolume := testAzVolume0.DeepCopy()
+			azVolume.Generation = 2
+			tt.setupFunc(azVolume)
+
+			azVolume = updateAzVolumeConditions(azVolume)
+			for _, condition := range azVolume.Status.Conditions {
+				require.Equal(t, int64(2), condition.ObservedGeneration)
+			}
+			tt.verifyFunc(t, azVolume)
+		})
+	}
+}
+
+func TestUpdateAzVolumeAttachmentConditions(t *testing.T) {
+	tests := []struct {
+		description string
+		setupFunc   func(*azdiskv1beta2.AzVolumeAttachment)
+		verifyFunc  func(*testing.T, *azdiskv1beta2.AzVolumeAttachment)
+	}{
+		{
+			description: "[Success] Should be ready when attached in the requested role",
+			setupFunc: func(azVolumeAttachment *azdiskv1beta2.AzVolumeAttachment) {
+				azVolumeAttachment.Status.State = azdiskv1beta2.Attached
+				azVolumeAttachment.Status.Detail = &azdiskv1beta2.AzVolumeAttachmentStatusDetail{Role: azVolumeAttachment.Spec.RequestedRole}
+			},
+			verifyFunc: func(t *testing.T, azVolumeAttachment *azdiskv1beta2.AzVolumeAttachment) {
+				requireCondition(t, azVolumeAttachment.Status.Conditions, azdiskv1beta2.AttachmentAttachedCondition, metav1.ConditionTrue, string(azdiskv1beta2.Attached))
+				requireCondition(t, azVolumeAttachment.Status.Conditions, azdiskv1beta2.AttachmentReadyCondition, metav1.ConditionTrue, string(azdiskv1beta2.Attached))
+				requireCondition(t, azVolumeAttachment.Status.Conditions, azdiskv1beta2.AttachmentDegradedCondition, metav1.ConditionFalse, conditionReasonNoError)
+				requireCondition(t, azVolumeAttachment.Status.Conditions, azdiskv1beta2.AttachmentForceDetachPendingCondition, metav1.ConditionFalse, string(azdiskv1beta2.Attached))
+			},
+		},
+		{
+			description: "[Success] Should not be ready when attached with replica promotion pending",
+			setupFunc: func(azVolumeAttachment *azdiskv1beta2.AzVolumeAttachment) {
+				azVolumeAttachment.Spec.RequestedRole = azdiskv1beta2.PrimaryRole
+				azVolumeAttachment.Status.State = azdiskv1beta2.Attached
+				azVolumeAttachment.Status.Detail = &azdiskv1beta2.AzVolumeAttachmentStatusDetail{Role: azdiskv1beta2.ReplicaRole}
+			},
+			verifyFunc: func(t *testing.T, azVolumeAttachment *azdiskv1beta2.AzVolumeAttachment) {
+				requireCondition(t, azVolumeAttachment.Status.Conditions, azdiskv1beta2.AttachmentAttachedCondition, metav1.ConditionTrue, string(azdiskv1beta2.Attached))
+				requireCondition(t, azVolumeAttachment.Status.Conditions, azdiskv1beta2.AttachmentReadyCondition, metav1.ConditionFalse, conditionReasonRoleChangePending)
+			},
+		},
+		{
+			description: "[Success] Should remain attached when replica force detachment is pending",
+			setupFunc: func(azVolumeAttachment *azdiskv1beta2.AzVolumeAttachment) {
+				azVolumeAttachment.Status.State = azdiskv1beta2.ForceDetachPending
+				azVolumeAttachment.Status.Detail = &azdiskv1beta2.AzVolumeAttachmentStatusDetail{Role: azVolumeAttachment.Spec.RequestedRole}
+				azVolumeAttachment.Status.Error = util.NewAzError(status.Error(codes.Unavailable, "test error"))
+			},
+			verifyFunc: func(t *testing.T, azVolumeAttachment *azdiskv1beta2.AzVolumeAttachment) {
+				requireCondition(t, azVolumeAttachment.Status.Conditions, azdiskv1beta2.AttachmentAttachedCondition, metav1.ConditionTrue, string(azdiskv1beta2.ForceDetachPending))
+				requireCondition(t, azVolumeAttachment.Status.Conditions, azdiskv1beta2.AttachmentReadyCondition, metav1.ConditionFalse, string(azdiskv1beta2.ForceDetachPending))
+				requireCondition(t, azVolumeAttachment.Status.Conditions, azdiskv1beta2.AttachmentDegradedCondition, metav1.ConditionTrue, string(azdiskv1beta2.AzErrorCodeUnavailable))
+				requireCondition(t, azVolumeAttachment.Status.Conditions, azdiskv1beta2.AttachmentForceDetachPendingCondition, metav1.ConditionTrue, string(azdiskv1beta2.ForceDetachPending))
+			},
+		},
+		{
+			description: "[Success] Should only update transition time when condition status changes",
+			setupFunc: func(azVolumeAttachment *azdiskv1beta2.AzVolumeAttachment) {
+				azVolumeAttachment.Status.State = azdiskv1beta2.Attaching
+				azVolumeAttachment.Status.Conditions = []metav1.Condition{
+					{
+						Type:               azdiskv1beta2.AttachmentAttachedCondition,
+						Status:             metav1.ConditionFalse,
+						Reason:             string(azdiskv1beta2.AttachmentPending),
+						LastTransitionTime: metav1.Unix(0, 0),
+					},
+				}
+			},
+			verifyFunc: func(t *testing.T, azVolumeAttachment *azdiskv1beta2.AzVolumeAttachment) {
+				condition := meta.FindStatusCondition(azVolumeAttachment.Status.Conditions, azdiskv1beta2.AttachmentAttachedCondition)
+				require.NotNil(t, condition)
+				require.Equal(t, string(azdiskv1beta2.Attaching), condition.Reason)
+				require.Equal(t, metav1.Unix(0, 0), condition.LastTransitionTime)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		tt := test
+		t.Run(tt.description, func(t *testing.T) {
+			azVolumeAttachment := testReplicaAzVolumeAttachment.DeepCopy()
+			tt.setupFunc(azVolumeAttachment)
+
+			tt.verifyFunc(t, updateAzVolumeAttachmentConditions(azVolumeAttachment))
+		})
+	}
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+	azdiskv1beta1 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta1"
+	azdiskv1beta2 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
+)
+
+// ConversionWebhookPath is the path at which the controller plugin serves the CRD conversion webhook.
+const ConversionWebhookPath = "/convert"
+
+// ConversionWebhook converts AzVolume, AzVolumeAttachment and AzDriverNode objects between the v1beta1 and v1beta2 api versions
+// on behalf of the API server, so that v1beta1 and v1beta2 clients can coexist during upgrades.
+type ConversionWebhook struct{}
+
+// NewConversionWebhook returns the handler of the CRD conversion webhook.
+func NewConversionWebhook() *ConversionWebhook {
+	return &ConversionWebhook{}
+}
+
+// ServeHTTP handles a ConversionReview request from the API server.
+func (wh *ConversionWebhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	review := apiextensionsv1.ConversionReview{}
+	if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
+		klog.Errorf("failed to decode conversion review: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if review.Request == nil {
+		klog.Error("conversion review has no request")
+		http.Error(w, "conversion review has no request", http.StatusBadRequest)
+		return
+	}
+
+	review.Response = wh.convert(review.Request)
+	review.Request = nil
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(review); err != nil {
+		klog.Errorf("failed to encode conversion review response: %v", err)
+	}
+}
+
+func (wh *ConversionWebhook) convert(request *apiextensionsv1.ConversionRequest) *apiextensionsv1.ConversionResponse {
+	response := &apiextensionsv1.ConversionResponse{
+		UID:              request.UID,
+		ConvertedObjects: make([]runtime.RawExtension, 0, len(request.Objects)),
+		Result:           metav1.Status{Status: metav1.StatusSuccess},
+	}
+
+	for _, object := range request.Objects {
+		convertedObject, err := convertObject(object.Raw, request.DesiredAPIVersion)
+		if err != nil {
+			klog.Errorf("failed to convert object to %s: %v", request.DesiredAPIVersion, err)
+			return &apiextensionsv1.ConversionResponse{
+				UID: request.UID,
+				Result: metav1.Status{
+					Status:  metav1.StatusFailure,
+					Message: err.Error(),
+				},
+			}
+		}
+		response.ConvertedObjects = append(response.ConvertedObjects, runtime.RawExtension{Raw: convertedObject})
+	}
+
+	return response
+}
+
+// convertObject converts the serialized object to the desired api version using v1beta2 as the hub version.
+func convertObject(raw []byte, desiredAPIVersion string) ([]byte, error) {
+	typeMeta := metav1.TypeMeta{}
+	if err := json.Unmarshal(raw, &typeMeta); err != nil {
+		return nil, err
+	}
+	if typeMeta.APIVersion == desiredAPIVersion {
+		return raw, nil
+	}
+
+	var converted interface{}
+	var err error
+	switch {
+	case typeMeta.APIVersion == azdiskv1beta1.SchemeGroupVersion.String() && desiredAPIVersion == azdiskv1beta2.SchemeGroupVersion.String():
+		converted, err = convertToV1Beta2(raw, typeMeta.Kind)
+	case typeMeta.APIVersion == azdiskv1beta2.SchemeGroupVersion.String() && desiredAPIVersion == azdiskv1beta1.SchemeGroupVersion.String():
+		converted, err = convertFromV1Beta2(raw, typeMeta.Kind)
+	default:
+		err = fmt.Errorf("conversion of %s from %s to %s is not supported", typeMeta.Kind, typeMeta.APIVersion, desiredAPIVersion)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(converted)
+}
+
+func convertToV1Beta2(raw []byte, kind string) (interface{}, error) {
+	switch kind {
+	case "AzVolume":
+		src, dst := &azdiskv1beta1.AzVolume{}, &azdiskv1beta2.AzVolume{}
+		if err := json.Unmarshal(raw, src); err != nil {
+			return nil, err
+		}
+		return dst, src.ConvertTo(dst)
+	case "AzVolumeAttachment":
+		src, dst := &azdiskv1beta1.AzVolumeAttachment{}, &azdiskv1beta2.AzVolumeAttachment{}
+		if err := json.Unmarshal(raw, src); err != nil {
+			return nil, err
+		}
+		return dst, src.ConvertTo(dst)
+	case "AzDriverNode":
+		src, dst := &azdiskv1beta1.AzDriverNode{}, &azdiskv1beta2.AzDriverNode{}
+		if err := json.Unmarshal(raw, src); err != nil {
+			return nil, err
+		}
+		return dst, src.ConvertTo(dst)
+	default:
+		return nil, fmt.Errorf("conversion of %s is not supported", kind)
+	}
+}
+
+func convertFromV1Beta2(raw []byte, kind string) (interface{}, error) {
+	switch kind {
+	case "AzVolume":
+		src, dst := &azdiskv1beta2.AzVolume{}, &azdiskv1beta1.AzVolume{}
+		if err := json.Unmarshal(raw, src); err != nil {
+			return nil, err
+		}
+		return dst, dst.ConvertFrom(src)
+	case "AzVolumeAttachment":
+		src, dst := &azdiskv1beta2.AzVolumeAttachment{}, &azdiskv1beta1.AzVolumeAttachment{}
+		if err := json.Unmarshal(raw, src); err != nil {
+			return nil, err
+		}
+		return dst, dst.ConvertFrom(src)
+	case "AzDriverNode":
+		src, dst := &azdiskv1beta2.AzDriverNode{}, &azdiskv1beta1.AzDriverNode{}
+		if err := json.Unmarshal(raw, src); err != nil {
+			return nil, err
+		}
+		return dst, dst.ConvertFrom(src)
+	default:
+		return nil, fmt.Errorf("conversion of %s is not supported", kind)
+	}
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	azdiskv1beta1 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta1"
+	azdiskv1beta2 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
+)
+
+func newConversionReview(t *testing.T, desiredAPIVersion string, objects ...interface{}) *bytes.Buffer {
+	review := apiextensionsv1.ConversionReview{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: apiextensionsv1.SchemeGroupVersion.String(),
+			Kind:       "ConversionReview",
+		},
+		Request: &apiextensionsv1.ConversionRequest{
+			UID:               types.UID("test-uid"),
+			DesiredAPIVersion: desiredAPIVersion,
+		},
+	}
+	for _, object := range objects {
+		raw, err := json.Marshal(object)
+		require.NoError(t, err)
+		review.Request.Objects = append(review.Request.Objects, runtime.RawExtension{Raw: raw})
+	}
+
+	body, err := json.Marshal(review)
+	require.NoError(t, err)
+	return bytes.NewBuffer(body)
+}
+
+func serveConversionReview(t *testing.T, body *bytes.Buffer) *apiextensionsv1.ConversionResponse {
+	recorder := httptest.NewRecorder()
+	NewConversionWebhook().ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, ConversionWebhookPath, body))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	review := apiextensionsv1.ConversionReview{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &review))
+	require.NotNil(t, review.Response)
+	require.Equal(t, types.UID("test-uid"), review.Response.UID)
+	return review.Response
+}
+
+func TestConversionWebhook(t *testing.T) {
+	azVolume := testAzVolume0.DeepCopy()
+	azVolume.APIVersion = azdiskv1beta2.SchemeGroupVersion.String()
+	azVolume.Kind = "AzVolume"
+	azVolume.Spec.PersistentVolume = testPersistentVolume0Name
+	azVolume.Status.Annotations = map[string]string{"test-annotation": "test"}
+
+	azVolumeAttachment := testPrimaryAzVolumeAttachment0.DeepCopy()
+	azVolumeAttachment.APIVersion = azdiskv1beta2.SchemeGroupVersion.String()
+	azVolumeAttachment.Kind = "AzVolumeAttachment"
+
+	// convert down to v1beta1
+	response := serveConversionReview(t, newConversionReview(t, azdiskv1beta1.SchemeGroupVersion.String(), azVolume, azVolumeAttachment))
+	require.Equal(t, metav1.StatusSuccess, response.Result.Status)
+	require.Len(t, response.ConvertedObjects, 2)
+
+	v1beta1AzVolume := azdiskv1beta1.AzVolume{}
+	require.NoError(t, json.Unmarshal(response.ConvertedObjects[0].Raw, &v1beta1AzVolume))
+	require.Equal(t, azdiskv1beta1.SchemeGroupVersion.String(), v1beta1AzVolume.APIVersion)
+	require.Equal(t, testPersistentVolume0Name, v1beta1AzVolume.Status.PersistentVolume)
+	require.Equal(t, "test", v1beta1AzVolume.Annotations["test-annotation"])
+
+	v1beta1AzVolumeAttachment := azdiskv1beta1.AzVolumeAttachment{}
+	require.NoError(t, json.Unmarshal(response.ConvertedObjects[1].Raw, &v1beta1AzVolumeAttachment))
+	require.Equal(t, azVolumeAttachment.Spec.NodeName, v1beta1AzVolumeAttachment.Spec.NodeName)
+
+	// convert back up to v1beta2
+	response = serveConversionReview(t, newConversionReview(t, azdiskv1beta2.SchemeGroupVersion.String(), &v1beta1AzVolume, &v1beta1AzVolumeAttachment))
+	require.Equal(t, metav1.StatusSuccess, response.Result.Status)
+	require.Len(t, response.ConvertedObjects, 2)
+
+	v1beta2AzVolume := azdiskv1beta2.AzVolume{}
+	require.NoError(t, json.Unmarshal(response.ConvertedObjects[0].Raw, &v1beta2AzVolume))
+	require.Equal(t, azVolume.Spec, v1beta2AzVolume.Spec)
+	require.Equal(t, azVolume.Annotations, v1beta2AzVolume.Annotations)
+	require.Equal(t, azVolume.Status.Annotations, v1beta2AzVolume.Status.Annotations)
+
+	v1beta2AzVolumeAttachment := azdiskv1beta2.AzVolumeAttachment{}
+	require.NoError(t, json.Unmarshal(response.ConvertedObjects[1].Raw, &v1beta2AzVolumeAttachment))
+	require.Equal(t, azVolumeAttachment.Spec, v1beta2AzVolumeAttachment.Spec)
+	require.Equal(t, azVolumeAttachment.Annotations, v1beta2AzVolumeAttachment.Annotations)
+}
+
+func TestConversionWebhookUnsupportedKind(t *testing.T) {
+	azSnapshot := &azdiskv1beta2.AzSnapshot{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: azdiskv1beta2.SchemeGroupVersion.String(),
+			Kind:       "AzSnapshot",
+		},
+	}
+
+	response := serveConversionReview(t, newConversionReview(t, azdiskv1beta1.SchemeGroupVersion.String(), azSnapshot))
+	require.Equal(t, metav1.StatusFailure, response.Result.Status)
+	require.Empty(t, response.ConvertedObjects)
+}
+
+func TestConversionWebhookInvalidRequest(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	NewConversionWebhook().ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, ConversionWebhookPath, bytes.NewBufferString("invalid")))
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+}
@@ -0,0 +1,314 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-03-01/compute"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	azdiskv1beta2 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
+	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/workflow"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+type CloudDiskAttachmentLister interface {
+	GetNodeDataDisks(ctx context.Context, nodeID string) ([]compute.DataDisk, error)
+	UnpublishVolume(ctx context.Context, volumeID string, nodeID string) error
+}
+
+/*
+Dangling attachment detector periodically reconciles the data disks attached to the VMs of the cluster nodes against AzVolumeAttachments.
+For a disk attached to a node without a corresponding AzVolumeAttachment, it
+ 1. adopts the attachment by creating an attached primary AzVolumeAttachment if a VolumeAttachment for the disk and node exists
+ 2. detaches the disk after a grace period if the disk is referenced by a PersistentVolume but not by a VolumeAttachment for the node
+ 3. raises an event on the node otherwise, as the disk is not managed by the driver
+*/
+type DanglingAttachmentDetector struct {
+	*SharedState
+	logger          logr.Logger
+	cloudDiskLister CloudDiskAttachmentLister
+	scanInterval    time.Duration
+	gracePeriod     time.Duration
+	// danglingSince maps a dangling attachment to the time it was first detected
+	danglingSince map[string]time.Time
+}
+
+var _ manager.LeaderElectionRunnable = &DanglingAttachmentDetector{}
+
+// Start scans for dangling attachments at every scan interval until the context is done.
+func (d *DanglingAttachmentDetector) Start(ctx context.Context) error {
+	d.logger.V(2).Info("Starting dangling attachment detector.", "interval", d.scanInterval, "gracePeriod", d.gracePeriod)
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		// when sharded, dangling attachments are detected by the owner of the coordinator shard
+		if !d.isRecoveryComplete() || !d.isCoordinator() {
+			return
+		}
+		_ = d.detectDanglingAttachments(ctx)
+	}, d.scanInterval)
+	return nil
+}
+
+// NeedLeaderElection returns true so that only the leader controller plugin detaches dangling attachments.
+func (d *DanglingAttachmentDetector) NeedLeaderElection() bool {
+	return true
+}
+
+func (d *DanglingAttachmentDetector) detectDanglingAttachments(ctx context.Context) error {
+	var err error
+	ctx, w := workflow.New(ctx)
+	defer func() { w.Finish(err) }()
+
+	var azDriverNodes *azdiskv1beta2.AzDriverNodeList
+	if azDriverNodes, err = d.azClient.DiskV1beta2().AzDriverNodes(d.config.ObjectNamespace).List(ctx, metav1.ListOptions{}); err != nil {
+		w.Logger().Error(err, "failed to list AzDriverNodes")
+		return err
+	}
+
+	var persistentVolumes *corev1.PersistentVolumeList
+	if persistentVolumes, err = d.kubeClient.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{}); err != nil {
+		w.Logger().Error(err, "failed to list PersistentVolumes")
+		return err
+	}
+	// map disk URI to the PersistentVolume referencing the disk
+	diskToPersistentVolumeMap := map[string]*corev1.PersistentVolume{}
+	for i := range persistentVolumes.Items {
+		pv := &persistentVolumes.Items[i]
+		if pv.Spec.CSI != nil && pv.Spec.CSI.Driver == d.config.DriverName {
+			diskToPersistentVolumeMap[strings.ToLower(pv.Spec.CSI.VolumeHandle)] = pv
+		}
+	}
+
+	var volumeAttachments *storagev1.VolumeAttachmentList
+	if volumeAttachments, err = d.kubeClient.StorageV1().VolumeAttachments().List(ctx, metav1.ListOptions{}); err != nil {
+		w.Logger().Error(err, "failed to list VolumeAttachments")
+		return err
+	}
+	// map PersistentVolume and node name pair to the VolumeAttachment attaching the PersistentVolume to the node
+	volumeAttachmentMap := map[string]*storagev1.VolumeAttachment{}
+	for i := range volumeAttachments.Items {
+		volumeAttachment := &volumeAttachments.Items[i]
+		if volumeAttachment.Spec.Attacher != d.config.DriverName || volumeAttachment.Spec.Source.PersistentVolumeName == nil {
+			continue
+		}
+		if deleteRequested, _ := objectDeletionRequested(volumeAttachment); deleteRequested {
+			continue
+		}
+		volumeAttachmentMap[getDanglingAttachmentKey(*volumeAttachment.Spec.Source.PersistentVolumeName, volumeAttachment.Spec.NodeName)] = volumeAttachment
+	}
+
+	detected := set{}
+	for _, azDriverNode := range azDriverNodes.Items {
+		nodeName := azDriverNode.Spec.NodeName
+
+		var dataDisks []compute.DataDisk
+		if dataDisks, err = d.cloudDiskLister.GetNodeDataDisks(ctx, nodeName); err != nil {
+			w.Logger().Errorf(err, "failed to get data disks of node (%s)", nodeName)
+			continue
+		}
+
+		var azVolumeAttachments []azdiskv1beta2.AzVolumeAttachment
+		if azVolumeAttachments, err = azureutils.GetAzVolumeAttachmentsForNode(ctx, d.cachedClient, nodeName, azureutils.AllRoles); err != nil {
+			w.Logger().Errorf(err, "failed to list AzVolumeAttachments of node (%s)", nodeName)
+			continue
+		}
+		attachedDisks := set{}
+		for _, azVolumeAttachment := range azVolumeAttachments {
+			attachedDisks.add(strings.ToLower(azVolumeAttachment.Spec.VolumeID))
+		}
+
+		for _, dataDisk := range dataDisks {
+			if dataDisk.ManagedDisk == nil || dataDisk.ManagedDisk.ID == nil || (dataDisk.ToBeDetached != nil && *dataDisk.ToBeDetached) {
+				continue
+			}
+			diskURI := *dataDisk.ManagedDisk.ID
+			if attachedDisks.has(strings.ToLower(diskURI)) {
+				continue
+			}
+
+			key := getDanglingAttachmentKey(strings.ToLower(diskURI), nodeName)
+			detected.add(key)
+			if herr := d.handleDanglingAttachment(ctx, key, diskURI, nodeName, dataDisk.Lun, diskToPersistentVolumeMap, volumeAttachmentMap); herr != nil {
+				w.Logger().Errorf(herr, "failed to handle dangling attachment of disk (%s) to node (%s)", diskURI, nodeName)
+			}
+		}
+	}
+
+	// forget the dangling attachments which have been resolved since the last scan
+	for key := range d.danglingSince {
+		if !detected.has(key) {
+			delete(d.danglingSince, key)
+		}
+	}
+	return nil
+}
+
+func (d *DanglingAttachmentDetector) handleDanglingAttachment(ctx context.Context, key, diskURI, nodeName string, lun *int32, diskToPersistentVolumeMap map[string]*corev1.PersistentVolume, volumeAttachmentMap map[string]*storagev1.VolumeAttachment) error {
+	w, _ := workflow.GetWorkflowFromContext(ctx)
+
+	detectedAt, seen := d.danglingSince[key]
+	if !seen {
+		detectedAt = time.Now()
+		d.danglingSince[key] = detectedAt
+	}
+
+	pv, ok := diskToPersistentVolumeMap[strings.ToLower(diskURI)]
+	if !ok {
+		// the disk is not managed by the driver, so leave it to the cluster administrator
+		if !seen {
+			w.Logger().Infof("Disk (%s) not managed by the driver is attached to node (%s)", diskURI, nodeName)
+			d.eventRecorder.Eventf(getNodeReference(nodeName), corev1.EventTypeWarning, consts.DanglingAttachmentDetectedEvent, "Disk %s is attached to node %s but is not referenced by any PersistentVolume", diskURI, nodeName)
+		}
+		return nil
+	}
+
+	if volumeAttachment, ok := volumeAttachmentMap[getDanglingAttachmentKey(pv.Name, nodeName)]; ok {
+		if err := d.adoptDanglingAttachment(ctx, pv, volumeAttachment, lun); err != nil {
+			return err
+		}
+		delete(d.danglingSince, key)
+		d.eventRecorder.Eventf(pv, corev1.EventTypeNormal, consts.DanglingAttachmentAdoptedEvent, "Created AzVolumeAttachment for disk %s attached to node %s", diskURI, nodeName)
+		return nil
+	}
+
+	if !seen {
+		w.Logger().Infof("Disk (%s) is attached to node (%s) without a VolumeAttachment. Detaching it after %v.", diskURI, nodeName, d.gracePeriod)
+		d.eventRecorder.Eventf(pv, corev1.EventTypeWarning, consts.DanglingAttachmentDetectedEvent, "Disk %s is attached to node %s without a VolumeAttachment and will be detached after %v", diskURI, nodeName, d.gracePeriod)
+	}
+	if time.Since(detectedAt) < d.gracePeriod {
+		return nil
+	}
+
+	w.Logger().Infof("Detaching dangling attachment of disk (%s) from node (%s)", diskURI, nodeName)
+	if err := d.cloudDiskLister.UnpublishVolume(ctx, diskURI, nodeName); err != nil {
+		return err
+	}
+	delete(d.danglingSince, key)
+	d.eventRecorder.Eventf(pv, corev1.EventTypeNormal, consts.DanglingAttachmentDetachedEvent, "Detached disk %s from node %s", diskURI, nodeName)
+	return nil
+}
+
+// adoptDanglingAttachment creates an attached primary AzVolumeAttachment for the disk attached to the node by the VolumeAttachment.
+func (d *DanglingAttachmentDetector) adoptDanglingAttachment(ctx context.Context, pv *corev1.PersistentVolume, volumeAttachment *storagev1.VolumeAttachment, lun *int32) error {
+	w, _ := workflow.GetWorkflowFromContext(ctx)
+
+	diskName, err := azureutils.GetDiskName(pv.Spec.CSI.VolumeHandle)
+	if err != nil {
+		return err
+	}
+	volumeName := strings.ToLower(diskName)
+	nodeName := volumeAttachment.Spec.NodeName
+	azVolumeAttachmentName := azureutils.GetAzVolumeAttachmentName(diskName, nodeName)
+
+	azVolumeAttachment := &azdiskv1beta2.AzVolumeAttachment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      azVolumeAttachmentName,
+			Namespace: d.config.ObjectNamespace,
+			Labels: map[string]string{
+				consts.NodeNameLabel:   nodeName,
+				consts.VolumeNameLabel: volumeName,
+				consts.RoleLabel:       string(azdiskv1beta2.PrimaryRole),
+			},
+			Finalizers: []string{consts.AzVolumeAttachmentFinalizer},
+		},
+		Spec: azdiskv1beta2.AzVolumeAttachmentSpec{
+			VolumeName:    volumeName,
+			VolumeID:      pv.Spec.CSI.VolumeHandle,
+			NodeName:      nodeName,
+			RequestedRole: azdiskv1beta2.PrimaryRole,
+			VolumeContext: pv.Spec.CSI.VolumeAttributes,
+		},
+	}
+	azureutils.AnnotateAPIVersion(azVolumeAttachment)
+
+	w.Logger().Infof("Adopting dangling attachment of volume (%s) to node (%s)", volumeName, nodeName)
+	azVolumeAttachment, err = d.azClient.DiskV1beta2().AzVolumeAttachments(d.config.ObjectNamespace).Create(ctx, azVolumeAttachment, metav1.CreateOptions{})
+	if err != nil {
+		if apiErrors.IsAlreadyExists(err) {
+			// the AzVolumeAttachment was created since the scan started
+			return nil
+		}
+		return err
+	}
+	d.azVolumeAttachmentToVaMap.Store(azVolumeAttachmentName, volumeAttachment.Name)
+
+	var publishContext map[string]string
+	if lun != nil {
+		publishContext = map[string]string{consts.LUN: strconv.Itoa(int(*lun))}
+	}
+	azVolumeAttachment.Status.Annotations = azureutils.AddToMap(azVolumeAttachment.Status.Annotations, consts.VolumeAttachmentKey, volumeAttachment.Name)
+	azVolumeAttachment = updateStatusDetail(azVolumeAttachment, publishContext)
+	_, _ = updateState(azVolumeAttachment, azdiskv1beta2.Attached, forceUpdate)
+	if _, err = d.azClient.DiskV1beta2().AzVolumeAttachments(d.config.ObjectNamespace).UpdateStatus(ctx, azVolumeAttachment, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+
+	// the disk attachment occupies one of the node's data disk slots
+	d.decrementAttachmentCount(ctx, nodeName)
+	return nil
+}
+
+func getDanglingAttachmentKey(volume, nodeName string) string {
+	return volume + "/" + nodeName
+}
+
+func getNodeReference(nodeName string) *corev1.ObjectReference {
+	return &corev1.ObjectReference{
+		Kind: "Node",
+		Name: nodeName,
+		UID:  types.UID(nodeName),
+	}
+}
+
+// NewDanglingAttachmentDetector initializes the dangling attachment detector and adds it to the manager.
+// It returns nil if dangling attachment detection is disabled.
+func NewDanglingAttachmentDetector(mgr manager.Manager, cloudDiskLister CloudDiskAttachmentLister, controllerSharedState *SharedState) (*DanglingAttachmentDetector, error) {
+	logger := mgr.GetLogger().WithValues("controller", "danglingattachment")
+	scanInterval := time.Duration(controllerSharedState.config.ControllerConfig.DanglingAttachmentScanIntervalInSec) * time.Second
+	if scanInterval <= 0 {
+		logger.V(2).Info("Dangling attachment detection is disabled.")
+		return nil, nil
+	}
+
+	detector := &DanglingAttachmentDetector{
+		SharedState:     controllerSharedState,
+		logger:          logger,
+		cloudDiskLister: cloudDiskLister,
+		scanInterval:    scanInterval,
+		gracePeriod:     time.Duration(controllerSharedState.config.ControllerConfig.DanglingAttachmentGracePeriodInSec) * time.Second,
+		danglingSince:   map[string]time.Time{},
+	}
+
+	if err := mgr.Add(detector); err != nil {
+		logger.Error(err, "failed to add dangling attachment detector to manager")
+		return nil, err
+	}
+	logger.V(2).Info("Controller set-up successful.")
+
+	return detector, nil
+}
@@ -0,0 +1,233 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-03-01/compute"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2/klogr"
+	azdiskv1beta2 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
+	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils/mockclient"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/controller/mockattachmentlister"
+)
+
+func NewTestDanglingAttachmentDetector(controller *gomock.Controller, namespace string, objects ...runtime.Object) *DanglingAttachmentDetector {
+	controllerSharedState := NewTestSharedState(controller, namespace, objects...)
+	controllerSharedState.eventRecorder = record.NewFakeRecorder(10)
+
+	return &DanglingAttachmentDetector{
+		SharedState:     controllerSharedState,
+		logger:          klogr.New(),
+		cloudDiskLister: mockattachmentlister.NewMockCloudDiskAttachmentLister(controller),
+		scanInterval:    time.Minute,
+		gracePeriod:     time.Hour,
+		danglingSince:   map[string]time.Time{},
+	}
+}
+
+func newTestDataDisk(diskURI string, lun int32) compute.DataDisk {
+	return compute.DataDisk{
+		Lun:         &lun,
+		ManagedDisk: &compute.ManagedDiskParameters{ID: &diskURI},
+	}
+}
+
+func requireEvents(t *testing.T, detector *DanglingAttachmentDetector, expectedReasons ...string) {
+	events := detector.eventRecorder.(*record.FakeRecorder).Events
+	for _, reason := range expectedReasons {
+		select {
+		case event := <-events:
+			require.Contains(t, event, reason)
+		default:
+			require.Failf(t, "missing event", "expected event with reason %s", reason)
+		}
+	}
+	require.Empty(t, events)
+}
+
+func TestDetectDanglingAttachments(t *testing.T) {
+	tests := []struct {
+		description string
+		setupFunc   func(*testing.T, *gomock.Controller) *DanglingAttachmentDetector
+		verifyFunc  func(*testing.T, *DanglingAttachmentDetector, error)
+	}{
+		{
+			description: "[Success] Should ignore disks with AzVolumeAttachments",
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *DanglingAttachmentDetector {
+				detector := NewTestDanglingAttachmentDetector(
+					mockCtl,
+					testNamespace,
+					&testAzDriverNode0,
+					&testPersistentVolume0,
+					&testPrimaryAzVolumeAttachment0,
+				)
+				mockClients(detector.cachedClient.(*mockclient.MockClient), detector.azClient, detector.kubeClient)
+
+				detector.cloudDiskLister.(*mockattachmentlister.MockCloudDiskAttachmentLister).EXPECT().
+					GetNodeDataDisks(gomock.Any(), testNode0Name).
+					Return([]compute.DataDisk{newTestDataDisk(strings.ToUpper(testManagedDiskURI0), 0)}, nil)
+
+				return detector
+			},
+			verifyFunc: func(t *testing.T, detector *DanglingAttachmentDetector, err error) {
+				require.NoError(t, err)
+				require.Empty(t, detector.danglingSince)
+				requireEvents(t, detector)
+			},
+		},
+		{
+			description: "[Success] Should adopt dangling attachment of disk with VolumeAttachment",
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *DanglingAttachmentDetector {
+				detector := NewTestDanglingAttachmentDetector(
+					mockCtl,
+					testNamespace,
+					&testAzDriverNode0,
+					&testPersistentVolume0,
+					&testVolumeAttachment,
+				)
+				mockClients(detector.cachedClient.(*mockclient.MockClient), detector.azClient, detector.kubeClient)
+
+				detector.cloudDiskLister.(*mockattachmentlister.MockCloudDiskAttachmentLister).EXPECT().
+					GetNodeDataDisks(gomock.Any(), testNode0Name).
+					Return([]compute.DataDisk{newTestDataDisk(testManagedDiskURI0, 1)}, nil)
+
+				return detector
+			},
+			verifyFunc: func(t *testing.T, detector *DanglingAttachmentDetector, err error) {
+				require.NoError(t, err)
+
+				azVolumeAttachment, err := detector.azClient.DiskV1beta2().AzVolumeAttachments(testNamespace).Get(context.TODO(), testPrimaryAzVolumeAttachment0Name, metav1.GetOptions{})
+				require.NoError(t, err)
+				require.Equal(t, azdiskv1beta2.PrimaryRole, azVolumeAttachment.Spec.RequestedRole)
+				require.Equal(t, azdiskv1beta2.Attached, azVolumeAttachment.Status.State)
+				require.NotNil(t, azVolumeAttachment.Status.Detail)
+				require.Equal(t, azdiskv1beta2.PrimaryRole, azVolumeAttachment.Status.Detail.Role)
+				require.Equal(t, "1", azVolumeAttachment.Status.Detail.PublishContext[consts.LUN])
+				require.Equal(t, testVolumeAttachmentName, azVolumeAttachment.Status.Annotations[consts.VolumeAttachmentKey])
+				require.Empty(t, detector.danglingSince)
+				requireEvents(t, detector, consts.DanglingAttachmentAdoptedEvent)
+			},
+		},
+		{
+			description: "[Success] Should detach dangling attachment of disk without VolumeAttachment after grace period",
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *DanglingAttachmentDetector {
+				detector := NewTestDanglingAttachmentDetector(
+					mockCtl,
+					testNamespace,
+					&testAzDriverNode0,
+					&testPersistentVolume0,
+				)
+				mockClients(detector.cachedClient.(*mockclient.MockClient), detector.azClient, detector.kubeClient)
+
+				mockLister := detector.cloudDiskLister.(*mockattachmentlister.MockCloudDiskAttachmentLister)
+				mockLister.EXPECT().
+					GetNodeDataDisks(gomock.Any(), testNode0Name).
+					Return([]compute.DataDisk{newTestDataDisk(testManagedDiskURI0, 0)}, nil).
+					Times(2)
+
+				// detect the dangling attachment without detaching the disk during the grace period
+				require.NoError(t, detector.detectDanglingAttachments(context.TODO()))
+				require.Len(t, detector.danglingSince, 1)
+				requireEvents(t, detector, consts.DanglingAttachmentDetectedEvent)
+
+				for key := range detector.danglingSince {
+					detector.danglingSince[key] = time.Now().Add(-detector.gracePeriod)
+				}
+				mockLister.EXPECT().
+					UnpublishVolume(gomock.Any(), testManagedDiskURI0, testNode0Name).
+					Return(nil)
+
+				return detector
+			},
+			verifyFunc: func(t *testing.T, detector *DanglingAttachmentDetector, err error) {
+				require.NoError(t, err)
+				require.Empty(t, detector.danglingSince)
+				requireEvents(t, detector, consts.DanglingAttachmentDetachedEvent)
+			},
+		},
+		{
+			description: "[Success] Should only raise an event for disk not referenced by PersistentVolume",
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *DanglingAttachmentDetector {
+				detector := NewTestDanglingAttachmentDetector(
+					mockCtl,
+					testNamespace,
+					&testAzDriverNode0,
+				)
+				mockClients(detector.cachedClient.(*mockclient.MockClient), detector.azClient, detector.kubeClient)
+
+				detector.cloudDiskLister.(*mockattachmentlister.MockCloudDiskAttachmentLister).EXPECT().
+					GetNodeDataDisks(gomock.Any(), testNode0Name).
+					Return([]compute.DataDisk{newTestDataDisk(testManagedDiskURI0, 0)}, nil)
+
+				return detector
+			},
+			verifyFunc: func(t *testing.T, detector *DanglingAttachmentDetector, err error) {
+				require.NoError(t, err)
+				require.Len(t, detector.danglingSince, 1)
+				requireEvents(t, detector, consts.DanglingAttachmentDetectedEvent)
+
+				_, err = detector.azClient.DiskV1beta2().AzVolumeAttachments(testNamespace).Get(context.TODO(), testPrimaryAzVolumeAttachment0Name, metav1.GetOptions{})
+				require.Error(t, err)
+			},
+		},
+		{
+			description: "[Success] Should forget resolved dangling attachments",
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *DanglingAttachmentDetector {
+				detector := NewTestDanglingAttachmentDetector(
+					mockCtl,
+					testNamespace,
+					&testAzDriverNode0,
+					&testPersistentVolume0,
+				)
+				mockClients(detector.cachedClient.(*mockclient.MockClient), detector.azClient, detector.kubeClient)
+				detector.danglingSince[getDanglingAttachmentKey(strings.ToLower(testManagedDiskURI0), testNode0Name)] = time.Now()
+
+				detector.cloudDiskLister.(*mockattachmentlister.MockCloudDiskAttachmentLister).EXPECT().
+					GetNodeDataDisks(gomock.Any(), testNode0Name).
+					Return([]compute.DataDisk{}, nil)
+
+				return detector
+			},
+			verifyFunc: func(t *testing.T, detector *DanglingAttachmentDetector, err error) {
+				require.NoError(t, err)
+				require.Empty(t, detector.danglingSince)
+				requireEvents(t, detector)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		tt := test
+		t.Run(tt.description, func(t *testing.T) {
+			mockCtl := gomock.NewController(t)
+			defer mockCtl.Finish()
+			detector := tt.setupFunc(t, mockCtl)
+			err := detector.detectDanglingAttachments(context.TODO())
+			tt.verifyFunc(t, detector, err)
+		})
+	}
+}
@@ -0,0 +1,276 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	crdClientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2/klogr"
+	azdiskv1beta2 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
+	azdisk "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/client/clientset/versioned"
+	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/provisioner"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/workflow"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DryRunCloudProvisioner records the cloud operations the controllers would perform instead of performing them.
+// Read-only operations are passed through to the underlying cloud provisioner.
+type DryRunCloudProvisioner struct {
+	CloudProvisioner
+	logger logr.Logger
+}
+
+var _ CloudProvisioner = &DryRunCloudProvisioner{}
+
+// NewDryRunCloudProvisioner wraps the cloud provisioner so that operations modifying cloud resources are only recorded.
+func NewDryRunCloudProvisioner(cloudProvisioner CloudProvisioner, logger logr.Logger) *DryRunCloudProvisioner {
+	return &DryRunCloudProvisioner{
+		CloudProvisioner: cloudProvisioner,
+		logger:           logger.WithValues("controller", "dryrun"),
+	}
+}
+
+func (p *DryRunCloudProvisioner) record(ctx context.Context, operation string, keysAndValues ...interface{}) {
+	logger := p.logger
+	if w, ok := workflow.GetWorkflowFromContext(ctx); ok {
+		logger = w.Logger().Logger
+	}
+	logger.WithValues(consts.DryRunOperationKey, operation).Info("Skipping cloud operation in dry-run mode", keysAndValues...)
+}
+
+func (p *DryRunCloudProvisioner) getResourceGroup() (subscriptionID, resourceGroup string) {
+	if cloud := p.GetCloud(); cloud != nil {
+		subscriptionID, resourceGroup = cloud.SubscriptionID, cloud.ResourceGroup
+	}
+	return
+}
+
+func (p *DryRunCloudProvisioner) CreateVolume(
+	ctx context.Context,
+	volumeName string,
+	capacityRange *azdiskv1beta2.CapacityRange,
+	volumeCapabilities []azdiskv1beta2.VolumeCapability,
+	parameters map[string]string,
+	secrets map[string]string,
+	volumeContentSource *azdiskv1beta2.ContentVolumeSource,
+	accessibilityTopology *azdiskv1beta2.TopologyRequirement) (*azdiskv1beta2.AzVolumeStatusDetail, error) {
+	subscriptionID, resourceGroup := p.getResourceGroup()
+	volumeID := fmt.Sprintf(consts.ManagedDiskPath, subscriptionID, resourceGroup, volumeName)
+
+	var capacityBytes int64
+	if capacityRange != nil {
+		capacityBytes = capacityRange.RequiredBytes
+	}
+	p.record(ctx, "CreateVolume", consts.VolumeNameLabel, volumeName, consts.VolumeIDLabel, volumeID, "capacityBytes", capacityBytes)
+
+	return &azdiskv1beta2.AzVolumeStatusDetail{
+		VolumeID:      volumeID,
+		CapacityBytes: capacityBytes,
+		VolumeContext: parameters,
+		ContentSource: volumeContentSource,
+	}, nil
+}
+
+func (p *DryRunCloudProvisioner) DeleteVolume(ctx context.Context, volumeID string, secrets map[string]string) error {
+	p.record(ctx, "DeleteVolume", consts.VolumeIDLabel, volumeID)
+	return nil
+}
+
+func (p *DryRunCloudProvisioner) PublishVolume(ctx context.Context, volumeID string, nodeID string, volumeContext map[string]string) provisioner.CloudAttachResult {
+	p.record(ctx, "PublishVolume", consts.VolumeIDLabel, volumeID, consts.NodeNameLabel, nodeID)
+
+	attachResult := provisioner.NewCloudAttachResult()
+	attachResult.ResultChannel() <- nil
+	close(attachResult.ResultChannel())
+	return attachResult
+}
+
+func (p *DryRunCloudProvisioner) UnpublishVolume(ctx context.Context, volumeID string, nodeID string) error {
+	p.record(ctx, "UnpublishVolume", consts.VolumeIDLabel, volumeID, consts.NodeNameLabel, nodeID)
+	return nil
+}
+
+func (p *DryRunCloudProvisioner) ExpandVolume(ctx context.Context, volumeID string, capacityRange *azdiskv1beta2.CapacityRange, secrets map[string]string) (*azdiskv1beta2.AzVolumeStatusDetail, error) {
+	var capacityBytes int64
+	if capacityRange != nil {
+		capacityBytes = capacityRange.RequiredBytes
+	}
+	p.record(ctx, "ExpandVolume", consts.VolumeIDLabel, volumeID, "capacityBytes", capacityBytes)
+
+	return &azdiskv1beta2.AzVolumeStatusDetail{
+		VolumeID:              volumeID,
+		CapacityBytes:         capacityBytes,
+		NodeExpansionRequired: true,
+	}, nil
+}
+
+func (p *DryRunCloudProvisioner) ModifyVolume(ctx context.Context, volumeID string, parameters map[string]string) error {
+	p.record(ctx, "ModifyVolume", consts.VolumeIDLabel, volumeID, "parameters", parameters)
+	return nil
+}
+
+func (p *DryRunCloudProvisioner) UpdateDiskTags(ctx context.Context, volumeID string, tags map[string]*string) error {
+	p.record(ctx, "UpdateDiskTags", consts.VolumeIDLabel, volumeID)
+	return nil
+}
+
+func (p *DryRunCloudProvisioner) CreateSnapshot(ctx context.Context, sourceVolumeID string, snapshotName string, secrets map[string]string, parameters map[string]string) (*azdiskv1beta2.Snapshot, error) {
+	subscriptionID, resourceGroup := p.getResourceGroup()
+	snapshotID := fmt.Sprintf(consts.DiskSnapshotPath, subscriptionID, resourceGroup, snapshotName)
+	p.record(ctx, "CreateSnapshot", consts.VolumeIDLabel, sourceVolumeID, "snapshotID", snapshotID)
+
+	return &azdiskv1beta2.Snapshot{
+		SnapshotID:     snapshotID,
+		SourceVolumeID: sourceVolumeID,
+		CreationTime:   metav1.Now(),
+		ReadyToUse:     true,
+	}, nil
+}
+
+func (p *DryRunCloudProvisioner) DeleteSnapshot(ctx context.Context, snapshotID string, secrets map[string]string) error {
+	p.record(ctx, "DeleteSnapshot", "snapshotID", snapshotID)
+	return nil
+}
+
+func (p *DryRunCloudProvisioner) CreateGroupSnapshot(ctx context.Context, groupSnapshotName string, sourceVolumeIDs []string, secrets map[string]string, parameters map[string]string) (*azdiskv1beta2.GroupSnapshot, error) {
+	subscriptionID, resourceGroup := p.getResourceGroup()
+	groupSnapshotID := fmt.Sprintf(consts.GroupSnapshotPath, subscriptionID, resourceGroup, groupSnapshotName)
+	p.record(ctx, "CreateGroupSnapshot", "groupSnapshotID", groupSnapshotID, "sourceVolumeIDs", sourceVolumeIDs)
+
+	groupSnapshot := &azdiskv1beta2.GroupSnapshot{
+		GroupSnapshotID: groupSnapshotID,
+		CreationTime:    metav1.Now(),
+		ReadyToUse:      true,
+	}
+	for _, sourceVolumeID := range sourceVolumeIDs {
+		diskName, err := azureutils.GetDiskName(sourceVolumeID)
+		if err != nil {
+			return nil, err
+		}
+		snapshotName := azureutils.CreateValidDiskName(fmt.Sprintf("%s-%s", groupSnapshotName, diskName), true)
+		groupSnapshot.Snapshots = append(groupSnapshot.Snapshots, azdiskv1beta2.Snapshot{
+			SnapshotID:     fmt.Sprintf(consts.DiskSnapshotPath, subscriptionID, resourceGroup, snapshotName),
+			SourceVolumeID: sourceVolumeID,
+			CreationTime:   groupSnapshot.CreationTime,
+			ReadyToUse:     true,
+		})
+	}
+	return groupSnapshot, nil
+}
+
+func (p *DryRunCloudProvisioner) DeleteGroupSnapshot(ctx context.Context, groupSnapshotID string, secrets map[string]string) error {
+	p.record(ctx, "DeleteGroupSnapshot", "groupSnapshotID", groupSnapshotID)
+	return nil
+}
+
+// dryRunRoundTripper requests the API server to validate but not persist every modifying request.
+type dryRunRoundTripper struct {
+	delegate http.RoundTripper
+}
+
+func (rt *dryRunRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch req.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		req = req.Clone(req.Context())
+		query := req.URL.Query()
+		query.Set("dryRun", metav1.DryRunAll)
+		req.URL.RawQuery = query.Encode()
+	}
+	return rt.delegate.RoundTrip(req)
+}
+
+// EnableDryRun replaces the clients of the shared state with clients that only validate modifying requests
+// so that the controllers do not persist any change to the custom resources.
+func (c *SharedState) EnableDryRun(kubeConfig *rest.Config) error {
+	dryRunConfig := rest.CopyConfig(kubeConfig)
+	dryRunConfig.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &dryRunRoundTripper{delegate: rt}
+	})
+
+	azClient, err := azdisk.NewForConfig(dryRunConfig)
+	if err != nil {
+		return err
+	}
+	kubeClient, err := kubernetes.NewForConfig(dryRunConfig)
+	if err != nil {
+		return err
+	}
+	crdClient, err := crdClientset.NewForConfig(dryRunConfig)
+	if err != nil {
+		return err
+	}
+
+	c.azClient = azClient
+	c.kubeClient = kubeClient
+	c.crdClient = crdClient
+	c.cachedClient = client.NewDryRunClient(c.cachedClient)
+	return nil
+}
+
+func (c *SharedState) isDryRun() bool {
+	return c.config.ControllerConfig.DryRun
+}
+
+// recordDryRunOperation logs the operation skipped in dry-run mode and raises an event for it on the specified object.
+func (c *SharedState) recordDryRunOperation(ctx context.Context, object *corev1.ObjectReference, operation string, message string, keysAndValues ...interface{}) {
+	logger := klogr.New()
+	if w, ok := workflow.GetWorkflowFromContext(ctx); ok {
+		logger = w.Logger().Logger
+	}
+	logger.WithValues(consts.DryRunOperationKey, operation).Info(message, keysAndValues...)
+	c.eventRecorder.Eventf(object, corev1.EventTypeNormal, consts.DryRunOperationEvent, "%s (dry-run): %s", operation, message)
+}
+
+// getPersistentVolumeReference returns a reference to the PersistentVolume for recording events on it.
+// The UID of the reference is left empty if the PersistentVolume is not in the cache.
+func (c *SharedState) getPersistentVolumeReference(ctx context.Context, pvName string) *corev1.ObjectReference {
+	reference := &corev1.ObjectReference{
+		Kind:       "PersistentVolume",
+		APIVersion: "v1",
+		Name:       pvName,
+	}
+
+	var pv corev1.PersistentVolume
+	if err := c.cachedClient.Get(ctx, types.NamespacedName{Name: pvName}, &pv); err == nil {
+		reference.UID = pv.UID
+	}
+	return reference
+}
+
+// recordDryRunCleanUp records the detach or delete request the clean-up of the AzVolumeAttachment would issue.
+func (c *SharedState) recordDryRunCleanUp(ctx context.Context, attachment *azdiskv1beta2.AzVolumeAttachment, cleanUpMode attachmentCleanUpMode) {
+	if deleteRequested, _ := objectDeletionRequested(attachment); deleteRequested || volumeDetachRequested(attachment) {
+		return
+	}
+
+	operation, verb := "DetachAzVolumeAttachment", "detach"
+	if attachment.Spec.RequestedRole == azdiskv1beta2.PrimaryRole && cleanUpMode != cleanUpAttachment {
+		operation, verb = "DeleteAzVolumeAttachment", "delete"
+	}
+	c.recordDryRunOperation(ctx, getNodeReference(attachment.Spec.NodeName), operation, fmt.Sprintf("Would %s %s AzVolumeAttachment %s for volume %s on node %s", verb, attachment.Spec.RequestedRole, attachment.Name, attachment.Spec.VolumeName, attachment.Spec.NodeName))
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2/klogr"
+	azdiskv1beta2 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
+	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils/mockclient"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/workflow"
+	"sigs.k8s.io/cloud-provider-azure/pkg/provider"
+)
+
+// testCloudProvisioner only implements GetCloud. Calling any other method of the cloud provisioner panics.
+type testCloudProvisioner struct {
+	CloudProvisioner
+}
+
+func (p *testCloudProvisioner) GetCloud() *provider.Cloud {
+	cloud := &provider.Cloud{}
+	cloud.SubscriptionID = testSubscription
+	cloud.ResourceGroup = testResourceGroup
+	return cloud
+}
+
+func NewTestDryRunSharedState(mockCtl *gomock.Controller, objects ...runtime.Object) (*SharedState, *record.FakeRecorder) {
+	sharedState := NewTestSharedState(mockCtl, testNamespace, objects...)
+	sharedState.config.ControllerConfig.DryRun = true
+	eventRecorder := record.NewFakeRecorder(10)
+	sharedState.eventRecorder = eventRecorder
+	return sharedState, eventRecorder
+}
+
+func requireDryRunEvent(t *testing.T, eventRecorder *record.FakeRecorder, operation string) {
+	select {
+	case event := <-eventRecorder.Events:
+		require.True(t, strings.Contains(event, consts.DryRunOperationEvent), "unexpected event: %s", event)
+		require.True(t, strings.Contains(event, operation), "unexpected event: %s", event)
+	default:
+		require.FailNow(t, "no event", "no event recorded for dry-run operation %s", operation)
+	}
+}
+
+func TestDryRunCloudProvisioner(t *testing.T) {
+	cloudProvisioner := NewDryRunCloudProvisioner(&testCloudProvisioner{}, klogr.New())
+	ctx, w := workflow.New(context.TODO())
+	defer w.Finish(nil)
+
+	detail, err := cloudProvisioner.CreateVolume(ctx, testPersistentVolume0Name, &azdiskv1beta2.CapacityRange{RequiredBytes: 10}, nil, nil, nil, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, testManagedDiskURI0, detail.VolumeID)
+	require.Equal(t, int64(10), detail.CapacityBytes)
+
+	attachResult := cloudProvisioner.PublishVolume(ctx, testManagedDiskURI0, testNode0Name, nil)
+	require.NoError(t, <-attachResult.ResultChannel())
+
+	snapshot, err := cloudProvisioner.CreateSnapshot(ctx, testManagedDiskURI0, "test-snapshot", nil, nil)
+	require.NoError(t, err)
+	require.True(t, snapshot.ReadyToUse)
+	require.Equal(t, testManagedDiskURI0, snapshot.SourceVolumeID)
+
+	require.NoError(t, cloudProvisioner.UnpublishVolume(ctx, testManagedDiskURI0, testNode0Name))
+	require.NoError(t, cloudProvisioner.DeleteVolume(ctx, testManagedDiskURI0, nil))
+	require.NoError(t, cloudProvisioner.DeleteSnapshot(ctx, snapshot.SnapshotID, nil))
+}
+
+func TestDryRunSharedState(t *testing.T) {
+	tests := []struct {
+		description string
+		verifyFunc  func(*testing.T, *SharedState, *record.FakeRecorder)
+	}{
+		{
+			description: "[Success] Should not create AzVolume in dry-run mode",
+			verifyFunc: func(t *testing.T, sharedState *SharedState, eventRecorder *record.FakeRecorder) {
+				mockClients(sharedState.cachedClient.(*mockclient.MockClient), sharedState.azClient, sharedState.kubeClient)
+				azVolume := testAzVolume0.DeepCopy()
+				azVolume.Spec.PersistentVolume = testPersistentVolume0Name
+				require.NoError(t, sharedState.createAzVolume(context.TODO(), azVolume))
+
+				_, err := sharedState.azClient.DiskV1beta2().AzVolumes(testNamespace).Get(context.TODO(), azVolume.Name, metav1.GetOptions{})
+				require.True(t, apiErrors.IsNotFound(err))
+				requireDryRunEvent(t, eventRecorder, "CreateAzVolume")
+			},
+		},
+		{
+			description: "[Success] Should not create replica AzVolumeAttachment in dry-run mode",
+			verifyFunc: func(t *testing.T, sharedState *SharedState, eventRecorder *record.FakeRecorder) {
+				require.NoError(t, sharedState.createReplicaAzVolumeAttachment(context.TODO(), testManagedDiskURI0, testNode1Name, nil))
+
+				_, err := sharedState.azClient.DiskV1beta2().AzVolumeAttachments(testNamespace).Get(context.TODO(), testReplicaAzVolumeAttachmentName, metav1.GetOptions{})
+				require.True(t, apiErrors.IsNotFound(err))
+				requireDryRunEvent(t, eventRecorder, "CreateReplicaAzVolumeAttachment")
+			},
+		},
+		{
+			description: "[Success] Should not detach or delete AzVolumeAttachments in dry-run mode",
+			verifyFunc: func(t *testing.T, sharedState *SharedState, eventRecorder *record.FakeRecorder) {
+				// the cached client mock has no expectations set, so any patch or delete request fails the test
+				attachments := []azdiskv1beta2.AzVolumeAttachment{testReplicaAzVolumeAttachment, testPrimaryAzVolumeAttachment0}
+				require.NoError(t, sharedState.cleanUpAzVolumeAttachments(context.TODO(), attachments, cleanUpAttachmentForUninstall, azdrivernode))
+
+				requireDryRunEvent(t, eventRecorder, "DetachAzVolumeAttachment")
+				requireDryRunEvent(t, eventRecorder, "DeleteAzVolumeAttachment")
+			},
+		},
+	}
+
+	for _, test := range tests {
+		tt := test
+		t.Run(tt.description, func(t *testing.T) {
+			mockCtl := gomock.NewController(t)
+			defer mockCtl.Finish()
+			sharedState, eventRecorder := NewTestDryRunSharedState(mockCtl)
+			tt.verifyFunc(t, sharedState, eventRecorder)
+		})
+	}
+}
+
+func TestGetPersistentVolumeReference(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	pv := testPersistentVolume0.DeepCopy()
+	pv.UID = "test-pv-uid"
+	sharedState := NewTestSharedState(mockCtl, testNamespace, pv)
+	mockClients(sharedState.cachedClient.(*mockclient.MockClient), sharedState.azClient, sharedState.kubeClient)
+
+	reference := sharedState.getPersistentVolumeReference(context.TODO(), testPersistentVolume0Name)
+	require.Equal(t, "PersistentVolume", reference.Kind)
+	require.Equal(t, testPersistentVolume0Name, reference.Name)
+	require.Equal(t, pv.UID, reference.UID)
+
+	reference = sharedState.getPersistentVolumeReference(context.TODO(), testPersistentVolume1Name)
+	require.Equal(t, testPersistentVolume1Name, reference.Name)
+	require.Empty(t, reference.UID)
+}
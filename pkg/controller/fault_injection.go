@@ -0,0 +1,189 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	azdiskv1beta2 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils/faultinjection"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/provisioner"
+	"sigs.k8s.io/cloud-provider-azure/pkg/retry"
+)
+
+// FaultInjectionCloudProvisioner injects the faults of the configured injector into the operations of the cloud provisioner.
+// The operations not modifying a disk are passed through to the underlying cloud provisioner.
+type FaultInjectionCloudProvisioner struct {
+	CloudProvisioner
+	injector *faultinjection.Injector
+}
+
+var _ CloudProvisioner = &FaultInjectionCloudProvisioner{}
+
+// NewFaultInjectionCloudProvisioner wraps the cloud provisioner so that the faults of the injector are injected into its operations.
+func NewFaultInjectionCloudProvisioner(cloudProvisioner CloudProvisioner, injector *faultinjection.Injector) *FaultInjectionCloudProvisioner {
+	return &FaultInjectionCloudProvisioner{
+		CloudProvisioner: cloudProvisioner,
+		injector:         injector,
+	}
+}
+
+func getFaultInjectionError(rerr *retry.Error) error {
+	switch rerr.HTTPStatusCode {
+	case http.StatusNotFound:
+		return status.Error(codes.NotFound, rerr.Error().Error())
+	case http.StatusBadRequest:
+		return status.Error(codes.InvalidArgument, rerr.Error().Error())
+	default:
+		return status.Error(codes.Internal, rerr.Error().Error())
+	}
+}
+
+// inject injects a fault into an operation returning only an error.
+func (p *FaultInjectionCloudProvisioner) inject(ctx context.Context, operation string, disks []string, call func() error) error {
+	rerr, dropResponse := p.injector.Inject(ctx, operation, disks...)
+	if rerr != nil {
+		return getFaultInjectionError(rerr)
+	}
+	err := call()
+	if err == nil && dropResponse {
+		err = getFaultInjectionError(faultinjection.DroppedResponseError(operation))
+	}
+	return err
+}
+
+func newFailedAttachResult(err error) provisioner.CloudAttachResult {
+	attachResult := provisioner.NewCloudAttachResult()
+	attachResult.ResultChannel() <- err
+	close(attachResult.ResultChannel())
+	return attachResult
+}
+
+// dropAttachResponse waits for the attach operation to complete and then replaces its result with a dropped response error.
+func dropAttachResponse(attachResult provisioner.CloudAttachResult, operation string) provisioner.CloudAttachResult {
+	droppedResult := provisioner.NewCloudAttachResult()
+	go func() {
+		<-attachResult.ResultChannel()
+		droppedResult.ResultChannel() <- getFaultInjectionError(faultinjection.DroppedResponseError(operation))
+		close(droppedResult.ResultChannel())
+	}()
+	return droppedResult
+}
+
+func (p *FaultInjectionCloudProvisioner) CreateVolume(
+	ctx context.Context,
+	volumeName string,
+	capacityRange *azdiskv1beta2.CapacityRange,
+	volumeCapabilities []azdiskv1beta2.VolumeCapability,
+	parameters map[string]string,
+	secrets map[string]string,
+	volumeContentSource *azdiskv1beta2.ContentVolumeSource,
+	accessibilityTopology *azdiskv1beta2.TopologyRequirement) (detail *azdiskv1beta2.AzVolumeStatusDetail, err error) {
+	err = p.inject(ctx, "CreateVolume", []string{volumeName}, func() error {
+		detail, err = p.CloudProvisioner.CreateVolume(ctx, volumeName, capacityRange, volumeCapabilities, parameters, secrets, volumeContentSource, accessibilityTopology)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return detail, nil
+}
+
+func (p *FaultInjectionCloudProvisioner) DeleteVolume(ctx context.Context, volumeID string, secrets map[string]string) error {
+	return p.inject(ctx, "DeleteVolume", []string{volumeID}, func() error {
+		return p.CloudProvisioner.DeleteVolume(ctx, volumeID, secrets)
+	})
+}
+
+func (p *FaultInjectionCloudProvisioner) PublishVolume(ctx context.Context, volumeID string, nodeID string, volumeContext map[string]string) provisioner.CloudAttachResult {
+	rerr, dropResponse := p.injector.Inject(ctx, "PublishVolume", volumeID)
+	if rerr != nil {
+		return newFailedAttachResult(getFaultInjectionError(rerr))
+	}
+
+	attachResult := p.CloudProvisioner.PublishVolume(ctx, volumeID, nodeID, volumeContext)
+	if dropResponse {
+		return dropAttachResponse(attachResult, "PublishVolume")
+	}
+	return attachResult
+}
+
+func (p *FaultInjectionCloudProvisioner) UnpublishVolume(ctx context.Context, volumeID string, nodeID string) error {
+	return p.inject(ctx, "UnpublishVolume", []string{volumeID}, func() error {
+		return p.CloudProvisioner.UnpublishVolume(ctx, volumeID, nodeID)
+	})
+}
+
+func (p *FaultInjectionCloudProvisioner) ExpandVolume(ctx context.Context, volumeID string, capacityRange *azdiskv1beta2.CapacityRange, secrets map[string]string) (detail *azdiskv1beta2.AzVolumeStatusDetail, err error) {
+	err = p.inject(ctx, "ExpandVolume", []string{volumeID}, func() error {
+		detail, err = p.CloudProvisioner.ExpandVolume(ctx, volumeID, capacityRange, secrets)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return detail, nil
+}
+
+func (p *FaultInjectionCloudProvisioner) ModifyVolume(ctx context.Context, volumeID string, parameters map[string]string) error {
+	return p.inject(ctx, "ModifyVolume", []string{volumeID}, func() error {
+		return p.CloudProvisioner.ModifyVolume(ctx, volumeID, parameters)
+	})
+}
+
+func (p *FaultInjectionCloudProvisioner) UpdateDiskTags(ctx context.Context, volumeID string, tags map[string]*string) error {
+	return p.inject(ctx, "UpdateDiskTags", []string{volumeID}, func() error {
+		return p.CloudProvisioner.UpdateDiskTags(ctx, volumeID, tags)
+	})
+}
+
+func (p *FaultInjectionCloudProvisioner) CreateSnapshot(ctx context.Context, sourceVolumeID string, snapshotName string, secrets map[string]string, parameters map[string]string) (snapshot *azdiskv1beta2.Snapshot, err error) {
+	err = p.inject(ctx, "CreateSnapshot", []string{sourceVolumeID, snapshotName}, func() error {
+		snapshot, err = p.CloudProvisioner.CreateSnapshot(ctx, sourceVolumeID, snapshotName, secrets, parameters)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+func (p *FaultInjectionCloudProvisioner) DeleteSnapshot(ctx context.Context, snapshotID string, secrets map[string]string) error {
+	return p.inject(ctx, "DeleteSnapshot", []string{snapshotID}, func() error {
+		return p.CloudProvisioner.DeleteSnapshot(ctx, snapshotID, secrets)
+	})
+}
+
+func (p *FaultInjectionCloudProvisioner) CreateGroupSnapshot(ctx context.Context, groupSnapshotName string, sourceVolumeIDs []string, secrets map[string]string, parameters map[string]string) (groupSnapshot *azdiskv1beta2.GroupSnapshot, err error) {
+	err = p.inject(ctx, "CreateGroupSnapshot", append([]string{groupSnapshotName}, sourceVolumeIDs...), func() error {
+		groupSnapshot, err = p.CloudProvisioner.CreateGroupSnapshot(ctx, groupSnapshotName, sourceVolumeIDs, secrets, parameters)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return groupSnapshot, nil
+}
+
+func (p *FaultInjectionCloudProvisioner) DeleteGroupSnapshot(ctx context.Context, groupSnapshotID string, secrets map[string]string) error {
+	return p.inject(ctx, "DeleteGroupSnapshot", []string{groupSnapshotID}, func() error {
+		return p.CloudProvisioner.DeleteGroupSnapshot(ctx, groupSnapshotID, secrets)
+	})
+}
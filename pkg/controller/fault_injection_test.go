@@ -0,0 +1,123 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils/faultinjection"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/provisioner"
+)
+
+// attachCloudProvisioner records the volumes attached and detached by the cloud provisioner.
+type attachCloudProvisioner struct {
+	testCloudProvisioner
+	lock     sync.Mutex
+	attached map[string]bool
+}
+
+func (p *attachCloudProvisioner) PublishVolume(ctx context.Context, volumeID string, nodeID string, volumeContext map[string]string) provisioner.CloudAttachResult {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.attached[volumeID] = true
+
+	attachResult := provisioner.NewCloudAttachResult()
+	attachResult.SetPublishContext(map[string]string{"LUN": "0"})
+	attachResult.ResultChannel() <- nil
+	close(attachResult.ResultChannel())
+	return attachResult
+}
+
+func (p *attachCloudProvisioner) UnpublishVolume(ctx context.Context, volumeID string, nodeID string) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	delete(p.attached, volumeID)
+	return nil
+}
+
+func NewTestFaultInjectionCloudProvisioner(t *testing.T, faults ...faultinjection.Fault) (*FaultInjectionCloudProvisioner, *attachCloudProvisioner) {
+	injector, err := faultinjection.NewInjector(&faultinjection.Config{Faults: faults})
+	require.NoError(t, err)
+	cloudProvisioner := &attachCloudProvisioner{attached: map[string]bool{}}
+	return NewFaultInjectionCloudProvisioner(cloudProvisioner, injector), cloudProvisioner
+}
+
+func TestFaultInjectionCloudProvisionerPublishVolume(t *testing.T) {
+	cloudProvisioner, underlying := NewTestFaultInjectionCloudProvisioner(t,
+		faultinjection.Fault{Operation: "PublishVolume", Disk: testPersistentVolume0Name, RetryAfterInSec: 10, Count: 1},
+		faultinjection.Fault{Operation: "PublishVolume", Disk: testPersistentVolume1Name, DropResponse: true},
+	)
+	ctx := context.TODO()
+
+	// the throttled attach is not performed
+	attachResult := cloudProvisioner.PublishVolume(ctx, testManagedDiskURI0, testNode0Name, nil)
+	err := <-attachResult.ResultChannel()
+	require.Error(t, err)
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Contains(t, err.Error(), "TooManyRequests")
+	assert.False(t, underlying.attached[testManagedDiskURI0])
+
+	attachResult = cloudProvisioner.PublishVolume(ctx, testManagedDiskURI0, testNode0Name, nil)
+	require.NoError(t, <-attachResult.ResultChannel())
+	assert.Equal(t, "0", attachResult.PublishContext()["LUN"])
+
+	// the attach with a dropped response is performed but fails
+	attachResult = cloudProvisioner.PublishVolume(ctx, testManagedDiskURI1, testNode0Name, nil)
+	err = <-attachResult.ResultChannel()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), syscall.ECONNRESET.Error())
+	assert.True(t, underlying.attached[testManagedDiskURI1])
+}
+
+func TestFaultInjectionCloudProvisionerUnpublishVolume(t *testing.T) {
+	cloudProvisioner, underlying := NewTestFaultInjectionCloudProvisioner(t,
+		faultinjection.Fault{Operation: "UnpublishVolume", Disk: testManagedDiskURI0, HTTPStatusCode: http.StatusBadRequest},
+	)
+	ctx := context.TODO()
+	underlying.attached[testManagedDiskURI0] = true
+	underlying.attached[testManagedDiskURI1] = true
+
+	// only the detach of the faulted volume fails
+	err := cloudProvisioner.UnpublishVolume(ctx, testManagedDiskURI0, testNode0Name)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.True(t, underlying.attached[testManagedDiskURI0])
+
+	err = cloudProvisioner.UnpublishVolume(ctx, testManagedDiskURI1, testNode0Name)
+	assert.NoError(t, err)
+	assert.False(t, underlying.attached[testManagedDiskURI1])
+}
+
+func TestFaultInjectionCloudProvisionerLatency(t *testing.T) {
+	cloudProvisioner, _ := NewTestFaultInjectionCloudProvisioner(t,
+		faultinjection.Fault{Operation: "DeleteVolume", LatencyInMillis: 60 * 1000},
+	)
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	err := cloudProvisioner.DeleteVolume(ctx, testManagedDiskURI0, nil)
+	require.Error(t, err)
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Contains(t, err.Error(), context.Canceled.Error())
+}
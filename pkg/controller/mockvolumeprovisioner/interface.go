@@ -94,3 +94,17 @@ func (mr *MockVolumeProvisionerMockRecorder) ExpandVolume(ctx, volumeID, capacit
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpandVolume", reflect.TypeOf((*MockVolumeProvisioner)(nil).ExpandVolume), ctx, volumeID, capacityRange, secrets)
 }
+
+// ModifyVolume mocks base method.
+func (m *MockVolumeProvisioner) ModifyVolume(ctx context.Context, volumeID string, parameters map[string]string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ModifyVolume", ctx, volumeID, parameters)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ModifyVolume indicates an expected call of ModifyVolume.
+func (mr *MockVolumeProvisionerMockRecorder) ModifyVolume(ctx, volumeID, parameters interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ModifyVolume", reflect.TypeOf((*MockVolumeProvisioner)(nil).ModifyVolume), ctx, volumeID, parameters)
+}
@@ -19,11 +19,13 @@ package controller
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 
 	azdiskv1beta2 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
 	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
@@ -60,13 +62,15 @@ func (r *ReconcileNode) Reconcile(ctx context.Context, request reconcile.Request
 
 	// If the node is not found, delete the corresponding AzDriverNode
 	if errors.IsNotFound(err) {
-		// Delete the azDriverNode, since corresponding node is deleted
-		azN := r.azClient.DiskV1beta2().AzDriverNodes(r.config.ObjectNamespace)
-		err = azN.Delete(ctx, request.Name, metav1.DeleteOptions{})
-
-		// If there is an issue in deleting the AzDriverNode, requeue
-		if err != nil && !errors.IsNotFound(err) {
-			return reconcile.Result{Requeue: true}, err
+		// Delete the azDriverNode, since corresponding node is deleted. Only the owner of the node's shard deletes it.
+		if r.ownsNode(request.Name) {
+			azN := r.azClient.DiskV1beta2().AzDriverNodes(r.config.ObjectNamespace)
+			err = azN.Delete(ctx, request.Name, metav1.DeleteOptions{})
+
+			// If there is an issue in deleting the AzDriverNode, requeue
+			if err != nil && !errors.IsNotFound(err) {
+				return reconcile.Result{Requeue: true}, err
+			}
 		}
 		r.deleteNodeFromAvailableAttachmentsMap(ctx, request.Name)
 
@@ -89,11 +93,149 @@ func (r *ReconcileNode) Reconcile(ctx context.Context, request reconcile.Request
 			logger.Info("Node is now available. Will requeue failed replica creation requests.")
 			r.tryCreateFailedReplicas(ctx, nodeavailability)
 		}
+
+		// Node is cordoned or being drained, so prepare replica attachments for the pods to be evicted from the node
+		if n.Spec.Unschedulable {
+			logger.Info("Node is cordoned. Will ensure replica attachments for the volumes with primary attachments on the node.")
+			if err = r.ensureReplicasForCordonedNode(ctx, n.Name); err != nil {
+				return reconcile.Result{Requeue: true}, err
+			}
+		}
+
+		// Node is unresponsive, so force detach its volumes once its lease expires to let the evicted pods recover on other nodes
+		if isNodeUnresponsive(n) {
+			var requeueAfter time.Duration
+			if requeueAfter, err = r.forceDetachOnNodeLeaseExpiry(ctx, n); err != nil {
+				return reconcile.Result{Requeue: true}, err
+			}
+			if requeueAfter > 0 {
+				return reconcile.Result{RequeueAfter: requeueAfter}, nil
+			}
+		}
 	}
 
 	return reconcile.Result{}, nil
 }
 
+// ensureReplicasForCordonedNode makes sure the volumes attached to the cordoned node as primary have replica attachments on other nodes,
+// so that the pods evicted from the node find their volumes already attached to their next node and only wait for the replica promotion.
+func (r *ReconcileNode) ensureReplicasForCordonedNode(ctx context.Context, nodeName string) error {
+	var err error
+	ctx, w := workflow.New(ctx, workflow.WithDetails(consts.NodeNameLabel, nodeName))
+	defer func() { w.Finish(err) }()
+
+	var primaryAttachments []azdiskv1beta2.AzVolumeAttachment
+	primaryAttachments, err = azureutils.GetAzVolumeAttachmentsForNode(ctx, r.cachedClient, nodeName, azureutils.PrimaryOnly)
+	if err != nil {
+		return err
+	}
+
+	for _, primaryAttachment := range primaryAttachments {
+		if deleteRequested, _ := objectDeletionRequested(&primaryAttachment); deleteRequested {
+			continue
+		}
+
+		volumeName := primaryAttachment.Spec.VolumeName
+		w.Logger().V(5).Infof("Ensuring replica attachments for volume (%s) with primary attachment on cordoned node.", volumeName)
+		r.addToOperationQueue(
+			ctx,
+			volumeName,
+			nodecordon,
+			func(ctx context.Context) error {
+				return r.manageReplicas(ctx, volumeName)
+			},
+			false,
+		)
+	}
+	return nil
+}
+
+// forceDetachOnNodeLeaseExpiry force detaches the primary attachments of the node if the last heartbeat of its AzDriverNode is older
+// than the configured node lease duration. Otherwise, it returns the duration after which the node lease expires.
+func (r *ReconcileNode) forceDetachOnNodeLeaseExpiry(ctx context.Context, node *corev1.Node) (time.Duration, error) {
+	leaseDuration := time.Duration(r.config.ControllerConfig.NodeLeaseDurationInSec) * time.Second
+	if leaseDuration <= 0 {
+		return 0, nil
+	}
+
+	var err error
+	ctx, w := workflow.New(ctx, workflow.WithDetails(consts.NodeNameLabel, node.Name))
+	defer func() { w.Finish(err) }()
+
+	azDriverNode := &azdiskv1beta2.AzDriverNode{}
+	if err = r.cachedClient.Get(ctx, types.NamespacedName{Namespace: r.config.ObjectNamespace, Name: node.Name}, azDriverNode); err != nil {
+		if errors.IsNotFound(err) {
+			// the node plugin has never registered the node, so no volume can be attached to it
+			err = nil
+		}
+		return 0, err
+	}
+
+	if azDriverNode.Status == nil || azDriverNode.Status.LastHeartbeatTime == nil {
+		w.Logger().V(5).Info("AzDriverNode has no heartbeat. Skipping node lease check.")
+		return 0, nil
+	}
+
+	if remaining := leaseDuration - time.Since(azDriverNode.Status.LastHeartbeatTime.Time); remaining > 0 {
+		w.Logger().V(5).Infof("Node is unresponsive. Node lease expires in %v.", remaining)
+		return remaining, nil
+	}
+
+	var primaryAttachments []azdiskv1beta2.AzVolumeAttachment
+	primaryAttachments, err = azureutils.GetAzVolumeAttachmentsForNode(ctx, r.cachedClient, node.Name, azureutils.PrimaryOnly)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, primaryAttachment := range primaryAttachments {
+		if deleteRequested, _ := objectDeletionRequested(&primaryAttachment); deleteRequested || volumeDetachRequested(&primaryAttachment) {
+			continue
+		}
+		// the volume is force detached by the owner of its shard
+		if !r.ownsVolume(primaryAttachment.Spec.VolumeName) {
+			continue
+		}
+
+		attachment := primaryAttachment.DeepCopy()
+		w.Logger().Infof("Node lease expired. Force detaching volume (%s) from node.", attachment.Spec.VolumeName)
+		r.eventRecorder.Eventf(node, corev1.EventTypeWarning, consts.NodeLeaseExpiredEvent, "Force detaching volume %s from node %s: last heartbeat was at %v", attachment.Spec.VolumeName, node.Name, azDriverNode.Status.LastHeartbeatTime.Time)
+		r.addToOperationQueue(
+			ctx,
+			attachment.Spec.VolumeName,
+			nodelease,
+			func(ctx context.Context) error {
+				_, err := azureutils.UpdateCRIWithRetry(ctx, nil, r.cachedClient, r.azClient, attachment, func(obj client.Object) error {
+					azVolumeAttachment := obj.(*azdiskv1beta2.AzVolumeAttachment)
+					if volumeDetachRequested(azVolumeAttachment) {
+						return nil
+					}
+					markDetachRequest(azVolumeAttachment, nodelease)
+					_, err := updateState(azVolumeAttachment, azdiskv1beta2.ForceDetachPending, forceUpdate)
+					return err
+				}, consts.NormalUpdateMaxNetRetry, azureutils.UpdateCRIStatus)
+				return err
+			},
+			false,
+		)
+	}
+	return 0, nil
+}
+
+// isNodeUnresponsive returns true if the node is NotReady or has been tainted as out of service.
+func isNodeUnresponsive(node *corev1.Node) bool {
+	for _, taint := range node.Spec.Taints {
+		if taint.Key == corev1.TaintNodeOutOfService {
+			return true
+		}
+	}
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status != corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
 // run an update on existing azdrivernode objects to store them under new version if necessary
 func (r *ReconcileNode) Recover(ctx context.Context, recoveryID string) error {
 	var err error
@@ -107,11 +249,18 @@ func (r *ReconcileNode) Recover(ctx context.Context, recoveryID string) error {
 
 	errCount := 0
 	for _, azNode := range azNodes.Items {
+		// only the owner of the node's shard recovers the azdrivernode object
+		ownsNode := r.ownsInScope(ctx, azNode.Spec.NodeName)
+
 		// if the corresponding node has been deleted, delete the azdrivernode object
 		var node *corev1.Node
 		node, err = r.kubeClient.CoreV1().Nodes().Get(ctx, azNode.Spec.NodeName, metav1.GetOptions{})
 		if err != nil {
 			if errors.IsNotFound(err) {
+				if !ownsNode {
+					err = nil
+					continue
+				}
 				n := r.azClient.DiskV1beta2().AzDriverNodes(r.config.ObjectNamespace)
 				if err = n.Delete(ctx, azNode.Name, metav1.DeleteOptions{}); err != nil {
 					w.Logger().Errorf(err, "failed to delete azDriverNode (%s)", azNode.Name)
@@ -122,6 +271,11 @@ func (r *ReconcileNode) Recover(ctx context.Context, recoveryID string) error {
 				errCount++
 			}
 		} else {
+			// every controller tracks the available attachments of all nodes to place replica attachments
+			if !ownsNode {
+				r.addNodeToAvailableAttachmentsMap(ctx, node.Name, node.GetLabels())
+				continue
+			}
 			updateFunc := func(obj client.Object) error {
 				azNode := obj.(*azdiskv1beta2.AzDriverNode)
 				azNode.Annotations = azureutils.AddToMap(azNode.Annotations, consts.RecoverAnnotation, recoveryID)
@@ -161,7 +315,7 @@ func NewNodeController(mgr manager.Manager, controllerSharedState *SharedState)
 		return nil, err
 	}
 
-	// Predicate to reconcile created, new schedulable and deleted nodes
+	// Predicate to reconcile created, new schedulable, cordoned, unresponsive and deleted nodes
 	p := predicate.Funcs{
 		CreateFunc: func(e event.CreateEvent) bool {
 			return true
@@ -190,7 +344,13 @@ func NewNodeController(mgr manager.Manager, controllerSharedState *SharedState)
 				_, oldLableOk := old.GetLabels()[corev1.LabelInstanceTypeStable]
 				_, newLabelOk := new.GetLabels()[corev1.LabelInstanceTypeStable]
 
-				return (wasUnschedulable && nowSchedulable) || (!oldLableOk && newLabelOk)
+				// update event when node gets cordoned
+				cordoned := !old.Spec.Unschedulable && new.Spec.Unschedulable
+
+				// update event when node becomes NotReady or gets tainted as out of service
+				unresponsive := !isNodeUnresponsive(old) && isNodeUnresponsive(new)
+
+				return (wasUnschedulable && nowSchedulable) || (!oldLableOk && newLabelOk) || cordoned || unresponsive
 			}
 			return false
 		},
@@ -203,7 +363,7 @@ func NewNodeController(mgr manager.Manager, controllerSharedState *SharedState)
 	}
 
 	logger.V(2).Info("Starting to watch cluster nodes.")
-	// Watch the nodes
+	// Watch all the nodes, as the work for the volumes on a node is done by the owners of the volumes' shards
 	err = c.Watch(&source.Kind{Type: &corev1.Node{}}, &handler.EnqueueRequestForObject{}, p)
 	if err != nil {
 		logger.Error(err, "failed to initialize watch for Node")
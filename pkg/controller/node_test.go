@@ -19,6 +19,7 @@ package controller
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/pborman/uuid"
@@ -106,6 +107,173 @@ func TestNodeControllerReconcile(t *testing.T) {
 				require.NoError(t, err)
 			},
 		},
+		{
+			description: "[Success] Should create AzVolumeReplica for volume attached to cordoned node",
+			request:     createReconcileRequest(testNamespace, testNode0Name),
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *ReconcileNode {
+				newAttachment := testPrimaryAzVolumeAttachment0.DeepCopy()
+				newAttachment.Status.State = azdiskv1beta2.Attached
+
+				newVolume := testAzVolume0.DeepCopy()
+				newVolume.Status.Detail = &azdiskv1beta2.AzVolumeStatusDetail{
+					VolumeID: testManagedDiskURI0,
+				}
+
+				newPod := testPod1.DeepCopy()
+				newPod.Status.Phase = v1.PodRunning
+
+				cordonedNode := testNode0.DeepCopy()
+				cordonedNode.Spec.Unschedulable = true
+				cordonedNode.Spec.Taints = []v1.Taint{{Key: v1.TaintNodeUnschedulable, Effect: v1.TaintEffectNoSchedule}}
+
+				controller := NewTestNodeController(
+					mockCtl,
+					testNamespace,
+					newVolume,
+					newAttachment,
+					&testPersistentVolume0,
+					cordonedNode,
+					&testSchedulableNode1,
+					newPod,
+				)
+
+				addTestNodeInAvailableAttachmentsMap(controller.SharedState, testSchedulableNode1.Name, testNodeAvailableAttachmentCount)
+				mockClients(controller.cachedClient.(*mockclient.MockClient), controller.azClient, controller.kubeClient)
+
+				return controller
+			},
+			verifyFunc: func(t *testing.T, controller *ReconcileNode, result reconcile.Result, err error) {
+				require.NoError(t, err)
+				require.False(t, result.Requeue)
+
+				roleReq, _ := azureutils.CreateLabelRequirements(consts.RoleLabel, selection.Equals, string(azdiskv1beta2.ReplicaRole))
+				labelSelector := labels.NewSelector().Add(*roleReq)
+
+				conditionFunc := func() (bool, error) {
+					replicas, localError := controller.azClient.DiskV1beta2().AzVolumeAttachments(testPrimaryAzVolumeAttachment0.Namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: labelSelector.String()})
+					require.NoError(t, localError)
+					require.NotNil(t, replicas)
+					return len(replicas.Items) == 1 && replicas.Items[0].Spec.NodeName == testSchedulableNodeName, nil
+				}
+				err = wait.PollImmediate(verifyCRIInterval, verifyCRITimeout, conditionFunc)
+
+				require.NoError(t, err)
+			},
+		},
+		{
+			description: "[Success] Should force detach primary AzVolumeAttachment from NotReady node with expired lease",
+			request:     createReconcileRequest(testNamespace, testNode0Name),
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *ReconcileNode {
+				newAttachment := testPrimaryAzVolumeAttachment0.DeepCopy()
+				newAttachment.Status.State = azdiskv1beta2.Attached
+
+				controller := NewTestNodeController(
+					mockCtl,
+					testNamespace,
+					newAttachment,
+					&testPersistentVolume0,
+					withNodeReadyCondition(&testNode0, v1.ConditionFalse),
+					withHeartbeat(&testAzDriverNode0, time.Now().Add(-10*time.Minute)),
+				)
+				controller.config.ControllerConfig.NodeLeaseDurationInSec = 300
+
+				mockClients(controller.cachedClient.(*mockclient.MockClient), controller.azClient, controller.kubeClient)
+
+				return controller
+			},
+			verifyFunc: func(t *testing.T, controller *ReconcileNode, result reconcile.Result, err error) {
+				require.NoError(t, err)
+				require.False(t, result.Requeue)
+				require.Zero(t, result.RequeueAfter)
+
+				conditionFunc := func() (bool, error) {
+					attachment, localError := controller.azClient.DiskV1beta2().AzVolumeAttachments(testNamespace).Get(context.TODO(), testPrimaryAzVolumeAttachment0Name, metav1.GetOptions{})
+					require.NoError(t, localError)
+					return attachment.Status.State == azdiskv1beta2.ForceDetachPending && volumeDetachRequested(attachment), nil
+				}
+				err = wait.PollImmediate(verifyCRIInterval, verifyCRITimeout, conditionFunc)
+
+				require.NoError(t, err)
+			},
+		},
+		{
+			description: "[Success] Should force detach owned volume from NotReady node in another shard",
+			request:     createReconcileRequest(testNamespace, testNode0Name),
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *ReconcileNode {
+				newAttachment := testPrimaryAzVolumeAttachment0.DeepCopy()
+				newAttachment.Status.State = azdiskv1beta2.Attached
+
+				controller := NewTestNodeController(
+					mockCtl,
+					testNamespace,
+					newAttachment,
+					&testPersistentVolume0,
+					withNodeReadyCondition(&testNode0, v1.ConditionFalse),
+					withHeartbeat(&testAzDriverNode0, time.Now().Add(-10*time.Minute)),
+				)
+				controller.config.ControllerConfig.NodeLeaseDurationInSec = 300
+
+				// own the shard of the volume but not the shard of the node
+				for shardCount := 2; ; shardCount++ {
+					shardManager := newTestShardManager(shardCount)
+					if volumeShard := shardManager.GetShard(testPersistentVolume0Name); volumeShard != shardManager.GetShard(testNode0Name) {
+						shardManager.ownedShards.Store(volumeShard, struct{}{})
+						controller.SetShardManager(shardManager)
+						break
+					}
+				}
+				require.False(t, controller.ownsNode(testNode0Name))
+
+				mockClients(controller.cachedClient.(*mockclient.MockClient), controller.azClient, controller.kubeClient)
+
+				return controller
+			},
+			verifyFunc: func(t *testing.T, controller *ReconcileNode, result reconcile.Result, err error) {
+				require.NoError(t, err)
+				require.False(t, result.Requeue)
+
+				conditionFunc := func() (bool, error) {
+					attachment, localError := controller.azClient.DiskV1beta2().AzVolumeAttachments(testNamespace).Get(context.TODO(), testPrimaryAzVolumeAttachment0Name, metav1.GetOptions{})
+					require.NoError(t, localError)
+					return attachment.Status.State == azdiskv1beta2.ForceDetachPending && volumeDetachRequested(attachment), nil
+				}
+				err = wait.PollImmediate(verifyCRIInterval, verifyCRITimeout, conditionFunc)
+
+				require.NoError(t, err)
+			},
+		},
+		{
+			description: "[Success] Should requeue NotReady node until its lease expires",
+			request:     createReconcileRequest(testNamespace, testNode0Name),
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *ReconcileNode {
+				newAttachment := testPrimaryAzVolumeAttachment0.DeepCopy()
+				newAttachment.Status.State = azdiskv1beta2.Attached
+
+				controller := NewTestNodeController(
+					mockCtl,
+					testNamespace,
+					newAttachment,
+					&testPersistentVolume0,
+					withNodeReadyCondition(&testNode0, v1.ConditionUnknown),
+					withHeartbeat(&testAzDriverNode0, time.Now()),
+				)
+				controller.config.ControllerConfig.NodeLeaseDurationInSec = 300
+
+				mockClients(controller.cachedClient.(*mockclient.MockClient), controller.azClient, controller.kubeClient)
+
+				return controller
+			},
+			verifyFunc: func(t *testing.T, controller *ReconcileNode, result reconcile.Result, err error) {
+				require.NoError(t, err)
+				require.Greater(t, result.RequeueAfter, time.Duration(0))
+				require.LessOrEqual(t, result.RequeueAfter, 300*time.Second)
+
+				attachment, err := controller.azClient.DiskV1beta2().AzVolumeAttachments(testNamespace).Get(context.TODO(), testPrimaryAzVolumeAttachment0Name, metav1.GetOptions{})
+				require.NoError(t, err)
+				require.Equal(t, azdiskv1beta2.Attached, attachment.Status.State)
+				require.False(t, volumeDetachRequested(attachment))
+			},
+		},
 		{
 			description: "[Success] Should delete AzDriverNode for deleted Node",
 			request:     testNode1Request,
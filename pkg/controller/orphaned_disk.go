@@ -0,0 +1,287 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-03-01/compute"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	azdiskv1beta2 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
+	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/workflow"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+type CloudOrphanedDiskManager interface {
+	ListDisksByResourceGroup(ctx context.Context, resourceGroup string) ([]compute.Disk, error)
+	UpdateDiskTags(ctx context.Context, volumeID string, tags map[string]*string) error
+	CreateSnapshot(ctx context.Context, sourceVolumeID string, snapshotName string, secrets map[string]string, parameters map[string]string) (*azdiskv1beta2.Snapshot, error)
+	DeleteVolume(ctx context.Context, volumeID string, secrets map[string]string) error
+}
+
+/*
+Orphaned disk collector periodically looks for the managed disks created by the driver which are referenced by neither a PersistentVolume nor an AzVolume.
+It scans the resource group of the cluster, the resource groups of the existing PersistentVolumes and the resource groups configured in the driver's StorageClasses,
+and for each unattached disk tagged with the identity of the cluster and the name of the PersistentVolume it was created for
+ 1. tags the disk with the time it was found orphaned
+ 2. removes the tag if the disk is referenced again by a PersistentVolume or an AzVolume
+ 3. applies the orphaned disk policy once the disk has been orphaned for longer than the retention period,
+    either reporting the disk or deleting it after taking a snapshot of it
+*/
+type OrphanedDiskCollector struct {
+	*SharedState
+	logger           logr.Logger
+	cloudDiskManager CloudOrphanedDiskManager
+	resourceGroup    string
+	scanInterval     time.Duration
+	retentionPeriod  time.Duration
+	policy           string
+}
+
+var _ manager.LeaderElectionRunnable = &OrphanedDiskCollector{}
+
+// Start scans for orphaned disks at every scan interval until the context is done.
+func (c *OrphanedDiskCollector) Start(ctx context.Context) error {
+	c.logger.V(2).Info("Starting orphaned disk collector.", "interval", c.scanInterval, "retentionPeriod", c.retentionPeriod, "policy", c.policy)
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		// when sharded, orphaned disks are collected by the owner of the coordinator shard
+		if !c.isRecoveryComplete() || !c.isCoordinator() {
+			return
+		}
+		_ = c.collectOrphanedDisks(ctx)
+	}, c.scanInterval)
+	return nil
+}
+
+// NeedLeaderElection returns true so that only the leader controller plugin collects orphaned disks.
+func (c *OrphanedDiskCollector) NeedLeaderElection() bool {
+	return true
+}
+
+func (c *OrphanedDiskCollector) collectOrphanedDisks(ctx context.Context) error {
+	var err error
+	ctx, w := workflow.New(ctx)
+	defer func() { w.Finish(err) }()
+
+	var persistentVolumes *corev1.PersistentVolumeList
+	if persistentVolumes, err = c.kubeClient.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{}); err != nil {
+		w.Logger().Error(err, "failed to list PersistentVolumes")
+		return err
+	}
+	var clusterID string
+	if clusterID, err = azureutils.GetClusterID(ctx, c.kubeClient); err != nil {
+		w.Logger().Error(err, "failed to get the cluster identity")
+		return err
+	}
+
+	referencedDisks := set{}
+	resourceGroups := set{}
+	addResourceGroup := func(diskURI string) {
+		if resourceGroup, rerr := azureutils.GetResourceGroupFromURI(diskURI); rerr == nil {
+			resourceGroups.add(strings.ToLower(resourceGroup))
+		}
+	}
+	resourceGroups.add(strings.ToLower(c.resourceGroup))
+	// a disk is referenced by any PersistentVolume naming it, whether provisioned by this driver, another driver or the in-tree plugin
+	for _, pv := range persistentVolumes.Items {
+		var diskURI string
+		switch {
+		case pv.Spec.CSI != nil:
+			diskURI = strings.ToLower(pv.Spec.CSI.VolumeHandle)
+		case pv.Spec.AzureDisk != nil:
+			diskURI = strings.ToLower(pv.Spec.AzureDisk.DataDiskURI)
+		default:
+			continue
+		}
+		referencedDisks.add(diskURI)
+		addResourceGroup(diskURI)
+	}
+
+	// the disks of the PersistentVolumes deleted out of band may be in the resource groups configured in the StorageClasses
+	var storageClasses *storagev1.StorageClassList
+	if storageClasses, err = c.kubeClient.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{}); err != nil {
+		w.Logger().Error(err, "failed to list StorageClasses")
+		return err
+	}
+	for _, storageClass := range storageClasses.Items {
+		if storageClass.Provisioner != c.config.DriverName {
+			continue
+		}
+		for key, value := range storageClass.Parameters {
+			if strings.EqualFold(key, consts.ResourceGroupField) && value != "" {
+				resourceGroups.add(strings.ToLower(value))
+			}
+		}
+	}
+
+	var azVolumes *azdiskv1beta2.AzVolumeList
+	if azVolumes, err = c.azClient.DiskV1beta2().AzVolumes(c.config.ObjectNamespace).List(ctx, metav1.ListOptions{}); err != nil {
+		w.Logger().Error(err, "failed to list AzVolumes")
+		return err
+	}
+	azVolumeNames := set{}
+	for _, azVolume := range azVolumes.Items {
+		azVolumeNames.add(azVolume.Name)
+	}
+
+	// scan the resource groups in a stable order
+	sortedResourceGroups := resourceGroups.toStringSlice()
+	sort.Strings(sortedResourceGroups)
+
+	for _, resourceGroup := range sortedResourceGroups {
+		var disks []compute.Disk
+		if disks, err = c.cloudDiskManager.ListDisksByResourceGroup(ctx, resourceGroup); err != nil {
+			w.Logger().Errorf(err, "failed to list disks in resource group (%s)", resourceGroup)
+			continue
+		}
+
+		for i := range disks {
+			disk := &disks[i]
+			if disk.ID == nil || disk.Name == nil {
+				continue
+			}
+			// only consider the unattached disks created by the driver of this cluster for a PersistentVolume
+			if _, ok := disk.Tags[consts.PvNameTag]; !ok || disk.ManagedBy != nil || !strings.EqualFold(getDiskTag(disk, consts.ClusterIDTag), clusterID) {
+				continue
+			}
+
+			isReferenced := referencedDisks.has(strings.ToLower(*disk.ID)) || azVolumeNames.has(strings.ToLower(*disk.Name))
+			if herr := c.handleDisk(ctx, disk, isReferenced); herr != nil {
+				w.Logger().Errorf(herr, "failed to handle orphaned disk (%s)", *disk.ID)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *OrphanedDiskCollector) handleDisk(ctx context.Context, disk *compute.Disk, isReferenced bool) error {
+	w, _ := workflow.GetWorkflowFromContext(ctx)
+	diskURI := *disk.ID
+
+	orphanedSinceTag, isTagged := disk.Tags[consts.OrphanedSinceTag]
+	if isReferenced {
+		if !isTagged {
+			return nil
+		}
+		// the disk was adopted by a statically provisioned PersistentVolume or AzVolume since it was found orphaned
+		w.Logger().Infof("Disk (%s) is no longer orphaned", diskURI)
+		tags := copyDiskTags(disk.Tags)
+		delete(tags, consts.OrphanedSinceTag)
+		return c.cloudDiskManager.UpdateDiskTags(ctx, diskURI, tags)
+	}
+
+	var orphanedSince time.Time
+	var perr error
+	if isTagged && orphanedSinceTag != nil {
+		orphanedSince, perr = time.Parse(time.RFC3339, *orphanedSinceTag)
+	}
+	if !isTagged || orphanedSinceTag == nil || perr != nil {
+		orphanedSince = time.Now().UTC()
+		w.Logger().Infof("Disk (%s) created for PersistentVolume (%s) is orphaned", diskURI, getDiskTag(disk, consts.PvNameTag))
+		tags := copyDiskTags(disk.Tags)
+		tags[consts.OrphanedSinceTag] = to.StringPtr(orphanedSince.Format(time.RFC3339))
+		return c.cloudDiskManager.UpdateDiskTags(ctx, diskURI, tags)
+	}
+
+	if time.Since(orphanedSince) < c.retentionPeriod {
+		return nil
+	}
+
+	switch c.policy {
+	case consts.OrphanedDiskPolicySnapshotAndDelete:
+		snapshotName := consts.OrphanedDiskSnapshotPrefix + *disk.Name
+		snapshotParameters := map[string]string{
+			consts.TagsField: fmt.Sprintf("%s=%s", consts.PvNameTag, getDiskTag(disk, consts.PvNameTag)),
+		}
+		w.Logger().Infof("Taking snapshot (%s) of disk (%s) orphaned since %v", snapshotName, diskURI, orphanedSince)
+		if _, err := c.cloudDiskManager.CreateSnapshot(ctx, diskURI, snapshotName, nil, snapshotParameters); err != nil {
+			return err
+		}
+		w.Logger().Infof("Deleting disk (%s) orphaned since %v", diskURI, orphanedSince)
+		return c.cloudDiskManager.DeleteVolume(ctx, diskURI, nil)
+	default:
+		w.Logger().Infof("Disk (%s) created for PersistentVolume (%s) and PersistentVolumeClaim (%s/%s) has been orphaned since %v", diskURI, getDiskTag(disk, consts.PvNameTag), getDiskTag(disk, consts.PvcNamespaceTag), getDiskTag(disk, consts.PvcNameTag), orphanedSince)
+	}
+	return nil
+}
+
+func copyDiskTags(tags map[string]*string) map[string]*string {
+	copied := make(map[string]*string, len(tags)+1)
+	for key, value := range tags {
+		copied[key] = value
+	}
+	return copied
+}
+
+func getDiskTag(disk *compute.Disk, key string) string {
+	if value, ok := disk.Tags[key]; ok && value != nil {
+		return *value
+	}
+	return ""
+}
+
+// NewOrphanedDiskCollector initializes the orphaned disk collector and adds it to the manager.
+// It returns nil if orphaned disk garbage collection is disabled.
+func NewOrphanedDiskCollector(mgr manager.Manager, cloudProvisioner CloudProvisioner, controllerSharedState *SharedState) (*OrphanedDiskCollector, error) {
+	logger := mgr.GetLogger().WithValues("controller", "orphaneddisk")
+	controllerConfig := controllerSharedState.config.ControllerConfig
+	scanInterval := time.Duration(controllerConfig.OrphanedDiskScanIntervalInSec) * time.Second
+	if scanInterval <= 0 {
+		logger.V(2).Info("Orphaned disk garbage collection is disabled.")
+		return nil, nil
+	}
+
+	policy := strings.ToLower(controllerConfig.OrphanedDiskPolicy)
+	switch policy {
+	case "":
+		policy = consts.OrphanedDiskPolicyReport
+	case consts.OrphanedDiskPolicyReport, consts.OrphanedDiskPolicySnapshotAndDelete:
+	default:
+		err := fmt.Errorf("invalid orphaned disk policy %q, available values: %s, %s", controllerConfig.OrphanedDiskPolicy, consts.OrphanedDiskPolicyReport, consts.OrphanedDiskPolicySnapshotAndDelete)
+		logger.Error(err, "failed to initialize orphaned disk collector")
+		return nil, err
+	}
+
+	collector := &OrphanedDiskCollector{
+		SharedState:      controllerSharedState,
+		logger:           logger,
+		cloudDiskManager: cloudProvisioner,
+		resourceGroup:    cloudProvisioner.GetCloud().ResourceGroup,
+		scanInterval:     scanInterval,
+		retentionPeriod:  time.Duration(controllerConfig.OrphanedDiskRetentionPeriodInSec) * time.Second,
+		policy:           policy,
+	}
+
+	if err := mgr.Add(collector); err != nil {
+		logger.Error(err, "failed to add orphaned disk collector to manager")
+		return nil, err
+	}
+	logger.V(2).Info("Controller set-up successful.")
+
+	return collector, nil
+}
@@ -0,0 +1,310 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-03-01/compute"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2/klogr"
+	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils/mockclient"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/controller/mockorphaneddiskmanager"
+)
+
+var testClusterID = "test-cluster-id"
+
+func NewTestOrphanedDiskCollector(controller *gomock.Controller, namespace string, policy string, objects ...runtime.Object) *OrphanedDiskCollector {
+	systemNamespace := &v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: metav1.NamespaceSystem,
+			UID:  types.UID(testClusterID),
+		},
+	}
+	controllerSharedState := NewTestSharedState(controller, namespace, append(objects, systemNamespace)...)
+
+	return &OrphanedDiskCollector{
+		SharedState:      controllerSharedState,
+		logger:           klogr.New(),
+		cloudDiskManager: mockorphaneddiskmanager.NewMockCloudOrphanedDiskManager(controller),
+		resourceGroup:    testResourceGroup,
+		scanInterval:     time.Minute,
+		retentionPeriod:  time.Hour,
+		policy:           policy,
+	}
+}
+
+// newTestDisk returns a disk tagged with the identity of the test cluster in addition to the specified tags.
+func newTestDisk(diskName string, tags map[string]*string) compute.Disk {
+	if tags != nil {
+		tags[consts.ClusterIDTag] = to.StringPtr(testClusterID)
+	}
+	return compute.Disk{
+		ID:   to.StringPtr(getTestDiskURI(diskName)),
+		Name: to.StringPtr(diskName),
+		Tags: tags,
+	}
+}
+
+func TestCollectOrphanedDisks(t *testing.T) {
+	orphanedDiskName := "orphaned-disk"
+	orphanedDiskURI := getTestDiskURI(orphanedDiskName)
+	expiredOrphanedSince := time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)
+	recentOrphanedSince := time.Now().UTC().Format(time.RFC3339)
+
+	tests := []struct {
+		description string
+		setupFunc   func(*testing.T, *gomock.Controller) *OrphanedDiskCollector
+		verifyFunc  func(*testing.T, *OrphanedDiskCollector, error)
+	}{
+		{
+			description: "[Success] Should ignore disks referenced by a PersistentVolume or AzVolume, attached disks and disks not created by the driver",
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *OrphanedDiskCollector {
+				collector := NewTestOrphanedDiskCollector(mockCtl, testNamespace, consts.OrphanedDiskPolicySnapshotAndDelete, &testPersistentVolume1, &testAzVolume0)
+				mockClients(collector.cachedClient.(*mockclient.MockClient), collector.azClient, collector.kubeClient)
+
+				attachedDisk := newTestDisk("attached-disk", map[string]*string{consts.PvNameTag: to.StringPtr("attached-disk")})
+				attachedDisk.ManagedBy = to.StringPtr("vm")
+
+				collector.cloudDiskManager.(*mockorphaneddiskmanager.MockCloudOrphanedDiskManager).EXPECT().
+					ListDisksByResourceGroup(gomock.Any(), testResourceGroup).
+					Return([]compute.Disk{
+						newTestDisk(testPersistentVolume0Name, map[string]*string{consts.PvNameTag: to.StringPtr(testPersistentVolume0Name)}),
+						newTestDisk(testPersistentVolume1Name, map[string]*string{consts.PvNameTag: to.StringPtr(testPersistentVolume1Name)}),
+						attachedDisk,
+						newTestDisk("unmanaged-disk", nil),
+					}, nil)
+
+				return collector
+			},
+			verifyFunc: func(t *testing.T, collector *OrphanedDiskCollector, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			description: "[Success] Should ignore disks created by another cluster or before the cluster identity was tagged",
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *OrphanedDiskCollector {
+				collector := NewTestOrphanedDiskCollector(mockCtl, testNamespace, consts.OrphanedDiskPolicySnapshotAndDelete)
+				mockClients(collector.cachedClient.(*mockclient.MockClient), collector.azClient, collector.kubeClient)
+
+				otherClusterDisk := newTestDisk("other-cluster-disk", map[string]*string{consts.PvNameTag: to.StringPtr("other-cluster-disk")})
+				otherClusterDisk.Tags[consts.ClusterIDTag] = to.StringPtr("other-cluster-id")
+				untaggedDisk := newTestDisk("untagged-disk", map[string]*string{consts.PvNameTag: to.StringPtr("untagged-disk")})
+				delete(untaggedDisk.Tags, consts.ClusterIDTag)
+
+				collector.cloudDiskManager.(*mockorphaneddiskmanager.MockCloudOrphanedDiskManager).EXPECT().
+					ListDisksByResourceGroup(gomock.Any(), testResourceGroup).
+					Return([]compute.Disk{otherClusterDisk, untaggedDisk}, nil)
+
+				return collector
+			},
+			verifyFunc: func(t *testing.T, collector *OrphanedDiskCollector, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			description: "[Success] Should ignore disk referenced by an in-tree PersistentVolume",
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *OrphanedDiskCollector {
+				inTreePersistentVolume := &v1.PersistentVolume{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "in-tree-pv",
+					},
+					Spec: v1.PersistentVolumeSpec{
+						PersistentVolumeSource: v1.PersistentVolumeSource{
+							AzureDisk: &v1.AzureDiskVolumeSource{
+								DiskName:    orphanedDiskName,
+								DataDiskURI: orphanedDiskURI,
+							},
+						},
+					},
+				}
+				collector := NewTestOrphanedDiskCollector(mockCtl, testNamespace, consts.OrphanedDiskPolicySnapshotAndDelete, inTreePersistentVolume)
+				mockClients(collector.cachedClient.(*mockclient.MockClient), collector.azClient, collector.kubeClient)
+
+				collector.cloudDiskManager.(*mockorphaneddiskmanager.MockCloudOrphanedDiskManager).EXPECT().
+					ListDisksByResourceGroup(gomock.Any(), testResourceGroup).
+					Return([]compute.Disk{newTestDisk(orphanedDiskName, map[string]*string{consts.PvNameTag: to.StringPtr(orphanedDiskName)})}, nil)
+
+				return collector
+			},
+			verifyFunc: func(t *testing.T, collector *OrphanedDiskCollector, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			description: "[Success] Should scan the resource groups configured in the StorageClasses of the driver",
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *OrphanedDiskCollector {
+				storageClass := testStorageClass.DeepCopy()
+				storageClass.Parameters = map[string]string{"resourceGroup": "Other-Resource-Group"}
+				collector := NewTestOrphanedDiskCollector(mockCtl, testNamespace, consts.OrphanedDiskPolicyReport, storageClass)
+				mockClients(collector.cachedClient.(*mockclient.MockClient), collector.azClient, collector.kubeClient)
+
+				mockManager := collector.cloudDiskManager.(*mockorphaneddiskmanager.MockCloudOrphanedDiskManager)
+				mockManager.EXPECT().
+					ListDisksByResourceGroup(gomock.Any(), "other-resource-group").
+					Return(nil, nil)
+				mockManager.EXPECT().
+					ListDisksByResourceGroup(gomock.Any(), testResourceGroup).
+					Return(nil, nil)
+
+				return collector
+			},
+			verifyFunc: func(t *testing.T, collector *OrphanedDiskCollector, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			description: "[Success] Should tag disk without PersistentVolume and AzVolume as orphaned",
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *OrphanedDiskCollector {
+				collector := NewTestOrphanedDiskCollector(mockCtl, testNamespace, consts.OrphanedDiskPolicyReport)
+				mockClients(collector.cachedClient.(*mockclient.MockClient), collector.azClient, collector.kubeClient)
+
+				mockManager := collector.cloudDiskManager.(*mockorphaneddiskmanager.MockCloudOrphanedDiskManager)
+				mockManager.EXPECT().
+					ListDisksByResourceGroup(gomock.Any(), testResourceGroup).
+					Return([]compute.Disk{newTestDisk(orphanedDiskName, map[string]*string{consts.PvNameTag: to.StringPtr(orphanedDiskName)})}, nil)
+				mockManager.EXPECT().
+					UpdateDiskTags(gomock.Any(), orphanedDiskURI, gomock.Any()).
+					DoAndReturn(func(ctx context.Context, volumeID string, tags map[string]*string) error {
+						require.Equal(t, orphanedDiskName, *tags[consts.PvNameTag])
+						require.NotNil(t, tags[consts.OrphanedSinceTag])
+						orphanedSince, err := time.Parse(time.RFC3339, *tags[consts.OrphanedSinceTag])
+						require.NoError(t, err)
+						require.WithinDuration(t, time.Now(), orphanedSince, time.Minute)
+						return nil
+					})
+
+				return collector
+			},
+			verifyFunc: func(t *testing.T, collector *OrphanedDiskCollector, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			description: "[Success] Should remove orphaned tag from disk referenced by a PersistentVolume",
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *OrphanedDiskCollector {
+				collector := NewTestOrphanedDiskCollector(mockCtl, testNamespace, consts.OrphanedDiskPolicySnapshotAndDelete, &testPersistentVolume0)
+				mockClients(collector.cachedClient.(*mockclient.MockClient), collector.azClient, collector.kubeClient)
+
+				mockManager := collector.cloudDiskManager.(*mockorphaneddiskmanager.MockCloudOrphanedDiskManager)
+				mockManager.EXPECT().
+					ListDisksByResourceGroup(gomock.Any(), testResourceGroup).
+					Return([]compute.Disk{newTestDisk(testPersistentVolume0Name, map[string]*string{
+						consts.PvNameTag:        to.StringPtr(testPersistentVolume0Name),
+						consts.OrphanedSinceTag: to.StringPtr(expiredOrphanedSince),
+					})}, nil)
+				mockManager.EXPECT().
+					UpdateDiskTags(gomock.Any(), testManagedDiskURI0, map[string]*string{
+						consts.PvNameTag:    to.StringPtr(testPersistentVolume0Name),
+						consts.ClusterIDTag: to.StringPtr(testClusterID),
+					}).
+					Return(nil)
+
+				return collector
+			},
+			verifyFunc: func(t *testing.T, collector *OrphanedDiskCollector, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			description: "[Success] Should retain orphaned disk during retention period",
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *OrphanedDiskCollector {
+				collector := NewTestOrphanedDiskCollector(mockCtl, testNamespace, consts.OrphanedDiskPolicySnapshotAndDelete)
+				mockClients(collector.cachedClient.(*mockclient.MockClient), collector.azClient, collector.kubeClient)
+
+				collector.cloudDiskManager.(*mockorphaneddiskmanager.MockCloudOrphanedDiskManager).EXPECT().
+					ListDisksByResourceGroup(gomock.Any(), testResourceGroup).
+					Return([]compute.Disk{newTestDisk(orphanedDiskName, map[string]*string{
+						consts.PvNameTag:        to.StringPtr(orphanedDiskName),
+						consts.OrphanedSinceTag: to.StringPtr(recentOrphanedSince),
+					})}, nil)
+
+				return collector
+			},
+			verifyFunc: func(t *testing.T, collector *OrphanedDiskCollector, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			description: "[Success] Should only report orphaned disk after retention period with report policy",
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *OrphanedDiskCollector {
+				collector := NewTestOrphanedDiskCollector(mockCtl, testNamespace, consts.OrphanedDiskPolicyReport)
+				mockClients(collector.cachedClient.(*mockclient.MockClient), collector.azClient, collector.kubeClient)
+
+				collector.cloudDiskManager.(*mockorphaneddiskmanager.MockCloudOrphanedDiskManager).EXPECT().
+					ListDisksByResourceGroup(gomock.Any(), testResourceGroup).
+					Return([]compute.Disk{newTestDisk(orphanedDiskName, map[string]*string{
+						consts.PvNameTag:        to.StringPtr(orphanedDiskName),
+						consts.OrphanedSinceTag: to.StringPtr(expiredOrphanedSince),
+					})}, nil)
+
+				return collector
+			},
+			verifyFunc: func(t *testing.T, collector *OrphanedDiskCollector, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			description: "[Success] Should snapshot and delete orphaned disk after retention period with snapshot-and-delete policy",
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *OrphanedDiskCollector {
+				collector := NewTestOrphanedDiskCollector(mockCtl, testNamespace, consts.OrphanedDiskPolicySnapshotAndDelete)
+				mockClients(collector.cachedClient.(*mockclient.MockClient), collector.azClient, collector.kubeClient)
+
+				mockManager := collector.cloudDiskManager.(*mockorphaneddiskmanager.MockCloudOrphanedDiskManager)
+				mockManager.EXPECT().
+					ListDisksByResourceGroup(gomock.Any(), testResourceGroup).
+					Return([]compute.Disk{newTestDisk(orphanedDiskName, map[string]*string{
+						consts.PvNameTag:        to.StringPtr(orphanedDiskName),
+						consts.OrphanedSinceTag: to.StringPtr(expiredOrphanedSince),
+					})}, nil)
+				gomock.InOrder(
+					mockManager.EXPECT().
+						CreateSnapshot(gomock.Any(), orphanedDiskURI, consts.OrphanedDiskSnapshotPrefix+orphanedDiskName, gomock.Any(), map[string]string{consts.TagsField: consts.PvNameTag + "=" + orphanedDiskName}).
+						Return(nil, nil),
+					mockManager.EXPECT().
+						DeleteVolume(gomock.Any(), orphanedDiskURI, gomock.Any()).
+						Return(nil),
+				)
+
+				return collector
+			},
+			verifyFunc: func(t *testing.T, collector *OrphanedDiskCollector, err error) {
+				require.NoError(t, err)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		tt := test
+		t.Run(tt.description, func(t *testing.T) {
+			mockCtl := gomock.NewController(t)
+			defer mockCtl.Finish()
+			collector := tt.setupFunc(t, mockCtl)
+			err := collector.collectOrphanedDisks(context.TODO())
+			tt.verifyFunc(t, collector, err)
+		})
+	}
+}
@@ -0,0 +1,333 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	azdiskv1beta2 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/workflow"
+)
+
+// Names of the built-in replica placement plugins used in the placement profile
+const (
+	InterPodAffinityFilterName     = "InterPodAffinity"
+	InterPodAntiAffinityFilterName = "InterPodAntiAffinity"
+	PodTolerationFilterName        = "PodToleration"
+	PodNodeAffinityFilterName      = "PodNodeAffinity"
+	PodNodeSelectorFilterName      = "PodNodeSelector"
+	VolumeNodeSelectorFilterName   = "VolumeNodeSelector"
+	TopologySpreadFilterName       = "TopologySpread"
+
+	NodeCapacityScorerName         = "NodeCapacity"
+	ReplicaCountScorerName         = "ReplicaCount"
+	InterPodAffinityScorerName     = "InterPodAffinity"
+	InterPodAntiAffinityScorerName = "InterPodAntiAffinity"
+	PodNodeAffinityScorerName      = "PodNodeAffinity"
+	TopologySpreadScorerName       = "TopologySpread"
+
+	maxScorerPriority = 5
+)
+
+// PlacementFilter filters the candidate nodes for the replica attachments of volumes.
+// Custom filters are registered with RegisterPlacementFilter.
+type PlacementFilter interface {
+	// Filter returns the nodes qualifying for replica attachments of the persistent volumes used by the pods.
+	Filter(ctx context.Context, nodes []v1.Node, pods []v1.Pod, persistentVolumes []*v1.PersistentVolume) ([]v1.Node, error)
+}
+
+// PlacementScorer scores the qualifying nodes for the replica attachments of volumes.
+// Custom scorers are registered with RegisterPlacementScorer.
+type PlacementScorer interface {
+	// Score returns the scores of the nodes in the range of 0 ~ 1. Nodes missing from the result are not scored.
+	Score(ctx context.Context, nodes []v1.Node, pods []v1.Pod, volumes []string) (map[string]float64, error)
+}
+
+type filterPluginRegistration struct {
+	newPlugin      func() filterPlugin
+	defaultEnabled bool
+}
+
+type nodeScorerPluginRegistration struct {
+	newPlugin       func() nodeScorerPlugin
+	defaultEnabled  bool
+	defaultPriority float64
+}
+
+var (
+	placementPluginsLock sync.RWMutex
+
+	// filterPluginNames and nodeScorerPluginNames hold the order in which the plugins are run
+	filterPluginNames = []string{
+		InterPodAffinityFilterName,
+		InterPodAntiAffinityFilterName,
+		PodTolerationFilterName,
+		PodNodeAffinityFilterName,
+		PodNodeSelectorFilterName,
+		VolumeNodeSelectorFilterName,
+		TopologySpreadFilterName,
+	}
+	filterPlugins = map[string]filterPluginRegistration{
+		InterPodAffinityFilterName:     {newPlugin: func() filterPlugin { return &interPodAffinityFilter{} }, defaultEnabled: true},
+		InterPodAntiAffinityFilterName: {newPlugin: func() filterPlugin { return &interPodAntiAffinityFilter{} }, defaultEnabled: true},
+		PodTolerationFilterName:        {newPlugin: func() filterPlugin { return &podTolerationFilter{} }, defaultEnabled: true},
+		PodNodeAffinityFilterName:      {newPlugin: func() filterPlugin { return &podNodeAffinityFilter{} }, defaultEnabled: true},
+		PodNodeSelectorFilterName:      {newPlugin: func() filterPlugin { return &podNodeSelectorFilter{} }, defaultEnabled: true},
+		VolumeNodeSelectorFilterName:   {newPlugin: func() filterPlugin { return &volumeNodeSelectorFilter{} }, defaultEnabled: true},
+		// the topology spread filter is disabled by default as it overrides the preferences of the scorers
+		TopologySpreadFilterName: {newPlugin: func() filterPlugin { return &topologySpreadFilter{} }},
+	}
+
+	nodeScorerPluginNames = []string{
+		NodeCapacityScorerName,
+		ReplicaCountScorerName,
+		InterPodAffinityScorerName,
+		InterPodAntiAffinityScorerName,
+		PodNodeAffinityScorerName,
+		TopologySpreadScorerName,
+	}
+	nodeScorerPlugins = map[string]nodeScorerPluginRegistration{
+		NodeCapacityScorerName:         {newPlugin: func() nodeScorerPlugin { return &scoreByNodeCapacity{} }, defaultEnabled: true, defaultPriority: 1},
+		ReplicaCountScorerName:         {newPlugin: func() nodeScorerPlugin { return &scoreByReplicaCount{} }, defaultEnabled: true, defaultPriority: 3},
+		InterPodAffinityScorerName:     {newPlugin: func() nodeScorerPlugin { return &scoreByInterPodAffinity{} }, defaultEnabled: true, defaultPriority: 2},
+		InterPodAntiAffinityScorerName: {newPlugin: func() nodeScorerPlugin { return &scoreByInterPodAntiAffinity{} }, defaultEnabled: true, defaultPriority: 2},
+		PodNodeAffinityScorerName:      {newPlugin: func() nodeScorerPlugin { return &scoreByPodNodeAffinity{} }, defaultEnabled: true, defaultPriority: 2},
+		TopologySpreadScorerName:       {newPlugin: func() nodeScorerPlugin { return &scoreByTopologySpread{} }, defaultEnabled: true, defaultPriority: 3},
+	}
+)
+
+// RegisterPlacementFilter registers a custom filter for replica placement. Custom filters run after the built-in
+// filters in the order of their registration, and only if enabled in the placement profile.
+func RegisterPlacementFilter(name string, newFilter func() PlacementFilter) error {
+	if name == "" || newFilter == nil {
+		return fmt.Errorf("a placement filter requires a name and a constructor")
+	}
+
+	placementPluginsLock.Lock()
+	defer placementPluginsLock.Unlock()
+
+	if _, exists := filterPlugins[name]; exists {
+		return fmt.Errorf("placement filter %s is already registered", name)
+	}
+	filterPlugins[name] = filterPluginRegistration{
+		newPlugin: func() filterPlugin { return &customFilterPlugin{pluginName: name, plugin: newFilter()} },
+	}
+	filterPluginNames = append(filterPluginNames, name)
+	return nil
+}
+
+// RegisterPlacementScorer registers a custom scorer for replica placement. Custom scorers run after the built-in
+// scorers in the order of their registration, and only if enabled in the placement profile.
+func RegisterPlacementScorer(name string, defaultPriority float64, newScorer func() PlacementScorer) error {
+	if name == "" || newScorer == nil {
+		return fmt.Errorf("a placement scorer requires a name and a constructor")
+	}
+	if err := validateScorerPriority(name, defaultPriority); err != nil {
+		return err
+	}
+
+	placementPluginsLock.Lock()
+	defer placementPluginsLock.Unlock()
+
+	if _, exists := nodeScorerPlugins[name]; exists {
+		return fmt.Errorf("placement scorer %s is already registered", name)
+	}
+	nodeScorerPlugins[name] = nodeScorerPluginRegistration{
+		newPlugin:       func() nodeScorerPlugin { return &customScorerPlugin{pluginName: name, plugin: newScorer()} },
+		defaultPriority: defaultPriority,
+	}
+	nodeScorerPluginNames = append(nodeScorerPluginNames, name)
+	return nil
+}
+
+// ValidatePlacementProfile validates that the placement profile only refers to registered plugins.
+func ValidatePlacementProfile(profile azdiskv1beta2.PlacementProfile) error {
+	placementPluginsLock.RLock()
+	defer placementPluginsLock.RUnlock()
+
+	configuredFilters := map[string]bool{}
+	for _, config := range profile.Filters {
+		if _, ok := filterPlugins[config.Name]; !ok {
+			return fmt.Errorf("unknown placement filter %q", config.Name)
+		}
+		if configuredFilters[config.Name] {
+			return fmt.Errorf("placement filter %s is configured more than once", config.Name)
+		}
+		configuredFilters[config.Name] = true
+	}
+
+	configuredScorers := map[string]bool{}
+	for _, config := range profile.Scorers {
+		if _, ok := nodeScorerPlugins[config.Name]; !ok {
+			return fmt.Errorf("unknown placement scorer %q", config.Name)
+		}
+		if configuredScorers[config.Name] {
+			return fmt.Errorf("placement scorer %s is configured more than once", config.Name)
+		}
+		configuredScorers[config.Name] = true
+		if config.Weight != nil {
+			if err := validateScorerPriority(config.Name, *config.Weight); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func validateScorerPriority(name string, priority float64) error {
+	if priority < 0 || priority > maxScorerPriority {
+		return fmt.Errorf("priority of placement scorer %s must be between 0 and %d: %v", name, maxScorerPriority, priority)
+	}
+	return nil
+}
+
+// newFilterPlugins returns the filter plugins enabled by the placement profile in the order they are run.
+func newFilterPlugins(profile azdiskv1beta2.PlacementProfile) []filterPlugin {
+	placementPluginsLock.RLock()
+	defer placementPluginsLock.RUnlock()
+
+	configs := getPlacementPluginConfigurations(profile.Filters)
+
+	var plugins []filterPlugin
+	for _, name := range filterPluginNames {
+		registration := filterPlugins[name]
+		enabled := registration.defaultEnabled
+		if config, ok := configs[name]; ok && config.Enabled != nil {
+			enabled = *config.Enabled
+		}
+		if enabled {
+			plugins = append(plugins, registration.newPlugin())
+		}
+	}
+	return plugins
+}
+
+// newNodeScorerPlugins returns the node scorer plugins enabled by the placement profile in the order they are run.
+func newNodeScorerPlugins(profile azdiskv1beta2.PlacementProfile) []nodeScorerPlugin {
+	placementPluginsLock.RLock()
+	defer placementPluginsLock.RUnlock()
+
+	configs := getPlacementPluginConfigurations(profile.Scorers)
+
+	var plugins []nodeScorerPlugin
+	for _, name := range nodeScorerPluginNames {
+		registration := nodeScorerPlugins[name]
+		enabled := registration.defaultEnabled
+		priority := registration.defaultPriority
+		if config, ok := configs[name]; ok {
+			if config.Enabled != nil {
+				enabled = *config.Enabled
+			}
+			if config.Weight != nil {
+				priority = *config.Weight
+			}
+		}
+		if enabled {
+			plugin := registration.newPlugin()
+			plugin.setPriority(priority)
+			plugins = append(plugins, plugin)
+		}
+	}
+	return plugins
+}
+
+func getPlacementPluginConfigurations(configs []azdiskv1beta2.PlacementPluginConfiguration) map[string]azdiskv1beta2.PlacementPluginConfiguration {
+	configMap := make(map[string]azdiskv1beta2.PlacementPluginConfiguration, len(configs))
+	for _, config := range configs {
+		configMap[config.Name] = config
+	}
+	return configMap
+}
+
+// scorerPriority implements the priority of a node scorer plugin configured by the placement profile.
+type scorerPriority struct {
+	value float64
+}
+
+func (p *scorerPriority) priority() float64 {
+	return p.value
+}
+
+func (p *scorerPriority) setPriority(priority float64) {
+	p.value = priority
+}
+
+// customFilterPlugin adapts a PlacementFilter to a filter plugin.
+type customFilterPlugin struct {
+	pluginName        string
+	plugin            PlacementFilter
+	pods              []v1.Pod
+	persistentVolumes []*v1.PersistentVolume
+}
+
+func (p *customFilterPlugin) name() string {
+	return p.pluginName
+}
+
+func (p *customFilterPlugin) setup(pods []v1.Pod, persistentVolumes []*v1.PersistentVolume, state *SharedState) {
+	p.pods = pods
+	p.persistentVolumes = persistentVolumes
+}
+
+func (p *customFilterPlugin) filter(ctx context.Context, nodes []v1.Node) ([]v1.Node, error) {
+	ctx, w := workflow.New(ctx, workflow.WithDetails("filter-plugin", p.name()))
+	defer w.Finish(nil)
+
+	return p.plugin.Filter(ctx, nodes, p.pods, p.persistentVolumes)
+}
+
+// customScorerPlugin adapts a PlacementScorer to a node scorer plugin.
+type customScorerPlugin struct {
+	scorerPriority
+	pluginName string
+	plugin     PlacementScorer
+	nodes      []v1.Node
+	pods       []v1.Pod
+	volumes    []string
+}
+
+func (s *customScorerPlugin) name() string {
+	return s.pluginName
+}
+
+func (s *customScorerPlugin) setup(nodes []v1.Node, pods []v1.Pod, volumes []string, state *SharedState) {
+	s.nodes = nodes
+	s.pods = pods
+	s.volumes = volumes
+}
+
+func (s *customScorerPlugin) score(ctx context.Context, nodeScores map[string]int) (map[string]int, error) {
+	ctx, w := workflow.New(ctx, workflow.WithDetails("score-plugin", s.name()))
+	defer w.Finish(nil)
+
+	scores, err := s.plugin.Score(ctx, s.nodes, s.pods, s.volumes)
+	if err != nil {
+		return nodeScores, err
+	}
+
+	for node, score := range scores {
+		if _, ok := nodeScores[node]; !ok {
+			continue
+		}
+		nodeScores[node] += int(math.Min(math.Max(score, 0), 1) * math.Pow(10, s.priority()))
+	}
+	return nodeScores, nil
+}
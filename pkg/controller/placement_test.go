@@ -0,0 +1,212 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/utils/pointer"
+	azdiskv1beta2 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils/mockclient"
+)
+
+const (
+	testPlacementFilterName = "test-placement-filter"
+	testPlacementScorerName = "test-placement-scorer"
+)
+
+type testPlacementFilter struct {
+	excludedNode string
+}
+
+func (f *testPlacementFilter) Filter(ctx context.Context, nodes []v1.Node, pods []v1.Pod, persistentVolumes []*v1.PersistentVolume) ([]v1.Node, error) {
+	var filteredNodes []v1.Node
+	for _, node := range nodes {
+		if node.Name != f.excludedNode {
+			filteredNodes = append(filteredNodes, node)
+		}
+	}
+	return filteredNodes, nil
+}
+
+type testPlacementScorer struct {
+	preferredNode string
+}
+
+func (s *testPlacementScorer) Score(ctx context.Context, nodes []v1.Node, pods []v1.Pod, volumes []string) (map[string]float64, error) {
+	return map[string]float64{s.preferredNode: 1}, nil
+}
+
+func init() {
+	_ = RegisterPlacementFilter(testPlacementFilterName, func() PlacementFilter { return &testPlacementFilter{excludedNode: testNode0Name} })
+	_ = RegisterPlacementScorer(testPlacementScorerName, 4, func() PlacementScorer { return &testPlacementScorer{preferredNode: testNode2Name} })
+}
+
+func getFilterPluginNames(plugins []filterPlugin) []string {
+	names := make([]string, len(plugins))
+	for i, plugin := range plugins {
+		names[i] = plugin.name()
+	}
+	return names
+}
+
+func getNodeScorerPluginNames(plugins []nodeScorerPlugin) []string {
+	names := make([]string, len(plugins))
+	for i, plugin := range plugins {
+		names[i] = plugin.name()
+	}
+	return names
+}
+
+func TestRegisterPlacementPlugins(t *testing.T) {
+	assert.Error(t, RegisterPlacementFilter(testPlacementFilterName, func() PlacementFilter { return &testPlacementFilter{} }))
+	assert.Error(t, RegisterPlacementFilter("", func() PlacementFilter { return &testPlacementFilter{} }))
+	assert.Error(t, RegisterPlacementScorer(testPlacementScorerName, 1, func() PlacementScorer { return &testPlacementScorer{} }))
+	assert.Error(t, RegisterPlacementScorer("invalid-priority-scorer", 6, func() PlacementScorer { return &testPlacementScorer{} }))
+	assert.Error(t, RegisterPlacementScorer("nil-scorer", 1, nil))
+}
+
+func TestValidatePlacementProfile(t *testing.T) {
+	tests := []struct {
+		description   string
+		profile       azdiskv1beta2.PlacementProfile
+		expectedError bool
+	}{
+		{
+			description: "[Success] Should accept an empty profile",
+		},
+		{
+			description: "[Success] Should accept built-in and registered plugins",
+			profile: azdiskv1beta2.PlacementProfile{
+				Filters: []azdiskv1beta2.PlacementPluginConfiguration{{Name: PodTolerationFilterName, Enabled: pointer.Bool(false)}, {Name: testPlacementFilterName, Enabled: pointer.Bool(true)}},
+				Scorers: []azdiskv1beta2.PlacementPluginConfiguration{{Name: NodeCapacityScorerName, Weight: pointer.Float64(4)}, {Name: testPlacementScorerName, Enabled: pointer.Bool(true)}},
+			},
+		},
+		{
+			description: "[Failure] Should reject an unknown filter",
+			profile: azdiskv1beta2.PlacementProfile{
+				Filters: []azdiskv1beta2.PlacementPluginConfiguration{{Name: "unknown"}},
+			},
+			expectedError: true,
+		},
+		{
+			description: "[Failure] Should reject an unknown scorer",
+			profile: azdiskv1beta2.PlacementProfile{
+				Scorers: []azdiskv1beta2.PlacementPluginConfiguration{{Name: PodTolerationFilterName}},
+			},
+			expectedError: true,
+		},
+		{
+			description: "[Failure] Should reject a plugin configured more than once",
+			profile: azdiskv1beta2.PlacementProfile{
+				Scorers: []azdiskv1beta2.PlacementPluginConfiguration{{Name: NodeCapacityScorerName}, {Name: NodeCapacityScorerName}},
+			},
+			expectedError: true,
+		},
+		{
+			description: "[Failure] Should reject a weight out of range",
+			profile: azdiskv1beta2.PlacementProfile{
+				Scorers: []azdiskv1beta2.PlacementPluginConfiguration{{Name: ReplicaCountScorerName, Weight: pointer.Float64(-1)}},
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		tt := test
+		t.Run(tt.description, func(t *testing.T) {
+			err := ValidatePlacementProfile(tt.profile)
+			if tt.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNewPlacementPlugins(t *testing.T) {
+	defaultFilters := getFilterPluginNames(newFilterPlugins(azdiskv1beta2.PlacementProfile{}))
+	assert.Len(t, defaultFilters, 6)
+	assert.NotContains(t, defaultFilters, (&topologySpreadFilter{}).name())
+	assert.NotContains(t, defaultFilters, testPlacementFilterName)
+
+	defaultScorers := newNodeScorerPlugins(azdiskv1beta2.PlacementProfile{})
+	require.Len(t, defaultScorers, 6)
+	assert.Equal(t, float64(1), defaultScorers[0].priority())
+	assert.Equal(t, float64(3), defaultScorers[1].priority())
+
+	profile := azdiskv1beta2.PlacementProfile{
+		Filters: []azdiskv1beta2.PlacementPluginConfiguration{{Name: PodTolerationFilterName, Enabled: pointer.Bool(false)}, {Name: testPlacementFilterName, Enabled: pointer.Bool(true)}},
+		Scorers: []azdiskv1beta2.PlacementPluginConfiguration{{Name: NodeCapacityScorerName, Weight: pointer.Float64(4)}, {Name: ReplicaCountScorerName, Enabled: pointer.Bool(false)}, {Name: testPlacementScorerName, Enabled: pointer.Bool(true)}},
+	}
+
+	filters := getFilterPluginNames(newFilterPlugins(profile))
+	assert.Len(t, filters, 6)
+	assert.NotContains(t, filters, (&podTolerationFilter{}).name())
+	assert.Equal(t, testPlacementFilterName, filters[len(filters)-1])
+
+	scorers := newNodeScorerPlugins(profile)
+	require.Len(t, scorers, 6)
+	assert.Equal(t, (&scoreByNodeCapacity{}).name(), scorers[0].name())
+	assert.Equal(t, float64(4), scorers[0].priority())
+	assert.NotContains(t, getNodeScorerPluginNames(scorers), (&scoreByReplicaCount{}).name())
+	assert.Equal(t, testPlacementScorerName, scorers[len(scorers)-1].name())
+	assert.Equal(t, float64(4), scorers[len(scorers)-1].priority())
+}
+
+func TestPrioritizeNodesWithPlacementProfile(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	nodes := []v1.Node{
+		*convertToNode(withLabel(&testNode0, map[string]string{v1.LabelInstanceTypeStable: "BASIC_A3"})),
+		*convertToNode(withLabel(&testNode1, map[string]string{v1.LabelInstanceTypeStable: "BASIC_A3"})),
+		*convertToNode(withLabel(&testNode2, map[string]string{v1.LabelInstanceTypeStable: "BASIC_A3"})),
+	}
+	primary1Node2 := createTestAzVolumeAttachment(testPersistentVolume1Name, testNode2Name, azdiskv1beta2.PrimaryRole)
+	sharedState := NewTestSharedState(
+		mockCtl,
+		testNamespace,
+		&nodes[0],
+		&nodes[1],
+		&nodes[2],
+		&testAzVolume0,
+		&testPersistentVolume0,
+		&testAzVolume1,
+		&testPersistentVolume1,
+		&primary1Node2,
+	)
+	mockClients(sharedState.cachedClient.(*mockclient.MockClient), sharedState.azClient, sharedState.kubeClient)
+
+	// Without the custom scorer the node with the least remaining capacity is ranked last
+	sortedNodes := sharedState.prioritizeNodes(context.TODO(), []v1.Pod{testPod0}, []string{testPersistentVolume0Name}, append([]v1.Node{}, nodes...))
+	require.Len(t, sortedNodes, 3)
+	assert.Equal(t, testNode2Name, sortedNodes[2].Name)
+
+	sharedState.config.ControllerConfig.PlacementProfile = azdiskv1beta2.PlacementProfile{
+		Scorers: []azdiskv1beta2.PlacementPluginConfiguration{{Name: testPlacementScorerName, Enabled: pointer.Bool(true)}},
+	}
+	sortedNodes = sharedState.prioritizeNodes(context.TODO(), []v1.Pod{testPod0}, []string{testPersistentVolume0Name}, append([]v1.Node{}, nodes...))
+	require.Len(t, sortedNodes, 3)
+	assert.Equal(t, testNode2Name, sortedNodes[0].Name)
+}
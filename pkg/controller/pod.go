@@ -158,12 +158,18 @@ func (r *ReconcilePod) Recover(ctx context.Context) error {
 	}
 
 	for _, pod := range pods.Items {
-		// update the shared map
-		podKey := getQualifiedName(pod.Namespace, pod.Name)
-		if err := r.addPod(ctx, &pod, skipLock); err != nil {
-			w.Logger().V(5).Infof("failed to add necessary components for pod (%s)", podKey)
+		// pods unchanged since the checkpoint already have their replicas requested
+		if r.restorePodFromCheckpoint(&pod) {
 			continue
 		}
+		// update the shared map unless the pods are already tracked and only the replicas of a newly acquired shard are recovered
+		podKey := getQualifiedName(pod.Namespace, pod.Name)
+		if _, shardScoped := getShardScope(ctx); !shardScoped {
+			if err := r.addPod(ctx, &pod, skipLock); err != nil {
+				w.Logger().V(5).Infof("failed to add necessary components for pod (%s)", podKey)
+				continue
+			}
+		}
 		if err := r.createReplicas(ctx, podKey); err != nil {
 			w.Logger().V(5).Infof("failed to create replica AzVolumeAttachments for pod (%s)", podKey)
 		}
@@ -130,6 +130,18 @@ func (r *ReconcilePV) Reconcile(ctx context.Context, request reconcile.Request)
 		return reconcileReturnOnSuccess(pv.Name, r.controllerRetryInfo)
 	}
 
+	// only the owner of the volume's shard updates the AzVolume, other controllers only track the volume's claim
+	if !r.ownsVolume(azVolumeName) {
+		switch pv.Status.Phase {
+		case corev1.VolumeBound:
+			r.addBoundPV(&pv, azVolumeName, getQualifiedName(pv.Spec.ClaimRef.Namespace, pv.Spec.ClaimRef.Name))
+		case corev1.VolumeReleased:
+			r.deleteVolumeAndClaim(azVolumeName)
+			r.pvCheckpoints.Delete(pv.Name)
+		}
+		return reconcileReturnOnSuccess(pv.Name, r.controllerRetryInfo)
+	}
+
 	var azVolumeUpdateFunc azureutils.UpdateCRIFunc
 
 	if azVolume.Spec.PersistentVolume != pv.Name {
@@ -175,13 +187,14 @@ func (r *ReconcilePV) Reconcile(ctx context.Context, request reconcile.Request)
 	switch phase := pv.Status.Phase; phase {
 	case corev1.VolumeBound:
 		pvClaimName := getQualifiedName(pv.Spec.ClaimRef.Namespace, pv.Spec.ClaimRef.Name)
-		r.addVolumeAndClaim(azVolumeName, pv.Name, pvClaimName)
+		r.addBoundPV(&pv, azVolumeName, pvClaimName)
 	case corev1.VolumeReleased:
 		if err := r.triggerRelease(ctx, &azVolume); err != nil {
 			logger.Error(err, "failed to release AzVolume")
 			return reconcileReturnOnError(ctx, &pv, "release", err, r.controllerRetryInfo)
 		}
 		r.deleteVolumeAndClaim(azVolumeName)
+		r.pvCheckpoints.Delete(pv.Name)
 	}
 
 	return reconcileReturnOnSuccess(pv.Name, r.controllerRetryInfo)
@@ -210,6 +223,9 @@ func (r *ReconcilePV) Recover(ctx context.Context) error {
 		if pv.Spec.CSI == nil || pv.Spec.CSI.Driver != r.config.DriverName || pv.Spec.ClaimRef == nil {
 			continue
 		}
+		if r.restorePVFromCheckpoint(&pv) {
+			continue
+		}
 
 		var diskName string
 		diskName, err = azureutils.GetDiskName(pv.Spec.CSI.VolumeHandle)
@@ -220,7 +236,7 @@ func (r *ReconcilePV) Recover(ctx context.Context) error {
 		}
 		azVolumeName := strings.ToLower(diskName)
 		pvClaimName := getQualifiedName(pv.Spec.ClaimRef.Namespace, pv.Spec.ClaimRef.Name)
-		r.addVolumeAndClaim(azVolumeName, pv.Name, pvClaimName)
+		r.addBoundPV(&pv, azVolumeName, pvClaimName)
 	}
 	if errCount > 0 {
 		pvCount := pvs.Size()
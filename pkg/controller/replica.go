@@ -131,7 +131,7 @@ func (r *ReconcileReplica) Reconcile(ctx context.Context, request reconcile.Requ
 					}
 
 					var updateFunc azureutils.UpdateCRIFunc
-					if retryCount < r.config.ControllerConfig.ReplicaVolumeAttachRetryLimit {
+					if retryCount < r.getReplicaVolumeAttachRetryLimit() {
 						updateFunc = func(obj client.Object) error {
 							azva := obj.(*azdiskv1beta2.AzVolumeAttachment)
 							azureutils.RemoveFromMap(azva.Status.Annotations, consts.ReplicaVolumeAttachRetryAnnotation)
@@ -305,7 +305,7 @@ func NewReplicaController(mgr manager.Manager, controllerSharedState *SharedStat
 		DeleteFunc: func(e event.DeleteEvent) bool {
 			return true
 		},
-	})
+	}, controllerSharedState.shardPredicate(getAzVolumeAttachmentShardKey))
 	if err != nil {
 		logger.Error(err, "failed to initialize watch for AzVolumeAttachment CRI")
 		return nil, err
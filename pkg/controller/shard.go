@@ -0,0 +1,226 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	azdiskv1beta2 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// CoordinatorShard is the shard whose owner runs the cluster-wide tasks which must not be run by more than one controller plugin instance.
+const CoordinatorShard = 0
+
+/*
+ShardManager partitions the volumes and nodes into a fixed number of shards so that several controller plugin instances can reconcile them at the same time.
+Volumes and nodes are assigned to a shard by the hash of their name, and each shard is owned by the controller plugin instance holding the shard's lease.
+When an instance acquires the lease of a shard, the shard acquired handler recovers the objects of the shard so that they are reconciled by the new owner.
+*/
+type ShardManager struct {
+	logger          logr.Logger
+	kubeClient      kubernetes.Interface
+	shardCount      int
+	leaseName       string
+	leaseNamespace  string
+	identity        string
+	leaseDuration   time.Duration
+	renewDeadline   time.Duration
+	retryPeriod     time.Duration
+	ownedShards     sync.Map
+	onShardAcquired func(ctx context.Context, shard int)
+}
+
+var _ manager.LeaderElectionRunnable = &ShardManager{}
+
+// NewShardManager creates a shard manager partitioning the volumes and nodes into the configured number of shards.
+func NewShardManager(config *azdiskv1beta2.AzDiskDriverConfiguration, kubeClient kubernetes.Interface, logger logr.Logger) (*ShardManager, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ShardManager{
+		logger:         logger.WithValues("controller", "shard"),
+		kubeClient:     kubeClient,
+		shardCount:     config.ControllerConfig.ShardCount,
+		leaseName:      config.ControllerConfig.PartitionName,
+		leaseNamespace: config.ControllerConfig.LeaderElectionNamespace,
+		identity:       hostname + "_" + string(uuid.NewUUID()),
+		leaseDuration:  time.Duration(config.ControllerConfig.LeaseDurationInSec) * time.Second,
+		renewDeadline:  time.Duration(config.ControllerConfig.LeaseRenewDeadlineInSec) * time.Second,
+		retryPeriod:    time.Duration(config.ControllerConfig.LeaseRetryPeriodInSec) * time.Second,
+	}, nil
+}
+
+// SetShardAcquiredHandler sets the function called when the lease of a shard is acquired.
+func (m *ShardManager) SetShardAcquiredHandler(handler func(ctx context.Context, shard int)) {
+	m.onShardAcquired = handler
+}
+
+// IsSharded returns true if the volumes and nodes are partitioned into more than one shard.
+func (m *ShardManager) IsSharded() bool {
+	return m != nil && m.shardCount > 1
+}
+
+// GetShard returns the shard of the volume or node with the specified name.
+func (m *ShardManager) GetShard(key string) int {
+	if !m.IsSharded() {
+		return CoordinatorShard
+	}
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(key))
+	return int(hash.Sum32() % uint32(m.shardCount))
+}
+
+// OwnsShard returns true if this controller plugin instance owns the specified shard.
+func (m *ShardManager) OwnsShard(shard int) bool {
+	if !m.IsSharded() {
+		return true
+	}
+	_, ok := m.ownedShards.Load(shard)
+	return ok
+}
+
+// Owns returns true if this controller plugin instance owns the shard of the volume or node with the specified name.
+func (m *ShardManager) Owns(key string) bool {
+	return m.OwnsShard(m.GetShard(key))
+}
+
+// Start competes for the lease of every shard until the context is done.
+func (m *ShardManager) Start(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for shard := 0; shard < m.shardCount; shard++ {
+		wg.Add(1)
+		go func(shard int) {
+			defer wg.Done()
+			m.runShardElection(ctx, shard)
+		}(shard)
+	}
+	wg.Wait()
+	return nil
+}
+
+// NeedLeaderElection returns false as every controller plugin instance competes for the shard leases.
+func (m *ShardManager) NeedLeaderElection() bool {
+	return false
+}
+
+func (m *ShardManager) runShardElection(ctx context.Context, shard int) {
+	logger := m.logger.WithValues("shard", shard)
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      getShardLeaseName(m.leaseName, shard),
+			Namespace: m.leaseNamespace,
+		},
+		Client: m.kubeClient.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: m.identity,
+		},
+	}
+
+	// compete for the lease again whenever it is lost until the context is done
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+			Lock:            lock,
+			LeaseDuration:   m.leaseDuration,
+			RenewDeadline:   m.renewDeadline,
+			RetryPeriod:     m.retryPeriod,
+			ReleaseOnCancel: true,
+			Name:            lock.LeaseMeta.Name,
+			Callbacks: leaderelection.LeaderCallbacks{
+				OnStartedLeading: func(ctx context.Context) {
+					logger.V(2).Info("Acquired shard lease.")
+					m.ownedShards.Store(shard, struct{}{})
+					if m.onShardAcquired != nil {
+						m.onShardAcquired(ctx, shard)
+					}
+				},
+				OnStoppedLeading: func() {
+					logger.V(2).Info("Lost shard lease.")
+					m.ownedShards.Delete(shard)
+				},
+			},
+		})
+		if err != nil {
+			logger.Error(err, "failed to create shard lease elector")
+			return
+		}
+		elector.Run(ctx)
+	}, m.retryPeriod)
+}
+
+func getShardLeaseName(leaseName string, shard int) string {
+	return fmt.Sprintf("%s-shard-%d", leaseName, shard)
+}
+
+type shardScopeKey struct{}
+
+// WithShardScope returns a context which scopes the recovery of the controllers to the volumes and nodes of the specified shard,
+// so that the objects of the shards already owned by this controller plugin instance are not recovered again.
+func WithShardScope(ctx context.Context, shard int) context.Context {
+	return context.WithValue(ctx, shardScopeKey{}, shard)
+}
+
+func getShardScope(ctx context.Context) (int, bool) {
+	shard, ok := ctx.Value(shardScopeKey{}).(int)
+	return shard, ok
+}
+
+// ownsInScope returns true if this controller plugin instance owns the shard of the volume or node with the specified name
+// and the shard is in the scope of the context.
+func (c *SharedState) ownsInScope(ctx context.Context, key string) bool {
+	if shard, ok := getShardScope(ctx); ok && c.shardManager.GetShard(key) != shard {
+		return false
+	}
+	return c.shardManager.Owns(key)
+}
+
+// shardPredicate filters out the events of objects whose shard is not owned by this controller plugin instance.
+// Objects for which getShardKey returns false are not filtered out.
+func (c *SharedState) shardPredicate(getShardKey func(client.Object) (string, bool)) predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		key, ok := getShardKey(obj)
+		return !ok || c.shardManager.Owns(key)
+	})
+}
+
+func getObjectNameShardKey(obj client.Object) (string, bool) {
+	return obj.GetName(), true
+}
+
+func getAzVolumeAttachmentShardKey(obj client.Object) (string, bool) {
+	azVolumeAttachment, ok := obj.(*azdiskv1beta2.AzVolumeAttachment)
+	if !ok {
+		return "", false
+	}
+	return azVolumeAttachment.Spec.VolumeName, true
+}
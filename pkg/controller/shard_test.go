@@ -0,0 +1,193 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"k8s.io/klog/v2/klogr"
+	azdiskv1beta2 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func newTestShardManager(shardCount int, ownedShards ...int) *ShardManager {
+	shardManager := &ShardManager{
+		logger:     klogr.New(),
+		shardCount: shardCount,
+	}
+	for _, shard := range ownedShards {
+		shardManager.ownedShards.Store(shard, struct{}{})
+	}
+	return shardManager
+}
+
+// getTestKeyInShard returns a volume name assigned to the specified shard.
+func getTestKeyInShard(t *testing.T, shardManager *ShardManager, shard int) string {
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("test-volume-%d", i)
+		if shardManager.GetShard(key) == shard {
+			return key
+		}
+	}
+	require.FailNow(t, "no key found", "no key assigned to shard %d", shard)
+	return ""
+}
+
+func TestShardManagerOwnership(t *testing.T) {
+	tests := []struct {
+		description  string
+		shardManager *ShardManager
+		verifyFunc   func(*testing.T, *ShardManager)
+	}{
+		{
+			description:  "[Success] Should own every volume when shard manager is not set",
+			shardManager: nil,
+			verifyFunc: func(t *testing.T, shardManager *ShardManager) {
+				require.False(t, shardManager.IsSharded())
+				require.Equal(t, CoordinatorShard, shardManager.GetShard(testPersistentVolume0Name))
+				require.True(t, shardManager.Owns(testPersistentVolume0Name))
+				require.True(t, shardManager.OwnsShard(CoordinatorShard))
+			},
+		},
+		{
+			description:  "[Success] Should own every volume with a single shard",
+			shardManager: newTestShardManager(1),
+			verifyFunc: func(t *testing.T, shardManager *ShardManager) {
+				require.False(t, shardManager.IsSharded())
+				require.True(t, shardManager.Owns(testPersistentVolume0Name))
+				require.True(t, shardManager.Owns(testPersistentVolume1Name))
+			},
+		},
+		{
+			description:  "[Success] Should assign volumes to a stable shard in range",
+			shardManager: newTestShardManager(3),
+			verifyFunc: func(t *testing.T, shardManager *ShardManager) {
+				require.True(t, shardManager.IsSharded())
+				for i := 0; i < 100; i++ {
+					key := fmt.Sprintf("test-volume-%d", i)
+					shard := shardManager.GetShard(key)
+					require.GreaterOrEqual(t, shard, 0)
+					require.Less(t, shard, 3)
+					require.Equal(t, shard, shardManager.GetShard(key))
+				}
+			},
+		},
+		{
+			description:  "[Success] Should only own volumes of owned shards",
+			shardManager: newTestShardManager(2, 1),
+			verifyFunc: func(t *testing.T, shardManager *ShardManager) {
+				require.False(t, shardManager.OwnsShard(CoordinatorShard))
+				require.True(t, shardManager.OwnsShard(1))
+				require.False(t, shardManager.Owns(getTestKeyInShard(t, shardManager, 0)))
+				require.True(t, shardManager.Owns(getTestKeyInShard(t, shardManager, 1)))
+
+				shardManager.ownedShards.Delete(1)
+				require.False(t, shardManager.Owns(getTestKeyInShard(t, shardManager, 1)))
+			},
+		},
+	}
+
+	for _, test := range tests {
+		tt := test
+		t.Run(tt.description, func(t *testing.T) {
+			tt.verifyFunc(t, tt.shardManager)
+		})
+	}
+}
+
+func TestShardPredicate(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	shardManager := newTestShardManager(2, 1)
+	sharedState := NewTestSharedState(mockCtl, testNamespace)
+	sharedState.SetShardManager(shardManager)
+
+	ownedVolumeName := getTestKeyInShard(t, shardManager, 1)
+	unownedVolumeName := getTestKeyInShard(t, shardManager, 0)
+
+	ownedAzVolume := createTestAzVolume(ownedVolumeName, 1)
+	unownedAzVolume := createTestAzVolume(unownedVolumeName, 1)
+	ownedAzVolumeAttachment := createTestAzVolumeAttachment(ownedVolumeName, testNode0Name, azdiskv1beta2.PrimaryRole)
+	unownedAzVolumeAttachment := createTestAzVolumeAttachment(unownedVolumeName, testNode0Name, azdiskv1beta2.PrimaryRole)
+
+	volumePredicate := sharedState.shardPredicate(getObjectNameShardKey)
+	require.True(t, volumePredicate.Create(event.CreateEvent{Object: &ownedAzVolume}))
+	require.False(t, volumePredicate.Create(event.CreateEvent{Object: &unownedAzVolume}))
+
+	attachmentPredicate := sharedState.shardPredicate(getAzVolumeAttachmentShardKey)
+	require.True(t, attachmentPredicate.Update(event.UpdateEvent{ObjectOld: &ownedAzVolumeAttachment, ObjectNew: &ownedAzVolumeAttachment}))
+	require.False(t, attachmentPredicate.Update(event.UpdateEvent{ObjectOld: &unownedAzVolumeAttachment, ObjectNew: &unownedAzVolumeAttachment}))
+	// objects without a shard key are not filtered out
+	require.True(t, attachmentPredicate.Create(event.CreateEvent{Object: &unownedAzVolume}))
+
+	require.False(t, sharedState.isCoordinator())
+}
+
+func TestAddToOperationQueueSkipsUnownedVolume(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	shardManager := newTestShardManager(2, 1)
+	sharedState := NewTestSharedState(mockCtl, testNamespace)
+	sharedState.SetShardManager(shardManager)
+
+	unownedVolumeName := getTestKeyInShard(t, shardManager, 0)
+	sharedState.createOperationQueue(unownedVolumeName)
+
+	sharedState.addToOperationQueue(context.TODO(), unownedVolumeName, replica, func(context.Context) error {
+		require.FailNow(t, "unexpected operation", "operation for volume (%s) not owned should not be run", unownedVolumeName)
+		return nil
+	}, false)
+
+	v, ok := sharedState.volumeOperationQueues.Load(unownedVolumeName)
+	require.True(t, ok)
+	require.Zero(t, v.(*lockableEntry).entry.(*operationQueue).Len())
+}
+
+func TestOwnsInScope(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	shardManager := newTestShardManager(3, 1, 2)
+	sharedState := NewTestSharedState(mockCtl, testNamespace)
+	sharedState.SetShardManager(shardManager)
+
+	unownedKey := getTestKeyInShard(t, shardManager, 0)
+	ownedKey := getTestKeyInShard(t, shardManager, 1)
+	acquiredKey := getTestKeyInShard(t, shardManager, 2)
+
+	// without a shard scope, the keys of all the owned shards are in scope
+	ctx := context.TODO()
+	require.False(t, sharedState.ownsInScope(ctx, unownedKey))
+	require.True(t, sharedState.ownsInScope(ctx, ownedKey))
+	require.True(t, sharedState.ownsInScope(ctx, acquiredKey))
+
+	// with a shard scope, only the keys of the scoped shard are in scope
+	ctx = WithShardScope(ctx, 2)
+	require.False(t, sharedState.ownsInScope(ctx, unownedKey))
+	require.False(t, sharedState.ownsInScope(ctx, ownedKey))
+	require.True(t, sharedState.ownsInScope(ctx, acquiredKey))
+
+	// a scoped shard which is not owned is not in scope
+	shardManager.ownedShards.Delete(2)
+	require.False(t, sharedState.ownsInScope(ctx, acquiredKey))
+}
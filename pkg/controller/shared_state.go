@@ -86,6 +86,16 @@ type SharedState struct {
 	azureDiskCSITranslator        csitranslator.InTreePlugin
 	availableAttachmentsMap       sync.Map
 	driverLifecycle               DriverLifecycle
+	replicaVolumeAttachRetryLimit int32
+	shardManager                  *ShardManager
+	// checkpoint is loaded before and read by the concurrent recoveries of the controllers
+	checkpoint atomic.Pointer[sharedStateCheckpoint]
+	// pvCheckpoints and podCheckpoints hold the checkpoint entries of the PersistentVolumes and Pods, recorded along with
+	// the shared state derived from them so that a checkpoint never pairs a newer resourceVersion with older state
+	pvCheckpoints  sync.Map
+	podCheckpoints sync.Map
+	// checkpointDisabled is set once the checkpoint outgrows the size limit of its ConfigMap
+	checkpointDisabled atomic.Bool
 }
 
 func NewSharedState(config *azdiskv1beta2.AzDiskDriverConfiguration, topologyKey string, eventRecorder record.EventRecorder, cachedClient client.Client, crdClient crdClientset.Interface, kubeClient kubernetes.Interface, driverLifecycle DriverLifecycle) *SharedState {
@@ -101,6 +111,7 @@ func NewSharedState(config *azdiskv1beta2.AzDiskDriverConfiguration, topologyKey
 		azureDiskCSITranslator: csitranslator.NewAzureDiskCSITranslator(),
 		driverLifecycle:        driverLifecycle,
 	}
+	newSharedState.SetReplicaVolumeAttachRetryLimit(config.ControllerConfig.ReplicaVolumeAttachRetryLimit)
 	newSharedState.createReplicaRequestsQueue()
 
 	return newSharedState
@@ -111,9 +122,40 @@ func (c *SharedState) isRecoveryComplete() bool {
 }
 
 func (c *SharedState) MarkRecoveryComplete() {
+	// the checkpoint is only valid for the recovery following its load
+	c.checkpoint.Store(nil)
 	atomic.StoreUint32(&c.recoveryComplete, 1)
 }
 
+// SetShardManager sets the shard manager used to filter out the volumes and nodes not owned by this controller plugin instance.
+func (c *SharedState) SetShardManager(shardManager *ShardManager) {
+	c.shardManager = shardManager
+}
+
+// ownsVolume returns true if this controller plugin instance owns the shard of the volume.
+func (c *SharedState) ownsVolume(volumeName string) bool {
+	return c.shardManager.Owns(volumeName)
+}
+
+// ownsNode returns true if this controller plugin instance owns the shard of the node.
+func (c *SharedState) ownsNode(nodeName string) bool {
+	return c.shardManager.Owns(nodeName)
+}
+
+// isCoordinator returns true if this controller plugin instance runs the cluster-wide tasks.
+func (c *SharedState) isCoordinator() bool {
+	return c.shardManager.OwnsShard(CoordinatorShard)
+}
+
+func (c *SharedState) getReplicaVolumeAttachRetryLimit() int {
+	return int(atomic.LoadInt32(&c.replicaVolumeAttachRetryLimit))
+}
+
+// SetReplicaVolumeAttachRetryLimit changes the maximum number of retries for creating a replica attachment.
+func (c *SharedState) SetReplicaVolumeAttachRetryLimit(limit int) {
+	atomic.StoreInt32(&c.replicaVolumeAttachRetryLimit, int32(limit))
+}
+
 func (c *SharedState) DeleteAPIVersion(ctx context.Context, deleteVersion string) error {
 	w, _ := workflow.GetWorkflowFromContext(ctx)
 	crdNames := []string{consts.AzDriverNodeCRDName, consts.AzVolumeCRDName, consts.AzVolumeAttachmentCRDName}
@@ -182,6 +224,12 @@ func (c *SharedState) addToOperationQueue(ctx context.Context, volumeName string
 	// The child workflow will be created below and be fed to the queued operation for necessary workflow information.
 	ctx, w := workflow.New(ctx, workflow.WithDetails(consts.VolumeNameLabel, volumeName))
 
+	// replica operations of a volume are only run by the owner of the volume's shard
+	if !c.ownsInScope(ctx, volumeName) {
+		w.Logger().V(5).Infof("Skipping %s operation for volume (%s) not owned by this controller.", requester, volumeName)
+		return
+	}
+
 	v, ok := c.volumeOperationQueues.Load(volumeName)
 	if !ok {
 		return
@@ -493,11 +541,13 @@ func (c *SharedState) addPod(ctx context.Context, pod *v1.Pod, updateOption upda
 		allClaims = append(allClaims, key.(string))
 	}
 	c.podToClaimsMap.Store(podKey, allClaims)
+	c.podCheckpoints.Store(podKey, podCheckpoint{ResourceVersion: pod.ResourceVersion, Claims: allClaims})
 	return nil
 }
 
 func (c *SharedState) deletePod(ctx context.Context, podKey string) error {
 	w, _ := workflow.GetWorkflowFromContext(ctx)
+	c.podCheckpoints.Delete(podKey)
 	value, exists := c.podLocks.LoadAndDelete(podKey)
 	if !exists {
 		return nil
@@ -566,8 +616,15 @@ func (c *SharedState) addVolumeAndClaim(azVolumeName, pvName, pvClaimName string
 	c.claimToVolumeMap.Store(pvClaimName, azVolumeName)
 }
 
+// addBoundPV adds the volume and claim of a bound PersistentVolume and records the resourceVersion they were derived from.
+func (c *SharedState) addBoundPV(pv *v1.PersistentVolume, azVolumeName, pvClaimName string) {
+	c.addVolumeAndClaim(azVolumeName, pv.Name, pvClaimName)
+	c.pvCheckpoints.Store(pv.Name, pvCheckpoint{ResourceVersion: pv.ResourceVersion, VolumeName: azVolumeName, ClaimName: pvClaimName})
+}
+
 func (c *SharedState) deletePV(pvName string) error {
 	var err error
+	c.pvCheckpoints.Delete(pvName)
 
 	ctx := context.Background()
 	ctx, w := workflow.New(ctx, workflow.WithDetails())
@@ -608,6 +665,12 @@ func (c *SharedState) deletePV(pvName string) error {
 	// The volume itself will not be deleted.
 	w.AddDetailToLogger(workflow.GetObjectDetails(&azVolume)...)
 
+	// the AzVolume is deleted by the owner of the volume's shard
+	if !c.ownsVolume(azVolume.Name) {
+		c.pvToVolumeMap.Delete(pvName)
+		return nil
+	}
+
 	if !isPreProvisioned(&azVolume) {
 		return nil
 	}
@@ -644,6 +707,7 @@ func (c *SharedState) deletePV(pvName string) error {
 					azVolume := obj.(*azdiskv1beta2.AzVolume)
 					azVolume.Status.Error = nil
 					azVolume.Status.State = azdiskv1beta2.VolumeCreated
+					_ = updateAzVolumeConditions(azVolume)
 					return nil
 				}
 				_, _ = azureutils.UpdateCRIWithRetry(goCtx, nil, c.cachedClient, c.azClient, azVolume, updateFunc, consts.ForcedUpdateMaxNetRetry, azureutils.UpdateCRIStatus)
@@ -725,17 +789,8 @@ func (c *SharedState) filterNodes(ctx context.Context, nodes []v1.Node, pods []v
 		pvs[i] = &pv
 	}
 
-	var filterPlugins = []filterPlugin{
-		&interPodAffinityFilter{},
-		&interPodAntiAffinityFilter{},
-		&podTolerationFilter{},
-		&podNodeAffinityFilter{},
-		&podNodeSelectorFilter{},
-		&volumeNodeSelectorFilter{},
-	}
-
 	filteredNodes := nodes
-	for _, filterPlugin := range filterPlugins {
+	for _, filterPlugin := range newFilterPlugins(c.config.ControllerConfig.PlacementProfile) {
 		filterPlugin.setup(pods, pvs, c)
 		if updatedFilteredNodes, err := filterPlugin.filter(ctx, filteredNodes); err != nil {
 			w.Logger().Errorf(err, "failed to filter node with filter plugin (%s). Ignoring filtered results.", filterPlugin.name())
@@ -761,15 +816,7 @@ func (c *SharedState) prioritizeNodes(ctx context.Context, pods []v1.Pod, volume
 		nodeScores[node.Name] = 0
 	}
 
-	var nodeScorerPlugins = []nodeScorerPlugin{
-		&scoreByNodeCapacity{},
-		&scoreByReplicaCount{},
-		&scoreByInterPodAffinity{},
-		&scoreByInterPodAntiAffinity{},
-		&scoreByPodNodeAffinity{},
-	}
-
-	for _, nodeScorerPlugin := range nodeScorerPlugins {
+	for _, nodeScorerPlugin := range newNodeScorerPlugins(c.config.ControllerConfig.PlacementProfile) {
 		nodeScorerPlugin.setup(nodes, pods, volumes, c)
 		if updatedNodeScores, err := nodeScorerPlugin.score(ctx, nodeScores); err != nil {
 			w.Logger().Errorf(err, "failed to score nodes by node scorer (%s)", nodeScorerPlugin.name())
@@ -1021,6 +1068,11 @@ func (c *SharedState) createReplicaAzVolumeAttachment(ctx context.Context, volum
 	w.AnnotateObject(&azVolumeAttachment)
 	azureutils.AnnotateAPIVersion(&azVolumeAttachment)
 
+	if c.isDryRun() {
+		c.recordDryRunOperation(ctx, getNodeReference(node), "CreateReplicaAzVolumeAttachment", fmt.Sprintf("Would create replica AzVolumeAttachment %s for volume %s on node %s", replicaName, volumeName, node))
+		return nil
+	}
+
 	_, err = c.azClient.DiskV1beta2().AzVolumeAttachments(c.config.ObjectNamespace).Create(ctx, &azVolumeAttachment, metav1.CreateOptions{})
 	if err != nil {
 		err = status.Errorf(codes.Internal, "failed to create replica AzVolumeAttachment %s.", replicaName)
@@ -1137,6 +1189,11 @@ func (c *SharedState) cleanUpAzVolumeAttachments(ctx context.Context, attachment
 	var err error
 
 	for _, attachment := range attachments {
+		if c.isDryRun() {
+			c.recordDryRunCleanUp(ctx, &attachment, cleanUpMode)
+			continue
+		}
+
 		patched := attachment.DeepCopy()
 
 		if attachment.Spec.RequestedRole == azdiskv1beta2.PrimaryRole {
@@ -1465,6 +1522,11 @@ func (c *SharedState) createAzVolumeFromPv(ctx context.Context, pv v1.Persistent
 
 	w.AddDetailToLogger(consts.PvNameKey, pv.Name, consts.VolumeNameLabel, desiredAzVolume.Name)
 
+	// the AzVolume is created by the owner of the volume's shard
+	if !c.ownsInScope(ctx, desiredAzVolume.Name) {
+		return nil
+	}
+
 	if err = c.createAzVolume(ctx, desiredAzVolume); err != nil {
 		err = status.Errorf(codes.Internal, "failed to create AzVolume (%s) for PV (%s): %v", desiredAzVolume.Name, pv.Name, err)
 		return err
@@ -1542,6 +1604,10 @@ func (c *SharedState) createAzVolume(ctx context.Context, desiredAzVolume *azdis
 	azVolume, err = c.azClient.DiskV1beta2().AzVolumes(c.config.ObjectNamespace).Get(ctx, desiredAzVolume.Name, metav1.GetOptions{})
 	if err != nil {
 		if apiErrors.IsNotFound(err) {
+			if c.isDryRun() {
+				c.recordDryRunOperation(ctx, c.getPersistentVolumeReference(ctx, desiredAzVolume.Spec.PersistentVolume), "CreateAzVolume", fmt.Sprintf("Would create AzVolume %s for PersistentVolume %s", desiredAzVolume.Name, desiredAzVolume.Spec.PersistentVolume))
+				return nil
+			}
 			azVolume, err = c.azClient.DiskV1beta2().AzVolumes(c.config.ObjectNamespace).Create(ctx, desiredAzVolume, metav1.CreateOptions{})
 			if err != nil {
 				return err
@@ -1765,7 +1831,7 @@ func (c *SharedState) getNodesTopologySelector(ctx context.Context, nodes []v1.N
 // addNodeToAvailableAttachmentsMap returns true if the node is added to or already in the availableAttachmentsMap, and false otherwise.
 func (c *SharedState) addNodeToAvailableAttachmentsMap(ctx context.Context, nodeName string, nodeLables map[string]string) bool {
 	if _, ok := c.availableAttachmentsMap.Load(nodeName); !ok {
-		capacity, err := azureutils.GetNodeRemainingDiskCount(ctx, c.cachedClient, nodeName)
+		capacity, err := azureutils.GetNodeRemainingDiskCount(ctx, c.cachedClient, c.config.ObjectNamespace, nodeName)
 		if err != nil {
 			klog.Errorf("Failed to get node(%s) remaining disk count with error: %v", nodeName, err)
 			// store the maximum capacity if an entry for the node doesn't exist.
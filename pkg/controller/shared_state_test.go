@@ -28,6 +28,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	fakev1 "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/pointer"
 	azdiskv1beta2 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
 	azdiskfakes "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/client/clientset/versioned/fake"
 	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
@@ -437,6 +438,82 @@ func TestPrioritizeNodes(t *testing.T) {
 				require.Equal(t, nodes[2].Name, testNode2Name)
 			},
 		},
+		{
+			description: "[Success] Should prioritize nodes in zones without attachments of a zone-redundant volume",
+			nodes: []v1.Node{*convertToNode(withLabel(&testNode0, map[string]string{consts.WellKnownTopologyKey: "eastus-1", v1.LabelInstanceTypeStable: "BASIC_A3"})),
+				*convertToNode(withLabel(&testNode1, map[string]string{consts.WellKnownTopologyKey: "eastus-1", v1.LabelInstanceTypeStable: "BASIC_A3"})),
+				*convertToNode(withLabel(&testNode2, map[string]string{consts.WellKnownTopologyKey: "eastus-2", v1.LabelInstanceTypeStable: "BASIC_A3"}))},
+			volumes: []string{testPersistentVolume0Name},
+			pods:    []v1.Pod{testPod0},
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *SharedState {
+				zrsVolume := testAzVolume0.DeepCopy()
+				zrsVolume.Spec.Parameters = map[string]string{consts.SkuNameField: "Premium_ZRS"}
+				primary0Node0 := createTestAzVolumeAttachment(testPersistentVolume0Name, testNode0Name, azdiskv1beta2.PrimaryRole)
+				testSharedState := NewTestSharedState(
+					mockCtl,
+					testNamespace,
+					convertToNode(withLabel(&testNode0, map[string]string{consts.WellKnownTopologyKey: "eastus-1", v1.LabelInstanceTypeStable: "BASIC_A3"})),
+					convertToNode(withLabel(&testNode1, map[string]string{consts.WellKnownTopologyKey: "eastus-1", v1.LabelInstanceTypeStable: "BASIC_A3"})),
+					convertToNode(withLabel(&testNode2, map[string]string{consts.WellKnownTopologyKey: "eastus-2", v1.LabelInstanceTypeStable: "BASIC_A3"})),
+					zrsVolume,
+					&testPersistentVolume0,
+					&primary0Node0,
+				)
+
+				mockClients(testSharedState.cachedClient.(*mockclient.MockClient), testSharedState.azClient, testSharedState.kubeClient)
+				return testSharedState
+			},
+			verifyFunc: func(t *testing.T, nodes []v1.Node) {
+				require.Len(t, nodes, 2)
+				require.Equal(t, nodes[0].Name, testNode2Name)
+				require.Equal(t, nodes[1].Name, testNode1Name)
+			},
+		},
+		{
+			description: "[Success] Should prioritize nodes in fault domains without attachments of a shared volume",
+			nodes: []v1.Node{*convertToNode(withLabel(&testNode0, map[string]string{v1.LabelInstanceTypeStable: "BASIC_A3"})),
+				*convertToNode(withLabel(&testNode1, map[string]string{v1.LabelInstanceTypeStable: "BASIC_A3"})),
+				*convertToNode(withLabel(&testNode2, map[string]string{v1.LabelInstanceTypeStable: "BASIC_A3"}))},
+			volumes: []string{testPersistentVolume0Name},
+			pods:    []v1.Pod{testPod0},
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *SharedState {
+				sharedVolume := testAzVolume0.DeepCopy()
+				sharedVolume.Spec.Parameters = map[string]string{consts.MaxSharesField: "2"}
+				primary0Node0 := createTestAzVolumeAttachment(testPersistentVolume0Name, testNode0Name, azdiskv1beta2.PrimaryRole)
+				azDriverNodes := make([]runtime.Object, 3)
+				for i, nodeName := range []string{testNode0Name, testNode1Name, testNode2Name} {
+					faultDomain := "0"
+					if nodeName == testNode2Name {
+						faultDomain = "1"
+					}
+					azDriverNodes[i] = &azdiskv1beta2.AzDriverNode{
+						ObjectMeta: metav1.ObjectMeta{Name: nodeName, Namespace: testNamespace},
+						Spec:       azdiskv1beta2.AzDriverNodeSpec{NodeName: nodeName},
+						Status:     &azdiskv1beta2.AzDriverNodeStatus{FaultDomain: &faultDomain},
+					}
+				}
+				testSharedState := NewTestSharedState(
+					mockCtl,
+					testNamespace,
+					append(azDriverNodes,
+						convertToNode(withLabel(&testNode0, map[string]string{v1.LabelInstanceTypeStable: "BASIC_A3"})),
+						convertToNode(withLabel(&testNode1, map[string]string{v1.LabelInstanceTypeStable: "BASIC_A3"})),
+						convertToNode(withLabel(&testNode2, map[string]string{v1.LabelInstanceTypeStable: "BASIC_A3"})),
+						sharedVolume,
+						&testPersistentVolume0,
+						&primary0Node0,
+					)...,
+				)
+
+				mockClients(testSharedState.cachedClient.(*mockclient.MockClient), testSharedState.azClient, testSharedState.kubeClient)
+				return testSharedState
+			},
+			verifyFunc: func(t *testing.T, nodes []v1.Node) {
+				require.Len(t, nodes, 2)
+				require.Equal(t, nodes[0].Name, testNode2Name)
+				require.Equal(t, nodes[1].Name, testNode1Name)
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -894,6 +971,75 @@ func TestFilterNodes(t *testing.T) {
 				require.Equal(t, nodes[0].Name, testNode0.Name)
 			},
 		},
+		{
+			description: "[Success] Should only select nodes in zones without attachments of a zone-redundant volume when the topology spread filter is enabled",
+			nodes: []v1.Node{*convertToNode(withLabel(&testNode0, map[string]string{consts.WellKnownTopologyKey: "eastus-1"})),
+				*convertToNode(withLabel(&testNode1, map[string]string{consts.WellKnownTopologyKey: "eastus-1"})),
+				*convertToNode(withLabel(&testNode2, map[string]string{consts.WellKnownTopologyKey: "eastus-2"}))},
+			volumes: []string{testPersistentVolume0Name},
+			pods:    []v1.Pod{testPod0},
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *SharedState {
+				zrsVolume := testAzVolume0.DeepCopy()
+				zrsVolume.Spec.Parameters = map[string]string{consts.SkuNameField: "StandardSSD_ZRS"}
+				primary0Node0 := createTestAzVolumeAttachment(testPersistentVolume0Name, testNode0Name, azdiskv1beta2.PrimaryRole)
+				testSharedState := NewTestSharedState(
+					mockCtl,
+					testNamespace,
+					convertToNode(withLabel(&testNode0, map[string]string{consts.WellKnownTopologyKey: "eastus-1"})),
+					convertToNode(withLabel(&testNode1, map[string]string{consts.WellKnownTopologyKey: "eastus-1"})),
+					convertToNode(withLabel(&testNode2, map[string]string{consts.WellKnownTopologyKey: "eastus-2"})),
+					zrsVolume,
+					&testPersistentVolume0,
+					&primary0Node0,
+				)
+				testSharedState.config.ControllerConfig.PlacementProfile.Filters = []azdiskv1beta2.PlacementPluginConfiguration{
+					{Name: TopologySpreadFilterName, Enabled: pointer.Bool(true)},
+				}
+
+				mockClients(testSharedState.cachedClient.(*mockclient.MockClient), testSharedState.azClient, testSharedState.kubeClient)
+				return testSharedState
+			},
+			verifyFunc: func(t *testing.T, nodes []v1.Node, err error) {
+				require.NoError(t, err)
+				require.Len(t, nodes, 1)
+				require.Equal(t, nodes[0].Name, testNode2Name)
+			},
+		},
+		{
+			description: "[Success] Should not spread the attachments of a volume which has its maximum number of replicas",
+			nodes: []v1.Node{*convertToNode(withLabel(&testNode0, map[string]string{consts.WellKnownTopologyKey: "eastus-1"})),
+				*convertToNode(withLabel(&testNode1, map[string]string{consts.WellKnownTopologyKey: "eastus-1"})),
+				*convertToNode(withLabel(&testNode2, map[string]string{consts.WellKnownTopologyKey: "eastus-2"}))},
+			volumes: []string{testPersistentVolume0Name},
+			pods:    []v1.Pod{testPod0},
+			setupFunc: func(t *testing.T, mockCtl *gomock.Controller) *SharedState {
+				zrsVolume := testAzVolume0.DeepCopy()
+				zrsVolume.Spec.Parameters = map[string]string{consts.SkuNameField: "StandardSSD_ZRS"}
+				primary0Node0 := createTestAzVolumeAttachment(testPersistentVolume0Name, testNode0Name, azdiskv1beta2.PrimaryRole)
+				replica0Node2 := createTestAzVolumeAttachment(testPersistentVolume0Name, testNode2Name, azdiskv1beta2.ReplicaRole)
+				testSharedState := NewTestSharedState(
+					mockCtl,
+					testNamespace,
+					convertToNode(withLabel(&testNode0, map[string]string{consts.WellKnownTopologyKey: "eastus-1"})),
+					convertToNode(withLabel(&testNode1, map[string]string{consts.WellKnownTopologyKey: "eastus-1"})),
+					convertToNode(withLabel(&testNode2, map[string]string{consts.WellKnownTopologyKey: "eastus-2"})),
+					zrsVolume,
+					&testPersistentVolume0,
+					&primary0Node0,
+					&replica0Node2,
+				)
+				testSharedState.config.ControllerConfig.PlacementProfile.Filters = []azdiskv1beta2.PlacementPluginConfiguration{
+					{Name: TopologySpreadFilterName, Enabled: pointer.Bool(true)},
+				}
+
+				mockClients(testSharedState.cachedClient.(*mockclient.MockClient), testSharedState.azClient, testSharedState.kubeClient)
+				return testSharedState
+			},
+			verifyFunc: func(t *testing.T, nodes []v1.Node, err error) {
+				require.NoError(t, err)
+				require.Len(t, nodes, 3)
+			},
+		},
 	}
 
 	for _, test := range tests {
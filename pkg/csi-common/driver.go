@@ -32,6 +32,7 @@ type CSIDriver struct {
 	Cap               []*csi.ControllerServiceCapability
 	VC                []*csi.VolumeCapability_AccessMode
 	NSCap             []*csi.NodeServiceCapability
+	GCCap             []*csi.GroupControllerServiceCapability
 }
 
 // Creates a NewCSIDriver object. Assumes vendor version is equal to driver version &
@@ -94,6 +95,15 @@ func (d *CSIDriver) AddNodeServiceCapabilities(nl []csi.NodeServiceCapability_RP
 	d.NSCap = nsc
 }
 
+func (d *CSIDriver) AddGroupControllerServiceCapabilities(gl []csi.GroupControllerServiceCapability_RPC_Type) {
+	var gcsc []*csi.GroupControllerServiceCapability
+	for _, g := range gl {
+		klog.V(2).Infof("Enabling group controller service capability: %v", g.String())
+		gcsc = append(gcsc, NewGroupControllerServiceCapability(g))
+	}
+	d.GCCap = gcsc
+}
+
 func (d *CSIDriver) AddVolumeCapabilityAccessModes(vc []csi.VolumeCapability_AccessMode_Mode) []*csi.VolumeCapability_AccessMode {
 	var vca []*csi.VolumeCapability_AccessMode
 	for _, c := range vc {
@@ -99,6 +99,10 @@ func (s *nonBlockingGRPCServer) serve(endpoint string, ids csi.IdentityServer, c
 	}
 	if cs != nil {
 		csi.RegisterControllerServer(server, cs)
+		// the group controller service is served by controller servers which implement it
+		if gcs, ok := cs.(csi.GroupControllerServer); ok {
+			csi.RegisterGroupControllerServer(server, gcs)
+		}
 	}
 	if ns != nil {
 		csi.RegisterNodeServer(server, ns)
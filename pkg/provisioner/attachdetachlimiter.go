@@ -0,0 +1,111 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"context"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-03-01/compute"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"golang.org/x/time/rate"
+	azdiskv1beta2 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azureclients/vmclient"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azureclients/vmssvmclient"
+	"sigs.k8s.io/cloud-provider-azure/pkg/provider"
+	"sigs.k8s.io/cloud-provider-azure/pkg/retry"
+)
+
+// newAttachDetachLimiter returns the rate limiter throttling the VM updates which attach and detach disks.
+// The driver owns the limiter instead of the cloud provider so that its settings can be changed while the driver runs
+// without replacing the per-node batch processors of the cloud provider.
+func newAttachDetachLimiter(cloudConfig azdiskv1beta2.CloudConfiguration) *rate.Limiter {
+	limiter := rate.NewLimiter(rate.Inf, 0)
+	setAttachDetachLimits(limiter, cloudConfig)
+	return limiter
+}
+
+// setAttachDetachLimits applies the attach/detach rate limiter settings of the cloud configuration to the limiter.
+func setAttachDetachLimits(limiter *rate.Limiter, cloudConfig azdiskv1beta2.CloudConfiguration) {
+	if !cloudConfig.EnableAzureClientAttachDetachRateLimiter {
+		limiter.SetLimit(rate.Inf)
+		return
+	}
+	limiter.SetBurst(cloudConfig.AzureClientAttachDetachRateLimiterBucket)
+	limiter.SetLimit(rate.Limit(cloudConfig.AzureClientAttachDetachRateLimiterQPS))
+}
+
+// installAttachDetachLimiter wraps the virtual machine and scale set virtual machine clients of the cloud so that
+// each VM update waits on the limiter, whether or not it attaches or detaches disks. The cloud provider applies all
+// the disks attached to or detached from a node within a batch in a single VM update, so the limiter is waited on
+// once per batch.
+func installAttachDetachLimiter(cloud *provider.Cloud, limiter *rate.Limiter) {
+	if cloud.VirtualMachinesClient != nil {
+		cloud.VirtualMachinesClient = &rateLimitedVirtualMachinesClient{Interface: cloud.VirtualMachinesClient, limiter: limiter}
+	}
+	if cloud.VirtualMachineScaleSetVMsClient != nil {
+		cloud.VirtualMachineScaleSetVMsClient = &rateLimitedVirtualMachineScaleSetVMsClient{Interface: cloud.VirtualMachineScaleSetVMsClient, limiter: limiter}
+	}
+}
+
+func waitForLimiter(ctx context.Context, limiter *rate.Limiter) *retry.Error {
+	if err := limiter.Wait(ctx); err != nil {
+		return retry.NewError(false, err)
+	}
+	return nil
+}
+
+type rateLimitedVirtualMachinesClient struct {
+	vmclient.Interface
+	limiter *rate.Limiter
+}
+
+var _ vmclient.Interface = &rateLimitedVirtualMachinesClient{}
+
+func (c *rateLimitedVirtualMachinesClient) Update(ctx context.Context, resourceGroupName string, VMName string, parameters compute.VirtualMachineUpdate, source string) (*compute.VirtualMachine, *retry.Error) {
+	if rerr := waitForLimiter(ctx, c.limiter); rerr != nil {
+		return nil, rerr
+	}
+	return c.Interface.Update(ctx, resourceGroupName, VMName, parameters, source)
+}
+
+func (c *rateLimitedVirtualMachinesClient) UpdateAsync(ctx context.Context, resourceGroupName string, VMName string, parameters compute.VirtualMachineUpdate, source string) (*azure.Future, *retry.Error) {
+	if rerr := waitForLimiter(ctx, c.limiter); rerr != nil {
+		return nil, rerr
+	}
+	return c.Interface.UpdateAsync(ctx, resourceGroupName, VMName, parameters, source)
+}
+
+type rateLimitedVirtualMachineScaleSetVMsClient struct {
+	vmssvmclient.Interface
+	limiter *rate.Limiter
+}
+
+var _ vmssvmclient.Interface = &rateLimitedVirtualMachineScaleSetVMsClient{}
+
+func (c *rateLimitedVirtualMachineScaleSetVMsClient) Update(ctx context.Context, resourceGroupName string, VMScaleSetName string, instanceID string, parameters compute.VirtualMachineScaleSetVM, source string) (*compute.VirtualMachineScaleSetVM, *retry.Error) {
+	if rerr := waitForLimiter(ctx, c.limiter); rerr != nil {
+		return nil, rerr
+	}
+	return c.Interface.Update(ctx, resourceGroupName, VMScaleSetName, instanceID, parameters, source)
+}
+
+func (c *rateLimitedVirtualMachineScaleSetVMsClient) UpdateAsync(ctx context.Context, resourceGroupName string, VMScaleSetName string, instanceID string, parameters compute.VirtualMachineScaleSetVM, source string) (*azure.Future, *retry.Error) {
+	if rerr := waitForLimiter(ctx, c.limiter); rerr != nil {
+		return nil, rerr
+	}
+	return c.Interface.UpdateAsync(ctx, resourceGroupName, VMScaleSetName, instanceID, parameters, source)
+}
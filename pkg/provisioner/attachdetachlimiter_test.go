@@ -0,0 +1,65 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-03-01/compute"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+	azdiskv1beta2 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azureclients/vmclient/mockvmclient"
+)
+
+func TestAttachDetachLimiter(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	cloudProvisioner, err := NewFakeCloudProvisioner(mockCtl)
+	require.NoError(t, err)
+
+	// a single VM update is allowed before the limiter throttles the next one
+	cloudProvisioner.SetAttachDetachRateLimit(azdiskv1beta2.CloudConfiguration{
+		EnableAzureClientAttachDetachRateLimiter: true,
+		AzureClientAttachDetachRateLimiterQPS:    0.001,
+		AzureClientAttachDetachRateLimiterBucket: 1,
+	})
+	mockVMClient := mockvmclient.NewMockInterface(mockCtl)
+	cloudProvisioner.cloud.VirtualMachinesClient = mockVMClient
+	installAttachDetachLimiter(cloudProvisioner.cloud, cloudProvisioner.attachDetachLimiter)
+	vmClient := cloudProvisioner.cloud.VirtualMachinesClient
+
+	mockVMClient.EXPECT().UpdateAsync(gomock.Any(), testResourceGroup, testVMName, gomock.Any(), gomock.Any()).Return(nil, nil).Times(2)
+	_, rerr := vmClient.UpdateAsync(context.TODO(), testResourceGroup, testVMName, compute.VirtualMachineUpdate{}, "attach_disk")
+	assert.Nil(t, rerr)
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 100*time.Millisecond)
+	defer cancel()
+	_, rerr = vmClient.UpdateAsync(ctx, testResourceGroup, testVMName, compute.VirtualMachineUpdate{}, "attach_disk")
+	assert.NotNil(t, rerr)
+
+	// disabling the limiter applies to the clients already wrapped
+	cloudProvisioner.SetAttachDetachRateLimit(azdiskv1beta2.CloudConfiguration{EnableAzureClientAttachDetachRateLimiter: false})
+	assert.Equal(t, rate.Inf, cloudProvisioner.attachDetachLimiter.Limit())
+	_, rerr = vmClient.UpdateAsync(context.TODO(), testResourceGroup, testVMName, compute.VirtualMachineUpdate{}, "attach_disk")
+	assert.Nil(t, rerr)
+}
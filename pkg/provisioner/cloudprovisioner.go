@@ -19,23 +19,27 @@ package provisioner
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"path"
 	"reflect"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-03-01/compute"
+	"golang.org/x/time/rate"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	clientset "k8s.io/client-go/kubernetes"
 	cloudprovider "k8s.io/cloud-provider"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/pointer"
 	azdiskv1beta2 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
 	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
 	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils"
@@ -43,6 +47,7 @@ import (
 	volumehelper "sigs.k8s.io/azuredisk-csi-driver/pkg/util"
 	"sigs.k8s.io/azuredisk-csi-driver/pkg/workflow"
 	azcache "sigs.k8s.io/cloud-provider-azure/pkg/cache"
+	cloudproviderconsts "sigs.k8s.io/cloud-provider-azure/pkg/consts"
 	azure "sigs.k8s.io/cloud-provider-azure/pkg/provider"
 	"sigs.k8s.io/cloud-provider-azure/pkg/retry"
 )
@@ -81,6 +86,14 @@ type CloudProvisioner struct {
 	enableAsyncAttach       bool
 	// a timed cache GetDisk throttling
 	getDiskThrottlingCache *azcache.TimedCache
+	usageClient            azureutils.UsageClient
+	// a timed cache of the subscription usages used to compute capacity
+	diskUsageCache *azcache.TimedCache
+	// the rate limiter throttling the VM updates which attach and detach disks
+	attachDetachLimiter *rate.Limiter
+	// the identity of the cluster tagged on the disks it creates, looked up on first use
+	clusterID      string
+	clusterIDMutex sync.Mutex
 }
 
 // listVolumeStatus explains the return status of `listVolumesByResourceGroup`
@@ -101,10 +114,13 @@ func NewCloudProvisioner(
 	enableOnlineDiskResize bool,
 	enableAsyncAttach bool,
 ) (*CloudProvisioner, error) {
+	// the attach/detach rate limiter of the cloud provider cannot be changed once created, so it is replaced by one owned by the driver
+	cloudProviderConfig := cloudConfig
+	cloudProviderConfig.EnableAzureClientAttachDetachRateLimiter = false
 	azCloud, err := azureutils.GetCloudProviderFromClient(
 		ctx,
 		kubeClient,
-		cloudConfig,
+		cloudProviderConfig,
 		userAgent)
 	if err != nil || azCloud.TenantID == "" || azCloud.SubscriptionID == "" {
 		klog.Fatalf("failed to get Azure Cloud Provider, error: %v", err)
@@ -120,7 +136,12 @@ func NewCloudProvisioner(
 		klog.Fatalf("failed to create disk throttling cache: %v", err)
 	}
 
-	return &CloudProvisioner{
+	usageClient, err := azureutils.NewUsageClient(azCloud)
+	if err != nil {
+		klog.Warningf("failed to create usage client, GetCapacity will not be available: %v", err)
+	}
+
+	cloudProvisioner := &CloudProvisioner{
 		cloud:                   azCloud,
 		kubeClient:              kubeClient,
 		cloudConfiguration:      cloudConfig,
@@ -128,7 +149,19 @@ func NewCloudProvisioner(
 		perfOptimizationEnabled: perfOptimizationEnabled,
 		enableAsyncAttach:       enableAsyncAttach,
 		getDiskThrottlingCache:  cache,
-	}, nil
+		usageClient:             usageClient,
+		attachDetachLimiter:     newAttachDetachLimiter(cloudConfig),
+	}
+	installAttachDetachLimiter(azCloud, cloudProvisioner.attachDetachLimiter)
+
+	cloudProvisioner.diskUsageCache, err = azureutils.NewDiskUsageCache(azureconstants.DiskUsageCacheTTL, func() azureutils.UsageClient {
+		return cloudProvisioner.usageClient
+	})
+	if err != nil {
+		klog.Fatalf("failed to create disk usage cache: %v", err)
+	}
+
+	return cloudProvisioner, nil
 }
 
 func (c *CloudProvisioner) CreateVolume(
@@ -155,7 +188,7 @@ func (c *CloudProvisioner) CreateVolume(
 		return nil, err
 	}
 
-	localCloud := c.cloud
+	localCloud := c.GetCloud()
 	isAdvancedPerfProfile := strings.EqualFold(diskParams.PerfProfile, azureconstants.PerfProfileAdvanced)
 	// If perfProfile is set to advanced and no/invalid device settings are provided, fail the request
 	if c.GetPerfOptimizationEnabled() && isAdvancedPerfProfile {
@@ -170,7 +203,7 @@ func (c *CloudProvisioner) CreateVolume(
 	diskParams.DiskName = azureutils.CreateValidDiskName(diskParams.DiskName, true)
 
 	if diskParams.ResourceGroup == "" {
-		diskParams.ResourceGroup = c.cloud.ResourceGroup
+		diskParams.ResourceGroup = c.GetCloud().ResourceGroup
 	}
 
 	if diskParams.UserAgent != "" {
@@ -206,6 +239,10 @@ func (c *CloudProvisioner) CreateVolume(
 	}
 
 	selectedAvailabilityZone := pickAvailabilityZone(accessibilityRequirements, c.GetCloud().Location)
+	if diskParams.Location != "" && !strings.EqualFold(diskParams.Location, c.GetCloud().Location) {
+		// the zones of the cluster nodes do not exist in the region of a disk created in another location
+		selectedAvailabilityZone = ""
+	}
 	accessibleTopology := []azdiskv1beta2.Topology{}
 	if skuName == compute.StandardSSDZRS || skuName == compute.PremiumZRS {
 		w.Logger().V(2).Infof("diskZone(%s) is reset as empty since disk(%s) is ZRS(%s)", selectedAvailabilityZone, diskParams.DiskName, skuName)
@@ -213,7 +250,7 @@ func (c *CloudProvisioner) CreateVolume(
 		// make volume scheduled on all 3 availability zones
 		for i := 1; i <= 3; i++ {
 			topology := azdiskv1beta2.Topology{
-				Segments: map[string]string{topologyKeyStr: fmt.Sprintf("%s-%d", c.cloud.Location, i)},
+				Segments: map[string]string{topologyKeyStr: fmt.Sprintf("%s-%d", c.GetCloud().Location, i)},
 			}
 			accessibleTopology = append(accessibleTopology, topology)
 		}
@@ -253,6 +290,9 @@ func (c *CloudProvisioner) CreateVolume(
 	if strings.EqualFold(diskParams.WriteAcceleratorEnabled, azureconstants.TrueValue) {
 		diskParams.Tags[azure.WriteAcceleratorEnabled] = azureconstants.TrueValue
 	}
+	if clusterID := c.getClusterID(ctx); clusterID != "" {
+		diskParams.Tags[azureconstants.ClusterIDTag] = clusterID
+	}
 	sourceID := ""
 	sourceType := ""
 	contentSource := &azdiskv1beta2.ContentVolumeSource{}
@@ -269,6 +309,8 @@ func (c *CloudProvisioner) CreateVolume(
 				diskParams.VolumeContext[azureconstants.ResizeRequired] = strconv.FormatBool(true)
 			}
 			cancel()
+		} else if err = c.validateSnapshotSource(ctx, sourceID, diskParams.Location); err != nil {
+			return nil, err
 		}
 	}
 
@@ -299,6 +341,7 @@ func (c *CloudProvisioner) CreateVolume(
 		MaxShares:           int32(diskParams.MaxShares),
 		LogicalSectorSize:   int32(diskParams.LogicalSectorSize),
 		BurstingEnabled:     diskParams.EnableBursting,
+		Location:            diskParams.Location,
 	}
 
 	volumeOptions.SkipGetDiskOperation = c.isGetDiskThrottled()
@@ -332,6 +375,40 @@ func (c *CloudProvisioner) CreateVolume(
 	}, nil
 }
 
+// validateSnapshotSource checks that a disk can be created in the specified location from the snapshot with the
+// specified ID. A snapshot copied from another region can only be restored in the region it was copied to once the
+// copy has completed.
+func (c *CloudProvisioner) validateSnapshotSource(ctx context.Context, snapshotID, location string) error {
+	snapshotName, resourceGroup, err := azureutils.GetSnapshotAndResourceNameFromSnapshotID(snapshotID)
+	if err != nil {
+		// let the disk creation report the invalid source
+		return nil
+	}
+	subsID := azureutils.GetSubscriptionIDFromURI(snapshotID)
+	if subsID == "" {
+		subsID = c.GetCloud().SubscriptionID
+	}
+	if location == "" {
+		location = c.GetCloud().Location
+	}
+
+	snapshot, rerr := c.GetCloud().SnapshotsClient.Get(ctx, subsID, resourceGroup, snapshotName)
+	if rerr != nil {
+		if rerr.HTTPStatusCode == http.StatusNotFound {
+			return status.Errorf(codes.NotFound, "snapshot(%s) under rg(%s) not found", snapshotName, resourceGroup)
+		}
+		return status.Errorf(codes.Internal, "get snapshot %s from rg(%s) error: %v", snapshotName, resourceGroup, rerr.Error())
+	}
+
+	if !azureutils.IsSnapshotCopyComplete(&snapshot) {
+		return status.Errorf(codes.Unavailable, "copy of snapshot(%s) to location(%s) is %.0f%% complete", snapshotName, pointer.StringDeref(snapshot.Location, ""), *snapshot.CompletionPercent)
+	}
+	if snapshot.Location != nil && !strings.EqualFold(*snapshot.Location, location) {
+		return status.Errorf(codes.InvalidArgument, "snapshot(%s) in location(%s) cannot be restored to a disk in location(%s), copy it to that location with the %s parameter of the VolumeSnapshotClass first", snapshotName, *snapshot.Location, location, azureconstants.TargetLocationField)
+	}
+	return nil
+}
+
 func (c *CloudProvisioner) DeleteVolume(
 	ctx context.Context,
 	volumeID string,
@@ -345,7 +422,7 @@ func (c *CloudProvisioner) DeleteVolume(
 		return nil
 	}
 
-	err = c.cloud.DeleteManagedDisk(ctx, volumeID)
+	err = c.GetCloud().DeleteManagedDisk(ctx, volumeID)
 	return err
 }
 
@@ -354,15 +431,65 @@ func (c *CloudProvisioner) ListVolumes(
 	maxEntries int32,
 	startingToken string) (*azdiskv1beta2.ListVolumesResult, error) {
 	start, _ := strconv.Atoi(startingToken)
-	kubeClient := c.cloud.KubeClient
+	kubeClient := c.GetCloud().KubeClient
 	if kubeClient != nil && kubeClient.CoreV1() != nil && kubeClient.CoreV1().PersistentVolumes() != nil {
 		klog.V(6).Infof("List Volumes in Cluster:")
 		return c.listVolumesInCluster(ctx, start, int(maxEntries))
 	}
-	klog.V(6).Infof("List Volumes in Node Resource Group: %s", c.cloud.ResourceGroup)
+	klog.V(6).Infof("List Volumes in Node Resource Group: %s", c.GetCloud().ResourceGroup)
 	return c.listVolumesInNodeResourceGroup(ctx, start, int(maxEntries))
 }
 
+// GetVolume returns the details and condition of the managed disk with the specified volumeID.
+func (c *CloudProvisioner) GetVolume(
+	ctx context.Context,
+	volumeID string) (*azdiskv1beta2.VolumeEntry, error) {
+	return azureutils.GetDiskVolumeEntry(ctx, c.GetCloud(), volumeID)
+}
+
+// GetCapacity returns the capacity available for new disks with the specified parameters in the specified topology
+// and the size of the largest disk that can be created, both in bytes, based on the subscription disk quota.
+func (c *CloudProvisioner) GetCapacity(
+	ctx context.Context,
+	parameters map[string]string,
+	accessibleTopology *azdiskv1beta2.Topology) (int64, int64, error) {
+	var err error
+	_, w := workflow.New(ctx)
+	defer func() { w.Finish(err) }()
+
+	diskParams, err := azureutils.ParseDiskParameters(parameters, azureutils.IgnoreUnknown)
+	if err != nil {
+		err = status.Errorf(codes.InvalidArgument, "Failed parsing disk parameters: %v", err)
+		return 0, 0, err
+	}
+
+	skuName, err := azureutils.NormalizeStorageAccountType(diskParams.AccountType, c.GetCloud().Config.Cloud, c.GetCloud().Config.DisableAzureStackCloud)
+	if err != nil {
+		err = status.Error(codes.InvalidArgument, err.Error())
+		return 0, 0, err
+	}
+
+	location := diskParams.Location
+	if location == "" {
+		location = c.GetCloud().Location
+	}
+
+	var zone string
+	if accessibleTopology != nil {
+		zone = azureutils.GetZoneFromTopologySegments(accessibleTopology.Segments, topologyKeyStr)
+	}
+
+	availableCapacity, maximumVolumeSize, err := azureutils.GetDiskCapacity(c.diskUsageCache, skuName, location, zone, int64(diskParams.CapacityDiskSizeGiB))
+	if err != nil {
+		err = status.Errorf(codes.Unavailable, "failed to get disk usages in location(%s): %v", location, err)
+		return 0, 0, err
+	}
+
+	w.Logger().V(5).Infof("available capacity for sku(%s) in location(%s) zone(%s) is %d bytes, maximum volume size is %d bytes", skuName, location, zone, availableCapacity, maximumVolumeSize)
+
+	return availableCapacity, maximumVolumeSize, nil
+}
+
 // PublishVolume calls AttachDisk asynchronously and returns early lun assignment value and a channel for the async attach results.
 func (c *CloudProvisioner) PublishVolume(
 	ctx context.Context,
@@ -400,7 +527,7 @@ func (c *CloudProvisioner) PublishVolume(
 
 	var lun int32
 	var vmState *string
-	lun, vmState, err = c.cloud.GetDiskLun(diskName, volumeID, nodeName)
+	lun, vmState, err = c.GetCloud().GetDiskLun(diskName, volumeID, nodeName)
 	if err == cloudprovider.InstanceNotFound {
 		err = status.Errorf(codes.NotFound, "failed to get azure instance id for node %q: %v", nodeName, err)
 		return
@@ -411,7 +538,7 @@ func (c *CloudProvisioner) PublishVolume(
 	if err == nil {
 		if vmState != nil && strings.ToLower(*vmState) == "failed" {
 			w.Logger().Infof("VM(%q) is in failed state, update VM first", nodeName)
-			if err = c.cloud.UpdateVM(ctx, nodeName); err != nil {
+			if err = c.GetCloud().UpdateVM(ctx, nodeName); err != nil {
 				if _, ok := err.(*retry.PartialUpdateError); !ok {
 					err = status.Errorf(codes.Internal, "update instance %q failed with %v", nodeName, err)
 				}
@@ -445,7 +572,7 @@ func (c *CloudProvisioner) PublishVolume(
 			ctx, w := workflow.New(ctx)
 			defer func() { w.Finish(resultErr) }()
 
-			resultLun, resultErr = c.cloud.AttachDisk(ctx, asyncAttach, diskName, volumeID, nodeName, cachingMode, disk)
+			resultLun, resultErr = c.GetCloud().AttachDisk(ctx, asyncAttach, diskName, volumeID, nodeName, cachingMode, disk)
 			attachResult.ResultChannel() <- resultErr
 			close(attachResult.ResultChannel())
 			if resultErr != nil {
@@ -486,7 +613,7 @@ func (c *CloudProvisioner) UnpublishVolume(
 
 	w.Logger().V(2).Infof("Trying to detach volume %s from node %s", volumeID, nodeID)
 
-	if err = c.cloud.DetachDisk(ctx, diskName, volumeID, nodeName); err != nil {
+	if err = c.GetCloud().DetachDisk(ctx, diskName, volumeID, nodeName); err != nil {
 		if strings.Contains(err.Error(), azureconstants.ErrDiskNotFound) {
 			w.Logger().Infof("volume %s already detached from node %s", volumeID, nodeID)
 		} else {
@@ -499,6 +626,25 @@ func (c *CloudProvisioner) UnpublishVolume(
 	return nil
 }
 
+// GetNodeDataDisks returns the data disks attached to the VM of the specified node.
+func (c *CloudProvisioner) GetNodeDataDisks(ctx context.Context, nodeID string) ([]compute.DataDisk, error) {
+	var err error
+	_, w := workflow.New(ctx)
+	defer func() { w.Finish(err) }()
+
+	var dataDisks []compute.DataDisk
+	dataDisks, _, err = c.GetCloud().GetNodeDataDisks(types.NodeName(nodeID), azcache.CacheReadTypeDefault)
+	if err == cloudprovider.InstanceNotFound {
+		err = status.Errorf(codes.NotFound, "failed to get azure instance id for node %q: %v", nodeID, err)
+		return nil, err
+	} else if err != nil {
+		err = status.Errorf(codes.Internal, "failed to get data disks of node %q: %v", nodeID, err)
+		return nil, err
+	}
+
+	return dataDisks, nil
+}
+
 func (c *CloudProvisioner) ExpandVolume(
 	ctx context.Context,
 	volumeID string,
@@ -529,7 +675,7 @@ func (c *CloudProvisioner) ExpandVolume(
 		return nil, err
 	}
 
-	result, rerr := c.cloud.DisksClient.Get(ctx, c.cloud.SubscriptionID, resourceGroup, diskName)
+	result, rerr := c.GetCloud().DisksClient.Get(ctx, c.GetCloud().SubscriptionID, resourceGroup, diskName)
 	if rerr != nil {
 		err = status.Errorf(codes.Internal, "could not get the disk(%s) under rg(%s) with error(%v)", diskName, resourceGroup, rerr.Error())
 		return nil, err
@@ -543,7 +689,7 @@ func (c *CloudProvisioner) ExpandVolume(
 	w.Logger().V(2).Infof("begin to expand azure disk(%s) with new size(%d)", volumeID, requestSize.Value())
 
 	var newSize resource.Quantity
-	newSize, err = c.cloud.ResizeDisk(ctx, volumeID, oldSize, requestSize, c.enableOnlineDiskResize)
+	newSize, err = c.GetCloud().ResizeDisk(ctx, volumeID, oldSize, requestSize, c.enableOnlineDiskResize)
 	if err != nil {
 		err = status.Errorf(codes.Internal, "failed to resize disk(%s) with error(%v)", volumeID, err)
 		return nil, err
@@ -563,6 +709,106 @@ func (c *CloudProvisioner) ExpandVolume(
 	}, nil
 }
 
+// ModifyVolume changes the sku, provisioned performance or bursting of an existing disk to match the parameters.
+func (c *CloudProvisioner) ModifyVolume(
+	ctx context.Context,
+	volumeID string,
+	parameters map[string]string) error {
+	var err error
+	ctx, w := workflow.New(ctx)
+	defer func() { w.Finish(err) }()
+
+	if err = azureutils.IsValidDiskURI(volumeID); err != nil {
+		err = status.Errorf(codes.InvalidArgument, "disk URI(%s) is not valid: %v", volumeID, err)
+		return err
+	}
+
+	var diskName string
+	diskName, err = azureutils.GetDiskName(volumeID)
+	if err != nil {
+		err = status.Errorf(codes.Internal, "could not get disk name from diskURI(%s) with error(%v)", volumeID, err)
+		return err
+	}
+
+	var resourceGroup string
+	resourceGroup, err = azureutils.GetResourceGroupFromURI(volumeID)
+	if err != nil {
+		err = status.Errorf(codes.Internal, "could not get resource group from diskURI(%s) with error(%v)", volumeID, err)
+		return err
+	}
+
+	subsID := azureutils.GetSubscriptionIDFromURI(volumeID)
+	result, rerr := c.GetCloud().DisksClient.Get(ctx, subsID, resourceGroup, diskName)
+	if rerr != nil {
+		err = status.Errorf(codes.Internal, "could not get the disk(%s) under rg(%s) with error(%v)", diskName, resourceGroup, rerr.Error())
+		return err
+	}
+
+	var diskUpdate *compute.DiskUpdate
+	diskUpdate, err = getDiskUpdate(&result, parameters, c.GetCloud().Config.Cloud, c.GetCloud().Config.DisableAzureStackCloud)
+	if err != nil {
+		return err
+	}
+	if diskUpdate == nil {
+		w.Logger().V(2).Infof("azure disk(%s) already matches the parameters(%v)", volumeID, parameters)
+		return nil
+	}
+
+	w.Logger().V(2).Infof("begin to modify azure disk(%s) with parameters(%v)", volumeID, parameters)
+
+	if rerr := c.GetCloud().DisksClient.Update(ctx, subsID, resourceGroup, diskName, *diskUpdate); rerr != nil {
+		err = status.Errorf(codes.Internal, "failed to modify disk(%s) with error(%v)", volumeID, rerr.Error())
+		return err
+	}
+
+	w.Logger().V(2).Infof("modify azure disk(%s) successfully", volumeID)
+
+	return nil
+}
+
+// ListDisksByResourceGroup returns the managed disks in the specified resource group.
+func (c *CloudProvisioner) ListDisksByResourceGroup(ctx context.Context, resourceGroup string) ([]compute.Disk, error) {
+	disks, rerr := c.GetCloud().DisksClient.ListByResourceGroup(ctx, c.GetCloud().SubscriptionID, resourceGroup)
+	if rerr != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list disks in rg(%s) with error(%v)", resourceGroup, rerr.Error())
+	}
+	return disks, nil
+}
+
+// UpdateDiskTags replaces the tags of the specified managed disk.
+func (c *CloudProvisioner) UpdateDiskTags(ctx context.Context, volumeID string, tags map[string]*string) error {
+	var err error
+	ctx, w := workflow.New(ctx)
+	defer func() { w.Finish(err) }()
+
+	var diskName string
+	diskName, err = azureutils.GetDiskName(volumeID)
+	if err != nil {
+		err = status.Errorf(codes.InvalidArgument, "could not get disk name from diskURI(%s) with error(%v)", volumeID, err)
+		return err
+	}
+
+	var resourceGroup string
+	resourceGroup, err = azureutils.GetResourceGroupFromURI(volumeID)
+	if err != nil {
+		err = status.Errorf(codes.InvalidArgument, "could not get resource group from diskURI(%s) with error(%v)", volumeID, err)
+		return err
+	}
+
+	subsID := azureutils.GetSubscriptionIDFromURI(volumeID)
+	if subsID == "" {
+		subsID = c.GetCloud().SubscriptionID
+	}
+
+	if rerr := c.GetCloud().DisksClient.Update(ctx, subsID, resourceGroup, diskName, compute.DiskUpdate{Tags: tags}); rerr != nil {
+		err = status.Errorf(codes.Internal, "failed to update tags of disk(%s) with error(%v)", volumeID, rerr.Error())
+		return err
+	}
+	w.Logger().V(2).Infof("update tags of azure disk(%s) successfully", volumeID)
+
+	return nil
+}
+
 func (c *CloudProvisioner) CreateSnapshot(
 	ctx context.Context,
 	sourceVolumeID string,
@@ -574,10 +820,10 @@ func (c *CloudProvisioner) CreateSnapshot(
 	var customTags string
 	// set incremental snapshot as true by default
 	incremental := true
-	var resourceGroup, subsID, dataAccessAuthMode string
+	var resourceGroup, subsID, dataAccessAuthMode, targetLocation string
 	var err error
-	localCloud := c.cloud
-	location := c.cloud.Location
+	localCloud := c.GetCloud()
+	location := c.GetCloud().Location
 
 	for k, v := range parameters {
 		switch strings.ToLower(k) {
@@ -595,6 +841,8 @@ func (c *CloudProvisioner) CreateSnapshot(
 			dataAccessAuthMode = v
 		case azureconstants.LocationField:
 			location = v
+		case azureconstants.TargetLocationField:
+			targetLocation = v
 		case azureconstants.UserAgentField:
 			newUserAgent := v
 			localCloud, err = azureutils.GetCloudProviderFromClient(
@@ -615,6 +863,13 @@ func (c *CloudProvisioner) CreateSnapshot(
 		incremental = false
 	}
 
+	if strings.EqualFold(targetLocation, location) {
+		targetLocation = ""
+	}
+	if targetLocation != "" && !incremental {
+		return nil, status.Errorf(codes.InvalidArgument, "only incremental snapshots can be copied to location(%s)", targetLocation)
+	}
+
 	if resourceGroup == "" {
 		resourceGroup, err = azureutils.GetResourceGroupFromURI(sourceVolumeID)
 		if err != nil {
@@ -653,18 +908,38 @@ func (c *CloudProvisioner) CreateSnapshot(
 		snapshot.SnapshotProperties.DataAccessAuthMode = compute.DataAccessAuthMode(dataAccessAuthMode)
 	}
 
-	klog.V(2).Infof("begin to create snapshot(%s, incremental: %v) under rg(%s)", snapshotName, incremental, resourceGroup)
+	// A snapshot copied to another region is taken in the region of the source disk first. The local snapshot is kept
+	// as the base of later incremental copies and is deleted together with the copy.
+	localSnapshotName := snapshotName
+	if targetLocation != "" {
+		localSnapshotName = azureutils.GetCrossRegionSourceSnapshotName(snapshotName, location)
+	}
 
-	rerr := localCloud.SnapshotsClient.CreateOrUpdate(ctx, subsID, resourceGroup, snapshotName, snapshot)
-	if rerr != nil {
-		if strings.Contains(rerr.Error().Error(), "existing disk") {
-			return nil, status.Error(codes.AlreadyExists, fmt.Sprintf("request snapshot(%s) under rg(%s) already exists, but the SourceVolumeId is different, error details: %v", snapshotName, resourceGroup, rerr.Error()))
+	klog.V(2).Infof("begin to create snapshot(%s, incremental: %v) under rg(%s)", localSnapshotName, incremental, resourceGroup)
+	if err := createOrUpdateSnapshot(ctx, localCloud, subsID, resourceGroup, localSnapshotName, snapshot); err != nil {
+		return nil, err
+	}
+	klog.V(2).Infof("create snapshot(%s) under rg(%s) successfully", localSnapshotName, resourceGroup)
+
+	if targetLocation != "" {
+		// The copy completes in the background and the snapshot is not ready to use until it does.
+		sourceSubsID := subsID
+		if sourceSubsID == "" {
+			sourceSubsID = localCloud.SubscriptionID
+		}
+		sourceSnapshotID := fmt.Sprintf(azureconstants.DiskSnapshotPath, sourceSubsID, resourceGroup, localSnapshotName)
+		snapshot.Location = &targetLocation
+		snapshot.SnapshotProperties.CreationData = &compute.CreationData{
+			CreateOption:     compute.CopyStart,
+			SourceResourceID: &sourceSnapshotID,
 		}
 
-		azureutils.SleepIfThrottled(rerr.Error(), azureconstants.SnapshotOpThrottlingSleepSec)
-		return nil, status.Error(codes.Internal, fmt.Sprintf("create snapshot error: %v", rerr.Error()))
+		klog.V(2).Infof("begin to copy snapshot(%s) to snapshot(%s) in location(%s) under rg(%s)", localSnapshotName, snapshotName, targetLocation, resourceGroup)
+		if err := createOrUpdateSnapshot(ctx, localCloud, subsID, resourceGroup, snapshotName, snapshot); err != nil {
+			return nil, err
+		}
+		klog.V(2).Infof("started copy of snapshot(%s) to location(%s) under rg(%s)", snapshotName, targetLocation, resourceGroup)
 	}
-	klog.V(2).Infof("create snapshot(%s) under rg(%s) successfully", snapshotName, resourceGroup)
 
 	snapshotObj, err := c.getSnapshotByID(ctx, resourceGroup, snapshotName, sourceVolumeID)
 	if err != nil {
@@ -674,6 +949,19 @@ func (c *CloudProvisioner) CreateSnapshot(
 	return snapshotObj, nil
 }
 
+func createOrUpdateSnapshot(ctx context.Context, cloud *azure.Cloud, subsID, resourceGroup, snapshotName string, snapshot compute.Snapshot) error {
+	rerr := cloud.SnapshotsClient.CreateOrUpdate(ctx, subsID, resourceGroup, snapshotName, snapshot)
+	if rerr != nil {
+		if strings.Contains(rerr.Error().Error(), "existing disk") {
+			return status.Error(codes.AlreadyExists, fmt.Sprintf("request snapshot(%s) under rg(%s) already exists, but the SourceVolumeId is different, error details: %v", snapshotName, resourceGroup, rerr.Error()))
+		}
+
+		azureutils.SleepIfThrottled(rerr.Error(), azureconstants.SnapshotOpThrottlingSleepSec)
+		return status.Error(codes.Internal, fmt.Sprintf("create snapshot error: %v", rerr.Error()))
+	}
+	return nil
+}
+
 func (c *CloudProvisioner) ListSnapshots(
 	ctx context.Context,
 	maxEntries int32,
@@ -683,7 +971,7 @@ func (c *CloudProvisioner) ListSnapshots(
 	secrets map[string]string) (*azdiskv1beta2.ListSnapshotsResult, error) {
 	// SnapshotID is not empty, return snapshot that match the snapshot id.
 	if len(snapshotID) != 0 {
-		snapshot, err := c.getSnapshotByID(ctx, c.cloud.ResourceGroup, snapshotID, sourceVolumeID)
+		snapshot, err := c.getSnapshotByID(ctx, c.GetCloud().ResourceGroup, snapshotID, sourceVolumeID)
 		if err != nil {
 			if strings.Contains(err.Error(), azureconstants.ResourceNotFound) {
 				return &azdiskv1beta2.ListSnapshotsResult{}, nil
@@ -699,7 +987,7 @@ func (c *CloudProvisioner) ListSnapshots(
 	}
 
 	// no SnapshotID is set, return all snapshots that satisfy the request.
-	snapshots, rerr := c.cloud.SnapshotsClient.ListByResourceGroup(ctx, c.cloud.SubscriptionID, c.cloud.ResourceGroup)
+	snapshots, rerr := c.GetCloud().SnapshotsClient.ListByResourceGroup(ctx, c.GetCloud().SubscriptionID, c.GetCloud().ResourceGroup)
 	if rerr != nil {
 		return nil, status.Error(codes.Internal, fmt.Sprintf("Unknown list snapshot error: %v", rerr.Error()))
 	}
@@ -764,13 +1052,28 @@ func (c *CloudProvisioner) DeleteSnapshot(
 		return err
 	}
 
+	subsID := azureutils.GetSubscriptionIDFromURI(snapshotID)
 	if snapshotName == "" && resourceGroup == "" {
 		snapshotName = snapshotID
-		resourceGroup = c.cloud.ResourceGroup
+		resourceGroup = c.GetCloud().ResourceGroup
+		subsID = c.GetCloud().SubscriptionID
+	}
+
+	// A snapshot copied from another region is deleted together with the snapshot it was copied from. The source
+	// snapshot is deleted first, so that it can still be found through the copy if its deletion fails.
+	if snapshot, rerr := c.GetCloud().SnapshotsClient.Get(ctx, subsID, resourceGroup, snapshotName); rerr == nil {
+		if sourceSnapshotName, sourceResourceGroup, sourceSubsID := getCrossRegionSourceSnapshot(snapshotName, &snapshot); sourceSnapshotName != "" {
+			klog.V(2).Infof("begin to delete source snapshot(%s) of snapshot(%s) under rg(%s)", sourceSnapshotName, snapshotName, sourceResourceGroup)
+			rerr := c.GetCloud().SnapshotsClient.Delete(ctx, sourceSubsID, sourceResourceGroup, sourceSnapshotName)
+			if rerr != nil && rerr.HTTPStatusCode != http.StatusNotFound {
+				return status.Error(codes.Internal, fmt.Sprintf("delete source snapshot(%s) error: %v", sourceSnapshotName, rerr.Error()))
+			}
+			klog.V(2).Infof("delete source snapshot(%s) under rg(%s) successfully", sourceSnapshotName, sourceResourceGroup)
+		}
 	}
 
 	klog.V(2).Infof("begin to delete snapshot(%s) under rg(%s)", snapshotName, resourceGroup)
-	rerr := c.cloud.SnapshotsClient.Delete(ctx, c.cloud.SubscriptionID, resourceGroup, snapshotName)
+	rerr := c.GetCloud().SnapshotsClient.Delete(ctx, subsID, resourceGroup, snapshotName)
 	if rerr != nil {
 		return status.Error(codes.Internal, fmt.Sprintf("delete snapshot error: %v", rerr.Error()))
 	}
@@ -779,6 +1082,218 @@ func (c *CloudProvisioner) DeleteSnapshot(
 	return nil
 }
 
+// getCrossRegionSourceSnapshot returns the name, resource group and subscription of the snapshot created by CreateSnapshot
+// in the region of the source disk if the specified snapshot was copied from it, or empty strings otherwise.
+func getCrossRegionSourceSnapshot(snapshotName string, snapshot *compute.Snapshot) (string, string, string) {
+	if snapshot.SnapshotProperties == nil || snapshot.CreationData == nil || snapshot.CreationData.CreateOption != compute.CopyStart || snapshot.CreationData.SourceResourceID == nil {
+		return "", "", ""
+	}
+
+	sourceSnapshotID := *snapshot.CreationData.SourceResourceID
+	sourceSnapshotName, sourceResourceGroup, err := azureutils.GetSnapshotAndResourceNameFromSnapshotID(sourceSnapshotID)
+	if err != nil {
+		return "", "", ""
+	}
+	// leave snapshots that were not created for this copy alone
+	if !azureutils.IsCrossRegionSourceSnapshotName(snapshotName, sourceSnapshotName) {
+		return "", "", ""
+	}
+	return sourceSnapshotName, sourceResourceGroup, azureutils.GetSubscriptionIDFromURI(sourceSnapshotID)
+}
+
+// CreateGroupSnapshot takes incremental snapshots of the specified volumes concurrently so that they capture the volumes
+// as close to the same point in time as possible. The snapshots are tagged with the name of the group snapshot, and if
+// any of them cannot be taken, the snapshots already taken are deleted.
+func (c *CloudProvisioner) CreateGroupSnapshot(
+	ctx context.Context,
+	groupSnapshotName string,
+	sourceVolumeIDs []string,
+	secrets map[string]string,
+	parameters map[string]string) (*azdiskv1beta2.GroupSnapshot, error) {
+	if len(sourceVolumeIDs) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "source volume IDs of a group snapshot must be provided")
+	}
+	groupSnapshotName = azureutils.CreateValidDiskName(groupSnapshotName, true)
+
+	var customTags, resourceGroup, subsID string
+	snapshotParameters := make(map[string]string, len(parameters)+1)
+	for k, v := range parameters {
+		switch strings.ToLower(k) {
+		case azureconstants.TagsField:
+			customTags = v
+			continue
+		case azureconstants.ResourceGroupField:
+			resourceGroup = v
+		case azureconstants.SubscriptionIDField:
+			subsID = v
+		case azureconstants.TargetLocationField:
+			return nil, status.Errorf(codes.InvalidArgument, "AzureDisk - option %s is not supported for group snapshots", k)
+		case azureconstants.FreezeFilesystemsField:
+			// the filesystems of the volumes are frozen by the caller before the snapshots are taken
+			continue
+		}
+		snapshotParameters[k] = v
+	}
+
+	// all the snapshots of a group are stored in the same resource group so that they can be found by their tag
+	if resourceGroup == "" {
+		var err error
+		resourceGroup, err = azureutils.GetResourceGroupFromURI(sourceVolumeIDs[0])
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "could not get resource group from diskURI(%s) with error(%v)", sourceVolumeIDs[0], err)
+		}
+		snapshotParameters[azureconstants.ResourceGroupField] = resourceGroup
+	}
+	if subsID == "" {
+		subsID = azureutils.GetSubscriptionIDFromURI(sourceVolumeIDs[0])
+	}
+	if subsID == "" {
+		subsID = c.GetCloud().SubscriptionID
+	}
+	if customTags != "" {
+		customTags += volumehelper.TagsDelimiter
+	}
+	snapshotParameters[azureconstants.TagsField] = customTags + azureconstants.GroupSnapshotNameTag + volumehelper.TagKeyValueDelimiter + groupSnapshotName
+
+	snapshotNames := make([]string, len(sourceVolumeIDs))
+	snapshotNameSet := map[string]bool{}
+	for i, sourceVolumeID := range sourceVolumeIDs {
+		diskName, err := azureutils.GetDiskName(sourceVolumeID)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		snapshotNames[i] = azureutils.CreateValidDiskName(fmt.Sprintf("%s-%s", groupSnapshotName, diskName), true)
+		if snapshotNameSet[snapshotNames[i]] {
+			return nil, status.Errorf(codes.InvalidArgument, "source volume(%s) is specified more than once in group snapshot(%s)", sourceVolumeID, groupSnapshotName)
+		}
+		snapshotNameSet[snapshotNames[i]] = true
+	}
+
+	klog.V(2).Infof("begin to create group snapshot(%s) of %d volumes under rg(%s)", groupSnapshotName, len(sourceVolumeIDs), resourceGroup)
+
+	snapshots := make([]*azdiskv1beta2.Snapshot, len(sourceVolumeIDs))
+	errs := make([]error, len(sourceVolumeIDs))
+	var wg sync.WaitGroup
+	for i := range sourceVolumeIDs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			snapshots[i], errs[i] = c.CreateSnapshot(ctx, sourceVolumeIDs[i], snapshotNames[i], secrets, snapshotParameters)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+
+		klog.Errorf("failed to create snapshot(%s) of volume(%s) for group snapshot(%s), deleting the snapshots of the group: %v", snapshotNames[i], sourceVolumeIDs[i], groupSnapshotName, err)
+		for j, snapshotName := range snapshotNames {
+			// a snapshot with the same name taken from another disk does not belong to the group
+			if status.Code(errs[j]) == codes.AlreadyExists {
+				continue
+			}
+			if rerr := c.GetCloud().SnapshotsClient.Delete(ctx, subsID, resourceGroup, snapshotName); rerr != nil {
+				klog.Warningf("failed to delete snapshot(%s) of failed group snapshot(%s) under rg(%s): %v", snapshotName, groupSnapshotName, resourceGroup, rerr.Error())
+			}
+		}
+		return nil, err
+	}
+	klog.V(2).Infof("create group snapshot(%s) under rg(%s) successfully", groupSnapshotName, resourceGroup)
+
+	entries := make([]azdiskv1beta2.Snapshot, len(snapshots))
+	for i := range snapshots {
+		entries[i] = *snapshots[i]
+	}
+	return newGroupSnapshot(azureutils.GetGroupSnapshotID(subsID, resourceGroup, groupSnapshotName), entries), nil
+}
+
+// GetGroupSnapshot returns the snapshots of the group snapshot with the specified ID.
+func (c *CloudProvisioner) GetGroupSnapshot(
+	ctx context.Context,
+	groupSnapshotID string,
+	secrets map[string]string) (*azdiskv1beta2.GroupSnapshot, error) {
+	snapshots, err := c.listGroupSnapshotMembers(ctx, groupSnapshotID)
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) == 0 {
+		return nil, status.Errorf(codes.NotFound, "group snapshot(%s) not found", groupSnapshotID)
+	}
+
+	entries := make([]azdiskv1beta2.Snapshot, 0, len(snapshots))
+	for i := range snapshots {
+		snapshot, err := azureutils.NewAzureDiskSnapshot("", &snapshots[i])
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to get snapshot(%s) of group snapshot(%s): %v", pointer.StringDeref(snapshots[i].Name, ""), groupSnapshotID, err)
+		}
+		entries = append(entries, *snapshot)
+	}
+	return newGroupSnapshot(groupSnapshotID, entries), nil
+}
+
+// DeleteGroupSnapshot deletes the snapshots of the group snapshot with the specified ID.
+func (c *CloudProvisioner) DeleteGroupSnapshot(
+	ctx context.Context,
+	groupSnapshotID string,
+	secrets map[string]string) error {
+	snapshots, err := c.listGroupSnapshotMembers(ctx, groupSnapshotID)
+	if err != nil {
+		return err
+	}
+
+	klog.V(2).Infof("begin to delete %d snapshots of group snapshot(%s)", len(snapshots), groupSnapshotID)
+	for i := range snapshots {
+		if err := c.DeleteSnapshot(ctx, pointer.StringDeref(snapshots[i].ID, ""), secrets); err != nil {
+			return err
+		}
+	}
+	klog.V(2).Infof("delete group snapshot(%s) successfully", groupSnapshotID)
+
+	return nil
+}
+
+func (c *CloudProvisioner) listGroupSnapshotMembers(ctx context.Context, groupSnapshotID string) ([]compute.Snapshot, error) {
+	subsID, resourceGroup, groupSnapshotName, err := azureutils.ParseGroupSnapshotID(groupSnapshotID)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	snapshots, rerr := c.GetCloud().SnapshotsClient.ListByResourceGroup(ctx, subsID, resourceGroup)
+	if rerr != nil {
+		return nil, status.Errorf(codes.Internal, "list snapshots under rg(%s) error: %v", resourceGroup, rerr.Error())
+	}
+
+	members := []compute.Snapshot{}
+	for _, snapshot := range snapshots {
+		if value, ok := snapshot.Tags[azureconstants.GroupSnapshotNameTag]; ok && value != nil && strings.EqualFold(*value, groupSnapshotName) {
+			members = append(members, snapshot)
+		}
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return pointer.StringDeref(members[i].ID, "") < pointer.StringDeref(members[j].ID, "")
+	})
+	return members, nil
+}
+
+// newGroupSnapshot returns a group snapshot which is ready to use once all of its snapshots are, and whose creation
+// time is that of its last snapshot.
+func newGroupSnapshot(groupSnapshotID string, snapshots []azdiskv1beta2.Snapshot) *azdiskv1beta2.GroupSnapshot {
+	groupSnapshot := &azdiskv1beta2.GroupSnapshot{
+		GroupSnapshotID: groupSnapshotID,
+		Snapshots:       snapshots,
+		ReadyToUse:      true,
+	}
+	for _, snapshot := range snapshots {
+		groupSnapshot.ReadyToUse = groupSnapshot.ReadyToUse && snapshot.ReadyToUse
+		if groupSnapshot.CreationTime.Before(&snapshot.CreationTime) {
+			groupSnapshot.CreationTime = snapshot.CreationTime
+		}
+	}
+	return groupSnapshot
+}
+
 func (c *CloudProvisioner) CheckDiskExists(ctx context.Context, diskURI string) (*compute.Disk, error) {
 	diskName, err := azureutils.GetDiskName(diskURI)
 	if err != nil {
@@ -795,7 +1310,7 @@ func (c *CloudProvisioner) CheckDiskExists(ctx context.Context, diskURI string)
 		return nil, nil
 	}
 
-	disk, rerr := c.cloud.DisksClient.Get(ctx, c.cloud.SubscriptionID, resourceGroup, diskName)
+	disk, rerr := c.GetCloud().DisksClient.Get(ctx, c.GetCloud().SubscriptionID, resourceGroup, diskName)
 	if rerr != nil {
 		if rerr.IsThrottled() || strings.Contains(rerr.RawError.Error(), azureconstants.RateLimited) {
 			klog.Warningf("checkDiskExists(%s) is throttled with error: %v", diskURI, rerr.Error())
@@ -808,6 +1323,32 @@ func (c *CloudProvisioner) CheckDiskExists(ctx context.Context, diskURI string)
 	return &disk, nil
 }
 
+// SetAttachDetachRateLimit applies the attach/detach rate limiter settings of the cloud configuration.
+// The VM updates already waiting on the limiter are throttled with the new settings.
+func (c *CloudProvisioner) SetAttachDetachRateLimit(cloudConfig azdiskv1beta2.CloudConfiguration) {
+	setAttachDetachLimits(c.attachDetachLimiter, cloudConfig)
+	klog.V(2).Infof("attach/detach disk operation rate limiter configuration - Enabled: %t QPS: %f, Bucket: %d",
+		cloudConfig.EnableAzureClientAttachDetachRateLimiter,
+		cloudConfig.AzureClientAttachDetachRateLimiterQPS,
+		cloudConfig.AzureClientAttachDetachRateLimiterBucket)
+}
+
+// getClusterID returns the identity of the cluster, or an empty string if it cannot be looked up.
+func (c *CloudProvisioner) getClusterID(ctx context.Context) string {
+	c.clusterIDMutex.Lock()
+	defer c.clusterIDMutex.Unlock()
+
+	if c.clusterID == "" && c.kubeClient != nil {
+		clusterID, err := azureutils.GetClusterID(ctx, c.kubeClient)
+		if err != nil {
+			klog.Warningf("failed to get the cluster identity, disks will not be tagged with it: %v", err)
+			return ""
+		}
+		c.clusterID = clusterID
+	}
+	return c.clusterID
+}
+
 func (c *CloudProvisioner) GetCloud() *azure.Cloud {
 	return c.cloud
 }
@@ -821,7 +1362,7 @@ func (c *CloudProvisioner) GetSourceDiskSize(ctx context.Context, resourceGroup,
 	if curDepth > maxDepth {
 		return nil, status.Error(codes.Internal, fmt.Sprintf("current depth (%d) surpassed the max depth (%d) while searching for the source disk size", curDepth, maxDepth))
 	}
-	result, rerr := c.cloud.DisksClient.Get(ctx, c.cloud.SubscriptionID, resourceGroup, diskName)
+	result, rerr := c.GetCloud().DisksClient.Get(ctx, c.GetCloud().SubscriptionID, resourceGroup, diskName)
 	if rerr != nil {
 		return nil, rerr.Error()
 	}
@@ -849,7 +1390,7 @@ func (c *CloudProvisioner) CheckDiskCapacity(ctx context.Context, resourceGroup,
 		return true, nil
 	}
 
-	disk, rerr := c.cloud.DisksClient.Get(ctx, c.cloud.SubscriptionID, resourceGroup, diskName)
+	disk, rerr := c.GetCloud().DisksClient.Get(ctx, c.GetCloud().SubscriptionID, resourceGroup, diskName)
 	// Because we can not judge the reason of the error. Maybe the disk does not exist.
 	// So here we do not handle the error.
 	if rerr == nil {
@@ -890,7 +1431,7 @@ func (c *CloudProvisioner) getSnapshotByID(ctx context.Context, resourceGroup st
 		resourceGroupName = resourceGroup
 	}
 
-	snapshot, rerr := c.cloud.SnapshotsClient.Get(ctx, c.cloud.SubscriptionID, resourceGroupName, snapshotNameVal)
+	snapshot, rerr := c.GetCloud().SnapshotsClient.Get(ctx, c.GetCloud().SubscriptionID, resourceGroupName, snapshotNameVal)
 	if rerr != nil {
 		return nil, status.Error(codes.Internal, fmt.Sprintf("get snapshot %s from rg(%s) error: %v", snapshotNameVal, resourceGroupName, rerr.Error()))
 	}
@@ -925,7 +1466,7 @@ func (c *CloudProvisioner) validateCreateVolumeRequestParams(
 		}
 	}
 
-	if azureutils.IsAzureStackCloud(c.cloud.Config.Cloud, c.cloud.Config.DisableAzureStackCloud) {
+	if azureutils.IsAzureStackCloud(c.GetCloud().Config.Cloud, c.GetCloud().Config.DisableAzureStackCloud) {
 		if diskParams.MaxShares > 1 {
 			return status.Error(codes.InvalidArgument, fmt.Sprintf("Invalid maxShares value: %d as Azure Stack does not support shared disk.", diskParams.MaxShares))
 		}
@@ -936,7 +1477,7 @@ func (c *CloudProvisioner) validateCreateVolumeRequestParams(
 
 // listVolumesInCluster is a helper function for ListVolumes used for when there is an available kubeclient
 func (c *CloudProvisioner) listVolumesInCluster(ctx context.Context, start, maxEntries int) (*azdiskv1beta2.ListVolumesResult, error) {
-	kubeClient := c.cloud.KubeClient
+	kubeClient := c.GetCloud().KubeClient
 	pvList, err := kubeClient.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "ListVolumes failed while fetching PersistentVolumes List with error: %v", err.Error())
@@ -1020,7 +1561,7 @@ func (c *CloudProvisioner) listVolumesInCluster(ctx context.Context, start, maxE
 // listVolumesInNodeResourceGroup is a helper function for ListVolumes used for when there is no available kubeclient
 func (c *CloudProvisioner) listVolumesInNodeResourceGroup(ctx context.Context, start, maxEntries int) (*azdiskv1beta2.ListVolumesResult, error) {
 	entries := []azdiskv1beta2.VolumeEntry{}
-	listStatus := c.listVolumesByResourceGroup(ctx, c.cloud.ResourceGroup, entries, start, maxEntries, nil)
+	listStatus := c.listVolumesByResourceGroup(ctx, c.GetCloud().ResourceGroup, entries, start, maxEntries, nil)
 	if listStatus.err != nil {
 		return nil, listStatus.err
 	}
@@ -1040,7 +1581,7 @@ func (c *CloudProvisioner) listVolumesInNodeResourceGroup(ctx context.Context, s
 
 // listVolumesByResourceGroup is a helper function that updates the ListVolumeResponse_Entry slice and returns number of total visited volumes, number of volumes that needs to be visited and an error if found
 func (c *CloudProvisioner) listVolumesByResourceGroup(ctx context.Context, resourceGroup string, entries []azdiskv1beta2.VolumeEntry, start, maxEntries int, volSet map[string]bool) listVolumeStatus {
-	disks, derr := c.cloud.DisksClient.ListByResourceGroup(ctx, c.cloud.SubscriptionID, resourceGroup)
+	disks, derr := c.GetCloud().DisksClient.ListByResourceGroup(ctx, c.GetCloud().SubscriptionID, resourceGroup)
 	if derr != nil {
 		return listVolumeStatus{err: status.Errorf(codes.Internal, "ListVolumes on rg(%s) failed with error: %v", resourceGroup, derr.Error())}
 	}
@@ -1055,7 +1596,7 @@ func (c *CloudProvisioner) listVolumesByResourceGroup(ctx context.Context, resou
 	if start > 0 && start >= len(disks) {
 		return listVolumeStatus{
 			numVisited: len(disks),
-			err:        status.Errorf(codes.FailedPrecondition, "ListVolumes starting token(%d) on rg(%s) is greater than total number of volumes", start, c.cloud.ResourceGroup),
+			err:        status.Errorf(codes.FailedPrecondition, "ListVolumes starting token(%d) on rg(%s) is greater than total number of volumes", start, c.GetCloud().ResourceGroup),
 		}
 	}
 	if start < 0 {
@@ -1080,7 +1621,7 @@ func (c *CloudProvisioner) listVolumesByResourceGroup(ctx context.Context, resou
 			nodeList := []string{}
 
 			if disk.ManagedBy != nil {
-				attachedNode, err := c.cloud.VMSet.GetNodeNameByProviderID(*disk.ManagedBy)
+				attachedNode, err := c.GetCloud().VMSet.GetNodeNameByProviderID(*disk.ManagedBy)
 				if err != nil {
 					return listVolumeStatus{err: err}
 				}
@@ -1148,3 +1689,103 @@ func (c *CloudProvisioner) GetPerfOptimizationEnabled() bool {
 func (c *CloudProvisioner) SetPerfOptimizationEnabled(enabled bool) {
 	c.perfOptimizationEnabled = enabled
 }
+
+// getDiskUpdate returns the update which changes the disk to match the modifiable parameters, or nil if the disk
+// already matches them. It fails if the disk cannot be changed in place.
+func getDiskUpdate(disk *compute.Disk, parameters map[string]string, cloud string, disableAzureStackCloud bool) (*compute.DiskUpdate, error) {
+	var currentSku compute.DiskStorageAccountTypes
+	if disk.Sku != nil {
+		currentSku = disk.Sku.Name
+	}
+	properties := disk.DiskProperties
+	if properties == nil {
+		properties = &compute.DiskProperties{}
+	}
+
+	targetSku := currentSku
+	update := &compute.DiskUpdateProperties{}
+	modified := false
+	for key, value := range parameters {
+		switch strings.ToLower(key) {
+		case azureconstants.SkuNameField:
+			sku, err := azureutils.NormalizeStorageAccountType(value, cloud, disableAzureStackCloud)
+			if err != nil || value == "" {
+				return nil, status.Errorf(codes.InvalidArgument, "invalid %s (%s): %v", key, value, err)
+			}
+			targetSku = sku
+		case azureconstants.DiskIOPSReadWriteField:
+			iops, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, status.Errorf(codes.InvalidArgument, "invalid %s (%s): %v", key, value, err)
+			}
+			if properties.DiskIOPSReadWrite == nil || *properties.DiskIOPSReadWrite != iops {
+				update.DiskIOPSReadWrite = &iops
+				modified = true
+			}
+		case azureconstants.DiskMBPSReadWriteField:
+			mbps, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, status.Errorf(codes.InvalidArgument, "invalid %s (%s): %v", key, value, err)
+			}
+			if properties.DiskMBpsReadWrite == nil || *properties.DiskMBpsReadWrite != mbps {
+				update.DiskMBpsReadWrite = &mbps
+				modified = true
+			}
+		case azureconstants.EnableBurstingField:
+			enabled, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, status.Errorf(codes.InvalidArgument, "invalid %s (%s): %v", key, value, err)
+			}
+			if (properties.BurstingEnabled != nil && *properties.BurstingEnabled) != enabled {
+				update.BurstingEnabled = &enabled
+				modified = true
+			}
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "parameter %s cannot be modified", key)
+		}
+	}
+
+	if (update.DiskIOPSReadWrite != nil || update.DiskMBpsReadWrite != nil) && !hasProvisionedPerformance(targetSku) {
+		return nil, status.Errorf(codes.InvalidArgument, "%s and %s can only be set for %s and %s disks", azureconstants.DiskIOPSReadWriteField, azureconstants.DiskMBPSReadWriteField, compute.UltraSSDLRS, cloudproviderconsts.PremiumV2LRS)
+	}
+	if update.BurstingEnabled != nil && *update.BurstingEnabled && targetSku != compute.PremiumLRS && targetSku != compute.PremiumZRS {
+		return nil, status.Errorf(codes.InvalidArgument, "%s can only be set for %s and %s disks", azureconstants.EnableBurstingField, compute.PremiumLRS, compute.PremiumZRS)
+	}
+
+	var sku *compute.DiskSku
+	if targetSku != currentSku {
+		if err := validateSkuTransition(disk, currentSku, targetSku); err != nil {
+			return nil, err
+		}
+		sku = &compute.DiskSku{Name: targetSku}
+		modified = true
+	}
+
+	if !modified {
+		return nil, nil
+	}
+	return &compute.DiskUpdate{DiskUpdateProperties: update, Sku: sku}, nil
+}
+
+// validateSkuTransition checks whether the sku of the disk can be changed in place.
+func validateSkuTransition(disk *compute.Disk, currentSku, targetSku compute.DiskStorageAccountTypes) error {
+	switch {
+	case currentSku == compute.UltraSSDLRS || targetSku == compute.UltraSSDLRS || currentSku == cloudproviderconsts.PremiumV2LRS:
+		return status.Errorf(codes.FailedPrecondition, "the sku of the disk cannot be changed from %s to %s in place, create a snapshot of the disk and restore it to a new volume instead", currentSku, targetSku)
+	case isZoneRedundant(currentSku) != isZoneRedundant(targetSku):
+		return status.Errorf(codes.FailedPrecondition, "the redundancy of the disk cannot be changed from %s to %s in place, create a snapshot of the disk and restore it to a new volume instead", currentSku, targetSku)
+	case disk.DiskProperties != nil && disk.DiskProperties.DiskState == compute.Attached:
+		return status.Errorf(codes.FailedPrecondition, "the disk must be detached before its sku can be changed from %s to %s", currentSku, targetSku)
+	}
+	return nil
+}
+
+// hasProvisionedPerformance returns true if the IOPS and throughput of disks with the sku can be provisioned independently of their size.
+func hasProvisionedPerformance(sku compute.DiskStorageAccountTypes) bool {
+	return sku == compute.UltraSSDLRS || sku == cloudproviderconsts.PremiumV2LRS
+}
+
+// isZoneRedundant returns true if disks with the sku are replicated across availability zones.
+func isZoneRedundant(sku compute.DiskStorageAccountTypes) bool {
+	return sku == compute.PremiumZRS || sku == compute.StandardSSDZRS
+}
@@ -39,6 +39,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	kfake "k8s.io/client-go/kubernetes/fake"
 	cloudprovider "k8s.io/cloud-provider"
+	"k8s.io/utils/pointer"
 	azdiskv1beta2 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
 	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
 	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils"
@@ -889,6 +890,62 @@ func TestUnpublishVolume(t *testing.T) {
 	}
 }
 
+func TestGetNodeDataDisks(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	provisioner := NewTestCloudProvisioner(mockCtrl)
+
+	lun := int32(1)
+	attachedDisks := []compute.DataDisk{
+		{
+			Lun:  &lun,
+			Name: &testDiskName0,
+		},
+	}
+
+	testVMWithAttachedDisk := testVM
+	testVMWithAttachedDisk.StorageProfile.DataDisks = &attachedDisks
+
+	provisioner.GetCloud().VirtualMachinesClient.(*mockvmclient.MockInterface).EXPECT().
+		Get(gomock.Any(), testResourceGroup, testVMName, gomock.Any()).
+		Return(testVMWithAttachedDisk, nil).
+		AnyTimes()
+
+	provisioner.GetCloud().VirtualMachinesClient.(*mockvmclient.MockInterface).EXPECT().
+		Get(gomock.Any(), testResourceGroup, missingVMName, gomock.Any()).
+		Return(compute.VirtualMachine{}, notFoundError).
+		AnyTimes()
+
+	tests := []struct {
+		description       string
+		nodeID            string
+		expectedDataDisks []compute.DataDisk
+		expectedError     error
+	}{
+		{
+			description:       "[Success] Returns the data disks attached to the VM",
+			nodeID:            testVMName,
+			expectedDataDisks: attachedDisks,
+			expectedError:     nil,
+		},
+		{
+			description:       "[Failure] Returns error for missing VM",
+			nodeID:            missingVMName,
+			expectedDataDisks: nil,
+			expectedError:     status.Errorf(codes.NotFound, "failed to get azure instance id for node %q: %v", missingVMName, cloudprovider.InstanceNotFound),
+		},
+	}
+
+	for _, test := range tests {
+		tt := test
+		t.Run(test.description, func(t *testing.T) {
+			dataDisks, err := provisioner.GetNodeDataDisks(context.TODO(), tt.nodeID)
+			assert.Equal(t, tt.expectedError, err)
+			assert.Equal(t, tt.expectedDataDisks, dataDisks)
+		})
+	}
+}
+
 func TestExpandVolume(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -949,6 +1006,215 @@ func TestExpandVolume(t *testing.T) {
 	}
 }
 
+func TestModifyVolume(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	provisioner := NewTestCloudProvisioner(mockCtrl)
+
+	mockExistingDisk(provisioner)
+	provisioner.GetCloud().DisksClient.(*mockdiskclient.MockInterface).EXPECT().
+		Update(gomock.Any(), testSubscription, testResourceGroup, testDiskName0, compute.DiskUpdate{
+			DiskUpdateProperties: &compute.DiskUpdateProperties{},
+			Sku:                  &compute.DiskSku{Name: compute.PremiumLRS},
+		}).
+		Return(nil).
+		Times(1)
+	mockMissingDisk(provisioner)
+
+	otherSubscription := "abcdef12-3456-7890-abcd-ef1234567890"
+	otherSubscriptionDiskURI := fmt.Sprintf(computeDiskURIFormat, otherSubscription, testResourceGroup, testDiskName0)
+	provisioner.GetCloud().DisksClient.(*mockdiskclient.MockInterface).EXPECT().
+		Get(gomock.Any(), otherSubscription, testResourceGroup, testDiskName0).
+		Return(testDisk, nil).
+		Times(1)
+	provisioner.GetCloud().DisksClient.(*mockdiskclient.MockInterface).EXPECT().
+		Update(gomock.Any(), otherSubscription, testResourceGroup, testDiskName0, compute.DiskUpdate{
+			DiskUpdateProperties: &compute.DiskUpdateProperties{},
+			Sku:                  &compute.DiskSku{Name: compute.PremiumLRS},
+		}).
+		Return(nil).
+		Times(1)
+
+	tests := []struct {
+		description   string
+		diskURI       string
+		parameters    map[string]string
+		expectedError error
+	}{
+		{
+			description:   "[Success] Modifies the sku of an existing disk",
+			diskURI:       testDiskURI0,
+			parameters:    map[string]string{azureconstants.SkuNameField: string(compute.PremiumLRS)},
+			expectedError: nil,
+		},
+		{
+			description:   "[Success] Modifies a disk in another subscription",
+			diskURI:       otherSubscriptionDiskURI,
+			parameters:    map[string]string{azureconstants.SkuNameField: string(compute.PremiumLRS)},
+			expectedError: nil,
+		},
+		{
+			description:   "[Success] Does not update a disk which already matches the parameters",
+			diskURI:       testDiskURI0,
+			parameters:    map[string]string{azureconstants.EnableBurstingField: "false"},
+			expectedError: nil,
+		},
+		{
+			description:   "[Failure] Returns an error for an unmodifiable parameter",
+			diskURI:       testDiskURI0,
+			parameters:    map[string]string{azureconstants.CachingModeField: "None"},
+			expectedError: status.Errorf(codes.InvalidArgument, "parameter %s cannot be modified", azureconstants.CachingModeField),
+		},
+		{
+			description:   "[Failure] Returns an error for missing disk",
+			diskURI:       missingDiskURI,
+			parameters:    map[string]string{azureconstants.SkuNameField: string(compute.PremiumLRS)},
+			expectedError: status.Errorf(codes.Internal, "could not get the disk(%s) under rg(%s) with error(%v)", missingDiskName, testResourceGroup, notFoundError.Error()),
+		},
+	}
+
+	for _, test := range tests {
+		tt := test
+		t.Run(test.description, func(t *testing.T) {
+			err := provisioner.ModifyVolume(context.TODO(), tt.diskURI, tt.parameters)
+			assert.Equal(t, tt.expectedError, err)
+		})
+	}
+}
+
+func TestUpdateDiskTags(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	provisioner := NewTestCloudProvisioner(mockCtrl)
+
+	tagValue := "value"
+	tags := map[string]*string{"key": &tagValue}
+	provisioner.GetCloud().DisksClient.(*mockdiskclient.MockInterface).EXPECT().
+		Update(gomock.Any(), testSubscription, testResourceGroup, testDiskName0, compute.DiskUpdate{Tags: tags}).
+		Return(nil).
+		Times(1)
+	provisioner.GetCloud().DisksClient.(*mockdiskclient.MockInterface).EXPECT().
+		Update(gomock.Any(), testSubscription, testResourceGroup, missingDiskName, compute.DiskUpdate{Tags: tags}).
+		Return(notFoundError).
+		Times(1)
+
+	tests := []struct {
+		description   string
+		diskURI       string
+		expectedError error
+	}{
+		{
+			description:   "[Success] Updates the tags of an existing disk",
+			diskURI:       testDiskURI0,
+			expectedError: nil,
+		},
+		{
+			description:   "[Failure] Returns an error for missing disk",
+			diskURI:       missingDiskURI,
+			expectedError: status.Errorf(codes.Internal, "failed to update tags of disk(%s) with error(%v)", missingDiskURI, notFoundError.Error()),
+		},
+	}
+
+	for _, test := range tests {
+		tt := test
+		t.Run(test.description, func(t *testing.T) {
+			err := provisioner.UpdateDiskTags(context.TODO(), tt.diskURI, tags)
+			assert.Equal(t, tt.expectedError, err)
+		})
+	}
+}
+
+func TestGetDiskUpdate(t *testing.T) {
+	newDisk := func(sku compute.DiskStorageAccountTypes, state compute.DiskState) *compute.Disk {
+		return &compute.Disk{
+			Sku:            &compute.DiskSku{Name: sku},
+			DiskProperties: &compute.DiskProperties{DiskState: state},
+		}
+	}
+
+	tests := []struct {
+		description    string
+		disk           *compute.Disk
+		parameters     map[string]string
+		expectedUpdate *compute.DiskUpdate
+		expectedCode   codes.Code
+	}{
+		{
+			description:    "[Success] Changes the sku of a detached disk",
+			disk:           newDisk(compute.StandardSSDLRS, compute.Unattached),
+			parameters:     map[string]string{azureconstants.SkuNameField: string(compute.PremiumLRS)},
+			expectedUpdate: &compute.DiskUpdate{DiskUpdateProperties: &compute.DiskUpdateProperties{}, Sku: &compute.DiskSku{Name: compute.PremiumLRS}},
+		},
+		{
+			description:    "[Success] Changes a Premium_LRS disk to PremiumV2_LRS with provisioned performance",
+			disk:           newDisk(compute.PremiumLRS, compute.Unattached),
+			parameters:     map[string]string{"skuName": "PremiumV2_LRS", "DiskIOPSReadWrite": "5000", "DiskMBpsReadWrite": "200"},
+			expectedUpdate: &compute.DiskUpdate{DiskUpdateProperties: &compute.DiskUpdateProperties{DiskIOPSReadWrite: pointer.Int64(5000), DiskMBpsReadWrite: pointer.Int64(200)}, Sku: &compute.DiskSku{Name: "PremiumV2_LRS"}},
+		},
+		{
+			description:    "[Success] Enables bursting on an attached Premium_LRS disk",
+			disk:           newDisk(compute.PremiumLRS, compute.Attached),
+			parameters:     map[string]string{azureconstants.EnableBurstingField: "true"},
+			expectedUpdate: &compute.DiskUpdate{DiskUpdateProperties: &compute.DiskUpdateProperties{BurstingEnabled: pointer.Bool(true)}},
+		},
+		{
+			description:    "[Success] Returns no update for a disk which matches the parameters",
+			disk:           newDisk(compute.PremiumLRS, compute.Attached),
+			parameters:     map[string]string{azureconstants.SkuNameField: string(compute.PremiumLRS)},
+			expectedUpdate: nil,
+		},
+		{
+			description:  "[Failure] Requires an attached disk to be detached before changing its sku",
+			disk:         newDisk(compute.PremiumLRS, compute.Attached),
+			parameters:   map[string]string{azureconstants.SkuNameField: "PremiumV2_LRS"},
+			expectedCode: codes.FailedPrecondition,
+		},
+		{
+			description:  "[Failure] Requires a snapshot to change the redundancy of a disk",
+			disk:         newDisk(compute.PremiumLRS, compute.Unattached),
+			parameters:   map[string]string{azureconstants.SkuNameField: string(compute.PremiumZRS)},
+			expectedCode: codes.FailedPrecondition,
+		},
+		{
+			description:  "[Failure] Requires a snapshot to change an UltraSSD_LRS disk",
+			disk:         newDisk(compute.UltraSSDLRS, compute.Unattached),
+			parameters:   map[string]string{azureconstants.SkuNameField: string(compute.PremiumLRS)},
+			expectedCode: codes.FailedPrecondition,
+		},
+		{
+			description:  "[Failure] Rejects provisioned IOPS for a Premium_LRS disk",
+			disk:         newDisk(compute.PremiumLRS, compute.Unattached),
+			parameters:   map[string]string{azureconstants.DiskIOPSReadWriteField: "5000"},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			description:  "[Failure] Rejects bursting for a StandardSSD_LRS disk",
+			disk:         newDisk(compute.StandardSSDLRS, compute.Unattached),
+			parameters:   map[string]string{azureconstants.EnableBurstingField: "true"},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			description:  "[Failure] Rejects an invalid IOPS value",
+			disk:         newDisk(compute.UltraSSDLRS, compute.Unattached),
+			parameters:   map[string]string{azureconstants.DiskIOPSReadWriteField: "fast"},
+			expectedCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, test := range tests {
+		tt := test
+		t.Run(test.description, func(t *testing.T) {
+			update, err := getDiskUpdate(tt.disk, tt.parameters, "", false)
+			if tt.expectedCode != codes.OK {
+				assert.Equal(t, tt.expectedCode, status.Code(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedUpdate, update)
+		})
+	}
+}
+
 func TestCreateSnapshot(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -1038,6 +1304,10 @@ func TestDeleteSnapshot(t *testing.T) {
 	defer mockCtrl.Finish()
 	provisioner := NewTestCloudProvisioner(mockCtrl)
 
+	provisioner.GetCloud().SnapshotsClient.(*mocksnapshotclient.MockInterface).EXPECT().
+		Get(gomock.Any(), testSubscription, testResourceGroup, gomock.Any()).
+		Return(compute.Snapshot{}, notFoundError).
+		AnyTimes()
 	provisioner.GetCloud().SnapshotsClient.(*mocksnapshotclient.MockInterface).EXPECT().
 		Delete(gomock.Any(), testSubscription, testResourceGroup, testSnapshotName).
 		Return(nil).
@@ -1073,6 +1343,97 @@ func TestDeleteSnapshot(t *testing.T) {
 	}
 }
 
+func TestDeleteCrossRegionSnapshot(t *testing.T) {
+	sourceSnapshotName := azureutils.GetCrossRegionSourceSnapshotName(testSnapshotName, "westus")
+	sourceSnapshotURI := fmt.Sprintf(computeSnapshotURIFormat, testSubscription, testResourceGroup, sourceSnapshotName)
+	copiedSnapshot := compute.Snapshot{
+		Name: &testSnapshotName,
+		SnapshotProperties: &compute.SnapshotProperties{
+			CreationData: &compute.CreationData{
+				CreateOption:     compute.CopyStart,
+				SourceResourceID: &sourceSnapshotURI,
+			},
+		},
+	}
+
+	otherSubscription := "00000000-0000-0000-0000-000000000000"
+	otherSubscriptionSnapshotURI := fmt.Sprintf(computeSnapshotURIFormat, otherSubscription, testResourceGroup, testSnapshotName)
+	otherSubscriptionSourceSnapshotURI := fmt.Sprintf(computeSnapshotURIFormat, otherSubscription, testResourceGroup, sourceSnapshotName)
+	otherSubscriptionCopiedSnapshot := compute.Snapshot{
+		Name: &testSnapshotName,
+		SnapshotProperties: &compute.SnapshotProperties{
+			CreationData: &compute.CreationData{
+				CreateOption:     compute.CopyStart,
+				SourceResourceID: &otherSubscriptionSourceSnapshotURI,
+			},
+		},
+	}
+
+	tests := []struct {
+		description   string
+		snapshotURI   string
+		setupFunc     func(*mocksnapshotclient.MockInterface)
+		expectedError error
+	}{
+		{
+			description: "[Success] Deletes the source snapshot before the copy",
+			setupFunc: func(snapshotsClient *mocksnapshotclient.MockInterface) {
+				snapshotsClient.EXPECT().Get(gomock.Any(), testSubscription, testResourceGroup, testSnapshotName).Return(copiedSnapshot, nil)
+				gomock.InOrder(
+					snapshotsClient.EXPECT().Delete(gomock.Any(), testSubscription, testResourceGroup, sourceSnapshotName).Return(nil),
+					snapshotsClient.EXPECT().Delete(gomock.Any(), testSubscription, testResourceGroup, testSnapshotName).Return(nil),
+				)
+			},
+		},
+		{
+			description: "[Success] Deletes the copy if the source snapshot was already deleted",
+			setupFunc: func(snapshotsClient *mocksnapshotclient.MockInterface) {
+				snapshotsClient.EXPECT().Get(gomock.Any(), testSubscription, testResourceGroup, testSnapshotName).Return(copiedSnapshot, nil)
+				gomock.InOrder(
+					snapshotsClient.EXPECT().Delete(gomock.Any(), testSubscription, testResourceGroup, sourceSnapshotName).Return(notFoundError),
+					snapshotsClient.EXPECT().Delete(gomock.Any(), testSubscription, testResourceGroup, testSnapshotName).Return(nil),
+				)
+			},
+		},
+		{
+			description: "[Failure] Keeps the copy if the source snapshot cannot be deleted",
+			setupFunc: func(snapshotsClient *mocksnapshotclient.MockInterface) {
+				snapshotsClient.EXPECT().Get(gomock.Any(), testSubscription, testResourceGroup, testSnapshotName).Return(copiedSnapshot, nil)
+				snapshotsClient.EXPECT().Delete(gomock.Any(), testSubscription, testResourceGroup, sourceSnapshotName).Return(existingDiskError)
+			},
+			expectedError: status.Error(codes.Internal, fmt.Sprintf("delete source snapshot(%s) error: %v", sourceSnapshotName, existingDiskError.Error())),
+		},
+		{
+			description: "[Success] Deletes the snapshots in the subscription of their URIs",
+			snapshotURI: otherSubscriptionSnapshotURI,
+			setupFunc: func(snapshotsClient *mocksnapshotclient.MockInterface) {
+				snapshotsClient.EXPECT().Get(gomock.Any(), otherSubscription, testResourceGroup, testSnapshotName).Return(otherSubscriptionCopiedSnapshot, nil)
+				gomock.InOrder(
+					snapshotsClient.EXPECT().Delete(gomock.Any(), otherSubscription, testResourceGroup, sourceSnapshotName).Return(nil),
+					snapshotsClient.EXPECT().Delete(gomock.Any(), otherSubscription, testResourceGroup, testSnapshotName).Return(nil),
+				)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		tt := test
+		t.Run(tt.description, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			provisioner := NewTestCloudProvisioner(mockCtrl)
+			tt.setupFunc(provisioner.GetCloud().SnapshotsClient.(*mocksnapshotclient.MockInterface))
+
+			snapshotURI := tt.snapshotURI
+			if snapshotURI == "" {
+				snapshotURI = testSnapshotURI
+			}
+			err := provisioner.DeleteSnapshot(context.TODO(), snapshotURI, map[string]string{})
+			assert.Equal(t, tt.expectedError, err)
+		})
+	}
+}
+
 func TestListVolumes(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
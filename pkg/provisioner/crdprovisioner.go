@@ -55,8 +55,8 @@ import (
 
 type CrdProvisioner struct {
 	azDiskClient         azdisk.Interface
-	kubeClient           kubeClientset.Interface // for crdprovisioner_test
-	crdClient            crdClientset.Interface  // for crdprovisioner_test
+	kubeClient           kubeClientset.Interface
+	crdClient            crdClientset.Interface // for crdprovisioner_test
 	config               *azdiskv1beta2.AzDiskDriverConfiguration
 	conditionWatcher     *watcher.ConditionWatcher
 	azCachedReader       CachedReader
@@ -113,6 +113,20 @@ func (a CachedReader) Get(_ context.Context, namespacedName types.NamespacedName
 			return err
 		}
 		azDriverNode.DeepCopyInto(target)
+	case *azdiskv1beta2.AzSnapshot:
+		var azSnapshot *azdiskv1beta2.AzSnapshot
+		azSnapshot, err = a.azInformer.Disk().V1beta2().AzSnapshots().Lister().AzSnapshots(namespacedName.Namespace).Get(namespacedName.Name)
+		if err != nil {
+			return err
+		}
+		azSnapshot.DeepCopyInto(target)
+	case *azdiskv1beta2.AzVolumeGroupSnapshot:
+		var azVolumeGroupSnapshot *azdiskv1beta2.AzVolumeGroupSnapshot
+		azVolumeGroupSnapshot, err = a.azInformer.Disk().V1beta2().AzVolumeGroupSnapshots().Lister().AzVolumeGroupSnapshots(namespacedName.Namespace).Get(namespacedName.Name)
+		if err != nil {
+			return err
+		}
+		azVolumeGroupSnapshot.DeepCopyInto(target)
 	}
 	return err
 }
@@ -174,13 +188,38 @@ func (a CachedReader) List(_ context.Context, objectList client.ObjectList, opts
 			return err
 		}
 		azDriverNodeList.DeepCopyInto(target)
+	case *azdiskv1beta2.AzSnapshotList:
+		var azSnapshots []*azdiskv1beta2.AzSnapshot
+		azSnapshots, err = a.azInformer.Disk().V1beta2().AzSnapshots().Lister().AzSnapshots(a.azNamespace).List(labelSelector)
+		azSnapshotDeref := make([]azdiskv1beta2.AzSnapshot, len(azSnapshots))
+		for i := range azSnapshots {
+			azSnapshotDeref[i] = *azSnapshots[i]
+		}
+		azSnapshotList := azdiskv1beta2.AzSnapshotList{Items: azSnapshotDeref}
+		if err != nil {
+			return err
+		}
+		azSnapshotList.DeepCopyInto(target)
+	case *azdiskv1beta2.AzVolumeGroupSnapshotList:
+		var azVolumeGroupSnapshots []*azdiskv1beta2.AzVolumeGroupSnapshot
+		azVolumeGroupSnapshots, err = a.azInformer.Disk().V1beta2().AzVolumeGroupSnapshots().Lister().AzVolumeGroupSnapshots(a.azNamespace).List(labelSelector)
+		azVolumeGroupSnapshotDeref := make([]azdiskv1beta2.AzVolumeGroupSnapshot, len(azVolumeGroupSnapshots))
+		for i := range azVolumeGroupSnapshots {
+			azVolumeGroupSnapshotDeref[i] = *azVolumeGroupSnapshots[i]
+		}
+		azVolumeGroupSnapshotList := azdiskv1beta2.AzVolumeGroupSnapshotList{Items: azVolumeGroupSnapshotDeref}
+		if err != nil {
+			return err
+		}
+		azVolumeGroupSnapshotList.DeepCopyInto(target)
 	}
 	return err
 }
 
-func NewCrdProvisioner(azdiskClient azdisk.Interface, config *azdiskv1beta2.AzDiskDriverConfiguration, cw *watcher.ConditionWatcher, azCachedReader CachedReader, informer azureutils.GenericInformer) (*CrdProvisioner, error) {
+func NewCrdProvisioner(azdiskClient azdisk.Interface, kubeClient kubeClientset.Interface, config *azdiskv1beta2.AzDiskDriverConfiguration, cw *watcher.ConditionWatcher, azCachedReader CachedReader, informer azureutils.GenericInformer) (*CrdProvisioner, error) {
 	c := &CrdProvisioner{
 		azDiskClient:     azdiskClient,
+		kubeClient:       kubeClient,
 		config:           config,
 		conditionWatcher: cw,
 		azCachedReader:   azCachedReader,
@@ -189,7 +228,7 @@ func NewCrdProvisioner(azdiskClient azdisk.Interface, config *azdiskv1beta2.AzDi
 	_, err := informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		UpdateFunc: func(_, new interface{}) {
 			crdObj := new.(*apiext.CustomResourceDefinition)
-			if crdObj.DeletionTimestamp != nil && (crdObj.Name == consts.AzVolumeAttachmentCRDName || crdObj.Name == consts.AzVolumeCRDName) {
+			if crdObj.DeletionTimestamp != nil && (crdObj.Name == consts.AzVolumeAttachmentCRDName || crdObj.Name == consts.AzVolumeCRDName || crdObj.Name == consts.AzSnapshotCRDName || crdObj.Name == consts.AzVolumeGroupSnapshotCRDName) {
 				go func() {
 					atomic.StoreUint32(&c.driverUninstallState, 1)
 				}()
@@ -231,6 +270,14 @@ func (c *CrdProvisioner) CreateVolume(
 	azVolumeInstance := &azdiskv1beta2.AzVolume{}
 	err = c.azCachedReader.Get(ctx, types.NamespacedName{Namespace: c.config.ObjectNamespace, Name: azVolumeName}, azVolumeInstance)
 	if err == nil {
+		isSameAsRequestParams := func(azVolume *azdiskv1beta2.AzVolume) (bool, error) {
+			azVolumeSecrets, err := azureutils.GetAzVolumeSecrets(ctx, c.kubeClient, azVolume)
+			if err != nil {
+				return false, status.Errorf(codes.Internal, "failed to get secrets of AzVolume (%s): %v", azVolume.Name, err)
+			}
+			return isAzVolumeSpecSameAsRequestParams(azVolume, azVolumeSecrets, maxMountReplicaCount, capacityRange, parameters, secrets, volumeContentSource, accessibilityReq), nil
+		}
+
 		updateFunc := func(obj client.Object) error {
 			updateInstance := obj.(*azdiskv1beta2.AzVolume)
 			switch updateInstance.Status.State {
@@ -241,7 +288,11 @@ func (c *CrdProvisioner) CreateVolume(
 			case azdiskv1beta2.VolumeCreated:
 				if updateInstance.Status.Detail != nil {
 					// If current request has different specifications than the existing volume, return error.
-					if !isAzVolumeSpecSameAsRequestParams(updateInstance, maxMountReplicaCount, capacityRange, parameters, secrets, volumeContentSource, accessibilityReq) {
+					var isSame bool
+					if isSame, err = isSameAsRequestParams(updateInstance); err != nil {
+						return err
+					}
+					if !isSame {
 						err = status.Errorf(codes.AlreadyExists, "Volume with name (%s) already exists with different specifications", volumeName)
 						return err
 					}
@@ -256,12 +307,21 @@ func (c *CrdProvisioner) CreateVolume(
 				updateInstance.Status.State = azdiskv1beta2.VolumeOperationPending
 				updateInstance.Finalizers = []string{consts.AzVolumeFinalizer}
 
-				if !isAzVolumeSpecSameAsRequestParams(updateInstance, maxMountReplicaCount, capacityRange, parameters, secrets, volumeContentSource, accessibilityReq) {
+				isSame, err := isSameAsRequestParams(updateInstance)
+				if err != nil {
+					return err
+				}
+				if !isSame {
+					secretRef, err := c.updateAzVolumeSecrets(ctx, updateInstance, secrets)
+					if err != nil {
+						return err
+					}
 					// Updating the spec fields to keep it up to date with the request
 					updateInstance.Spec.MaxMountReplicaCount = maxMountReplicaCount
 					updateInstance.Spec.CapacityRange = capacityRange
 					updateInstance.Spec.Parameters = parameters
-					updateInstance.Spec.Secrets = secrets
+					updateInstance.Spec.Secrets = nil
+					updateInstance.Spec.SecretRef = secretRef
 					updateInstance.Spec.ContentVolumeSource = volumeContentSource
 					updateInstance.Spec.AccessibilityRequirements = accessibilityReq
 				}
@@ -285,9 +345,11 @@ func (c *CrdProvisioner) CreateVolume(
 		if !pvExists || !pvcExists || !namespaceExists {
 			w.Logger().Info("CreateVolume request does not contain pv, pvc, namespace information. Please enable -extra-create-metadata flag in csi-provisioner")
 		}
+
 		azVolume := &azdiskv1beta2.AzVolume{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:        azVolumeName,
+				Namespace:   c.config.ObjectNamespace,
 				Finalizers:  []string{consts.AzVolumeFinalizer},
 				Labels:      map[string]string{consts.PvNameLabel: pv, consts.PvcNameLabel: pvc, consts.PvcNamespaceLabel: namespace},
 				Annotations: map[string]string{consts.RequestIDKey: w.RequestID(), consts.RequestStartimeKey: w.StartTime().Format(consts.RequestTimeFormat)},
@@ -298,7 +360,6 @@ func (c *CrdProvisioner) CreateVolume(
 				VolumeCapability:          volumeCapabilities,
 				CapacityRange:             capacityRange,
 				Parameters:                parameters,
-				Secrets:                   secrets,
 				ContentVolumeSource:       volumeContentSource,
 				AccessibilityRequirements: accessibilityReq,
 				PersistentVolume:          pv,
@@ -306,10 +367,20 @@ func (c *CrdProvisioner) CreateVolume(
 		}
 		azureutils.AnnotateAPIVersion(azVolume)
 
+		// the AzVolume controller makes the AzVolume the owner of the Secret once the AzVolume has been created
+		azVolume.Spec.SecretRef, err = azureutils.StoreAzVolumeSecrets(ctx, c.kubeClient, azVolume, secrets)
+		if err != nil {
+			err = status.Errorf(codes.Internal, "failed to store secrets of AzVolume (%s): %v", azVolumeName, err)
+			return nil, err
+		}
+
 		w.Logger().V(5).Info("Creating AzVolume CRI")
 
 		_, err = azVolumeClient.Create(ctx, azVolume, metav1.CreateOptions{})
 		if err != nil {
+			if derr := azureutils.DeleteAzVolumeSecrets(ctx, c.kubeClient, azVolume); derr != nil {
+				w.Logger().Errorf(derr, "failed to delete secrets of AzVolume (%s)", azVolumeName)
+			}
 			err = status.Errorf(codes.Internal, "failed to create AzVolume CRI: %v", err)
 			return nil, err
 		}
@@ -390,6 +461,14 @@ func (c *CrdProvisioner) DeleteVolume(ctx context.Context, volumeID string, secr
 		return nil
 	}
 
+	// refresh the referenced secrets so that the volume is deleted with the secrets of the request
+	if len(secrets) > 0 && azVolumeInstance.Spec.SecretRef != nil {
+		if _, err = azureutils.StoreAzVolumeSecrets(ctx, c.kubeClient, azVolumeInstance, secrets); err != nil {
+			err = status.Errorf(codes.Internal, "failed to store secrets of AzVolume (%s): %v", azVolumeName, err)
+			return err
+		}
+	}
+
 	// if deletion failed requeue deletion
 	updateFunc := func(obj client.Object) error {
 		updateInstance := obj.(*azdiskv1beta2.AzVolume)
@@ -979,6 +1058,14 @@ func (c *CrdProvisioner) ExpandVolume(
 			err = status.Errorf(codes.Internal, "unexpected expand volume request: AzVolume is currently in %s state", azVolume.Status.State)
 			return err
 		}
+		if len(secrets) > 0 {
+			secretRef, err := c.updateAzVolumeSecrets(ctx, updateInstance, secrets)
+			if err != nil {
+				return err
+			}
+			updateInstance.Spec.Secrets = nil
+			updateInstance.Spec.SecretRef = secretRef
+		}
 		w.AnnotateObject(updateInstance)
 		updateInstance.Spec.CapacityRange = capacityRange
 		return nil
@@ -1008,6 +1095,589 @@ func (c *CrdProvisioner) ExpandVolume(
 	return azVolumeInstance.Status.Detail, nil
 }
 
+/*
+ModifyVolume updates the parameters of the AzVolume CRI and waits for the disk to be modified to match them.
+*/
+func (c *CrdProvisioner) ModifyVolume(
+	ctx context.Context,
+	volumeID string,
+	parameters map[string]string) error {
+	var err error
+	for key := range parameters {
+		if !azureutils.IsModifiableDiskParameter(key) {
+			err = status.Errorf(codes.InvalidArgument, "parameter %s cannot be modified", key)
+			return err
+		}
+	}
+
+	volumeName, err := azureutils.GetDiskName(volumeID)
+	if err != nil {
+		return err
+	}
+	azVolumeName := strings.ToLower(volumeName)
+
+	azVolume := &azdiskv1beta2.AzVolume{}
+	err = c.azCachedReader.Get(ctx, types.NamespacedName{Namespace: c.config.ObjectNamespace, Name: azVolumeName}, azVolume)
+	if err != nil || azVolume == nil {
+		err = status.Errorf(codes.Internal, "failed to retrieve volume id (%s): %v", volumeID, err)
+		return err
+	}
+
+	ctx, w := workflow.New(ctx, workflow.WithDetails(workflow.GetObjectDetails(azVolume)...))
+	defer func() { w.Finish(err) }()
+
+	// return early if the disk already matches the parameters
+	if azVolume.Status.Detail != nil && azVolume.Status.State != azdiskv1beta2.VolumeUpdating && len(azureutils.GetModifiedDiskParameters(parameters, azVolume.Status.Detail.VolumeContext)) == 0 {
+		w.Logger().V(5).Infof("AzVolume (%s) already matches the parameters (%v)", azVolumeName, parameters)
+		return nil
+	}
+
+	waiter := c.conditionWatcher.NewConditionWaiter(ctx, watcher.AzVolumeType, azVolumeName, waitForModifyVolumeFunc(parameters))
+	defer waiter.Close()
+
+	updateFunc := func(obj client.Object) error {
+		updateInstance := obj.(*azdiskv1beta2.AzVolume)
+		switch updateInstance.Status.State {
+		case azdiskv1beta2.VolumeCreated, azdiskv1beta2.VolumeUpdating, azdiskv1beta2.VolumeUpdated:
+		case azdiskv1beta2.VolumeUpdateFailed:
+			updateInstance.Status.Error = nil
+			updateInstance.Status.State = azdiskv1beta2.VolumeCreated
+		default:
+			err = status.Errorf(codes.Internal, "unexpected modify volume request: AzVolume is currently in %s state", updateInstance.Status.State)
+			return err
+		}
+		w.AnnotateObject(updateInstance)
+		if updateInstance.Spec.Parameters == nil {
+			updateInstance.Spec.Parameters = make(map[string]string, len(parameters))
+		}
+		for key, value := range parameters {
+			// replace differently-cased keys of the parameter so its value is unambiguous
+			for existingKey := range updateInstance.Spec.Parameters {
+				if strings.EqualFold(existingKey, key) {
+					delete(updateInstance.Spec.Parameters, existingKey)
+				}
+			}
+			updateInstance.Spec.Parameters[key] = value
+		}
+		return nil
+	}
+
+	if _, err = azureutils.UpdateCRIWithRetry(ctx, c.azCachedReader.azInformer, nil, c.azDiskClient, azVolume, updateFunc, consts.NormalUpdateMaxNetRetry, azureutils.UpdateAll); err != nil {
+		return err
+	}
+
+	var obj runtime.Object
+	obj, err = waiter.Wait(ctx)
+	if err != nil {
+		err = status.Errorf(codes.Internal, "failed to modify volume (%s): %v", volumeID, err)
+		return err
+	}
+	if obj == nil {
+		err = status.Error(codes.Aborted, "failed to modify volume: unknown error")
+		return err
+	}
+
+	return nil
+}
+
+/*
+CreateSnapshot creates AzSnapshot CRI to correspond with the given CSI request.
+*/
+func (c *CrdProvisioner) CreateSnapshot(
+	ctx context.Context,
+	sourceVolumeID string,
+	snapshotName string,
+	secrets map[string]string,
+	parameters map[string]string) (*azdiskv1beta2.Snapshot, error) {
+	var err error
+	azSnapshotClient := c.azDiskClient.DiskV1beta2().AzSnapshots(c.config.ObjectNamespace)
+
+	// Getting the valid snapshot name here since after snapshot
+	// creation the snapshot ID will consist of the valid snapshot name
+	snapshotName = azureutils.CreateValidDiskName(snapshotName, true)
+	azSnapshotName := strings.ToLower(snapshotName)
+
+	ctx, w := workflow.New(ctx, workflow.WithDetails(consts.SnapshotName, snapshotName, consts.SourceResourceID, sourceVolumeID))
+	defer func() { w.Finish(err) }()
+
+	azSnapshotInstance := &azdiskv1beta2.AzSnapshot{}
+	err = c.azCachedReader.Get(ctx, types.NamespacedName{Namespace: c.config.ObjectNamespace, Name: azSnapshotName}, azSnapshotInstance)
+	if err == nil {
+		updateFunc := func(obj client.Object) error {
+			updateInstance := obj.(*azdiskv1beta2.AzSnapshot)
+			// If current request has a different source volume than the existing snapshot, return error.
+			if !strings.EqualFold(updateInstance.Spec.SourceVolumeID, sourceVolumeID) {
+				err = status.Errorf(codes.AlreadyExists, "Snapshot with name (%s) already exists with a different source volume (%s)", snapshotName, updateInstance.Spec.SourceVolumeID)
+				return err
+			}
+			switch updateInstance.Status.State {
+			case "":
+				break
+			case azdiskv1beta2.SnapshotOperationPending:
+				break
+			case azdiskv1beta2.SnapshotCreating:
+				return nil
+			case azdiskv1beta2.SnapshotCreated:
+				return nil
+			case azdiskv1beta2.SnapshotCreationFailed:
+				w.Logger().V(5).Info("Requeuing CreateSnapshot request")
+				// otherwise requeue operation
+				w.AnnotateObject(updateInstance)
+				updateInstance.Status.Error = nil
+				updateInstance.Status.State = azdiskv1beta2.SnapshotOperationPending
+				updateInstance.Finalizers = []string{consts.AzSnapshotFinalizer}
+
+				// Updating the spec fields to keep it up to date with the request
+				secretRef, err := c.updateAzSnapshotSecrets(ctx, updateInstance, secrets)
+				if err != nil {
+					return err
+				}
+				updateInstance.Spec.Parameters = parameters
+				updateInstance.Spec.SecretRef = secretRef
+			default:
+				return status.Errorf(codes.Internal, "unexpected create snapshot request: snapshot is currently in %s state", updateInstance.Status.State)
+			}
+			return nil
+		}
+
+		if _, err = azureutils.UpdateCRIWithRetry(ctx, c.azCachedReader.azInformer, nil, c.azDiskClient, azSnapshotInstance, updateFunc, consts.NormalUpdateMaxNetRetry, azureutils.UpdateAll); err != nil {
+			return nil, err
+		}
+		// if the error was caused by errors other than IsNotFound, return failure
+	} else if !apiErrors.IsNotFound(err) {
+		err = status.Errorf(codes.Internal, "failed to get AzSnapshot CRI: %v", err)
+		return nil, err
+	} else {
+		azSnapshot := &azdiskv1beta2.AzSnapshot{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        azSnapshotName,
+				Namespace:   c.config.ObjectNamespace,
+				Finalizers:  []string{consts.AzSnapshotFinalizer},
+				Annotations: map[string]string{consts.RequestIDKey: w.RequestID(), consts.RequestStartimeKey: w.StartTime().Format(consts.RequestTimeFormat)},
+			},
+			Spec: azdiskv1beta2.AzSnapshotSpec{
+				SnapshotName:   snapshotName,
+				SourceVolumeID: sourceVolumeID,
+				Parameters:     parameters,
+			},
+		}
+		azureutils.AnnotateAPIVersion(azSnapshot)
+
+		// the AzSnapshot controller makes the AzSnapshot the owner of the Secret once the AzSnapshot has been created
+		azSnapshot.Spec.SecretRef, err = azureutils.StoreAzSnapshotSecrets(ctx, c.kubeClient, azSnapshot, secrets)
+		if err != nil {
+			err = status.Errorf(codes.Internal, "failed to store secrets of AzSnapshot (%s): %v", azSnapshotName, err)
+			return nil, err
+		}
+
+		w.Logger().V(5).Info("Creating AzSnapshot CRI")
+
+		_, err = azSnapshotClient.Create(ctx, azSnapshot, metav1.CreateOptions{})
+		if err != nil {
+			if derr := azureutils.DeleteAzSnapshotSecrets(ctx, c.kubeClient, azSnapshot); derr != nil {
+				w.Logger().Errorf(derr, "failed to delete secrets of AzSnapshot (%s)", azSnapshotName)
+			}
+			err = status.Errorf(codes.Internal, "failed to create AzSnapshot CRI: %v", err)
+			return nil, err
+		}
+
+		w.Logger().V(5).Info("Successfully created AzSnapshot CRI")
+	}
+
+	waiter := c.conditionWatcher.NewConditionWaiter(ctx, watcher.AzSnapshotType, azSnapshotName, waitForCreateSnapshotFunc)
+	defer waiter.Close()
+
+	var obj runtime.Object
+	obj, err = waiter.Wait(ctx)
+	if obj == nil || err != nil {
+		// if the error was due to context deadline exceeding, do not delete AzSnapshot CRI
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			return nil, err
+		}
+		// if snapshot creation was unsuccessful, delete the AzSnapshot CRI and return error
+		go func() {
+			w.Logger().V(5).Info("Deleting snapshot due to failed snapshot creation")
+			conditionFunc := func() (bool, error) {
+				if err := azSnapshotClient.Delete(context.Background(), azSnapshotName, metav1.DeleteOptions{}); err != nil && !apiErrors.IsNotFound(err) {
+					w.Logger().Error(err, "failed to make a delete request for AzSnapshot CRI")
+					return false, nil
+				}
+				return true, nil
+			}
+			if err := wait.PollImmediateInfinite(interval, conditionFunc); err != nil {
+				w.Logger().Error(err, "failed to delete AzSnapshot CRI")
+			}
+		}()
+		return nil, err
+	}
+	azSnapshotInstance = obj.(*azdiskv1beta2.AzSnapshot)
+
+	if azSnapshotInstance.Status.Detail == nil {
+		// this line should not be reached
+		err = status.Errorf(codes.Internal, "failed to create snapshot (%s)", snapshotName)
+		return nil, err
+	}
+
+	return azSnapshotInstance.Status.Detail, nil
+}
+
+/*
+DeleteSnapshot deletes the AzSnapshot CRI corresponding to the given snapshot ID and waits for the underlying snapshot to be deleted.
+If no AzSnapshot CRI exists for the snapshot, the NotFound error is returned to the caller.
+*/
+func (c *CrdProvisioner) DeleteSnapshot(ctx context.Context, snapshotID string, secrets map[string]string) error {
+	var err error
+	azSnapshotClient := c.azDiskClient.DiskV1beta2().AzSnapshots(c.config.ObjectNamespace)
+
+	snapshotName := snapshotID
+	if azureutils.IsARMResourceID(snapshotID) {
+		if snapshotName, err = azureutils.GetSnapshotNameFromURI(snapshotID); err != nil {
+			return status.Errorf(codes.Internal, err.Error())
+		}
+	}
+	azSnapshotName := strings.ToLower(snapshotName)
+
+	azSnapshotInstance := &azdiskv1beta2.AzSnapshot{}
+	err = c.azCachedReader.Get(ctx, types.NamespacedName{Namespace: c.config.ObjectNamespace, Name: azSnapshotName}, azSnapshotInstance)
+	ctx, w := workflow.New(ctx, workflow.WithDetails(workflow.GetObjectDetails(azSnapshotInstance)...))
+	defer func() { w.Finish(err) }()
+
+	if err != nil {
+		if apiErrors.IsNotFound(err) {
+			w.Logger().Logger.WithValues(consts.SnapshotName, snapshotName).V(5).Info("could not find the AzSnapshot CRI")
+			return err
+		}
+		w.Logger().Logger.WithValues(consts.SnapshotName, snapshotName).Error(err, "failed to get AzSnapshot CRI")
+		return err
+	}
+
+	// refresh the referenced secrets so that the snapshot is deleted with the secrets of the request
+	if len(secrets) > 0 && azSnapshotInstance.Spec.SecretRef != nil {
+		if _, err = azureutils.StoreAzSnapshotSecrets(ctx, c.kubeClient, azSnapshotInstance, secrets); err != nil {
+			err = status.Errorf(codes.Internal, "failed to store secrets of AzSnapshot (%s): %v", azSnapshotName, err)
+			return err
+		}
+	}
+
+	// if deletion failed requeue deletion
+	updateFunc := func(obj client.Object) error {
+		updateInstance := obj.(*azdiskv1beta2.AzSnapshot)
+		switch updateInstance.Status.State {
+		case azdiskv1beta2.SnapshotCreating:
+			// if snapshot is still being created, wait for creation
+			waiter := c.conditionWatcher.NewConditionWaiter(ctx, watcher.AzSnapshotType, azSnapshotName, waitForCreateSnapshotFunc)
+			obj, err := waiter.Wait(ctx)
+			// close cannot be called on defer because this will interfere wait for delete
+			waiter.Close()
+			if err != nil {
+				return err
+			}
+			azSnapshotInstance = obj.(*azdiskv1beta2.AzSnapshot)
+		case azdiskv1beta2.SnapshotDeleting:
+			// if snapshot is still being deleted, don't update
+			return nil
+		case azdiskv1beta2.SnapshotDeletionFailed:
+			// remove deletion failure error from AzSnapshot CRI and revert snapshot deletion state to retrigger deletion
+			updateInstance.Status.Error = nil
+			updateInstance.Status.State = azdiskv1beta2.SnapshotCreated
+		}
+		w.AnnotateObject(updateInstance)
+		return nil
+	}
+
+	var updateObj client.Object
+	// update AzSnapshot CRI with request annotations and reset state with retry upon conflict
+	if updateObj, err = azureutils.UpdateCRIWithRetry(ctx, c.azCachedReader.azInformer, nil, c.azDiskClient, azSnapshotInstance, updateFunc, consts.NormalUpdateMaxNetRetry, azureutils.UpdateAll); err != nil {
+		return err
+	}
+
+	// if the driver is uninstalling, the delete request is rejected.
+	if c.IsDriverUninstall() {
+		err = fmt.Errorf("SnapshotDeleteRequestError")
+		w.Logger().Error(err, "AzSnapshot deletion is being requested as the driver is uninstalling.")
+		return err
+	}
+
+	waiter := c.conditionWatcher.NewConditionWaiter(ctx, watcher.AzSnapshotType, azSnapshotName, waitForDeleteSnapshotFunc)
+	defer waiter.Close()
+
+	// only make delete request if object's deletion timestamp is not set
+	azSnapshotInstance = updateObj.(*azdiskv1beta2.AzSnapshot)
+	if azSnapshotInstance.DeletionTimestamp.IsZero() {
+		err = azSnapshotClient.Delete(ctx, azSnapshotName, metav1.DeleteOptions{})
+		if err != nil {
+			if apiErrors.IsNotFound(err) {
+				err = nil
+				return nil
+			}
+
+			err = status.Errorf(codes.Internal, "failed to delete AzSnapshot CRI: %v", err)
+			return err
+		}
+	}
+
+	_, err = waiter.Wait(ctx)
+	return err
+}
+
+/*
+CreateGroupSnapshot creates AzVolumeGroupSnapshot CRI to correspond with the given CSI request.
+*/
+func (c *CrdProvisioner) CreateGroupSnapshot(
+	ctx context.Context,
+	groupSnapshotName string,
+	sourceVolumeIDs []string,
+	secrets map[string]string,
+	parameters map[string]string) (*azdiskv1beta2.GroupSnapshot, error) {
+	var err error
+	azVolumeGroupSnapshotClient := c.azDiskClient.DiskV1beta2().AzVolumeGroupSnapshots(c.config.ObjectNamespace)
+
+	// Getting the valid group snapshot name here since after group snapshot
+	// creation the group snapshot ID will consist of the valid group snapshot name
+	groupSnapshotName = azureutils.CreateValidDiskName(groupSnapshotName, true)
+	azVolumeGroupSnapshotName := strings.ToLower(groupSnapshotName)
+
+	ctx, w := workflow.New(ctx, workflow.WithDetails(consts.GroupSnapshotName, groupSnapshotName))
+	defer func() { w.Finish(err) }()
+
+	azVolumeGroupSnapshotInstance := &azdiskv1beta2.AzVolumeGroupSnapshot{}
+	err = c.azCachedReader.Get(ctx, types.NamespacedName{Namespace: c.config.ObjectNamespace, Name: azVolumeGroupSnapshotName}, azVolumeGroupSnapshotInstance)
+	if err == nil {
+		updateFunc := func(obj client.Object) error {
+			updateInstance := obj.(*azdiskv1beta2.AzVolumeGroupSnapshot)
+			// If current request has different source volumes than the existing group snapshot, return error.
+			if !isSameVolumeIDSet(updateInstance.Spec.SourceVolumeIDs, sourceVolumeIDs) {
+				err = status.Errorf(codes.AlreadyExists, "Group snapshot with name (%s) already exists with different source volumes (%v)", groupSnapshotName, updateInstance.Spec.SourceVolumeIDs)
+				return err
+			}
+			switch updateInstance.Status.State {
+			case "":
+				break
+			case azdiskv1beta2.GroupSnapshotOperationPending:
+				break
+			case azdiskv1beta2.GroupSnapshotCreating:
+				return nil
+			case azdiskv1beta2.GroupSnapshotCreated:
+				return nil
+			case azdiskv1beta2.GroupSnapshotCreationFailed:
+				w.Logger().V(5).Info("Requeuing CreateGroupSnapshot request")
+				// otherwise requeue operation
+				w.AnnotateObject(updateInstance)
+				updateInstance.Status.Error = nil
+				updateInstance.Status.State = azdiskv1beta2.GroupSnapshotOperationPending
+				updateInstance.Finalizers = []string{consts.AzVolumeGroupSnapshotFinalizer}
+
+				// Updating the spec fields to keep it up to date with the request
+				secretRef, err := c.updateAzVolumeGroupSnapshotSecrets(ctx, updateInstance, secrets)
+				if err != nil {
+					return err
+				}
+				updateInstance.Spec.Parameters = parameters
+				updateInstance.Spec.SecretRef = secretRef
+			default:
+				return status.Errorf(codes.Internal, "unexpected create group snapshot request: group snapshot is currently in %s state", updateInstance.Status.State)
+			}
+			return nil
+		}
+
+		if _, err = azureutils.UpdateCRIWithRetry(ctx, c.azCachedReader.azInformer, nil, c.azDiskClient, azVolumeGroupSnapshotInstance, updateFunc, consts.NormalUpdateMaxNetRetry, azureutils.UpdateAll); err != nil {
+			return nil, err
+		}
+		// if the error was caused by errors other than IsNotFound, return failure
+	} else if !apiErrors.IsNotFound(err) {
+		err = status.Errorf(codes.Internal, "failed to get AzVolumeGroupSnapshot CRI: %v", err)
+		return nil, err
+	} else {
+		azVolumeGroupSnapshot := &azdiskv1beta2.AzVolumeGroupSnapshot{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        azVolumeGroupSnapshotName,
+				Namespace:   c.config.ObjectNamespace,
+				Finalizers:  []string{consts.AzVolumeGroupSnapshotFinalizer},
+				Annotations: map[string]string{consts.RequestIDKey: w.RequestID(), consts.RequestStartimeKey: w.StartTime().Format(consts.RequestTimeFormat)},
+			},
+			Spec: azdiskv1beta2.AzVolumeGroupSnapshotSpec{
+				GroupSnapshotName: groupSnapshotName,
+				SourceVolumeIDs:   sourceVolumeIDs,
+				Parameters:        parameters,
+			},
+		}
+		azureutils.AnnotateAPIVersion(azVolumeGroupSnapshot)
+
+		// the AzVolumeGroupSnapshot controller makes the AzVolumeGroupSnapshot the owner of the Secret once the AzVolumeGroupSnapshot has been created
+		azVolumeGroupSnapshot.Spec.SecretRef, err = azureutils.StoreAzVolumeGroupSnapshotSecrets(ctx, c.kubeClient, azVolumeGroupSnapshot, secrets)
+		if err != nil {
+			err = status.Errorf(codes.Internal, "failed to store secrets of AzVolumeGroupSnapshot (%s): %v", azVolumeGroupSnapshotName, err)
+			return nil, err
+		}
+
+		w.Logger().V(5).Info("Creating AzVolumeGroupSnapshot CRI")
+
+		_, err = azVolumeGroupSnapshotClient.Create(ctx, azVolumeGroupSnapshot, metav1.CreateOptions{})
+		if err != nil {
+			if derr := azureutils.DeleteAzVolumeGroupSnapshotSecrets(ctx, c.kubeClient, azVolumeGroupSnapshot); derr != nil {
+				w.Logger().Errorf(derr, "failed to delete secrets of AzVolumeGroupSnapshot (%s)", azVolumeGroupSnapshotName)
+			}
+			err = status.Errorf(codes.Internal, "failed to create AzVolumeGroupSnapshot CRI: %v", err)
+			return nil, err
+		}
+
+		w.Logger().V(5).Info("Successfully created AzVolumeGroupSnapshot CRI")
+	}
+
+	waiter := c.conditionWatcher.NewConditionWaiter(ctx, watcher.AzVolumeGroupSnapshotType, azVolumeGroupSnapshotName, waitForCreateGroupSnapshotFunc)
+	defer waiter.Close()
+
+	var obj runtime.Object
+	obj, err = waiter.Wait(ctx)
+	if obj == nil || err != nil {
+		// if the error was due to context deadline exceeding, do not delete AzVolumeGroupSnapshot CRI
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			return nil, err
+		}
+		// if group snapshot creation was unsuccessful, delete the AzVolumeGroupSnapshot CRI and return error
+		go func() {
+			w.Logger().V(5).Info("Deleting group snapshot due to failed group snapshot creation")
+			conditionFunc := func() (bool, error) {
+				if err := azVolumeGroupSnapshotClient.Delete(context.Background(), azVolumeGroupSnapshotName, metav1.DeleteOptions{}); err != nil && !apiErrors.IsNotFound(err) {
+					w.Logger().Error(err, "failed to make a delete request for AzVolumeGroupSnapshot CRI")
+					return false, nil
+				}
+				return true, nil
+			}
+			if err := wait.PollImmediateInfinite(interval, conditionFunc); err != nil {
+				w.Logger().Error(err, "failed to delete AzVolumeGroupSnapshot CRI")
+			}
+		}()
+		return nil, err
+	}
+	azVolumeGroupSnapshotInstance = obj.(*azdiskv1beta2.AzVolumeGroupSnapshot)
+
+	if azVolumeGroupSnapshotInstance.Status.Detail == nil {
+		// this line should not be reached
+		err = status.Errorf(codes.Internal, "failed to create group snapshot (%s)", groupSnapshotName)
+		return nil, err
+	}
+
+	return azVolumeGroupSnapshotInstance.Status.Detail, nil
+}
+
+/*
+GetGroupSnapshot returns the group snapshot recorded by the AzVolumeGroupSnapshot CRI corresponding to the given group snapshot ID.
+If no AzVolumeGroupSnapshot CRI exists for the group snapshot, the NotFound error is returned to the caller.
+*/
+func (c *CrdProvisioner) GetGroupSnapshot(ctx context.Context, groupSnapshotID string, secrets map[string]string) (*azdiskv1beta2.GroupSnapshot, error) {
+	_, _, groupSnapshotName, err := azureutils.ParseGroupSnapshotID(groupSnapshotID)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	azVolumeGroupSnapshotInstance := &azdiskv1beta2.AzVolumeGroupSnapshot{}
+	if err = c.azCachedReader.Get(ctx, types.NamespacedName{Namespace: c.config.ObjectNamespace, Name: strings.ToLower(groupSnapshotName)}, azVolumeGroupSnapshotInstance); err != nil {
+		return nil, err
+	}
+	if azVolumeGroupSnapshotInstance.Status.Detail == nil {
+		return nil, status.Errorf(codes.NotFound, "group snapshot (%s) has not been created", groupSnapshotName)
+	}
+	return azVolumeGroupSnapshotInstance.Status.Detail, nil
+}
+
+/*
+DeleteGroupSnapshot deletes the AzVolumeGroupSnapshot CRI corresponding to the given group snapshot ID and waits for the underlying
+snapshots to be deleted. If no AzVolumeGroupSnapshot CRI exists for the group snapshot, the NotFound error is returned to the caller.
+*/
+func (c *CrdProvisioner) DeleteGroupSnapshot(ctx context.Context, groupSnapshotID string, secrets map[string]string) error {
+	var err error
+	azVolumeGroupSnapshotClient := c.azDiskClient.DiskV1beta2().AzVolumeGroupSnapshots(c.config.ObjectNamespace)
+
+	var groupSnapshotName string
+	if _, _, groupSnapshotName, err = azureutils.ParseGroupSnapshotID(groupSnapshotID); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	azVolumeGroupSnapshotName := strings.ToLower(groupSnapshotName)
+
+	azVolumeGroupSnapshotInstance := &azdiskv1beta2.AzVolumeGroupSnapshot{}
+	err = c.azCachedReader.Get(ctx, types.NamespacedName{Namespace: c.config.ObjectNamespace, Name: azVolumeGroupSnapshotName}, azVolumeGroupSnapshotInstance)
+	ctx, w := workflow.New(ctx, workflow.WithDetails(workflow.GetObjectDetails(azVolumeGroupSnapshotInstance)...))
+	defer func() { w.Finish(err) }()
+
+	if err != nil {
+		if apiErrors.IsNotFound(err) {
+			w.Logger().Logger.WithValues(consts.GroupSnapshotName, groupSnapshotName).V(5).Info("could not find the AzVolumeGroupSnapshot CRI")
+			return err
+		}
+		w.Logger().Logger.WithValues(consts.GroupSnapshotName, groupSnapshotName).Error(err, "failed to get AzVolumeGroupSnapshot CRI")
+		return err
+	}
+
+	// refresh the referenced secrets so that the group snapshot is deleted with the secrets of the request
+	if len(secrets) > 0 && azVolumeGroupSnapshotInstance.Spec.SecretRef != nil {
+		if _, err = azureutils.StoreAzVolumeGroupSnapshotSecrets(ctx, c.kubeClient, azVolumeGroupSnapshotInstance, secrets); err != nil {
+			err = status.Errorf(codes.Internal, "failed to store secrets of AzVolumeGroupSnapshot (%s): %v", azVolumeGroupSnapshotName, err)
+			return err
+		}
+	}
+
+	// if deletion failed requeue deletion
+	updateFunc := func(obj client.Object) error {
+		updateInstance := obj.(*azdiskv1beta2.AzVolumeGroupSnapshot)
+		switch updateInstance.Status.State {
+		case azdiskv1beta2.GroupSnapshotCreating:
+			// if group snapshot is still being created, wait for creation
+			waiter := c.conditionWatcher.NewConditionWaiter(ctx, watcher.AzVolumeGroupSnapshotType, azVolumeGroupSnapshotName, waitForCreateGroupSnapshotFunc)
+			obj, err := waiter.Wait(ctx)
+			// close cannot be called on defer because this will interfere wait for delete
+			waiter.Close()
+			if err != nil {
+				return err
+			}
+			azVolumeGroupSnapshotInstance = obj.(*azdiskv1beta2.AzVolumeGroupSnapshot)
+		case azdiskv1beta2.GroupSnapshotDeleting:
+			// if group snapshot is still being deleted, don't update
+			return nil
+		case azdiskv1beta2.GroupSnapshotDeletionFailed:
+			// remove deletion failure error from AzVolumeGroupSnapshot CRI and revert group snapshot deletion state to retrigger deletion
+			updateInstance.Status.Error = nil
+			updateInstance.Status.State = azdiskv1beta2.GroupSnapshotCreated
+		}
+		w.AnnotateObject(updateInstance)
+		return nil
+	}
+
+	var updateObj client.Object
+	// update AzVolumeGroupSnapshot CRI with request annotations and reset state with retry upon conflict
+	if updateObj, err = azureutils.UpdateCRIWithRetry(ctx, c.azCachedReader.azInformer, nil, c.azDiskClient, azVolumeGroupSnapshotInstance, updateFunc, consts.NormalUpdateMaxNetRetry, azureutils.UpdateAll); err != nil {
+		return err
+	}
+
+	// if the driver is uninstalling, the delete request is rejected.
+	if c.IsDriverUninstall() {
+		err = fmt.Errorf("GroupSnapshotDeleteRequestError")
+		w.Logger().Error(err, "AzVolumeGroupSnapshot deletion is being requested as the driver is uninstalling.")
+		return err
+	}
+
+	waiter := c.conditionWatcher.NewConditionWaiter(ctx, watcher.AzVolumeGroupSnapshotType, azVolumeGroupSnapshotName, waitForDeleteGroupSnapshotFunc)
+	defer waiter.Close()
+
+	// only make delete request if object's deletion timestamp is not set
+	azVolumeGroupSnapshotInstance = updateObj.(*azdiskv1beta2.AzVolumeGroupSnapshot)
+	if azVolumeGroupSnapshotInstance.DeletionTimestamp.IsZero() {
+		err = azVolumeGroupSnapshotClient.Delete(ctx, azVolumeGroupSnapshotName, metav1.DeleteOptions{})
+		if err != nil {
+			if apiErrors.IsNotFound(err) {
+				err = nil
+				return nil
+			}
+
+			err = status.Errorf(codes.Internal, "failed to delete AzVolumeGroupSnapshot CRI: %v", err)
+			return err
+		}
+	}
+
+	_, err = waiter.Wait(ctx)
+	return err
+}
+
 func (c *CrdProvisioner) GetAzVolumeAttachment(ctx context.Context, volumeID string, nodeID string) (*azdiskv1beta2.AzVolumeAttachment, error) {
 	diskName, err := azureutils.GetDiskName(volumeID)
 	if err != nil {
@@ -1023,6 +1693,81 @@ func (c *CrdProvisioner) GetAzVolumeAttachment(ctx context.Context, volumeID str
 	return azVolumeAttachmentInstance, nil
 }
 
+// GetVolume returns the details and condition of the volume as reported by its AzVolume and AzVolumeAttachment CRIs.
+func (c *CrdProvisioner) GetVolume(ctx context.Context, volumeID string) (*azdiskv1beta2.VolumeEntry, error) {
+	diskName, err := azureutils.GetDiskName(volumeID)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "could not get disk name from diskURI(%s) with error(%v)", volumeID, err)
+	}
+	azVolumeName := strings.ToLower(diskName)
+
+	azVolume := &azdiskv1beta2.AzVolume{}
+	if err = c.azCachedReader.Get(ctx, types.NamespacedName{Namespace: c.config.ObjectNamespace, Name: azVolumeName}, azVolume); err != nil {
+		if apiErrors.IsNotFound(err) {
+			return nil, status.Errorf(codes.NotFound, "volume (%s) does not exist", volumeID)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get AzVolume (%s): %v", azVolumeName, err)
+	}
+
+	if azVolume.Status.State == azdiskv1beta2.VolumeDeleted {
+		return nil, status.Errorf(codes.NotFound, "volume (%s) has been deleted", volumeID)
+	}
+
+	details := &azdiskv1beta2.VolumeDetails{
+		VolumeID: volumeID,
+	}
+	if azVolume.Status.Detail != nil {
+		details.VolumeID = azVolume.Status.Detail.VolumeID
+		details.CapacityBytes = azVolume.Status.Detail.CapacityBytes
+		details.VolumeContext = azVolume.Status.Detail.VolumeContext
+		details.ContentSource = azVolume.Status.Detail.ContentSource
+		details.AccessibleTopology = azVolume.Status.Detail.AccessibleTopology
+	}
+
+	condition := &azdiskv1beta2.VolumeCondition{
+		Message: fmt.Sprintf("volume state: %s", azVolume.Status.State),
+	}
+	switch azVolume.Status.State {
+	case azdiskv1beta2.VolumeCreationFailed, azdiskv1beta2.VolumeUpdateFailed:
+		condition.Abnormal = true
+		if azVolume.Status.Error != nil {
+			condition.Message = fmt.Sprintf("volume state: %s, error: %s", azVolume.Status.State, azVolume.Status.Error.Message)
+		}
+	}
+
+	attachments, err := azureutils.GetAzVolumeAttachmentsForVolume(ctx, c.azCachedReader, azVolumeName, azureutils.AllRoles)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeList := []string{}
+	for _, attachment := range attachments {
+		switch attachment.Status.State {
+		case azdiskv1beta2.Attached:
+			if attachment.Spec.RequestedRole == azdiskv1beta2.PrimaryRole {
+				nodeList = append(nodeList, attachment.Spec.NodeName)
+			}
+		case azdiskv1beta2.AttachmentFailed:
+			// a failed attachment is only reported if the volume itself is healthy
+			if !condition.Abnormal {
+				condition.Abnormal = true
+				condition.Message = fmt.Sprintf("attachment to node (%s) failed", attachment.Spec.NodeName)
+				if attachment.Status.Error != nil {
+					condition.Message = fmt.Sprintf("%s: %s", condition.Message, attachment.Status.Error.Message)
+				}
+			}
+		}
+	}
+
+	return &azdiskv1beta2.VolumeEntry{
+		Details: details,
+		Status: &azdiskv1beta2.VolumeStatus{
+			PublishedNodeIds: nodeList,
+			Condition:        condition,
+		},
+	}, nil
+}
+
 func (c *CrdProvisioner) GetDiskClientSet() azdisk.Interface {
 	return c.azDiskClient
 }
@@ -1035,9 +1780,80 @@ func (c *CrdProvisioner) IsDriverUninstall() bool {
 	return atomic.LoadUint32(&c.driverUninstallState) == 1
 }
 
-// Compares the fields in the AzVolumeSpec with the other parameters.
+// updateAzVolumeSecrets stores the secrets of a request for the AzVolume and returns the reference to them.
+// The previously referenced Secret is deleted if the request has no secrets.
+func (c *CrdProvisioner) updateAzVolumeSecrets(ctx context.Context, azVolume *azdiskv1beta2.AzVolume, secrets map[string]string) (*v1.SecretReference, error) {
+	if len(secrets) == 0 {
+		if err := azureutils.DeleteAzVolumeSecrets(ctx, c.kubeClient, azVolume); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to delete secrets of AzVolume (%s): %v", azVolume.Name, err)
+		}
+		return nil, nil
+	}
+
+	secretRef, err := azureutils.StoreAzVolumeSecrets(ctx, c.kubeClient, azVolume, secrets)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to store secrets of AzVolume (%s): %v", azVolume.Name, err)
+	}
+	return secretRef, nil
+}
+
+// updateAzSnapshotSecrets stores the secrets of a request for the AzSnapshot and returns the reference to them.
+// The previously referenced Secret is deleted if the request has no secrets.
+func (c *CrdProvisioner) updateAzSnapshotSecrets(ctx context.Context, azSnapshot *azdiskv1beta2.AzSnapshot, secrets map[string]string) (*v1.SecretReference, error) {
+	if len(secrets) == 0 {
+		if err := azureutils.DeleteAzSnapshotSecrets(ctx, c.kubeClient, azSnapshot); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to delete secrets of AzSnapshot (%s): %v", azSnapshot.Name, err)
+		}
+		return nil, nil
+	}
+
+	secretRef, err := azureutils.StoreAzSnapshotSecrets(ctx, c.kubeClient, azSnapshot, secrets)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to store secrets of AzSnapshot (%s): %v", azSnapshot.Name, err)
+	}
+	return secretRef, nil
+}
+
+// updateAzVolumeGroupSnapshotSecrets stores the secrets of a request for the AzVolumeGroupSnapshot and returns the reference to them.
+// The previously referenced Secret is deleted if the request has no secrets.
+func (c *CrdProvisioner) updateAzVolumeGroupSnapshotSecrets(ctx context.Context, azVolumeGroupSnapshot *azdiskv1beta2.AzVolumeGroupSnapshot, secrets map[string]string) (*v1.SecretReference, error) {
+	if len(secrets) == 0 {
+		if err := azureutils.DeleteAzVolumeGroupSnapshotSecrets(ctx, c.kubeClient, azVolumeGroupSnapshot); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to delete secrets of AzVolumeGroupSnapshot (%s): %v", azVolumeGroupSnapshot.Name, err)
+		}
+		return nil, nil
+	}
+
+	secretRef, err := azureutils.StoreAzVolumeGroupSnapshotSecrets(ctx, c.kubeClient, azVolumeGroupSnapshot, secrets)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to store secrets of AzVolumeGroupSnapshot (%s): %v", azVolumeGroupSnapshot.Name, err)
+	}
+	return secretRef, nil
+}
+
+// isSameVolumeIDSet returns true if both lists contain the same volume IDs regardless of their order and case.
+func isSameVolumeIDSet(volumeIDs, otherVolumeIDs []string) bool {
+	if len(volumeIDs) != len(otherVolumeIDs) {
+		return false
+	}
+	volumeIDSet := make(map[string]int, len(volumeIDs))
+	for _, volumeID := range volumeIDs {
+		volumeIDSet[strings.ToLower(volumeID)]++
+	}
+	for _, volumeID := range otherVolumeIDs {
+		key := strings.ToLower(volumeID)
+		if volumeIDSet[key] == 0 {
+			return false
+		}
+		volumeIDSet[key]--
+	}
+	return true
+}
+
+// Compares the fields in the AzVolumeSpec and the resolved secrets of the AzVolume with the other parameters.
 // Returns true if they are equal, false otherwise.
 func isAzVolumeSpecSameAsRequestParams(defaultAzVolume *azdiskv1beta2.AzVolume,
+	azVolumeSecrets map[string]string,
 	maxMountReplicaCount int,
 	capacityRange *azdiskv1beta2.CapacityRange,
 	parameters map[string]string,
@@ -1048,7 +1864,7 @@ func isAzVolumeSpecSameAsRequestParams(defaultAzVolume *azdiskv1beta2.AzVolume,
 	// For comparison purpose, we want nil and empty map/array as equal.
 	// Thus, modifyng the nil values to empty map/array for desired result.
 	defaultParams := defaultAzVolume.Spec.Parameters
-	defaultSecret := defaultAzVolume.Spec.Secrets
+	defaultSecret := azVolumeSecrets
 	defaultAccReq := defaultAzVolume.Spec.AccessibilityRequirements
 	defaultVolContentSource := defaultAzVolume.Spec.ContentVolumeSource
 	defaultCapRange := defaultAzVolume.Spec.CapacityRange
@@ -1175,7 +1991,7 @@ func (c *CrdProvisioner) getReplicasToDetach(ctx context.Context, volumeName, no
 	}
 
 	var maxDiskCount int
-	if maxDiskCount, err = azureutils.GetNodeMaxDiskCount(ctx, c.azCachedReader, nodeID); err != nil {
+	if maxDiskCount, err = azureutils.GetNodeMaxDiskCount(ctx, c.azCachedReader, c.config.ObjectNamespace, nodeID); err != nil {
 		// continue if k8s node object is not found, we have already verified the node's existence through azdrivernode.
 		if !apiErrors.IsNotFound(err) {
 			return
@@ -1245,6 +2061,77 @@ func waitForExpandVolumeFunc(newCapacity int64) func(interface{}, bool) (bool, e
 	}
 }
 
+func waitForModifyVolumeFunc(parameters map[string]string) func(interface{}, bool) (bool, error) {
+	return func(obj interface{}, objectDeleted bool) (bool, error) {
+		if obj == nil || objectDeleted {
+			return false, nil
+		}
+		azVolumeInstance := obj.(*azdiskv1beta2.AzVolume)
+		// Checking that the status is updated with the modified parameters
+		if azVolumeInstance.Status.State == azdiskv1beta2.VolumeUpdated && azVolumeInstance.Status.Detail != nil && len(azureutils.GetModifiedDiskParameters(parameters, azVolumeInstance.Status.Detail.VolumeContext)) == 0 {
+			return true, nil
+		}
+		if azVolumeInstance.Status.State == azdiskv1beta2.VolumeUpdateFailed && azVolumeInstance.Status.Error != nil {
+			return false, util.ErrorFromAzError(azVolumeInstance.Status.Error)
+		}
+		return false, nil
+	}
+}
+
+func waitForCreateSnapshotFunc(obj interface{}, objectDeleted bool) (bool, error) {
+	if obj == nil || objectDeleted {
+		return false, nil
+	}
+	azSnapshotInstance := obj.(*azdiskv1beta2.AzSnapshot)
+	if azSnapshotInstance.Status.Detail != nil {
+		return true, nil
+	} else if azSnapshotInstance.Status.Error != nil {
+		return false, util.ErrorFromAzError(azSnapshotInstance.Status.Error)
+	}
+	return false, nil
+}
+
+func waitForDeleteSnapshotFunc(obj interface{}, objectDeleted bool) (bool, error) {
+	// if no object is found, object is deleted
+	if obj == nil || objectDeleted {
+		return true, nil
+	}
+
+	// otherwise, the snapshot deletion has either failed with error or pending
+	azSnapshotInstance := obj.(*azdiskv1beta2.AzSnapshot)
+	if azSnapshotInstance.Status.Error != nil {
+		return false, util.ErrorFromAzError(azSnapshotInstance.Status.Error)
+	}
+	return false, nil
+}
+
+func waitForCreateGroupSnapshotFunc(obj interface{}, objectDeleted bool) (bool, error) {
+	if obj == nil || objectDeleted {
+		return false, nil
+	}
+	azVolumeGroupSnapshotInstance := obj.(*azdiskv1beta2.AzVolumeGroupSnapshot)
+	if azVolumeGroupSnapshotInstance.Status.Detail != nil {
+		return true, nil
+	} else if azVolumeGroupSnapshotInstance.Status.Error != nil {
+		return false, util.ErrorFromAzError(azVolumeGroupSnapshotInstance.Status.Error)
+	}
+	return false, nil
+}
+
+func waitForDeleteGroupSnapshotFunc(obj interface{}, objectDeleted bool) (bool, error) {
+	// if no object is found, object is deleted
+	if obj == nil || objectDeleted {
+		return true, nil
+	}
+
+	// otherwise, the group snapshot deletion has either failed with error or pending
+	azVolumeGroupSnapshotInstance := obj.(*azdiskv1beta2.AzVolumeGroupSnapshot)
+	if azVolumeGroupSnapshotInstance.Status.Error != nil {
+		return false, util.ErrorFromAzError(azVolumeGroupSnapshotInstance.Status.Error)
+	}
+	return false, nil
+}
+
 func waitForLunFunc(obj interface{}, objectDeleted bool) (bool, error) {
 	if obj == nil || objectDeleted {
 		return false, nil
@@ -229,6 +229,7 @@ func TestCrdProvisionerCreateVolume(t *testing.T) {
 		contentSource        *azdiskv1beta2.ContentVolumeSource
 		topology             *azdiskv1beta2.TopologyRequirement
 		expectedError        error
+		expectSecretRef      bool
 	}{
 		{
 			description:          "[Success] Create an AzVolume CRI with default parameters",
@@ -263,8 +264,9 @@ func TestCrdProvisionerCreateVolume(t *testing.T) {
 				ContentSource:   azdiskv1beta2.ContentVolumeSourceTypeVolume,
 				ContentSourceID: "content-volume-source",
 			},
-			topology:      &defaultTopology,
-			expectedError: nil,
+			topology:        &defaultTopology,
+			expectedError:   nil,
+			expectSecretRef: true,
 		},
 		{
 			description:          "[Success] Create an AzVolume CRI with invalid volume name length",
@@ -416,6 +418,49 @@ func TestCrdProvisionerCreateVolume(t *testing.T) {
 			topology:      &defaultTopology,
 			expectedError: nil,
 		},
+		{
+			description: "[Success] Move the secrets of an existing AzVolume CRI with a previous creation error to a Secret when they are updated",
+			existingAzVolumes: []azdiskv1beta2.AzVolume{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      testDiskName0,
+						Namespace: provisioner.config.ObjectNamespace,
+					},
+					Spec: azdiskv1beta2.AzVolumeSpec{
+						VolumeName: testDiskName0,
+						VolumeCapability: []azdiskv1beta2.VolumeCapability{
+							{
+								AccessType: azdiskv1beta2.VolumeCapabilityAccessMount,
+								AccessMode: azdiskv1beta2.VolumeCapabilityAccessModeSingleNodeWriter,
+							},
+						},
+						Parameters: map[string]string{"location": "westus2"},
+						Secrets:    map[string]string{"secret": "old"},
+					},
+					Status: azdiskv1beta2.AzVolumeStatus{
+						Error: &azdiskv1beta2.AzError{
+							Message: "Test error message here",
+						},
+						State: azdiskv1beta2.VolumeCreationFailed,
+					},
+				},
+			},
+			volumeName:           testDiskName0,
+			definePrependReactor: true,
+			capacity:             &azdiskv1beta2.CapacityRange{},
+			capabilities: []azdiskv1beta2.VolumeCapability{
+				{
+					AccessType: azdiskv1beta2.VolumeCapabilityAccessMount,
+					AccessMode: azdiskv1beta2.VolumeCapabilityAccessModeSingleNodeWriter,
+				},
+			},
+			parameters:      map[string]string{"location": "westus2"},
+			secrets:         map[string]string{"secret": "new"},
+			contentSource:   &azdiskv1beta2.ContentVolumeSource{},
+			topology:        &azdiskv1beta2.TopologyRequirement{},
+			expectedError:   nil,
+			expectSecretRef: true,
+		},
 		{
 			description: "[Failure] Return AlreadyExists error when an AzVolume CRI exists with same volume name but different CreateVolume request parameters",
 			existingAzVolumes: []azdiskv1beta2.AzVolume{
@@ -536,6 +581,17 @@ func TestCrdProvisionerCreateVolume(t *testing.T) {
 			if outputErr == nil {
 				assert.NotNil(t, output)
 			}
+
+			if tt.expectSecretRef {
+				azVolume, err := provisioner.azDiskClient.DiskV1beta2().AzVolumes(provisioner.config.ObjectNamespace).Get(context.TODO(), tt.volumeName, metav1.GetOptions{})
+				require.NoError(t, err)
+				assert.Empty(t, azVolume.Spec.Secrets)
+				require.NotNil(t, azVolume.Spec.SecretRef)
+
+				secrets, err := azureutils.GetAzVolumeSecrets(context.TODO(), provisioner.kubeClient, azVolume)
+				require.NoError(t, err)
+				assert.Equal(t, tt.secrets, secrets)
+			}
 		})
 	}
 }
@@ -1754,6 +1810,235 @@ func TestCrdProvisionerExpandVolume(t *testing.T) {
 	}
 }
 
+func TestCrdProvisionerModifyVolume(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	provisioner := NewTestCrdProvisioner(mockCtrl)
+
+	newAzVolume := func(volumeContext map[string]string) azdiskv1beta2.AzVolume {
+		return azdiskv1beta2.AzVolume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      testDiskName0,
+				Namespace: provisioner.config.ObjectNamespace,
+			},
+			Spec: azdiskv1beta2.AzVolumeSpec{
+				VolumeName: testDiskName0,
+				Parameters: volumeContext,
+			},
+			Status: azdiskv1beta2.AzVolumeStatus{
+				Detail: &azdiskv1beta2.AzVolumeStatusDetail{
+					VolumeID:      testDiskURI0,
+					VolumeContext: volumeContext,
+				},
+				State: azdiskv1beta2.VolumeCreated,
+			},
+		}
+	}
+
+	tests := []struct {
+		description          string
+		existingAzVolumes    []azdiskv1beta2.AzVolume
+		diskURI              string
+		parameters           map[string]string
+		definePrependReactor bool
+		expectedError        error
+	}{
+		{
+			description:          "[Success] Update the parameters of an existing AzVolume CRI with the given diskURI",
+			existingAzVolumes:    []azdiskv1beta2.AzVolume{newAzVolume(map[string]string{consts.SkuNameField: "Premium_LRS"})},
+			diskURI:              testDiskURI0,
+			parameters:           map[string]string{"skuName": "PremiumV2_LRS", "DiskIOPSReadWrite": "5000"},
+			definePrependReactor: true,
+			expectedError:        nil,
+		},
+		{
+			description:          "[Success] Return without waiting when the AzVolume CRI already matches the parameters",
+			existingAzVolumes:    []azdiskv1beta2.AzVolume{newAzVolume(map[string]string{consts.SkuNameField: "Premium_LRS"})},
+			diskURI:              testDiskURI0,
+			parameters:           map[string]string{"skuName": "Premium_LRS"},
+			definePrependReactor: false,
+			expectedError:        nil,
+		},
+		{
+			description:          "[Failure] Return an error when a parameter cannot be modified",
+			existingAzVolumes:    []azdiskv1beta2.AzVolume{newAzVolume(nil)},
+			diskURI:              testDiskURI0,
+			parameters:           map[string]string{consts.CachingModeField: "None"},
+			definePrependReactor: false,
+			expectedError:        status.Errorf(codes.InvalidArgument, "parameter %s cannot be modified", consts.CachingModeField),
+		},
+		{
+			description:          "[Failure] Return an error when the AzVolume CRI with the given diskURI doesn't exist",
+			existingAzVolumes:    nil,
+			diskURI:              testDiskURI0,
+			parameters:           map[string]string{consts.SkuNameField: "PremiumV2_LRS"},
+			definePrependReactor: false,
+			expectedError:        status.Error(codes.Internal, fmt.Sprintf("failed to retrieve volume id (%s): azvolume.disk.csi.azure.com \"%s\" not found", testDiskURI0, testDiskName0)),
+		},
+	}
+
+	for _, test := range tests {
+		tt := test
+		t.Run(test.description, func(t *testing.T) {
+			existingWatcher := provisioner.conditionWatcher
+			existingClient := provisioner.azDiskClient
+			existingAzCacheReader := provisioner.azCachedReader
+			defer func() { provisioner.azCachedReader = existingAzCacheReader }()
+			defer func() { provisioner.conditionWatcher = existingWatcher }()
+			defer func() { provisioner.azDiskClient = existingClient }()
+
+			if tt.existingAzVolumes != nil {
+				existingList := make([]runtime.Object, len(tt.existingAzVolumes))
+				for itr, azVol := range tt.existingAzVolumes {
+					azVol := azVol
+					existingList[itr] = &azVol
+				}
+				provisioner.azDiskClient = azdiskfakes.NewSimpleClientset(existingList...)
+			}
+
+			UpdateTestCrdProvisionerWithNewClient(provisioner, provisioner.azDiskClient, provisioner.kubeClient, provisioner.crdClient)
+
+			if tt.definePrependReactor {
+				// Using the tracker to update the existing object as if the controller modified the disk
+				tracker := provisioner.azDiskClient.(*azdiskfakes.Clientset).Tracker()
+
+				provisioner.azDiskClient.(*azdiskfakes.Clientset).Fake.PrependReactor(
+					"update",
+					"azvolumes",
+					func(action testingClient.Action) (bool, runtime.Object, error) {
+						objPresent := action.(testingClient.UpdateAction).GetObject().(*azdiskv1beta2.AzVolume)
+						objPresent.Status.Detail.VolumeContext = objPresent.Spec.Parameters
+						objPresent.Status.State = azdiskv1beta2.VolumeUpdated
+
+						err := tracker.Update(action.GetResource(), objPresent, action.GetNamespace())
+						if err != nil {
+							return true, nil, err
+						}
+
+						return true, objPresent, nil
+					})
+			}
+
+			outputErr := provisioner.ModifyVolume(
+				context.TODO(),
+				tt.diskURI,
+				tt.parameters)
+
+			assert.Equal(t, tt.expectedError, outputErr)
+		})
+	}
+}
+
+func TestCrdProvisionerGetVolume(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	provisioner := NewTestCrdProvisioner(mockCtrl)
+
+	createAttachment := func(nodeName string, role azdiskv1beta2.Role, state azdiskv1beta2.AzVolumeAttachmentAttachmentState) *azdiskv1beta2.AzVolumeAttachment {
+		return &azdiskv1beta2.AzVolumeAttachment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      azureutils.GetAzVolumeAttachmentName(testDiskName0, nodeName),
+				Namespace: testNamespace,
+				Labels: map[string]string{
+					consts.NodeNameLabel:   nodeName,
+					consts.VolumeNameLabel: testDiskName0,
+					consts.RoleLabel:       string(role),
+				},
+			},
+			Spec: azdiskv1beta2.AzVolumeAttachmentSpec{
+				VolumeName:    testDiskName0,
+				VolumeID:      testDiskURI0,
+				NodeName:      nodeName,
+				RequestedRole: role,
+			},
+			Status: azdiskv1beta2.AzVolumeAttachmentStatus{
+				State: state,
+			},
+		}
+	}
+
+	createdAzVolume := createAzVolume(testNamespace, testDiskName0, 1)
+	createdAzVolume.Status = azdiskv1beta2.AzVolumeStatus{
+		Detail: &azdiskv1beta2.AzVolumeStatusDetail{
+			VolumeID:      testDiskURI0,
+			CapacityBytes: 10,
+		},
+		State: azdiskv1beta2.VolumeCreated,
+	}
+
+	failedAzVolume := createAzVolume(testNamespace, testDiskName0, 1)
+	failedAzVolume.Status = azdiskv1beta2.AzVolumeStatus{
+		State: azdiskv1beta2.VolumeCreationFailed,
+		Error: &azdiskv1beta2.AzError{Message: "test error"},
+	}
+
+	tests := []struct {
+		description      string
+		existingObjects  []runtime.Object
+		diskURI          string
+		expectedErrCode  codes.Code
+		expectedNodes    []string
+		expectedAbnormal bool
+	}{
+		{
+			description:     "[Failure] Return NotFound when the AzVolume CRI with the given diskURI doesn't exist",
+			diskURI:         testDiskURI0,
+			expectedErrCode: codes.NotFound,
+		},
+		{
+			description: "[Success] Return published nodes of attached primary AzVolumeAttachments",
+			existingObjects: []runtime.Object{
+				createdAzVolume,
+				createAttachment(testNodeName0, azdiskv1beta2.PrimaryRole, azdiskv1beta2.Attached),
+				createAttachment(testNodeName1, azdiskv1beta2.ReplicaRole, azdiskv1beta2.Attached),
+			},
+			diskURI:       testDiskURI0,
+			expectedNodes: []string{testNodeName0},
+		},
+		{
+			description: "[Success] Return an abnormal condition when an AzVolumeAttachment failed",
+			existingObjects: []runtime.Object{
+				createdAzVolume,
+				createAttachment(testNodeName0, azdiskv1beta2.PrimaryRole, azdiskv1beta2.AttachmentFailed),
+			},
+			diskURI:          testDiskURI0,
+			expectedNodes:    []string{},
+			expectedAbnormal: true,
+		},
+		{
+			description:      "[Success] Return an abnormal condition when the AzVolume creation failed",
+			existingObjects:  []runtime.Object{failedAzVolume},
+			diskURI:          testDiskURI0,
+			expectedNodes:    []string{},
+			expectedAbnormal: true,
+		},
+	}
+
+	for _, test := range tests {
+		tt := test
+		t.Run(test.description, func(t *testing.T) {
+			existingWatcher := provisioner.conditionWatcher
+			existingClient := provisioner.azDiskClient
+			existingAzCacheReader := provisioner.azCachedReader
+			defer func() { provisioner.azCachedReader = existingAzCacheReader }()
+			defer func() { provisioner.conditionWatcher = existingWatcher }()
+			defer func() { provisioner.azDiskClient = existingClient }()
+
+			provisioner.azDiskClient = azdiskfakes.NewSimpleClientset(tt.existingObjects...)
+			UpdateTestCrdProvisionerWithNewClient(provisioner, provisioner.azDiskClient, provisioner.kubeClient, provisioner.crdClient)
+
+			output, outputErr := provisioner.GetVolume(context.TODO(), tt.diskURI)
+
+			assert.Equal(t, tt.expectedErrCode, status.Code(outputErr))
+			if outputErr == nil {
+				assert.Equal(t, tt.diskURI, output.Details.VolumeID)
+				assert.Equal(t, tt.expectedNodes, output.Status.PublishedNodeIds)
+				assert.Equal(t, tt.expectedAbnormal, output.Status.Condition.Abnormal)
+			}
+		})
+	}
+}
+
 func TestIsAzVolumeSpecSameAsRequestParams(t *testing.T) {
 	tests := []struct {
 		description          string
@@ -1917,6 +2202,7 @@ func TestIsAzVolumeSpecSameAsRequestParams(t *testing.T) {
 		t.Run(test.description, func(t *testing.T) {
 			output := isAzVolumeSpecSameAsRequestParams(
 				&tt.azVolume,
+				tt.azVolume.Spec.Secrets,
 				tt.maxMountReplicaCount,
 				tt.capacityRange,
 				tt.parameters,
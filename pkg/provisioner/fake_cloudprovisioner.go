@@ -20,6 +20,9 @@ import (
 	"time"
 
 	"github.com/golang/mock/gomock"
+	azdiskv1beta2 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils/fakecompute"
 	azcache "sigs.k8s.io/cloud-provider-azure/pkg/cache"
 	"sigs.k8s.io/cloud-provider-azure/pkg/provider"
 )
@@ -38,19 +41,28 @@ func NewFakeCloudProvisioner(ctrl *gomock.Controller) (*FakeCloudProvisioner, er
 		return nil, err
 	}
 
-	return &FakeCloudProvisioner{
-		CloudProvisioner: CloudProvisioner{cloud: fakeCloud, getDiskThrottlingCache: cache},
-	}, nil
-}
+	fake := &FakeCloudProvisioner{
+		CloudProvisioner: CloudProvisioner{cloud: fakeCloud, getDiskThrottlingCache: cache, attachDetachLimiter: newAttachDetachLimiter(azdiskv1beta2.CloudConfiguration{})},
+	}
+
+	fake.diskUsageCache, err = azureutils.NewDiskUsageCache(time.Minute, func() azureutils.UsageClient {
+		return fake.usageClient
+	})
+	if err != nil {
+		return nil, err
+	}
 
-func (fake *FakeCloudProvisioner) GetCloud() *provider.Cloud {
-	return fake.cloud
+	return fake, nil
 }
 
 func (fake *FakeCloudProvisioner) SetCloud(cloud *provider.Cloud) {
 	fake.cloud = cloud
 }
 
+func (fake *FakeCloudProvisioner) SetUsageClient(usageClient azureutils.UsageClient) {
+	fake.usageClient = usageClient
+}
+
 func (fake *FakeCloudProvisioner) GetPerfOptimizationEnabled() bool {
 	return fake.perfOptimizationEnabled
 }
@@ -58,3 +70,14 @@ func (fake *FakeCloudProvisioner) GetPerfOptimizationEnabled() bool {
 func (fake *FakeCloudProvisioner) SetPerfOptimizationEnabled(enabled bool) {
 	fake.perfOptimizationEnabled = enabled
 }
+
+// NewFakeCloudProvisionerWithBackend returns a fake cloud provisioner whose compute clients are served by the fake compute backend.
+func NewFakeCloudProvisionerWithBackend(ctrl *gomock.Controller, backend *fakecompute.Backend) (*FakeCloudProvisioner, error) {
+	fake, err := NewFakeCloudProvisioner(ctrl)
+	if err != nil {
+		return nil, err
+	}
+
+	backend.Install(fake.cloud)
+	return fake, nil
+}
@@ -0,0 +1,285 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	azdiskv1beta2 "sigs.k8s.io/azuredisk-csi-driver/pkg/apis/azuredisk/v1beta2"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils/fakecompute"
+	"sigs.k8s.io/cloud-provider-azure/pkg/provider"
+)
+
+const (
+	// a Standard_D2s_v3 VM supports at most 4 data disks
+	fakeComputeVMSize         = "Standard_D2s_v3"
+	fakeComputeMaxDataDisks   = 4
+	fakeComputeScaleSetName   = "test-vmss"
+	fakeComputeScaleSetVMName = "test-vmss000000"
+)
+
+func NewTestFakeComputeCloudProvisioner(t *testing.T, mockCtl *gomock.Controller) (*FakeCloudProvisioner, *fakecompute.Backend) {
+	backend := fakecompute.NewBackend(testSubscription, "westus")
+	fake, err := NewFakeCloudProvisionerWithBackend(mockCtl, backend)
+	require.NoError(t, err)
+	fake.GetCloud().ResourceGroup = testResourceGroup
+	return fake, backend
+}
+
+func createFakeComputeVolumes(t *testing.T, cloudProvisioner *FakeCloudProvisioner, count int) []string {
+	volumeIDs := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		volume, err := cloudProvisioner.CreateVolume(context.TODO(), fmt.Sprintf("fake-compute-disk-%d", i), &azdiskv1beta2.CapacityRange{RequiredBytes: 10 << 30}, nil, map[string]string{}, nil, nil, nil)
+		require.NoError(t, err)
+		volumeIDs = append(volumeIDs, volume.VolumeID)
+	}
+	return volumeIDs
+}
+
+// publishFakeComputeVolumes attaches the volumes to the node concurrently and verifies that the cloud provider attaches them in a single VM update.
+func publishFakeComputeVolumes(t *testing.T, cloudProvisioner *FakeCloudProvisioner, backend *fakecompute.Backend, nodeID string, volumeIDs []string) map[string]string {
+	updateCount := backend.GetUpdateCount(nodeID)
+	attachResults := make([]CloudAttachResult, len(volumeIDs))
+	var wg sync.WaitGroup
+	for i, volumeID := range volumeIDs {
+		wg.Add(1)
+		go func(i int, volumeID string) {
+			defer wg.Done()
+			attachResults[i] = cloudProvisioner.PublishVolume(context.TODO(), volumeID, nodeID, map[string]string{})
+		}(i, volumeID)
+	}
+	wg.Wait()
+
+	luns := map[string]string{}
+	for i, attachResult := range attachResults {
+		require.NoError(t, <-attachResult.ResultChannel())
+		luns[volumeIDs[i]] = attachResult.PublishContext()["LUN"]
+	}
+	require.Equal(t, updateCount+1, backend.GetUpdateCount(nodeID))
+	return luns
+}
+
+// unpublishFakeComputeVolumes detaches the volumes from the node concurrently and verifies that the cloud provider detaches them in a single VM update.
+func unpublishFakeComputeVolumes(t *testing.T, cloudProvisioner *FakeCloudProvisioner, backend *fakecompute.Backend, nodeID string, volumeIDs []string) {
+	updateCount := backend.GetUpdateCount(nodeID)
+	detachErrs := make([]error, len(volumeIDs))
+	var wg sync.WaitGroup
+	for i, volumeID := range volumeIDs {
+		wg.Add(1)
+		go func(i int, volumeID string) {
+			defer wg.Done()
+			detachErrs[i] = cloudProvisioner.UnpublishVolume(context.TODO(), volumeID, nodeID)
+		}(i, volumeID)
+	}
+	wg.Wait()
+
+	for _, err := range detachErrs {
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, updateCount+1, backend.GetUpdateCount(nodeID))
+}
+
+func TestFakeComputeCloudProvisioner(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	cloudProvisioner, backend := NewTestFakeComputeCloudProvisioner(t, mockCtl)
+	backend.AddVirtualMachine(testResourceGroup, testVMName, fakeComputeVMSize)
+	backend.SetOperationLatency(10 * time.Millisecond)
+
+	volumeIDs := createFakeComputeVolumes(t, cloudProvisioner, fakeComputeMaxDataDisks+1)
+
+	// the disks attached concurrently get distinct LUNs
+	luns := publishFakeComputeVolumes(t, cloudProvisioner, backend, testVMName, volumeIDs[:fakeComputeMaxDataDisks])
+	lunSet := map[string]bool{}
+	for _, lun := range luns {
+		lunSet[lun] = true
+	}
+	assert.Len(t, lunSet, fakeComputeMaxDataDisks)
+
+	dataDisks, err := cloudProvisioner.GetNodeDataDisks(context.TODO(), testVMName)
+	require.NoError(t, err)
+	assert.Len(t, dataDisks, fakeComputeMaxDataDisks)
+
+	// the VM cannot take more than its maximum number of data disks
+	attachResult := cloudProvisioner.PublishVolume(context.TODO(), volumeIDs[fakeComputeMaxDataDisks], testVMName, map[string]string{})
+	assert.Error(t, <-attachResult.ResultChannel())
+
+	// an attached disk cannot be deleted
+	assert.Error(t, cloudProvisioner.DeleteVolume(context.TODO(), volumeIDs[0], nil))
+
+	snapshot, err := cloudProvisioner.CreateSnapshot(context.TODO(), volumeIDs[0], "fake-compute-snapshot", nil, nil)
+	require.NoError(t, err)
+	assert.True(t, snapshot.ReadyToUse)
+	assert.Equal(t, int64(10<<30), snapshot.SizeBytes)
+
+	clone, err := cloudProvisioner.CreateVolume(context.TODO(), "fake-compute-clone", &azdiskv1beta2.CapacityRange{RequiredBytes: 10 << 30}, nil, map[string]string{}, nil,
+		&azdiskv1beta2.ContentVolumeSource{ContentSource: azdiskv1beta2.ContentVolumeSourceTypeSnapshot, ContentSourceID: snapshot.SnapshotID}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10<<30), clone.CapacityBytes)
+
+	unpublishFakeComputeVolumes(t, cloudProvisioner, backend, testVMName, volumeIDs[:fakeComputeMaxDataDisks])
+	dataDisks, ok := backend.GetDataDisks(testVMName)
+	require.True(t, ok)
+	assert.Empty(t, dataDisks)
+
+	require.NoError(t, cloudProvisioner.DeleteSnapshot(context.TODO(), snapshot.SnapshotID, nil))
+	for _, volumeID := range append(volumeIDs, clone.VolumeID) {
+		require.NoError(t, cloudProvisioner.DeleteVolume(context.TODO(), volumeID, nil))
+		_, ok := backend.GetDisk(volumeID)
+		assert.False(t, ok)
+	}
+}
+
+func TestFakeComputeCloudProvisionerThrottling(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	cloudProvisioner, backend := NewTestFakeComputeCloudProvisioner(t, mockCtl)
+	backend.AddVirtualMachine(testResourceGroup, testVMName, fakeComputeVMSize)
+
+	volumeIDs := createFakeComputeVolumes(t, cloudProvisioner, 1)
+
+	backend.ThrottleFor(time.Minute)
+	attachResult := cloudProvisioner.PublishVolume(context.TODO(), volumeIDs[0], testVMName, map[string]string{})
+	assert.Error(t, <-attachResult.ResultChannel())
+
+	backend.ThrottleFor(0)
+	attachResult = cloudProvisioner.PublishVolume(context.TODO(), volumeIDs[0], testVMName, map[string]string{})
+	assert.NoError(t, <-attachResult.ResultChannel())
+}
+
+func TestFakeComputeCloudProvisionerScaleSet(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	cloudProvisioner, backend := NewTestFakeComputeCloudProvisioner(t, mockCtl)
+	backend.AddVirtualMachineScaleSet(testResourceGroup, fakeComputeScaleSetName, fakeComputeVMSize, 2)
+
+	scaleSet, err := provider.NewTestScaleSet(mockCtl)
+	require.NoError(t, err)
+	scaleSet.Cloud.ResourceGroup = testResourceGroup
+	scaleSet.Cloud.VMSet = scaleSet
+	backend.Install(scaleSet.Cloud)
+	cloudProvisioner.SetCloud(scaleSet.Cloud)
+
+	volumeIDs := createFakeComputeVolumes(t, cloudProvisioner, 2)
+	luns := publishFakeComputeVolumes(t, cloudProvisioner, backend, fakeComputeScaleSetVMName, volumeIDs)
+	assert.Len(t, luns, 2)
+
+	dataDisks, ok := backend.GetDataDisks(fakeComputeScaleSetVMName)
+	require.True(t, ok)
+	assert.Len(t, dataDisks, 2)
+
+	unpublishFakeComputeVolumes(t, cloudProvisioner, backend, fakeComputeScaleSetVMName, volumeIDs)
+	dataDisks, _ = backend.GetDataDisks(fakeComputeScaleSetVMName)
+	assert.Empty(t, dataDisks)
+}
+
+func TestFakeComputeCloudProvisionerCrossRegionSnapshot(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	cloudProvisioner, backend := NewTestFakeComputeCloudProvisioner(t, mockCtl)
+
+	volumeIDs := createFakeComputeVolumes(t, cloudProvisioner, 1)
+	capacityRange := &azdiskv1beta2.CapacityRange{RequiredBytes: 10 << 30}
+
+	// only incremental snapshots can be copied to another region
+	_, err := cloudProvisioner.CreateSnapshot(context.TODO(), volumeIDs[0], "fake-compute-full-snapshot", nil, map[string]string{"targetLocation": "eastus", "incremental": "false"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	snapshot, err := cloudProvisioner.CreateSnapshot(context.TODO(), volumeIDs[0], "fake-compute-snapshot", nil, map[string]string{"targetLocation": "eastus"})
+	require.NoError(t, err)
+	assert.False(t, snapshot.ReadyToUse)
+	contentSource := &azdiskv1beta2.ContentVolumeSource{ContentSource: azdiskv1beta2.ContentVolumeSourceTypeSnapshot, ContentSourceID: snapshot.SnapshotID}
+
+	// the snapshot cannot be restored until the copy completes
+	_, err = cloudProvisioner.CreateVolume(context.TODO(), "fake-compute-restore", capacityRange, nil, map[string]string{"location": "eastus"}, nil, contentSource, nil)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	require.True(t, backend.SetSnapshotCopyCompletionPercent(snapshot.SnapshotID, 100))
+	snapshots, err := cloudProvisioner.ListSnapshots(context.TODO(), 0, "", "", snapshot.SnapshotID, nil)
+	require.NoError(t, err)
+	require.Len(t, snapshots.Entries, 1)
+	assert.True(t, snapshots.Entries[0].ReadyToUse)
+
+	// the copy can only be restored in the region it was copied to
+	_, err = cloudProvisioner.CreateVolume(context.TODO(), "fake-compute-restore", capacityRange, nil, map[string]string{}, nil, contentSource, nil)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	restored, err := cloudProvisioner.CreateVolume(context.TODO(), "fake-compute-restore", capacityRange, nil, map[string]string{"location": "eastus"}, nil, contentSource, nil)
+	require.NoError(t, err)
+	disk, ok := backend.GetDisk(restored.VolumeID)
+	require.True(t, ok)
+	assert.Equal(t, "eastus", *disk.Location)
+
+	// the snapshot taken in the region of the source disk is deleted with its copy
+	sourceSnapshotName := azureutils.GetCrossRegionSourceSnapshotName("fake-compute-snapshot", "westus")
+	_, rerr := cloudProvisioner.GetCloud().SnapshotsClient.Get(context.TODO(), testSubscription, testResourceGroup, sourceSnapshotName)
+	require.Nil(t, rerr)
+	require.NoError(t, cloudProvisioner.DeleteSnapshot(context.TODO(), snapshot.SnapshotID, nil))
+	_, rerr = cloudProvisioner.GetCloud().SnapshotsClient.Get(context.TODO(), testSubscription, testResourceGroup, sourceSnapshotName)
+	assert.NotNil(t, rerr)
+}
+
+func TestFakeComputeCloudProvisionerGroupSnapshot(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	cloudProvisioner, _ := NewTestFakeComputeCloudProvisioner(t, mockCtl)
+
+	volumeIDs := createFakeComputeVolumes(t, cloudProvisioner, 2)
+
+	groupSnapshot, err := cloudProvisioner.CreateGroupSnapshot(context.TODO(), "fake-compute-group-snapshot", volumeIDs, nil, map[string]string{"tags": "key=value"})
+	require.NoError(t, err)
+	assert.True(t, groupSnapshot.ReadyToUse)
+	require.Len(t, groupSnapshot.Snapshots, 2)
+	for i, snapshot := range groupSnapshot.Snapshots {
+		assert.Equal(t, volumeIDs[i], snapshot.SourceVolumeID)
+	}
+
+	got, err := cloudProvisioner.GetGroupSnapshot(context.TODO(), groupSnapshot.GroupSnapshotID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, groupSnapshot.GroupSnapshotID, got.GroupSnapshotID)
+	require.Len(t, got.Snapshots, 2)
+	for i, snapshot := range got.Snapshots {
+		assert.Equal(t, groupSnapshot.Snapshots[i].SnapshotID, snapshot.SnapshotID)
+		assert.Equal(t, volumeIDs[i], snapshot.SourceVolumeID)
+	}
+
+	// the snapshots already taken are deleted if any volume of the group cannot be snapshotted
+	missingVolumeID := fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Compute/disks/missing-disk", testSubscription, testResourceGroup)
+	_, err = cloudProvisioner.CreateGroupSnapshot(context.TODO(), "fake-compute-failed-group-snapshot", append(volumeIDs, missingVolumeID), nil, nil)
+	assert.Error(t, err)
+	_, err = cloudProvisioner.GetGroupSnapshot(context.TODO(), azureutils.GetGroupSnapshotID(testSubscription, testResourceGroup, "fake-compute-failed-group-snapshot"), nil)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = cloudProvisioner.CreateGroupSnapshot(context.TODO(), "fake-compute-duplicate-group-snapshot", []string{volumeIDs[0], volumeIDs[0]}, nil, nil)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	require.NoError(t, cloudProvisioner.DeleteGroupSnapshot(context.TODO(), groupSnapshot.GroupSnapshotID, nil))
+	_, err = cloudProvisioner.GetGroupSnapshot(context.TODO(), groupSnapshot.GroupSnapshotID, nil)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	// deleting a group snapshot is idempotent
+	assert.NoError(t, cloudProvisioner.DeleteGroupSnapshot(context.TODO(), groupSnapshot.GroupSnapshotID, nil))
+}
@@ -84,6 +84,40 @@ func (c *FakeCrdProvisioner) ExpandVolume(
 	return c.fakeCloudProv.ExpandVolume(ctx, volumeID, capacityRange, secrets)
 }
 
+func (c *FakeCrdProvisioner) GetVolume(ctx context.Context, volumeID string) (*azdiskv1beta2.VolumeEntry, error) {
+	return c.fakeCloudProv.GetVolume(ctx, volumeID)
+}
+
+func (c *FakeCrdProvisioner) CreateSnapshot(
+	ctx context.Context,
+	sourceVolumeID string,
+	snapshotName string,
+	secrets map[string]string,
+	parameters map[string]string) (*azdiskv1beta2.Snapshot, error) {
+	return c.fakeCloudProv.CreateSnapshot(ctx, sourceVolumeID, snapshotName, secrets, parameters)
+}
+
+func (c *FakeCrdProvisioner) DeleteSnapshot(ctx context.Context, snapshotID string, secrets map[string]string) error {
+	return c.fakeCloudProv.DeleteSnapshot(ctx, snapshotID, secrets)
+}
+
+func (c *FakeCrdProvisioner) CreateGroupSnapshot(
+	ctx context.Context,
+	groupSnapshotName string,
+	sourceVolumeIDs []string,
+	secrets map[string]string,
+	parameters map[string]string) (*azdiskv1beta2.GroupSnapshot, error) {
+	return c.fakeCloudProv.CreateGroupSnapshot(ctx, groupSnapshotName, sourceVolumeIDs, secrets, parameters)
+}
+
+func (c *FakeCrdProvisioner) GetGroupSnapshot(ctx context.Context, groupSnapshotID string, secrets map[string]string) (*azdiskv1beta2.GroupSnapshot, error) {
+	return c.fakeCloudProv.GetGroupSnapshot(ctx, groupSnapshotID, secrets)
+}
+
+func (c *FakeCrdProvisioner) DeleteGroupSnapshot(ctx context.Context, groupSnapshotID string, secrets map[string]string) error {
+	return c.fakeCloudProv.DeleteGroupSnapshot(ctx, groupSnapshotID, secrets)
+}
+
 func (c *FakeCrdProvisioner) WaitForAttach(ctx context.Context, volumeID, nodeID string) (*azdiskv1beta2.AzVolumeAttachment, error) {
 	return &azdiskv1beta2.AzVolumeAttachment{}, nil
 }
@@ -94,3 +94,17 @@ func (fake *FakeNodeProvisioner) SetMountCheckError(target string, err error) {
 func (fake *FakeNodeProvisioner) SetMounter(mounter *mount.SafeFormatAndMount) {
 	fake.mounter = mounter
 }
+
+// FreezeFilesystem pretends to freeze the filesystem mounted at the specified path.
+func (fake *FakeNodeProvisioner) FreezeFilesystem(path string) error {
+	if err, ok := fake.mounter.Interface.(*mounter.FakeSafeMounter).MountCheckErrors[path]; ok {
+		return err
+	}
+
+	return nil
+}
+
+// ThawFilesystem pretends to thaw the filesystem mounted at the specified path.
+func (fake *FakeNodeProvisioner) ThawFilesystem(path string) error {
+	return nil
+}
@@ -185,6 +185,11 @@ func (p *NodeProvisioner) Unmount(target string) error {
 	return p.mounter.Unmount(target)
 }
 
+// GetMountPoints returns the mount points of the node.
+func (p *NodeProvisioner) GetMountPoints() ([]mount.MountPoint, error) {
+	return p.mounter.List()
+}
+
 func isCorruptedMount(target string) bool {
 	_, pathErr := mount.PathExists(target)
 	klog.Errorf("IsCorruptedDir(%s) returned with error: %v", target, pathErr)
@@ -23,6 +23,8 @@ import (
 	"context"
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // NeedsResize returns true if the volume needs to be resized.
@@ -34,3 +36,13 @@ func (p *NodeProvisioner) NeedsResize(devicePath, volumePath string) (bool, erro
 func (p *NodeProvisioner) GetVolumeStats(ctx context.Context, target string) ([]*csi.VolumeUsage, error) {
 	return []*csi.VolumeUsage{}, nil
 }
+
+// FreezeFilesystem suspends access to the filesystem mounted at the specified path.
+func (p *NodeProvisioner) FreezeFilesystem(path string) error {
+	return status.Error(codes.Unimplemented, "filesystem freeze is not supported on darwin")
+}
+
+// ThawFilesystem resumes access to the filesystem mounted at the specified path.
+func (p *NodeProvisioner) ThawFilesystem(path string) error {
+	return status.Error(codes.Unimplemented, "filesystem thaw is not supported on darwin")
+}
@@ -112,3 +112,19 @@ func (p *NodeProvisioner) GetVolumeStats(ctx context.Context, target string) ([]
 		},
 	}, nil
 }
+
+// FreezeFilesystem suspends access to the filesystem mounted at the specified path and flushes it to disk.
+func (p *NodeProvisioner) FreezeFilesystem(path string) error {
+	if output, err := p.mounter.Exec.Command("fsfreeze", "--freeze", path).CombinedOutput(); err != nil {
+		return status.Errorf(codes.Internal, "failed to freeze filesystem at %s: %v, output: %s", path, err, string(output))
+	}
+	return nil
+}
+
+// ThawFilesystem resumes access to the filesystem mounted at the specified path.
+func (p *NodeProvisioner) ThawFilesystem(path string) error {
+	if output, err := p.mounter.Exec.Command("fsfreeze", "--unfreeze", path).CombinedOutput(); err != nil {
+		return status.Errorf(codes.Internal, "failed to thaw filesystem at %s: %v, output: %s", path, err, string(output))
+	}
+	return nil
+}
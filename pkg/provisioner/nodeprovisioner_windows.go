@@ -25,6 +25,7 @@ import (
 	"strconv"
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/azuredisk-csi-driver/pkg/mounter"
@@ -146,6 +147,16 @@ func (p *NodeProvisioner) GetVolumeStats(ctx context.Context, target string) ([]
 	return []*csi.VolumeUsage{}, fmt.Errorf("could not cast to csi proxy class")
 }
 
+// FreezeFilesystem suspends access to the filesystem mounted at the specified path.
+func (p *NodeProvisioner) FreezeFilesystem(path string) error {
+	return status.Error(codes.Unimplemented, "filesystem freeze is not supported on windows")
+}
+
+// ThawFilesystem resumes access to the filesystem mounted at the specified path.
+func (p *NodeProvisioner) ThawFilesystem(path string) error {
+	return status.Error(codes.Unimplemented, "filesystem thaw is not supported on windows")
+}
+
 // readyMountPoint readies the mount point for mount.
 func (p *NodeProvisioner) readyMountPoint(path string) error {
 	return nil
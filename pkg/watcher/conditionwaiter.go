@@ -44,6 +44,10 @@ func (w *ConditionWaiter) Wait(ctx context.Context) (runtime.Object, error) {
 		obj, err = w.watcher.informerFactory.Disk().V1beta2().AzVolumeAttachments().Lister().AzVolumeAttachments(namespace).Get(w.objName)
 	case AzDriverNodeType:
 		obj, err = w.watcher.informerFactory.Disk().V1beta2().AzDriverNodes().Lister().AzDriverNodes(namespace).Get(w.objName)
+	case AzSnapshotType:
+		obj, err = w.watcher.informerFactory.Disk().V1beta2().AzSnapshots().Lister().AzSnapshots(namespace).Get(w.objName)
+	case AzVolumeGroupSnapshotType:
+		obj, err = w.watcher.informerFactory.Disk().V1beta2().AzVolumeGroupSnapshots().Lister().AzVolumeGroupSnapshots(namespace).Get(w.objName)
 	}
 
 	_, wf := workflow.New(ctx, workflow.WithCaller(1), workflow.WithDetails(workflow.GetObjectDetails(obj)...))
@@ -37,9 +37,11 @@ import (
 type ObjectType string
 
 const (
-	AzVolumeAttachmentType ObjectType = "azvolumeattachments"
-	AzVolumeType           ObjectType = "azvolume"
-	AzDriverNodeType       ObjectType = "azdrivernode"
+	AzVolumeAttachmentType    ObjectType = "azvolumeattachments"
+	AzVolumeType              ObjectType = "azvolume"
+	AzDriverNodeType          ObjectType = "azdrivernode"
+	AzSnapshotType            ObjectType = "azsnapshot"
+	AzVolumeGroupSnapshotType ObjectType = "azvolumegroupsnapshot"
 )
 
 type eventType int
@@ -146,6 +148,10 @@ func (c *ConditionWatcher) handleEvent(obj interface{}, eventType eventType) {
 		objType = AzVolumeAttachmentType
 	case *azdiskv1beta2.AzDriverNode:
 		objType = AzDriverNodeType
+	case *azdiskv1beta2.AzSnapshot:
+		objType = AzSnapshotType
+	case *azdiskv1beta2.AzVolumeGroupSnapshot:
+		objType = AzVolumeGroupSnapshotType
 	default:
 		// unknown object type
 		klog.Errorf("unsupported object type %v", reflect.TypeOf(obj))
@@ -77,6 +77,14 @@ func GetObjectDetails(obj k8sRuntime.Object) []interface{} {
 		if target != nil {
 			return []interface{}{consts.VolumeNameLabel, target.Spec.VolumeName, consts.NodeNameLabel, target.Spec.NodeName, consts.RoleLabel, target.Spec.RequestedRole}
 		}
+	case *azdiskv1beta2.AzSnapshot:
+		if target != nil {
+			return []interface{}{consts.SnapshotName, target.Spec.SnapshotName, consts.SourceResourceID, target.Spec.SourceVolumeID}
+		}
+	case *azdiskv1beta2.AzVolumeGroupSnapshot:
+		if target != nil {
+			return []interface{}{consts.GroupSnapshotName, target.Spec.GroupSnapshotName}
+		}
 	}
 	return []interface{}{}
 }
@@ -141,7 +141,7 @@ func setUpProvisioner() error {
 		},
 		ObjectNamespace: namespace,
 	}
-	crdProvisioner, err = provisioner.NewCrdProvisioner(azClient, &config, cw, provisioner.NewCachedReader(kubeInformerFactory, azInformerFactory, namespace), crdInformer)
+	crdProvisioner, err = provisioner.NewCrdProvisioner(azClient, kubeClient, &config, cw, provisioner.NewCachedReader(kubeInformerFactory, azInformerFactory, namespace), crdInformer)
 	if err != nil {
 		return fmt.Errorf("Failed to create CrdProvisioner: %v", err)
 	}